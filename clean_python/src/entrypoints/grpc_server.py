@@ -1,9 +1,13 @@
 import asyncio
+import contextvars
 import logging
+import os
+from pathlib import Path
 import grpc
-from typing import AsyncIterator
+from typing import AsyncIterator, Optional
 
 from src.entrypoints.deps import get_uow
+from src.entrypoints.edge_identity import METADATA_KEY, Caller, EdgeIdentityVerifier, InvalidIdentity
 from src.service_layer import handlers
 from src.domain import models
 # Note: In a real environment, these imports would come from the generated code package.
@@ -79,25 +83,116 @@ class UserService(user_bridge_pb2_grpc.UserServiceServicer):
     async def StreamUserEvents(self, request, context) -> AsyncIterator[user_bridge_pb2.UserEvent]:
         # Simulation of streaming events
         events = [
-            ("user_registered", "alice"),
-            ("user_activated", "alice"),
-            ("user_updated", "alice")
+            ("evt-1", "user_registered", "alice"),
+            ("evt-2", "user_activated", "alice"),
+            ("evt-3", "user_updated", "alice")
         ]
-        for evt_type, user in events:
+        # A reconnecting client resumes after the last event it handled;
+        # an unknown token starts from the beginning of what we still have.
+        ids = [evt_id for evt_id, _, _ in events]
+        resume_token = getattr(request, "resume_token", "")
+        if resume_token in ids:
+            events = events[ids.index(resume_token) + 1:]
+        for evt_id, evt_type, user in events:
             yield user_bridge_pb2.UserEvent(
-                id="evt-123",
+                id=evt_id,
                 type=evt_type,
                 payload=user_bridge_pb2.User(username=user),
                 occurred_at="2023-10-27T10:00:00Z"
             )
             await asyncio.sleep(0.5)
 
+def server_credentials():
+    """mTLS credentials from USERS_GRPC_TLS_CERT/_KEY/_CA, or None for plaintext.
+
+    Clients must present a certificate issued by the CA; the edge checks
+    this server's SANs on its side.
+    """
+    paths = [os.environ.get(f"USERS_GRPC_TLS_{name}", "") for name in ("CERT", "KEY", "CA")]
+    if not any(paths):
+        return None
+    if not all(paths):
+        raise RuntimeError("USERS_GRPC_TLS_CERT, _KEY and _CA must be set together")
+    cert, key, ca = (Path(path).read_bytes() for path in paths)
+    return grpc.ssl_server_credentials([(key, cert)], root_certificates=ca, require_client_auth=True)
+
+# The caller the edge vouched for, for the RPC being handled.
+edge_caller: contextvars.ContextVar[Optional[Caller]] = contextvars.ContextVar("edge_caller", default=None)
+
+
+class EdgeIdentityInterceptor(grpc.aio.ServerInterceptor):
+    """Rejects calls without a valid x-edge-identity token with UNAUTHENTICATED.
+
+    Only the edge holds the signing secret, so only calls it vouched for
+    reach UserService; their caller is in edge_caller.
+    """
+
+    def __init__(self, verifier: EdgeIdentityVerifier):
+        self.verifier = verifier
+
+    async def _authenticate(self, context) -> None:
+        metadata = dict(context.invocation_metadata() or ())
+        try:
+            edge_caller.set(self.verifier.verify(metadata.get(METADATA_KEY)))
+        except InvalidIdentity as e:
+            logger.warning(f"Refused call without a valid edge identity: {e}")
+            await context.abort(grpc.StatusCode.UNAUTHENTICATED, "invalid edge identity")
+
+    async def intercept_service(self, continuation, handler_call_details):
+        handler = await continuation(handler_call_details)
+        if handler is None:
+            return None
+        serializers = dict(
+            request_deserializer=handler.request_deserializer,
+            response_serializer=handler.response_serializer,
+        )
+        if handler.unary_unary:
+            async def unary_unary(request, context):
+                await self._authenticate(context)
+                return await handler.unary_unary(request, context)
+            return grpc.unary_unary_rpc_method_handler(unary_unary, **serializers)
+        if handler.unary_stream:
+            async def unary_stream(request, context):
+                await self._authenticate(context)
+                async for response in handler.unary_stream(request, context):
+                    yield response
+            return grpc.unary_stream_rpc_method_handler(unary_stream, **serializers)
+        if handler.stream_unary:
+            async def stream_unary(requests, context):
+                await self._authenticate(context)
+                return await handler.stream_unary(requests, context)
+            return grpc.stream_unary_rpc_method_handler(stream_unary, **serializers)
+        async def stream_stream(requests, context):
+            await self._authenticate(context)
+            async for response in handler.stream_stream(requests, context):
+                yield response
+        return grpc.stream_stream_rpc_method_handler(stream_stream, **serializers)
+
+def server_interceptors():
+    """The edge identity check, keyed by USERS_IDENTITY_SECRET (the edge's
+    EDGE_IDENTITY_SECRET).
+
+    Only APP_ENV dev or test may run without the secret, and then serve
+    anyone who can reach the port.
+    """
+    secret = os.environ.get("USERS_IDENTITY_SECRET", "")
+    if secret:
+        return [EdgeIdentityInterceptor(EdgeIdentityVerifier(secret.encode(), audience="users"))]
+    profile = os.environ.get("APP_ENV", "")
+    if profile not in ("dev", "test"):
+        raise RuntimeError(f"USERS_IDENTITY_SECRET is required for APP_ENV {profile!r}")
+    return []
+
 async def serve():
-    server = grpc.aio.server()
+    server = grpc.aio.server(interceptors=server_interceptors())
     user_bridge_pb2_grpc.add_UserServiceServicer_to_server(UserService(), server)
     listen_addr = "[::]:50051"
-    server.add_insecure_port(listen_addr)
-    logger.info(f"gRPC Server starting on {listen_addr}")
+    credentials = server_credentials()
+    if credentials is None:
+        server.add_insecure_port(listen_addr)
+    else:
+        server.add_secure_port(listen_addr, credentials)
+    logger.info(f"gRPC Server starting on {listen_addr} (mTLS: {credentials is not None})")
     await server.start()
     await server.wait_for_termination()
 
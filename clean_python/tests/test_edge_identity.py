@@ -0,0 +1,58 @@
+import base64
+import hashlib
+import hmac
+import json
+
+import pytest
+from src.entrypoints.edge_identity import EdgeIdentityVerifier, InvalidIdentity
+
+SECRET = b"s3cret"
+NOW = 1_700_000_000
+
+
+def _b64(raw: bytes) -> str:
+    return base64.urlsafe_b64encode(raw).rstrip(b"=").decode()
+
+
+def sign(claims: dict, secret: bytes = SECRET) -> str:
+    """Mint a token the way go/pkg/edgetoken does."""
+    signed = _b64(json.dumps({"alg": "HS256", "typ": "JWT"}).encode()) + "." + _b64(json.dumps(claims).encode())
+    signature = hmac.new(secret, signed.encode(), hashlib.sha256).digest()
+    return signed + "." + _b64(signature)
+
+
+def edge_claims(**overrides) -> dict:
+    claims = {"iss": "edge", "sub": "user-1", "aud": ["users"], "iat": NOW, "exp": NOW + 30, "email": "a@example.com"}
+    claims.update(overrides)
+    return claims
+
+
+@pytest.fixture
+def verifier():
+    return EdgeIdentityVerifier(SECRET, audience="users", now=lambda: NOW)
+
+
+def test_verify_accepts_a_token_the_edge_signed_for_users(verifier):
+    caller = verifier.verify(sign(edge_claims()))
+    assert caller.subject == "user-1"
+    assert caller.issuer == "edge"
+    assert caller.email == "a@example.com"
+
+
+@pytest.mark.parametrize(
+    "token",
+    [
+        None,
+        "",
+        "not-a-token",
+        sign(edge_claims(), secret=b"forged"),
+        sign(edge_claims(iss="someone-else")),
+        sign(edge_claims(aud=["catalog"])),
+        sign(edge_claims(exp=NOW - 1)),
+        sign(edge_claims(sub="")),
+    ],
+    ids=["missing", "empty", "malformed", "forged", "issuer", "audience", "expired", "no-subject"],
+)
+def test_verify_rejects_anything_else(verifier, token):
+    with pytest.raises(InvalidIdentity):
+        verifier.verify(token)
@@ -0,0 +1,246 @@
+// Package breaker implements a circuit breaker: after too many recent
+// failures it rejects calls outright for a cooldown, then lets a few probe
+// calls through to decide whether the dependency has recovered.
+package breaker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrOpen is returned instead of calling through while the breaker is open.
+var ErrOpen = errors.New("circuit breaker is open")
+
+// State is the breaker's position.
+type State int
+
+const (
+	// Closed lets every call through and counts failures.
+	Closed State = iota
+	// Open rejects every call until Cooldown has passed.
+	Open
+	// HalfOpen lets up to Probes calls through; one failure reopens the
+	// breaker, Probes successes close it.
+	HalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// Breaker guards one dependency. Configure the exported fields before the
+// first call; it is safe for concurrent use afterwards.
+type Breaker struct {
+	// Window is how far back failures are counted, in Buckets slices.
+	Window  time.Duration
+	Buckets int
+	// The breaker opens once the window holds at least MinRequests calls
+	// and at least FailureRate (0..1) of them failed.
+	MinRequests int
+	FailureRate float64
+	// Cooldown is how long the breaker stays open before probing.
+	Cooldown time.Duration
+	// Probes is how many trial calls the half-open breaker allows.
+	Probes int
+	// IsFailure decides which errors count against the dependency. The
+	// default counts every error except the caller's own cancellation.
+	IsFailure func(err error) bool
+
+	// OnStateChange and OnCall are metrics hooks. OnCall sees every call,
+	// with ErrOpen for rejected ones. Both run outside the breaker's lock.
+	OnStateChange func(name string, from, to State)
+	OnCall        func(name string, state State, err error)
+
+	// Now returns the current time; tests replace it.
+	Now func() time.Time
+
+	name string
+
+	mu         sync.Mutex
+	state      State
+	generation uint64 // bumped on every state change
+	openedAt   time.Time
+	buckets    []bucket
+	probes     int // in flight while half-open
+	successes  int // probe successes while half-open
+}
+
+type bucket struct {
+	slot                int64 // which Window/Buckets slice of time it counts
+	successes, failures int
+}
+
+// New returns a breaker named name (used in hooks) that opens when half of
+// at least 20 calls in the last 30 seconds failed, and probes after 10s.
+func New(name string) *Breaker {
+	return &Breaker{
+		Window:      30 * time.Second,
+		Buckets:     10,
+		MinRequests: 20,
+		FailureRate: 0.5,
+		Cooldown:    10 * time.Second,
+		Probes:      3,
+		IsFailure:   defaultIsFailure,
+		Now:         time.Now,
+		name:        name,
+	}
+}
+
+func defaultIsFailure(err error) bool {
+	return err != nil && !errors.Is(err, context.Canceled)
+}
+
+// State reports the current state, moving Open to HalfOpen if the cooldown
+// has passed.
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	change := b.expireLocked(b.Now())
+	state := b.state
+	b.mu.Unlock()
+	b.notify(change)
+	return state
+}
+
+// Do calls fn unless the breaker is open, and records the outcome.
+func (b *Breaker) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	generation, state, change, err := b.allow()
+	b.notify(change)
+	if err != nil {
+		b.observe(state, err)
+		return err
+	}
+
+	err = fn(ctx)
+	b.notify(b.record(generation, b.IsFailure(err)))
+	b.observe(state, err)
+	return err
+}
+
+// transition is a state change to report once the lock is released.
+type transition struct {
+	from, to State
+	changed  bool
+}
+
+func (b *Breaker) allow() (uint64, State, transition, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	change := b.expireLocked(b.Now())
+	switch b.state {
+	case Open:
+		return 0, b.state, change, ErrOpen
+	case HalfOpen:
+		if b.probes >= b.Probes {
+			return 0, b.state, change, ErrOpen
+		}
+		b.probes++
+	}
+	return b.generation, b.state, change, nil
+}
+
+func (b *Breaker) record(generation uint64, failed bool) transition {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// The breaker moved on while the call ran; its outcome is stale.
+	if generation != b.generation {
+		return transition{}
+	}
+
+	now := b.Now()
+	switch b.state {
+	case HalfOpen:
+		b.probes--
+		if failed {
+			return b.setLocked(Open, now)
+		}
+		if b.successes++; b.successes >= b.Probes {
+			return b.setLocked(Closed, now)
+		}
+	case Closed:
+		bkt := b.bucketLocked(now)
+		if failed {
+			bkt.failures++
+		} else {
+			bkt.successes++
+		}
+		total, failures := b.countsLocked(now)
+		if total >= b.MinRequests && float64(failures) >= b.FailureRate*float64(total) {
+			return b.setLocked(Open, now)
+		}
+	}
+	return transition{}
+}
+
+func (b *Breaker) expireLocked(now time.Time) transition {
+	if b.state == Open && now.Sub(b.openedAt) >= b.Cooldown {
+		return b.setLocked(HalfOpen, now)
+	}
+	return transition{}
+}
+
+func (b *Breaker) setLocked(to State, now time.Time) transition {
+	from := b.state
+	b.state = to
+	b.generation++
+	b.buckets = nil
+	b.probes, b.successes = 0, 0
+	if to == Open {
+		b.openedAt = now
+	}
+	return transition{from: from, to: to, changed: true}
+}
+
+func (b *Breaker) bucketWidth() time.Duration {
+	n := max(b.Buckets, 1)
+	return max(b.Window/time.Duration(n), time.Nanosecond)
+}
+
+// bucketLocked returns the bucket counting now, recycling a stale one.
+func (b *Breaker) bucketLocked(now time.Time) *bucket {
+	if b.buckets == nil {
+		b.buckets = make([]bucket, max(b.Buckets, 1))
+	}
+	slot := now.UnixNano() / int64(b.bucketWidth())
+	bkt := &b.buckets[slot%int64(len(b.buckets))]
+	if bkt.slot != slot {
+		*bkt = bucket{slot: slot}
+	}
+	return bkt
+}
+
+// countsLocked sums the buckets that still fall inside the window.
+func (b *Breaker) countsLocked(now time.Time) (total, failures int) {
+	current := now.UnixNano() / int64(b.bucketWidth())
+	for _, bkt := range b.buckets {
+		if current-bkt.slot < int64(len(b.buckets)) {
+			total += bkt.successes + bkt.failures
+			failures += bkt.failures
+		}
+	}
+	return total, failures
+}
+
+func (b *Breaker) notify(t transition) {
+	if t.changed && b.OnStateChange != nil {
+		b.OnStateChange(b.name, t.from, t.to)
+	}
+}
+
+func (b *Breaker) observe(state State, err error) {
+	if b.OnCall != nil {
+		b.OnCall(b.name, state, err)
+	}
+}
@@ -0,0 +1,66 @@
+// Package budget splits what is left of a request's deadline across the
+// downstream calls it makes. Fixed per-call timeouts either all fire at
+// once (each longer than the request) or never fire (no request deadline);
+// a share of the remaining time avoids both.
+package budget
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Budget is one downstream call's slice of its caller's deadline.
+type Budget struct {
+	// Share is the fraction (0..1] of the remaining time the call gets;
+	// zero means all of it.
+	Share float64
+	// Floor is the least a call gets, so it is not started doomed. The
+	// caller's own deadline still applies.
+	Floor time.Duration
+	// Ceiling caps the call and is its timeout when ctx has no deadline;
+	// zero means no cap.
+	Ceiling time.Duration
+}
+
+// Timeout reports the call's timeout under ctx; false means unbounded.
+func (b Budget) Timeout(ctx context.Context) (time.Duration, bool) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return b.Ceiling, b.Ceiling > 0
+	}
+
+	d := time.Until(deadline)
+	if b.Share > 0 && b.Share < 1 {
+		d = time.Duration(float64(d) * b.Share)
+	}
+	if d < b.Floor {
+		d = b.Floor
+	}
+	if b.Ceiling > 0 && d > b.Ceiling {
+		d = b.Ceiling
+	}
+	return d, true
+}
+
+// Apply derives the call's context from ctx.
+func (b Budget) Apply(ctx context.Context) (context.Context, context.CancelFunc) {
+	d, ok := b.Timeout(ctx)
+	if !ok {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, d)
+}
+
+// Middleware gives every request a deadline of total, which the budgets of
+// its downstream calls are then carved from.
+func Middleware(total time.Duration, next http.Handler) http.Handler {
+	if total <= 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), total)
+		defer cancel()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
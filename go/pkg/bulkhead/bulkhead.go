@@ -0,0 +1,91 @@
+// Package bulkhead caps how many calls to one dependency run at once. When
+// a dependency slows down, its callers queue briefly and then fail fast
+// with ErrFull instead of piling up goroutines that every other request
+// then has to wait behind.
+package bulkhead
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrFull is returned when no slot freed up within the queue timeout.
+var ErrFull = errors.New("bulkhead full")
+
+// Bulkhead is a semaphore for one dependency. A nil *Bulkhead imposes no
+// limit, so callers can leave unconfigured pools out.
+type Bulkhead struct {
+	name         string
+	slots        chan struct{}
+	queueTimeout time.Duration
+}
+
+// New allows limit concurrent calls; a caller waits at most queueTimeout
+// for a slot (zero means it fails at once when all are taken). A limit
+// below 1 returns nil: no bulkhead.
+func New(name string, limit int, queueTimeout time.Duration) *Bulkhead {
+	if limit < 1 {
+		return nil
+	}
+	return &Bulkhead{name: name, slots: make(chan struct{}, limit), queueTimeout: queueTimeout}
+}
+
+// Do runs fn in a slot, waiting for one up to the queue timeout or until
+// ctx ends.
+func (b *Bulkhead) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	release, err := b.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return fn(ctx)
+}
+
+// Acquire takes a slot; the caller must call release exactly once.
+func (b *Bulkhead) Acquire(ctx context.Context) (release func(), err error) {
+	if b == nil {
+		return func() {}, nil
+	}
+
+	// 1. Fast path: a free slot
+	select {
+	case b.slots <- struct{}{}:
+		return b.release, nil
+	default:
+	}
+	if b.queueTimeout <= 0 {
+		return nil, fmt.Errorf("%s: %w", b.name, ErrFull)
+	}
+
+	// 2. Queue for one
+	timer := time.NewTimer(b.queueTimeout)
+	defer timer.Stop()
+	select {
+	case b.slots <- struct{}{}:
+		return b.release, nil
+	case <-timer.C:
+		return nil, fmt.Errorf("%s: %w", b.name, ErrFull)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (b *Bulkhead) release() { <-b.slots }
+
+// InUse reports how many slots are taken, for metrics.
+func (b *Bulkhead) InUse() int {
+	if b == nil {
+		return 0
+	}
+	return len(b.slots)
+}
+
+// Limit reports the number of slots; zero for a nil bulkhead.
+func (b *Bulkhead) Limit() int {
+	if b == nil {
+		return 0
+	}
+	return cap(b.slots)
+}
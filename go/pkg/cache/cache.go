@@ -0,0 +1,177 @@
+// Package cache provides a generic in-process cache: an LRU bounded by size
+// with per-entry TTLs, hit/miss/eviction counters and single-flight loading.
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Cache is the minimal contract shared by cache implementations.
+type Cache[K comparable, V any] interface {
+	Get(key K) (V, bool)
+	Set(key K, value V)
+	Delete(key K)
+	Len() int
+}
+
+// Stats are cumulative counters, suitable for exporting as metrics.
+type Stats struct {
+	Hits        uint64
+	Misses      uint64
+	Evictions   uint64 // dropped to make room
+	Expirations uint64 // dropped because their TTL passed
+}
+
+type entry[K comparable, V any] struct {
+	key     K
+	value   V
+	expires time.Time
+}
+
+// LRU is a Cache holding at most capacity entries, each for at most ttl.
+// It is safe for concurrent use.
+type LRU[K comparable, V any] struct {
+	capacity int
+	ttl      time.Duration
+
+	mu    sync.Mutex
+	order *list.List // front = most recently used
+	items map[K]*list.Element
+	calls map[K]*call[V]
+
+	hits, misses, evictions, expirations atomic.Uint64
+}
+
+var _ Cache[string, int] = (*LRU[string, int])(nil)
+
+// NewLRU returns an empty cache. A ttl of zero means entries never expire.
+func NewLRU[K comparable, V any](capacity int, ttl time.Duration) *LRU[K, V] {
+	return &LRU[K, V]{
+		capacity: capacity,
+		ttl:      ttl,
+		order:    list.New(),
+		items:    make(map[K]*list.Element),
+		calls:    make(map[K]*call[V]),
+	}
+}
+
+func (c *LRU[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.getLocked(key)
+}
+
+func (c *LRU[K, V]) Set(key K, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.setLocked(key, value)
+}
+
+func (c *LRU[K, V]) Delete(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		c.removeLocked(el)
+	}
+}
+
+func (c *LRU[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+func (c *LRU[K, V]) Stats() Stats {
+	return Stats{
+		Hits:        c.hits.Load(),
+		Misses:      c.misses.Load(),
+		Evictions:   c.evictions.Load(),
+		Expirations: c.expirations.Load(),
+	}
+}
+
+// call is one in-flight load shared by every concurrent GetOrLoad of a key.
+type call[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+// GetOrLoad returns the cached value or calls load once for all concurrent
+// callers of the same key (single flight), caching a successful result.
+// Errors are returned to every waiter and never cached.
+func (c *LRU[K, V]) GetOrLoad(ctx context.Context, key K, load func(ctx context.Context) (V, error)) (V, error) {
+	c.mu.Lock()
+	if v, ok := c.getLocked(key); ok {
+		c.mu.Unlock()
+		return v, nil
+	}
+	if inflight, ok := c.calls[key]; ok {
+		c.mu.Unlock()
+		select {
+		case <-inflight.done:
+			return inflight.value, inflight.err
+		case <-ctx.Done():
+			var zero V
+			return zero, ctx.Err()
+		}
+	}
+	cl := &call[V]{done: make(chan struct{})}
+	c.calls[key] = cl
+	c.mu.Unlock()
+
+	cl.value, cl.err = load(ctx)
+
+	c.mu.Lock()
+	delete(c.calls, key)
+	if cl.err == nil {
+		c.setLocked(key, cl.value)
+	}
+	c.mu.Unlock()
+	close(cl.done)
+	return cl.value, cl.err
+}
+
+func (c *LRU[K, V]) getLocked(key K) (V, bool) {
+	el, ok := c.items[key]
+	if !ok {
+		c.misses.Add(1)
+		var zero V
+		return zero, false
+	}
+	e := el.Value.(*entry[K, V])
+	if c.ttl > 0 && time.Now().After(e.expires) {
+		c.removeLocked(el)
+		c.expirations.Add(1)
+		c.misses.Add(1)
+		var zero V
+		return zero, false
+	}
+	c.order.MoveToFront(el)
+	c.hits.Add(1)
+	return e.value, true
+}
+
+func (c *LRU[K, V]) setLocked(key K, value V) {
+	expires := time.Now().Add(c.ttl)
+	if el, ok := c.items[key]; ok {
+		e := el.Value.(*entry[K, V])
+		e.value, e.expires = value, expires
+		c.order.MoveToFront(el)
+		return
+	}
+	c.items[key] = c.order.PushFront(&entry[K, V]{key: key, value: value, expires: expires})
+	for c.capacity > 0 && c.order.Len() > c.capacity {
+		c.removeLocked(c.order.Back())
+		c.evictions.Add(1)
+	}
+}
+
+func (c *LRU[K, V]) removeLocked(el *list.Element) {
+	c.order.Remove(el)
+	delete(c.items, el.Value.(*entry[K, V]).key)
+}
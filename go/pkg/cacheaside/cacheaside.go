@@ -0,0 +1,97 @@
+// Package cacheaside implements the cache-aside pattern over any key/value
+// store: reads try the cache, fall back to the source of truth and populate
+// the cache; writes invalidate. The cache is strictly best effort, so a
+// store outage degrades to uncached reads instead of failing them.
+package cacheaside
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+)
+
+// ErrMiss is returned by Store.Get when the key is not cached.
+var ErrMiss = errors.New("cache miss")
+
+// Store is the port a cache backend implements. Get returns ErrMiss for
+// absent keys; any other error means the backend itself is unavailable.
+type Store[V any] interface {
+	Get(ctx context.Context, key string) (V, error)
+	Set(ctx context.Context, key string, value V) error
+	Delete(ctx context.Context, keys ...string) error
+}
+
+// PrefixDeleter is implemented by stores that can drop every key starting
+// with a prefix, which Flush needs.
+type PrefixDeleter interface {
+	DeletePrefix(ctx context.Context, prefix string) (int, error)
+}
+
+// Cache applies cache-aside semantics on top of a Store.
+type Cache[V any] struct {
+	store  Store[V]
+	logger *log.Logger
+}
+
+func New[V any](store Store[V], logger *log.Logger) *Cache[V] {
+	return &Cache[V]{store: store, logger: logger}
+}
+
+// Get returns the cached value for key, or calls load and caches its
+// result under key and any aliases (other keys the same value is looked up
+// by). Errors from load are returned as is and never cached.
+func (c *Cache[V]) Get(ctx context.Context, key string, load func(ctx context.Context) (V, error), aliases func(V) []string) (V, error) {
+	// 1. Try the cache
+	v, err := c.store.Get(ctx, key)
+	if err == nil {
+		return v, nil
+	}
+	if !errors.Is(err, ErrMiss) {
+		c.logger.Printf("cache: get %s: %v", key, err)
+	}
+
+	// 2. Fall back to the source
+	v, err = load(ctx)
+	if err != nil {
+		return v, err
+	}
+
+	// 3. Populate for next time
+	keys := []string{key}
+	if aliases != nil {
+		keys = append(keys, aliases(v)...)
+	}
+	for _, k := range keys {
+		if err := c.store.Set(ctx, k, v); err != nil {
+			c.logger.Printf("cache: set %s: %v", k, err)
+			break
+		}
+	}
+	return v, nil
+}
+
+// Peek returns the cached value without falling back; ok is false on a
+// miss or when the store is unavailable.
+func (c *Cache[V]) Peek(ctx context.Context, key string) (v V, ok bool) {
+	v, err := c.store.Get(ctx, key)
+	return v, err == nil
+}
+
+// Invalidate drops keys so the next Get reloads them.
+func (c *Cache[V]) Invalidate(ctx context.Context, keys ...string) {
+	if err := c.store.Delete(ctx, keys...); err != nil {
+		c.logger.Printf("cache: invalidate %v: %v", keys, err)
+	}
+}
+
+// Flush drops every key starting with prefix and returns how many went.
+// Unlike Invalidate it reports store errors, as an operator asked for it;
+// stores that are not a PrefixDeleter fail with errors.ErrUnsupported.
+func (c *Cache[V]) Flush(ctx context.Context, prefix string) (int, error) {
+	d, ok := c.store.(PrefixDeleter)
+	if !ok {
+		return 0, fmt.Errorf("cache: flush: %w", errors.ErrUnsupported)
+	}
+	return d.DeletePrefix(ctx, prefix)
+}
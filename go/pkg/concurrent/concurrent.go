@@ -0,0 +1,67 @@
+// Package concurrent runs fan-outs with a cap on how many tasks run at
+// once and a deadline for each, so a slice of a thousand items does not
+// become a thousand goroutines hammering one dependency, and one stuck
+// task does not hold up the rest past its budget.
+package concurrent
+
+import (
+	"context"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// Options bounds a fan-out. The zero value runs every task at once with
+// no deadline but the caller's.
+type Options struct {
+	// Limit caps the tasks running at once; 0 means no cap.
+	Limit int
+	// Timeout bounds each task on its own; 0 means no bound.
+	Timeout time.Duration
+}
+
+// Map calls fn on every item concurrently and returns the results in the
+// order of items. The first error cancels the context of the calls still
+// running, stops new ones from starting, and is returned.
+func Map[T, R any](ctx context.Context, opts Options, items []T, fn func(ctx context.Context, item T) (R, error)) ([]R, error) {
+	results := make([]R, len(items))
+	g, ctx := errgroup.WithContext(ctx)
+	if opts.Limit > 0 {
+		g.SetLimit(opts.Limit)
+	}
+	for i, item := range items {
+		i, item := i, item
+		g.Go(func() error {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			taskCtx, cancel := opts.task(ctx)
+			defer cancel()
+			r, err := fn(taskCtx, item)
+			results[i] = r
+			return err
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// Gather runs tasks concurrently and waits for all of them. Like Map, the
+// first error cancels the others and is returned; a task that should not
+// sink the rest records its failure and returns nil.
+func Gather(ctx context.Context, opts Options, tasks ...func(ctx context.Context) error) error {
+	_, err := Map(ctx, opts, tasks, func(ctx context.Context, task func(context.Context) error) (struct{}, error) {
+		return struct{}{}, task(ctx)
+	})
+	return err
+}
+
+// task derives one task's context from the fan-out's.
+func (o Options) task(ctx context.Context) (context.Context, context.CancelFunc) {
+	if o.Timeout > 0 {
+		return context.WithTimeout(ctx, o.Timeout)
+	}
+	return context.WithCancel(ctx)
+}
@@ -0,0 +1,7 @@
+// Packages shared by the Go services and clean_go_system. Each consumer
+// requires clean-code-cookbook/go/pkg and replaces it with this directory.
+module clean-code-cookbook/go/pkg
+
+go 1.21
+
+require golang.org/x/sync v0.10.0
@@ -0,0 +1,208 @@
+// Package jwt signs and verifies the compact JSON Web Tokens the service
+// deals in: HS256 for its own session tokens and RS256 for ID tokens from
+// an OpenID Connect provider. It implements only what those need.
+package jwt
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	ErrMalformed = errors.New("jwt: malformed token")
+	ErrSignature = errors.New("jwt: invalid signature")
+	ErrExpired   = errors.New("jwt: token expired")
+	ErrClaims    = errors.New("jwt: unexpected claims")
+)
+
+// Header is the JOSE header.
+type Header struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid,omitempty"`
+	Typ string `json:"typ,omitempty"`
+}
+
+// Claims are the registered claims plus the OIDC profile claims we read.
+type Claims struct {
+	Issuer    string   `json:"iss,omitempty"`
+	Subject   string   `json:"sub,omitempty"`
+	Audience  Audience `json:"aud,omitempty"`
+	ExpiresAt int64    `json:"exp,omitempty"`
+	NotBefore int64    `json:"nbf,omitempty"`
+	IssuedAt  int64    `json:"iat,omitempty"`
+	Nonce     string   `json:"nonce,omitempty"`
+
+	Email             string `json:"email,omitempty"`
+	EmailVerified     bool   `json:"email_verified,omitempty"`
+	PreferredUsername string `json:"preferred_username,omitempty"`
+}
+
+// Audience is the "aud" claim, which may be a string or a list.
+type Audience []string
+
+func (a *Audience) UnmarshalJSON(raw []byte) error {
+	var one string
+	if err := json.Unmarshal(raw, &one); err == nil {
+		*a = Audience{one}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(raw, &many); err != nil {
+		return err
+	}
+	*a = many
+	return nil
+}
+
+func (a Audience) contains(aud string) bool {
+	for _, v := range a {
+		if v == aud {
+			return true
+		}
+	}
+	return false
+}
+
+// Expect lists what Validate checks beyond the signature.
+type Expect struct {
+	Issuer   string
+	Audience string
+	Now      time.Time
+	// Leeway absorbs clock skew between us and the issuer.
+	Leeway time.Duration
+}
+
+// Validate checks issuer, audience and the validity window.
+func (c Claims) Validate(e Expect) error {
+	if c.Issuer != e.Issuer {
+		return fmt.Errorf("%w: issuer %q", ErrClaims, c.Issuer)
+	}
+	if e.Audience != "" && !c.Audience.contains(e.Audience) {
+		return fmt.Errorf("%w: audience %v", ErrClaims, c.Audience)
+	}
+	now := e.Now.Unix()
+	leeway := int64(e.Leeway / time.Second)
+	if c.ExpiresAt == 0 || now > c.ExpiresAt+leeway {
+		return ErrExpired
+	}
+	if c.NotBefore != 0 && now < c.NotBefore-leeway {
+		return fmt.Errorf("%w: not valid yet", ErrClaims)
+	}
+	return nil
+}
+
+// Token is a parsed, not yet verified, token.
+type Token struct {
+	Header Header
+	Claims Claims
+
+	signed    []byte // header.payload, as received
+	signature []byte
+}
+
+// Parse splits and decodes raw. It does not check the signature: call one
+// of the Verify methods before trusting the claims.
+func Parse(raw string) (*Token, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformed
+	}
+	var t Token
+	if err := decodeSegment(parts[0], &t.Header); err != nil {
+		return nil, err
+	}
+	if err := decodeSegment(parts[1], &t.Claims); err != nil {
+		return nil, err
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	t.signed, t.signature = []byte(parts[0]+"."+parts[1]), sig
+	return &t, nil
+}
+
+func decodeSegment(seg string, into any) error {
+	raw, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return ErrMalformed
+	}
+	if err := json.Unmarshal(raw, into); err != nil {
+		return fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	return nil
+}
+
+// VerifyHS256 checks an HMAC-SHA256 signature made with secret.
+func (t *Token) VerifyHS256(secret []byte) error {
+	if t.Header.Alg != "HS256" {
+		return fmt.Errorf("%w: alg %s", ErrSignature, t.Header.Alg)
+	}
+	if !hmac.Equal(t.signature, hs256(t.signed, secret)) {
+		return ErrSignature
+	}
+	return nil
+}
+
+// VerifyRS256 checks an RSASSA-PKCS1-v1_5 SHA-256 signature.
+func (t *Token) VerifyRS256(key *rsa.PublicKey) error {
+	if t.Header.Alg != "RS256" {
+		return fmt.Errorf("%w: alg %s", ErrSignature, t.Header.Alg)
+	}
+	digest := sha256.Sum256(t.signed)
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], t.signature); err != nil {
+		return ErrSignature
+	}
+	return nil
+}
+
+// SignHS256 encodes claims as a token signed with secret.
+func SignHS256(claims Claims, secret []byte) (string, error) {
+	signed, err := signingInput(Header{Alg: "HS256", Typ: "JWT"}, claims)
+	if err != nil {
+		return "", err
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(hs256([]byte(signed), secret)), nil
+}
+
+// SignRS256 encodes claims as a token signed with key, naming it kid so
+// verifiers can pick the matching public key.
+func SignRS256(claims Claims, key *rsa.PrivateKey, kid string) (string, error) {
+	signed, err := signingInput(Header{Alg: "RS256", Typ: "JWT", Kid: kid}, claims)
+	if err != nil {
+		return "", err
+	}
+	digest := sha256.Sum256([]byte(signed))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+func signingInput(h Header, c Claims) (string, error) {
+	header, err := json.Marshal(h)
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload), nil
+}
+
+func hs256(signed, secret []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(signed)
+	return mac.Sum(nil)
+}
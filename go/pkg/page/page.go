@@ -0,0 +1,129 @@
+// Package page is the pagination contract shared by every listing, from
+// the repositories up to the HTTP and gRPC adapters: a Request names the
+// page size, the Sort and the Cursor to resume after; a Page carries one
+// batch of items and the Cursor of the next.
+package page
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+)
+
+var (
+	ErrInvalidCursor = errors.New("invalid page cursor")
+	ErrInvalidSort   = errors.New("invalid sort")
+)
+
+// Cursor is an opaque position in a listing. Clients echo it back
+// unchanged; only the store that issued it reads it. The zero Cursor is
+// the start.
+type Cursor string
+
+// NewCursor packs the sort keys of the last item on a page, typically
+// its sort field then its ID, so the next page starts strictly after it
+// even while rows are inserted.
+func NewCursor(keys ...string) Cursor {
+	raw, _ := json.Marshal(keys)
+	return Cursor(base64.RawURLEncoding.EncodeToString(raw))
+}
+
+// Keys unpacks the n keys NewCursor packed.
+func (c Cursor) Keys(n int) ([]string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(string(c))
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var keys []string
+	if err := json.Unmarshal(raw, &keys); err != nil || len(keys) != n {
+		return nil, ErrInvalidCursor
+	}
+	return keys, nil
+}
+
+// Sort orders a listing by one field, ties broken by ID. The zero Sort
+// leaves the order to the store.
+type Sort struct {
+	Field string
+	Desc  bool
+}
+
+// ParseSort reads "field" or "-field" (descending), accepting only the
+// allowed fields. An empty string is the zero Sort.
+func ParseSort(s string, allowed ...string) (Sort, error) {
+	if s == "" {
+		return Sort{}, nil
+	}
+	field, desc := strings.CutPrefix(s, "-")
+	if !slices.Contains(allowed, field) {
+		return Sort{}, ErrInvalidSort
+	}
+	return Sort{Field: field, Desc: desc}, nil
+}
+
+func (s Sort) String() string {
+	if s.Desc {
+		return "-" + s.Field
+	}
+	return s.Field
+}
+
+// Request asks for one page of a listing.
+type Request struct {
+	// Size is the most items to return.
+	Size  int
+	After Cursor
+	Sort  Sort
+}
+
+// Clamp defaults an unset Size to def and caps it at max.
+func (r Request) Clamp(def, max int) Request {
+	switch {
+	case r.Size <= 0:
+		r.Size = def
+	case r.Size > max:
+		r.Size = max
+	}
+	return r
+}
+
+// Page is one batch of a listing.
+type Page[T any] struct {
+	Items []T
+	// Next resumes after the last item; it is empty on the last page.
+	Next Cursor
+}
+
+// Map converts the items of p, keeping its cursor, e.g. from domain
+// entities to response DTOs.
+func Map[T, U any](p Page[T], f func(T) U) Page[U] {
+	out := Page[U]{Items: make([]U, len(p.Items)), Next: p.Next}
+	for i, item := range p.Items {
+		out.Items[i] = f(item)
+	}
+	return out
+}
+
+// Each calls fn for every item of the listing fetch pages through,
+// starting at req and following Next, so only one page is held at a time.
+// It stops at the first error of fetch or fn.
+func Each[T any](ctx context.Context, req Request, fetch func(context.Context, Request) (Page[T], error), fn func(T) error) error {
+	for {
+		p, err := fetch(ctx, req)
+		if err != nil {
+			return err
+		}
+		for _, item := range p.Items {
+			if err := fn(item); err != nil {
+				return err
+			}
+		}
+		if p.Next == "" {
+			return nil
+		}
+		req.After = p.Next
+	}
+}
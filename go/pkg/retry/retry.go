@@ -0,0 +1,100 @@
+// Package retry runs an operation until it succeeds, a policy gives up, or
+// the context ends. It replaces the hand-written backoff loops that used to
+// live next to each caller.
+package retry
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+// Backoff returns how long to wait after the given failed attempt (1-based).
+type Backoff func(attempt int) time.Duration
+
+// Constant waits d between every attempt.
+func Constant(d time.Duration) Backoff {
+	return func(int) time.Duration { return d }
+}
+
+// Exponential waits initial after the first failure and doubles the wait
+// after each further one, capped at max (no cap when max is zero).
+func Exponential(initial, max time.Duration) Backoff {
+	return func(attempt int) time.Duration {
+		d := initial
+		for i := 1; i < attempt; i++ {
+			d *= 2
+			if max > 0 && d >= max {
+				return max
+			}
+		}
+		if max > 0 && d > max {
+			return max
+		}
+		return d
+	}
+}
+
+// Jitter spreads b's waits uniformly over [d/2, d), so callers that failed
+// together do not retry in lockstep.
+func Jitter(b Backoff) Backoff {
+	return func(attempt int) time.Duration {
+		d := b(attempt)
+		if d <= 1 {
+			return d
+		}
+		half := d / 2
+		return half + time.Duration(rand.Int63n(int64(d-half)))
+	}
+}
+
+// Policy decides how often and when to try again.
+type Policy struct {
+	// Attempts is the total number of calls, including the first; values
+	// below 1 mean a single call.
+	Attempts int
+	// Backoff is the wait between attempts; nil retries immediately.
+	Backoff Backoff
+	// RetryIf reports whether err is worth another attempt; nil retries
+	// every error.
+	RetryIf func(err error) bool
+	// OnRetry, if set, is called before waiting for the next attempt.
+	OnRetry func(attempt int, err error, wait time.Duration)
+}
+
+// Do calls fn until it returns nil or the policy gives up, and returns
+// fn's last error. If ctx ends while waiting, it returns ctx.Err() instead.
+func Do(ctx context.Context, p Policy, fn func(ctx context.Context) error) error {
+	attempts := max(p.Attempts, 1)
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil || attempt >= attempts || (p.RetryIf != nil && !p.RetryIf(err)) {
+			return err
+		}
+
+		var wait time.Duration
+		if p.Backoff != nil {
+			wait = p.Backoff(attempt)
+		}
+		if p.OnRetry != nil {
+			p.OnRetry(attempt, err, wait)
+		}
+		if err := sleep(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"clean-code-cookbook/go/pkg/signing"
+	httpadapter "clean-code-cookbook/go/services/auth/internal/adapter/http"
+	"clean-code-cookbook/go/services/auth/internal/adapter/keys"
+	"clean-code-cookbook/go/services/auth/internal/adapter/memory"
+	"clean-code-cookbook/go/services/auth/internal/adapter/postgres"
+	"clean-code-cookbook/go/services/auth/internal/app"
+	"clean-code-cookbook/go/services/auth/internal/ports"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	logger := log.New(os.Stdout, "[auth] ", log.LstdFlags)
+
+	// 1. Credentials and refresh tokens live in Postgres
+	// (AUTH_DATABASE_URL), or in memory for local runs.
+	var credentials ports.CredentialRepository = memory.NewCredentialRepository()
+	var refresh ports.RefreshTokenRepository = memory.NewRefreshTokenRepository()
+	if dsn := os.Getenv("AUTH_DATABASE_URL"); dsn != "" {
+		db, err := postgres.Open(ctx, dsn)
+		if err != nil {
+			logger.Fatal(err)
+		}
+		defer db.Close()
+		credentials, refresh = postgres.NewCredentialRepository(db), postgres.NewRefreshTokenRepository(db)
+	}
+
+	// 2. Access tokens are signed with AUTH_SIGNING_KEY_FILE; the keys in
+	// AUTH_RETIRED_KEY_FILES are still published until their tokens expire.
+	keySet, err := loadKeys(logger)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	// 3. Wire the use cases
+	tokens := &app.TokenIssuer{
+		Refresh:    refresh,
+		Signer:     keySet,
+		Issuer:     env("AUTH_ISSUER", "http://localhost:8084"),
+		Audience:   env("AUTH_AUDIENCE", "clean-code-cookbook"),
+		AccessTTL:  durationEnv(logger, "AUTH_ACCESS_TTL", 15*time.Minute),
+		RefreshTTL: durationEnv(logger, "AUTH_REFRESH_TTL", 30*24*time.Hour),
+	}
+	mux := http.NewServeMux()
+	httpadapter.NewHandler(
+		&app.RegisterCommand{Credentials: credentials},
+		&app.LoginCommand{Credentials: credentials, Tokens: tokens},
+		&app.RefreshCommand{Tokens: tokens},
+		&app.LogoutCommand{Refresh: refresh},
+		logger,
+	).Register(mux)
+	mux.Handle("GET /.well-known/jwks.json", keySet.Handler())
+	// Services with a password flow of their own set passwords here,
+	// signing with one of AUTH_INTERNAL_KEYS ("id:secret,...").
+	if spec := os.Getenv("AUTH_INTERNAL_KEYS"); spec != "" {
+		internal, err := signing.ParseKeys(spec)
+		if err != nil {
+			logger.Fatalf("AUTH_INTERNAL_KEYS: %v", err)
+		}
+		httpadapter.NewCredentialsHandler(
+			&app.SetPasswordCommand{Credentials: credentials, Refresh: refresh},
+			signing.NewKeyring(internal...),
+			logger,
+		).Register(mux)
+	}
+
+	server := &http.Server{
+		Addr:              env("AUTH_HTTP_ADDR", ":8084"),
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	// 4. Serve until interrupted, then drain
+	go func() {
+		logger.Printf("HTTP listening on %s", server.Addr)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Fatalf("http server: %v", err)
+		}
+	}()
+	<-ctx.Done()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		logger.Printf("shutdown: %v", err)
+	}
+	logger.Println("Done.")
+}
+
+// loadKeys reads the signing keys, or makes a throwaway one for local
+// runs; its tokens stop verifying when the process exits.
+func loadKeys(logger *log.Logger) (*keys.KeySet, error) {
+	path := os.Getenv("AUTH_SIGNING_KEY_FILE")
+	if path == "" {
+		logger.Println("AUTH_SIGNING_KEY_FILE not set; signing with a temporary key")
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			return nil, err
+		}
+		return keys.NewKeySet(key), nil
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	active, err := keys.ParsePrivateKey(raw)
+	if err != nil {
+		return nil, err
+	}
+	var retired []*rsa.PublicKey
+	for _, p := range strings.Split(os.Getenv("AUTH_RETIRED_KEY_FILES"), ",") {
+		if p = strings.TrimSpace(p); p == "" {
+			continue
+		}
+		raw, err := os.ReadFile(p)
+		if err != nil {
+			return nil, err
+		}
+		pub, err := keys.ParsePublicKey(raw)
+		if err != nil {
+			return nil, err
+		}
+		retired = append(retired, pub)
+	}
+	return keys.NewKeySet(active, retired...), nil
+}
+
+func env(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+func durationEnv(logger *log.Logger, key string, def time.Duration) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		logger.Fatalf("%s: %q is not a positive duration", key, v)
+	}
+	return d
+}
@@ -0,0 +1,12 @@
+module clean-code-cookbook/go/services/auth
+
+go 1.22
+
+require (
+	clean-code-cookbook/go/pkg v0.0.0
+	github.com/google/uuid v1.6.0
+	github.com/lib/pq v1.10.9
+	golang.org/x/crypto v0.31.0
+)
+
+replace clean-code-cookbook/go/pkg => ../../pkg
@@ -0,0 +1,60 @@
+package httpadapter
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"clean-code-cookbook/go/pkg/signing"
+	"clean-code-cookbook/go/services/auth/internal/app"
+	"clean-code-cookbook/go/services/auth/internal/domain"
+	"github.com/google/uuid"
+)
+
+type setPasswordRequest struct {
+	Email        string `json:"email"`
+	PasswordHash []byte `json:"password_hash"`
+}
+
+// CredentialsHandler serves PUT /credentials/{user_id} to the services
+// that own a password flow of their own, so the passwords they set are
+// the ones /login checks. Every request must be signed by one of them.
+type CredentialsHandler struct {
+	set    *app.SetPasswordCommand
+	keys   *signing.Keyring
+	logger *log.Logger
+}
+
+func NewCredentialsHandler(set *app.SetPasswordCommand, keys *signing.Keyring, logger *log.Logger) *CredentialsHandler {
+	return &CredentialsHandler{set: set, keys: keys, logger: logger}
+}
+
+// Register mounts the route on mux.
+func (h *CredentialsHandler) Register(mux *http.ServeMux) {
+	mux.Handle("PUT /credentials/{user_id}", h.keys.Middleware(http.HandlerFunc(h.SetPassword)))
+}
+
+// SetPassword handles PUT /credentials/{user_id} and answers 204.
+func (h *CredentialsHandler) SetPassword(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(r.PathValue("user_id"))
+	if err != nil {
+		http.Error(w, "invalid user id", http.StatusBadRequest)
+		return
+	}
+	var req setPasswordRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	err = h.set.Execute(r.Context(), userID, req.Email, req.PasswordHash)
+	switch {
+	case err == nil:
+		w.WriteHeader(http.StatusNoContent)
+	case errors.Is(err, domain.ErrInvalidEmail), errors.Is(err, domain.ErrInvalidPasswordHash):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	case errors.Is(err, domain.ErrEmailTaken):
+		http.Error(w, domain.ErrEmailTaken.Error(), http.StatusConflict)
+	default:
+		h.logger.Printf("http: %v", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,162 @@
+// Package httpadapter serves the auth use cases as a JSON API.
+package httpadapter
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"clean-code-cookbook/go/services/auth/internal/app"
+	"clean-code-cookbook/go/services/auth/internal/domain"
+)
+
+// maxBody bounds a request body.
+const maxBody = 1 << 16
+
+type credentialsRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type registerResponse struct {
+	UserID string `json:"user_id"`
+	Email  string `json:"email"`
+}
+
+// tokenResponse follows the OAuth 2.0 token response (RFC 6749 §5.1).
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// Handler serves POST /register, /login, /refresh and /logout.
+type Handler struct {
+	register *app.RegisterCommand
+	login    *app.LoginCommand
+	refresh  *app.RefreshCommand
+	logout   *app.LogoutCommand
+	logger   *log.Logger
+}
+
+func NewHandler(register *app.RegisterCommand, login *app.LoginCommand, refresh *app.RefreshCommand, logout *app.LogoutCommand, logger *log.Logger) *Handler {
+	return &Handler{register: register, login: login, refresh: refresh, logout: logout, logger: logger}
+}
+
+// Register mounts the routes on mux.
+func (h *Handler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("POST /register", h.SignUp)
+	mux.HandleFunc("POST /login", h.Login)
+	mux.HandleFunc("POST /refresh", h.Refresh)
+	mux.HandleFunc("POST /logout", h.Logout)
+}
+
+// SignUp handles POST /register and answers 201 with the new user's ID.
+func (h *Handler) SignUp(w http.ResponseWriter, r *http.Request) {
+	var req credentialsRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	cred, err := h.register.Execute(r.Context(), req.Email, req.Password)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, registerResponse{UserID: cred.UserID.String(), Email: cred.Email})
+}
+
+// Login handles POST /login.
+func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
+	var req credentialsRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	pair, err := h.login.Execute(r.Context(), req.Email, req.Password)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	writeTokens(w, pair)
+}
+
+// Refresh handles POST /refresh. The refresh token sent is used up; the
+// response carries its successor.
+func (h *Handler) Refresh(w http.ResponseWriter, r *http.Request) {
+	var req refreshRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	pair, err := h.refresh.Execute(r.Context(), req.RefreshToken)
+	if errors.Is(err, domain.ErrRefreshTokenReused) {
+		h.logger.Printf("http: refresh token reused; its family was revoked")
+	}
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	writeTokens(w, pair)
+}
+
+// Logout handles POST /logout and answers 204.
+func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
+	var req refreshRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	if err := h.logout.Execute(r.Context(), req.RefreshToken); err != nil {
+		h.writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeError maps domain errors to statuses. Every token failure is the
+// same 401, so a client cannot tell a reused token from an expired one.
+func (h *Handler) writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidCredentials):
+		http.Error(w, domain.ErrInvalidCredentials.Error(), http.StatusUnauthorized)
+	case errors.Is(err, domain.ErrInvalidRefreshToken), errors.Is(err, domain.ErrRefreshTokenReused):
+		http.Error(w, domain.ErrInvalidRefreshToken.Error(), http.StatusUnauthorized)
+	case errors.Is(err, domain.ErrInvalidEmail), errors.Is(err, domain.ErrWeakPassword):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	case errors.Is(err, domain.ErrEmailTaken):
+		http.Error(w, domain.ErrEmailTaken.Error(), http.StatusConflict)
+	default:
+		h.logger.Printf("http: %v", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
+
+func decode(w http.ResponseWriter, r *http.Request, into any) bool {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBody))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(into); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// writeTokens sends a pair; tokens must never be cached.
+func writeTokens(w http.ResponseWriter, pair domain.TokenPair) {
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, tokenResponse{
+		AccessToken:  pair.AccessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(pair.ExpiresIn.Seconds()),
+		RefreshToken: pair.RefreshToken,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
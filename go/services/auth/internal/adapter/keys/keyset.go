@@ -0,0 +1,141 @@
+// Package keys holds the RSA keys access tokens are signed with and
+// publishes their public halves as a JSON Web Key Set.
+package keys
+
+import (
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+
+	"clean-code-cookbook/go/pkg/jwt"
+)
+
+// JWK is one RSA public key as RFC 7517 spells it.
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// JWKS is the document verifiers fetch.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// KeySet implements ports.Signer with RS256. It signs with the active key
+// and publishes that key and the retired ones, so tokens signed before a
+// rotation verify until they expire. Key IDs are RFC 7638 thumbprints.
+type KeySet struct {
+	active    *rsa.PrivateKey
+	activeKid string
+	jwks      JWKS
+}
+
+// NewKeySet signs with active and also publishes retired.
+func NewKeySet(active *rsa.PrivateKey, retired ...*rsa.PublicKey) *KeySet {
+	ks := &KeySet{active: active}
+	for i, pub := range append([]*rsa.PublicKey{&active.PublicKey}, retired...) {
+		jwk := toJWK(pub)
+		if i == 0 {
+			ks.activeKid = jwk.Kid
+		}
+		ks.jwks.Keys = append(ks.jwks.Keys, jwk)
+	}
+	return ks
+}
+
+func (ks *KeySet) Sign(claims jwt.Claims) (string, error) {
+	return jwt.SignRS256(claims, ks.active, ks.activeKid)
+}
+
+// JWKS returns the published keys, the active one first.
+func (ks *KeySet) JWKS() JWKS {
+	return JWKS{Keys: append([]JWK(nil), ks.jwks.Keys...)}
+}
+
+// Handler serves the JWKS. Verifiers may cache it for five minutes, so a
+// new key should be published that long before it signs anything.
+func (ks *KeySet) Handler() http.Handler {
+	body, _ := json.Marshal(ks.jwks)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		_, _ = w.Write(body)
+	})
+}
+
+func toJWK(pub *rsa.PublicKey) JWK {
+	n := base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+	e := base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	// The thumbprint hashes the required members in lexicographic order
+	thumb := sha256.Sum256([]byte(`{"e":"` + e + `","kty":"RSA","n":"` + n + `"}`))
+	return JWK{Kty: "RSA", Use: "sig", Alg: "RS256", Kid: base64.RawURLEncoding.EncodeToString(thumb[:]), N: n, E: e}
+}
+
+// ParsePrivateKey reads an RSA private key in PEM, PKCS #1 or PKCS #8.
+func ParsePrivateKey(pemBytes []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return nil, errors.New("keys: no PEM block")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("keys: %w", err)
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("keys: %T is not an RSA key", key)
+	}
+	return rsaKey, nil
+}
+
+// ParsePublicKey reads an RSA public key in PEM (PKIX), or the public half
+// of a private key, so a retired key file can be passed as it was.
+func ParsePublicKey(pemBytes []byte) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return nil, errors.New("keys: no PEM block")
+	}
+	if block.Type != "PUBLIC KEY" {
+		priv, err := ParsePrivateKey(pemBytes)
+		if err != nil {
+			return nil, err
+		}
+		return &priv.PublicKey, nil
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("keys: %w", err)
+	}
+	rsaKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("keys: %T is not an RSA key", key)
+	}
+	return rsaKey, nil
+}
+
+// PublicKey turns a published JWK back into a key, for verifiers.
+func (k JWK) PublicKey() (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, fmt.Errorf("keys: jwk %s: %w", k.Kid, err)
+	}
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, fmt.Errorf("keys: jwk %s: %w", k.Kid, err)
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+}
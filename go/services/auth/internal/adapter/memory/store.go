@@ -0,0 +1,150 @@
+// Package memory provides in-process adapters, so the auth service runs
+// in dev and tests with no database.
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"clean-code-cookbook/go/services/auth/internal/domain"
+	"github.com/google/uuid"
+)
+
+// CredentialRepository implements ports.CredentialRepository on a map
+// keyed by email. It is safe for concurrent use.
+type CredentialRepository struct {
+	mu    sync.RWMutex
+	creds map[string]domain.Credential
+}
+
+func NewCredentialRepository() *CredentialRepository {
+	return &CredentialRepository{creds: make(map[string]domain.Credential)}
+}
+
+func (r *CredentialRepository) Create(ctx context.Context, c domain.Credential) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.creds[c.Email]; ok {
+		return domain.ErrEmailTaken
+	}
+	r.creds[c.Email] = c
+	return nil
+}
+
+func (r *CredentialRepository) GetByEmail(ctx context.Context, email string) (domain.Credential, error) {
+	if err := ctx.Err(); err != nil {
+		return domain.Credential{}, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	c, ok := r.creds[email]
+	if !ok {
+		return domain.Credential{}, domain.ErrCredentialNotFound
+	}
+	return c, nil
+}
+
+func (r *CredentialRepository) SetPassword(ctx context.Context, c domain.Credential) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if taken, ok := r.creds[c.Email]; ok && taken.UserID != c.UserID {
+		return domain.ErrEmailTaken
+	}
+	for email, old := range r.creds {
+		if old.UserID == c.UserID {
+			c.CreatedAt = old.CreatedAt
+			delete(r.creds, email)
+		}
+	}
+	r.creds[c.Email] = c
+	return nil
+}
+
+// RefreshTokenRepository implements ports.RefreshTokenRepository on a
+// map. It is safe for concurrent use.
+type RefreshTokenRepository struct {
+	mu     sync.Mutex
+	tokens map[uuid.UUID]domain.RefreshToken
+}
+
+func NewRefreshTokenRepository() *RefreshTokenRepository {
+	return &RefreshTokenRepository{tokens: make(map[uuid.UUID]domain.RefreshToken)}
+}
+
+func (r *RefreshTokenRepository) Save(ctx context.Context, t domain.RefreshToken) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tokens[t.ID] = t
+	return nil
+}
+
+func (r *RefreshTokenRepository) Get(ctx context.Context, id uuid.UUID) (domain.RefreshToken, error) {
+	if err := ctx.Err(); err != nil {
+		return domain.RefreshToken{}, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t, ok := r.tokens[id]
+	if !ok {
+		return domain.RefreshToken{}, domain.ErrInvalidRefreshToken
+	}
+	return t, nil
+}
+
+func (r *RefreshTokenRepository) Use(ctx context.Context, id uuid.UUID, at time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t, ok := r.tokens[id]
+	if !ok {
+		return domain.ErrInvalidRefreshToken
+	}
+	if !t.UsedAt.IsZero() || !t.RevokedAt.IsZero() {
+		return domain.ErrRefreshTokenReused
+	}
+	t.UsedAt = at
+	r.tokens[id] = t
+	return nil
+}
+
+func (r *RefreshTokenRepository) RevokeFamily(ctx context.Context, familyID uuid.UUID, at time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, t := range r.tokens {
+		if t.FamilyID == familyID && t.RevokedAt.IsZero() {
+			t.RevokedAt = at
+			r.tokens[id] = t
+		}
+	}
+	return nil
+}
+
+func (r *RefreshTokenRepository) RevokeUser(ctx context.Context, userID uuid.UUID, at time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, t := range r.tokens {
+		if t.UserID == userID && t.RevokedAt.IsZero() {
+			t.RevokedAt = at
+			r.tokens[id] = t
+		}
+	}
+	return nil
+}
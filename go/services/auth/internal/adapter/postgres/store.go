@@ -0,0 +1,154 @@
+// Package postgres stores credentials and refresh tokens in PostgreSQL
+// through database/sql and the lib/pq driver.
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"clean-code-cookbook/go/services/auth/internal/domain"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// schema keeps only hashes: bcrypt for passwords, SHA-256 for refresh
+// token secrets.
+const schema = `
+CREATE TABLE IF NOT EXISTS credentials (
+    user_id       UUID PRIMARY KEY,
+    email         TEXT NOT NULL UNIQUE,
+    password_hash BYTEA NOT NULL,
+    created_at    TIMESTAMPTZ NOT NULL
+);
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id         UUID PRIMARY KEY,
+    family_id  UUID NOT NULL,
+    user_id    UUID NOT NULL REFERENCES credentials (user_id) ON DELETE CASCADE,
+    hash       BYTEA NOT NULL,
+    issued_at  TIMESTAMPTZ NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at    TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS refresh_tokens_family_idx ON refresh_tokens (family_id);
+CREATE INDEX IF NOT EXISTS refresh_tokens_user_idx ON refresh_tokens (user_id);`
+
+// uniqueViolation is the SQLSTATE of a duplicate key.
+const uniqueViolation = "23505"
+
+// Open connects to dsn and creates the schema if it is missing.
+func Open(ctx context.Context, dsn string) (*sql.DB, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open postgres: %w", err)
+	}
+	if _, err := db.ExecContext(ctx, schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("migrate auth: %w", err)
+	}
+	return db, nil
+}
+
+// CredentialRepository implements ports.CredentialRepository on the
+// credentials table.
+type CredentialRepository struct {
+	db *sql.DB
+}
+
+func NewCredentialRepository(db *sql.DB) *CredentialRepository {
+	return &CredentialRepository{db: db}
+}
+
+func (r *CredentialRepository) Create(ctx context.Context, c domain.Credential) error {
+	_, err := r.db.ExecContext(ctx, `INSERT INTO credentials (user_id, email, password_hash, created_at) VALUES ($1, $2, $3, $4)`,
+		c.UserID, c.Email, c.PasswordHash, c.CreatedAt)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		return domain.ErrEmailTaken
+	}
+	return err
+}
+
+func (r *CredentialRepository) GetByEmail(ctx context.Context, email string) (domain.Credential, error) {
+	var c domain.Credential
+	err := r.db.QueryRowContext(ctx, `SELECT user_id, email, password_hash, created_at FROM credentials WHERE email = $1`, email).
+		Scan(&c.UserID, &c.Email, &c.PasswordHash, &c.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Credential{}, domain.ErrCredentialNotFound
+	}
+	return c, err
+}
+
+// SetPassword keeps created_at of existing credentials.
+func (r *CredentialRepository) SetPassword(ctx context.Context, c domain.Credential) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO credentials (user_id, email, password_hash, created_at) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id) DO UPDATE SET email = EXCLUDED.email, password_hash = EXCLUDED.password_hash`,
+		c.UserID, c.Email, c.PasswordHash, c.CreatedAt)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		return domain.ErrEmailTaken
+	}
+	return err
+}
+
+// RefreshTokenRepository implements ports.RefreshTokenRepository on the
+// refresh_tokens table.
+type RefreshTokenRepository struct {
+	db *sql.DB
+}
+
+func NewRefreshTokenRepository(db *sql.DB) *RefreshTokenRepository {
+	return &RefreshTokenRepository{db: db}
+}
+
+func (r *RefreshTokenRepository) Save(ctx context.Context, t domain.RefreshToken) error {
+	_, err := r.db.ExecContext(ctx, `INSERT INTO refresh_tokens (id, family_id, user_id, hash, issued_at, expires_at) VALUES ($1, $2, $3, $4, $5, $6)`,
+		t.ID, t.FamilyID, t.UserID, t.Hash, t.IssuedAt, t.ExpiresAt)
+	return err
+}
+
+func (r *RefreshTokenRepository) Get(ctx context.Context, id uuid.UUID) (domain.RefreshToken, error) {
+	var t domain.RefreshToken
+	var usedAt, revokedAt sql.NullTime
+	err := r.db.QueryRowContext(ctx, `SELECT id, family_id, user_id, hash, issued_at, expires_at, used_at, revoked_at FROM refresh_tokens WHERE id = $1`, id).
+		Scan(&t.ID, &t.FamilyID, &t.UserID, &t.Hash, &t.IssuedAt, &t.ExpiresAt, &usedAt, &revokedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.RefreshToken{}, domain.ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return domain.RefreshToken{}, err
+	}
+	t.UsedAt, t.RevokedAt = usedAt.Time, revokedAt.Time
+	return t, nil
+}
+
+// Use only updates an unused, unrevoked row, so the database settles a
+// race between two refreshes.
+func (r *RefreshTokenRepository) Use(ctx context.Context, id uuid.UUID, at time.Time) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE refresh_tokens SET used_at = $2 WHERE id = $1 AND used_at IS NULL AND revoked_at IS NULL`, id, at)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return domain.ErrRefreshTokenReused
+	}
+	return nil
+}
+
+func (r *RefreshTokenRepository) RevokeFamily(ctx context.Context, familyID uuid.UUID, at time.Time) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE refresh_tokens SET revoked_at = $2 WHERE family_id = $1 AND revoked_at IS NULL`, familyID, at)
+	return err
+}
+
+func (r *RefreshTokenRepository) RevokeUser(ctx context.Context, userID uuid.UUID, at time.Time) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE refresh_tokens SET revoked_at = $2 WHERE user_id = $1 AND revoked_at IS NULL`, userID, at)
+	return err
+}
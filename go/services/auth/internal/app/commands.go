@@ -0,0 +1,201 @@
+package app
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"time"
+
+	"clean-code-cookbook/go/services/auth/internal/domain"
+	"clean-code-cookbook/go/services/auth/internal/ports"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// dummyHash is compared against when the email is unknown, so a sign-in
+// takes as long whether or not the address exists.
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("not-a-real-password"), bcrypt.DefaultCost)
+
+// RegisterCommand creates the credentials of a new user.
+type RegisterCommand struct {
+	Credentials ports.CredentialRepository
+	// Cost is the bcrypt cost; it defaults to bcrypt.DefaultCost.
+	Cost int
+	// Now stamps the credentials; it defaults to time.Now.
+	Now func() time.Time
+}
+
+// Execute registers email with password and returns the new user's
+// credentials.
+func (c *RegisterCommand) Execute(ctx context.Context, email, password string) (domain.Credential, error) {
+	// 1. Validate input
+	email, err := domain.NormalizeEmail(email)
+	if err != nil {
+		return domain.Credential{}, err
+	}
+	if err := domain.ValidatePassword(password); err != nil {
+		return domain.Credential{}, err
+	}
+
+	// 2. Hash and store
+	cost := c.Cost
+	if cost == 0 {
+		cost = bcrypt.DefaultCost
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), cost)
+	if err != nil {
+		return domain.Credential{}, fmt.Errorf("failed to hash password: %w", err)
+	}
+	cred := domain.Credential{UserID: uuid.New(), Email: email, PasswordHash: hash, CreatedAt: now(c.Now)}
+	if err := c.Credentials.Create(ctx, cred); err != nil {
+		return domain.Credential{}, fmt.Errorf("failed to save credentials: %w", err)
+	}
+	return cred, nil
+}
+
+// SetPasswordCommand stores a password another service hashed, such as
+// clean_go_system after a password reset, and signs the user out
+// everywhere.
+type SetPasswordCommand struct {
+	Credentials ports.CredentialRepository
+	Refresh     ports.RefreshTokenRepository
+	// Now stamps new credentials and the revocation; it defaults to
+	// time.Now.
+	Now func() time.Time
+}
+
+// Execute sets the bcrypt hash of userID, registering email for them if
+// they have no credentials yet, and revokes their refresh tokens, so a
+// stolen one does not outlive the old password.
+func (c *SetPasswordCommand) Execute(ctx context.Context, userID uuid.UUID, email string, hash []byte) error {
+	// 1. Validate input
+	email, err := domain.NormalizeEmail(email)
+	if err != nil {
+		return err
+	}
+	if _, err := bcrypt.Cost(hash); err != nil {
+		return domain.ErrInvalidPasswordHash
+	}
+
+	// 2. Store, then end every sign-in made with the old password
+	at := now(c.Now)
+	cred := domain.Credential{UserID: userID, Email: email, PasswordHash: hash, CreatedAt: at}
+	if err := c.Credentials.SetPassword(ctx, cred); err != nil {
+		return fmt.Errorf("failed to save credentials: %w", err)
+	}
+	if err := c.Refresh.RevokeUser(ctx, userID, at); err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+	return nil
+}
+
+// LoginCommand signs a user in with email and password.
+type LoginCommand struct {
+	Credentials ports.CredentialRepository
+	Tokens      *TokenIssuer
+}
+
+// Execute returns a token pair that starts a new refresh token family, or
+// domain.ErrInvalidCredentials.
+func (c *LoginCommand) Execute(ctx context.Context, email, password string) (domain.TokenPair, error) {
+	cred, err := c.Credentials.GetByEmail(ctx, normalizeOrKeep(email))
+	if errors.Is(err, domain.ErrCredentialNotFound) {
+		_ = bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+		return domain.TokenPair{}, domain.ErrInvalidCredentials
+	}
+	if err != nil {
+		return domain.TokenPair{}, fmt.Errorf("failed to load credentials: %w", err)
+	}
+	if bcrypt.CompareHashAndPassword(cred.PasswordHash, []byte(password)) != nil {
+		return domain.TokenPair{}, domain.ErrInvalidCredentials
+	}
+	return c.Tokens.issue(ctx, cred.UserID, uuid.New())
+}
+
+// RefreshCommand exchanges a refresh token for a new pair, rotating the
+// refresh token.
+type RefreshCommand struct {
+	Tokens *TokenIssuer
+}
+
+// Execute uses raw up and returns its successor. A token that was used
+// before revokes its family and fails with domain.ErrRefreshTokenReused.
+func (c *RefreshCommand) Execute(ctx context.Context, raw string) (domain.TokenPair, error) {
+	// 1. Only the holder of the secret gets any further, so a guessed ID
+	// cannot revoke someone else's family
+	token, err := redeemable(ctx, c.Tokens.Refresh, raw)
+	if err != nil {
+		return domain.TokenPair{}, err
+	}
+	at := now(c.Tokens.Now)
+	if !token.RevokedAt.IsZero() || !at.Before(token.ExpiresAt) {
+		return domain.TokenPair{}, domain.ErrInvalidRefreshToken
+	}
+
+	// 2. A second use means the token leaked: end the whole family
+	err = domain.ErrRefreshTokenReused
+	if token.UsedAt.IsZero() {
+		err = c.Tokens.Refresh.Use(ctx, token.ID, at)
+	}
+	if errors.Is(err, domain.ErrRefreshTokenReused) {
+		if revokeErr := c.Tokens.Refresh.RevokeFamily(context.WithoutCancel(ctx), token.FamilyID, at); revokeErr != nil {
+			err = errors.Join(err, fmt.Errorf("revoke family %s: %w", token.FamilyID, revokeErr))
+		}
+		return domain.TokenPair{}, err
+	}
+	if err != nil {
+		return domain.TokenPair{}, fmt.Errorf("failed to use refresh token: %w", err)
+	}
+
+	// 3. Hand out the successor in the same family
+	return c.Tokens.issue(ctx, token.UserID, token.FamilyID)
+}
+
+// LogoutCommand ends a sign-in by revoking its refresh token family.
+type LogoutCommand struct {
+	Refresh ports.RefreshTokenRepository
+	// Now stamps the revocation; it defaults to time.Now.
+	Now func() time.Time
+}
+
+// Execute revokes the family of raw. Access tokens already handed out
+// stay valid until they expire.
+func (c *LogoutCommand) Execute(ctx context.Context, raw string) error {
+	token, err := redeemable(ctx, c.Refresh, raw)
+	if err != nil {
+		return err
+	}
+	if err := c.Refresh.RevokeFamily(ctx, token.FamilyID, now(c.Now)); err != nil {
+		return fmt.Errorf("failed to revoke family %s: %w", token.FamilyID, err)
+	}
+	return nil
+}
+
+// redeemable loads the token raw names and checks its secret.
+func redeemable(ctx context.Context, repo ports.RefreshTokenRepository, raw string) (domain.RefreshToken, error) {
+	id, secret, err := parseRefreshToken(raw)
+	if err != nil {
+		return domain.RefreshToken{}, err
+	}
+	token, err := repo.Get(ctx, id)
+	if errors.Is(err, domain.ErrInvalidRefreshToken) {
+		return domain.RefreshToken{}, err
+	}
+	if err != nil {
+		return domain.RefreshToken{}, fmt.Errorf("failed to load refresh token: %w", err)
+	}
+	if subtle.ConstantTimeCompare(hashSecret(secret), token.Hash) != 1 {
+		return domain.RefreshToken{}, domain.ErrInvalidRefreshToken
+	}
+	return token, nil
+}
+
+// normalizeOrKeep looks malformed addresses up as typed; they match
+// nothing.
+func normalizeOrKeep(email string) string {
+	if normalized, err := domain.NormalizeEmail(email); err == nil {
+		return normalized
+	}
+	return email
+}
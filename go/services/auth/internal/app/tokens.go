@@ -0,0 +1,99 @@
+package app
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"clean-code-cookbook/go/pkg/jwt"
+	"clean-code-cookbook/go/services/auth/internal/domain"
+	"clean-code-cookbook/go/services/auth/internal/ports"
+	"github.com/google/uuid"
+)
+
+// TokenIssuer hands out token pairs: a short-lived access token, a JWT
+// other services verify offline against the published keys, and a
+// long-lived opaque refresh token that only this service can redeem.
+type TokenIssuer struct {
+	Refresh ports.RefreshTokenRepository
+	Signer  ports.Signer
+	// Issuer and Audience go into every access token's iss and aud.
+	Issuer   string
+	Audience string
+	// AccessTTL and RefreshTTL are the two tokens' lifetimes.
+	AccessTTL  time.Duration
+	RefreshTTL time.Duration
+	// Now stamps tokens; it defaults to time.Now.
+	Now func() time.Time
+}
+
+// issue signs an access token for userID and stores the next refresh
+// token of familyID.
+func (i *TokenIssuer) issue(ctx context.Context, userID, familyID uuid.UUID) (domain.TokenPair, error) {
+	at := now(i.Now)
+	access, err := i.Signer.Sign(jwt.Claims{
+		Issuer:    i.Issuer,
+		Subject:   userID.String(),
+		Audience:  jwt.Audience{i.Audience},
+		IssuedAt:  at.Unix(),
+		NotBefore: at.Unix(),
+		ExpiresAt: at.Add(i.AccessTTL).Unix(),
+	})
+	if err != nil {
+		return domain.TokenPair{}, fmt.Errorf("failed to sign access token: %w", err)
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return domain.TokenPair{}, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+	refresh := domain.RefreshToken{
+		ID:        uuid.New(),
+		FamilyID:  familyID,
+		UserID:    userID,
+		Hash:      hashSecret(secret),
+		IssuedAt:  at,
+		ExpiresAt: at.Add(i.RefreshTTL),
+	}
+	if err := i.Refresh.Save(ctx, refresh); err != nil {
+		return domain.TokenPair{}, fmt.Errorf("failed to save refresh token: %w", err)
+	}
+	return domain.TokenPair{
+		AccessToken:  access,
+		RefreshToken: refresh.ID.String() + "." + base64.RawURLEncoding.EncodeToString(secret),
+		ExpiresIn:    i.AccessTTL,
+	}, nil
+}
+
+// parseRefreshToken splits "<id>.<secret>".
+func parseRefreshToken(raw string) (uuid.UUID, []byte, error) {
+	idPart, secretPart, ok := strings.Cut(raw, ".")
+	if !ok {
+		return uuid.Nil, nil, domain.ErrInvalidRefreshToken
+	}
+	id, err := uuid.Parse(idPart)
+	if err != nil {
+		return uuid.Nil, nil, domain.ErrInvalidRefreshToken
+	}
+	secret, err := base64.RawURLEncoding.DecodeString(secretPart)
+	if err != nil || len(secret) != 32 {
+		return uuid.Nil, nil, domain.ErrInvalidRefreshToken
+	}
+	return id, secret, nil
+}
+
+func hashSecret(secret []byte) []byte {
+	sum := sha256.Sum256(secret)
+	return sum[:]
+}
+
+func now(clock func() time.Time) time.Time {
+	if clock == nil {
+		return time.Now()
+	}
+	return clock()
+}
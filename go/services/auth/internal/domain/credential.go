@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Password length bounds; bcrypt ignores everything past 72 bytes.
+const (
+	MinPasswordLength = 8
+	MaxPasswordLength = 72
+)
+
+// Credential is what a user signs in with. The auth service owns it; other
+// services only ever see the user ID, as the subject of a token.
+type Credential struct {
+	UserID       uuid.UUID
+	Email        string
+	PasswordHash []byte
+	CreatedAt    time.Time
+}
+
+// NormalizeEmail lowercases a bare address such as "Alice@Example.com",
+// or fails with ErrInvalidEmail.
+func NormalizeEmail(email string) (string, error) {
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != strings.TrimSpace(email) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidEmail, email)
+	}
+	return strings.ToLower(addr.Address), nil
+}
+
+// ValidatePassword checks a new password's length.
+func ValidatePassword(password string) error {
+	if len(password) < MinPasswordLength || len(password) > MaxPasswordLength {
+		return ErrWeakPassword
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+package domain
+
+import "errors"
+
+var (
+	// ErrInvalidCredentials is returned for an unknown email or a wrong
+	// password alike, so callers cannot probe which addresses exist.
+	ErrInvalidCredentials = errors.New("invalid email or password")
+	// ErrCredentialNotFound is returned by CredentialRepository
+	// implementations for an unknown email.
+	ErrCredentialNotFound = errors.New("credential not found")
+	// ErrEmailTaken is returned by CredentialRepository.Create for an
+	// address that already has credentials.
+	ErrEmailTaken = errors.New("email already registered")
+	// ErrInvalidEmail and ErrWeakPassword are returned when registering.
+	ErrInvalidEmail = errors.New("invalid email")
+	ErrWeakPassword = errors.New("password must be 8-72 bytes")
+	// ErrInvalidPasswordHash is returned when another service sets a
+	// password hash that is not bcrypt.
+	ErrInvalidPasswordHash = errors.New("password hash is not bcrypt")
+	// ErrInvalidRefreshToken is returned for a refresh token that is
+	// malformed, unknown, expired or revoked.
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	// ErrRefreshTokenReused is returned when a refresh token is presented
+	// a second time. Its whole family is revoked by then.
+	ErrRefreshTokenReused = errors.New("refresh token reused")
+)
@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RefreshToken is the stored half of a refresh token: the client holds
+// ID and a secret, the store only the secret's hash.
+//
+// Tokens rotate: every refresh uses its token up and issues the next one
+// in the same Family. A used token that comes back means it was copied,
+// so the whole family is revoked and the thief and the user alike must
+// sign in again.
+type RefreshToken struct {
+	ID        uuid.UUID
+	FamilyID  uuid.UUID
+	UserID    uuid.UUID
+	Hash      []byte
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+	// UsedAt is set once the token was exchanged for its successor.
+	UsedAt time.Time
+	// RevokedAt is set when the family was revoked, by logout or reuse.
+	RevokedAt time.Time
+}
+
+// Usable reports whether the token may still be exchanged at now.
+func (t RefreshToken) Usable(now time.Time) bool {
+	return t.UsedAt.IsZero() && t.RevokedAt.IsZero() && now.Before(t.ExpiresAt)
+}
+
+// TokenPair is what a sign-in or a refresh hands out.
+type TokenPair struct {
+	AccessToken  string
+	RefreshToken string
+	// ExpiresIn is the access token's lifetime.
+	ExpiresIn time.Duration
+}
@@ -0,0 +1,19 @@
+package ports
+
+import (
+	"context"
+
+	"clean-code-cookbook/go/services/auth/internal/domain"
+)
+
+// CredentialRepository is a port for storing sign-in credentials.
+type CredentialRepository interface {
+	// Create returns domain.ErrEmailTaken if the email has credentials.
+	Create(ctx context.Context, c domain.Credential) error
+	// GetByEmail returns domain.ErrCredentialNotFound for an unknown email.
+	GetByEmail(ctx context.Context, email string) (domain.Credential, error)
+	// SetPassword stores c's email and hash under c.UserID, creating the
+	// credentials if the user has none. It returns domain.ErrEmailTaken
+	// if another user has the email.
+	SetPassword(ctx context.Context, c domain.Credential) error
+}
@@ -0,0 +1,32 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"clean-code-cookbook/go/pkg/jwt"
+	"clean-code-cookbook/go/services/auth/internal/domain"
+	"github.com/google/uuid"
+)
+
+// RefreshTokenRepository is a port for storing refresh tokens.
+type RefreshTokenRepository interface {
+	Save(ctx context.Context, t domain.RefreshToken) error
+	// Get returns domain.ErrInvalidRefreshToken for an unknown id.
+	Get(ctx context.Context, id uuid.UUID) (domain.RefreshToken, error)
+	// Use marks the token used at at, or returns
+	// domain.ErrRefreshTokenReused if it was used or revoked already.
+	// Checking and marking are one step, so of two refreshes racing with
+	// one token only one wins.
+	Use(ctx context.Context, id uuid.UUID, at time.Time) error
+	// RevokeFamily revokes every token of familyID that is not yet.
+	RevokeFamily(ctx context.Context, familyID uuid.UUID, at time.Time) error
+	// RevokeUser revokes every token of userID that is not yet.
+	RevokeUser(ctx context.Context, userID uuid.UUID, at time.Time) error
+}
+
+// Signer is a port for signing access tokens with a key whose public half
+// other services can fetch.
+type Signer interface {
+	Sign(claims jwt.Claims) (string, error)
+}
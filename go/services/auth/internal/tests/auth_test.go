@@ -0,0 +1,369 @@
+package tests
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"clean-code-cookbook/go/pkg/jwt"
+	"clean-code-cookbook/go/pkg/signing"
+	httpadapter "clean-code-cookbook/go/services/auth/internal/adapter/http"
+	"clean-code-cookbook/go/services/auth/internal/adapter/keys"
+	"clean-code-cookbook/go/services/auth/internal/adapter/memory"
+	"clean-code-cookbook/go/services/auth/internal/app"
+	"clean-code-cookbook/go/services/auth/internal/domain"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	issuer   = "https://auth.test"
+	audience = "cookbook"
+	email    = "ada@example.com"
+	password = "correct horse battery"
+)
+
+// authService wires the commands to in-memory stores and a fresh key.
+type authService struct {
+	keys        *keys.KeySet
+	refresh     *memory.RefreshTokenRepository
+	register    *app.RegisterCommand
+	login       *app.LoginCommand
+	rotate      *app.RefreshCommand
+	logout      *app.LogoutCommand
+	setPassword *app.SetPasswordCommand
+}
+
+func newAuthService(t *testing.T) *authService {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	credentials := memory.NewCredentialRepository()
+	s := &authService{keys: keys.NewKeySet(key), refresh: memory.NewRefreshTokenRepository()}
+	tokens := &app.TokenIssuer{
+		Refresh:    s.refresh,
+		Signer:     s.keys,
+		Issuer:     issuer,
+		Audience:   audience,
+		AccessTTL:  15 * time.Minute,
+		RefreshTTL: time.Hour,
+	}
+	s.register = &app.RegisterCommand{Credentials: credentials, Cost: bcrypt.MinCost}
+	s.login = &app.LoginCommand{Credentials: credentials, Tokens: tokens}
+	s.rotate = &app.RefreshCommand{Tokens: tokens}
+	s.logout = &app.LogoutCommand{Refresh: s.refresh}
+	s.setPassword = &app.SetPasswordCommand{Credentials: credentials, Refresh: s.refresh}
+	return s
+}
+
+// signIn registers the test user and logs them in.
+func (s *authService) signIn(t *testing.T) (domain.Credential, domain.TokenPair) {
+	t.Helper()
+	cred, err := s.register.Execute(context.Background(), email, password)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	pair, err := s.login.Execute(context.Background(), "  ADA@example.com ", password)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	return cred, pair
+}
+
+func quietLogger() *log.Logger {
+	return log.New(io.Discard, "", 0)
+}
+
+func TestLogin_AccessTokenVerifiesAgainstPublishedKeys(t *testing.T) {
+	// Arrange
+	s := newAuthService(t)
+	cred, pair := s.signIn(t)
+	server := httptest.NewServer(s.keys.Handler())
+	defer server.Close()
+
+	// Act: verify the way another service would, knowing only the JWKS URL
+	res, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	defer res.Body.Close()
+	var set keys.JWKS
+	if err := json.NewDecoder(res.Body).Decode(&set); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	token, err := jwt.Parse(pair.AccessToken)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+
+	// Assert
+	if len(set.Keys) != 1 || set.Keys[0].Kid != token.Header.Kid {
+		t.Fatalf("Expected the JWKS to publish key '%s', but got %+v", token.Header.Kid, set.Keys)
+	}
+	pub, err := set.Keys[0].PublicKey()
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if err := token.VerifyRS256(pub); err != nil {
+		t.Fatalf("Expected the signature to verify, but got: %v", err)
+	}
+	if err := token.Claims.Validate(jwt.Expect{Issuer: issuer, Audience: audience, Now: time.Now()}); err != nil {
+		t.Errorf("Expected valid claims, but got: %v", err)
+	}
+	if token.Claims.Subject != cred.UserID.String() {
+		t.Errorf("Expected subject '%s', but got '%s'", cred.UserID, token.Claims.Subject)
+	}
+}
+
+func TestKeySet_PublishesRetiredKeys(t *testing.T) {
+	// Arrange
+	active, _ := rsa.GenerateKey(rand.Reader, 2048)
+	retired, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+	// Act
+	set := keys.NewKeySet(active, &retired.PublicKey).JWKS()
+
+	// Assert
+	if len(set.Keys) != 2 {
+		t.Fatalf("Expected 2 published keys, but got %d", len(set.Keys))
+	}
+	if set.Keys[0].Kid == set.Keys[1].Kid {
+		t.Errorf("Expected distinct key IDs, but both were '%s'", set.Keys[0].Kid)
+	}
+}
+
+func TestLogin_RejectsWrongPasswordAndUnknownEmailAlike(t *testing.T) {
+	// Arrange
+	s := newAuthService(t)
+	s.signIn(t)
+
+	// Act
+	_, wrongPassword := s.login.Execute(context.Background(), email, "not the password")
+	_, unknownEmail := s.login.Execute(context.Background(), "nobody@example.com", password)
+
+	// Assert
+	if !errors.Is(wrongPassword, domain.ErrInvalidCredentials) {
+		t.Errorf("Expected error '%v', but got '%v'", domain.ErrInvalidCredentials, wrongPassword)
+	}
+	if !errors.Is(unknownEmail, domain.ErrInvalidCredentials) {
+		t.Errorf("Expected error '%v', but got '%v'", domain.ErrInvalidCredentials, unknownEmail)
+	}
+}
+
+func TestRegister_RejectsDuplicateEmailAndWeakPassword(t *testing.T) {
+	// Arrange
+	s := newAuthService(t)
+	s.signIn(t)
+
+	// Act
+	_, duplicate := s.register.Execute(context.Background(), "Ada@Example.com", password)
+	_, weak := s.register.Execute(context.Background(), "grace@example.com", "short")
+
+	// Assert
+	if !errors.Is(duplicate, domain.ErrEmailTaken) {
+		t.Errorf("Expected error '%v', but got '%v'", domain.ErrEmailTaken, duplicate)
+	}
+	if !errors.Is(weak, domain.ErrWeakPassword) {
+		t.Errorf("Expected error '%v', but got '%v'", domain.ErrWeakPassword, weak)
+	}
+}
+
+func TestRefresh_RotatesTheRefreshToken(t *testing.T) {
+	// Arrange
+	s := newAuthService(t)
+	_, first := s.signIn(t)
+
+	// Act
+	second, err := s.rotate.Execute(context.Background(), first.RefreshToken)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	third, err := s.rotate.Execute(context.Background(), second.RefreshToken)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected the successor to be redeemable, but got: %v", err)
+	}
+	if second.RefreshToken == first.RefreshToken || third.RefreshToken == second.RefreshToken {
+		t.Errorf("Expected every refresh to hand out a new refresh token")
+	}
+}
+
+func TestRefresh_ReuseRevokesTheFamily(t *testing.T) {
+	// Arrange: an attacker replays a token the client has already rotated
+	s := newAuthService(t)
+	_, stolen := s.signIn(t)
+	current, err := s.rotate.Execute(context.Background(), stolen.RefreshToken)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+
+	// Act
+	_, reuse := s.rotate.Execute(context.Background(), stolen.RefreshToken)
+	_, legit := s.rotate.Execute(context.Background(), current.RefreshToken)
+
+	// Assert
+	if !errors.Is(reuse, domain.ErrRefreshTokenReused) {
+		t.Errorf("Expected error '%v', but got '%v'", domain.ErrRefreshTokenReused, reuse)
+	}
+	if !errors.Is(legit, domain.ErrInvalidRefreshToken) {
+		t.Errorf("Expected the rest of the family to be revoked, but got '%v'", legit)
+	}
+}
+
+func TestRefresh_RejectsForgedSecretWithoutRevoking(t *testing.T) {
+	// Arrange
+	s := newAuthService(t)
+	_, pair := s.signIn(t)
+	id, _, _ := strings.Cut(pair.RefreshToken, ".")
+
+	// Act
+	_, forged := s.rotate.Execute(context.Background(), id+".AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA")
+	_, legit := s.rotate.Execute(context.Background(), pair.RefreshToken)
+
+	// Assert
+	if !errors.Is(forged, domain.ErrInvalidRefreshToken) {
+		t.Errorf("Expected error '%v', but got '%v'", domain.ErrInvalidRefreshToken, forged)
+	}
+	if legit != nil {
+		t.Errorf("Expected the real token to still work, but got: %v", legit)
+	}
+}
+
+func TestRefresh_RejectsExpiredToken(t *testing.T) {
+	// Arrange
+	s := newAuthService(t)
+	_, pair := s.signIn(t)
+	s.rotate.Tokens.Now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+
+	// Act
+	_, err := s.rotate.Execute(context.Background(), pair.RefreshToken)
+
+	// Assert
+	if !errors.Is(err, domain.ErrInvalidRefreshToken) {
+		t.Errorf("Expected error '%v', but got '%v'", domain.ErrInvalidRefreshToken, err)
+	}
+}
+
+func TestLogout_RevokesTheRefreshToken(t *testing.T) {
+	// Arrange
+	s := newAuthService(t)
+	_, pair := s.signIn(t)
+
+	// Act
+	if err := s.logout.Execute(context.Background(), pair.RefreshToken); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	_, err := s.rotate.Execute(context.Background(), pair.RefreshToken)
+
+	// Assert
+	if !errors.Is(err, domain.ErrInvalidRefreshToken) {
+		t.Errorf("Expected error '%v', but got '%v'", domain.ErrInvalidRefreshToken, err)
+	}
+}
+
+func TestHTTPHandler_SignInFlow(t *testing.T) {
+	// Arrange
+	s := newAuthService(t)
+	mux := http.NewServeMux()
+	httpadapter.NewHandler(s.register, s.login, s.rotate, s.logout, quietLogger()).Register(mux)
+	post := func(path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, path, strings.NewReader(body)))
+		return rec
+	}
+	creds := `{"email":"` + email + `","password":"` + password + `"}`
+
+	// Act
+	registered := post("/register", creds)
+	duplicate := post("/register", creds)
+	weak := post("/register", `{"email":"grace@example.com","password":"short"}`)
+	wrong := post("/login", `{"email":"`+email+`","password":"nope nope nope"}`)
+	login := post("/login", creds)
+	var tokens struct {
+		AccessToken  string `json:"access_token"`
+		TokenType    string `json:"token_type"`
+		ExpiresIn    int64  `json:"expires_in"`
+		RefreshToken string `json:"refresh_token"`
+	}
+	_ = json.NewDecoder(login.Body).Decode(&tokens)
+	refresh := `{"refresh_token":"` + tokens.RefreshToken + `"}`
+	rotated := post("/refresh", refresh)
+	reused := post("/refresh", refresh)
+
+	// Assert
+	for _, c := range []struct {
+		name string
+		got  int
+		want int
+	}{
+		{"register", registered.Code, http.StatusCreated},
+		{"duplicate register", duplicate.Code, http.StatusConflict},
+		{"weak password", weak.Code, http.StatusUnprocessableEntity},
+		{"wrong password", wrong.Code, http.StatusUnauthorized},
+		{"login", login.Code, http.StatusOK},
+		{"refresh", rotated.Code, http.StatusOK},
+		{"reused refresh", reused.Code, http.StatusUnauthorized},
+	} {
+		if c.got != c.want {
+			t.Errorf("%s: expected status %d, but got %d", c.name, c.want, c.got)
+		}
+	}
+	if tokens.TokenType != "Bearer" || tokens.ExpiresIn != 900 || tokens.AccessToken == "" {
+		t.Errorf("Expected a Bearer token valid for 900s, but got %+v", tokens)
+	}
+	if got := login.Header().Get("Cache-Control"); got != "no-store" {
+		t.Errorf("Expected Cache-Control 'no-store', but got '%s'", got)
+	}
+}
+
+func TestCredentialsHandler_SetsTheLoginPasswordAndSignsOut(t *testing.T) {
+	// Arrange
+	s := newAuthService(t)
+	cred, pair := s.signIn(t)
+	callers := signing.NewKeyring(signing.Key{ID: "users", Secret: []byte("shared secret")})
+	mux := http.NewServeMux()
+	httpadapter.NewCredentialsHandler(s.setPassword, callers, quietLogger()).Register(mux)
+	hash, err := bcrypt.GenerateFromPassword([]byte("a brand new password"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	body, _ := json.Marshal(map[string]any{"email": email, "password_hash": hash})
+	put := func(sign bool) int {
+		req := httptest.NewRequest(http.MethodPut, "/credentials/"+cred.UserID.String(), strings.NewReader(string(body)))
+		if sign {
+			callers.SignRequest(req, body)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// Act
+	unsigned := put(false)
+	signed := put(true)
+
+	// Assert
+	if unsigned != http.StatusUnauthorized || signed != http.StatusNoContent {
+		t.Fatalf("Expected 401 unsigned and 204 signed, but got %d and %d", unsigned, signed)
+	}
+	if _, err := s.login.Execute(context.Background(), email, password); !errors.Is(err, domain.ErrInvalidCredentials) {
+		t.Errorf("Expected the old password to fail with '%v', but got '%v'", domain.ErrInvalidCredentials, err)
+	}
+	if _, err := s.login.Execute(context.Background(), email, "a brand new password"); err != nil {
+		t.Errorf("Expected the new password to sign in, but got: %v", err)
+	}
+	if _, err := s.rotate.Execute(context.Background(), pair.RefreshToken); !errors.Is(err, domain.ErrInvalidRefreshToken) {
+		t.Errorf("Expected the old refresh token to fail with '%v', but got '%v'", domain.ErrInvalidRefreshToken, err)
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"clean-code-cookbook/go/services/catalog/internal/adapter/elasticsearch"
+	"clean-code-cookbook/go/services/catalog/internal/adapter/memory"
+	"clean-code-cookbook/go/services/catalog/internal/adapter/sqlite"
+	"clean-code-cookbook/go/services/catalog/internal/app"
+	"clean-code-cookbook/go/services/catalog/internal/domain"
+	"clean-code-cookbook/go/services/catalog/internal/ports"
+)
+
+// catalogApp is what every command shares: the profile (APP_ENV, default dev),
+// its local product store and the read models its writes are applied to.
+type catalogApp struct {
+	profile string
+	store   store
+	// index is the Elasticsearch/OpenSearch index (ELASTICSEARCH_URL) that
+	// large catalogs search instead of the store; nil without one.
+	index *elasticsearch.Index
+	// events receives the product events of every write: the index's,
+	// when there is one.
+	events ports.ProductEventSink
+	// retention is how long deleted products are kept before purge
+	// removes them: CATALOG_DELETED_RETENTION, default 720h.
+	retention time.Duration
+}
+
+func bootstrap(ctx context.Context) (*catalogApp, error) {
+	a := &catalogApp{profile: env("APP_ENV", "dev"), retention: 30 * 24 * time.Hour}
+	if raw := os.Getenv("CATALOG_DELETED_RETENTION"); raw != "" {
+		retention, err := time.ParseDuration(raw)
+		if err != nil || retention <= 0 {
+			return nil, fmt.Errorf("CATALOG_DELETED_RETENTION must be a positive duration, got %q", raw)
+		}
+		a.retention = retention
+	}
+	var err error
+	if a.store, err = productStore(ctx, a.profile); err != nil {
+		return nil, err
+	}
+	if esURL := os.Getenv("ELASTICSEARCH_URL"); esURL != "" {
+		if a.index, err = searchIndex(ctx, esURL); err != nil {
+			return nil, err
+		}
+		a.events = a.index
+	}
+	return a, nil
+}
+
+// searchIndex prepares the search index (ELASTICSEARCH_INDEX, default
+// "products"). It holds what the catalog's writes have applied to it, so
+// products written before it existed need seeding again.
+func searchIndex(ctx context.Context, baseURL string) (*elasticsearch.Index, error) {
+	name := os.Getenv("ELASTICSEARCH_INDEX")
+	if name == "" {
+		name = "products"
+	}
+	index := elasticsearch.NewIndex(baseURL, name, &http.Client{Timeout: 5 * time.Second})
+	if err := index.EnsureMapping(ctx); err != nil {
+		return nil, err
+	}
+	return index, nil
+}
+
+// purge removes the products deleted longer than the retention ago.
+func (a *catalogApp) purge(ctx context.Context) error {
+	purge := app.PurgeDeletedProductsCommand{ProductDeleter: a.store, Retention: a.retention}
+	n, err := purge.Execute(ctx)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		log.Printf("purged %d deleted product(s)", n)
+	}
+	return nil
+}
+
+// close releases the store, if it holds anything.
+func (a *catalogApp) close() {
+	if c, ok := a.store.(io.Closer); ok {
+		_ = c.Close()
+	}
+}
+
+// sampleProducts are the test profile's products and what seed loads
+// without a -file, each with sampleStock in stock.
+var sampleProducts = []domain.Product{
+	{ID: "sku-1", Name: "Clean Code", Price: 39.99},
+	{ID: "sku-2", Name: "Refactoring", Price: 44.50},
+}
+
+const sampleStock = 100
+
+// store is what a profile's local product storage provides.
+type store interface {
+	ports.ProductFetcher
+	ports.ProductSearcher
+	ports.ProductDeleter
+	ports.StockKeeper
+}
+
+// productStore picks the product storage for a profile: dev keeps its
+// products in a local SQLite file (CATALOG_DB) that seed fills, test the
+// sample products in memory. Staging and prod look products up in the
+// upstream catalog API (CATALOG_UPSTREAM_URL, see runServe) and keep the
+// SQLite file as the stale copy served while it is down, and as the stock.
+func productStore(ctx context.Context, profile string) (store, error) {
+	switch profile {
+	case "dev":
+		return sqlite.Open(ctx, env("CATALOG_DB", "file:catalog.db"))
+	case "test":
+		products := memory.NewProductFetcher(sampleProducts...)
+		for _, p := range sampleProducts {
+			if err := products.Restock(ctx, p.ID, sampleStock); err != nil {
+				return nil, err
+			}
+		}
+		return products, nil
+	case "staging", "prod":
+		if os.Getenv("CATALOG_UPSTREAM_URL") == "" {
+			return nil, fmt.Errorf("CATALOG_UPSTREAM_URL is required for APP_ENV %q", profile)
+		}
+		return sqlite.Open(ctx, env("CATALOG_DB", "file:catalog.db"))
+	default:
+		return nil, fmt.Errorf("unknown APP_ENV %q (want dev, test, staging or prod)", profile)
+	}
+}
@@ -1,12 +1,48 @@
 package main
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+)
+
+// command is one subcommand of the catalog binary.
+type command struct {
+	name    string
+	summary string
+	run     func(ctx context.Context, args []string) error
+}
+
+var commands = []command{
+	{name: "serve", summary: "run the read API until interrupted", run: runServe},
+	{name: "seed", summary: "load fixture products into the dev store", run: runSeed},
+	{name: "purge", summary: "remove products deleted longer than the retention ago", run: runPurge},
+}
 
 func main() {
-	fmt.Println("This is the entrypoint for the catalog service.")
-	// In a real application, you would:
-	// 1. Initialize configuration (e.g., from environment variables).
-	// 2. Create concrete adapters (e.g., a real HTTP ProductFetcher).
-	// 3. Instantiate the application use cases (e.g., FetchProductQuery).
-	// 4. Start a server (e.g., HTTP, gRPC) and wire it up to the use cases.
-}
\ No newline at end of file
+	// `go run ./cmd/catalog` with no arguments keeps its old meaning: serve.
+	name, args := "serve", os.Args[1:]
+	if len(args) > 0 {
+		name, args = args[0], args[1:]
+	}
+
+	for _, c := range commands {
+		if c.name == name {
+			if err := c.run(context.Background(), args); err != nil {
+				log.Fatalf("%s: %v", name, err)
+			}
+			return
+		}
+	}
+
+	usage()
+	os.Exit(2)
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: %s <command> [flags]\n\ncommands:\n", os.Args[0])
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %-8s %s\n", c.name, c.summary)
+	}
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"context"
+	"flag"
+)
+
+// runPurge removes the products deleted longer than
+// CATALOG_DELETED_RETENTION ago, as serve does on start, for stores that
+// outlive one process and want it on a schedule.
+func runPurge(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("purge", flag.ExitOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	a, err := bootstrap(ctx)
+	if err != nil {
+		return err
+	}
+	defer a.close()
+	return a.purge(ctx)
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"clean-code-cookbook/go/services/catalog/internal/app"
+	"clean-code-cookbook/go/services/catalog/internal/domain"
+	"clean-code-cookbook/go/services/catalog/internal/ports"
+)
+
+type fixtureProduct struct {
+	ID    string  `json:"id"`
+	Name  string  `json:"name"`
+	Price float64 `json:"price"`
+	Stock int     `json:"stock"`
+}
+
+// seedProduct is a product to seed and how many of it are in stock.
+type seedProduct struct {
+	domain.Product
+	Stock int
+}
+
+// runSeed writes fixture products, and their stock, to the profile's
+// store, and applies them to the search index when there is one. Products
+// that already exist are overwritten with the fixture's values.
+func runSeed(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("seed", flag.ExitOnError)
+	file := fs.String("file", "", `JSON fixture file, [{"id", "name", "price", "stock"}] (defaults to the sample products)`)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	products, err := readFixtures(*file)
+	if err != nil {
+		return err
+	}
+
+	a, err := bootstrap(ctx)
+	if err != nil {
+		return err
+	}
+	defer a.close()
+	saver, ok := a.store.(ports.ProductSaver)
+	if !ok {
+		return fmt.Errorf("the %s profile's store cannot be seeded", a.profile)
+	}
+	save := app.SaveProductCommand{ProductSaver: saver, Events: a.events}
+	for _, p := range products {
+		if err := save.Execute(ctx, p.Product); err != nil {
+			return fmt.Errorf("seed %s: %w", p.ID, err)
+		}
+		if err := a.store.Restock(ctx, p.ID, p.Stock); err != nil {
+			return fmt.Errorf("stock %s: %w", p.ID, err)
+		}
+		log.Printf("seed: saved %s, %d in stock", p.ID, p.Stock)
+	}
+	return nil
+}
+
+func readFixtures(path string) ([]seedProduct, error) {
+	if path == "" {
+		products := make([]seedProduct, len(sampleProducts))
+		for i, p := range sampleProducts {
+			products[i] = seedProduct{Product: p, Stock: sampleStock}
+		}
+		return products, nil
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var fixtures []fixtureProduct
+	if err := json.Unmarshal(raw, &fixtures); err != nil {
+		return nil, fmt.Errorf("parse fixtures: %w", err)
+	}
+	// Fixtures hold the same invariants as any other product; the index
+	// says which one broke them, as IDs may be missing or malformed.
+	at := time.Now()
+	products := make([]seedProduct, len(fixtures))
+	for i, f := range fixtures {
+		p, err := domain.NewProduct(f.ID, f.Name, f.Price, at)
+		if err == nil {
+			err = domain.ValidateStock(f.Stock)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("fixture %d (%q): %w", i, f.ID, err)
+		}
+		products[i] = seedProduct{Product: p, Stock: f.Stock}
+	}
+	return products, nil
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"clean-code-cookbook/go/pkg/breaker"
+	"clean-code-cookbook/go/pkg/cache"
+	"clean-code-cookbook/go/pkg/edgetoken"
+	"clean-code-cookbook/go/pkg/tlsconfig"
+	"clean-code-cookbook/go/services/catalog/internal/adapter/cached"
+	"clean-code-cookbook/go/services/catalog/internal/adapter/chaos"
+	"clean-code-cookbook/go/services/catalog/internal/adapter/composite"
+	"clean-code-cookbook/go/services/catalog/internal/adapter/fallback"
+	"clean-code-cookbook/go/services/catalog/internal/adapter/hedged"
+	httpadapter "clean-code-cookbook/go/services/catalog/internal/adapter/http"
+	"clean-code-cookbook/go/services/catalog/internal/app"
+	"clean-code-cookbook/go/services/catalog/internal/domain"
+	"clean-code-cookbook/go/services/catalog/internal/ports"
+)
+
+func runServe(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// 1. Create concrete adapters. Products soft-deleted longer than the
+	// retention ago are purged from the store on start, as purge does.
+	a, err := bootstrap(ctx)
+	if err != nil {
+		return err
+	}
+	defer a.close()
+	if err := a.purge(ctx); err != nil {
+		return err
+	}
+	// Staging can inject faults into lookups (CATALOG_CHAOS_LATENCY as a
+	// duration, CATALOG_CHAOS_ERROR_RATE in [0, 1]); the upstream gets them
+	// when there is one, the store otherwise.
+	var fetcher ports.ProductFetcher = a.store
+	// With an upstream catalog API (CATALOG_UPSTREAM_URL), the profile's
+	// store becomes the stale local copy served while the upstream is down.
+	if upstreamURL := os.Getenv("CATALOG_UPSTREAM_URL"); upstreamURL != "" {
+		client, err := upstreamClient()
+		if err != nil {
+			return fmt.Errorf("upstream TLS: %w", err)
+		}
+		httpFetcher := httpadapter.NewProductFetcher(upstreamURL, client)
+		httpFetcher.Breaker.OnStateChange = func(name string, from, to breaker.State) {
+			log.Printf("breaker %s: %s -> %s", name, from, to)
+		}
+		upstream, err := withChaos(httpFetcher, a.profile)
+		if err != nil {
+			return err
+		}
+		// CATALOG_HEDGE_DELAY (the upstream's p95, e.g. "150ms") sends a
+		// second request for lookups slower than that.
+		if delay := os.Getenv("CATALOG_HEDGE_DELAY"); delay != "" {
+			d, err := time.ParseDuration(delay)
+			if err != nil {
+				return fmt.Errorf("CATALOG_HEDGE_DELAY: %w", err)
+			}
+			upstream = hedged.NewProductFetcher(upstream, d)
+		}
+		fetcher = composite.NewProductFetcher(
+			composite.Source{Name: "upstream", Fetcher: upstream, Timeout: 2 * time.Second},
+			composite.Source{Name: "local", Fetcher: fetcher, Timeout: 200 * time.Millisecond},
+		)
+	} else if fetcher, err = withChaos(fetcher, a.profile); err != nil {
+		return err
+	}
+
+	// 2. Instantiate the application use cases, caching product lookups.
+	productCache := cache.NewLRU[string, domain.Product](1000, time.Minute)
+	// Outside the cache, the fallback serves the last-known-good copy
+	// (flagged Stale, never cached) for up to a day when lookups fail.
+	lastGood := fallback.NewProductFetcher(cached.NewProductFetcher(fetcher, productCache), 10_000, 24*time.Hour)
+	lastGood.OnFallback = func(id string, err error) {
+		log.Printf("serving stale product %s: %v", id, err)
+	}
+	query := app.FetchProductQuery{ProductFetcher: lastGood}
+	search := app.SearchProductsQuery{ProductSearcher: a.store}
+	// Large catalogs search an Elasticsearch/OpenSearch index
+	// (ELASTICSEARCH_URL) instead of scanning the store.
+	if a.index != nil {
+		search.ProductSearcher = a.index
+	}
+
+	// Stock is always the profile's store's, upstream or not: reservations
+	// must be taken where they are counted.
+	reserve := app.ReserveStockCommand{StockKeeper: a.store}
+	release := app.ReleaseStockCommand{StockKeeper: a.store}
+
+	// 3. Serve the read and stock APIs (CATALOG_HTTP_ADDR) until
+	// interrupted, then drain. Only requests vouched for with an
+	// x-edge-identity token addressed to "catalog", signed with
+	// CATALOG_IDENTITY_SECRET (the edge's EDGE_IDENTITY_SECRET), get
+	// through; dev and test may leave the secret out and serve anyone.
+	mux := http.NewServeMux()
+	httpadapter.NewHandler(&query, &search, log.Default()).Register(mux)
+	httpadapter.NewReservationHandler(&reserve, &release, log.Default()).Register(mux)
+	var handler http.Handler = mux
+	if secret := os.Getenv("CATALOG_IDENTITY_SECRET"); secret != "" {
+		handler = edgetoken.NewSigner([]byte(secret)).Middleware("catalog", mux)
+	} else if a.profile != "dev" && a.profile != "test" {
+		return fmt.Errorf("CATALOG_IDENTITY_SECRET is required for APP_ENV %q", a.profile)
+	}
+	server := &http.Server{
+		Addr:              env("CATALOG_HTTP_ADDR", ":8082"),
+		Handler:           handler,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	if server.TLSConfig, err = serverTLS(); err != nil {
+		return fmt.Errorf("server TLS: %w", err)
+	}
+	errs := make(chan error, 1)
+	go func() {
+		var err error
+		if server.TLSConfig != nil {
+			log.Printf("HTTPS (mTLS) listening on %s", server.Addr)
+			// The key pair is loaded into the config, so no files are named.
+			err = server.ListenAndServeTLS("", "")
+		} else {
+			log.Printf("HTTP listening on %s", server.Addr)
+			err = server.ListenAndServe()
+		}
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			errs <- fmt.Errorf("http server: %w", err)
+		}
+	}()
+	select {
+	case <-ctx.Done():
+	case err = <-errs:
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("shutdown: %v", err)
+	}
+	return err
+}
+
+func env(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+// serverTLS serves mTLS when CATALOG_SERVER_TLS_CERT, _KEY and _CA are
+// set: callers (the edge, orders) must present a certificate issued by the
+// CA, and CATALOG_SERVER_TLS_PEERS (comma-separated SANs) narrows down
+// which. Without them it returns nil and the catalog serves plain HTTP.
+func serverTLS() (*tls.Config, error) {
+	files := tlsconfig.Files{
+		CertFile: os.Getenv("CATALOG_SERVER_TLS_CERT"),
+		KeyFile:  os.Getenv("CATALOG_SERVER_TLS_KEY"),
+		CAFile:   os.Getenv("CATALOG_SERVER_TLS_CA"),
+	}
+	if !files.Enabled() {
+		return nil, nil
+	}
+	if peers := os.Getenv("CATALOG_SERVER_TLS_PEERS"); peers != "" {
+		files.PeerNames = strings.Split(peers, ",")
+	}
+	return tlsconfig.Server(files)
+}
+
+// upstreamClient calls the upstream catalog over mTLS when
+// CATALOG_UPSTREAM_TLS_CERT, _KEY and _CA are set; CATALOG_UPSTREAM_TLS_PEERS
+// (comma-separated SANs) restricts which server identities are accepted.
+func upstreamClient() (*http.Client, error) {
+	files := tlsconfig.Files{
+		CertFile: os.Getenv("CATALOG_UPSTREAM_TLS_CERT"),
+		KeyFile:  os.Getenv("CATALOG_UPSTREAM_TLS_KEY"),
+		CAFile:   os.Getenv("CATALOG_UPSTREAM_TLS_CA"),
+	}
+	if !files.Enabled() {
+		return http.DefaultClient, nil
+	}
+	if peers := os.Getenv("CATALOG_UPSTREAM_TLS_PEERS"); peers != "" {
+		files.PeerNames = strings.Split(peers, ",")
+	}
+	cfg, err := tlsconfig.Client(files, "")
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = cfg
+	return &http.Client{Transport: transport}, nil
+}
+
+// withChaos wraps next in a chaos.ProductFetcher when CATALOG_CHAOS_LATENCY
+// or CATALOG_CHAOS_ERROR_RATE is set. The prod profile refuses both.
+func withChaos(next ports.ProductFetcher, profile string) (ports.ProductFetcher, error) {
+	latencyEnv, rateEnv := os.Getenv("CATALOG_CHAOS_LATENCY"), os.Getenv("CATALOG_CHAOS_ERROR_RATE")
+	if latencyEnv == "" && rateEnv == "" {
+		return next, nil
+	}
+	if profile == "prod" {
+		return nil, fmt.Errorf("CATALOG_CHAOS_* cannot be set in the prod profile")
+	}
+	var latency time.Duration
+	var rate float64
+	var err error
+	if latencyEnv != "" {
+		if latency, err = time.ParseDuration(latencyEnv); err != nil {
+			return nil, fmt.Errorf("CATALOG_CHAOS_LATENCY: %w", err)
+		}
+	}
+	if rateEnv != "" {
+		if rate, err = strconv.ParseFloat(rateEnv, 64); err != nil || rate < 0 || rate > 1 {
+			return nil, fmt.Errorf("CATALOG_CHAOS_ERROR_RATE must be a number in [0, 1], got %q", rateEnv)
+		}
+	}
+	log.Printf("chaos: %v latency, %.0f%% errors on product lookups", latency, rate*100)
+	return chaos.NewProductFetcher(next, latency, rate), nil
+}
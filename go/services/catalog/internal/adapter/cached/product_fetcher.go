@@ -0,0 +1,38 @@
+// Package cached decorates ports with an in-process cache.
+package cached
+
+import (
+	"context"
+
+	"clean-code-cookbook/go/pkg/cache"
+	"clean-code-cookbook/go/services/catalog/internal/domain"
+	"clean-code-cookbook/go/services/catalog/internal/ports"
+)
+
+// ProductFetcher serves repeated lookups from an LRU and collapses
+// concurrent misses for the same ID into one upstream call. Failures,
+// including domain.ErrProductNotFound, are not cached.
+type ProductFetcher struct {
+	next  ports.ProductFetcher
+	cache *cache.LRU[string, domain.Product]
+}
+
+// NewProductFetcher wraps next with c.
+func NewProductFetcher(next ports.ProductFetcher, c *cache.LRU[string, domain.Product]) *ProductFetcher {
+	return &ProductFetcher{next: next, cache: c}
+}
+
+// FetchProductByID returns a copy, so callers cannot mutate cached entries.
+func (f *ProductFetcher) FetchProductByID(ctx context.Context, id string) (*domain.Product, error) {
+	p, err := f.cache.GetOrLoad(ctx, id, func(ctx context.Context) (domain.Product, error) {
+		p, err := f.next.FetchProductByID(ctx, id)
+		if err != nil {
+			return domain.Product{}, err
+		}
+		return *p, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
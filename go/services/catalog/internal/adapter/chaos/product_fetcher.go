@@ -0,0 +1,51 @@
+// Package chaos decorates ports with injected latency and failures, for
+// resilience testing outside of production.
+package chaos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"clean-code-cookbook/go/services/catalog/internal/domain"
+	"clean-code-cookbook/go/services/catalog/internal/ports"
+)
+
+// ErrInjected marks a failure that was injected rather than real.
+var ErrInjected = errors.New("injected fault")
+
+// ProductFetcher delays every lookup by Latency and fails ErrorRate (0..1)
+// of them before they reach next.
+type ProductFetcher struct {
+	Latency   time.Duration
+	ErrorRate float64
+	// Rand returns a number in [0, 1); tests replace it to make outcomes
+	// deterministic.
+	Rand func() float64
+
+	next ports.ProductFetcher
+}
+
+// NewProductFetcher wraps next; with zero latency and error rate it is a
+// pass-through.
+func NewProductFetcher(next ports.ProductFetcher, latency time.Duration, errorRate float64) *ProductFetcher {
+	return &ProductFetcher{Latency: latency, ErrorRate: errorRate, Rand: rand.Float64, next: next}
+}
+
+func (f *ProductFetcher) FetchProductByID(ctx context.Context, id string) (*domain.Product, error) {
+	if f.Latency > 0 {
+		timer := time.NewTimer(f.Latency)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+	if f.Rand() < f.ErrorRate {
+		return nil, fmt.Errorf("fetch product %s: %w", id, ErrInjected)
+	}
+	return f.next.FetchProductByID(ctx, id)
+}
@@ -0,0 +1,133 @@
+// Package composite chains several ProductFetchers into one, falling back
+// along the chain when a source fails.
+package composite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"clean-code-cookbook/go/services/catalog/internal/domain"
+	"clean-code-cookbook/go/services/catalog/internal/ports"
+)
+
+// Source is one link of the chain.
+type Source struct {
+	Name    string
+	Fetcher ports.ProductFetcher
+	// Timeout bounds each call to this source; zero means only the
+	// caller's deadline applies.
+	Timeout time.Duration
+}
+
+// SourceHealth is a snapshot of a source's circuit, for logs and metrics.
+type SourceHealth struct {
+	Name                string
+	Healthy             bool
+	ConsecutiveFailures int
+}
+
+type source struct {
+	Source
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+}
+
+// ProductFetcher tries its sources in order (e.g. upstream API, then a
+// local copy) and returns the first answer. A source that fails
+// FailureThreshold times in a row is skipped for Cooldown, so a dead
+// upstream costs nothing but its first few timeouts. If every source is
+// cooling down, all are tried anyway.
+//
+// domain.ErrProductNotFound is an answer, not a failure: it ends the chain
+// and does not count against the source's health.
+type ProductFetcher struct {
+	FailureThreshold int
+	Cooldown         time.Duration
+
+	sources []*source
+}
+
+func NewProductFetcher(sources ...Source) *ProductFetcher {
+	f := &ProductFetcher{FailureThreshold: 3, Cooldown: 30 * time.Second}
+	for _, s := range sources {
+		f.sources = append(f.sources, &source{Source: s})
+	}
+	return f
+}
+
+func (f *ProductFetcher) FetchProductByID(ctx context.Context, id string) (*domain.Product, error) {
+	// 1. Pick the sources worth trying
+	now := time.Now()
+	var candidates []*source
+	for _, s := range f.sources {
+		if s.healthy(now) {
+			candidates = append(candidates, s)
+		}
+	}
+	if len(candidates) == 0 {
+		candidates = f.sources
+	}
+
+	// 2. Walk the chain
+	var errs []error
+	for _, s := range candidates {
+		p, err := s.fetch(ctx, id)
+		if err == nil || errors.Is(err, domain.ErrProductNotFound) {
+			s.succeed()
+			return p, err
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err() // the caller gave up; not the source's fault
+		}
+		s.fail(time.Now(), f.FailureThreshold, f.Cooldown)
+		errs = append(errs, fmt.Errorf("%s: %w", s.Name, err))
+	}
+	return nil, fmt.Errorf("all product sources failed: %w", errors.Join(errs...))
+}
+
+// Health reports every source's state, in chain order.
+func (f *ProductFetcher) Health() []SourceHealth {
+	now := time.Now()
+	out := make([]SourceHealth, 0, len(f.sources))
+	for _, s := range f.sources {
+		s.mu.Lock()
+		out = append(out, SourceHealth{Name: s.Name, Healthy: now.After(s.openUntil), ConsecutiveFailures: s.failures})
+		s.mu.Unlock()
+	}
+	return out
+}
+
+func (s *source) fetch(ctx context.Context, id string) (*domain.Product, error) {
+	if s.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.Timeout)
+		defer cancel()
+	}
+	return s.Fetcher.FetchProductByID(ctx, id)
+}
+
+func (s *source) healthy(now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return now.After(s.openUntil)
+}
+
+func (s *source) succeed() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = 0
+}
+
+func (s *source) fail(now time.Time, threshold int, cooldown time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures++
+	if s.failures >= threshold {
+		s.openUntil = now.Add(cooldown)
+	}
+}
@@ -0,0 +1,64 @@
+// Package fallback decorates ports so a failing source degrades to stale
+// or default data instead of an error.
+package fallback
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"clean-code-cookbook/go/pkg/cache"
+	"clean-code-cookbook/go/services/catalog/internal/domain"
+	"clean-code-cookbook/go/services/catalog/internal/ports"
+)
+
+// ProductFetcher remembers every product next returned. When next fails,
+// it serves the last-known-good copy, or else Default, flagged Stale.
+// ErrProductNotFound and the caller's own cancellation are passed through:
+// they are answers, not failures.
+type ProductFetcher struct {
+	// Default, if set, is served (with the requested ID) for products
+	// that were never seen.
+	Default *domain.Product
+	// OnFallback, if set, is called whenever a stale copy is served.
+	OnFallback func(id string, err error)
+
+	next     ports.ProductFetcher
+	lastGood *cache.LRU[string, domain.Product]
+}
+
+// NewProductFetcher keeps up to capacity last-known-good products for at
+// most maxAge.
+func NewProductFetcher(next ports.ProductFetcher, capacity int, maxAge time.Duration) *ProductFetcher {
+	return &ProductFetcher{next: next, lastGood: cache.NewLRU[string, domain.Product](capacity, maxAge)}
+}
+
+func (f *ProductFetcher) FetchProductByID(ctx context.Context, id string) (*domain.Product, error) {
+	p, err := f.next.FetchProductByID(ctx, id)
+	if err == nil {
+		f.lastGood.Set(id, *p)
+		return p, nil
+	}
+	if errors.Is(err, domain.ErrProductNotFound) {
+		f.lastGood.Delete(id)
+		return nil, err
+	}
+	if errors.Is(err, context.Canceled) {
+		return nil, err
+	}
+
+	stale, ok := f.lastGood.Get(id)
+	switch {
+	case ok:
+	case f.Default != nil:
+		stale = *f.Default
+		stale.ID = id
+	default:
+		return nil, err
+	}
+	if f.OnFallback != nil {
+		f.OnFallback(id, err)
+	}
+	stale.Stale = true
+	return &stale, nil
+}
@@ -0,0 +1,81 @@
+// Package hedged decorates ports with hedged requests: a slow call gets a
+// twin, and whichever answers first wins.
+package hedged
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"clean-code-cookbook/go/services/catalog/internal/domain"
+	"clean-code-cookbook/go/services/catalog/internal/ports"
+)
+
+// ProductFetcher sends a second lookup to next when the first has not
+// answered within Delay, and returns the first answer. The loser is
+// cancelled. Set Delay to the upstream's p95 latency: about one call in
+// twenty is then hedged, in exchange for a much shorter tail.
+//
+// A product or ErrProductNotFound is an answer; any other error waits for
+// the twin, if one was sent, before it is returned.
+type ProductFetcher struct {
+	Delay time.Duration
+
+	next   ports.ProductFetcher
+	hedges atomic.Int64
+}
+
+func NewProductFetcher(next ports.ProductFetcher, delay time.Duration) *ProductFetcher {
+	return &ProductFetcher{Delay: delay, next: next}
+}
+
+// Hedges reports how many second requests were sent, for metrics.
+func (f *ProductFetcher) Hedges() int64 { return f.hedges.Load() }
+
+type result struct {
+	product *domain.Product
+	err     error
+}
+
+func (r result) answered() bool {
+	return r.err == nil || errors.Is(r.err, domain.ErrProductNotFound)
+}
+
+func (f *ProductFetcher) FetchProductByID(ctx context.Context, id string) (*domain.Product, error) {
+	// Cancelling ctx on return stops whichever call is still running.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan result, 2) // buffered: the loser never blocks
+	launch := func() {
+		go func() {
+			p, err := f.next.FetchProductByID(ctx, id)
+			results <- result{product: p, err: err}
+		}()
+	}
+
+	// 1. The primary call
+	launch()
+	timer := time.NewTimer(f.Delay)
+	defer timer.Stop()
+
+	// 2. The first answer wins; a slow primary gets its twin. A failure
+	// before the delay is returned as is: retrying is not this layer's job.
+	pending := 1
+	for {
+		select {
+		case r := <-results:
+			pending--
+			if r.answered() || pending == 0 {
+				return r.product, r.err
+			}
+		case <-timer.C:
+			pending++
+			f.hedges.Add(1)
+			launch()
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
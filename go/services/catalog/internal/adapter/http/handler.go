@@ -0,0 +1,136 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"clean-code-cookbook/go/pkg/breaker"
+	"clean-code-cookbook/go/pkg/bulkhead"
+	"clean-code-cookbook/go/pkg/page"
+	"clean-code-cookbook/go/services/catalog/internal/app"
+	"clean-code-cookbook/go/services/catalog/internal/domain"
+)
+
+type searchResponse struct {
+	Items []productDTO `json:"items"`
+	Next  page.Cursor  `json:"next,omitempty"`
+}
+
+// Handler serves the catalog's read API, GET /products/{id} and
+// GET /products, in the JSON shape ProductFetcher reads, so one catalog
+// can be another's upstream.
+type Handler struct {
+	fetch  *app.FetchProductQuery
+	search *app.SearchProductsQuery
+	logger *log.Logger
+}
+
+func NewHandler(fetch *app.FetchProductQuery, search *app.SearchProductsQuery, logger *log.Logger) *Handler {
+	return &Handler{fetch: fetch, search: search, logger: logger}
+}
+
+// Register mounts the routes on mux.
+func (h *Handler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("/products", h.Search)
+	mux.HandleFunc("/products/", h.Get)
+}
+
+// Get handles GET /products/{id}. A stale copy served while the store is
+// failing is answered like a fresh one.
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	if !allowGet(w, r) {
+		return
+	}
+	id := strings.TrimPrefix(r.URL.Path, "/products/")
+	if id == "" || strings.Contains(id, "/") {
+		http.NotFound(w, r)
+		return
+	}
+	product, err := h.fetch.Execute(r.Context(), id)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toDTO(*product))
+}
+
+// Search handles GET /products?q=&min_price=&max_price=&size=&sort=&after=,
+// one page of the products whose name matches q. sort is name or price,
+// "-" first for descending; after is the next cursor of the previous page.
+func (h *Handler) Search(w http.ResponseWriter, r *http.Request) {
+	if !allowGet(w, r) {
+		return
+	}
+	q := r.URL.Query()
+	filter := domain.ProductFilter{Query: q.Get("q"), Page: page.Request{After: page.Cursor(q.Get("after"))}}
+	for name, into := range map[string]*float64{"min_price": &filter.MinPrice, "max_price": &filter.MaxPrice} {
+		if raw := q.Get(name); raw != "" {
+			v, err := strconv.ParseFloat(raw, 64)
+			if err != nil {
+				http.Error(w, name+" must be a number", http.StatusBadRequest)
+				return
+			}
+			*into = v
+		}
+	}
+	if size := q.Get("size"); size != "" {
+		n, err := strconv.Atoi(size)
+		if err != nil || n < 1 {
+			http.Error(w, "size must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		filter.Page.Size = n
+	}
+	var err error
+	if filter.Page.Sort, err = page.ParseSort(q.Get("sort"), domain.ProductSortFields...); err != nil {
+		h.writeError(w, err)
+		return
+	}
+	products, err := h.search.Execute(r.Context(), filter)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	resp := page.Map(products, toDTO)
+	writeJSON(w, http.StatusOK, searchResponse{Items: resp.Items, Next: resp.Next})
+}
+
+// writeError maps domain errors to statuses. Anything unexpected is logged
+// and answered with a bare 500.
+func (h *Handler) writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrProductNotFound):
+		http.Error(w, domain.ErrProductNotFound.Error(), http.StatusNotFound)
+	case errors.Is(err, domain.ErrInvalidFilter), errors.Is(err, page.ErrInvalidCursor), errors.Is(err, page.ErrInvalidSort):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, breaker.ErrOpen), errors.Is(err, bulkhead.ErrFull):
+		w.Header().Set("Retry-After", "1")
+		http.Error(w, "catalog busy, retry later", http.StatusServiceUnavailable)
+	default:
+		h.logger.Printf("http: %v", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
+
+func allowGet(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		return true
+	}
+	w.Header().Set("Allow", "GET, HEAD")
+	http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	return false
+}
+
+func toDTO(p domain.Product) productDTO {
+	return productDTO{ID: p.ID, Name: p.Name, Price: p.Price}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
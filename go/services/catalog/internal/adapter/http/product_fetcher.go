@@ -0,0 +1,138 @@
+// Package http is the catalog's HTTP adapter: Handler serves its read
+// API, ReservationHandler its stock API, and ProductFetcher reads products
+// from an upstream catalog's.
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"clean-code-cookbook/go/pkg/breaker"
+	"clean-code-cookbook/go/pkg/bulkhead"
+	"clean-code-cookbook/go/pkg/retry"
+	"clean-code-cookbook/go/services/catalog/internal/domain"
+)
+
+// productDTO is the upstream's JSON shape; the domain model has no tags.
+type productDTO struct {
+	ID    string  `json:"id"`
+	Name  string  `json:"name"`
+	Price float64 `json:"price"`
+}
+
+// ProductFetcher implements ports.ProductFetcher with GET {baseURL}/products/{id}.
+type ProductFetcher struct {
+	// Retry repeats lookups that failed in transit or with a 5xx or 429;
+	// a 404 or a malformed body is final.
+	Retry retry.Policy
+	// Breaker stops calling an upstream that keeps failing; a lookup it
+	// rejects fails with breaker.ErrOpen. Retries count as one call.
+	Breaker *breaker.Breaker
+	// Bulkhead caps concurrent requests to the upstream; nil is unlimited.
+	Bulkhead *bulkhead.Bulkhead
+
+	baseURL string
+	client  *http.Client
+}
+
+// NewProductFetcher uses client, which should carry a sensible Timeout.
+func NewProductFetcher(baseURL string, client *http.Client) *ProductFetcher {
+	return &ProductFetcher{
+		Retry: retry.Policy{
+			Attempts: 3,
+			Backoff:  retry.Jitter(retry.Exponential(50*time.Millisecond, time.Second)),
+			RetryIf:  retryable,
+		},
+		Breaker:  newBreaker(),
+		Bulkhead: bulkhead.New("product-upstream", 20, 100*time.Millisecond),
+		baseURL:  strings.TrimSuffix(baseURL, "/"),
+		client:   client,
+	}
+}
+
+// statusError is an unexpected upstream response status.
+type statusError struct {
+	code   int
+	status string
+}
+
+func (e *statusError) Error() string { return "upstream returned " + e.status }
+
+// decodeError is a response body that is not a product.
+type decodeError struct{ err error }
+
+func (e *decodeError) Error() string { return "decode product: " + e.err.Error() }
+func (e *decodeError) Unwrap() error { return e.err }
+
+// retryable reports whether a failed lookup may succeed when repeated.
+func retryable(err error) bool {
+	var status *statusError
+	if errors.As(err, &status) {
+		return status.code >= 500 || status.code == http.StatusTooManyRequests
+	}
+	var decode *decodeError
+	return !errors.Is(err, domain.ErrProductNotFound) && !errors.As(err, &decode)
+}
+
+// newBreaker counts only failures that say something about the upstream's
+// health: a missing product is a healthy answer.
+func newBreaker() *breaker.Breaker {
+	b := breaker.New("product-upstream")
+	b.IsFailure = func(err error) bool {
+		return err != nil && retryable(err) && !errors.Is(err, context.Canceled)
+	}
+	return b
+}
+
+func (f *ProductFetcher) FetchProductByID(ctx context.Context, id string) (*domain.Product, error) {
+	var product *domain.Product
+	err := f.Breaker.Do(ctx, func(ctx context.Context) error {
+		return retry.Do(ctx, f.Retry, func(ctx context.Context) error {
+			var err error
+			product, err = f.fetch(ctx, id)
+			return err
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return product, nil
+}
+
+func (f *ProductFetcher) fetch(ctx context.Context, id string) (*domain.Product, error) {
+	release, err := f.Bulkhead.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, f.baseURL+"/products/"+url.PathEscape(id), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, domain.ErrProductNotFound
+	case resp.StatusCode != http.StatusOK:
+		return nil, &statusError{code: resp.StatusCode, status: resp.Status}
+	}
+
+	var dto productDTO
+	if err := json.NewDecoder(resp.Body).Decode(&dto); err != nil {
+		return nil, &decodeError{err: err}
+	}
+	return &domain.Product{ID: dto.ID, Name: dto.Name, Price: dto.Price}, nil
+}
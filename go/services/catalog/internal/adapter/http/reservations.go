@@ -0,0 +1,120 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"clean-code-cookbook/go/services/catalog/internal/app"
+	"clean-code-cookbook/go/services/catalog/internal/domain"
+)
+
+type reserveRequest struct {
+	OrderID string `json:"order_id"`
+	Items   []struct {
+		SKU      string `json:"sku"`
+		Quantity int    `json:"quantity"`
+	} `json:"items"`
+}
+
+type reservedLineDTO struct {
+	SKU       string  `json:"sku"`
+	Quantity  int     `json:"quantity"`
+	UnitPrice float64 `json:"unit_price"`
+}
+
+type reservationDTO struct {
+	ID    string            `json:"id"`
+	Lines []reservedLineDTO `json:"lines"`
+}
+
+// maxReserveBody caps a reservation request; a cart is small.
+const maxReserveBody = 1 << 20
+
+// ReservationHandler serves the catalog's stock API, POST /reservations
+// and DELETE /reservations/{id}, which the orders service reserves stock
+// for checkouts with.
+type ReservationHandler struct {
+	reserve *app.ReserveStockCommand
+	release *app.ReleaseStockCommand
+	logger  *log.Logger
+}
+
+func NewReservationHandler(reserve *app.ReserveStockCommand, release *app.ReleaseStockCommand, logger *log.Logger) *ReservationHandler {
+	return &ReservationHandler{reserve: reserve, release: release, logger: logger}
+}
+
+// Register mounts the routes on mux.
+func (h *ReservationHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("/reservations", h.Reserve)
+	mux.HandleFunc("/reservations/", h.Release)
+}
+
+// Reserve handles POST /reservations with {"order_id", "items": [{"sku",
+// "quantity"}]} and answers 201 with the priced lines. Stock running short
+// is 409 and an unknown product 422; reserving again for the same order
+// answers with the first reservation.
+func (h *ReservationHandler) Reserve(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req reserveRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxReserveBody)).Decode(&req); err != nil {
+		http.Error(w, "malformed reservation: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	items := make([]domain.StockItem, len(req.Items))
+	for i, it := range req.Items {
+		items[i] = domain.StockItem{SKU: it.SKU, Quantity: it.Quantity}
+	}
+	res, err := h.reserve.Execute(r.Context(), req.OrderID, items)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	dto := reservationDTO{ID: res.ID, Lines: make([]reservedLineDTO, len(res.Lines))}
+	for i, l := range res.Lines {
+		dto.Lines[i] = reservedLineDTO{SKU: l.SKU, Quantity: l.Quantity, UnitPrice: l.UnitPrice}
+	}
+	writeJSON(w, http.StatusCreated, dto)
+}
+
+// Release handles DELETE /reservations/{id}, answering 204, or 404 for a
+// reservation that was released already or never taken.
+func (h *ReservationHandler) Release(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		w.Header().Set("Allow", "DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	id := strings.TrimPrefix(r.URL.Path, "/reservations/")
+	if id == "" || strings.Contains(id, "/") {
+		http.NotFound(w, r)
+		return
+	}
+	if err := h.release.Execute(r.Context(), id); err != nil {
+		h.writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *ReservationHandler) writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidReservation):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, domain.ErrOutOfStock):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, domain.ErrProductNotFound):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	case errors.Is(err, domain.ErrReservationNotFound):
+		http.Error(w, domain.ErrReservationNotFound.Error(), http.StatusNotFound)
+	default:
+		h.logger.Printf("http: %v", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,187 @@
+// Package memory provides an in-process product store, so the catalog runs
+// in dev and tests with no upstream service or database.
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"clean-code-cookbook/go/pkg/page"
+	"clean-code-cookbook/go/services/catalog/internal/domain"
+)
+
+// ProductFetcher implements ports.ProductFetcher, ports.ProductSearcher,
+// ports.ProductDeleter and ports.StockKeeper on maps. It is safe for
+// concurrent use.
+type ProductFetcher struct {
+	mu           sync.RWMutex
+	products     map[string]domain.Product
+	stock        map[string]int
+	reservations map[string]domain.Reservation
+}
+
+// NewProductFetcher returns a fetcher pre-loaded with products, none of
+// them in stock until Restock.
+func NewProductFetcher(products ...domain.Product) *ProductFetcher {
+	f := &ProductFetcher{
+		products:     make(map[string]domain.Product, len(products)),
+		stock:        make(map[string]int, len(products)),
+		reservations: make(map[string]domain.Reservation),
+	}
+	for _, p := range products {
+		f.products[p.ID] = p
+	}
+	return f
+}
+
+// Put adds or replaces a product.
+func (f *ProductFetcher) Put(p domain.Product) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.products[p.ID] = p
+}
+
+// FetchProductByID returns a copy of the stored product, so callers cannot
+// mutate the fetcher's state through it.
+func (f *ProductFetcher) FetchProductByID(ctx context.Context, id string) (*domain.Product, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	p, ok := f.products[id]
+	if !ok || p.Deleted() && !domain.IncludesDeleted(ctx) {
+		return nil, domain.ErrProductNotFound
+	}
+	return &p, nil
+}
+
+// DeleteProduct soft-deletes the product.
+func (f *ProductFetcher) DeleteProduct(ctx context.Context, id string, at time.Time) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	p, ok := f.products[id]
+	if !ok || p.Deleted() {
+		return domain.ErrProductNotFound
+	}
+	p.UpdatedAt, p.DeletedAt = at, &at
+	f.products[id] = p
+	return nil
+}
+
+// PurgeDeleted removes products soft-deleted before cutoff.
+func (f *ProductFetcher) PurgeDeleted(ctx context.Context, cutoff time.Time) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := 0
+	for id, p := range f.products {
+		if p.Deleted() && p.DeletedAt.Before(cutoff) {
+			delete(f.products, id)
+			delete(f.stock, id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// SearchProducts does a case-insensitive substring match on the name,
+// ordered by ID unless the filter sorts otherwise.
+func (f *ProductFetcher) SearchProducts(ctx context.Context, filter domain.ProductFilter) (page.Page[domain.Product], error) {
+	sortBy := filter.Page.Sort
+	after, resume, err := domain.ParseProductCursor(filter.Page.After, sortBy)
+	if err != nil {
+		return page.Page[domain.Product]{}, err
+	}
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	query := strings.ToLower(filter.Query)
+	includeDeleted := domain.IncludesDeleted(ctx)
+	var out []domain.Product
+	for _, p := range f.products {
+		if p.Deleted() && !includeDeleted {
+			continue
+		}
+		if !strings.Contains(strings.ToLower(p.Name), query) || p.Price < filter.MinPrice {
+			continue
+		}
+		if filter.MaxPrice > 0 && p.Price > filter.MaxPrice {
+			continue
+		}
+		if resume && domain.CompareProducts(p, after.After, sortBy) <= 0 {
+			continue
+		}
+		out = append(out, p)
+	}
+	slices.SortFunc(out, func(a, b domain.Product) int { return domain.CompareProducts(a, b, sortBy) })
+
+	var next page.Cursor
+	if size := filter.Page.Size; size > 0 && len(out) > size {
+		out = out[:size]
+		next = domain.ProductCursor{Sort: sortBy, After: out[size-1]}.Cursor()
+	}
+	return page.Page[domain.Product]{Items: out, Next: next}, nil
+}
+
+// Restock sets how many of the product are left to reserve.
+func (f *ProductFetcher) Restock(ctx context.Context, sku string, available int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if p, ok := f.products[sku]; !ok || p.Deleted() {
+		return domain.ErrProductNotFound
+	}
+	f.stock[sku] = available
+	return nil
+}
+
+// Reserve takes items out of stock, all or nothing. Each reservation is
+// named after its order.
+func (f *ProductFetcher) Reserve(ctx context.Context, orderID string, items []domain.StockItem, at time.Time) (domain.Reservation, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if r, ok := f.reservations[orderID]; ok {
+		r.Lines = slices.Clone(r.Lines)
+		return r, nil
+	}
+	r := domain.Reservation{ID: orderID, ReservedAt: at}
+	for _, it := range items {
+		p, ok := f.products[it.SKU]
+		if !ok || p.Deleted() {
+			return domain.Reservation{}, fmt.Errorf("%w: %s", domain.ErrProductNotFound, it.SKU)
+		}
+		if left := f.stock[it.SKU]; left < it.Quantity {
+			return domain.Reservation{}, fmt.Errorf("%w: %s has %d left", domain.ErrOutOfStock, it.SKU, left)
+		}
+		r.Lines = append(r.Lines, domain.ReservedLine{SKU: it.SKU, Quantity: it.Quantity, UnitPrice: p.Price})
+	}
+	for _, l := range r.Lines {
+		f.stock[l.SKU] -= l.Quantity
+	}
+	f.reservations[orderID] = r
+	return domain.Reservation{ID: r.ID, Lines: slices.Clone(r.Lines), ReservedAt: r.ReservedAt}, nil
+}
+
+// Release puts the reservation's stock back.
+func (f *ProductFetcher) Release(ctx context.Context, reservationID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	r, ok := f.reservations[reservationID]
+	if !ok {
+		return domain.ErrReservationNotFound
+	}
+	for _, l := range r.Lines {
+		if _, ok := f.products[l.SKU]; ok {
+			f.stock[l.SKU] += l.Quantity
+		}
+	}
+	delete(f.reservations, reservationID)
+	return nil
+}
+
+// Available reports how much of sku is left to reserve.
+func (f *ProductFetcher) Available(sku string) int {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.stock[sku]
+}
@@ -0,0 +1,279 @@
+// Package sqlite stores products in a local SQLite file, so the catalog runs
+// on a laptop with no upstream service. It uses the pure-Go modernc driver.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"clean-code-cookbook/go/pkg/page"
+	"clean-code-cookbook/go/services/catalog/internal/domain"
+	_ "modernc.org/sqlite" // registers the "sqlite" driver
+)
+
+const schema = `CREATE TABLE IF NOT EXISTS products (
+    id         TEXT PRIMARY KEY,
+    name       TEXT NOT NULL,
+    price      REAL NOT NULL,
+    updated_at DATETIME,
+    deleted_at DATETIME,
+    available  INTEGER NOT NULL DEFAULT 0
+)`
+
+// addedColumns were added to products after files already existed;
+// ALTER TABLE has no IF NOT EXISTS, so Open adds whichever are missing.
+var addedColumns = []string{"updated_at DATETIME", "deleted_at DATETIME", "available INTEGER NOT NULL DEFAULT 0"}
+
+// stockTables hold the reservations taken from products.available.
+var stockTables = []string{
+	`CREATE TABLE IF NOT EXISTS reservations (
+    id          TEXT PRIMARY KEY,
+    reserved_at DATETIME NOT NULL
+)`,
+	`CREATE TABLE IF NOT EXISTS reservation_lines (
+    reservation_id TEXT NOT NULL REFERENCES reservations (id),
+    sku            TEXT NOT NULL,
+    quantity       INTEGER NOT NULL,
+    unit_price     REAL NOT NULL,
+    PRIMARY KEY (reservation_id, sku)
+)`,
+}
+
+const productColumns = `id, name, price, updated_at, deleted_at`
+
+// ProductRepository implements ports.ProductFetcher, ports.ProductSearcher,
+// ports.ProductDeleter and ports.StockKeeper on a products table. Soft-deleted products are
+// hidden unless the context comes from domain.WithDeleted.
+type ProductRepository struct {
+	db *sql.DB
+}
+
+// Open opens dsn (e.g. "file:catalog.db") and creates the schema if it is
+// missing, so a fresh file is usable immediately.
+func Open(ctx context.Context, dsn string) (*ProductRepository, error) {
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open sqlite: %w", err)
+	}
+	// One connection: SQLite has a single writer, and ":memory:" databases
+	// exist per connection.
+	db.SetMaxOpenConns(1)
+
+	if err := migrate(ctx, db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("migrate products: %w", err)
+	}
+	return &ProductRepository{db: db}, nil
+}
+
+func migrate(ctx context.Context, db *sql.DB) error {
+	for _, stmt := range append([]string{schema}, stockTables...) {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	rows, err := db.QueryContext(ctx, `SELECT name FROM pragma_table_info('products')`)
+	if err != nil {
+		return err
+	}
+	have := map[string]bool{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return err
+		}
+		have[name] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for _, column := range addedColumns {
+		name, _, _ := strings.Cut(column, " ")
+		if have[name] {
+			continue
+		}
+		if _, err := db.ExecContext(ctx, `ALTER TABLE products ADD COLUMN `+column); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close releases the database.
+func (r *ProductRepository) Close() error {
+	return r.db.Close()
+}
+
+// Save inserts or replaces a product; saving a deleted product's ID
+// restores it. A zero UpdatedAt is stamped with the current time.
+func (r *ProductRepository) Save(ctx context.Context, p domain.Product) error {
+	query := `INSERT INTO products (` + productColumns + `) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET name = excluded.name, price = excluded.price,
+			updated_at = excluded.updated_at, deleted_at = excluded.deleted_at`
+
+	updated := p.UpdatedAt
+	if updated.IsZero() {
+		updated = time.Now()
+	}
+	_, err := r.db.ExecContext(ctx, query, p.ID, p.Name, p.Price, updated.UTC(), utc(p.DeletedAt))
+	return err
+}
+
+// DeleteProduct soft-deletes the product: it disappears from lookups and
+// searches but stays in the table until PurgeDeleted.
+func (r *ProductRepository) DeleteProduct(ctx context.Context, id string, at time.Time) error {
+	query := `UPDATE products SET deleted_at = ?, updated_at = ? WHERE id = ? AND deleted_at IS NULL`
+
+	res, err := r.db.ExecContext(ctx, query, at.UTC(), at.UTC(), id)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return domain.ErrProductNotFound
+	}
+	return nil
+}
+
+// PurgeDeleted removes products soft-deleted before cutoff for good.
+func (r *ProductRepository) PurgeDeleted(ctx context.Context, cutoff time.Time) (int, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM products WHERE deleted_at < ?`, cutoff.UTC())
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+// FetchProductByID returns domain.ErrProductNotFound for unknown IDs.
+func (r *ProductRepository) FetchProductByID(ctx context.Context, id string) (*domain.Product, error) {
+	query := `SELECT ` + productColumns + ` FROM products WHERE id = ?` + notDeleted(ctx)
+
+	p, err := scanProduct(r.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrProductNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// SearchProducts matches the name with LIKE, which scans the table; fine for
+// a local catalog, not for a large one. Pages are keyset-paginated on the
+// sort column and id.
+func (r *ProductRepository) SearchProducts(ctx context.Context, filter domain.ProductFilter) (page.Page[domain.Product], error) {
+	sortBy := filter.Page.Sort
+	after, resume, err := domain.ParseProductCursor(filter.Page.After, sortBy)
+	if err != nil {
+		return page.Page[domain.Product]{}, err
+	}
+
+	maxPrice := filter.MaxPrice
+	if maxPrice == 0 {
+		maxPrice = math.MaxFloat64
+	}
+	pattern := "%" + likeEscaper.Replace(filter.Query) + "%"
+	args := []any{pattern, filter.MinPrice, maxPrice}
+
+	order, seek, seekArgs := keyset(sortBy, after)
+	if !resume {
+		seek, seekArgs = "", nil
+	}
+	args = append(args, seekArgs...)
+	limit := -1 // no limit
+	if filter.Page.Size > 0 {
+		limit = filter.Page.Size + 1 // one more, to tell whether there is a next page
+	}
+	args = append(args, limit)
+
+	query := `SELECT ` + productColumns + ` FROM products
+		WHERE name LIKE ? ESCAPE '\' AND price >= ? AND price <= ?` + notDeleted(ctx) + seek + `
+		ORDER BY ` + order + ` LIMIT ?`
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return page.Page[domain.Product]{}, err
+	}
+	defer rows.Close()
+
+	var out []domain.Product
+	for rows.Next() {
+		p, err := scanProduct(rows)
+		if err != nil {
+			return page.Page[domain.Product]{}, err
+		}
+		out = append(out, p)
+	}
+	if err := rows.Err(); err != nil {
+		return page.Page[domain.Product]{}, err
+	}
+	var next page.Cursor
+	if size := filter.Page.Size; size > 0 && len(out) > size {
+		out = out[:size]
+		next = domain.ProductCursor{Sort: sortBy, After: out[size-1]}.Cursor()
+	}
+	return page.Page[domain.Product]{Items: out, Next: next}, nil
+}
+
+// keyset is the ORDER BY for a search sorted by s and the condition that
+// resumes after c under it.
+func keyset(s page.Sort, c domain.ProductCursor) (order, seek string, args []any) {
+	dir, op := "", ">"
+	if s.Desc {
+		dir, op = " DESC", "<"
+	}
+	switch s.Field {
+	case "name":
+		return "name" + dir + ", id" + dir, " AND (name, id) " + op + " (?, ?)", []any{c.After.Name, c.After.ID}
+	case "price":
+		return "price" + dir + ", id" + dir, " AND (price, id) " + op + " (?, ?)", []any{c.After.Price, c.After.ID}
+	default:
+		return "id" + dir, " AND id " + op + " ?", []any{c.After.ID}
+	}
+}
+
+// scanProduct reads one row of productColumns. Rows from before
+// updated_at existed come back with a zero UpdatedAt.
+func scanProduct(row interface{ Scan(...any) error }) (domain.Product, error) {
+	var (
+		p                  domain.Product
+		updated, deletedAt sql.NullTime
+	)
+	if err := row.Scan(&p.ID, &p.Name, &p.Price, &updated, &deletedAt); err != nil {
+		return domain.Product{}, err
+	}
+	p.UpdatedAt = updated.Time
+	if deletedAt.Valid {
+		p.DeletedAt = &deletedAt.Time
+	}
+	return p, nil
+}
+
+// notDeleted is the filter that hides soft-deleted rows, unless ctx asks
+// for them.
+func notDeleted(ctx context.Context) string {
+	if domain.IncludesDeleted(ctx) {
+		return ""
+	}
+	return ` AND deleted_at IS NULL`
+}
+
+func utc(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+	return t.UTC()
+}
+
+// likeEscaper makes user input match literally inside a LIKE pattern.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
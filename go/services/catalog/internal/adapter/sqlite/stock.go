@@ -0,0 +1,136 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"clean-code-cookbook/go/services/catalog/internal/domain"
+)
+
+// Restock sets products.available for a product that is not deleted.
+func (r *ProductRepository) Restock(ctx context.Context, sku string, available int) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE products SET available = ? WHERE id = ? AND deleted_at IS NULL`, available, sku)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return domain.ErrProductNotFound
+	}
+	return nil
+}
+
+// Reserve takes items out of products.available and records the
+// reservation, named after its order, in one transaction.
+func (r *ProductRepository) Reserve(ctx context.Context, orderID string, items []domain.StockItem, at time.Time) (domain.Reservation, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return domain.Reservation{}, err
+	}
+	defer tx.Rollback()
+
+	// 1. A retried reservation finds the first one
+	existing, err := reservation(ctx, tx, orderID)
+	if err == nil {
+		return existing, nil
+	}
+	if !errors.Is(err, domain.ErrReservationNotFound) {
+		return domain.Reservation{}, err
+	}
+
+	// 2. Price every line and check it is in stock
+	res := domain.Reservation{ID: orderID, ReservedAt: at.UTC()}
+	for _, it := range items {
+		var (
+			price     float64
+			available int
+		)
+		err := tx.QueryRowContext(ctx, `SELECT price, available FROM products WHERE id = ? AND deleted_at IS NULL`, it.SKU).Scan(&price, &available)
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.Reservation{}, fmt.Errorf("%w: %s", domain.ErrProductNotFound, it.SKU)
+		}
+		if err != nil {
+			return domain.Reservation{}, err
+		}
+		if available < it.Quantity {
+			return domain.Reservation{}, fmt.Errorf("%w: %s has %d left", domain.ErrOutOfStock, it.SKU, available)
+		}
+		res.Lines = append(res.Lines, domain.ReservedLine{SKU: it.SKU, Quantity: it.Quantity, UnitPrice: price})
+	}
+
+	// 3. Take the stock and record the reservation
+	if _, err := tx.ExecContext(ctx, `INSERT INTO reservations (id, reserved_at) VALUES (?, ?)`, res.ID, res.ReservedAt); err != nil {
+		return domain.Reservation{}, err
+	}
+	for _, l := range res.Lines {
+		if _, err := tx.ExecContext(ctx, `UPDATE products SET available = available - ? WHERE id = ?`, l.Quantity, l.SKU); err != nil {
+			return domain.Reservation{}, err
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO reservation_lines (reservation_id, sku, quantity, unit_price) VALUES (?, ?, ?, ?)`,
+			res.ID, l.SKU, l.Quantity, l.UnitPrice); err != nil {
+			return domain.Reservation{}, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return domain.Reservation{}, err
+	}
+	return res, nil
+}
+
+// Release puts the reservation's stock back and forgets it.
+func (r *ProductRepository) Release(ctx context.Context, reservationID string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := reservation(ctx, tx, reservationID)
+	if err != nil {
+		return err
+	}
+	for _, l := range res.Lines {
+		if _, err := tx.ExecContext(ctx, `UPDATE products SET available = available + ? WHERE id = ?`, l.Quantity, l.SKU); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM reservation_lines WHERE reservation_id = ?`, reservationID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM reservations WHERE id = ?`, reservationID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// reservation loads a reservation with its lines, or returns
+// domain.ErrReservationNotFound.
+func reservation(ctx context.Context, tx *sql.Tx, id string) (domain.Reservation, error) {
+	res := domain.Reservation{ID: id}
+	err := tx.QueryRowContext(ctx, `SELECT reserved_at FROM reservations WHERE id = ?`, id).Scan(&res.ReservedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Reservation{}, domain.ErrReservationNotFound
+	}
+	if err != nil {
+		return domain.Reservation{}, err
+	}
+	rows, err := tx.QueryContext(ctx, `SELECT sku, quantity, unit_price FROM reservation_lines WHERE reservation_id = ? ORDER BY rowid`, id)
+	if err != nil {
+		return domain.Reservation{}, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var l domain.ReservedLine
+		if err := rows.Scan(&l.SKU, &l.Quantity, &l.UnitPrice); err != nil {
+			return domain.Reservation{}, err
+		}
+		res.Lines = append(res.Lines, l)
+	}
+	return res, rows.Err()
+}
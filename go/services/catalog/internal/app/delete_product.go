@@ -0,0 +1,62 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"clean-code-cookbook/go/services/catalog/internal/domain"
+	"clean-code-cookbook/go/services/catalog/internal/ports"
+)
+
+// DeleteProductCommand is a use case that soft-deletes a product. It drops
+// out of lookups and searches at once; PurgeDeletedProductsCommand removes
+// it for good later. Caches in front of the store may serve it until their
+// entries expire.
+type DeleteProductCommand struct {
+	ProductDeleter ports.ProductDeleter
+	// Events receives a ProductDeleted for every deleted product; without
+	// it nothing is told.
+	Events ports.ProductEventSink
+	// Now stamps the deletion; it defaults to time.Now.
+	Now func() time.Time
+}
+
+// Execute deletes the product with id, or returns domain.ErrProductNotFound.
+func (c *DeleteProductCommand) Execute(ctx context.Context, id string) error {
+	if err := c.ProductDeleter.DeleteProduct(ctx, id, now(c.Now)); err != nil {
+		return fmt.Errorf("failed to delete product with id %s: %w", id, err)
+	}
+	if c.Events == nil {
+		return nil
+	}
+	if err := c.Events.Apply(ctx, domain.ProductDeleted{ID: id}); err != nil {
+		return fmt.Errorf("product %s deleted, but failed to apply its event: %w", id, err)
+	}
+	return nil
+}
+
+// PurgeDeletedProductsCommand is the purge job: it removes products that
+// have been soft-deleted for longer than Retention.
+type PurgeDeletedProductsCommand struct {
+	ProductDeleter ports.ProductDeleter
+	Retention      time.Duration
+	// Now dates the cutoff; it defaults to time.Now.
+	Now func() time.Time
+}
+
+// Execute purges and returns how many products went.
+func (c *PurgeDeletedProductsCommand) Execute(ctx context.Context) (int, error) {
+	n, err := c.ProductDeleter.PurgeDeleted(ctx, now(c.Now).Add(-c.Retention))
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge deleted products: %w", err)
+	}
+	return n, nil
+}
+
+func now(clock func() time.Time) time.Time {
+	if clock == nil {
+		return time.Now()
+	}
+	return clock()
+}
@@ -0,0 +1,47 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"clean-code-cookbook/go/services/catalog/internal/domain"
+	"clean-code-cookbook/go/services/catalog/internal/ports"
+)
+
+// ReserveStockCommand is a use case that holds stock for an order, so the
+// order can only be placed for products that are there, at the price they
+// had when it was taken.
+type ReserveStockCommand struct {
+	StockKeeper ports.StockKeeper
+	// Now stamps the reservation; it defaults to time.Now.
+	Now func() time.Time
+}
+
+// Execute reserves items for orderID, or returns domain.ErrInvalidReservation,
+// domain.ErrProductNotFound or domain.ErrOutOfStock and reserves nothing.
+func (c *ReserveStockCommand) Execute(ctx context.Context, orderID string, items []domain.StockItem) (domain.Reservation, error) {
+	if err := domain.ValidateReservation(orderID, items); err != nil {
+		return domain.Reservation{}, err
+	}
+	r, err := c.StockKeeper.Reserve(ctx, orderID, items, now(c.Now))
+	if err != nil {
+		return domain.Reservation{}, fmt.Errorf("failed to reserve stock for order %s: %w", orderID, err)
+	}
+	return r, nil
+}
+
+// ReleaseStockCommand is a use case that gives a reservation's stock back,
+// for an order that was not placed after all.
+type ReleaseStockCommand struct {
+	StockKeeper ports.StockKeeper
+}
+
+// Execute releases the reservation with id, or returns
+// domain.ErrReservationNotFound.
+func (c *ReleaseStockCommand) Execute(ctx context.Context, id string) error {
+	if err := c.StockKeeper.Release(ctx, id); err != nil {
+		return fmt.Errorf("failed to release reservation %s: %w", id, err)
+	}
+	return nil
+}
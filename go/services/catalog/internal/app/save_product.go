@@ -0,0 +1,33 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"clean-code-cookbook/go/services/catalog/internal/domain"
+	"clean-code-cookbook/go/services/catalog/internal/ports"
+)
+
+// SaveProductCommand is a use case that creates or overwrites a product
+// and tells the read models about it.
+type SaveProductCommand struct {
+	ProductSaver ports.ProductSaver
+	// Events receives a ProductUpserted for every saved product; without
+	// it nothing is told.
+	Events ports.ProductEventSink
+}
+
+// Execute saves p. Saving again is safe, so a product whose event was not
+// applied can simply be saved again.
+func (c *SaveProductCommand) Execute(ctx context.Context, p domain.Product) error {
+	if err := c.ProductSaver.Save(ctx, p); err != nil {
+		return fmt.Errorf("failed to save product with id %s: %w", p.ID, err)
+	}
+	if c.Events == nil {
+		return nil
+	}
+	if err := c.Events.Apply(ctx, domain.ProductUpserted{Product: p}); err != nil {
+		return fmt.Errorf("product %s saved, but failed to apply its event: %w", p.ID, err)
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"clean-code-cookbook/go/pkg/page"
+	"clean-code-cookbook/go/services/catalog/internal/domain"
+	"clean-code-cookbook/go/services/catalog/internal/ports"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+// SearchProductsQuery is a use case that finds products matching a filter.
+// The searcher may be a SQL LIKE scan for small catalogs or a search engine
+// for large ones; the use case does not care which.
+type SearchProductsQuery struct {
+	ProductSearcher ports.ProductSearcher
+}
+
+// Execute validates the filter, applies the default page size, and runs the
+// search.
+func (q *SearchProductsQuery) Execute(ctx context.Context, filter domain.ProductFilter) (page.Page[domain.Product], error) {
+	if filter.MinPrice < 0 || filter.MaxPrice < 0 || (filter.MaxPrice > 0 && filter.MaxPrice < filter.MinPrice) {
+		return page.Page[domain.Product]{}, fmt.Errorf("%w: price range [%v, %v]", domain.ErrInvalidFilter, filter.MinPrice, filter.MaxPrice)
+	}
+	if f := filter.Page.Sort.Field; f != "" && !slices.Contains(domain.ProductSortFields, f) {
+		return page.Page[domain.Product]{}, fmt.Errorf("%w: %w %q", domain.ErrInvalidFilter, page.ErrInvalidSort, f)
+	}
+	filter.Page = filter.Page.Clamp(defaultSearchLimit, maxSearchLimit)
+
+	products, err := q.ProductSearcher.SearchProducts(ctx, filter)
+	if err != nil {
+		return page.Page[domain.Product]{}, fmt.Errorf("failed to search products for %q: %w", filter.Query, err)
+	}
+	return products, nil
+}
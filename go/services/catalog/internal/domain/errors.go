@@ -0,0 +1,50 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrProductNotFound is returned by ProductFetcher implementations when no
+// product has the requested ID.
+var ErrProductNotFound = errors.New("product not found")
+
+// ErrInvalidFilter is returned for a ProductFilter that can match nothing,
+// such as a MaxPrice below MinPrice.
+var ErrInvalidFilter = errors.New("invalid product filter")
+
+// ErrOutOfStock is returned by StockKeeper implementations when a product
+// has fewer left than a reservation asks for.
+var ErrOutOfStock = errors.New("out of stock")
+
+// ErrInvalidReservation is returned for a reservation request that cannot
+// be met as asked, such as one without items.
+var ErrInvalidReservation = errors.New("invalid reservation")
+
+// ErrReservationNotFound is returned by StockKeeper implementations when
+// no reservation has the requested ID.
+var ErrReservationNotFound = errors.New("reservation not found")
+
+// Product invariants; NewProduct, Rename and ChangePrice return them
+// wrapped in an InvariantError.
+var (
+	ErrInvalidSKU   = errors.New("invalid sku")
+	ErrInvalidName  = errors.New("invalid product name")
+	ErrInvalidPrice = errors.New("invalid price")
+	ErrInvalidStock = errors.New("invalid stock")
+)
+
+// InvariantError says which field of a product broke which rule. It
+// unwraps to one of the ErrInvalid sentinels, so callers can match with
+// errors.Is and still show Reason.
+type InvariantError struct {
+	Field  string
+	Reason string
+	Err    error
+}
+
+func (e *InvariantError) Error() string {
+	return fmt.Sprintf("%v: %s %s", e.Err, e.Field, e.Reason)
+}
+
+func (e *InvariantError) Unwrap() error { return e.Err }
@@ -0,0 +1,20 @@
+package domain
+
+// ProductEvent is a change to the catalog. Read models such as the search
+// index are kept up to date by applying these in order.
+type ProductEvent interface {
+	productEvent()
+}
+
+// ProductUpserted records that a product was created or changed.
+type ProductUpserted struct {
+	Product Product
+}
+
+// ProductDeleted records that a product was removed from the catalog.
+type ProductDeleted struct {
+	ID string
+}
+
+func (ProductUpserted) productEvent() {}
+func (ProductDeleted) productEvent()  {}
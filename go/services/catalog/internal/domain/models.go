@@ -1,10 +1,109 @@
 package domain
 
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
 // Product is the core domain model. It represents a product in our catalog.
 // Note that it contains no tags for JSON or database serialization.
-// This is a pure, business-logic-oriented struct.
+// This is a pure, business-logic-oriented struct. New products come from
+// NewProduct and change through Rename and ChangePrice, which keep its
+// invariants; adapters may still rebuild stored products field by field.
 type Product struct {
 	ID    string
 	Name  string
 	Price float64 // Use float64 for currency in this example, but consider a dedicated type in production.
-}
\ No newline at end of file
+	// UpdatedAt is when the product last changed.
+	UpdatedAt time.Time
+	// DeletedAt is set once the product is soft-deleted; stores hide it
+	// from lookups and searches unless the context asks (see WithDeleted).
+	DeletedAt *time.Time
+	// Stale marks a last-known-good or default copy served while the
+	// source of truth was failing.
+	Stale bool
+}
+
+// Limits on what a product may hold.
+const (
+	MaxNameLength = 200
+	MaxPrice      = 1_000_000
+)
+
+// skuPattern is an ID as merchants write them: letters, digits, dots,
+// dashes and underscores, starting with a letter or digit.
+var skuPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,63}$`)
+
+// NewProduct builds a product that holds the catalog's invariants: a
+// well-formed SKU as its ID, a non-blank name of at most MaxNameLength
+// characters and a price in [0, MaxPrice]. The name is trimmed.
+func NewProduct(sku, name string, price float64, at time.Time) (Product, error) {
+	if err := ValidateSKU(sku); err != nil {
+		return Product{}, err
+	}
+	p := Product{ID: sku, UpdatedAt: at}
+	if err := p.Rename(name, at); err != nil {
+		return Product{}, err
+	}
+	if err := p.ChangePrice(price, at); err != nil {
+		return Product{}, err
+	}
+	return p, nil
+}
+
+// Rename gives the product a new name, trimmed. An invalid name leaves
+// the product unchanged.
+func (p *Product) Rename(name string, at time.Time) error {
+	name = strings.TrimSpace(name)
+	switch n := utf8.RuneCountInString(name); {
+	case n == 0:
+		return &InvariantError{Field: "name", Reason: "must not be blank", Err: ErrInvalidName}
+	case n > MaxNameLength:
+		return &InvariantError{Field: "name", Reason: fmt.Sprintf("must be at most %d characters", MaxNameLength), Err: ErrInvalidName}
+	}
+	p.Name, p.UpdatedAt = name, at
+	return nil
+}
+
+// ChangePrice reprices the product. An invalid price leaves the product
+// unchanged.
+func (p *Product) ChangePrice(price float64, at time.Time) error {
+	if math.IsNaN(price) || price < 0 || price > MaxPrice {
+		return &InvariantError{Field: "price", Reason: fmt.Sprintf("must be between 0 and %d", MaxPrice), Err: ErrInvalidPrice}
+	}
+	p.Price, p.UpdatedAt = price, at
+	return nil
+}
+
+// ValidateSKU accepts up to 64 letters, digits, dots, dashes and
+// underscores, starting with a letter or digit.
+func ValidateSKU(sku string) error {
+	if !skuPattern.MatchString(sku) {
+		return &InvariantError{Field: "sku", Reason: "must be 1-64 letters, digits, '.', '-' or '_'", Err: ErrInvalidSKU}
+	}
+	return nil
+}
+
+// Deleted reports whether the product has been soft-deleted.
+func (p Product) Deleted() bool {
+	return p.DeletedAt != nil
+}
+
+type includeDeletedKey struct{}
+
+// WithDeleted makes lookups and searches with ctx return soft-deleted
+// products too, e.g. to audit or restore them.
+func WithDeleted(ctx context.Context) context.Context {
+	return context.WithValue(ctx, includeDeletedKey{}, true)
+}
+
+// IncludesDeleted reports whether ctx came from WithDeleted.
+func IncludesDeleted(ctx context.Context) bool {
+	include, _ := ctx.Value(includeDeletedKey{}).(bool)
+	return include
+}
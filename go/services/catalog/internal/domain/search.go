@@ -0,0 +1,87 @@
+package domain
+
+import (
+	"cmp"
+	"strconv"
+
+	"clean-code-cookbook/go/pkg/page"
+)
+
+// ProductSortFields are what a search can be sorted by besides relevance.
+var ProductSortFields = []string{"name", "price"}
+
+// ProductFilter narrows a product search. Zero values mean "no constraint".
+type ProductFilter struct {
+	// Query matches against the product name.
+	Query    string
+	MinPrice float64
+	MaxPrice float64
+	// Page picks the page, sorted by one of ProductSortFields; the zero
+	// Sort leaves the order to the searcher.
+	Page page.Request
+}
+
+// ProductCursor is where a search resumes: after the product whose sort
+// field and ID it holds. Cursors remember their sort, so one cannot be
+// replayed under another.
+type ProductCursor struct {
+	Sort  page.Sort
+	After Product
+}
+
+// Cursor packs c.
+func (c ProductCursor) Cursor() page.Cursor {
+	var key string
+	switch c.Sort.Field {
+	case "name":
+		key = c.After.Name
+	case "price":
+		key = strconv.FormatFloat(c.After.Price, 'g', -1, 64)
+	}
+	return page.NewCursor(c.Sort.String(), key, c.After.ID)
+}
+
+// ParseProductCursor unpacks a cursor issued under sort s; ok is false
+// for the empty cursor.
+func ParseProductCursor(cursor page.Cursor, s page.Sort) (c ProductCursor, ok bool, err error) {
+	if cursor == "" {
+		return ProductCursor{}, false, nil
+	}
+	keys, err := cursor.Keys(3)
+	if err != nil {
+		return ProductCursor{}, false, err
+	}
+	if keys[0] != s.String() {
+		return ProductCursor{}, false, page.ErrInvalidCursor
+	}
+	c = ProductCursor{Sort: s, After: Product{ID: keys[2]}}
+	switch s.Field {
+	case "name":
+		c.After.Name = keys[1]
+	case "price":
+		if c.After.Price, err = strconv.ParseFloat(keys[1], 64); err != nil {
+			return ProductCursor{}, false, page.ErrInvalidCursor
+		}
+	}
+	return c, true, nil
+}
+
+// CompareProducts orders a and b the way a search sorted by s does: by
+// the sort field, then by ID, both reversed when s is descending. The
+// zero Sort orders by ID alone.
+func CompareProducts(a, b Product, s page.Sort) int {
+	var c int
+	switch s.Field {
+	case "name":
+		c = cmp.Compare(a.Name, b.Name)
+	case "price":
+		c = cmp.Compare(a.Price, b.Price)
+	}
+	if c == 0 {
+		c = cmp.Compare(a.ID, b.ID)
+	}
+	if s.Desc {
+		return -c
+	}
+	return c
+}
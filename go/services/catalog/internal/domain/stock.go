@@ -0,0 +1,63 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// StockItem is a quantity of one product an order asks for.
+type StockItem struct {
+	SKU      string
+	Quantity int
+}
+
+// ReservedLine is stock held for a reservation, priced at what the product
+// cost when it was taken.
+type ReservedLine struct {
+	SKU       string
+	Quantity  int
+	UnitPrice float64
+}
+
+// Reservation is stock held for one order until the order is dropped. It
+// is named after the order, so reserving for the same order again finds
+// it instead of taking the stock twice.
+type Reservation struct {
+	ID         string
+	Lines      []ReservedLine
+	ReservedAt time.Time
+}
+
+// ValidateReservation checks a request to reserve items for orderID: an
+// order ID, at least one item, well-formed SKUs, positive quantities and
+// each SKU once.
+func ValidateReservation(orderID string, items []StockItem) error {
+	if orderID == "" {
+		return fmt.Errorf("%w: no order id", ErrInvalidReservation)
+	}
+	if len(items) == 0 {
+		return fmt.Errorf("%w: no items", ErrInvalidReservation)
+	}
+	seen := make(map[string]bool, len(items))
+	for _, it := range items {
+		if err := ValidateSKU(it.SKU); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidReservation, err)
+		}
+		if it.Quantity < 1 {
+			return fmt.Errorf("%w: quantity of %s must be positive", ErrInvalidReservation, it.SKU)
+		}
+		if seen[it.SKU] {
+			return fmt.Errorf("%w: %s listed twice", ErrInvalidReservation, it.SKU)
+		}
+		seen[it.SKU] = true
+	}
+	return nil
+}
+
+// ValidateStock accepts any stock level that is not negative.
+func ValidateStock(available int) error {
+	if available < 0 {
+		return &InvariantError{Field: "stock", Reason: "must not be negative", Err: ErrInvalidStock}
+	}
+	return nil
+}
@@ -0,0 +1,14 @@
+package ports
+
+import (
+	"context"
+
+	"clean-code-cookbook/go/services/catalog/internal/domain"
+)
+
+// ProductEventSink is a port for read models kept up to date from the
+// catalog's writes, such as the search index. Events arrive in the order
+// the writes happened.
+type ProductEventSink interface {
+	Apply(ctx context.Context, events ...domain.ProductEvent) error
+}
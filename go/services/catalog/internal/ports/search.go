@@ -0,0 +1,16 @@
+package ports
+
+import (
+	"context"
+
+	"clean-code-cookbook/go/pkg/page"
+	"clean-code-cookbook/go/services/catalog/internal/domain"
+)
+
+// ProductSearcher is a port for finding products by name and price.
+type ProductSearcher interface {
+	// SearchProducts returns at most filter.Page.Size matches, best first
+	// unless filter.Page.Sort says otherwise, and the cursor of the rest.
+	// A cursor from another searcher is page.ErrInvalidCursor.
+	SearchProducts(ctx context.Context, filter domain.ProductFilter) (page.Page[domain.Product], error)
+}
@@ -0,0 +1,25 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"clean-code-cookbook/go/services/catalog/internal/domain"
+)
+
+// StockKeeper is a port for stores that hold how much of each product is
+// left, and the reservations taken from it.
+type StockKeeper interface {
+	// Reserve takes items out of stock for orderID, all or nothing, and
+	// prices each line at the product's current price. It returns
+	// domain.ErrProductNotFound for an unknown or deleted product and
+	// domain.ErrOutOfStock when one runs short. Reserving again for the
+	// same orderID returns the first reservation.
+	Reserve(ctx context.Context, orderID string, items []domain.StockItem, at time.Time) (domain.Reservation, error)
+	// Release puts a reservation's stock back, or returns
+	// domain.ErrReservationNotFound.
+	Release(ctx context.Context, reservationID string) error
+	// Restock sets how many of a product are left to reserve, or returns
+	// domain.ErrProductNotFound.
+	Restock(ctx context.Context, sku string, available int) error
+}
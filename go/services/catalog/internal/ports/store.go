@@ -0,0 +1,25 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"clean-code-cookbook/go/services/catalog/internal/domain"
+)
+
+// ProductSaver is a port for stores that products can be written to.
+type ProductSaver interface {
+	// Save creates p, or overwrites the product with its ID.
+	Save(ctx context.Context, p domain.Product) error
+}
+
+// ProductDeleter is a port for stores that own product data. Deletes are
+// soft: the row stays, hidden, until PurgeDeleted removes it.
+type ProductDeleter interface {
+	// DeleteProduct marks the product deleted as of at. Unknown or already
+	// deleted products return domain.ErrProductNotFound.
+	DeleteProduct(ctx context.Context, id string, at time.Time) error
+	// PurgeDeleted removes products deleted before cutoff and returns how
+	// many went.
+	PurgeDeleted(ctx context.Context, cutoff time.Time) (int, error)
+}
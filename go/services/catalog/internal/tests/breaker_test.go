@@ -0,0 +1,159 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"clean-code-cookbook/go/pkg/breaker"
+	"clean-code-cookbook/go/pkg/bulkhead"
+	httpadapter "clean-code-cookbook/go/services/catalog/internal/adapter/http"
+)
+
+// newTestBreaker opens after 2 failures out of at least 4 calls, and is
+// driven by the returned clock.
+func newTestBreaker() (*breaker.Breaker, *time.Time) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	b := breaker.New("test")
+	b.MinRequests, b.FailureRate, b.Probes = 4, 0.5, 2
+	b.Now = func() time.Time { return now }
+	return b, &now
+}
+
+var errDown = errors.New("down")
+
+func callBreaker(b *breaker.Breaker, err error) error {
+	return b.Do(context.Background(), func(context.Context) error { return err })
+}
+
+func TestBreaker_OpensAtFailureRate(t *testing.T) {
+	// Arrange
+	b, _ := newTestBreaker()
+	var changes []string
+	b.OnStateChange = func(name string, from, to breaker.State) { changes = append(changes, from.String()+"->"+to.String()) }
+
+	// Act
+	callBreaker(b, nil)
+	callBreaker(b, nil)
+	callBreaker(b, errDown)
+	callBreaker(b, errDown)
+	err := callBreaker(b, nil)
+
+	// Assert
+	if !errors.Is(err, breaker.ErrOpen) {
+		t.Fatalf("Expected error '%v', but got '%v'", breaker.ErrOpen, err)
+	}
+	if len(changes) != 1 || changes[0] != "closed->open" {
+		t.Errorf("Expected one closed->open change, but got %v", changes)
+	}
+}
+
+func TestBreaker_StaysClosedBelowMinRequests(t *testing.T) {
+	// Arrange
+	b, _ := newTestBreaker()
+
+	// Act
+	callBreaker(b, errDown)
+	callBreaker(b, errDown)
+	callBreaker(b, errDown)
+
+	// Assert
+	if b.State() != breaker.Closed {
+		t.Errorf("Expected closed, but got %s", b.State())
+	}
+}
+
+func TestBreaker_OldFailuresLeaveTheWindow(t *testing.T) {
+	// Arrange
+	b, now := newTestBreaker()
+	callBreaker(b, errDown)
+	callBreaker(b, errDown)
+	callBreaker(b, errDown)
+	*now = now.Add(b.Window)
+
+	// Act
+	callBreaker(b, errDown)
+
+	// Assert
+	if b.State() != breaker.Closed {
+		t.Errorf("Expected closed once old failures expired, but got %s", b.State())
+	}
+}
+
+func TestBreaker_HalfOpenProbesClose(t *testing.T) {
+	// Arrange
+	b, now := newTestBreaker()
+	for i := 0; i < 4; i++ {
+		callBreaker(b, errDown)
+	}
+	*now = now.Add(b.Cooldown)
+
+	// Act
+	state := b.State()
+	first, second := callBreaker(b, nil), callBreaker(b, nil)
+
+	// Assert
+	if state != breaker.HalfOpen {
+		t.Fatalf("Expected half-open after the cooldown, but got %s", state)
+	}
+	if first != nil || second != nil || b.State() != breaker.Closed {
+		t.Errorf("Expected the probes to close the breaker, but got %v, %v and %s", first, second, b.State())
+	}
+}
+
+func TestBreaker_HalfOpenFailureReopens(t *testing.T) {
+	// Arrange
+	b, now := newTestBreaker()
+	for i := 0; i < 4; i++ {
+		callBreaker(b, errDown)
+	}
+	*now = now.Add(b.Cooldown)
+
+	// Act
+	callBreaker(b, errDown)
+
+	// Assert
+	if b.State() != breaker.Open {
+		t.Errorf("Expected open after a failed probe, but got %s", b.State())
+	}
+}
+
+func TestHTTPFetcher_BreakerIgnoresNotFound(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+	fetcher := httpadapter.NewProductFetcher(server.URL, server.Client())
+	fetcher.Breaker.MinRequests = 1
+
+	// Act
+	for i := 0; i < 5; i++ {
+		fetcher.FetchProductByID(context.Background(), "missing")
+	}
+
+	// Assert
+	if state := fetcher.Breaker.State(); state != breaker.Closed {
+		t.Errorf("Expected not-found answers to keep the breaker closed, but got %s", state)
+	}
+}
+
+func TestHTTPFetcher_BulkheadCapsConcurrentRequests(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+	fetcher := httpadapter.NewProductFetcher(server.URL, server.Client())
+	fetcher.Bulkhead = bulkhead.New("upstream", 1, 0)
+	fetcher.Retry.Attempts = 1
+	release, _ := fetcher.Bulkhead.Acquire(context.Background())
+	defer release()
+
+	// Act
+	_, err := fetcher.FetchProductByID(context.Background(), "123")
+
+	// Assert
+	if !errors.Is(err, bulkhead.ErrFull) {
+		t.Errorf("Expected error '%v', but got '%v'", bulkhead.ErrFull, err)
+	}
+}
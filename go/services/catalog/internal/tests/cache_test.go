@@ -0,0 +1,111 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"clean-code-cookbook/go/pkg/cache"
+	"clean-code-cookbook/go/services/catalog/internal/adapter/cached"
+	"clean-code-cookbook/go/services/catalog/internal/domain"
+)
+
+func TestLRU_EvictsLeastRecentlyUsed(t *testing.T) {
+	// Arrange
+	c := cache.NewLRU[string, int](2, 0)
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.Get("a")
+
+	// Act
+	c.Set("c", 3)
+
+	// Assert
+	if _, ok := c.Get("b"); ok {
+		t.Error("Expected 'b' to be evicted, but it is still cached")
+	}
+	if _, ok := c.Get("a"); !ok {
+		t.Error("Expected 'a' to survive as recently used, but it was evicted")
+	}
+	if got := c.Stats().Evictions; got != 1 {
+		t.Errorf("Expected 1 eviction, but got %d", got)
+	}
+}
+
+func TestLRU_ExpiresEntries(t *testing.T) {
+	// Arrange
+	c := cache.NewLRU[string, int](10, time.Millisecond)
+	c.Set("a", 1)
+	time.Sleep(5 * time.Millisecond)
+
+	// Act
+	_, ok := c.Get("a")
+
+	// Assert
+	if ok {
+		t.Error("Expected 'a' to have expired, but it is still cached")
+	}
+	if got := c.Stats().Expirations; got != 1 {
+		t.Errorf("Expected 1 expiration, but got %d", got)
+	}
+}
+
+func TestLRU_GetOrLoadIsSingleFlight(t *testing.T) {
+	// Arrange
+	c := cache.NewLRU[string, int](10, time.Minute)
+	var loads atomic.Int32
+	release := make(chan struct{})
+	load := func(ctx context.Context) (int, error) {
+		loads.Add(1)
+		<-release
+		return 42, nil
+	}
+
+	// Act
+	var wg sync.WaitGroup
+	results := make([]int, 10)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], _ = c.GetOrLoad(context.Background(), "answer", load)
+		}(i)
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	// Assert
+	if got := loads.Load(); got != 1 {
+		t.Errorf("Expected 1 load, but got %d", got)
+	}
+	for _, v := range results {
+		if v != 42 {
+			t.Fatalf("Expected every caller to get 42, but got %v", results)
+		}
+	}
+}
+
+func TestCachedProductFetcher_DoesNotCacheFailures(t *testing.T) {
+	// Arrange
+	upstream := &mockProductFetcher{mockedError: errors.New("network error")}
+	fetcher := cached.NewProductFetcher(upstream, cache.NewLRU[string, domain.Product](10, time.Minute))
+	ctx := context.Background()
+	_, _ = fetcher.FetchProductByID(ctx, "123")
+
+	// Act
+	upstream.mockedError = nil
+	upstream.mockedProduct = &domain.Product{ID: "123", Name: "Test Product", Price: 99.99}
+	product, err := fetcher.FetchProductByID(ctx, "123")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if product.Name != "Test Product" {
+		t.Errorf("Expected product name 'Test Product', but got '%s'", product.Name)
+	}
+}
@@ -0,0 +1,62 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"clean-code-cookbook/go/services/catalog/internal/adapter/chaos"
+	"clean-code-cookbook/go/services/catalog/internal/adapter/composite"
+	"clean-code-cookbook/go/services/catalog/internal/adapter/memory"
+)
+
+func TestChaosFetcher_InjectsErrors(t *testing.T) {
+	// Arrange
+	fetcher := chaos.NewProductFetcher(memory.NewProductFetcher(staleProduct), 0, 0.5)
+	fetcher.Rand = func() float64 { return 0.1 }
+
+	// Act
+	_, err := fetcher.FetchProductByID(context.Background(), staleProduct.ID)
+
+	// Assert
+	if !errors.Is(err, chaos.ErrInjected) {
+		t.Fatalf("Expected error '%v', but got '%v'", chaos.ErrInjected, err)
+	}
+}
+
+func TestChaosFetcher_PassesThroughBelowTheRate(t *testing.T) {
+	// Arrange
+	fetcher := chaos.NewProductFetcher(memory.NewProductFetcher(staleProduct), 0, 0.5)
+	fetcher.Rand = func() float64 { return 0.9 }
+
+	// Act
+	p, err := fetcher.FetchProductByID(context.Background(), staleProduct.ID)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if p.ID != staleProduct.ID {
+		t.Errorf("Expected product %s, but got %s", staleProduct.ID, p.ID)
+	}
+}
+
+func TestChaosFetcher_CompositeSurvivesSlowUpstream(t *testing.T) {
+	// Arrange
+	fetcher := composite.NewProductFetcher(
+		composite.Source{Name: "upstream", Fetcher: chaos.NewProductFetcher(memory.NewProductFetcher(), time.Second, 0), Timeout: 10 * time.Millisecond},
+		composite.Source{Name: "local", Fetcher: memory.NewProductFetcher(staleProduct)},
+	)
+
+	// Act
+	p, err := fetcher.FetchProductByID(context.Background(), staleProduct.ID)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected the local copy, but got: %v", err)
+	}
+	if p.Name != staleProduct.Name {
+		t.Errorf("Expected %q, but got %q", staleProduct.Name, p.Name)
+	}
+}
@@ -0,0 +1,209 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"clean-code-cookbook/go/services/catalog/internal/adapter/composite"
+	httpadapter "clean-code-cookbook/go/services/catalog/internal/adapter/http"
+	"clean-code-cookbook/go/services/catalog/internal/adapter/memory"
+	"clean-code-cookbook/go/services/catalog/internal/domain"
+	"clean-code-cookbook/go/services/catalog/internal/ports"
+)
+
+// countingFetcher counts calls before delegating.
+type countingFetcher struct {
+	next  ports.ProductFetcher
+	calls int
+}
+
+func (c *countingFetcher) FetchProductByID(ctx context.Context, id string) (*domain.Product, error) {
+	c.calls++
+	return c.next.FetchProductByID(ctx, id)
+}
+
+// blockingFetcher never answers before ctx is done.
+type blockingFetcher struct{}
+
+func (blockingFetcher) FetchProductByID(ctx context.Context, id string) (*domain.Product, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+var staleProduct = domain.Product{ID: "123", Name: "Stale Product", Price: 9.99}
+
+func TestCompositeFetcher_FallsBackWhenPrimaryFails(t *testing.T) {
+	// Arrange
+	fetcher := composite.NewProductFetcher(
+		composite.Source{Name: "upstream", Fetcher: &mockProductFetcher{mockedError: errors.New("network error")}},
+		composite.Source{Name: "local", Fetcher: memory.NewProductFetcher(staleProduct)},
+	)
+
+	// Act
+	product, err := fetcher.FetchProductByID(context.Background(), "123")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if product.Name != staleProduct.Name {
+		t.Errorf("Expected the local copy '%s', but got '%s'", staleProduct.Name, product.Name)
+	}
+}
+
+func TestCompositeFetcher_AppliesPerSourceTimeout(t *testing.T) {
+	// Arrange
+	fetcher := composite.NewProductFetcher(
+		composite.Source{Name: "upstream", Fetcher: blockingFetcher{}, Timeout: 10 * time.Millisecond},
+		composite.Source{Name: "local", Fetcher: memory.NewProductFetcher(staleProduct)},
+	)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// Act
+	product, err := fetcher.FetchProductByID(ctx, "123")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if product.ID != "123" {
+		t.Errorf("Expected product '123', but got '%s'", product.ID)
+	}
+}
+
+func TestCompositeFetcher_NotFoundEndsTheChain(t *testing.T) {
+	// Arrange
+	local := &countingFetcher{next: memory.NewProductFetcher(staleProduct)}
+	fetcher := composite.NewProductFetcher(
+		composite.Source{Name: "upstream", Fetcher: memory.NewProductFetcher()},
+		composite.Source{Name: "local", Fetcher: local},
+	)
+
+	// Act
+	_, err := fetcher.FetchProductByID(context.Background(), "123")
+
+	// Assert
+	if !errors.Is(err, domain.ErrProductNotFound) {
+		t.Fatalf("Expected error '%v', but got '%v'", domain.ErrProductNotFound, err)
+	}
+	if local.calls != 0 {
+		t.Errorf("Expected the local copy to be skipped, but it was called %d times", local.calls)
+	}
+}
+
+func TestCompositeFetcher_SkipsUnhealthySourceDuringCooldown(t *testing.T) {
+	// Arrange
+	upstream := &countingFetcher{next: &mockProductFetcher{mockedError: errors.New("network error")}}
+	fetcher := composite.NewProductFetcher(
+		composite.Source{Name: "upstream", Fetcher: upstream},
+		composite.Source{Name: "local", Fetcher: memory.NewProductFetcher(staleProduct)},
+	)
+	fetcher.FailureThreshold = 2
+	ctx := context.Background()
+
+	// Act
+	for i := 0; i < 5; i++ {
+		if _, err := fetcher.FetchProductByID(ctx, "123"); err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+	}
+
+	// Assert
+	if upstream.calls != 2 {
+		t.Errorf("Expected 2 upstream calls before the cooldown, but got %d", upstream.calls)
+	}
+	if health := fetcher.Health(); health[0].Healthy || !health[1].Healthy {
+		t.Errorf("Expected only the upstream to be unhealthy, but got %+v", health)
+	}
+}
+
+func TestCompositeFetcher_ReportsEveryFailure(t *testing.T) {
+	// Arrange
+	fetcher := composite.NewProductFetcher(
+		composite.Source{Name: "upstream", Fetcher: &mockProductFetcher{mockedError: errors.New("network error")}},
+		composite.Source{Name: "local", Fetcher: &mockProductFetcher{mockedError: errors.New("disk error")}},
+	)
+
+	// Act
+	_, err := fetcher.FetchProductByID(context.Background(), "123")
+
+	// Assert
+	if err == nil {
+		t.Fatal("Expected an error, but got none")
+	}
+	for _, want := range []string{"upstream: network error", "local: disk error"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error to mention '%s', but got '%v'", want, err)
+		}
+	}
+}
+
+func TestHTTPFetcher_MapsNotFound(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/products/123" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"id":"123","name":"Test Product","price":99.99}`))
+	}))
+	defer server.Close()
+	fetcher := httpadapter.NewProductFetcher(server.URL, server.Client())
+	ctx := context.Background()
+
+	// Act
+	product, err := fetcher.FetchProductByID(ctx, "123")
+	_, missing := fetcher.FetchProductByID(ctx, "456")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if product.Name != "Test Product" || product.Price != 99.99 {
+		t.Errorf("Expected the decoded product, but got %+v", product)
+	}
+	if !errors.Is(missing, domain.ErrProductNotFound) {
+		t.Errorf("Expected error '%v', but got '%v'", domain.ErrProductNotFound, missing)
+	}
+}
+
+func TestHTTPFetcher_RetriesServerErrorsButNotNotFound(t *testing.T) {
+	// Arrange
+	calls := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls[r.URL.Path]++
+		switch {
+		case r.URL.Path == "/products/123" && calls[r.URL.Path] < 3:
+			http.Error(w, "overloaded", http.StatusServiceUnavailable)
+		case r.URL.Path == "/products/123":
+			w.Write([]byte(`{"id":"123","name":"Test Product","price":99.99}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	fetcher := httpadapter.NewProductFetcher(server.URL, server.Client())
+	fetcher.Retry.Backoff = nil
+	ctx := context.Background()
+
+	// Act
+	_, err := fetcher.FetchProductByID(ctx, "123")
+	_, missing := fetcher.FetchProductByID(ctx, "456")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected the third attempt to succeed, but got: %v", err)
+	}
+	if calls["/products/123"] != 3 {
+		t.Errorf("Expected 3 attempts, but got %d", calls["/products/123"])
+	}
+	if !errors.Is(missing, domain.ErrProductNotFound) || calls["/products/456"] != 1 {
+		t.Errorf("Expected a single not-found attempt, but got %d and '%v'", calls["/products/456"], missing)
+	}
+}
@@ -0,0 +1,129 @@
+package tests
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"clean-code-cookbook/go/services/catalog/internal/adapter/memory"
+	"clean-code-cookbook/go/services/catalog/internal/adapter/sqlite"
+	"clean-code-cookbook/go/services/catalog/internal/app"
+	"clean-code-cookbook/go/services/catalog/internal/domain"
+	"clean-code-cookbook/go/services/catalog/internal/ports"
+)
+
+// productStore is what the delete tests need of a store.
+type productStore interface {
+	ports.ProductFetcher
+	ports.ProductSearcher
+	ports.ProductDeleter
+}
+
+func productStores(t *testing.T) map[string]productStore {
+	t.Helper()
+	repo, err := sqlite.Open(context.Background(), ":memory:")
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	t.Cleanup(func() { repo.Close() })
+	for _, p := range searchProducts {
+		if err := repo.Save(context.Background(), p); err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+	}
+	return map[string]productStore{"sqlite": repo, "memory": memory.NewProductFetcher(searchProducts...)}
+}
+
+func TestDeleteProductCommand_IsSoft(t *testing.T) {
+	for name, store := range productStores(t) {
+		t.Run(name, func(t *testing.T) {
+			// Arrange
+			ctx := context.Background()
+			at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+			del := app.DeleteProductCommand{ProductDeleter: store, Now: func() time.Time { return at }}
+
+			// Act
+			err := del.Execute(ctx, "sku-1")
+			again := del.Execute(ctx, "sku-1")
+
+			// Assert
+			if err != nil {
+				t.Fatalf("Expected no error, but got: %v", err)
+			}
+			if !errors.Is(again, domain.ErrProductNotFound) {
+				t.Errorf("Expected error '%v', but got '%v'", domain.ErrProductNotFound, again)
+			}
+			if _, err := store.FetchProductByID(ctx, "sku-1"); !errors.Is(err, domain.ErrProductNotFound) {
+				t.Errorf("Expected error '%v', but got '%v'", domain.ErrProductNotFound, err)
+			}
+			if found, _ := store.SearchProducts(ctx, domain.ProductFilter{Query: "clean"}); len(found.Items) != 1 || found.Items[0].ID != "sku-2" {
+				t.Errorf("Expected only sku-2 to match, but got %+v", found.Items)
+			}
+			kept, err := store.FetchProductByID(domain.WithDeleted(ctx), "sku-1")
+			if err != nil {
+				t.Fatalf("Expected no error, but got: %v", err)
+			}
+			if kept.DeletedAt == nil || !kept.DeletedAt.Equal(at) || !kept.UpdatedAt.Equal(at) {
+				t.Errorf("Expected the product deleted and updated at %v, but got %+v", at, kept)
+			}
+		})
+	}
+}
+
+func TestPurgeDeletedProductsCommand(t *testing.T) {
+	for name, store := range productStores(t) {
+		t.Run(name, func(t *testing.T) {
+			// Arrange
+			ctx := context.Background()
+			now := time.Now()
+			_ = store.DeleteProduct(ctx, "sku-1", now.Add(-48*time.Hour))
+			_ = store.DeleteProduct(ctx, "sku-2", now)
+			purge := app.PurgeDeletedProductsCommand{ProductDeleter: store, Retention: 24 * time.Hour}
+
+			// Act
+			n, err := purge.Execute(ctx)
+
+			// Assert
+			if err != nil || n != 1 {
+				t.Fatalf("Expected 1 product purged, but got %d, %v", n, err)
+			}
+			if _, err := store.FetchProductByID(domain.WithDeleted(ctx), "sku-1"); !errors.Is(err, domain.ErrProductNotFound) {
+				t.Errorf("Expected error '%v', but got '%v'", domain.ErrProductNotFound, err)
+			}
+			if _, err := store.FetchProductByID(domain.WithDeleted(ctx), "sku-2"); err != nil {
+				t.Errorf("Expected the recent deletion to be kept, but got: %v", err)
+			}
+		})
+	}
+}
+
+func TestSQLiteOpen_AddsColumnsToExistingFile(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	dsn := "file:" + filepath.Join(t.TempDir(), "catalog.db")
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	_, err = db.ExecContext(ctx, `CREATE TABLE products (id TEXT PRIMARY KEY, name TEXT NOT NULL, price REAL NOT NULL);
+		INSERT INTO products VALUES ('sku-1', 'Clean Code', 39.99)`)
+	db.Close()
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+
+	// Act
+	repo, err := sqlite.Open(ctx, dsn)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	defer repo.Close()
+	if p, err := repo.FetchProductByID(ctx, "sku-1"); err != nil || p.Name != "Clean Code" || p.Deleted() {
+		t.Errorf("Expected the old row to read as a live product, but got %+v, %v", p, err)
+	}
+}
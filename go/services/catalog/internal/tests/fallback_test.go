@@ -0,0 +1,95 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"clean-code-cookbook/go/services/catalog/internal/adapter/fallback"
+	"clean-code-cookbook/go/services/catalog/internal/domain"
+)
+
+// switchableFetcher answers with product until err is set.
+type switchableFetcher struct {
+	product domain.Product
+	err     error
+}
+
+func (f *switchableFetcher) FetchProductByID(ctx context.Context, id string) (*domain.Product, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	p := f.product
+	return &p, nil
+}
+
+func TestFallbackFetcher_ServesLastKnownGood(t *testing.T) {
+	// Arrange
+	source := &switchableFetcher{product: staleProduct}
+	fetcher := fallback.NewProductFetcher(source, 10, time.Hour)
+	ctx := context.Background()
+	if _, err := fetcher.FetchProductByID(ctx, staleProduct.ID); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	source.err = errors.New("upstream returned 502 Bad Gateway")
+
+	// Act
+	p, err := fetcher.FetchProductByID(ctx, staleProduct.ID)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected the last-known-good copy, but got: %v", err)
+	}
+	if !p.Stale || p.Name != staleProduct.Name {
+		t.Errorf("Expected a stale %q, but got %+v", staleProduct.Name, p)
+	}
+}
+
+func TestFallbackFetcher_ServesDefaultForUnseenProducts(t *testing.T) {
+	// Arrange
+	fetcher := fallback.NewProductFetcher(&switchableFetcher{err: errors.New("down")}, 10, time.Hour)
+	fetcher.Default = &domain.Product{Name: "Temporarily unavailable"}
+
+	// Act
+	p, err := fetcher.FetchProductByID(context.Background(), "sku-9")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected the default, but got: %v", err)
+	}
+	if p.ID != "sku-9" || !p.Stale || p.Name != "Temporarily unavailable" {
+		t.Errorf("Expected the stale default for sku-9, but got %+v", p)
+	}
+}
+
+func TestFallbackFetcher_PassesNotFoundThrough(t *testing.T) {
+	// Arrange
+	source := &switchableFetcher{product: staleProduct}
+	fetcher := fallback.NewProductFetcher(source, 10, time.Hour)
+	fetcher.Default = &domain.Product{Name: "Temporarily unavailable"}
+	fetcher.FetchProductByID(context.Background(), staleProduct.ID)
+	source.err = domain.ErrProductNotFound
+
+	// Act
+	_, err := fetcher.FetchProductByID(context.Background(), staleProduct.ID)
+
+	// Assert
+	if !errors.Is(err, domain.ErrProductNotFound) {
+		t.Errorf("Expected error '%v', but got '%v'", domain.ErrProductNotFound, err)
+	}
+}
+
+func TestFallbackFetcher_WithoutCopyOrDefaultReturnsError(t *testing.T) {
+	// Arrange
+	down := errors.New("down")
+	fetcher := fallback.NewProductFetcher(&switchableFetcher{err: down}, 10, time.Hour)
+
+	// Act
+	_, err := fetcher.FetchProductByID(context.Background(), "sku-9")
+
+	// Assert
+	if !errors.Is(err, down) {
+		t.Errorf("Expected error '%v', but got '%v'", down, err)
+	}
+}
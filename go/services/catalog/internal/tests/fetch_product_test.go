@@ -4,6 +4,9 @@ import (
 	"context"
 	"errors"
 	"testing"
+
+	"clean-code-cookbook/go/services/catalog/internal/adapter/memory"
+	"clean-code-cookbook/go/services/catalog/internal/adapter/sqlite"
 	"clean-code-cookbook/go/services/catalog/internal/app"
 	"clean-code-cookbook/go/services/catalog/internal/domain"
 )
@@ -69,4 +72,48 @@ func TestFetchProductQuery_Execute_FetcherError(t *testing.T) {
 	if !errors.Is(err, expectedError) {
 		t.Fatalf("Expected error '%v', but got '%v'", expectedError, err)
 	}
-}
\ No newline at end of file
+}
+
+func TestFetchProductQuery_Execute_InMemoryNotFound(t *testing.T) {
+	// Arrange
+	fetcher := memory.NewProductFetcher(domain.Product{ID: "123", Name: "Test Product", Price: 99.99})
+	query := app.FetchProductQuery{ProductFetcher: fetcher}
+	ctx := context.Background()
+
+	// Act
+	_, err := query.Execute(ctx, "456")
+
+	// Assert
+	if !errors.Is(err, domain.ErrProductNotFound) {
+		t.Fatalf("Expected error '%v', but got '%v'", domain.ErrProductNotFound, err)
+	}
+}
+
+func TestFetchProductQuery_Execute_SQLite(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	repo, err := sqlite.Open(ctx, "file::memory:")
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	defer repo.Close()
+	if err := repo.Save(ctx, domain.Product{ID: "123", Name: "Test Product", Price: 99.99}); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	query := app.FetchProductQuery{ProductFetcher: repo}
+
+	// Act
+	product, err := query.Execute(ctx, "123")
+	_, missingErr := query.Execute(ctx, "456")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if product.Name != "Test Product" || product.Price != 99.99 {
+		t.Errorf("Expected the saved product, but got %+v", product)
+	}
+	if !errors.Is(missingErr, domain.ErrProductNotFound) {
+		t.Errorf("Expected error '%v', but got '%v'", domain.ErrProductNotFound, missingErr)
+	}
+}
@@ -0,0 +1,179 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"clean-code-cookbook/go/pkg/edgetoken"
+	httpadapter "clean-code-cookbook/go/services/catalog/internal/adapter/http"
+	"clean-code-cookbook/go/services/catalog/internal/adapter/memory"
+	"clean-code-cookbook/go/services/catalog/internal/app"
+	"clean-code-cookbook/go/services/catalog/internal/domain"
+)
+
+// newCatalogServer serves the read API over searchProducts.
+func newCatalogServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	store := memory.NewProductFetcher(searchProducts...)
+	mux := http.NewServeMux()
+	httpadapter.NewHandler(
+		&app.FetchProductQuery{ProductFetcher: store},
+		&app.SearchProductsQuery{ProductSearcher: store},
+		log.New(io.Discard, "", 0),
+	).Register(mux)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// call sends an empty request and returns the answer's status and body.
+func call(t *testing.T, method, url string) (int, []byte) {
+	t.Helper()
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	return resp.StatusCode, body
+}
+
+func TestHandler_ServesProductsAndSearch(t *testing.T) {
+	// Arrange
+	srv := newCatalogServer(t)
+
+	// Act
+	found, foundBody := call(t, http.MethodGet, srv.URL+"/products/sku-1")
+	search, searchBody := call(t, http.MethodGet, srv.URL+"/products?q=clean&sort=-price&size=1")
+	missing, _ := call(t, http.MethodGet, srv.URL+"/products/sku-404")
+	badSort, _ := call(t, http.MethodGet, srv.URL+"/products?sort=stock")
+	post, _ := call(t, http.MethodPost, srv.URL+"/products/sku-1")
+
+	// Assert
+	var product struct {
+		ID    string  `json:"id"`
+		Name  string  `json:"name"`
+		Price float64 `json:"price"`
+	}
+	if err := json.Unmarshal(foundBody, &product); err != nil || found != http.StatusOK {
+		t.Fatalf("Expected the product, but got %d, %v", found, err)
+	}
+	if product.ID != "sku-1" || product.Name != "Clean Code" || product.Price != 39.99 {
+		t.Errorf("Unexpected product %+v", product)
+	}
+	var page struct {
+		Items []struct {
+			ID string `json:"id"`
+		} `json:"items"`
+		Next string `json:"next"`
+	}
+	if err := json.Unmarshal(searchBody, &page); err != nil || search != http.StatusOK {
+		t.Fatalf("Expected a page, but got %d, %v", search, err)
+	}
+	if len(page.Items) != 1 || page.Items[0].ID != "sku-1" || page.Next == "" {
+		t.Errorf("Expected sku-1 and a next cursor, but got %+v", page)
+	}
+	for _, c := range []struct {
+		name string
+		got  int
+		want int
+	}{
+		{"missing", missing, http.StatusNotFound},
+		{"bad sort", badSort, http.StatusBadRequest},
+		{"post", post, http.StatusMethodNotAllowed},
+	} {
+		if c.got != c.want {
+			t.Errorf("%s: expected status %d, but got %d", c.name, c.want, c.got)
+		}
+	}
+}
+
+func TestProductFetcher_ReadsAnotherCatalogsHandler(t *testing.T) {
+	// Arrange
+	srv := newCatalogServer(t)
+	fetcher := httpadapter.NewProductFetcher(srv.URL, srv.Client())
+
+	// Act
+	product, err := fetcher.FetchProductByID(context.Background(), "sku-3")
+	_, missingErr := fetcher.FetchProductByID(context.Background(), "sku-404")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if product.Name != "Refactoring" || product.Price != 44.50 {
+		t.Errorf("Unexpected product %+v", product)
+	}
+	if !errors.Is(missingErr, domain.ErrProductNotFound) {
+		t.Errorf("Expected error '%v', but got '%v'", domain.ErrProductNotFound, missingErr)
+	}
+}
+
+func TestHandler_BehindTheEdgeOnlyServesVouchedCallers(t *testing.T) {
+	// Arrange
+	mux := http.NewServeMux()
+	store := memory.NewProductFetcher(searchProducts...)
+	httpadapter.NewHandler(&app.FetchProductQuery{ProductFetcher: store}, &app.SearchProductsQuery{ProductSearcher: store}, log.New(io.Discard, "", 0)).Register(mux)
+	edge := edgetoken.NewSigner([]byte("internal-secret"))
+	srv := httptest.NewServer(edge.Middleware("catalog", mux))
+	defer srv.Close()
+	alice := edgetoken.Caller{Subject: "u-1"}
+	valid, _ := edge.Sign(alice, "catalog")
+	forged, _ := edgetoken.NewSigner([]byte("guessed")).Sign(alice, "catalog")
+	misaddressed, _ := edge.Sign(alice, "orders")
+	stale := edgetoken.NewSigner([]byte("internal-secret"))
+	stale.Now = func() time.Time { return time.Now().Add(-time.Minute) }
+	expired, _ := stale.Sign(alice, "catalog")
+
+	get := func(token string) int {
+		req, err := http.NewRequest(http.MethodGet, srv.URL+"/products/sku-1", nil)
+		if err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+		if token != "" {
+			req.Header.Set(edgetoken.Header, token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	// Act
+	statuses := map[string]int{
+		"vouched":              get(valid),
+		"unsigned":             get(""),
+		"forged":               get(forged),
+		"for another upstream": get(misaddressed),
+		"expired":              get(expired),
+	}
+
+	// Assert
+	for name, want := range map[string]int{
+		"vouched":              http.StatusOK,
+		"unsigned":             http.StatusUnauthorized,
+		"forged":               http.StatusUnauthorized,
+		"for another upstream": http.StatusUnauthorized,
+		"expired":              http.StatusUnauthorized,
+	} {
+		if got := statuses[name]; got != want {
+			t.Errorf("%s: expected status %d, but got %d", name, want, got)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"clean-code-cookbook/go/services/catalog/internal/adapter/hedged"
+	"clean-code-cookbook/go/services/catalog/internal/domain"
+)
+
+// scriptedFetcher answers each call with the next step, after its delay.
+// Calls beyond the script get the last step.
+type scriptedFetcher struct {
+	steps     []scriptedStep
+	calls     atomic.Int32
+	cancelled atomic.Int32
+}
+
+type scriptedStep struct {
+	delay time.Duration
+	err   error
+}
+
+func (f *scriptedFetcher) FetchProductByID(ctx context.Context, id string) (*domain.Product, error) {
+	n := int(f.calls.Add(1)) - 1
+	step := f.steps[min(n, len(f.steps)-1)]
+	select {
+	case <-time.After(step.delay):
+	case <-ctx.Done():
+		f.cancelled.Add(1)
+		return nil, ctx.Err()
+	}
+	if step.err != nil {
+		return nil, step.err
+	}
+	return &domain.Product{ID: id, Name: fmt.Sprintf("call %d", n+1)}, nil
+}
+
+func TestHedgedFetcher_FastPrimaryIsNotHedged(t *testing.T) {
+	// Arrange
+	next := &scriptedFetcher{steps: []scriptedStep{{delay: 0}}}
+	fetcher := hedged.NewProductFetcher(next, 50*time.Millisecond)
+
+	// Act
+	_, err := fetcher.FetchProductByID(context.Background(), "sku-1")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if fetcher.Hedges() != 0 || next.calls.Load() != 1 {
+		t.Errorf("Expected a single call, but got %d calls and %d hedges", next.calls.Load(), fetcher.Hedges())
+	}
+}
+
+func TestHedgedFetcher_SlowPrimaryLosesToTwin(t *testing.T) {
+	// Arrange
+	next := &scriptedFetcher{steps: []scriptedStep{{delay: time.Second}, {delay: 0}}}
+	fetcher := hedged.NewProductFetcher(next, 10*time.Millisecond)
+
+	// Act
+	start := time.Now()
+	p, err := fetcher.FetchProductByID(context.Background(), "sku-1")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if p.Name != "call 2" || time.Since(start) > 500*time.Millisecond {
+		t.Errorf("Expected the twin's answer without waiting for the primary, but got %q after %s", p.Name, time.Since(start))
+	}
+	waitFor(t, func() bool { return next.cancelled.Load() == 1 })
+}
+
+func TestHedgedFetcher_FailedPrimaryWaitsForTwin(t *testing.T) {
+	// Arrange
+	down := errors.New("connection reset")
+	next := &scriptedFetcher{steps: []scriptedStep{{delay: 30 * time.Millisecond, err: down}, {delay: 50 * time.Millisecond}}}
+	fetcher := hedged.NewProductFetcher(next, 10*time.Millisecond)
+
+	// Act
+	p, err := fetcher.FetchProductByID(context.Background(), "sku-1")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected the twin to answer, but got: %v", err)
+	}
+	if p.Name != "call 2" {
+		t.Errorf("Expected the twin's answer, but got %q", p.Name)
+	}
+}
+
+func TestHedgedFetcher_NotFoundIsAnAnswer(t *testing.T) {
+	// Arrange
+	next := &scriptedFetcher{steps: []scriptedStep{{delay: 20 * time.Millisecond, err: domain.ErrProductNotFound}, {delay: time.Second}}}
+	fetcher := hedged.NewProductFetcher(next, 10*time.Millisecond)
+
+	// Act
+	start := time.Now()
+	_, err := fetcher.FetchProductByID(context.Background(), "sku-1")
+
+	// Assert
+	if !errors.Is(err, domain.ErrProductNotFound) || time.Since(start) > 500*time.Millisecond {
+		t.Errorf("Expected an early '%v', but got '%v' after %s", domain.ErrProductNotFound, err, time.Since(start))
+	}
+}
+
+// waitFor polls cond for up to a second.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		if cond() {
+			return
+		}
+	}
+	t.Fatal("Expected the condition to hold within 1s")
+}
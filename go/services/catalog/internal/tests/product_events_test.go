@@ -0,0 +1,78 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"clean-code-cookbook/go/services/catalog/internal/adapter/sqlite"
+	"clean-code-cookbook/go/services/catalog/internal/app"
+	"clean-code-cookbook/go/services/catalog/internal/domain"
+)
+
+// recordingSink stands in for the search index, failing while err is set.
+type recordingSink struct {
+	applied []domain.ProductEvent
+	err     error
+}
+
+func (s *recordingSink) Apply(_ context.Context, events ...domain.ProductEvent) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.applied = append(s.applied, events...)
+	return nil
+}
+
+func TestProductWrites_ApplyTheirEvents(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	repo, err := sqlite.Open(ctx, ":memory:")
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	defer repo.Close()
+	sink := &recordingSink{}
+	save := app.SaveProductCommand{ProductSaver: repo, Events: sink}
+	del := app.DeleteProductCommand{ProductDeleter: repo, Events: sink}
+	product := domain.Product{ID: "sku-1", Name: "Clean Code", Price: 39.99}
+
+	// Act
+	saveErr := save.Execute(ctx, product)
+	deleteErr := del.Execute(ctx, "sku-1")
+	missingErr := del.Execute(ctx, "sku-1")
+
+	// Assert
+	if err := errors.Join(saveErr, deleteErr); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if !errors.Is(missingErr, domain.ErrProductNotFound) {
+		t.Errorf("Expected error '%v', but got '%v'", domain.ErrProductNotFound, missingErr)
+	}
+	want := []domain.ProductEvent{domain.ProductUpserted{Product: product}, domain.ProductDeleted{ID: "sku-1"}}
+	if len(sink.applied) != len(want) || sink.applied[0] != want[0] || sink.applied[1] != want[1] {
+		t.Errorf("Expected events %+v, but got %+v", want, sink.applied)
+	}
+}
+
+func TestSaveProductCommand_ReportsAnEventThatWasNotApplied(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	repo, err := sqlite.Open(ctx, ":memory:")
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	defer repo.Close()
+	save := app.SaveProductCommand{ProductSaver: repo, Events: &recordingSink{err: errors.New("index down")}}
+
+	// Act
+	err = save.Execute(ctx, domain.Product{ID: "sku-1", Name: "Clean Code", Price: 39.99})
+
+	// Assert
+	if err == nil {
+		t.Fatal("Expected an error, but got nil")
+	}
+	if _, fetchErr := repo.FetchProductByID(ctx, "sku-1"); fetchErr != nil {
+		t.Errorf("Expected the product saved anyway, but got: %v", fetchErr)
+	}
+}
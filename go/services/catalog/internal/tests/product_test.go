@@ -0,0 +1,85 @@
+package tests
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"clean-code-cookbook/go/services/catalog/internal/domain"
+)
+
+func TestNewProduct_EnforcesInvariants(t *testing.T) {
+	at := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name    string
+		sku     string
+		product string
+		price   float64
+		want    error
+	}{
+		{"valid", "SKU-1.a_b", "  Clean Code ", 39.99, nil},
+		{"free", "sku-1", "Sample", 0, nil},
+		{"empty sku", "", "Clean Code", 1, domain.ErrInvalidSKU},
+		{"sku with spaces", "sku 1", "Clean Code", 1, domain.ErrInvalidSKU},
+		{"sku too long", strings.Repeat("a", 65), "Clean Code", 1, domain.ErrInvalidSKU},
+		{"blank name", "sku-1", "   ", 1, domain.ErrInvalidName},
+		{"name too long", "sku-1", strings.Repeat("é", domain.MaxNameLength+1), 1, domain.ErrInvalidName},
+		{"negative price", "sku-1", "Clean Code", -0.01, domain.ErrInvalidPrice},
+		{"NaN price", "sku-1", "Clean Code", math.NaN(), domain.ErrInvalidPrice},
+		{"infinite price", "sku-1", "Clean Code", math.Inf(1), domain.ErrInvalidPrice},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Act
+			p, err := domain.NewProduct(c.sku, c.product, c.price, at)
+
+			// Assert
+			if !errors.Is(err, c.want) {
+				t.Fatalf("Expected error '%v', but got '%v'", c.want, err)
+			}
+			if c.want != nil {
+				var invariant *domain.InvariantError
+				if !errors.As(err, &invariant) || invariant.Reason == "" {
+					t.Errorf("Expected an InvariantError with a reason, but got %#v", err)
+				}
+				return
+			}
+			if p.ID != c.sku || p.Name != strings.TrimSpace(c.product) || p.Price != c.price || !p.UpdatedAt.Equal(at) {
+				t.Errorf("Unexpected product %+v", p)
+			}
+		})
+	}
+}
+
+func TestProduct_ChangePriceAndRename(t *testing.T) {
+	// Arrange
+	created := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	later := created.Add(time.Hour)
+	p, err := domain.NewProduct("sku-1", "Clean Code", 39.99, created)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+
+	// Act
+	badPrice := p.ChangePrice(-1, later)
+	badName := p.Rename("", later)
+	unchanged := p
+	priceErr := p.ChangePrice(29.99, later)
+	nameErr := p.Rename("Clean Code, 2nd ed.", later)
+
+	// Assert
+	if !errors.Is(badPrice, domain.ErrInvalidPrice) || !errors.Is(badName, domain.ErrInvalidName) {
+		t.Errorf("Expected errors '%v' and '%v', but got '%v' and '%v'", domain.ErrInvalidPrice, domain.ErrInvalidName, badPrice, badName)
+	}
+	if unchanged.Price != 39.99 || unchanged.Name != "Clean Code" || !unchanged.UpdatedAt.Equal(created) {
+		t.Errorf("Expected rejected changes to leave the product alone, but got %+v", unchanged)
+	}
+	if priceErr != nil || nameErr != nil {
+		t.Fatalf("Expected no errors, but got '%v' and '%v'", priceErr, nameErr)
+	}
+	if p.Price != 29.99 || p.Name != "Clean Code, 2nd ed." || !p.UpdatedAt.Equal(later) {
+		t.Errorf("Expected the product repriced and renamed at %v, but got %+v", later, p)
+	}
+}
@@ -0,0 +1,167 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	httpadapter "clean-code-cookbook/go/services/catalog/internal/adapter/http"
+	"clean-code-cookbook/go/services/catalog/internal/adapter/memory"
+	"clean-code-cookbook/go/services/catalog/internal/adapter/sqlite"
+	"clean-code-cookbook/go/services/catalog/internal/app"
+	"clean-code-cookbook/go/services/catalog/internal/domain"
+	"clean-code-cookbook/go/services/catalog/internal/ports"
+)
+
+// stockStores are the stores that keep stock, each holding searchProducts
+// with three of sku-1 and none of sku-2.
+func stockStores(t *testing.T) map[string]ports.StockKeeper {
+	t.Helper()
+	ctx := context.Background()
+	repo, err := sqlite.Open(ctx, ":memory:")
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	t.Cleanup(func() { repo.Close() })
+	for _, p := range searchProducts {
+		if err := repo.Save(ctx, p); err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+	}
+	stores := map[string]ports.StockKeeper{"sqlite": repo, "memory": memory.NewProductFetcher(searchProducts...)}
+	for _, s := range stores {
+		if err := s.Restock(ctx, "sku-1", 3); err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+	}
+	return stores
+}
+
+func TestReserveStockCommand_IsAllOrNothingAndReleasable(t *testing.T) {
+	for name, store := range stockStores(t) {
+		t.Run(name, func(t *testing.T) {
+			// Arrange
+			ctx := context.Background()
+			at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+			reserve := app.ReserveStockCommand{StockKeeper: store, Now: func() time.Time { return at }}
+			release := app.ReleaseStockCommand{StockKeeper: store}
+
+			// Act
+			_, shortErr := reserve.Execute(ctx, "o-1", []domain.StockItem{{SKU: "sku-1", Quantity: 2}, {SKU: "sku-2", Quantity: 1}})
+			first, err := reserve.Execute(ctx, "o-2", []domain.StockItem{{SKU: "sku-1", Quantity: 2}})
+			retried, retryErr := reserve.Execute(ctx, "o-2", []domain.StockItem{{SKU: "sku-1", Quantity: 2}})
+			_, drainedErr := reserve.Execute(ctx, "o-3", []domain.StockItem{{SKU: "sku-1", Quantity: 2}})
+			releaseErr := release.Execute(ctx, "o-2")
+			_, refilledErr := reserve.Execute(ctx, "o-3", []domain.StockItem{{SKU: "sku-1", Quantity: 3}})
+			againErr := release.Execute(ctx, "o-2")
+			_, unknownErr := reserve.Execute(ctx, "o-4", []domain.StockItem{{SKU: "sku-404", Quantity: 1}})
+
+			// Assert
+			if !errors.Is(shortErr, domain.ErrOutOfStock) {
+				t.Errorf("Expected error '%v', but got '%v'", domain.ErrOutOfStock, shortErr)
+			}
+			if err != nil || retryErr != nil {
+				t.Fatalf("Expected no errors, but got: %v, %v", err, retryErr)
+			}
+			want := []domain.ReservedLine{{SKU: "sku-1", Quantity: 2, UnitPrice: 39.99}}
+			if first.ID != "o-2" || len(first.Lines) != 1 || first.Lines[0] != want[0] {
+				t.Errorf("Expected %v reserved as o-2, but got %+v", want, first)
+			}
+			if retried.ID != first.ID || len(retried.Lines) != 1 || retried.Lines[0] != want[0] {
+				t.Errorf("Expected the retry to return %+v, but got %+v", first, retried)
+			}
+			if !errors.Is(drainedErr, domain.ErrOutOfStock) {
+				t.Errorf("Expected the retry to take no more stock, but got '%v'", drainedErr)
+			}
+			if releaseErr != nil || refilledErr != nil {
+				t.Errorf("Expected releasing to give the stock back, but got: %v, %v", releaseErr, refilledErr)
+			}
+			if !errors.Is(againErr, domain.ErrReservationNotFound) {
+				t.Errorf("Expected error '%v', but got '%v'", domain.ErrReservationNotFound, againErr)
+			}
+			if !errors.Is(unknownErr, domain.ErrProductNotFound) {
+				t.Errorf("Expected error '%v', but got '%v'", domain.ErrProductNotFound, unknownErr)
+			}
+		})
+	}
+}
+
+func TestReserveStockCommand_RejectsMalformedRequests(t *testing.T) {
+	// Arrange
+	reserve := app.ReserveStockCommand{StockKeeper: memory.NewProductFetcher(searchProducts...)}
+	requests := map[string][]domain.StockItem{
+		"no items":      nil,
+		"zero quantity": {{SKU: "sku-1", Quantity: 0}},
+		"bad sku":       {{SKU: "-sku", Quantity: 1}},
+		"repeated sku":  {{SKU: "sku-1", Quantity: 1}, {SKU: "sku-1", Quantity: 1}},
+	}
+
+	for name, items := range requests {
+		// Act
+		_, err := reserve.Execute(context.Background(), "o-1", items)
+
+		// Assert
+		if !errors.Is(err, domain.ErrInvalidReservation) {
+			t.Errorf("%s: Expected error '%v', but got '%v'", name, domain.ErrInvalidReservation, err)
+		}
+	}
+}
+
+func TestReservationHandler_ServesTheOrdersServicesCalls(t *testing.T) {
+	// Arrange
+	store := stockStores(t)["memory"]
+	mux := http.NewServeMux()
+	httpadapter.NewReservationHandler(
+		&app.ReserveStockCommand{StockKeeper: store},
+		&app.ReleaseStockCommand{StockKeeper: store},
+		log.New(io.Discard, "", 0),
+	).Register(mux)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	post := func(body string) (int, []byte) {
+		resp, err := http.Post(srv.URL+"/reservations", "application/json", bytes.NewBufferString(body))
+		if err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+		defer resp.Body.Close()
+		raw, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, raw
+	}
+
+	// Act
+	created, body := post(`{"order_id":"o-1","items":[{"sku":"sku-1","quantity":2}]}`)
+	short, _ := post(`{"order_id":"o-2","items":[{"sku":"sku-2","quantity":1}]}`)
+	unknown, _ := post(`{"order_id":"o-3","items":[{"sku":"sku-404","quantity":1}]}`)
+	malformed, _ := post(`{"order_id":"o-4","items":[]}`)
+	released, _ := call(t, http.MethodDelete, srv.URL+"/reservations/o-1")
+	gone, _ := call(t, http.MethodDelete, srv.URL+"/reservations/o-1")
+
+	// Assert
+	var res struct {
+		ID    string `json:"id"`
+		Lines []struct {
+			SKU       string  `json:"sku"`
+			Quantity  int     `json:"quantity"`
+			UnitPrice float64 `json:"unit_price"`
+		} `json:"lines"`
+	}
+	if err := json.Unmarshal(body, &res); err != nil || created != http.StatusCreated {
+		t.Fatalf("Expected the reservation, but got %d, %v", created, err)
+	}
+	if res.ID != "o-1" || len(res.Lines) != 1 || res.Lines[0].SKU != "sku-1" || res.Lines[0].Quantity != 2 || res.Lines[0].UnitPrice != 39.99 {
+		t.Errorf("Unexpected reservation %+v", res)
+	}
+	if short != http.StatusConflict || unknown != http.StatusUnprocessableEntity || malformed != http.StatusBadRequest {
+		t.Errorf("Expected 409, 422 and 400, but got %d, %d and %d", short, unknown, malformed)
+	}
+	if released != http.StatusNoContent || gone != http.StatusNotFound {
+		t.Errorf("Expected 204 then 404, but got %d and %d", released, gone)
+	}
+}
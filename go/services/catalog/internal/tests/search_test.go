@@ -0,0 +1,218 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"clean-code-cookbook/go/pkg/page"
+	"clean-code-cookbook/go/services/catalog/internal/adapter/elasticsearch"
+	"clean-code-cookbook/go/services/catalog/internal/adapter/memory"
+	"clean-code-cookbook/go/services/catalog/internal/adapter/sqlite"
+	"clean-code-cookbook/go/services/catalog/internal/app"
+	"clean-code-cookbook/go/services/catalog/internal/domain"
+	"clean-code-cookbook/go/services/catalog/internal/ports"
+)
+
+var searchProducts = []domain.Product{
+	{ID: "sku-1", Name: "Clean Code", Price: 39.99},
+	{ID: "sku-2", Name: "Clean Architecture", Price: 29.99},
+	{ID: "sku-3", Name: "Refactoring", Price: 44.50},
+	{ID: "sku-4", Name: "100% Go_lang", Price: 10},
+}
+
+func TestSearchProductsQuery_RejectsInvertedPriceRange(t *testing.T) {
+	// Arrange
+	query := app.SearchProductsQuery{ProductSearcher: memory.NewProductFetcher(searchProducts...)}
+
+	// Act
+	_, err := query.Execute(context.Background(), domain.ProductFilter{MinPrice: 50, MaxPrice: 10})
+
+	// Assert
+	if !errors.Is(err, domain.ErrInvalidFilter) {
+		t.Fatalf("Expected error '%v', but got '%v'", domain.ErrInvalidFilter, err)
+	}
+}
+
+func TestSQLiteSearch_FiltersByNameAndPrice(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	repo, err := sqlite.Open(ctx, ":memory:")
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	defer repo.Close()
+	for _, p := range searchProducts {
+		_ = repo.Save(ctx, p)
+	}
+	query := app.SearchProductsQuery{ProductSearcher: repo}
+
+	// Act
+	clean, err := query.Execute(ctx, domain.ProductFilter{Query: "clean", MaxPrice: 30})
+	literal, _ := query.Execute(ctx, domain.ProductFilter{Query: "%"})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if len(clean.Items) != 1 || clean.Items[0].ID != "sku-2" {
+		t.Errorf("Expected only sku-2, but got %+v", clean.Items)
+	}
+	if len(literal.Items) != 1 || literal.Items[0].ID != "sku-4" {
+		t.Errorf("Expected '%%' to match literally, but got %+v", literal.Items)
+	}
+}
+
+func TestSearchProductsQuery_RejectsUnknownSort(t *testing.T) {
+	// Arrange
+	query := app.SearchProductsQuery{ProductSearcher: memory.NewProductFetcher(searchProducts...)}
+
+	// Act
+	_, err := query.Execute(context.Background(), domain.ProductFilter{Page: page.Request{Sort: page.Sort{Field: "id; DROP TABLE products"}}})
+
+	// Assert
+	if !errors.Is(err, domain.ErrInvalidFilter) || !errors.Is(err, page.ErrInvalidSort) {
+		t.Fatalf("Expected error '%v', but got '%v'", page.ErrInvalidSort, err)
+	}
+}
+
+func TestSearchProducts_PagesThroughSortedResults(t *testing.T) {
+	ctx := context.Background()
+	repo, err := sqlite.Open(ctx, ":memory:")
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	defer repo.Close()
+	for _, p := range searchProducts {
+		_ = repo.Save(ctx, p)
+	}
+
+	for name, searcher := range map[string]ports.ProductSearcher{
+		"memory": memory.NewProductFetcher(searchProducts...),
+		"sqlite": repo,
+	} {
+		t.Run(name, func(t *testing.T) {
+			// Arrange
+			query := app.SearchProductsQuery{ProductSearcher: searcher}
+			req := page.Request{Size: 3, Sort: page.Sort{Field: "price", Desc: true}}
+
+			// Act
+			first, err := query.Execute(ctx, domain.ProductFilter{Page: req})
+			if err != nil {
+				t.Fatalf("Expected no error, but got: %v", err)
+			}
+			req.After = first.Next
+			second, err := query.Execute(ctx, domain.ProductFilter{Page: req})
+			if err != nil {
+				t.Fatalf("Expected no error, but got: %v", err)
+			}
+			req.Sort.Desc = false
+			_, replayed := query.Execute(ctx, domain.ProductFilter{Page: req})
+
+			// Assert
+			got := append(ids(first.Items), ids(second.Items)...)
+			if strings.Join(got, ",") != "sku-3,sku-1,sku-2,sku-4" {
+				t.Errorf("Expected products by descending price, but got %v", got)
+			}
+			if first.Next == "" || second.Next != "" {
+				t.Errorf("Expected a cursor after the first page only, but got %q and %q", first.Next, second.Next)
+			}
+			if !errors.Is(replayed, page.ErrInvalidCursor) {
+				t.Errorf("Expected error '%v' for a cursor from another sort, but got '%v'", page.ErrInvalidCursor, replayed)
+			}
+		})
+	}
+}
+
+func ids(products []domain.Product) []string {
+	out := make([]string, len(products))
+	for i, p := range products {
+		out[i] = p.ID
+	}
+	return out
+}
+
+func TestElasticsearchIndex_ApplySendsBulkRequest(t *testing.T) {
+	// Arrange
+	var lines []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/_bulk" || r.Header.Get("Content-Type") != "application/x-ndjson" {
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		raw, _ := io.ReadAll(r.Body)
+		lines = strings.Split(strings.TrimSpace(string(raw)), "\n")
+		w.Write([]byte(`{"errors":true,"items":[{"index":{"_id":"sku-1","status":201}},{"delete":{"_id":"sku-9","status":404}}]}`))
+	}))
+	defer server.Close()
+	index := elasticsearch.NewIndex(server.URL, "products", server.Client())
+
+	// Act
+	err := index.Apply(context.Background(),
+		domain.ProductUpserted{Product: searchProducts[0]},
+		domain.ProductDeleted{ID: "sku-9"},
+	)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if len(lines) != 3 {
+		t.Fatalf("Expected 3 NDJSON lines (index, doc, delete), but got %d: %q", len(lines), lines)
+	}
+	if !strings.Contains(lines[1], `"name":"Clean Code"`) || !strings.Contains(lines[2], `"delete"`) {
+		t.Errorf("Unexpected bulk body: %q", lines)
+	}
+}
+
+func TestElasticsearchIndex_ApplyReportsItemFailures(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"errors":true,"items":[{"index":{"_id":"sku-1","status":400,"error":{"type":"mapper_parsing_exception","reason":"bad price"}}}]}`))
+	}))
+	defer server.Close()
+	index := elasticsearch.NewIndex(server.URL, "products", server.Client())
+
+	// Act
+	err := index.Apply(context.Background(), domain.ProductUpserted{Product: searchProducts[0]})
+
+	// Assert
+	if err == nil || !strings.Contains(err.Error(), "mapper_parsing_exception") {
+		t.Errorf("Expected the item failure to be reported, but got: %v", err)
+	}
+}
+
+func TestElasticsearchIndex_SearchBuildsQueryDSL(t *testing.T) {
+	// Arrange
+	var body map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/products/_search" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		w.Write([]byte(`{"hits":{"hits":[{"_source":{"id":"sku-2","name":"Clean Architecture","price":29.99}}]}}`))
+	}))
+	defer server.Close()
+	index := elasticsearch.NewIndex(server.URL, "products", server.Client())
+
+	// Act
+	products, err := index.SearchProducts(context.Background(), domain.ProductFilter{Query: "clean", MaxPrice: 30, Page: page.Request{Size: 5, Sort: page.Sort{Field: "name"}}})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if len(products.Items) != 1 || products.Items[0].ID != "sku-2" || products.Next != "" {
+		t.Errorf("Expected sku-2 alone, but got %+v", products)
+	}
+	raw, _ := json.Marshal(body)
+	for _, want := range []string{`"match":{"name":{"fuzziness":"AUTO","operator":"and","query":"clean"}}`, `"range":{"price":{"lte":30}}`, `"size":6`, `"sort":[{"name.raw":"asc"},{"id":"asc"}]`} {
+		if !strings.Contains(string(raw), want) {
+			t.Errorf("Expected query to contain %s, but got %s", want, raw)
+		}
+	}
+}
@@ -0,0 +1,280 @@
+package main
+
+import (
+	"errors"
+	"expvar"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"clean-code-cookbook/go/services/edge/internal/adapter/gateway"
+	adapter "clean-code-cookbook/go/services/edge/internal/adapter/grpc"
+	"clean-code-cookbook/go/services/edge/internal/adapter/sse"
+	"clean-code-cookbook/go/services/edge/internal/adapter/ws"
+	"clean-code-cookbook/go/services/edge/internal/identity"
+	"clean-code-cookbook/go/services/edge/internal/upstream"
+)
+
+// app is the gateway wired from the environment, shared by every command.
+// Nothing listens or dials yet: gRPC connections are made on first use.
+type app struct {
+	log       *log.Logger
+	registry  *upstream.Registry
+	users     *adapter.UserClient
+	userCache *gateway.ResponseCache
+	broker    *sse.Broker
+	hub       *ws.Hub
+	routes    []gateway.Route
+	mux       *http.ServeMux
+}
+
+func bootstrap() (*app, error) {
+	a := &app{log: log.New(os.Stdout, "[edge] ", log.LstdFlags)}
+
+	// 1. The upstreams come from EDGE_UPSTREAMS_FILE (see upstream.Load) or,
+	// without it, from the older USERS_GRPC_* and CATALOG_* variables.
+	// Each gets its own breaker, retry budget and per-attempt timeout,
+	// below the route's EDGE_UPSTREAM_TIMEOUT (default 5s); their counters
+	// are published with expvar.
+	timeout, err := time.ParseDuration(env("EDGE_UPSTREAM_TIMEOUT", "5s"))
+	if err != nil {
+		return nil, fmt.Errorf("EDGE_UPSTREAM_TIMEOUT: %w", err)
+	}
+	if a.registry, err = loadUpstreams(timeout); err != nil {
+		return nil, fmt.Errorf("upstreams: %w", err)
+	}
+	ok := false
+	defer func() {
+		if !ok {
+			a.registry.Close()
+		}
+	}()
+	// Callers the gateway authenticates are vouched for to every upstream
+	// with internal tokens signed with EDGE_IDENTITY_SECRET. Upstreams
+	// refuse unsigned calls, so only APP_ENV dev or test may go without.
+	if secret := os.Getenv("EDGE_IDENTITY_SECRET"); secret != "" {
+		a.registry.Signer = identity.NewSigner([]byte(secret))
+	} else if profile := os.Getenv("APP_ENV"); profile != "dev" && profile != "test" {
+		return nil, fmt.Errorf("EDGE_IDENTITY_SECRET is required for APP_ENV %q", profile)
+	}
+
+	if a.users, err = a.registry.UserClient("users"); err != nil {
+		return nil, fmt.Errorf("users upstream: %w", err)
+	}
+	upstreams := map[string]*upstreamMetrics{"users": watchUpstream(a.users.Breaker, a.users.Retries, a.log)}
+	var catalog *gateway.ResilientTransport
+	var catalogURL string
+	if a.registry.Has("catalog") {
+		if catalog, catalogURL, err = a.registry.HTTPTransport("catalog"); err != nil {
+			return nil, fmt.Errorf("catalog upstream: %w", err)
+		}
+		upstreams["catalog"] = watchUpstream(catalog.Breaker, catalog.Retries, a.log)
+	}
+	expvar.Publish("upstreams", expvar.Func(func() any {
+		snapshot := make(map[string]any, len(upstreams))
+		for name, m := range upstreams {
+			snapshot[name] = m.snapshot()
+		}
+		return snapshot
+	}))
+
+	// 2. Cache upstream reads for EDGE_CACHE_TTL (default 30s, 0 turns
+	// caching off), and bridge StreamUserEvents to WebSocket and streaming
+	// HTTP clients once serving; the same events clear cached users.
+	cacheTTL, err := time.ParseDuration(env("EDGE_CACHE_TTL", "30s"))
+	if err != nil {
+		return nil, fmt.Errorf("EDGE_CACHE_TTL: %w", err)
+	}
+	a.userCache = gateway.NewResponseCache(10_000, cacheTTL)
+	productCache := gateway.NewResponseCache(10_000, cacheTTL)
+	a.users.OnStreamError = func(err error, retryIn time.Duration) {
+		a.log.Printf("user events: stream ended (%v), reconnecting in %s", err, retryIn)
+	}
+	brokerOpts, err := eventStreamOptions()
+	if err != nil {
+		return nil, fmt.Errorf("event stream: %w", err)
+	}
+	a.broker = sse.NewBroker(brokerOpts, a.log)
+	expvar.Publish("events", expvar.Func(func() any { return a.broker.Stats() }))
+	a.hub = ws.NewHub(ws.DefaultOptions, a.log)
+
+	a.mux = http.NewServeMux()
+	a.mux.Handle("/ws/events", a.hub.Handler(ws.StaticToken(os.Getenv("EDGE_WS_TOKEN")), nil))
+
+	// 3. The API gateway: /api/users to the users service, /api/products
+	// to the catalog when one is configured, /api/profile to both,
+	// /api/events to the event stream, and the upstreams' web_services to
+	// browsers over gRPC-Web.
+	auth, err := apiAuth(a.log)
+	if err != nil {
+		return nil, fmt.Errorf("gateway auth: %w", err)
+	}
+	if a.routes, err = apiRoutes(a.users, catalog, catalogURL, auth, timeout, a.userCache, productCache, a.log); err != nil {
+		return nil, fmt.Errorf("gateway: %w", err)
+	}
+	webRoutes, err := grpcWebRoutes(a.registry, auth, a.log)
+	if err != nil {
+		return nil, fmt.Errorf("grpc-web: %w", err)
+	}
+	a.routes = append(a.routes, webRoutes...)
+	a.routes = append(a.routes, gateway.Route{
+		Prefix:     "/api/events",
+		Handler:    a.broker.Handler(),
+		Middleware: []gateway.Middleware{gateway.AccessLog(a.log, "events"), auth},
+	})
+	// EDGE_TRANSFORMS_FILE reshapes routes' JSON and headers for public
+	// clients; see gateway.LoadTransforms.
+	if path := os.Getenv("EDGE_TRANSFORMS_FILE"); path != "" {
+		transforms, err := gateway.LoadTransformsFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("gateway: %w", err)
+		}
+		if err := gateway.ApplyTransforms(a.routes, transforms); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	gateway.Mount(a.mux, a.routes...)
+	ok = true
+	return a, nil
+}
+
+// apiRoutes configures the gateway's routes. Users and profile calls go
+// through auth; product reads are public. User and product reads go
+// through their caches. Each route gives its upstreams timeout; without a
+// catalog only the users side is served.
+func apiRoutes(users gateway.Users, catalog *gateway.ResilientTransport, catalogURL string, auth gateway.Middleware, timeout time.Duration,
+	userCache, productCache *gateway.ResponseCache, logger *log.Logger) ([]gateway.Route, error) {
+	routes := []gateway.Route{{
+		Prefix:  "/api/users",
+		Handler: gateway.NewUsersHandler(users, logger),
+		Middleware: []gateway.Middleware{
+			gateway.AccessLog(logger, "users"),
+			auth,
+			userCache.Middleware,
+			gateway.Timeout(timeout),
+		},
+	}}
+
+	// The profile leaves products out without a catalog.
+	profile := func(catalog gateway.Catalog) gateway.Route {
+		return gateway.Route{
+			Prefix:  "/api/profile",
+			Handler: gateway.NewProfileHandler(users, catalog, logger),
+			Middleware: []gateway.Middleware{
+				gateway.AccessLog(logger, "profile"),
+				auth,
+				gateway.Methods(http.MethodGet),
+				gateway.Timeout(timeout),
+			},
+		}
+	}
+
+	if catalog == nil {
+		logger.Printf("gateway: no catalog upstream, /api/products is not served")
+		return append(routes, profile(nil)), nil
+	}
+	products, err := gateway.NewProductsProxy(catalogURL, catalog, logger)
+	if err != nil {
+		return nil, err
+	}
+	return append(routes, profile(gateway.NewCatalogClient(catalogURL, catalog)), gateway.Route{
+		Prefix:  "/api/products",
+		Handler: products,
+		Middleware: []gateway.Middleware{
+			gateway.AccessLog(logger, "products"),
+			gateway.Methods(http.MethodGet, http.MethodHead),
+			productCache.Middleware,
+			gateway.Timeout(timeout),
+		},
+	}), nil
+}
+
+// eventStreamOptions reads the /api/events settings: EDGE_EVENTS_BUFFER,
+// how many events may queue per client (default 256), and
+// EDGE_EVENTS_SLOW_CONSUMER, "disconnect" (the default) or "drop" for
+// clients that fall that far behind.
+func eventStreamOptions() (sse.Options, error) {
+	opts := sse.DefaultOptions
+	buffer, err := strconv.Atoi(env("EDGE_EVENTS_BUFFER", strconv.Itoa(opts.Buffer)))
+	if err != nil || buffer < 1 {
+		return opts, fmt.Errorf("EDGE_EVENTS_BUFFER must be a positive number, got %q", os.Getenv("EDGE_EVENTS_BUFFER"))
+	}
+	opts.Buffer = buffer
+	switch policy := env("EDGE_EVENTS_SLOW_CONSUMER", "disconnect"); policy {
+	case "disconnect":
+		opts.Policy = sse.Disconnect
+	case "drop":
+		opts.Policy = sse.Drop
+	default:
+		return opts, fmt.Errorf("EDGE_EVENTS_SLOW_CONSUMER must be disconnect or drop, got %q", policy)
+	}
+	return opts, nil
+}
+
+// grpcWebRoutes mounts every gRPC service an upstream exposes to browsers
+// at /<service>/, behind auth. EDGE_GRPC_WEB_ORIGINS (comma-separated)
+// lists the page origins allowed to call them cross-origin.
+func grpcWebRoutes(registry *upstream.Registry, auth gateway.Middleware, logger *log.Logger) ([]gateway.Route, error) {
+	var origins []string
+	for _, origin := range strings.Split(os.Getenv("EDGE_GRPC_WEB_ORIGINS"), ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+	var routes []gateway.Route
+	for service, name := range registry.WebServices() {
+		conn, err := registry.Conn(name)
+		if err != nil {
+			return nil, err
+		}
+		routes = append(routes, gateway.Route{
+			Prefix:  "/" + service,
+			Handler: gateway.NewGRPCWebHandler(service, conn, logger),
+			Middleware: []gateway.Middleware{
+				gateway.AccessLog(logger, "grpc-web"),
+				gateway.AllowOrigins(origins...),
+				auth,
+			},
+		})
+	}
+	return routes, nil
+}
+
+// apiAuth validates caller JWTs when any issuer is configured:
+// EDGE_JWT_HS256_ISSUER with EDGE_JWT_HS256_SECRET (the users service's
+// own session tokens), and EDGE_JWT_JWKS_ISSUER with EDGE_JWT_JWKS_URL (an
+// identity provider). EDGE_JWT_AUDIENCE, if set, must be in every token.
+// Without issuers it falls back to the shared EDGE_API_TOKEN, and callers
+// stay anonymous to the upstreams.
+func apiAuth(logger *log.Logger) (gateway.Middleware, error) {
+	auth := identity.NewAuthenticator(os.Getenv("EDGE_JWT_AUDIENCE"))
+	issuer, secret := os.Getenv("EDGE_JWT_HS256_ISSUER"), os.Getenv("EDGE_JWT_HS256_SECRET")
+	if (issuer == "") != (secret == "") {
+		return nil, errors.New("EDGE_JWT_HS256_ISSUER and EDGE_JWT_HS256_SECRET must be set together")
+	}
+	if issuer != "" {
+		auth.TrustHS256(issuer, []byte(secret))
+	}
+	issuer, jwksURL := os.Getenv("EDGE_JWT_JWKS_ISSUER"), os.Getenv("EDGE_JWT_JWKS_URL")
+	if (issuer == "") != (jwksURL == "") {
+		return nil, errors.New("EDGE_JWT_JWKS_ISSUER and EDGE_JWT_JWKS_URL must be set together")
+	}
+	if issuer != "" {
+		auth.TrustJWKS(issuer, jwksURL, &http.Client{Timeout: 5 * time.Second})
+	}
+	if auth.Issuers() == 0 {
+		return gateway.BearerToken(os.Getenv("EDGE_API_TOKEN")), nil
+	}
+	return gateway.JWTAuth(auth, logger), nil
+}
+
+func env(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
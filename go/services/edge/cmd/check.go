@@ -0,0 +1,29 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+)
+
+// runCheck wires the gateway exactly as serve does, so a bad upstreams or
+// transforms file, or a half-set pair of auth variables, fails here, in a
+// deploy pipeline, instead of at start. It prints the routes it would
+// serve.
+func runCheck(_ context.Context, args []string) error {
+	fs := flag.NewFlagSet("check", flag.ExitOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	a, err := bootstrap()
+	if err != nil {
+		return err
+	}
+	defer a.registry.Close()
+	for _, r := range a.routes {
+		fmt.Fprintln(os.Stdout, r.Prefix)
+	}
+	return nil
+}
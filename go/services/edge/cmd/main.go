@@ -2,54 +2,46 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
-	"time"
-
-	adapter "clean-code-cookbook/go/services/edge/internal/adapter/grpc"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"os"
 )
 
-func main() {
-	// 1. Establish connection to the Python "Brains" service
-	// Using insecure for demo; production should use mTLS
-	conn, err := grpc.Dial("localhost:50051", grpc.WithTransportCredentials(insecure.NewCredentials()))
-	if err != nil {
-		log.Fatalf("did not connect: %v", err)
-	}
-	defer conn.Close()
+// command is one subcommand of the gateway binary.
+type command struct {
+	name    string
+	summary string
+	run     func(ctx context.Context, args []string) error
+}
 
-	client := adapter.NewUserClient(conn)
-	ctx := context.Background()
+var commands = []command{
+	{name: "serve", summary: "run the gateway until interrupted", run: runServe},
+	{name: "check", summary: "validate the configuration and list the routes", run: runCheck},
+}
 
-	// 2. Demonstrate RegisterUser
-	log.Println("--- 1. Registering User ---")
-	regResp, err := client.RegisterUser(ctx, "alice@example.com", "alice_wonder")
-	if err != nil {
-		log.Printf("Error registering: %v", err)
-	} else {
-		log.Printf("Success! User ID: %s, Status: %s", regResp.Id, regResp.Status)
+func main() {
+	// `go run ./cmd` with no arguments keeps its old meaning: serve.
+	name, args := "serve", os.Args[1:]
+	if len(args) > 0 {
+		name, args = args[0], args[1:]
 	}
 
-	// 3. Demonstrate GetUser
-	log.Println("\n--- 2. Fetching User ---")
-	getResp, err := client.GetUser(ctx, "alice@example.com")
-	if err != nil {
-		log.Printf("Error fetching user: %v", err)
-	} else {
-		log.Printf("Found User: %s (Active: %v)", getResp.User.Username, getResp.User.IsActive)
+	for _, c := range commands {
+		if c.name == name {
+			if err := c.run(context.Background(), args); err != nil {
+				log.Fatalf("%s: %v", name, err)
+			}
+			return
+		}
 	}
 
-	// 4. Demonstrate Streaming
-	log.Println("\n--- 3. Streaming Events ---")
-	// Run in a goroutine or blocking? Blocking for demo.
-	go func() {
-		if err := client.StreamEvents(ctx); err != nil {
-			log.Printf("Streaming ended: %v", err)
-		}
-	}()
+	usage()
+	os.Exit(2)
+}
 
-	// Keep main alive for a bit to receive events
-	time.Sleep(3 * time.Second)
-	log.Println("Done.")
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: %s <command> [flags]\n\ncommands:\n", os.Args[0])
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %-8s %s\n", c.name, c.summary)
+	}
 }
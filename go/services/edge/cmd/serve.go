@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"flag"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"clean-code-cookbook/go/services/edge/internal/adapter/gateway"
+)
+
+func runServe(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	a, err := bootstrap()
+	if err != nil {
+		return err
+	}
+	defer a.registry.Close()
+	go a.hub.Bridge(ctx, a.broker.Relay(gateway.Invalidating(a.users, a.userCache.InvalidateUsers("/api/users"))))
+
+	server := &http.Server{
+		Addr:              env("EDGE_HTTP_ADDR", ":8081"),
+		Handler:           a.mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	// /debug/vars stays off the public listener, on EDGE_ADMIN_ADDR
+	// (loopback by default; "off" disables it).
+	adminMux := http.NewServeMux()
+	adminMux.Handle("/debug/vars", expvar.Handler())
+	admin := &http.Server{
+		Addr:              env("EDGE_ADMIN_ADDR", "127.0.0.1:6061"),
+		Handler:           adminMux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	// Serve until interrupted, then drain. A listener that fails ends the
+	// command like an interrupt does, with its error.
+	errs := make(chan error, 2)
+	go func() {
+		a.log.Printf("Gateway listening on %s", server.Addr)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			errs <- err
+		}
+	}()
+	if admin.Addr != "off" {
+		go func() {
+			a.log.Printf("Admin listening on %s", admin.Addr)
+			if err := admin.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				errs <- err
+			}
+		}()
+	}
+	select {
+	case <-ctx.Done():
+	case err = <-errs:
+	}
+
+	// Hijacked sockets are not tracked by Shutdown, and event streams never
+	// go idle; end both first.
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	a.hub.Close()
+	a.broker.Close()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		a.log.Printf("shutdown: %v", err)
+	}
+	_ = admin.Shutdown(shutdownCtx)
+	a.log.Println("Done.")
+	return err
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"clean-code-cookbook/go/pkg/breaker"
+	"clean-code-cookbook/go/services/edge/internal/upstream"
+	"clean-code-cookbook/go/services/edge/pkg/retrybudget"
+)
+
+// loadUpstreams builds the registry from EDGE_UPSTREAMS_FILE, falling
+// back to the per-service variables; either way a "users" upstream is
+// required.
+func loadUpstreams(route time.Duration) (*upstream.Registry, error) {
+	var configs []upstream.Config
+	var err error
+	if path := os.Getenv("EDGE_UPSTREAMS_FILE"); path != "" {
+		configs, err = upstream.LoadFile(path)
+	} else {
+		configs, err = upstream.FromEnv()
+	}
+	if err != nil {
+		return nil, err
+	}
+	registry, err := upstream.NewRegistry(configs, route)
+	if err != nil {
+		return nil, err
+	}
+	if !registry.Has("users") {
+		return nil, errors.New(`no "users" upstream configured`)
+	}
+	return registry, nil
+}
+
+// upstreamMetrics counts one upstream's calls for /debug/vars.
+type upstreamMetrics struct {
+	breaker *breaker.Breaker
+	retries *retrybudget.Budget
+
+	calls, failures, rejected atomic.Int64
+}
+
+// watchUpstream hooks into b to count calls and log state changes.
+func watchUpstream(b *breaker.Breaker, retries *retrybudget.Budget, logger *log.Logger) *upstreamMetrics {
+	m := &upstreamMetrics{breaker: b, retries: retries}
+	b.OnStateChange = func(name string, from, to breaker.State) {
+		logger.Printf("breaker %s: %s -> %s", name, from, to)
+	}
+	b.OnCall = func(_ string, _ breaker.State, err error) {
+		m.calls.Add(1)
+		switch {
+		case errors.Is(err, breaker.ErrOpen):
+			m.rejected.Add(1)
+		case err != nil && b.IsFailure(err):
+			m.failures.Add(1)
+		}
+	}
+	return m
+}
+
+func (m *upstreamMetrics) snapshot() any {
+	return map[string]any{
+		"breaker":  m.breaker.State().String(),
+		"calls":    m.calls.Load(),
+		"failures": m.failures.Load(),
+		"rejected": m.rejected.Load(),
+		"retries":  m.retries.Stats(),
+	}
+}
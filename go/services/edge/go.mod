@@ -3,6 +3,19 @@ module clean-code-cookbook/go/services/edge
 go 1.21
 
 require (
+	clean-code-cookbook/go/pkg v0.0.0
+	github.com/gorilla/websocket v1.5.3
 	google.golang.org/grpc v1.59.0
 	google.golang.org/protobuf v1.31.0
-)
\ No newline at end of file
+)
+
+require (
+	github.com/golang/protobuf v1.5.3 // indirect
+	golang.org/x/net v0.14.0 // indirect
+	golang.org/x/sync v0.10.0 // indirect
+	golang.org/x/sys v0.11.0 // indirect
+	golang.org/x/text v0.12.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20230822172742-b8732ec3820d // indirect
+)
+
+replace clean-code-cookbook/go/pkg => ../../pkg
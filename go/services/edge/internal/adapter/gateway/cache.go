@@ -0,0 +1,217 @@
+package gateway
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"clean-code-cookbook/go/pkg/cache"
+	pb "github.com/clean-code-coockbook/proto/gen/go/users/v1"
+)
+
+// maxCachedBody is the largest response body ResponseCache stores.
+const maxCachedBody = 1 << 20
+
+// ResponseCache keeps successful GET responses of a route in memory,
+// keyed by path, and answers repeats without calling the upstream. It
+// runs after authentication, so it only ever serves callers the route
+// already admits.
+//
+// Upstreams shorten the lifetime with Cache-Control max-age (or s-maxage)
+// and opt out with no-store or private. Callers skip a cached copy with
+// no-cache, skip the cache entirely with no-store, and bound a copy's age
+// with max-age. Responses carry X-Cache (HIT or MISS) and, on hits, Age.
+type ResponseCache struct {
+	// MaxAge is the longest a response is kept, and how long when the
+	// upstream does not say. Entries an event does not invalidate may be
+	// this stale.
+	MaxAge time.Duration
+	// Now ages entries; it defaults to time.Now.
+	Now func() time.Time
+
+	entries *cache.LRU[string, cachedResponse]
+}
+
+// NewResponseCache holds up to size responses for at most maxAge; a
+// maxAge of zero stores nothing.
+func NewResponseCache(size int, maxAge time.Duration) *ResponseCache {
+	return &ResponseCache{
+		MaxAge:  maxAge,
+		Now:     time.Now,
+		entries: cache.NewLRU[string, cachedResponse](size, maxAge),
+	}
+}
+
+type cachedResponse struct {
+	header  http.Header
+	body    []byte
+	stored  time.Time
+	expires time.Time
+}
+
+// Invalidate drops the cached response for path, if any.
+func (c *ResponseCache) Invalidate(path string) {
+	c.entries.Delete(path)
+}
+
+// InvalidateUsers returns an event handler that drops the cached
+// prefix/{email} response of every user the users service reports a
+// change for.
+func (c *ResponseCache) InvalidateUsers(prefix string) func(*pb.UserEvent) {
+	prefix = strings.TrimSuffix(prefix, "/") + "/"
+	return func(e *pb.UserEvent) {
+		if email := e.GetPayload().GetEmail(); email != "" {
+			c.Invalidate(prefix + email)
+		}
+	}
+}
+
+// Middleware serves GET requests from the cache and stores what next
+// answers with 200.
+func (c *ResponseCache) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			next.ServeHTTP(w, r)
+			return
+		}
+		request := parseCacheControl(r.Header.Get("Cache-Control"))
+		if _, ok := request["no-store"]; ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// 1. A fresh enough copy answers straight away
+		key := r.URL.Path
+		now := c.Now()
+		if _, ok := request["no-cache"]; !ok {
+			if hit, ok := c.entries.Get(key); ok && now.Before(hit.expires) && acceptsAge(request, now.Sub(hit.stored)) {
+				for k, v := range hit.header {
+					w.Header()[k] = v
+				}
+				w.Header().Set("Age", strconv.Itoa(int(now.Sub(hit.stored).Seconds())))
+				w.Header().Set("X-Cache", "HIT")
+				w.WriteHeader(http.StatusOK)
+				_, _ = w.Write(hit.body)
+				return
+			}
+		}
+
+		// 2. Otherwise ask the upstream and keep a copy if allowed
+		rec := &cacheRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		if rec.status != http.StatusOK || rec.overflow {
+			return
+		}
+		ttl, ok := c.ttl(parseCacheControl(rec.Header().Get("Cache-Control")))
+		if !ok {
+			return
+		}
+		header := rec.Header().Clone()
+		header.Del("X-Cache")
+		c.entries.Set(key, cachedResponse{header: header, body: rec.body, stored: now, expires: now.Add(ttl)})
+	})
+}
+
+// ttl is how long a response with these directives may be kept.
+func (c *ResponseCache) ttl(directives map[string]string) (time.Duration, bool) {
+	if _, ok := directives["no-store"]; ok {
+		return 0, false
+	}
+	if _, ok := directives["private"]; ok {
+		return 0, false
+	}
+	ttl := c.MaxAge
+	for _, name := range []string{"s-maxage", "max-age"} {
+		if v, ok := directives[name]; ok {
+			seconds, err := strconv.Atoi(v)
+			if err != nil || seconds <= 0 {
+				return 0, false
+			}
+			ttl = min(ttl, time.Duration(seconds)*time.Second)
+			break
+		}
+	}
+	return ttl, ttl > 0
+}
+
+// acceptsAge applies a request's max-age to a cached copy of age.
+func acceptsAge(request map[string]string, age time.Duration) bool {
+	v, ok := request["max-age"]
+	if !ok {
+		return true
+	}
+	seconds, err := strconv.Atoi(v)
+	return err == nil && age <= time.Duration(seconds)*time.Second
+}
+
+// parseCacheControl splits a Cache-Control header into lower-case
+// directives and their (unquoted) values.
+func parseCacheControl(h string) map[string]string {
+	directives := make(map[string]string)
+	for _, part := range strings.Split(h, ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		if name != "" {
+			directives[strings.ToLower(name)] = strings.Trim(value, `"`)
+		}
+	}
+	return directives
+}
+
+// cacheRecorder passes the response through, marked as a miss, and keeps
+// a copy of the body unless it grows past maxCachedBody.
+type cacheRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        []byte
+	overflow    bool
+}
+
+func (r *cacheRecorder) WriteHeader(code int) {
+	if !r.wroteHeader {
+		r.wroteHeader, r.status = true, code
+		r.Header().Set("X-Cache", "MISS")
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *cacheRecorder) Write(p []byte) (int, error) {
+	if !r.wroteHeader {
+		r.WriteHeader(http.StatusOK)
+	}
+	if !r.overflow {
+		if len(r.body)+len(p) > maxCachedBody {
+			r.overflow, r.body = true, nil
+		} else {
+			r.body = append(r.body, p...)
+		}
+	}
+	return r.ResponseWriter.Write(p)
+}
+
+// Invalidating returns source with every event passed to invalidate
+// before handle sees it, so caches are cleared by the same stream that
+// feeds the WebSocket hub.
+func Invalidating(source EventSource, invalidate func(*pb.UserEvent)) EventSource {
+	return invalidatingSource{source: source, invalidate: invalidate}
+}
+
+// EventSource is the upstream user-event stream; grpc.UserClient
+// satisfies it.
+type EventSource interface {
+	StreamEvents(ctx context.Context, handle func(*pb.UserEvent)) error
+}
+
+type invalidatingSource struct {
+	source     EventSource
+	invalidate func(*pb.UserEvent)
+}
+
+func (s invalidatingSource) StreamEvents(ctx context.Context, handle func(*pb.UserEvent)) error {
+	return s.source.StreamEvents(ctx, func(e *pb.UserEvent) {
+		s.invalidate(e)
+		handle(e)
+	})
+}
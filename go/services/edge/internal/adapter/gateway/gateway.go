@@ -0,0 +1,124 @@
+// Package gateway is the edge's public HTTP API. Each route owns a path
+// prefix, forwards to one upstream and runs its own middleware, so auth,
+// timeouts and method checks can differ between upstreams.
+package gateway
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Middleware wraps a handler.
+type Middleware func(http.Handler) http.Handler
+
+// Route sends every request under Prefix (say "/api/users") to Handler,
+// with the prefix stripped, through Middleware in order: the first one
+// sees the request first.
+type Route struct {
+	Prefix     string
+	Handler    http.Handler
+	Middleware []Middleware
+}
+
+// Mount registers routes on mux.
+func Mount(mux *http.ServeMux, routes ...Route) {
+	for _, rt := range routes {
+		prefix := strings.TrimSuffix(rt.Prefix, "/")
+		var h http.Handler = http.StripPrefix(prefix, rt.Handler)
+		for i := len(rt.Middleware) - 1; i >= 0; i-- {
+			h = rt.Middleware[i](h)
+		}
+		// Both, so POST /api/users is not redirected to /api/users/.
+		mux.Handle(prefix, h)
+		mux.Handle(prefix+"/", h)
+	}
+}
+
+// BearerToken lets through requests carrying the shared token. An empty
+// token refuses everyone, so a missing secret never opens a route.
+func BearerToken(token string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || token == "" || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				writeError(w, http.StatusUnauthorized, "unauthorized")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Methods answers 405 to any method not listed.
+func Methods(allowed ...string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !slices.Contains(allowed, r.Method) {
+				w.Header().Set("Allow", strings.Join(allowed, ", "))
+				writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Timeout gives each request d to finish upstream. It only sets the
+// context deadline; the route's handler turns an expired one into 504.
+func Timeout(d time.Duration) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), d)
+			defer cancel()
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// AccessLog logs one line per request, tagged with the route name.
+func AccessLog(logger *log.Logger, route string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rec, r)
+			logger.Printf("%s %s %s -> %d in %v", route, r.Method, r.URL.Path, rec.status, time.Since(start).Round(time.Millisecond))
+		})
+	}
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	s.status = code
+	s.ResponseWriter.WriteHeader(code)
+}
+
+// Unwrap lets http.ResponseController reach the connection, so streamed
+// responses can still be flushed.
+func (s *statusRecorder) Unwrap() http.ResponseWriter { return s.ResponseWriter }
+
+// errorResponse is the body of every error the gateway itself produces.
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, errorResponse{Error: msg})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,336 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// maxGRPCWebRequest bounds a gRPC-Web request message, as gRPC's own
+// default receive limit does.
+const maxGRPCWebRequest = 4 << 20
+
+// trailerFlag marks the gRPC-Web frame that carries the status.
+const trailerFlag = 0x80
+
+// GRPCWebHandler lets browsers call one gRPC service of an upstream over
+// gRPC-Web, mounted at the service's full name, e.g.
+//
+//	POST /users.v1.UserService/GetUser
+//
+// Messages are passed through as bytes, so the edge needs no generated
+// code for the service. Unary and server-streaming methods work; browsers
+// cannot stream requests. Both the binary (application/grpc-web+proto)
+// and base64 text (application/grpc-web-text) encodings are spoken.
+// Incoming headers are not forwarded as metadata; the caller reaches the
+// upstream only as the identity the gateway vouches for.
+type GRPCWebHandler struct {
+	service string
+	conn    grpc.ClientConnInterface
+	logger  *log.Logger
+}
+
+func NewGRPCWebHandler(service string, conn grpc.ClientConnInterface, logger *log.Logger) *GRPCWebHandler {
+	return &GRPCWebHandler{service: service, conn: conn, logger: logger}
+}
+
+func (h *GRPCWebHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	method := strings.Trim(r.URL.Path, "/")
+	if method == "" || strings.Contains(method, "/") {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	contentType, text, ok := grpcWebContentType(r.Header.Get("Content-Type"))
+	if !ok {
+		writeError(w, http.StatusUnsupportedMediaType, "expected application/grpc-web")
+		return
+	}
+
+	// 1. The request message: exactly one data frame
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxGRPCWebRequest*2))
+	if err == nil && text {
+		body, err = decodeGRPCWebText(body)
+	}
+	var msg []byte
+	if err == nil {
+		msg, err = readRequestFrame(body)
+	}
+
+	ctx := r.Context()
+	if timeout, ok := parseGRPCTimeout(r.Header.Get("Grpc-Timeout")); ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	// 2. The call; its frames go out as they arrive, then the trailer
+	w.Header().Set("Content-Type", contentType)
+	rw := &grpcWebWriter{w: w, rc: http.NewResponseController(w), text: text}
+	if err != nil {
+		rw.trailer(status.New(codes.InvalidArgument, err.Error()), nil)
+		return
+	}
+	st, trailer := h.call(ctx, "/"+h.service+"/"+method, msg, rw)
+	if st.Code() != codes.OK && st.Code() != codes.NotFound && st.Code() != codes.InvalidArgument {
+		h.logger.Printf("grpc-web %s/%s: %s: %s", h.service, method, st.Code(), st.Message())
+	}
+	rw.trailer(st, trailer)
+}
+
+// call runs method on the upstream, writing each response message to rw.
+func (h *GRPCWebHandler) call(ctx context.Context, method string, msg []byte, rw *grpcWebWriter) (*status.Status, metadata.MD) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var header, trailer metadata.MD
+	stream, err := h.conn.NewStream(ctx, &grpc.StreamDesc{ServerStreams: true}, method,
+		grpc.ForceCodec(rawCodec{}), grpc.Header(&header), grpc.Trailer(&trailer))
+	if err != nil {
+		return status.Convert(err), nil
+	}
+	if err := stream.SendMsg(msg); err != nil && !errors.Is(err, io.EOF) {
+		return status.Convert(err), nil
+	}
+	if err := stream.CloseSend(); err != nil {
+		return status.Convert(err), nil
+	}
+	for {
+		var reply []byte
+		err := stream.RecvMsg(&reply)
+		if errors.Is(err, io.EOF) {
+			return status.New(codes.OK, ""), trailer
+		}
+		if err != nil {
+			return status.Convert(err), trailer
+		}
+		if !rw.headerSent {
+			copyMetadata(rw.w.Header(), header)
+		}
+		if err := rw.frame(0, reply); err != nil {
+			// The browser went away; nobody is left to tell.
+			return status.New(codes.Canceled, err.Error()), nil
+		}
+	}
+}
+
+// grpcWebWriter frames messages onto the response, flushing each so
+// streamed replies reach the browser as they happen.
+type grpcWebWriter struct {
+	w          http.ResponseWriter
+	rc         *http.ResponseController
+	text       bool
+	headerSent bool
+}
+
+func (rw *grpcWebWriter) frame(flag byte, payload []byte) error {
+	rw.headerSent = true
+	frame := make([]byte, 5+len(payload))
+	frame[0] = flag
+	binary.BigEndian.PutUint32(frame[1:5], uint32(len(payload)))
+	copy(frame[5:], payload)
+	if rw.text {
+		frame = []byte(base64.StdEncoding.EncodeToString(frame))
+	}
+	if _, err := rw.w.Write(frame); err != nil {
+		return err
+	}
+	if err := rw.rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	return nil
+}
+
+// trailer ends the response with st and the upstream's trailing metadata,
+// in the body, since browsers cannot read HTTP trailers.
+func (rw *grpcWebWriter) trailer(st *status.Status, md metadata.MD) {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "grpc-status: %d\r\n", st.Code())
+	if st.Message() != "" {
+		fmt.Fprintf(&b, "grpc-message: %s\r\n", encodeGRPCMessage(st.Message()))
+	}
+	for key, values := range md {
+		if strings.HasSuffix(key, "-bin") {
+			continue
+		}
+		for _, v := range values {
+			fmt.Fprintf(&b, "%s: %s\r\n", key, v)
+		}
+	}
+	_ = rw.frame(trailerFlag, b.Bytes())
+}
+
+// copyMetadata exposes the upstream's response headers, minus binary
+// ones, and lets browser code read them across origins.
+func copyMetadata(h http.Header, md metadata.MD) {
+	var exposed []string
+	for key, values := range md {
+		if strings.HasSuffix(key, "-bin") {
+			continue
+		}
+		for _, v := range values {
+			h.Add(key, v)
+		}
+		exposed = append(exposed, key)
+	}
+	if len(exposed) > 0 {
+		slices.Sort(exposed)
+		h.Add("Access-Control-Expose-Headers", strings.Join(exposed, ", "))
+	}
+}
+
+// grpcWebContentType accepts application/grpc-web[-text][+proto] and
+// returns what to answer with.
+func grpcWebContentType(raw string) (contentType string, text, ok bool) {
+	mediaType, _, err := mime.ParseMediaType(raw)
+	if err != nil {
+		return "", false, false
+	}
+	switch mediaType {
+	case "application/grpc-web", "application/grpc-web+proto":
+		return mediaType, false, true
+	case "application/grpc-web-text", "application/grpc-web-text+proto":
+		return mediaType, true, true
+	default:
+		return "", false, false
+	}
+}
+
+// decodeGRPCWebText decodes a text-mode body, which clients may send as
+// several base64 chunks, each with its own padding.
+func decodeGRPCWebText(body []byte) ([]byte, error) {
+	var out []byte
+	for len(body) > 0 {
+		end := len(body)
+		if i := bytes.IndexByte(body, '='); i >= 0 {
+			end = i
+			for end < len(body) && body[end] == '=' {
+				end++
+			}
+		}
+		chunk := make([]byte, base64.StdEncoding.DecodedLen(end))
+		n, err := base64.StdEncoding.Decode(chunk, body[:end])
+		if err != nil {
+			return nil, fmt.Errorf("invalid base64 body: %w", err)
+		}
+		out, body = append(out, chunk[:n]...), body[end:]
+	}
+	return out, nil
+}
+
+// readRequestFrame unwraps the single uncompressed message of a request.
+func readRequestFrame(body []byte) ([]byte, error) {
+	if len(body) < 5 {
+		return nil, errors.New("request has no message frame")
+	}
+	if body[0] != 0 {
+		return nil, errors.New("compressed or trailer frames are not accepted in requests")
+	}
+	n := binary.BigEndian.Uint32(body[1:5])
+	if n > maxGRPCWebRequest {
+		return nil, fmt.Errorf("request message is over %d bytes", maxGRPCWebRequest)
+	}
+	if uint32(len(body)-5) != n {
+		return nil, errors.New("request must carry exactly one message")
+	}
+	return body[5:], nil
+}
+
+// parseGRPCTimeout reads a grpc-timeout header such as "500m" or "3S".
+func parseGRPCTimeout(raw string) (time.Duration, bool) {
+	if len(raw) < 2 || len(raw) > 9 {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(raw[:len(raw)-1], 10, 64)
+	if err != nil || n <= 0 {
+		return 0, false
+	}
+	unit, ok := map[byte]time.Duration{
+		'H': time.Hour, 'M': time.Minute, 'S': time.Second,
+		'm': time.Millisecond, 'u': time.Microsecond, 'n': time.Nanosecond,
+	}[raw[len(raw)-1]]
+	return time.Duration(n) * unit, ok
+}
+
+// encodeGRPCMessage percent-encodes a status message the way gRPC does.
+func encodeGRPCMessage(msg string) string {
+	var b strings.Builder
+	for i := 0; i < len(msg); i++ {
+		if c := msg[i]; c >= ' ' && c <= '~' && c != '%' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// rawCodec passes messages through as the bytes they are on the wire.
+type rawCodec struct{}
+
+func (rawCodec) Name() string { return "proto" }
+
+func (rawCodec) Marshal(v any) ([]byte, error) {
+	b, ok := v.([]byte)
+	if !ok {
+		return nil, fmt.Errorf("rawCodec: cannot marshal %T", v)
+	}
+	return b, nil
+}
+
+func (rawCodec) Unmarshal(data []byte, v any) error {
+	b, ok := v.(*[]byte)
+	if !ok {
+		return fmt.Errorf("rawCodec: cannot unmarshal into %T", v)
+	}
+	*b = append((*b)[:0], data...)
+	return nil
+}
+
+// AllowOrigins answers CORS preflights from the listed browser origins
+// and marks their responses readable, so a page served elsewhere can call
+// the route. It must run before auth, since preflights carry no
+// credentials. Other origins get no CORS headers and the browser blocks
+// them.
+func AllowOrigins(origins ...string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			if origin == "" || !slices.Contains(origins, origin) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			h := w.Header()
+			h.Set("Access-Control-Allow-Origin", origin)
+			h.Add("Vary", "Origin")
+			if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+				h.Set("Access-Control-Allow-Methods", "POST")
+				h.Set("Access-Control-Allow-Headers", "Authorization, Content-Type, Grpc-Timeout, X-Grpc-Web, X-User-Agent")
+				h.Set("Access-Control-Max-Age", "600")
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			h.Set("Access-Control-Expose-Headers", "Grpc-Status, Grpc-Message")
+			next.ServeHTTP(w, r)
+		})
+	}
+}
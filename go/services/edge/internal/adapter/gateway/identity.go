@@ -0,0 +1,74 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"clean-code-cookbook/go/pkg/jwt"
+	"clean-code-cookbook/go/services/edge/internal/identity"
+)
+
+// Authenticator checks a caller's bearer token; identity.Authenticator
+// implements it.
+type Authenticator interface {
+	Authenticate(ctx context.Context, raw string) (identity.Caller, error)
+}
+
+// JWTAuth lets through requests with a valid bearer JWT and hands their
+// identity.Caller to next. Bad tokens get 401; an issuer whose keys
+// cannot be fetched gets 503, since the caller may well be fine.
+func JWTAuth(auth Authenticator, logger *log.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			raw, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || raw == "" {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				writeError(w, http.StatusUnauthorized, "unauthorized")
+				return
+			}
+			caller, err := auth.Authenticate(r.Context(), raw)
+			switch {
+			case errors.Is(err, jwt.ErrMalformed), errors.Is(err, jwt.ErrSignature), errors.Is(err, jwt.ErrExpired),
+				errors.Is(err, jwt.ErrClaims), errors.Is(err, identity.ErrUnknownKey):
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				writeError(w, http.StatusUnauthorized, "invalid token")
+			case err != nil:
+				logger.Printf("gateway: authenticate: %v", err)
+				writeError(w, http.StatusServiceUnavailable, "identity provider unavailable")
+			default:
+				next.ServeHTTP(w, r.WithContext(identity.WithCaller(r.Context(), caller)))
+			}
+		})
+	}
+}
+
+// PropagateIdentity sends the request's caller to an HTTP upstream as an
+// internal token for audience in the X-Edge-Identity header. Whatever the
+// client put in that header is dropped first, so it cannot be replayed
+// from outside; with a nil signer that is all it does.
+func PropagateIdentity(signer *identity.Signer, audience string, next http.RoundTripper) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		caller, ok := identity.FromContext(req.Context())
+		ok = ok && signer != nil
+		if !ok && req.Header.Get(identity.Header) == "" {
+			return next.RoundTrip(req)
+		}
+		req = req.Clone(req.Context())
+		req.Header.Del(identity.Header)
+		if ok {
+			token, err := signer.Sign(caller, audience)
+			if err != nil {
+				return nil, err
+			}
+			req.Header.Set(identity.Header, token)
+		}
+		return next.RoundTrip(req)
+	})
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
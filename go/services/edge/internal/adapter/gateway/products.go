@@ -0,0 +1,52 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+
+	"clean-code-cookbook/go/pkg/breaker"
+)
+
+// NewProductsProxy forwards the products route, mounted at /api/products,
+// to the catalog's read API: /api/products/{id} to {catalogURL}/products/{id}
+// and the search, /api/products?q=..., to {catalogURL}/products. The
+// caller's Authorization header is for the edge and is not passed on.
+func NewProductsProxy(catalogURL string, transport http.RoundTripper, logger *log.Logger) (http.Handler, error) {
+	target, err := url.Parse(strings.TrimSuffix(catalogURL, "/") + "/products")
+	if err != nil {
+		return nil, err
+	}
+	if target.Scheme == "" || target.Host == "" {
+		return nil, errors.New("gateway: catalog URL needs a scheme and host")
+	}
+	return &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.SetURL(target)
+			if pr.In.URL.Path == "" || pr.In.URL.Path == "/" {
+				// SetURL would add a slash, which the catalog does not route
+				pr.Out.URL.Path, pr.Out.URL.RawPath = target.Path, ""
+			}
+			pr.SetXForwarded()
+			pr.Out.Header.Del("Authorization")
+		},
+		Transport: transport,
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			if errors.Is(err, breaker.ErrOpen) {
+				w.Header().Set("Retry-After", "1")
+				writeError(w, http.StatusServiceUnavailable, "catalog unavailable")
+				return
+			}
+			if errors.Is(err, context.DeadlineExceeded) {
+				writeError(w, http.StatusGatewayTimeout, "catalog timed out")
+				return
+			}
+			logger.Printf("catalog upstream: %v", err)
+			writeError(w, http.StatusBadGateway, "bad gateway")
+		},
+	}, nil
+}
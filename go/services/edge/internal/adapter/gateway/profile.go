@@ -0,0 +1,159 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"clean-code-cookbook/go/pkg/concurrent"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Product is the public JSON shape of a catalog product.
+type Product struct {
+	ID    string  `json:"id"`
+	Name  string  `json:"name"`
+	Price float64 `json:"price"`
+}
+
+// Catalog is the catalog service as the profile endpoint calls it.
+type Catalog interface {
+	// SuggestedProducts returns up to limit products to suggest.
+	SuggestedProducts(ctx context.Context, limit int) ([]Product, error)
+}
+
+// CatalogClient implements Catalog with the catalog's search,
+// GET {baseURL}/products?size=n. The catalog keeps nothing per user, so
+// every profile is suggested the first page of its listing.
+type CatalogClient struct {
+	baseURL string
+	client  *http.Client
+}
+
+func NewCatalogClient(baseURL string, transport http.RoundTripper) *CatalogClient {
+	return &CatalogClient{baseURL: strings.TrimSuffix(baseURL, "/"), client: &http.Client{Transport: transport}}
+}
+
+func (c *CatalogClient) SuggestedProducts(ctx context.Context, limit int) ([]Product, error) {
+	target := c.baseURL + "/products?size=" + strconv.Itoa(limit)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("catalog returned %s", resp.Status)
+	}
+	var page struct {
+		Items []Product `json:"items"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return nil, fmt.Errorf("decode products: %w", err)
+	}
+	return page.Items, nil
+}
+
+// ProfileHandler serves GET /api/profile/{email}: the user and the
+// products suggested to them, fetched concurrently. A failing upstream leaves its
+// part empty and is named in "degraded" rather than failing the whole
+// response; only an unknown user, or both upstreams down, is an error.
+type ProfileHandler struct {
+	// Limit is how many products to suggest.
+	Limit int
+	// CatalogTimeout bounds the catalog call, so a slow catalog costs the
+	// profile its products rather than its latency.
+	CatalogTimeout time.Duration
+
+	users   Users
+	catalog Catalog // nil leaves products out
+	logger  *log.Logger
+}
+
+func NewProfileHandler(users Users, catalog Catalog, logger *log.Logger) *ProfileHandler {
+	return &ProfileHandler{Limit: 5, CatalogTimeout: time.Second, users: users, catalog: catalog, logger: logger}
+}
+
+type profileResponse struct {
+	User              *User     `json:"user"`
+	SuggestedProducts []Product `json:"suggested_products"`
+	// Degraded names the upstreams whose part is missing.
+	Degraded []string `json:"degraded,omitempty"`
+}
+
+// errUnknownUser ends the fan-out early: without a user there is no
+// profile, so the catalog call is cancelled.
+var errUnknownUser = errors.New("unknown user")
+
+func (h *ProfileHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	email := strings.Trim(r.URL.Path, "/")
+	if email == "" || strings.Contains(email, "/") {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	// 1. Fan out; each call records its own failure so the other keeps going
+	var (
+		resp                 = profileResponse{SuggestedProducts: []Product{}}
+		usersErr, catalogErr error
+	)
+	tasks := []func(ctx context.Context) error{func(ctx context.Context) error {
+		got, err := h.users.GetUser(ctx, email)
+		if status.Code(err) == codes.NotFound {
+			return errUnknownUser
+		}
+		if err != nil {
+			usersErr = err
+			return nil
+		}
+		u := userFromProto(got.GetUser())
+		resp.User = &u
+		return nil
+	}}
+	if h.catalog != nil {
+		tasks = append(tasks, func(ctx context.Context) error {
+			ctx, cancel := context.WithTimeout(ctx, h.CatalogTimeout)
+			defer cancel()
+			products, err := h.catalog.SuggestedProducts(ctx, h.Limit)
+			if err != nil {
+				catalogErr = err
+				return nil
+			}
+			if products != nil {
+				resp.SuggestedProducts = products
+			}
+			return nil
+		})
+	}
+	if err := concurrent.Gather(r.Context(), concurrent.Options{}, tasks...); errors.Is(err, errUnknownUser) {
+		writeError(w, http.StatusNotFound, "user not found")
+		return
+	}
+
+	// 2. Merge what came back
+	if usersErr != nil {
+		h.logger.Printf("profile: users upstream: %v", usersErr)
+		resp.Degraded = append(resp.Degraded, "users")
+	}
+	if catalogErr != nil {
+		h.logger.Printf("profile: catalog upstream: %v", catalogErr)
+		resp.Degraded = append(resp.Degraded, "catalog")
+	}
+	if usersErr != nil && (h.catalog == nil || catalogErr != nil) {
+		writeError(w, http.StatusBadGateway, "upstreams unavailable")
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,237 @@
+package gateway
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+)
+
+// maxTransformedBody is the largest JSON body a Transform rewrites.
+const maxTransformedBody = 1 << 20
+
+// Transform reshapes one route's traffic so upstream field names and
+// internals do not leak to public clients. Field rules apply to JSON
+// bodies at any depth: Rename maps upstream names to public ones (and
+// back, for request bodies), Redact drops upstream fields from responses.
+// Header rules run on the way in (Request*) and out (Response*).
+type Transform struct {
+	Rename map[string]string `json:"rename,omitempty"`
+	Redact []string          `json:"redact,omitempty"`
+
+	SetRequestHeaders     map[string]string `json:"set_request_headers,omitempty"`
+	RemoveRequestHeaders  []string          `json:"remove_request_headers,omitempty"`
+	SetResponseHeaders    map[string]string `json:"set_response_headers,omitempty"`
+	RemoveResponseHeaders []string          `json:"remove_response_headers,omitempty"`
+}
+
+// LoadTransforms reads a JSON object of route prefix to Transform, such
+// as {"/api/users": {"rename": {"is_active": "active"}}}.
+func LoadTransforms(r io.Reader) (map[string]Transform, error) {
+	var transforms map[string]Transform
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&transforms); err != nil {
+		return nil, fmt.Errorf("gateway: decode transforms: %w", err)
+	}
+	return transforms, nil
+}
+
+// LoadTransformsFile reads LoadTransforms' format from path.
+func LoadTransformsFile(path string) (map[string]Transform, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("gateway: %w", err)
+	}
+	defer f.Close()
+	return LoadTransforms(f)
+}
+
+// ApplyTransforms adds each route's Transform, keyed by prefix, as its
+// innermost middleware, so caches keep the public shape. A transform for
+// a route that does not exist is an error rather than silently ignored.
+func ApplyTransforms(routes []Route, transforms map[string]Transform) error {
+	for prefix := range transforms {
+		if !slices.ContainsFunc(routes, func(rt Route) bool { return rt.Prefix == prefix }) {
+			return fmt.Errorf("gateway: transform for %s, which is not a route", prefix)
+		}
+	}
+	for i := range routes {
+		if t, ok := transforms[routes[i].Prefix]; ok {
+			routes[i].Middleware = append(routes[i].Middleware, t.Middleware)
+		}
+	}
+	return nil
+}
+
+// Middleware applies t around next.
+func (t Transform) Middleware(next http.Handler) http.Handler {
+	unrename := make(map[string]string, len(t.Rename))
+	for from, to := range t.Rename {
+		unrename[to] = from
+	}
+	redact := make(map[string]string, len(t.Redact))
+	for _, name := range t.Redact {
+		redact[name] = ""
+	}
+	rewritesBodies := len(t.Rename) > 0 || len(t.Redact) > 0
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 1. The request: headers, then public field names back to upstream ones
+		for _, name := range t.RemoveRequestHeaders {
+			r.Header.Del(name)
+		}
+		for name, value := range t.SetRequestHeaders {
+			r.Header.Set(name, value)
+		}
+		if len(unrename) > 0 && isJSON(r.Header) && r.Body != nil {
+			body, err := io.ReadAll(io.LimitReader(r.Body, maxTransformedBody+1))
+			if err != nil || len(body) > maxTransformedBody {
+				writeError(w, http.StatusRequestEntityTooLarge, "request body too large")
+				return
+			}
+			if body, err = rewriteJSON(body, unrename, nil); err != nil {
+				writeError(w, http.StatusBadRequest, "invalid JSON body")
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			r.ContentLength = int64(len(body))
+			r.Header.Set("Content-Length", strconv.Itoa(len(body)))
+		}
+
+		// 2. The response; JSON is held back until it has been rewritten
+		if !rewritesBodies && len(t.SetResponseHeaders) == 0 && len(t.RemoveResponseHeaders) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		tw := &transformWriter{ResponseWriter: w, t: t, redact: redact, rewritesBodies: rewritesBodies && r.Method != http.MethodHead}
+		next.ServeHTTP(tw, r)
+		tw.finish()
+	})
+}
+
+// applyHeaders runs the response header rules on h.
+func (t Transform) applyHeaders(h http.Header) {
+	for _, name := range t.RemoveResponseHeaders {
+		h.Del(name)
+	}
+	for name, value := range t.SetResponseHeaders {
+		h.Set(name, value)
+	}
+}
+
+func isJSON(h http.Header) bool {
+	mediaType, _, err := mime.ParseMediaType(h.Get("Content-Type"))
+	return err == nil && mediaType == "application/json"
+}
+
+// rewriteJSON renames and drops object keys throughout body; a key in
+// drop is removed, one in rename gets its new name.
+func rewriteJSON(body []byte, rename, drop map[string]string) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber() // keep large IDs and prices exactly as sent
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	out, err := json.Marshal(rewriteValue(v, rename, drop))
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
+
+func rewriteValue(v any, rename, drop map[string]string) any {
+	switch v := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, field := range v {
+			if _, ok := drop[k]; ok {
+				continue
+			}
+			if to, ok := rename[k]; ok {
+				k = to
+			}
+			out[k] = rewriteValue(field, rename, drop)
+		}
+		return out
+	case []any:
+		for i := range v {
+			v[i] = rewriteValue(v[i], rename, drop)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// transformWriter applies the response rules. Non-JSON responses stream
+// through once their headers are fixed; JSON ones are buffered, up to
+// maxTransformedBody, and rewritten by finish.
+type transformWriter struct {
+	http.ResponseWriter
+	t              Transform
+	redact         map[string]string
+	rewritesBodies bool
+
+	wroteHeader bool
+	buffering   bool
+	status      int
+	body        bytes.Buffer
+	overflow    bool
+}
+
+func (tw *transformWriter) WriteHeader(code int) {
+	if tw.wroteHeader {
+		return
+	}
+	tw.wroteHeader, tw.status = true, code
+	tw.buffering = tw.rewritesBodies && isJSON(tw.Header())
+	if !tw.buffering {
+		tw.t.applyHeaders(tw.Header())
+		tw.ResponseWriter.WriteHeader(code)
+	}
+}
+
+func (tw *transformWriter) Write(p []byte) (int, error) {
+	tw.WriteHeader(http.StatusOK)
+	if !tw.buffering {
+		return tw.ResponseWriter.Write(p)
+	}
+	if tw.body.Len()+len(p) > maxTransformedBody {
+		tw.overflow = true
+		return len(p), nil
+	}
+	return tw.body.Write(p)
+}
+
+// finish sends a buffered response. A JSON body too large or too broken
+// to rewrite is refused with 502 rather than sent with fields left in.
+func (tw *transformWriter) finish() {
+	tw.WriteHeader(http.StatusOK)
+	if !tw.buffering {
+		return
+	}
+	body := tw.body.Bytes()
+	var err error
+	if tw.overflow {
+		err = fmt.Errorf("more than %d bytes", maxTransformedBody)
+	} else if len(body) > 0 {
+		body, err = rewriteJSON(body, tw.t.Rename, tw.redact)
+	}
+	if err != nil {
+		tw.Header().Del("Content-Length")
+		writeError(tw.ResponseWriter, http.StatusBadGateway, "bad gateway")
+		return
+	}
+	h := tw.Header()
+	h.Set("Content-Length", strconv.Itoa(len(body)))
+	tw.t.applyHeaders(h)
+	tw.ResponseWriter.WriteHeader(tw.status)
+	_, _ = tw.ResponseWriter.Write(body)
+}
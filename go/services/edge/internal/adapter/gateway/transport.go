@@ -0,0 +1,137 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"clean-code-cookbook/go/pkg/breaker"
+	"clean-code-cookbook/go/pkg/budget"
+	"clean-code-cookbook/go/services/edge/pkg/retrybudget"
+)
+
+// errServerError marks a 5xx answer as a failure for the breaker; the
+// response itself is still returned to the caller.
+var errServerError = errors.New("upstream answered with a server error")
+
+// ResilientTransport guards one HTTP upstream, so its failures stay its
+// own: every request runs through the upstream's breaker, each attempt
+// gets a slice of the caller's deadline, and GET and HEAD requests that
+// fail to connect, time out or get 502/503/504 are retried while the
+// retry budget allows.
+type ResilientTransport struct {
+	// Budget bounds every attempt: a share of what is left of the caller's
+	// deadline, so a retry still has time to run.
+	Budget budget.Budget
+	// Attempts is how often an idempotent request is tried.
+	Attempts int
+	// Backoff is the pause before the first retry; it doubles after each.
+	Backoff time.Duration
+	// Retries caps retries across all callers; nil leaves only Attempts.
+	Retries *retrybudget.Budget
+	// Breaker rejects requests with breaker.ErrOpen while the upstream
+	// keeps failing or answering 5xx. A request with its retries counts as
+	// one call.
+	Breaker *breaker.Breaker
+
+	next http.RoundTripper
+}
+
+// NewResilientTransport wraps next with a breaker named name, two
+// attempts and a 10% retry budget.
+func NewResilientTransport(name string, next http.RoundTripper) *ResilientTransport {
+	return &ResilientTransport{
+		Budget:   budget.Budget{Share: 0.5, Floor: 50 * time.Millisecond, Ceiling: 5 * time.Second},
+		Attempts: 2,
+		Backoff:  50 * time.Millisecond,
+		Retries:  retrybudget.New(0.1),
+		Breaker:  breaker.New(name),
+		next:     next,
+	}
+}
+
+func (t *ResilientTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var resp *http.Response
+	err := t.Breaker.Do(req.Context(), func(ctx context.Context) error {
+		var err error
+		resp, err = t.roundTripWithRetries(req)
+		if err == nil && resp.StatusCode >= 500 {
+			return errServerError
+		}
+		return err
+	})
+	if errors.Is(err, errServerError) {
+		return resp, nil
+	}
+	return resp, err
+}
+
+func (t *ResilientTransport) roundTripWithRetries(req *http.Request) (*http.Response, error) {
+	idempotent := (req.Method == http.MethodGet || req.Method == http.MethodHead) &&
+		(req.Body == nil || req.Body == http.NoBody)
+	if t.Retries != nil {
+		t.Retries.Request()
+	}
+	backoff := t.Backoff
+	for attempt := 1; ; attempt++ {
+		resp, err := t.attempt(req)
+		if !idempotent || attempt >= t.Attempts || !retryable(req, resp, err) {
+			return resp, err
+		}
+		if t.Retries != nil && !t.Retries.Retry() {
+			return resp, err
+		}
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4<<10))
+			resp.Body.Close()
+		}
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// attempt sends req once under its share of the deadline, which lasts
+// until the response body is closed.
+func (t *ResilientTransport) attempt(req *http.Request) (*http.Response, error) {
+	ctx, cancel := t.Budget.Apply(req.Context())
+	resp, err := t.next.RoundTrip(req.Clone(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// retryable reports whether another attempt may succeed: the caller is
+// still waiting and the upstream was unreachable, slow or overloaded.
+func retryable(req *http.Request, resp *http.Response, err error) bool {
+	if req.Context().Err() != nil {
+		return false
+	}
+	if err != nil {
+		return true
+	}
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
@@ -0,0 +1,138 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"clean-code-cookbook/go/pkg/breaker"
+	pb "github.com/clean-code-coockbook/proto/gen/go/users/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Users is the users service as the gateway calls it; grpc.UserClient
+// implements it.
+type Users interface {
+	RegisterUser(ctx context.Context, email, username string) (*pb.RegisterUserResponse, error)
+	GetUser(ctx context.Context, email string) (*pb.GetUserResponse, error)
+}
+
+// User is the public JSON shape of a user.
+type User struct {
+	ID        string `json:"id"`
+	Email     string `json:"email"`
+	Username  string `json:"username"`
+	IsActive  bool   `json:"is_active"`
+	CreatedAt string `json:"created_at,omitempty"`
+}
+
+// UsersHandler serves the users route, mounted at /api/users:
+//
+//	POST /api/users          {"email", "username"} -> 201
+//	GET  /api/users/{email}  -> 200
+type UsersHandler struct {
+	users  Users
+	logger *log.Logger
+}
+
+func NewUsersHandler(users Users, logger *log.Logger) *UsersHandler {
+	return &UsersHandler{users: users, logger: logger}
+}
+
+// maxRegisterBody bounds a registration payload.
+const maxRegisterBody = 64 << 10
+
+func (h *UsersHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	email := strings.Trim(r.URL.Path, "/")
+	switch {
+	case strings.Contains(email, "/"):
+		writeError(w, http.StatusNotFound, "not found")
+	case email == "" && r.Method == http.MethodPost:
+		h.register(w, r)
+	case email != "" && r.Method == http.MethodGet:
+		h.get(w, r, email)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+type registerRequest struct {
+	Email    string `json:"email"`
+	Username string `json:"username"`
+}
+
+type registerResponse struct {
+	ID       string `json:"id"`
+	Email    string `json:"email"`
+	Username string `json:"username"`
+	Status   string `json:"status"`
+}
+
+func (h *UsersHandler) register(w http.ResponseWriter, r *http.Request) {
+	var req registerRequest
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRegisterBody))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	resp, err := h.users.RegisterUser(r.Context(), req.Email, req.Username)
+	if err != nil {
+		h.writeUpstreamError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, registerResponse{
+		ID:       resp.GetId(),
+		Email:    resp.GetEmail(),
+		Username: resp.GetUsername(),
+		Status:   resp.GetStatus(),
+	})
+}
+
+func (h *UsersHandler) get(w http.ResponseWriter, r *http.Request, email string) {
+	resp, err := h.users.GetUser(r.Context(), email)
+	if err != nil {
+		h.writeUpstreamError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, userFromProto(resp.GetUser()))
+}
+
+func userFromProto(u *pb.User) User {
+	return User{
+		ID:        u.GetId(),
+		Email:     u.GetEmail(),
+		Username:  u.GetUsername(),
+		IsActive:  u.GetIsActive(),
+		CreatedAt: u.GetCreatedAt(),
+	}
+}
+
+// writeUpstreamError maps gRPC failures to HTTP. Client mistakes keep the
+// upstream's message; anything else is logged and answered generically.
+func (h *UsersHandler) writeUpstreamError(w http.ResponseWriter, err error) {
+	st, _ := status.FromError(err)
+	switch {
+	case errors.Is(err, breaker.ErrOpen):
+		w.Header().Set("Retry-After", "1")
+		writeError(w, http.StatusServiceUnavailable, "users service unavailable")
+	case errors.Is(err, context.DeadlineExceeded), st.Code() == codes.DeadlineExceeded:
+		writeError(w, http.StatusGatewayTimeout, "users service timed out")
+	case st.Code() == codes.InvalidArgument:
+		writeError(w, http.StatusBadRequest, st.Message())
+	case st.Code() == codes.NotFound:
+		writeError(w, http.StatusNotFound, st.Message())
+	case st.Code() == codes.AlreadyExists:
+		writeError(w, http.StatusConflict, st.Message())
+	case st.Code() == codes.Unavailable, st.Code() == codes.ResourceExhausted:
+		w.Header().Set("Retry-After", "1")
+		writeError(w, http.StatusServiceUnavailable, "users service unavailable")
+	default:
+		h.logger.Printf("users upstream: %v", err)
+		writeError(w, http.StatusBadGateway, "bad gateway")
+	}
+}
@@ -4,28 +4,78 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"log"
 	"time"
 
 	// In a real scenario, this import path must match the generated code location.
 	// We are assuming the proto definition's go_package option is respected.
+	"clean-code-cookbook/go/pkg/breaker"
+	"clean-code-cookbook/go/pkg/budget"
+	"clean-code-cookbook/go/services/edge/pkg/retrybudget"
 	pb "github.com/clean-code-coockbook/proto/gen/go/users/v1"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type UserClient struct {
+	// Budget bounds every unary attempt: a share of what is left of the
+	// caller's deadline, so a retry still has time to run.
+	Budget budget.Budget
+	// Attempts is how often GetUser is tried while the upstream answers
+	// Unavailable. RegisterUser is not idempotent and is never retried.
+	Attempts int
+	// Backoff is the pause before the first retry; it doubles after each.
+	Backoff time.Duration
+	// Retries caps GetUser retries across all callers, so a struggling
+	// upstream is not hit with Attempts times its load; nil leaves only
+	// Attempts.
+	Retries *retrybudget.Budget
+	// Breaker rejects calls with breaker.ErrOpen while the upstream keeps
+	// failing. A GetUser with its retries counts as one call.
+	Breaker *breaker.Breaker
+	// StreamBackoff is StreamEvents' pause before reconnecting; it doubles
+	// after each failed stream up to StreamMaxBackoff.
+	StreamBackoff    time.Duration
+	StreamMaxBackoff time.Duration
+	// OnStreamError, if set, sees why StreamEvents is reconnecting and
+	// how long it waits first.
+	OnStreamError func(err error, retryIn time.Duration)
+
 	client pb.UserServiceClient
 }
 
 func NewUserClient(conn *grpc.ClientConn) *UserClient {
 	return &UserClient{
+		Budget:   budget.Budget{Share: 0.5, Floor: 50 * time.Millisecond, Ceiling: 5 * time.Second},
+		Attempts: 3,
+		Backoff:  100 * time.Millisecond,
+		Retries:  retrybudget.New(0.1),
+		Breaker:  newBreaker(),
+
+		StreamBackoff:    time.Second,
+		StreamMaxBackoff: 30 * time.Second,
+
 		client: pb.NewUserServiceClient(conn),
 	}
 }
 
+// newBreaker counts only codes that say the upstream is unhealthy; a
+// rejected or unknown user is a healthy answer.
+func newBreaker() *breaker.Breaker {
+	b := breaker.New("users-grpc")
+	b.IsFailure = func(err error) bool {
+		switch status.Code(err) {
+		case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Internal, codes.Unknown:
+			return true
+		default:
+			return false
+		}
+	}
+	return b
+}
+
 func (c *UserClient) RegisterUser(ctx context.Context, email, username string) (*pb.RegisterUserResponse, error) {
-	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	ctx, cancel := c.Budget.Apply(ctx)
 	defer cancel()
 
 	req := &pb.RegisterUserRequest{
@@ -33,33 +83,128 @@ func (c *UserClient) RegisterUser(ctx context.Context, email, username string) (
 		Username: username,
 	}
 
-	return c.client.RegisterUser(ctx, req)
+	var resp *pb.RegisterUserResponse
+	err := c.Breaker.Do(ctx, func(ctx context.Context) error {
+		var err error
+		resp, err = c.client.RegisterUser(ctx, req)
+		return err
+	})
+	return resp, err
 }
 
 func (c *UserClient) GetUser(ctx context.Context, email string) (*pb.GetUserResponse, error) {
-	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	var resp *pb.GetUserResponse
+	err := c.Breaker.Do(ctx, func(ctx context.Context) error {
+		var err error
+		resp, err = c.getUserWithRetries(ctx, email)
+		return err
+	})
+	return resp, err
+}
+
+func (c *UserClient) getUserWithRetries(ctx context.Context, email string) (*pb.GetUserResponse, error) {
+	if c.Retries != nil {
+		c.Retries.Request()
+	}
+	backoff := c.Backoff
+	for attempt := 1; ; attempt++ {
+		resp, err := c.getUser(ctx, email)
+		if status.Code(err) != codes.Unavailable || attempt >= c.Attempts {
+			return resp, err
+		}
+		if c.Retries != nil && !c.Retries.Retry() {
+			return resp, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+func (c *UserClient) getUser(ctx context.Context, email string) (*pb.GetUserResponse, error) {
+	ctx, cancel := c.Budget.Apply(ctx)
 	defer cancel()
 
 	return c.client.GetUser(ctx, &pb.GetUserRequest{Email: email})
 }
 
-func (c *UserClient) StreamEvents(ctx context.Context) error {
-	stream, err := c.client.StreamUserEvents(ctx, &pb.UserEventsRequest{})
+// StreamEvents calls handle for every user event until ctx is cancelled,
+// re-establishing the stream with backoff whenever it ends or breaks.
+// Each new stream resumes after the last event handled, so a reconnect
+// neither replays nor skips what the upstream still has. It returns nil
+// once ctx is done, and an error only if the upstream refuses the stream
+// outright (unimplemented, unauthenticated, forbidden or invalid).
+func (c *UserClient) StreamEvents(ctx context.Context, handle func(*pb.UserEvent)) error {
+	var resumeToken string
+	backoff := c.StreamBackoff
+	for {
+		started, delivered := time.Now(), false
+		err := c.subscribe(ctx, resumeToken, func(e *pb.UserEvent) {
+			resumeToken, delivered = e.GetId(), true
+			handle(e)
+		})
+		if ctx.Err() != nil {
+			return nil
+		}
+		if permanent(err) {
+			return err
+		}
+		// A stream that delivered or stayed up a while was healthy.
+		if delivered || time.Since(started) > time.Minute {
+			backoff = c.StreamBackoff
+		}
+		if c.OnStreamError != nil {
+			c.OnStreamError(err, backoff)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, c.StreamMaxBackoff)
+	}
+}
+
+// permanent reports whether retrying a stream that failed with err is
+// pointless until someone changes configuration.
+func permanent(err error) bool {
+	switch status.Code(err) {
+	case codes.Unimplemented, codes.Unauthenticated, codes.PermissionDenied, codes.InvalidArgument:
+		return true
+	}
+	return false
+}
+
+// SubscribeEvents opens StreamUserEvents and calls handle for every event
+// until the upstream closes the stream (nil) or it breaks (error).
+func (c *UserClient) SubscribeEvents(ctx context.Context, handle func(*pb.UserEvent)) error {
+	return c.subscribe(ctx, "", handle)
+}
+
+// subscribe is SubscribeEvents resuming after the event resumeToken names.
+func (c *UserClient) subscribe(ctx context.Context, resumeToken string, handle func(*pb.UserEvent)) error {
+	var stream pb.UserService_StreamUserEventsClient
+	err := c.Breaker.Do(ctx, func(ctx context.Context) error {
+		var err error
+		stream, err = c.client.StreamUserEvents(ctx, &pb.UserEventsRequest{ResumeToken: resumeToken})
+		return err
+	})
 	if err != nil {
 		return fmt.Errorf("failed to start stream: %w", err)
 	}
 
-	log.Println("Started listening for user events...")
 	for {
 		event, err := stream.Recv()
 		if err == io.EOF {
-			break
+			return nil
 		}
 		if err != nil {
 			return fmt.Errorf("stream error: %w", err)
 		}
-		log.Printf("Received Event: Type=%s, User=%s, Time=%s", 
-			event.Type, event.Payload.Username, event.OccurredAt)
+		handle(event)
 	}
-	return nil
 }
@@ -0,0 +1,94 @@
+package grpc
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+
+	"google.golang.org/grpc"
+	_ "google.golang.org/grpc/health" // registers the client-side health checker
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/resolver/manual"
+)
+
+// Upstream says where the users service runs. Calls are spread over every
+// ready backend with round_robin; a backend whose grpc.health.v1 status is
+// not SERVING is taken out of rotation until it recovers. Servers without
+// the health service count as healthy.
+type Upstream struct {
+	// Targets is either a single resolver target such as
+	// "dns:///users:50051" or a static list of host:port addresses. DNS is
+	// re-resolved whenever a backend connection fails, so replaced pods
+	// are picked up without a restart.
+	Targets []string
+	// Subset caps how many static addresses one edge instance connects to,
+	// chosen at random so a large fleet of edges spreads evenly; 0 uses
+	// them all. It does not apply to resolver targets.
+	Subset int
+	// HealthService is the service name asked of grpc.health.v1; empty
+	// asks for the server's overall health.
+	HealthService string
+	// Tuning sets keepalive, message sizes and compression.
+	Tuning Tuning
+}
+
+// ErrNoTargets is returned by Dial when the Upstream names no backend.
+var ErrNoTargets = errors.New("grpc: no upstream targets")
+
+// ParseTargets splits a comma-separated address list such as the
+// USERS_GRPC_ADDR variable, dropping blanks.
+func ParseTargets(s string) []string {
+	var targets []string
+	for _, t := range strings.Split(s, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			targets = append(targets, t)
+		}
+	}
+	return targets
+}
+
+// staticScheme names the resolver Dial installs for an address list.
+const staticScheme = "edge-static"
+
+// Dial connects to the upstream. opts carry transport credentials and
+// anything else the caller needs; the balancing config and u's Tuning are
+// added here.
+func Dial(u Upstream, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	if len(u.Targets) == 0 {
+		return nil, ErrNoTargets
+	}
+	opts = append(opts, grpc.WithDefaultServiceConfig(serviceConfig(u.HealthService)))
+	opts = append(opts, u.Tuning.dialOptions()...)
+
+	// 1. A single target with a scheme goes to the registered resolver.
+	target := u.Targets[0]
+	if len(u.Targets) == 1 && strings.Contains(target, "://") {
+		return grpc.Dial(target, opts...)
+	}
+
+	// 2. Anything else is a fixed list, fed to a resolver of our own.
+	addrs := subset(u.Targets, u.Subset)
+	r := manual.NewBuilderWithScheme(staticScheme)
+	state := resolver.State{}
+	for _, addr := range addrs {
+		state.Addresses = append(state.Addresses, resolver.Address{Addr: addr})
+	}
+	r.InitialState(state)
+	return grpc.Dial(staticScheme+":///users", append(opts, grpc.WithResolvers(r))...)
+}
+
+func serviceConfig(healthService string) string {
+	return fmt.Sprintf(`{"loadBalancingConfig":[{"round_robin":{}}],"healthCheckConfig":{"serviceName":%q}}`, healthService)
+}
+
+// subset returns n addresses picked at random, or all of them when n is
+// zero or covers the list.
+func subset(addrs []string, n int) []string {
+	if n <= 0 || n >= len(addrs) {
+		return addrs
+	}
+	picked := append([]string(nil), addrs...)
+	rand.Shuffle(len(picked), func(i, j int) { picked[i], picked[j] = picked[j], picked[i] })
+	return picked[:n]
+}
@@ -0,0 +1,45 @@
+package grpc
+
+import (
+	"context"
+
+	"clean-code-cookbook/go/services/edge/internal/identity"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// PropagateIdentity returns dial options that attach the caller of
+// every call, if the gateway authenticated one, as an internal token for
+// audience under the x-edge-identity metadata key. Streams opened for a
+// browser carry it too; the event stream runs on the edge's own behalf and
+// carries none.
+func PropagateIdentity(signer *identity.Signer, audience string) []grpc.DialOption {
+	withCaller := func(ctx context.Context) (context.Context, error) {
+		caller, ok := identity.FromContext(ctx)
+		if !ok {
+			return ctx, nil
+		}
+		token, err := signer.Sign(caller, audience)
+		if err != nil {
+			return nil, err
+		}
+		return metadata.AppendToOutgoingContext(ctx, identity.MetadataKey, token), nil
+	}
+	unary := func(ctx context.Context, method string, req, reply any,
+		cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx, err := withCaller(ctx)
+		if err != nil {
+			return err
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+	stream := func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string,
+		streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		ctx, err := withCaller(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return streamer(ctx, desc, cc, method, opts...)
+	}
+	return []grpc.DialOption{grpc.WithChainUnaryInterceptor(unary), grpc.WithChainStreamInterceptor(stream)}
+}
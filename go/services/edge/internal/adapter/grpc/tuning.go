@@ -0,0 +1,63 @@
+package grpc
+
+import (
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/keepalive"
+)
+
+// Keepalive defaults. Load balancers and NAT gateways drop connections
+// idle for a few minutes without telling either end; a ping every 30s
+// keeps them open and finds a dead one within KeepaliveTimeout. The
+// server has to permit pings this often (its default is one per 5
+// minutes) or it answers with GOAWAY too_many_pings.
+const (
+	DefaultKeepaliveTime    = 30 * time.Second
+	DefaultKeepaliveTimeout = 10 * time.Second
+)
+
+// Tuning is how a connection is kept alive and what its calls may carry.
+// Zero values keep the defaults.
+type Tuning struct {
+	// KeepaliveTime is how long the connection may sit without activity
+	// before it is pinged; it is also pinged with no call in flight.
+	KeepaliveTime time.Duration
+	// KeepaliveTimeout is how long a ping may go unanswered before the
+	// connection is closed.
+	KeepaliveTimeout time.Duration
+	// MaxRecvBytes and MaxSendBytes cap a single message; gRPC's own
+	// defaults are 4MB received and unlimited sent.
+	MaxRecvBytes int
+	MaxSendBytes int
+	// Compression is "gzip" to compress every request, or empty. Servers
+	// answer compressed in kind.
+	Compression string
+}
+
+// dialOptions turns t into the options Dial adds.
+func (t Tuning) dialOptions() []grpc.DialOption {
+	params := keepalive.ClientParameters{
+		Time:                DefaultKeepaliveTime,
+		Timeout:             DefaultKeepaliveTimeout,
+		PermitWithoutStream: true,
+	}
+	if t.KeepaliveTime > 0 {
+		params.Time = t.KeepaliveTime
+	}
+	if t.KeepaliveTimeout > 0 {
+		params.Timeout = t.KeepaliveTimeout
+	}
+	var call []grpc.CallOption
+	if t.MaxRecvBytes > 0 {
+		call = append(call, grpc.MaxCallRecvMsgSize(t.MaxRecvBytes))
+	}
+	if t.MaxSendBytes > 0 {
+		call = append(call, grpc.MaxCallSendMsgSize(t.MaxSendBytes))
+	}
+	if t.Compression == gzip.Name {
+		call = append(call, grpc.UseCompressor(gzip.Name))
+	}
+	return []grpc.DialOption{grpc.WithKeepaliveParams(params), grpc.WithDefaultCallOptions(call...)}
+}
@@ -0,0 +1,203 @@
+// Package sse fans the upstream user-event stream out to plain HTTP
+// clients, as server-sent events or newline-delimited JSON over a chunked
+// response, for callers that cannot or will not speak WebSocket.
+package sse
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"clean-code-cookbook/go/services/edge/internal/adapter/ws"
+	pb "github.com/clean-code-coockbook/proto/gen/go/users/v1"
+)
+
+// Policy is what happens to a client whose buffer is full.
+type Policy int
+
+const (
+	// Disconnect ends the slow client's response; it can reconnect and
+	// knows, from the event ids, what it missed.
+	Disconnect Policy = iota
+	// Drop skips events for the slow client and keeps it connected; each
+	// skipped event is counted.
+	Drop
+)
+
+func (p Policy) String() string {
+	if p == Drop {
+		return "drop"
+	}
+	return "disconnect"
+}
+
+// Options tunes the per-client buffers and keepalive timings.
+type Options struct {
+	// Buffer is how many events may queue for one client before Policy
+	// applies.
+	Buffer int
+	Policy Policy
+	// Heartbeat is how often an idle response gets a keepalive line, so
+	// proxies do not time it out.
+	Heartbeat time.Duration
+	// WriteWait bounds a single write; a client that stops reading
+	// altogether is dropped once it passes.
+	WriteWait time.Duration
+}
+
+// DefaultOptions suits browsers behind typical load balancers (60s idle).
+var DefaultOptions = Options{
+	Buffer:    256,
+	Policy:    Disconnect,
+	Heartbeat: 25 * time.Second,
+	WriteWait: 10 * time.Second,
+}
+
+// Broker tracks streaming clients and broadcasts every event to all of
+// them. Each client has its own buffer, so one stalled reader never holds
+// up the others or the upstream stream.
+type Broker struct {
+	opts   Options
+	logger *log.Logger
+
+	mu      sync.Mutex
+	clients map[*client]struct{}
+
+	delivered, dropped, disconnected atomic.Int64
+}
+
+func NewBroker(opts Options, logger *log.Logger) *Broker {
+	return &Broker{opts: opts, logger: logger, clients: make(map[*client]struct{})}
+}
+
+// queued is an event waiting in a client's buffer.
+type queued struct {
+	id, typ string
+	data    []byte
+	at      time.Time
+}
+
+type client struct {
+	subject string
+	send    chan queued
+	// dropped counts events skipped under Drop; delay is how long the
+	// last written event waited in the buffer, in nanoseconds.
+	dropped atomic.Int64
+	delay   atomic.Int64
+}
+
+// Broadcast encodes e once and queues it for every client.
+func (b *Broker) Broadcast(e ws.Event) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		b.logger.Printf("sse: encode %s: %v", e.Type, err)
+		return
+	}
+	q := queued{id: e.ID, typ: e.Type, data: data, at: time.Now()}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for c := range b.clients {
+		select {
+		case c.send <- q:
+		default:
+			if b.opts.Policy == Drop {
+				c.dropped.Add(1)
+				b.dropped.Add(1)
+				continue
+			}
+			b.logger.Printf("sse: disconnecting slow consumer %s", c.subject)
+			b.disconnected.Add(1)
+			b.removeLocked(c)
+		}
+	}
+}
+
+// Relay returns source with every event also broadcast to b's clients,
+// so they share one upstream stream with whatever else consumes it.
+func (b *Broker) Relay(source ws.EventSource) ws.EventSource {
+	return relay{source: source, broker: b}
+}
+
+type relay struct {
+	source ws.EventSource
+	broker *Broker
+}
+
+func (r relay) StreamEvents(ctx context.Context, handle func(*pb.UserEvent)) error {
+	return r.source.StreamEvents(ctx, func(e *pb.UserEvent) {
+		r.broker.Broadcast(ws.FromProto(e))
+		handle(e)
+	})
+}
+
+// Stats is a snapshot of the broker for /debug/vars. Lag is the most
+// events queued for any one client, Delay the longest the last event
+// written to any client had waited.
+type Stats struct {
+	Clients      int           `json:"clients"`
+	Policy       string        `json:"policy"`
+	Delivered    int64         `json:"delivered"`
+	Dropped      int64         `json:"dropped"`
+	Disconnected int64         `json:"disconnected"`
+	Lag          int           `json:"lag"`
+	Delay        time.Duration `json:"delay_ns"`
+}
+
+func (b *Broker) Stats() Stats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	s := Stats{
+		Clients:      len(b.clients),
+		Policy:       b.opts.Policy.String(),
+		Delivered:    b.delivered.Load(),
+		Dropped:      b.dropped.Load(),
+		Disconnected: b.disconnected.Load(),
+	}
+	for c := range b.clients {
+		s.Lag = max(s.Lag, len(c.send))
+		s.Delay = max(s.Delay, time.Duration(c.delay.Load()))
+	}
+	return s
+}
+
+// Clients reports how many clients are connected.
+func (b *Broker) Clients() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.clients)
+}
+
+// Close ends every client's response.
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for c := range b.clients {
+		b.removeLocked(c)
+	}
+}
+
+func (b *Broker) add(c *client) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.clients[c] = struct{}{}
+}
+
+func (b *Broker) remove(c *client) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.removeLocked(c)
+}
+
+// removeLocked closes the send channel exactly once; the handler sees the
+// close and ends the response.
+func (b *Broker) removeLocked(c *client) {
+	if _, ok := b.clients[c]; !ok {
+		return
+	}
+	delete(b.clients, c)
+	close(c.send)
+}
@@ -0,0 +1,105 @@
+package sse
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"clean-code-cookbook/go/services/edge/internal/identity"
+)
+
+// Handler streams events to each GET until the client goes away or is
+// disconnected as a slow consumer. Clients asking for
+// application/x-ndjson get one JSON event per line; everyone else gets
+// text/event-stream. Authentication is left to the route's middleware.
+func (b *Broker) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		// 1. Register before answering, so no event after this is missed
+		ndjson := wantsNDJSON(r.Header.Get("Accept"))
+		subject := r.RemoteAddr
+		if caller, ok := identity.FromContext(r.Context()); ok {
+			subject = caller.Subject
+		}
+		c := &client{subject: subject, send: make(chan queued, b.opts.Buffer)}
+		b.add(c)
+		defer b.remove(c)
+
+		h := w.Header()
+		if ndjson {
+			h.Set("Content-Type", "application/x-ndjson")
+		} else {
+			h.Set("Content-Type", "text/event-stream")
+		}
+		h.Set("Cache-Control", "no-cache")
+		h.Set("X-Accel-Buffering", "no") // nginx would otherwise hold events back
+		w.WriteHeader(http.StatusOK)
+		rc := http.NewResponseController(w)
+		if err := rc.Flush(); err != nil {
+			b.logger.Printf("sse: %s cannot be streamed to: %v", subject, err)
+			return
+		}
+
+		// 2. Pump events and heartbeats until one side is done
+		heartbeat := time.NewTicker(b.opts.Heartbeat)
+		defer heartbeat.Stop()
+		for {
+			var frame string
+			var event bool
+			select {
+			case <-r.Context().Done():
+				return
+			case q, ok := <-c.send:
+				if !ok {
+					return
+				}
+				c.delay.Store(int64(time.Since(q.at)))
+				frame, event = format(q, ndjson), true
+			case <-heartbeat.C:
+				frame = ": keepalive\n\n"
+				if ndjson {
+					frame = "\n"
+				}
+			}
+			_ = rc.SetWriteDeadline(time.Now().Add(b.opts.WriteWait))
+			if _, err := fmt.Fprint(w, frame); err != nil {
+				return
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+			if event {
+				b.delivered.Add(1)
+			}
+		}
+	})
+}
+
+// format renders q as one server-sent event or one NDJSON line. Event ids
+// let an EventSource client tell, after reconnecting, what it missed.
+func format(q queued, ndjson bool) string {
+	if ndjson {
+		return string(q.data) + "\n"
+	}
+	return fmt.Sprintf("id: %s\nevent: %s\ndata: %s\n\n", oneLine(q.id), oneLine(q.typ), q.data)
+}
+
+// oneLine keeps upstream strings from breaking the event framing.
+func oneLine(s string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(s)
+}
+
+func wantsNDJSON(accept string) bool {
+	for _, part := range strings.Split(accept, ",") {
+		if mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part)); err == nil && mediaType == "application/x-ndjson" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,25 @@
+package ws
+
+import (
+	"context"
+
+	pb "github.com/clean-code-coockbook/proto/gen/go/users/v1"
+)
+
+// EventSource is the upstream stream; grpc.UserClient satisfies it.
+type EventSource interface {
+	// StreamEvents calls handle for every event, reconnecting as needed,
+	// until ctx is cancelled (nil) or the upstream refuses it (error).
+	StreamEvents(ctx context.Context, handle func(*pb.UserEvent)) error
+}
+
+// Bridge relays upstream events to the hub until ctx is cancelled or the
+// upstream refuses the stream for good.
+func (h *Hub) Bridge(ctx context.Context, source EventSource) {
+	err := source.StreamEvents(ctx, func(e *pb.UserEvent) {
+		h.Broadcast(FromProto(e))
+	})
+	if err != nil {
+		h.logger.Printf("ws: upstream stream stopped: %v", err)
+	}
+}
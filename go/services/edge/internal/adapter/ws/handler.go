@@ -0,0 +1,118 @@
+package ws
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// ErrUnauthorized is returned by an Authenticator to reject a handshake.
+var ErrUnauthorized = errors.New("unauthorized")
+
+// Authenticator inspects the upgrade request and returns the caller's
+// identity. It runs before the upgrade, so a rejection is a plain 401.
+type Authenticator func(r *http.Request) (subject string, err error)
+
+// StaticToken accepts a shared bearer token. Browsers cannot set headers on
+// a WebSocket handshake, so the token may also come in ?access_token=.
+func StaticToken(token string) Authenticator {
+	return func(r *http.Request) (string, error) {
+		got := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if got == "" {
+			got = r.URL.Query().Get("access_token")
+		}
+		if token == "" || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			return "", ErrUnauthorized
+		}
+		return r.RemoteAddr, nil
+	}
+}
+
+type client struct {
+	subject string
+	conn    *websocket.Conn
+	send    chan []byte
+}
+
+// Handler upgrades authenticated requests and registers the socket with
+// the hub. checkOrigin may be nil to allow same-origin requests only.
+func (h *Hub) Handler(auth Authenticator, checkOrigin func(*http.Request) bool) http.Handler {
+	upgrader := websocket.Upgrader{
+		ReadBufferSize:  1024,
+		WriteBufferSize: 1024,
+		CheckOrigin:     checkOrigin,
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 1. Authenticate before upgrading
+		subject, err := auth(r)
+		if err != nil {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		// 2. Upgrade (the upgrader writes its own error response)
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+
+		// 3. Register and pump
+		c := &client{subject: subject, conn: conn, send: make(chan []byte, h.opts.SendBuffer)}
+		h.add(c)
+		go h.writePump(c)
+		go h.readPump(c)
+	})
+}
+
+// readPump discards client frames; it exists to process pongs and notice
+// when the browser goes away.
+func (h *Hub) readPump(c *client) {
+	defer h.remove(c)
+
+	c.conn.SetReadLimit(512)
+	_ = c.conn.SetReadDeadline(time.Now().Add(h.opts.PongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(h.opts.PongWait))
+	})
+	for {
+		if _, _, err := c.conn.NextReader(); err != nil {
+			return
+		}
+	}
+}
+
+// writePump is the only goroutine writing to the connection. It owns the
+// connection's lifetime: when send is closed it says goodbye and closes.
+func (h *Hub) writePump(c *client) {
+	ticker := time.NewTicker(h.opts.PingInterval)
+	defer func() {
+		ticker.Stop()
+		_ = c.conn.Close()
+	}()
+
+	for {
+		select {
+		case frame, ok := <-c.send:
+			_ = c.conn.SetWriteDeadline(time.Now().Add(h.opts.WriteWait))
+			if !ok {
+				_ = c.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, ""))
+				return
+			}
+			if err := c.conn.WriteMessage(websocket.TextMessage, frame); err != nil {
+				h.remove(c)
+				return
+			}
+		case <-ticker.C:
+			_ = c.conn.SetWriteDeadline(time.Now().Add(h.opts.WriteWait))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				h.remove(c)
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,139 @@
+// Package ws fans the upstream user-event stream out to browser clients
+// over WebSockets.
+package ws
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	pb "github.com/clean-code-coockbook/proto/gen/go/users/v1"
+)
+
+// Event is the JSON frame browsers receive. It mirrors pb.UserEvent but
+// keeps the wire format independent of the generated code.
+type Event struct {
+	ID         string `json:"id"`
+	Type       string `json:"type"`
+	OccurredAt string `json:"occurred_at"`
+	User       *User  `json:"user,omitempty"`
+}
+
+// User is the public projection of pb.User sent to browsers.
+type User struct {
+	ID        string `json:"id"`
+	Email     string `json:"email"`
+	Username  string `json:"username"`
+	IsActive  bool   `json:"is_active"`
+	CreatedAt string `json:"created_at"`
+}
+
+// FromProto converts an upstream event into its browser representation.
+func FromProto(e *pb.UserEvent) Event {
+	out := Event{ID: e.GetId(), Type: e.GetType(), OccurredAt: e.GetOccurredAt()}
+	if u := e.GetPayload(); u != nil {
+		out.User = &User{
+			ID:        u.GetId(),
+			Email:     u.GetEmail(),
+			Username:  u.GetUsername(),
+			IsActive:  u.GetIsActive(),
+			CreatedAt: u.GetCreatedAt(),
+		}
+	}
+	return out
+}
+
+// Options tunes the per-socket buffers and keepalive timings.
+type Options struct {
+	// SendBuffer is how many frames may queue for one socket before it is
+	// considered a slow consumer and evicted.
+	SendBuffer int
+	// PingInterval is how often the server pings; PongWait must be longer.
+	PingInterval time.Duration
+	// PongWait is how long a socket may stay silent before it is dropped.
+	PongWait time.Duration
+	// WriteWait bounds a single frame write.
+	WriteWait time.Duration
+}
+
+// DefaultOptions suits browsers behind typical load balancers (60s idle).
+var DefaultOptions = Options{
+	SendBuffer:   64,
+	PingInterval: 25 * time.Second,
+	PongWait:     60 * time.Second,
+	WriteWait:    10 * time.Second,
+}
+
+// Hub tracks connected sockets and broadcasts every event to all of them.
+// A socket whose buffer is full is disconnected instead of blocking the
+// others: one stalled tab must not delay events for everyone else.
+type Hub struct {
+	opts   Options
+	logger *log.Logger
+
+	mu      sync.Mutex
+	clients map[*client]struct{}
+}
+
+func NewHub(opts Options, logger *log.Logger) *Hub {
+	return &Hub{opts: opts, logger: logger, clients: make(map[*client]struct{})}
+}
+
+// Broadcast encodes e once and queues it on every socket.
+func (h *Hub) Broadcast(e Event) {
+	frame, err := json.Marshal(e)
+	if err != nil {
+		h.logger.Printf("ws: encode %s: %v", e.Type, err)
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for c := range h.clients {
+		select {
+		case c.send <- frame:
+		default:
+			h.logger.Printf("ws: evicting slow consumer %s", c.subject)
+			h.removeLocked(c)
+		}
+	}
+}
+
+// Clients reports how many sockets are connected.
+func (h *Hub) Clients() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.clients)
+}
+
+// Close disconnects every socket.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for c := range h.clients {
+		h.removeLocked(c)
+	}
+}
+
+func (h *Hub) add(c *client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.clients[c] = struct{}{}
+}
+
+func (h *Hub) remove(c *client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.removeLocked(c)
+}
+
+// removeLocked closes the send channel exactly once; the writer sees the
+// close, sends a close frame and tears the connection down.
+func (h *Hub) removeLocked(c *client) {
+	if _, ok := h.clients[c]; !ok {
+		return
+	}
+	delete(h.clients, c)
+	close(c.send)
+}
@@ -0,0 +1,170 @@
+package identity
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"clean-code-cookbook/go/pkg/jwt"
+)
+
+// ErrUnknownKey is returned for an RS256 token signed with a key the
+// issuer's JWKS does not list.
+var ErrUnknownKey = errors.New("identity: unknown signing key")
+
+// keysRefreshEvery bounds how often an unknown kid triggers a JWKS fetch,
+// so junk tokens cannot make the edge hammer the identity provider.
+const keysRefreshEvery = time.Minute
+
+// Authenticator validates caller JWTs from the issuers it trusts. The
+// unverified "iss" claim only picks which issuer's key applies.
+type Authenticator struct {
+	// Audience, when set, must be in every token's "aud".
+	Audience string
+	// Leeway absorbs clock skew between the edge and the issuers.
+	Leeway time.Duration
+	// Now checks expiry; it defaults to time.Now.
+	Now func() time.Time
+
+	issuers map[string]func(ctx context.Context, token *jwt.Token) error
+}
+
+func NewAuthenticator(audience string) *Authenticator {
+	return &Authenticator{
+		Audience: audience,
+		Leeway:   time.Minute,
+		Now:      time.Now,
+		issuers:  make(map[string]func(context.Context, *jwt.Token) error),
+	}
+}
+
+// Issuers reports how many issuers are trusted.
+func (a *Authenticator) Issuers() int { return len(a.issuers) }
+
+// TrustHS256 accepts tokens from issuer signed with a shared secret, such
+// as the users service's own session tokens.
+func (a *Authenticator) TrustHS256(issuer string, secret []byte) {
+	a.issuers[issuer] = func(_ context.Context, token *jwt.Token) error {
+		return token.VerifyHS256(secret)
+	}
+}
+
+// TrustJWKS accepts RS256 tokens from issuer signed with a key published
+// at jwksURL, typically an OpenID Connect provider's.
+func (a *Authenticator) TrustJWKS(issuer, jwksURL string, client *http.Client) {
+	keys := &jwks{url: jwksURL, client: client, now: func() time.Time { return a.Now() }}
+	a.issuers[issuer] = func(ctx context.Context, token *jwt.Token) error {
+		key, err := keys.key(ctx, token.Header.Kid)
+		if err != nil {
+			return err
+		}
+		return token.VerifyRS256(key)
+	}
+}
+
+// Authenticate verifies raw and returns its caller. Bad tokens fail with
+// one of the jwt errors or ErrUnknownKey; anything else means an issuer's
+// keys could not be fetched.
+func (a *Authenticator) Authenticate(ctx context.Context, raw string) (Caller, error) {
+	token, err := jwt.Parse(raw)
+	if err != nil {
+		return Caller{}, err
+	}
+	verify, ok := a.issuers[token.Claims.Issuer]
+	if !ok {
+		return Caller{}, fmt.Errorf("%w: issuer %q", jwt.ErrClaims, token.Claims.Issuer)
+	}
+	if err := verify(ctx, token); err != nil {
+		return Caller{}, err
+	}
+	expect := jwt.Expect{Issuer: token.Claims.Issuer, Audience: a.Audience, Now: a.Now(), Leeway: a.Leeway}
+	if err := token.Claims.Validate(expect); err != nil {
+		return Caller{}, err
+	}
+	if token.Claims.Subject == "" {
+		return Caller{}, fmt.Errorf("%w: no subject", jwt.ErrClaims)
+	}
+	return Caller{Subject: token.Claims.Subject, Issuer: token.Claims.Issuer, Email: token.Claims.Email}, nil
+}
+
+// jwks caches an issuer's RSA signing keys, refetching them when a token
+// names a kid it has not seen.
+type jwks struct {
+	url    string
+	client *http.Client
+	now    func() time.Time
+
+	mu      sync.Mutex
+	keys    map[string]*rsa.PublicKey
+	fetched time.Time
+}
+
+func (j *jwks) key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if key, ok := j.keys[kid]; ok {
+		return key, nil
+	}
+	if !j.fetched.IsZero() && j.now().Sub(j.fetched) < keysRefreshEvery {
+		return nil, ErrUnknownKey
+	}
+	keys, err := j.fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	j.keys, j.fetched = keys, j.now()
+	if key, ok := keys[kid]; ok {
+		return key, nil
+	}
+	return nil, ErrUnknownKey
+}
+
+// fetch reads the RSA signing keys of the JWKS; other key types are
+// skipped.
+func (j *jwks) fetch(ctx context.Context) (map[string]*rsa.PublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, j.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := j.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("jwks: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("jwks: GET %s: status %d", j.url, resp.StatusCode)
+	}
+
+	var set struct {
+		Keys []struct {
+			Kid string `json:"kid"`
+			Kty string `json:"kty"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, fmt.Errorf("jwks: %w", err)
+	}
+	keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+		n, errN := base64.RawURLEncoding.DecodeString(k.N)
+		e, errE := base64.RawURLEncoding.DecodeString(k.E)
+		if errN != nil || errE != nil {
+			return nil, fmt.Errorf("jwks: key %s is not valid base64url", k.Kid)
+		}
+		keys[k.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	}
+	return keys, nil
+}
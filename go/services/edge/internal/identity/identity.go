@@ -0,0 +1,41 @@
+// Package identity carries the authenticated caller through the edge and
+// on to the services behind it. The edge validates the caller's JWT once;
+// upstreams receive a short-lived internal token, signed by the edge, in
+// the x-edge-identity gRPC metadata key or HTTP header. The token is
+// go/pkg/edgetoken's, so upstreams verify it with the code that signs it
+// here.
+package identity
+
+import (
+	"context"
+
+	"clean-code-cookbook/go/pkg/edgetoken"
+)
+
+// Header is the HTTP header, and MetadataKey the gRPC metadata key, that
+// carry the internal token.
+const (
+	Header      = edgetoken.Header
+	MetadataKey = edgetoken.MetadataKey
+)
+
+// Caller is who a request speaks for.
+type Caller = edgetoken.Caller
+
+// Signer mints internal tokens for one upstream (the audience) at a time.
+type Signer = edgetoken.Signer
+
+// WithCaller returns ctx carrying c.
+func WithCaller(ctx context.Context, c Caller) context.Context {
+	return edgetoken.WithCaller(ctx, c)
+}
+
+// FromContext returns the caller of the request, if it was authenticated.
+func FromContext(ctx context.Context) (Caller, bool) {
+	return edgetoken.FromContext(ctx)
+}
+
+// NewSigner returns a signer issuing as "edge" for 30 seconds.
+func NewSigner(secret []byte) *Signer {
+	return edgetoken.NewSigner(secret)
+}
@@ -0,0 +1,137 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	adapter "clean-code-cookbook/go/services/edge/internal/adapter/grpc"
+	pb "github.com/clean-code-coockbook/proto/gen/go/users/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// listenFakeUsers serves fake on a loopback TCP port, with health when
+// non-nil, and returns the address.
+func listenFakeUsers(t *testing.T, fake *fakeUsers, hs *health.Server) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	server := grpc.NewServer()
+	pb.RegisterUserServiceServer(server, fake)
+	if hs != nil {
+		healthpb.RegisterHealthServer(server, hs)
+	}
+	go server.Serve(listener) //nolint:errcheck // returns on Stop
+	t.Cleanup(server.Stop)
+	return listener.Addr().String()
+}
+
+func dialUpstream(t *testing.T, u adapter.Upstream) *adapter.UserClient {
+	t.Helper()
+	conn, err := adapter.Dial(u, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	client := adapter.NewUserClient(conn)
+	client.Budget.Ceiling = time.Second
+	client.Backoff = time.Millisecond
+	return client
+}
+
+func getUsers(t *testing.T, client *adapter.UserClient, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		if _, err := client.GetUser(context.Background(), alice.Email); err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+	}
+}
+
+func TestDial_StaticList_RoundRobins(t *testing.T) {
+	// Arrange
+	first := &fakeUsers{users: map[string]*pb.User{alice.Email: alice}}
+	second := &fakeUsers{users: map[string]*pb.User{alice.Email: alice}}
+	client := dialUpstream(t, adapter.Upstream{Targets: []string{
+		listenFakeUsers(t, first, nil),
+		listenFakeUsers(t, second, nil),
+	}})
+
+	// Act
+	// The first calls may run while round_robin still connects to the
+	// second backend, so keep calling until both answered or time is up.
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) && (first.callCount("GetUser") == 0 || second.callCount("GetUser") == 0) {
+		getUsers(t, client, 1)
+	}
+
+	// Assert
+	if first.callCount("GetUser") == 0 || second.callCount("GetUser") == 0 {
+		t.Errorf("Expected calls on both backends, but got %d and %d", first.callCount("GetUser"), second.callCount("GetUser"))
+	}
+}
+
+func TestDial_SkipsUnhealthyBackends(t *testing.T) {
+	// Arrange
+	sick := health.NewServer()
+	sick.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	unhealthy := &fakeUsers{users: map[string]*pb.User{alice.Email: alice}}
+	healthy := &fakeUsers{users: map[string]*pb.User{alice.Email: alice}}
+	client := dialUpstream(t, adapter.Upstream{Targets: []string{
+		listenFakeUsers(t, unhealthy, sick),
+		listenFakeUsers(t, healthy, health.NewServer()),
+	}})
+
+	// Act
+	getUsers(t, client, 10)
+
+	// Assert
+	if got := unhealthy.callCount("GetUser"); got != 0 {
+		t.Errorf("Expected no calls to the NOT_SERVING backend, but got %d", got)
+	}
+	if got := healthy.callCount("GetUser"); got != 10 {
+		t.Errorf("Expected 10 calls to the healthy backend, but got %d", got)
+	}
+}
+
+func TestDial_SubsetConnectsToSomeBackends(t *testing.T) {
+	// Arrange
+	fakes := make([]*fakeUsers, 4)
+	targets := make([]string, len(fakes))
+	for i := range fakes {
+		fakes[i] = &fakeUsers{users: map[string]*pb.User{alice.Email: alice}}
+		targets[i] = listenFakeUsers(t, fakes[i], nil)
+	}
+	client := dialUpstream(t, adapter.Upstream{Targets: targets, Subset: 2})
+
+	// Act
+	getUsers(t, client, 40)
+
+	// Assert
+	used := 0
+	for _, fake := range fakes {
+		if fake.callCount("GetUser") > 0 {
+			used++
+		}
+	}
+	if used > 2 {
+		t.Errorf("Expected at most 2 backends in use, but got %d", used)
+	}
+}
+
+func TestDial_NoTargets(t *testing.T) {
+	// Act
+	_, err := adapter.Dial(adapter.Upstream{Targets: adapter.ParseTargets(" , ")})
+
+	// Assert
+	if !errors.Is(err, adapter.ErrNoTargets) {
+		t.Errorf("Expected error '%v', but got '%v'", adapter.ErrNoTargets, err)
+	}
+}
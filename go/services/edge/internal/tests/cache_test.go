@@ -0,0 +1,159 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"clean-code-cookbook/go/services/edge/internal/adapter/gateway"
+	pb "github.com/clean-code-coockbook/proto/gen/go/users/v1"
+)
+
+// countingUpstream answers every request with 200, the given
+// Cache-Control, and a body naming how many calls it has seen.
+func countingUpstream(cacheControl string) (http.Handler, *int) {
+	calls := new(int)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*calls++
+		if cacheControl != "" {
+			w.Header().Set("Cache-Control", cacheControl)
+		}
+		_, _ = w.Write([]byte(strconv.Itoa(*calls)))
+	}), calls
+}
+
+func newTestCache(now *time.Time) *gateway.ResponseCache {
+	c := gateway.NewResponseCache(100, time.Minute)
+	c.Now = func() time.Time { return *now }
+	return c
+}
+
+func get(h http.Handler, path, cacheControl string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if cacheControl != "" {
+		req.Header.Set("Cache-Control", cacheControl)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestResponseCache_ServesRepeatsFromCache(t *testing.T) {
+	// Arrange
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	upstream, calls := countingUpstream("")
+	handler := newTestCache(&now).Middleware(upstream)
+
+	// Act
+	miss := get(handler, "/api/products/sku-1", "")
+	now = now.Add(10 * time.Second)
+	hit := get(handler, "/api/products/sku-1", "")
+	other := get(handler, "/api/products/sku-2", "")
+	now = now.Add(time.Minute)
+	expired := get(handler, "/api/products/sku-1", "")
+
+	// Assert
+	if miss.Header().Get("X-Cache") != "MISS" || hit.Header().Get("X-Cache") != "HIT" {
+		t.Errorf("Expected MISS then HIT, but got %q then %q", miss.Header().Get("X-Cache"), hit.Header().Get("X-Cache"))
+	}
+	if hit.Body.String() != "1" || hit.Header().Get("Age") != "10" {
+		t.Errorf("Expected the first response, 10s old, but got %q aged %q", hit.Body, hit.Header().Get("Age"))
+	}
+	if other.Body.String() != "2" || expired.Body.String() != "3" || *calls != 3 {
+		t.Errorf("Expected 3 upstream calls, but got %d", *calls)
+	}
+}
+
+func TestResponseCache_HonoursCacheControl(t *testing.T) {
+	cases := []struct {
+		name      string
+		upstream  string
+		request   string
+		advance   time.Duration
+		wantCalls int
+	}{
+		{"upstream max-age shortens", "max-age=5", "", 6 * time.Second, 2},
+		{"upstream max-age kept", "public, max-age=5", "", 4 * time.Second, 1},
+		{"upstream no-store", "no-store", "", 0, 2},
+		{"upstream private", "private, max-age=60", "", 0, 2},
+		{"client no-cache", "", "no-cache", 0, 2},
+		{"client no-store", "", "no-store", 0, 2},
+		{"client max-age", "", "max-age=3", 4 * time.Second, 2},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+			upstream, calls := countingUpstream(tc.upstream)
+			handler := newTestCache(&now).Middleware(upstream)
+
+			// Act
+			get(handler, "/api/products/sku-1", "")
+			now = now.Add(tc.advance)
+			get(handler, "/api/products/sku-1", tc.request)
+
+			// Assert
+			if *calls != tc.wantCalls {
+				t.Errorf("Expected %d upstream calls, but got %d", tc.wantCalls, *calls)
+			}
+		})
+	}
+}
+
+func TestResponseCache_KeepsOnlySuccesses(t *testing.T) {
+	// Arrange
+	now := time.Now()
+	calls := 0
+	handler := newTestCache(&now).Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		http.NotFound(w, r)
+	}))
+
+	// Act
+	get(handler, "/api/products/sku-404", "")
+	get(handler, "/api/products/sku-404", "")
+
+	// Assert
+	if calls != 2 {
+		t.Errorf("Expected 2 upstream calls, but got %d", calls)
+	}
+}
+
+// eventSourceFunc adapts a function to gateway.EventSource.
+type eventSourceFunc func(ctx context.Context, handle func(*pb.UserEvent)) error
+
+func (f eventSourceFunc) StreamEvents(ctx context.Context, handle func(*pb.UserEvent)) error {
+	return f(ctx, handle)
+}
+
+func TestResponseCache_UserEventsInvalidate(t *testing.T) {
+	// Arrange
+	now := time.Now()
+	c := newTestCache(&now)
+	upstream, calls := countingUpstream("")
+	handler := c.Middleware(upstream)
+	get(handler, "/api/users/alice@example.com", "")
+	get(handler, "/api/users/bob@example.com", "")
+	source := eventSourceFunc(func(_ context.Context, handle func(*pb.UserEvent)) error {
+		handle(&pb.UserEvent{Type: "user_updated", Payload: alice})
+		return nil
+	})
+	var relayed int
+
+	// Act
+	err := gateway.Invalidating(source, c.InvalidateUsers("/api/users")).
+		StreamEvents(context.Background(), func(*pb.UserEvent) { relayed++ })
+	get(handler, "/api/users/alice@example.com", "")
+	get(handler, "/api/users/bob@example.com", "")
+
+	// Assert
+	if err != nil || relayed != 1 {
+		t.Fatalf("Expected the event relayed once, but got %d (%v)", relayed, err)
+	}
+	if *calls != 3 {
+		t.Errorf("Expected only alice to be fetched again (3 calls), but got %d", *calls)
+	}
+}
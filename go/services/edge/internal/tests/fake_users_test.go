@@ -0,0 +1,169 @@
+package tests
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	pb "github.com/clean-code-coockbook/proto/gen/go/users/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// fakeUsers is an in-process users service. Tests program it before
+// dialing: each unary call first sleeps latency, then pops the next code
+// from failures[method] (failing with it), and only succeeds once that
+// script is used up.
+type fakeUsers struct {
+	pb.UnimplementedUserServiceServer
+
+	latency  time.Duration
+	failures map[string][]codes.Code
+	users    map[string]*pb.User
+
+	// events are streamed in order, starting after the request's resume
+	// token and at most perStream (if set) per stream. The stream then ends
+	// with the next of streamEnds, or streamEnd once those are used up
+	// (nil closes it cleanly), unless holdStream keeps it open until the
+	// client goes away.
+	events     []*pb.UserEvent
+	perStream  int
+	streamEnds []error
+	streamEnd  error
+	holdStream bool
+
+	mu    sync.Mutex
+	calls map[string]int
+	// identities are the x-edge-identity tokens unary calls carried.
+	identities []string
+	// resumeTokens are what each StreamUserEvents call asked to resume
+	// after.
+	resumeTokens []string
+}
+
+// dialFakeUsers serves fake over an in-memory listener and returns a
+// connection to it, dialed with opts; both are torn down with the test.
+func dialFakeUsers(t *testing.T, fake *fakeUsers, opts ...grpc.DialOption) *grpc.ClientConn {
+	t.Helper()
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	pb.RegisterUserServiceServer(server, fake)
+	go server.Serve(listener) //nolint:errcheck // returns on Stop
+
+	conn, err := grpc.Dial("bufnet", append([]grpc.DialOption{
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	}, opts...)...)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	t.Cleanup(func() {
+		conn.Close()
+		server.Stop()
+	})
+	return conn
+}
+
+// called counts a call to method and returns the scripted failure, if any.
+func (f *fakeUsers) called(ctx context.Context, method string) error {
+	f.mu.Lock()
+	if f.calls == nil {
+		f.calls = make(map[string]int)
+	}
+	f.calls[method]++
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		f.identities = append(f.identities, md.Get("x-edge-identity")...)
+	}
+	var code codes.Code
+	if script := f.failures[method]; len(script) > 0 {
+		code, f.failures[method] = script[0], script[1:]
+	}
+	f.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return status.FromContextError(ctx.Err()).Err()
+	case <-time.After(f.latency):
+	}
+	if code != codes.OK {
+		return status.Errorf(code, "scripted %s failure", method)
+	}
+	return nil
+}
+
+func (f *fakeUsers) callCount(method string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls[method]
+}
+
+func (f *fakeUsers) seenIdentities() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.identities...)
+}
+
+func (f *fakeUsers) RegisterUser(ctx context.Context, req *pb.RegisterUserRequest) (*pb.RegisterUserResponse, error) {
+	if err := f.called(ctx, "RegisterUser"); err != nil {
+		return nil, err
+	}
+	return &pb.RegisterUserResponse{Id: "u-1", Email: req.GetEmail(), Username: req.GetUsername(), Status: "created"}, nil
+}
+
+func (f *fakeUsers) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.GetUserResponse, error) {
+	if err := f.called(ctx, "GetUser"); err != nil {
+		return nil, err
+	}
+	user, ok := f.users[req.GetEmail()]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "user %s not found", req.GetEmail())
+	}
+	return &pb.GetUserResponse{User: user}, nil
+}
+
+func (f *fakeUsers) StreamUserEvents(req *pb.UserEventsRequest, stream pb.UserService_StreamUserEventsServer) error {
+	if err := f.called(stream.Context(), "StreamUserEvents"); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	f.resumeTokens = append(f.resumeTokens, req.GetResumeToken())
+	events := f.events
+	for i, e := range f.events {
+		if e.GetId() == req.GetResumeToken() {
+			events = f.events[i+1:]
+		}
+	}
+	if f.perStream > 0 && len(events) > f.perStream {
+		events = events[:f.perStream]
+	}
+	end := f.streamEnd
+	if len(f.streamEnds) > 0 {
+		end, f.streamEnds = f.streamEnds[0], f.streamEnds[1:]
+	}
+	f.mu.Unlock()
+
+	for _, e := range events {
+		if err := stream.Send(e); err != nil {
+			return err
+		}
+	}
+	if f.holdStream {
+		<-stream.Context().Done()
+		return stream.Context().Err()
+	}
+	return end
+}
+
+func (f *fakeUsers) seenResumeTokens() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.resumeTokens...)
+}
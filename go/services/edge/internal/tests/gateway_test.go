@@ -0,0 +1,162 @@
+package tests
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"clean-code-cookbook/go/services/edge/internal/adapter/gateway"
+	pb "github.com/clean-code-coockbook/proto/gen/go/users/v1"
+	"google.golang.org/grpc/codes"
+)
+
+// newGateway mounts the users route on fake and the products route on a
+// catalog that knows sku-1, finds it searching for "clean", and records
+// the headers it was sent.
+func newGateway(t *testing.T, fake *fakeUsers) (http.Handler, *http.Header) {
+	t.Helper()
+	logger := log.New(io.Discard, "", 0)
+	seen := &http.Header{}
+	catalog := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*seen = r.Header.Clone()
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/products/sku-1":
+			_, _ = io.WriteString(w, `{"id":"sku-1","name":"Clean Code","price":39.99}`)
+		case r.URL.Path == "/products" && r.URL.Query().Get("q") == "clean":
+			_, _ = io.WriteString(w, `{"items":[{"id":"sku-1","name":"Clean Code","price":39.99}]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(catalog.Close)
+
+	products, err := gateway.NewProductsProxy(catalog.URL, http.DefaultTransport, logger)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	mux := http.NewServeMux()
+	gateway.Mount(mux,
+		gateway.Route{
+			Prefix:     "/api/users",
+			Handler:    gateway.NewUsersHandler(newFakeClient(t, fake), logger),
+			Middleware: []gateway.Middleware{gateway.BearerToken("secret"), gateway.Timeout(time.Second)},
+		},
+		gateway.Route{
+			Prefix:     "/api/products",
+			Handler:    products,
+			Middleware: []gateway.Middleware{gateway.Methods(http.MethodGet, http.MethodHead)},
+		},
+	)
+	return mux, seen
+}
+
+func serve(h http.Handler, method, path, body, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestGateway_UsersRoute(t *testing.T) {
+	gw, _ := newGateway(t, &fakeUsers{
+		users:    map[string]*pb.User{alice.Email: alice},
+		failures: map[string][]codes.Code{"RegisterUser": {codes.OK, codes.AlreadyExists}},
+	})
+
+	cases := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		token  string
+		want   int
+	}{
+		{"get", http.MethodGet, "/api/users/alice@example.com", "", "secret", http.StatusOK},
+		{"unknown user", http.MethodGet, "/api/users/nobody@example.com", "", "secret", http.StatusNotFound},
+		{"no token", http.MethodGet, "/api/users/alice@example.com", "", "", http.StatusUnauthorized},
+		{"wrong token", http.MethodGet, "/api/users/alice@example.com", "", "guess", http.StatusUnauthorized},
+		{"register", http.MethodPost, "/api/users", `{"email":"bob@example.com","username":"bob"}`, "secret", http.StatusCreated},
+		{"register twice", http.MethodPost, "/api/users", `{"email":"bob@example.com","username":"bob"}`, "secret", http.StatusConflict},
+		{"bad payload", http.MethodPost, "/api/users", `{"email":`, "secret", http.StatusBadRequest},
+		{"wrong method", http.MethodDelete, "/api/users/alice@example.com", "", "secret", http.StatusMethodNotAllowed},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Act
+			rec := serve(gw, tc.method, tc.path, tc.body, tc.token)
+
+			// Assert
+			if rec.Code != tc.want {
+				t.Errorf("Expected status %d, but got %d: %s", tc.want, rec.Code, rec.Body)
+			}
+		})
+	}
+}
+
+func TestGateway_GetUserBody(t *testing.T) {
+	// Arrange
+	gw, _ := newGateway(t, &fakeUsers{users: map[string]*pb.User{alice.Email: alice}})
+
+	// Act
+	rec := serve(gw, http.MethodGet, "/api/users/alice@example.com", "", "secret")
+
+	// Assert
+	var got gateway.User
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if got.ID != alice.Id || got.Username != "alice" || !got.IsActive {
+		t.Errorf("Expected alice, but got %+v", got)
+	}
+}
+
+func TestGateway_UsersUnavailable(t *testing.T) {
+	// Arrange
+	gw, _ := newGateway(t, &fakeUsers{failures: map[string][]codes.Code{"GetUser": {codes.Unavailable, codes.Unavailable, codes.Unavailable}}})
+
+	// Act
+	rec := serve(gw, http.MethodGet, "/api/users/alice@example.com", "", "secret")
+
+	// Assert
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503, but got %d", rec.Code)
+	}
+}
+
+func TestGateway_ProductsRoute(t *testing.T) {
+	// Arrange
+	gw, seen := newGateway(t, &fakeUsers{})
+
+	// Act
+	found := serve(gw, http.MethodGet, "/api/products/sku-1", "", "secret")
+	forwarded := seen.Clone()
+	search := serve(gw, http.MethodGet, "/api/products?q=clean", "", "")
+	missing := serve(gw, http.MethodGet, "/api/products/sku-404", "", "")
+	write := serve(gw, http.MethodPost, "/api/products/sku-1", "{}", "")
+
+	// Assert
+	if found.Code != http.StatusOK || !strings.Contains(found.Body.String(), "Clean Code") {
+		t.Errorf("Expected the catalog's product, but got %d: %s", found.Code, found.Body)
+	}
+	if search.Code != http.StatusOK || !strings.Contains(search.Body.String(), `"items"`) {
+		t.Errorf("Expected the catalog's search page, but got %d: %s", search.Code, search.Body)
+	}
+	if missing.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, but got %d", missing.Code)
+	}
+	if write.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status 405, but got %d", write.Code)
+	}
+	if forwarded.Get("Authorization") != "" {
+		t.Errorf("Expected the edge token to stay at the edge, but the catalog got %q", forwarded.Get("Authorization"))
+	}
+}
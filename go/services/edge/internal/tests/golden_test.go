@@ -0,0 +1,62 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"clean-code-cookbook/go/services/edge/internal/adapter/ws"
+	pb "github.com/clean-code-coockbook/proto/gen/go/users/v1"
+)
+
+// update rewrites the golden files instead of comparing against them:
+//
+//	go test ./internal/tests -run Golden -update
+var update = flag.Bool("update", false, "rewrite golden files in testdata/golden")
+
+// assertGolden compares got with testdata/golden/<name>.golden.
+func assertGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", "golden", name+".golden")
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Expected golden file %s (run with -update to create it), but got: %v", path, err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("Wire format of %s changed; run with -update if intended.\n--- want\n%s\n--- got\n%s", name, want, got)
+	}
+}
+
+func TestGolden_EventFrames(t *testing.T) {
+	cases := map[string]*pb.UserEvent{
+		"frame_user_registered": {
+			Id: "evt-1", Type: "user.registered", OccurredAt: "2024-01-02T03:04:05Z",
+			Payload: &pb.User{Id: "u-1", Email: "alice@example.com", Username: "alice", IsActive: true, CreatedAt: "2024-01-02T03:04:05Z"},
+		},
+		"frame_without_payload": {Id: "evt-2", Type: "user.deleted", OccurredAt: "2024-01-02T03:04:05Z"},
+	}
+	for name, event := range cases {
+		t.Run(name, func(t *testing.T) {
+			// Act
+			frame, err := json.Marshal(ws.FromProto(event))
+
+			// Assert
+			if err != nil {
+				t.Fatalf("Expected no error, but got: %v", err)
+			}
+			assertGolden(t, name, append(frame, '\n'))
+		})
+	}
+}
@@ -0,0 +1,203 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"clean-code-cookbook/go/services/edge/internal/adapter/gateway"
+	adapter "clean-code-cookbook/go/services/edge/internal/adapter/grpc"
+	"clean-code-cookbook/go/services/edge/internal/identity"
+	pb "github.com/clean-code-coockbook/proto/gen/go/users/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/encoding"
+)
+
+var protoCodec = encoding.GetCodec("proto")
+
+// newGRPCWebGateway mounts the fake users service for gRPC-Web, with
+// origins allowed cross-origin.
+func newGRPCWebGateway(t *testing.T, conn *grpc.ClientConn, origins ...string) http.Handler {
+	t.Helper()
+	mux := http.NewServeMux()
+	gateway.Mount(mux, gateway.Route{
+		Prefix:     "/users.v1.UserService",
+		Handler:    gateway.NewGRPCWebHandler("users.v1.UserService", conn, log.New(&bytes.Buffer{}, "", 0)),
+		Middleware: []gateway.Middleware{gateway.AllowOrigins(origins...)},
+	})
+	return mux
+}
+
+// grpcWebRequest frames msg as a gRPC-Web call of method.
+func grpcWebRequest(t *testing.T, ctx context.Context, method string, msg any, text bool) *http.Request {
+	t.Helper()
+	payload, err := protoCodec.Marshal(msg)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	frame := append([]byte{0, 0, 0, 0, 0}, payload...)
+	binary.BigEndian.PutUint32(frame[1:5], uint32(len(payload)))
+	contentType := "application/grpc-web+proto"
+	if text {
+		frame, contentType = []byte(base64.StdEncoding.EncodeToString(frame)), "application/grpc-web-text"
+	}
+	req := httptest.NewRequest(http.MethodPost, "/users.v1.UserService/"+method, bytes.NewReader(frame)).WithContext(ctx)
+	req.Header.Set("Content-Type", contentType)
+	return req
+}
+
+// grpcWebResponse splits a response body into its messages and trailer.
+func grpcWebResponse(t *testing.T, rec *httptest.ResponseRecorder, text bool) (messages [][]byte, trailer string) {
+	t.Helper()
+	body := rec.Body.Bytes()
+	if text {
+		// Each frame is its own padded base64 chunk.
+		var decoded []byte
+		for len(body) > 0 {
+			head, err := base64.StdEncoding.DecodeString(string(body[:8]))
+			if err != nil {
+				t.Fatalf("Expected no error, but got: %v", err)
+			}
+			size := base64.StdEncoding.EncodedLen(5 + int(binary.BigEndian.Uint32(head[1:5])))
+			frame, err := base64.StdEncoding.DecodeString(string(body[:size]))
+			if err != nil {
+				t.Fatalf("Expected no error, but got: %v", err)
+			}
+			decoded, body = append(decoded, frame...), body[size:]
+		}
+		body = decoded
+	}
+	for len(body) >= 5 {
+		size := 5 + int(binary.BigEndian.Uint32(body[1:5]))
+		if body[0]&0x80 != 0 {
+			trailer = string(body[5:size])
+		} else {
+			messages = append(messages, body[5:size])
+		}
+		body = body[size:]
+	}
+	return messages, trailer
+}
+
+func TestGRPCWeb_UnaryCall(t *testing.T) {
+	// Arrange
+	signer := identity.NewSigner([]byte("internal-secret"))
+	fake := &fakeUsers{users: map[string]*pb.User{alice.Email: alice}}
+	h := newGRPCWebGateway(t, dialFakeUsers(t, fake, adapter.PropagateIdentity(signer, "users")...))
+	ctx := identity.WithCaller(context.Background(), identity.Caller{Subject: "u-1"})
+	rec := httptest.NewRecorder()
+
+	// Act
+	h.ServeHTTP(rec, grpcWebRequest(t, ctx, "GetUser", &pb.GetUserRequest{Email: alice.Email}, false))
+
+	// Assert
+	messages, trailer := grpcWebResponse(t, rec, false)
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/grpc-web+proto" {
+		t.Fatalf("Expected a 200 gRPC-Web response, but got %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(trailer, "grpc-status: 0\r\n") || len(messages) != 1 {
+		t.Fatalf("Expected one message and status 0, but got %d and %q", len(messages), trailer)
+	}
+	var resp pb.GetUserResponse
+	if err := protoCodec.Unmarshal(messages[0], &resp); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if resp.GetUser().GetEmail() != alice.Email {
+		t.Errorf("Expected alice, but got %q", resp.GetUser().GetEmail())
+	}
+	if len(fake.seenIdentities()) != 1 {
+		t.Errorf("Expected the caller to be vouched for upstream, but got %d identities", len(fake.seenIdentities()))
+	}
+}
+
+func TestGRPCWeb_StreamsInTextMode(t *testing.T) {
+	// Arrange
+	fake := &fakeUsers{events: []*pb.UserEvent{
+		{Id: "evt-1", Type: "user.registered", Payload: alice},
+		{Id: "evt-2", Type: "user.updated", Payload: alice},
+	}}
+	h := newGRPCWebGateway(t, dialFakeUsers(t, fake))
+	rec := httptest.NewRecorder()
+
+	// Act
+	h.ServeHTTP(rec, grpcWebRequest(t, context.Background(), "StreamUserEvents", &pb.UserEventsRequest{ResumeToken: "evt-1"}, true))
+
+	// Assert
+	messages, trailer := grpcWebResponse(t, rec, true)
+	if !strings.Contains(trailer, "grpc-status: 0\r\n") || len(messages) != 1 {
+		t.Fatalf("Expected the one event after evt-1 and status 0, but got %d and %q", len(messages), trailer)
+	}
+	var event pb.UserEvent
+	if err := protoCodec.Unmarshal(messages[0], &event); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if event.GetId() != "evt-2" {
+		t.Errorf("Expected evt-2, but got %q", event.GetId())
+	}
+}
+
+func TestGRPCWeb_UpstreamErrorInTrailer(t *testing.T) {
+	// Arrange
+	h := newGRPCWebGateway(t, dialFakeUsers(t, &fakeUsers{}))
+	rec := httptest.NewRecorder()
+
+	// Act
+	h.ServeHTTP(rec, grpcWebRequest(t, context.Background(), "GetUser", &pb.GetUserRequest{Email: "nobody@example.com"}, false))
+
+	// Assert
+	messages, trailer := grpcWebResponse(t, rec, false)
+	if len(messages) != 0 || !strings.Contains(trailer, "grpc-status: 5\r\n") || !strings.Contains(trailer, "grpc-message: user nobody@example.com not found") {
+		t.Errorf("Expected NOT_FOUND in the trailer, but got %d messages and %q", len(messages), trailer)
+	}
+}
+
+func TestGRPCWeb_RejectsNonGRPCWeb(t *testing.T) {
+	// Arrange
+	h := newGRPCWebGateway(t, dialFakeUsers(t, &fakeUsers{}))
+
+	// Act
+	rec := serve(h, http.MethodPost, "/users.v1.UserService/GetUser", `{"email":"alice@example.com"}`, "")
+
+	// Assert
+	if rec.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("Expected 415, but got %d", rec.Code)
+	}
+}
+
+func TestAllowOrigins(t *testing.T) {
+	h := newGRPCWebGateway(t, dialFakeUsers(t, &fakeUsers{}), "https://app.example.com")
+	preflight := func(origin string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodOptions, "/users.v1.UserService/GetUser", nil)
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("allowed origin", func(t *testing.T) {
+		// Act
+		rec := preflight("https://app.example.com")
+
+		// Assert
+		if rec.Code != http.StatusNoContent || rec.Header().Get("Access-Control-Allow-Origin") != "https://app.example.com" {
+			t.Errorf("Expected 204 allowing the origin, but got %d %q", rec.Code, rec.Header().Get("Access-Control-Allow-Origin"))
+		}
+	})
+
+	t.Run("other origin", func(t *testing.T) {
+		// Act
+		rec := preflight("https://evil.example.com")
+
+		// Assert
+		if rec.Header().Get("Access-Control-Allow-Origin") != "" {
+			t.Errorf("Expected no CORS headers, but got %q", rec.Header().Get("Access-Control-Allow-Origin"))
+		}
+	})
+}
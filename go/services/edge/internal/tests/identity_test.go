@@ -0,0 +1,234 @@
+package tests
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"clean-code-cookbook/go/pkg/jwt"
+	"clean-code-cookbook/go/services/edge/internal/adapter/gateway"
+	adapter "clean-code-cookbook/go/services/edge/internal/adapter/grpc"
+	"clean-code-cookbook/go/services/edge/internal/identity"
+	pb "github.com/clean-code-coockbook/proto/gen/go/users/v1"
+)
+
+var sessionSecret = []byte("0123456789abcdef0123456789abcdef")
+
+func sessionToken(t *testing.T, claims jwt.Claims) string {
+	t.Helper()
+	token, err := jwt.SignHS256(claims, sessionSecret)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	return token
+}
+
+func validClaims() jwt.Claims {
+	now := time.Now()
+	return jwt.Claims{Issuer: "clean_go_system", Subject: "u-1", Email: "alice@example.com", IssuedAt: now.Unix(), ExpiresAt: now.Add(time.Hour).Unix()}
+}
+
+func TestAuthenticator_HS256(t *testing.T) {
+	auth := identity.NewAuthenticator("")
+	auth.TrustHS256("clean_go_system", sessionSecret)
+
+	expired := validClaims()
+	expired.ExpiresAt = time.Now().Add(-time.Hour).Unix()
+	stranger := validClaims()
+	stranger.Issuer = "someone-else"
+	forged, _ := jwt.SignHS256(validClaims(), []byte("not the secret"))
+
+	cases := []struct {
+		name  string
+		token string
+		want  error
+	}{
+		{"valid", sessionToken(t, validClaims()), nil},
+		{"expired", sessionToken(t, expired), jwt.ErrExpired},
+		{"unknown issuer", sessionToken(t, stranger), jwt.ErrClaims},
+		{"wrong secret", forged, jwt.ErrSignature},
+		{"garbage", "not-a-jwt", jwt.ErrMalformed},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Act
+			caller, err := auth.Authenticate(context.Background(), tc.token)
+
+			// Assert
+			if !errors.Is(err, tc.want) {
+				t.Fatalf("Expected error '%v', but got '%v'", tc.want, err)
+			}
+			if err == nil && (caller.Subject != "u-1" || caller.Email != "alice@example.com" || caller.Issuer != "clean_go_system") {
+				t.Errorf("Expected alice's identity, but got %+v", caller)
+			}
+		})
+	}
+}
+
+func TestAuthenticator_JWKS(t *testing.T) {
+	// Arrange
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	var fetches atomic.Int32
+	idp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kid": "k1", "kty": "RSA", "use": "sig",
+			"n": base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	}))
+	t.Cleanup(idp.Close)
+	auth := identity.NewAuthenticator("edge")
+	auth.TrustJWKS("https://idp.example.com", idp.URL, idp.Client())
+	claims := validClaims()
+	claims.Issuer, claims.Audience = "https://idp.example.com", jwt.Audience{"edge"}
+	good, _ := jwt.SignRS256(claims, key, "k1")
+	rotated, _ := jwt.SignRS256(claims, key, "k2")
+	claims.Audience = jwt.Audience{"someone-else"}
+	misaddressed, _ := jwt.SignRS256(claims, key, "k1")
+
+	// Act
+	caller, errGood := auth.Authenticate(context.Background(), good)
+	_, errRotated := auth.Authenticate(context.Background(), rotated)
+	_, errRotatedAgain := auth.Authenticate(context.Background(), rotated)
+	_, errAudience := auth.Authenticate(context.Background(), misaddressed)
+
+	// Assert
+	if errGood != nil || caller.Subject != "u-1" {
+		t.Fatalf("Expected alice's identity, but got %+v (%v)", caller, errGood)
+	}
+	if !errors.Is(errRotated, identity.ErrUnknownKey) || !errors.Is(errRotatedAgain, identity.ErrUnknownKey) {
+		t.Errorf("Expected error '%v', but got '%v' and '%v'", identity.ErrUnknownKey, errRotated, errRotatedAgain)
+	}
+	if !errors.Is(errAudience, jwt.ErrClaims) {
+		t.Errorf("Expected error '%v', but got '%v'", jwt.ErrClaims, errAudience)
+	}
+	if got := fetches.Load(); got != 1 {
+		t.Errorf("Expected unknown keys not to refetch within a minute, but the JWKS was fetched %d times", got)
+	}
+}
+
+// authenticatorFunc adapts a function to gateway.Authenticator.
+type authenticatorFunc func(ctx context.Context, raw string) (identity.Caller, error)
+
+func (f authenticatorFunc) Authenticate(ctx context.Context, raw string) (identity.Caller, error) {
+	return f(ctx, raw)
+}
+
+func TestJWTAuth(t *testing.T) {
+	auth := identity.NewAuthenticator("")
+	auth.TrustHS256("clean_go_system", sessionSecret)
+	unreachable := authenticatorFunc(func(context.Context, string) (identity.Caller, error) {
+		return identity.Caller{}, errors.New("jwks: connection refused")
+	})
+	var seen identity.Caller
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen, _ = identity.FromContext(r.Context())
+	})
+	logger := log.New(io.Discard, "", 0)
+
+	cases := []struct {
+		name  string
+		auth  gateway.Authenticator
+		token string
+		want  int
+	}{
+		{"valid", auth, sessionToken(t, validClaims()), http.StatusOK},
+		{"missing", auth, "", http.StatusUnauthorized},
+		{"invalid", auth, "not-a-jwt", http.StatusUnauthorized},
+		{"issuer unreachable", unreachable, sessionToken(t, validClaims()), http.StatusServiceUnavailable},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Act
+			rec := serve(gateway.JWTAuth(tc.auth, logger)(next), http.MethodGet, "/", "", tc.token)
+
+			// Assert
+			if rec.Code != tc.want {
+				t.Errorf("Expected status %d, but got %d", tc.want, rec.Code)
+			}
+		})
+	}
+	if seen.Subject != "u-1" {
+		t.Errorf("Expected the caller to reach the handler, but got %+v", seen)
+	}
+}
+
+func TestPropagateIdentity_UsersService(t *testing.T) {
+	// Arrange
+	signer := identity.NewSigner([]byte("internal-secret"))
+	fake := &fakeUsers{users: map[string]*pb.User{alice.Email: alice}}
+	client := adapter.NewUserClient(dialFakeUsers(t, fake, adapter.PropagateIdentity(signer, "users")...))
+	ctx := identity.WithCaller(context.Background(), identity.Caller{Subject: "u-1", Issuer: "clean_go_system", Email: "alice@example.com"})
+
+	// Act
+	_, err := client.GetUser(ctx, alice.Email)
+	_, errAnonymous := client.GetUser(context.Background(), alice.Email)
+
+	// Assert
+	if err != nil || errAnonymous != nil {
+		t.Fatalf("Expected no error, but got: %v, %v", err, errAnonymous)
+	}
+	tokens := fake.seenIdentities()
+	if len(tokens) != 1 {
+		t.Fatalf("Expected only the authenticated call to carry an identity, but got %d", len(tokens))
+	}
+	caller, err := signer.Verify(tokens[0], "users")
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if caller.Subject != "u-1" || caller.Email != "alice@example.com" || caller.Issuer != "edge" {
+		t.Errorf("Expected alice vouched for by the edge, but got %+v", caller)
+	}
+	if _, err := signer.Verify(tokens[0], "catalog"); !errors.Is(err, jwt.ErrClaims) {
+		t.Errorf("Expected error '%v' for another audience, but got '%v'", jwt.ErrClaims, err)
+	}
+}
+
+func TestPropagateIdentity_Catalog(t *testing.T) {
+	// Arrange
+	signer := identity.NewSigner([]byte("internal-secret"))
+	var got string
+	catalog := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get(identity.Header)
+	}))
+	t.Cleanup(catalog.Close)
+	send := func(t *testing.T, signer *identity.Signer, ctx context.Context) string {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, catalog.URL, nil)
+		req.Header.Set(identity.Header, "forged")
+		resp, err := gateway.PropagateIdentity(signer, "catalog", http.DefaultTransport).RoundTrip(req)
+		if err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+		resp.Body.Close()
+		return got
+	}
+	asAlice := identity.WithCaller(context.Background(), identity.Caller{Subject: "u-1"})
+
+	// Act
+	signed := send(t, signer, asAlice)
+	anonymous := send(t, signer, context.Background())
+	unsigned := send(t, nil, asAlice)
+
+	// Assert
+	if caller, err := signer.Verify(signed, "catalog"); err != nil || caller.Subject != "u-1" {
+		t.Errorf("Expected a token for u-1, but got %+v (%v)", caller, err)
+	}
+	if anonymous != "" || unsigned != "" {
+		t.Errorf("Expected the forged header to be dropped, but got %q and %q", anonymous, unsigned)
+	}
+}
@@ -0,0 +1,184 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"clean-code-cookbook/go/services/edge/internal/adapter/gateway"
+	pb "github.com/clean-code-coockbook/proto/gen/go/users/v1"
+	"google.golang.org/grpc/codes"
+)
+
+// catalogFunc adapts a function to gateway.Catalog.
+type catalogFunc func(ctx context.Context, limit int) ([]gateway.Product, error)
+
+func (f catalogFunc) SuggestedProducts(ctx context.Context, limit int) ([]gateway.Product, error) {
+	return f(ctx, limit)
+}
+
+var cleanCode = gateway.Product{ID: "sku-1", Name: "Clean Code", Price: 39.99}
+
+type profile struct {
+	User              *gateway.User     `json:"user"`
+	SuggestedProducts []gateway.Product `json:"suggested_products"`
+	Degraded          []string          `json:"degraded"`
+}
+
+func getProfile(t *testing.T, fake *fakeUsers, catalog gateway.Catalog) (int, profile) {
+	t.Helper()
+	handler := gateway.NewProfileHandler(newFakeClient(t, fake), catalog, log.New(io.Discard, "", 0))
+	handler.CatalogTimeout = 50 * time.Millisecond
+	mux := http.NewServeMux()
+	gateway.Mount(mux, gateway.Route{Prefix: "/api/profile", Handler: handler})
+
+	rec := serve(mux, http.MethodGet, "/api/profile/alice@example.com", "", "")
+	var got profile
+	if rec.Code == http.StatusOK {
+		if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+	}
+	return rec.Code, got
+}
+
+func TestProfile_MergesBothUpstreams(t *testing.T) {
+	// Arrange
+	fake := &fakeUsers{users: map[string]*pb.User{alice.Email: alice}}
+	catalog := catalogFunc(func(_ context.Context, limit int) ([]gateway.Product, error) {
+		if limit != 5 {
+			t.Errorf("Expected 5 products to be asked for, but got %d", limit)
+		}
+		return []gateway.Product{cleanCode}, nil
+	})
+
+	// Act
+	code, got := getProfile(t, fake, catalog)
+
+	// Assert
+	if code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d", code)
+	}
+	if got.User == nil || got.User.ID != alice.Id || !slices.Equal(got.SuggestedProducts, []gateway.Product{cleanCode}) || got.Degraded != nil {
+		t.Errorf("Expected alice with the suggested products, but got %+v", got)
+	}
+}
+
+func TestProfile_DegradesWhenOneUpstreamFails(t *testing.T) {
+	working := catalogFunc(func(context.Context, int) ([]gateway.Product, error) {
+		return []gateway.Product{cleanCode}, nil
+	})
+	failing := catalogFunc(func(context.Context, int) ([]gateway.Product, error) {
+		return nil, errors.New("catalog returned 500 Internal Server Error")
+	})
+	slow := catalogFunc(func(ctx context.Context, _ int) ([]gateway.Product, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	usersDown := map[string][]codes.Code{"GetUser": {codes.Unavailable, codes.Unavailable, codes.Unavailable}}
+
+	cases := []struct {
+		name         string
+		fake         *fakeUsers
+		catalog      gateway.Catalog
+		wantUser     bool
+		wantProducts int
+		wantDegraded []string
+	}{
+		{"catalog fails", &fakeUsers{users: map[string]*pb.User{alice.Email: alice}}, failing, true, 0, []string{"catalog"}},
+		{"catalog too slow", &fakeUsers{users: map[string]*pb.User{alice.Email: alice}}, slow, true, 0, []string{"catalog"}},
+		{"users fail", &fakeUsers{failures: usersDown}, working, false, 1, []string{"users"}},
+		{"no catalog", &fakeUsers{users: map[string]*pb.User{alice.Email: alice}}, nil, true, 0, nil},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Act
+			code, got := getProfile(t, tc.fake, tc.catalog)
+
+			// Assert
+			if code != http.StatusOK {
+				t.Fatalf("Expected status 200, but got %d", code)
+			}
+			if (got.User != nil) != tc.wantUser || len(got.SuggestedProducts) != tc.wantProducts || !slices.Equal(got.Degraded, tc.wantDegraded) {
+				t.Errorf("Expected user=%v, %d products, degraded %v, but got %+v", tc.wantUser, tc.wantProducts, tc.wantDegraded, got)
+			}
+		})
+	}
+}
+
+func TestProfile_UnknownUserCancelsCatalog(t *testing.T) {
+	// Arrange
+	cancelled := make(chan bool, 1)
+	catalog := catalogFunc(func(ctx context.Context, _ int) ([]gateway.Product, error) {
+		select {
+		case <-ctx.Done():
+			cancelled <- true
+		case <-time.After(time.Second):
+			cancelled <- false
+		}
+		return nil, ctx.Err()
+	})
+
+	// Act
+	code, _ := getProfile(t, &fakeUsers{}, catalog)
+
+	// Assert
+	if code != http.StatusNotFound {
+		t.Errorf("Expected status 404, but got %d", code)
+	}
+	if !<-cancelled {
+		t.Error("Expected the catalog call to be cancelled")
+	}
+}
+
+func TestProfile_BothUpstreamsDown(t *testing.T) {
+	// Arrange
+	fake := &fakeUsers{failures: map[string][]codes.Code{"GetUser": {codes.Unavailable, codes.Unavailable, codes.Unavailable}}}
+	catalog := catalogFunc(func(context.Context, int) ([]gateway.Product, error) {
+		return nil, errors.New("connection refused")
+	})
+
+	// Act
+	code, _ := getProfile(t, fake, catalog)
+
+	// Assert
+	if code != http.StatusBadGateway {
+		t.Errorf("Expected status 502, but got %d", code)
+	}
+}
+
+func TestCatalogClient_ReadsTheCatalogSearch(t *testing.T) {
+	// Arrange: the catalog's GET /products answer
+	var asked string
+	catalog := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		asked = r.URL.RequestURI()
+		if r.URL.Path != "/products" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"items":[{"id":"sku-1","name":"Clean Code","price":39.99}],"next":"abc"}`)
+	}))
+	defer catalog.Close()
+
+	// Act
+	products, err := gateway.NewCatalogClient(catalog.URL, http.DefaultTransport).SuggestedProducts(context.Background(), 5)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if asked != "/products?size=5" {
+		t.Errorf("Expected GET /products?size=5, but got %s", asked)
+	}
+	if !slices.Equal(products, []gateway.Product{cleanCode}) {
+		t.Errorf("Expected %v, but got %v", []gateway.Product{cleanCode}, products)
+	}
+}
@@ -0,0 +1,205 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"clean-code-cookbook/go/pkg/breaker"
+	"clean-code-cookbook/go/services/edge/internal/adapter/gateway"
+	"clean-code-cookbook/go/services/edge/pkg/retrybudget"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRetryBudget_CapsRetriesAtRatio(t *testing.T) {
+	// Arrange
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	b := retrybudget.New(0.1)
+	b.MinRetries = 1
+	b.Now = func() time.Time { return now }
+	for i := 0; i < 20; i++ {
+		b.Request()
+	}
+
+	// Act
+	var allowed int
+	for i := 0; i < 10; i++ {
+		if b.Retry() {
+			allowed++
+		}
+	}
+	now = now.Add(b.Window)
+	afterWindow := b.Retry()
+
+	// Assert
+	if allowed != 3 { // 1 + 10% of 20
+		t.Errorf("Expected 3 retries, but got %d", allowed)
+	}
+	if !afterWindow {
+		t.Error("Expected the budget to refill once the window passed")
+	}
+	if got := b.Stats(); got.Requests != 20 || got.Retries != 4 || got.Denied != 7 {
+		t.Errorf("Expected 20 requests, 4 retries and 7 denied, but got %+v", got)
+	}
+}
+
+// flakyCatalog answers the first failures requests with status and the
+// rest with 200, counting every request.
+func flakyCatalog(t *testing.T, failures int32, status int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= failures {
+			w.WriteHeader(status)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func newTestTransport() *gateway.ResilientTransport {
+	transport := gateway.NewResilientTransport("catalog-http", http.DefaultTransport)
+	transport.Backoff = time.Millisecond
+	transport.Budget.Ceiling = time.Second
+	return transport
+}
+
+func roundTrip(t *testing.T, rt http.RoundTripper, method, url string) (*http.Response, error) {
+	t.Helper()
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	resp, err := rt.RoundTrip(req)
+	if resp != nil {
+		t.Cleanup(func() { resp.Body.Close() })
+	}
+	return resp, err
+}
+
+func TestResilientTransport_RetriesIdempotentReads(t *testing.T) {
+	cases := []struct {
+		name       string
+		method     string
+		status     int
+		wantStatus int
+		wantCalls  int32
+	}{
+		{"GET after 503", http.MethodGet, http.StatusServiceUnavailable, http.StatusOK, 2},
+		{"GET after 500", http.MethodGet, http.StatusInternalServerError, http.StatusInternalServerError, 1},
+		{"POST after 503", http.MethodPost, http.StatusServiceUnavailable, http.StatusServiceUnavailable, 1},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			catalog, calls := flakyCatalog(t, 1, tc.status)
+
+			// Act
+			resp, err := roundTrip(t, newTestTransport(), tc.method, catalog.URL)
+
+			// Assert
+			if err != nil {
+				t.Fatalf("Expected no error, but got: %v", err)
+			}
+			if resp.StatusCode != tc.wantStatus || calls.Load() != tc.wantCalls {
+				t.Errorf("Expected %d after %d calls, but got %d after %d", tc.wantStatus, tc.wantCalls, resp.StatusCode, calls.Load())
+			}
+		})
+	}
+}
+
+func TestResilientTransport_RetryBudgetExhausted(t *testing.T) {
+	// Arrange
+	catalog, calls := flakyCatalog(t, 100, http.StatusServiceUnavailable)
+	transport := newTestTransport()
+	transport.Retries.MinRetries = 1
+	transport.Retries.Ratio = 0
+
+	// Act
+	for i := 0; i < 3; i++ {
+		roundTrip(t, transport, http.MethodGet, catalog.URL)
+	}
+
+	// Assert
+	if got := calls.Load(); got != 4 {
+		t.Errorf("Expected a single retry across 3 requests (4 calls), but got %d calls", got)
+	}
+}
+
+func TestResilientTransport_BreakerIsPerUpstream(t *testing.T) {
+	// Arrange
+	failing, failingCalls := flakyCatalog(t, 100, http.StatusServiceUnavailable)
+	healthy, _ := flakyCatalog(t, 0, 0)
+	broken, other := newTestTransport(), newTestTransport()
+	broken.Attempts = 1
+	broken.Breaker.MinRequests = 2
+	roundTrip(t, broken, http.MethodGet, failing.URL)
+	roundTrip(t, broken, http.MethodGet, failing.URL)
+
+	// Act
+	_, rejected := roundTrip(t, broken, http.MethodGet, failing.URL)
+	resp, err := roundTrip(t, other, http.MethodGet, healthy.URL)
+
+	// Assert
+	if !errors.Is(rejected, breaker.ErrOpen) {
+		t.Fatalf("Expected error '%v', but got '%v'", breaker.ErrOpen, rejected)
+	}
+	if failingCalls.Load() != 2 {
+		t.Errorf("Expected the open breaker to spare the upstream, but it saw %d calls", failingCalls.Load())
+	}
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected the other upstream to answer 200, but got %v (%v)", resp, err)
+	}
+}
+
+func TestResilientTransport_AttemptTimeout(t *testing.T) {
+	// Arrange
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	t.Cleanup(slow.Close)
+	transport := newTestTransport()
+	transport.Attempts = 1
+	transport.Budget.Ceiling = 20 * time.Millisecond
+
+	// Act
+	start := time.Now()
+	_, err := roundTrip(t, transport, http.MethodGet, slow.URL)
+
+	// Assert
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected error '%v', but got '%v'", context.DeadlineExceeded, err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Expected the attempt to stop at its timeout, but it took %s", elapsed)
+	}
+}
+
+func TestUserClient_GetUser_RespectsRetryBudget(t *testing.T) {
+	// Arrange
+	fake := &fakeUsers{failures: map[string][]codes.Code{"GetUser": {codes.Unavailable, codes.Unavailable, codes.Unavailable}}}
+	client := newFakeClient(t, fake)
+	client.Retries.MinRetries = 0
+	client.Retries.Ratio = 0
+
+	// Act
+	_, err := client.GetUser(context.Background(), alice.Email)
+
+	// Assert
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("Expected Unavailable, but got: %v", err)
+	}
+	if got := fake.callCount("GetUser"); got != 1 {
+		t.Errorf("Expected no retries without budget, but got %d attempts", got)
+	}
+}
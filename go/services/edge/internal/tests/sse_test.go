@@ -0,0 +1,163 @@
+package tests
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"clean-code-cookbook/go/services/edge/internal/adapter/sse"
+	"clean-code-cookbook/go/services/edge/internal/adapter/ws"
+	pb "github.com/clean-code-coockbook/proto/gen/go/users/v1"
+)
+
+func newBrokerServer(t *testing.T, opts sse.Options) (*sse.Broker, string) {
+	t.Helper()
+	broker := sse.NewBroker(opts, log.New(io.Discard, "", 0))
+	server := httptest.NewServer(broker.Handler())
+	t.Cleanup(func() {
+		broker.Close()
+		server.Close()
+	})
+	return broker, server.URL
+}
+
+// openStream starts a streaming GET with accept and returns its body.
+func openStream(t *testing.T, url, accept string) *http.Response {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	req.Header.Set("Accept", accept)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestBroker_StreamsServerSentEvents(t *testing.T) {
+	// Arrange
+	broker, url := newBrokerServer(t, sse.DefaultOptions)
+	resp := openStream(t, url, "text/event-stream")
+	waitForClients(t, broker, 1)
+
+	// Act
+	broker.Broadcast(ws.Event{ID: "evt-1", Type: "user_registered", User: &ws.User{Username: "alice"}})
+
+	// Assert
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Expected text/event-stream, but got %q", ct)
+	}
+	lines := bufio.NewReader(resp.Body)
+	var frame []string
+	for len(frame) < 3 {
+		line, err := lines.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+		frame = append(frame, strings.TrimSuffix(line, "\n"))
+	}
+	if frame[0] != "id: evt-1" || frame[1] != "event: user_registered" || !strings.Contains(frame[2], `"username":"alice"`) {
+		t.Errorf("Expected alice's event, but got %q", frame)
+	}
+}
+
+func TestBroker_StreamsNDJSON(t *testing.T) {
+	// Arrange
+	broker, url := newBrokerServer(t, sse.DefaultOptions)
+	resp := openStream(t, url, "application/x-ndjson")
+	waitForClients(t, broker, 1)
+
+	// Act
+	broker.Broadcast(ws.Event{ID: "evt-1", Type: "user_registered"})
+	broker.Broadcast(ws.Event{ID: "evt-2", Type: "user_updated"})
+
+	// Assert
+	dec := json.NewDecoder(resp.Body)
+	for _, want := range []string{"evt-1", "evt-2"} {
+		var got ws.Event
+		if err := dec.Decode(&got); err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+		if got.ID != want {
+			t.Errorf("Expected %s, but got %s", want, got.ID)
+		}
+	}
+}
+
+// floodStalledClient connects a client that never reads and broadcasts
+// large events at it until its one-event buffer must have overflowed.
+func floodStalledClient(t *testing.T, policy sse.Policy) *sse.Broker {
+	t.Helper()
+	opts := sse.DefaultOptions
+	opts.Buffer, opts.Policy = 1, policy
+	broker, url := newBrokerServer(t, opts)
+	openStream(t, url, "text/event-stream")
+	waitForClients(t, broker, 1)
+
+	big := strings.Repeat("x", 1<<20)
+	for i := 0; i < 64 && broker.Clients() > 0; i++ {
+		broker.Broadcast(ws.Event{ID: "evt", Type: big})
+	}
+	return broker
+}
+
+func TestBroker_DisconnectsSlowConsumer(t *testing.T) {
+	// Act
+	broker := floodStalledClient(t, sse.Disconnect)
+
+	// Assert
+	waitForClients(t, broker, 0)
+	if stats := broker.Stats(); stats.Disconnected != 1 {
+		t.Errorf("Expected 1 disconnected client, but got %+v", stats)
+	}
+}
+
+func TestBroker_DropsForSlowConsumer(t *testing.T) {
+	// Act
+	broker := floodStalledClient(t, sse.Drop)
+
+	// Assert
+	stats := broker.Stats()
+	if stats.Clients != 1 || stats.Dropped == 0 || stats.Lag != 1 {
+		t.Errorf("Expected the client kept with events dropped and a full buffer, but got %+v", stats)
+	}
+}
+
+func TestBroker_RelaysUpstreamEvents(t *testing.T) {
+	// Arrange
+	broker, url := newBrokerServer(t, sse.DefaultOptions)
+	resp := openStream(t, url, "application/x-ndjson")
+	waitForClients(t, broker, 1)
+	source := eventSourceFunc(func(ctx context.Context, handle func(*pb.UserEvent)) error {
+		handle(&pb.UserEvent{Id: "evt-1", Type: "user_registered", Payload: alice})
+		return nil
+	})
+	var handled int
+
+	// Act
+	err := broker.Relay(source).StreamEvents(context.Background(), func(*pb.UserEvent) { handled++ })
+
+	// Assert
+	if err != nil || handled != 1 {
+		t.Fatalf("Expected the event passed on, but got %d and %v", handled, err)
+	}
+	var got ws.Event
+	_ = json.NewDecoder(resp.Body).Decode(&got)
+	if got.ID != "evt-1" || got.User == nil || got.User.Email != alice.Email {
+		t.Errorf("Expected alice's event streamed, but got %+v", got)
+	}
+	deadline := time.Now().Add(time.Second)
+	for broker.Stats().Delivered != 1 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if delivered := broker.Stats().Delivered; delivered != 1 {
+		t.Errorf("Expected 1 delivered event, but got %d", delivered)
+	}
+}
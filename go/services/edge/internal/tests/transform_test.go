@@ -0,0 +1,145 @@
+package tests
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"clean-code-cookbook/go/services/edge/internal/adapter/gateway"
+)
+
+// upstreamUser answers like the users service would, echoing the request
+// body it got in X-Got-Body.
+var upstreamUser = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	w.Header().Set("X-Got-Body", string(body))
+	w.Header().Set("X-Got-Tenant", r.Header.Get("X-Tenant"))
+	w.Header().Set("X-Upstream-Node", "users-7")
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = io.WriteString(w, `{"user":{"id":"u-1","is_active":true,"internal_score":0.7},"related":[{"id":"u-2","is_active":false,"internal_score":0.1}]}`)
+})
+
+var publicUsers = gateway.Transform{
+	Rename:                map[string]string{"is_active": "active"},
+	Redact:                []string{"internal_score"},
+	SetRequestHeaders:     map[string]string{"X-Tenant": "public"},
+	RemoveResponseHeaders: []string{"X-Upstream-Node"},
+	SetResponseHeaders:    map[string]string{"X-Content-Type-Options": "nosniff"},
+}
+
+func TestTransform_ReshapesResponses(t *testing.T) {
+	// Arrange
+	handler := publicUsers.Middleware(upstreamUser)
+
+	// Act
+	rec := serve(handler, http.MethodGet, "/u-1", "", "")
+
+	// Assert
+	want := `{"related":[{"active":false,"id":"u-2"}],"user":{"active":true,"id":"u-1"}}` + "\n"
+	if rec.Body.String() != want {
+		t.Errorf("Expected %s, but got %s", want, rec.Body)
+	}
+	if got := rec.Header().Get("Content-Length"); got != strconv.Itoa(len(want)) {
+		t.Errorf("Expected Content-Length %d, but got %s", len(want), got)
+	}
+	if rec.Header().Get("X-Upstream-Node") != "" || rec.Header().Get("X-Content-Type-Options") != "nosniff" {
+		t.Errorf("Expected response headers rewritten, but got %v", rec.Header())
+	}
+	if got := rec.Header().Get("X-Got-Tenant"); got != "public" {
+		t.Errorf("Expected the upstream to get X-Tenant public, but got %q", got)
+	}
+}
+
+func TestTransform_RenamesRequestFieldsBack(t *testing.T) {
+	// Arrange
+	handler := publicUsers.Middleware(upstreamUser)
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"email":"alice@example.com","active":true}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+
+	// Act
+	handler.ServeHTTP(rec, req)
+
+	// Assert
+	var got map[string]any
+	if err := json.Unmarshal([]byte(rec.Header().Get("X-Got-Body")), &got); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if _, ok := got["is_active"]; !ok || got["active"] != nil {
+		t.Errorf("Expected the upstream to see is_active, but got %v", got)
+	}
+}
+
+func TestTransform_PassesOtherContentThrough(t *testing.T) {
+	// Arrange
+	handler := publicUsers.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Header().Set("X-Upstream-Node", "users-7")
+		_, _ = io.WriteString(w, "is_active internal_score")
+	}))
+
+	// Act
+	rec := serve(handler, http.MethodGet, "/", "", "")
+
+	// Assert
+	if rec.Body.String() != "is_active internal_score" || rec.Header().Get("X-Upstream-Node") != "" {
+		t.Errorf("Expected the text untouched and headers rewritten, but got %q, %v", rec.Body, rec.Header())
+	}
+}
+
+func TestTransform_RefusesJSONTooLargeToRedact(t *testing.T) {
+	// Arrange
+	handler := publicUsers.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"internal_score":"`+strings.Repeat("x", 2<<20)+`"}`)
+	}))
+
+	// Act
+	rec := serve(handler, http.MethodGet, "/", "", "")
+
+	// Assert
+	if rec.Code != http.StatusBadGateway || strings.Contains(rec.Body.String(), "internal_score") {
+		t.Errorf("Expected 502 without the field, but got %d", rec.Code)
+	}
+}
+
+func TestLoadTransforms(t *testing.T) {
+	routes := []gateway.Route{{Prefix: "/api/users", Handler: upstreamUser}}
+
+	t.Run("valid", func(t *testing.T) {
+		// Act
+		transforms, err := gateway.LoadTransforms(strings.NewReader(`{"/api/users":{"rename":{"is_active":"active"},"redact":["internal_score"]}}`))
+		if err == nil {
+			err = gateway.ApplyTransforms(routes, transforms)
+		}
+
+		// Assert
+		if err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+	})
+
+	t.Run("unknown rule", func(t *testing.T) {
+		// Act
+		_, err := gateway.LoadTransforms(strings.NewReader(`{"/api/users":{"redcat":["internal_score"]}}`))
+
+		// Assert
+		if err == nil {
+			t.Error("Expected an error, but got nil")
+		}
+	})
+
+	t.Run("unknown route", func(t *testing.T) {
+		// Act
+		err := gateway.ApplyTransforms(routes, map[string]gateway.Transform{"/api/uesrs": publicUsers})
+
+		// Assert
+		if err == nil {
+			t.Error("Expected an error, but got nil")
+		}
+	})
+}
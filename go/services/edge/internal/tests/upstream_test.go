@@ -0,0 +1,177 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"clean-code-cookbook/go/services/edge/internal/upstream"
+	pb "github.com/clean-code-coockbook/proto/gen/go/users/v1"
+)
+
+func newRegistry(t *testing.T, config string) *upstream.Registry {
+	t.Helper()
+	configs, err := upstream.Load(strings.NewReader(config))
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	registry, err := upstream.NewRegistry(configs, 5*time.Second)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	t.Cleanup(func() { registry.Close() })
+	return registry
+}
+
+func TestRegistry_HandsOutConfiguredClients(t *testing.T) {
+	// Arrange
+	fake := &fakeUsers{users: map[string]*pb.User{alice.Email: alice}}
+	addr := listenFakeUsers(t, fake, nil)
+	catalog := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.URL.Path)
+	}))
+	defer catalog.Close()
+	registry := newRegistry(t, fmt.Sprintf(`{"upstreams":[
+		{"name":"users","scheme":"grpc","addresses":[%q],"timeout":"1s","retry":{"attempts":4,"backoff":"1ms","budget":0.5},"grpc":{"compression":"gzip","max_recv_bytes":1048576}},
+		{"name":"catalog","scheme":"http","addresses":[%q],"retry":{"attempts":1}}
+	]}`, addr, strings.TrimPrefix(catalog.URL, "http://")))
+
+	// Act
+	users, err := registry.UserClient("users")
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	again, _ := registry.UserClient("users")
+	_, getErr := users.GetUser(context.Background(), alice.Email)
+	transport, baseURL, err := registry.HTTPTransport("catalog")
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	resp, httpErr := roundTrip(t, transport, http.MethodGet, baseURL+"/products")
+
+	// Assert
+	if getErr != nil || httpErr != nil {
+		t.Fatalf("Expected both upstreams to answer, but got: %v, %v", getErr, httpErr)
+	}
+	resp.Body.Close()
+	if again != users {
+		t.Error("Expected the same client for the same upstream")
+	}
+	if users.Attempts != 4 || users.Backoff != time.Millisecond || users.Budget.Ceiling != time.Second || users.Retries.Ratio != 0.5 {
+		t.Errorf("Expected the users retry policy to be applied, but got %+v", users)
+	}
+	if transport.Attempts != 1 || transport.Budget.Ceiling != 2*time.Second {
+		t.Errorf("Expected 1 attempt with the default 2s timeout, but got %d and %s", transport.Attempts, transport.Budget.Ceiling)
+	}
+}
+
+func TestRegistry_UnknownOrMismatchedUpstream(t *testing.T) {
+	// Arrange
+	registry := newRegistry(t, `{"upstreams":[{"name":"catalog","scheme":"https","addresses":["catalog:8443"]}]}`)
+
+	// Act
+	_, unknownErr := registry.UserClient("users")
+	_, schemeErr := registry.UserClient("catalog")
+
+	// Assert
+	if !errors.Is(unknownErr, upstream.ErrUnknown) {
+		t.Errorf("Expected error '%v', but got '%v'", upstream.ErrUnknown, unknownErr)
+	}
+	if schemeErr == nil {
+		t.Error("Expected an error for a gRPC client of an HTTP upstream, but got nil")
+	}
+}
+
+func TestNewRegistry_Validates(t *testing.T) {
+	cases := map[string]string{
+		"no name":             `{"addresses":["a:1"],"scheme":"grpc"}`,
+		"unknown scheme":      `{"name":"users","scheme":"ftp","addresses":["a:1"]}`,
+		"no addresses":        `{"name":"users","scheme":"grpc"}`,
+		"two http backends":   `{"name":"catalog","scheme":"http","addresses":["a:1","b:1"]}`,
+		"timeout too long":    `{"name":"users","scheme":"grpc","addresses":["a:1"],"timeout":"5s"}`,
+		"budget over 1":       `{"name":"users","scheme":"grpc","addresses":["a:1"],"retry":{"budget":1.5}}`,
+		"mTLS over http":      `{"name":"catalog","scheme":"http","addresses":["a:1"],"tls":{"ca":"ca.pem"}}`,
+		"duplicate":           `{"name":"users","scheme":"grpc","addresses":["a:1"]},{"name":"users","scheme":"grpc","addresses":["b:1"]}`,
+		"web over http":       `{"name":"catalog","scheme":"http","addresses":["a:1"],"web_services":["catalog.v1.Catalog"]}`,
+		"grpc over http":      `{"name":"catalog","scheme":"http","addresses":["a:1"],"grpc":{"compression":"gzip"}}`,
+		"keepalive too often": `{"name":"users","scheme":"grpc","addresses":["a:1"],"grpc":{"keepalive_time":"1s"}}`,
+		"unknown compression": `{"name":"users","scheme":"grpc","addresses":["a:1"],"grpc":{"compression":"brotli"}}`,
+		"web service twice":   `{"name":"users","scheme":"grpc","addresses":["a:1"],"web_services":["users.v1.UserService"]},{"name":"users-b","scheme":"grpc","addresses":["b:1"],"web_services":["users.v1.UserService"]}`,
+	}
+	for name, config := range cases {
+		t.Run(name, func(t *testing.T) {
+			// Arrange
+			configs, err := upstream.Load(strings.NewReader(`{"upstreams":[` + config + `]}`))
+			if err != nil {
+				t.Fatalf("Expected no error, but got: %v", err)
+			}
+
+			// Act
+			_, err = upstream.NewRegistry(configs, 5*time.Second)
+
+			// Assert
+			if err == nil {
+				t.Error("Expected an error, but got nil")
+			}
+		})
+	}
+
+	t.Run("unknown field", func(t *testing.T) {
+		// Act
+		_, err := upstream.Load(strings.NewReader(`{"upstreams":[{"name":"users","adresses":["a:1"]}]}`))
+
+		// Assert
+		if err == nil {
+			t.Error("Expected an error, but got nil")
+		}
+	})
+}
+
+func TestFromEnv(t *testing.T) {
+	t.Run("legacy variables", func(t *testing.T) {
+		// Arrange
+		t.Setenv("USERS_GRPC_ADDR", "users-1:50051, users-2:50051")
+		t.Setenv("USERS_ATTEMPT_TIMEOUT", "1s")
+		t.Setenv("USERS_GRPC_KEEPALIVE_TIME", "20s")
+		t.Setenv("USERS_GRPC_MAX_MSG_BYTES", "8388608")
+		t.Setenv("USERS_GRPC_COMPRESSION", "gzip")
+		t.Setenv("CATALOG_URL", "https://catalog:8443/")
+		t.Setenv("CATALOG_ATTEMPTS", "1")
+
+		// Act
+		configs, err := upstream.FromEnv()
+
+		// Assert
+		if err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+		if len(configs) != 2 || len(configs[0].Addresses) != 2 || configs[0].Timeout != upstream.Duration(time.Second) {
+			t.Fatalf("Expected users with two addresses and a 1s timeout, but got %+v", configs)
+		}
+		want := upstream.GRPC{KeepaliveTime: upstream.Duration(20 * time.Second), MaxRecvBytes: 8 << 20, MaxSendBytes: 8 << 20, Compression: "gzip"}
+		if configs[0].GRPC != want {
+			t.Errorf("Expected gRPC tuning %+v, but got %+v", want, configs[0].GRPC)
+		}
+		if c := configs[1]; c.Scheme != "https" || c.Addresses[0] != "catalog:8443" || c.Retry.Attempts != 1 {
+			t.Errorf("Expected the catalog at https://catalog:8443 with 1 attempt, but got %+v", c)
+		}
+	})
+
+	t.Run("no users address", func(t *testing.T) {
+		// Arrange
+		t.Setenv("USERS_GRPC_ADDR", "")
+
+		// Act
+		_, err := upstream.FromEnv()
+
+		// Assert
+		if err == nil {
+			t.Error("Expected an error, but got nil")
+		}
+	})
+}
@@ -0,0 +1,284 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"clean-code-cookbook/go/pkg/breaker"
+	adapter "clean-code-cookbook/go/services/edge/internal/adapter/grpc"
+	pb "github.com/clean-code-coockbook/proto/gen/go/users/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func newFakeClient(t *testing.T, fake *fakeUsers) *adapter.UserClient {
+	t.Helper()
+	client := adapter.NewUserClient(dialFakeUsers(t, fake))
+	client.Budget.Ceiling = time.Second
+	client.Backoff = time.Millisecond
+	return client
+}
+
+var alice = &pb.User{Id: "u-1", Email: "alice@example.com", Username: "alice", IsActive: true}
+
+func TestUserClient_GetUser_RetriesUnavailable(t *testing.T) {
+	// Arrange
+	fake := &fakeUsers{
+		users:    map[string]*pb.User{alice.Email: alice},
+		failures: map[string][]codes.Code{"GetUser": {codes.Unavailable, codes.Unavailable}},
+	}
+	client := newFakeClient(t, fake)
+
+	// Act
+	resp, err := client.GetUser(context.Background(), alice.Email)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if resp.GetUser().GetUsername() != "alice" {
+		t.Errorf("Expected alice, but got %v", resp.GetUser())
+	}
+	if got := fake.callCount("GetUser"); got != 3 {
+		t.Errorf("Expected 3 attempts, but got %d", got)
+	}
+}
+
+func TestUserClient_GetUser_GivesUpAfterAttempts(t *testing.T) {
+	// Arrange
+	fake := &fakeUsers{failures: map[string][]codes.Code{"GetUser": {codes.Unavailable, codes.Unavailable, codes.Unavailable, codes.Unavailable}}}
+	client := newFakeClient(t, fake)
+
+	// Act
+	_, err := client.GetUser(context.Background(), alice.Email)
+
+	// Assert
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("Expected Unavailable, but got: %v", err)
+	}
+	if got := fake.callCount("GetUser"); got != client.Attempts {
+		t.Errorf("Expected %d attempts, but got %d", client.Attempts, got)
+	}
+}
+
+func TestUserClient_GetUser_DoesNotRetryNotFound(t *testing.T) {
+	// Arrange
+	fake := &fakeUsers{}
+	client := newFakeClient(t, fake)
+
+	// Act
+	_, err := client.GetUser(context.Background(), "nobody@example.com")
+
+	// Assert
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("Expected NotFound, but got: %v", err)
+	}
+	if got := fake.callCount("GetUser"); got != 1 {
+		t.Errorf("Expected 1 attempt, but got %d", got)
+	}
+}
+
+func TestUserClient_RegisterUser_IsNeverRetried(t *testing.T) {
+	// Arrange
+	fake := &fakeUsers{failures: map[string][]codes.Code{"RegisterUser": {codes.Unavailable}}}
+	client := newFakeClient(t, fake)
+
+	// Act
+	_, err := client.RegisterUser(context.Background(), alice.Email, "alice")
+
+	// Assert
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("Expected Unavailable, but got: %v", err)
+	}
+	if got := fake.callCount("RegisterUser"); got != 1 {
+		t.Errorf("Expected 1 attempt, but got %d", got)
+	}
+}
+
+func TestUserClient_RegisterUser_HonoursTimeout(t *testing.T) {
+	// Arrange
+	fake := &fakeUsers{latency: time.Second}
+	client := newFakeClient(t, fake)
+	client.Budget.Ceiling = 20 * time.Millisecond
+
+	// Act
+	start := time.Now()
+	_, err := client.RegisterUser(context.Background(), alice.Email, "alice")
+
+	// Assert
+	if status.Code(err) != codes.DeadlineExceeded {
+		t.Fatalf("Expected DeadlineExceeded, but got: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Expected the call to stop at the timeout, but it took %s", elapsed)
+	}
+}
+
+func TestUserClient_BreakerRejectsWhileUpstreamFails(t *testing.T) {
+	// Arrange
+	fake := &fakeUsers{failures: map[string][]codes.Code{"RegisterUser": {codes.Unavailable, codes.Unavailable}}}
+	client := newFakeClient(t, fake)
+	client.Breaker.MinRequests = 2
+	ctx := context.Background()
+	client.RegisterUser(ctx, alice.Email, "alice")
+	client.RegisterUser(ctx, alice.Email, "alice")
+
+	// Act
+	_, err := client.RegisterUser(ctx, alice.Email, "alice")
+
+	// Assert
+	if !errors.Is(err, breaker.ErrOpen) {
+		t.Fatalf("Expected error '%v', but got '%v'", breaker.ErrOpen, err)
+	}
+	if got := fake.callCount("RegisterUser"); got != 2 {
+		t.Errorf("Expected the open breaker to spare the upstream, but it saw %d calls", got)
+	}
+}
+
+func TestUserClient_BreakerIgnoresNotFound(t *testing.T) {
+	// Arrange
+	client := newFakeClient(t, &fakeUsers{})
+	client.Breaker.MinRequests = 1
+
+	// Act
+	for i := 0; i < 3; i++ {
+		client.GetUser(context.Background(), "nobody@example.com")
+	}
+
+	// Assert
+	if state := client.Breaker.State(); state != breaker.Closed {
+		t.Errorf("Expected NotFound to keep the breaker closed, but got %s", state)
+	}
+}
+
+func TestUserClient_RegisterUser_TakesShareOfCallerDeadline(t *testing.T) {
+	// Arrange
+	fake := &fakeUsers{latency: time.Second}
+	client := newFakeClient(t, fake)
+	client.Budget.Share, client.Budget.Floor = 0.1, 0
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	// Act
+	start := time.Now()
+	_, err := client.RegisterUser(ctx, alice.Email, "alice")
+
+	// Assert
+	if status.Code(err) != codes.DeadlineExceeded {
+		t.Fatalf("Expected DeadlineExceeded, but got: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 250*time.Millisecond {
+		t.Errorf("Expected the call to stop at its share of the deadline, but it took %s", elapsed)
+	}
+	if ctx.Err() != nil {
+		t.Error("Expected the caller's deadline to still have time left")
+	}
+}
+
+func TestUserClient_SubscribeEvents_CleanEnd(t *testing.T) {
+	// Arrange
+	fake := &fakeUsers{events: []*pb.UserEvent{{Id: "e-1", Type: "registered"}, {Id: "e-2", Type: "deactivated"}}}
+	client := newFakeClient(t, fake)
+	var got []string
+
+	// Act
+	err := client.SubscribeEvents(context.Background(), func(e *pb.UserEvent) { got = append(got, e.GetId()) })
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if len(got) != 2 || got[0] != "e-1" || got[1] != "e-2" {
+		t.Errorf("Expected [e-1 e-2], but got %v", got)
+	}
+}
+
+func TestUserClient_SubscribeEvents_BrokenStream(t *testing.T) {
+	// Arrange
+	fake := &fakeUsers{
+		events:    []*pb.UserEvent{{Id: "e-1"}},
+		streamEnd: status.Error(codes.Unavailable, "upstream restarting"),
+	}
+	client := newFakeClient(t, fake)
+	var got int
+
+	// Act
+	err := client.SubscribeEvents(context.Background(), func(*pb.UserEvent) { got++ })
+
+	// Assert
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("Expected Unavailable, but got: %v", err)
+	}
+	if got != 1 {
+		t.Errorf("Expected the event before the break to be delivered, but got %d", got)
+	}
+}
+
+func TestUserClient_SubscribeEvents_StopsOnCancel(t *testing.T) {
+	// Arrange
+	fake := &fakeUsers{events: []*pb.UserEvent{{Id: "e-1"}}, holdStream: true}
+	client := newFakeClient(t, fake)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// Act
+	err := client.SubscribeEvents(ctx, func(*pb.UserEvent) { cancel() })
+
+	// Assert
+	if status.Code(err) != codes.Canceled {
+		t.Errorf("Expected Canceled, but got: %v", err)
+	}
+}
+
+func TestUserClient_StreamEvents_ResumesAfterBreaks(t *testing.T) {
+	// Arrange
+	fake := &fakeUsers{
+		events:     []*pb.UserEvent{{Id: "e-1"}, {Id: "e-2"}, {Id: "e-3"}},
+		perStream:  2,
+		streamEnds: []error{status.Error(codes.Unavailable, "upstream restarting")},
+	}
+	client := newFakeClient(t, fake)
+	client.StreamBackoff = time.Millisecond
+	var reconnects int
+	client.OnStreamError = func(error, time.Duration) { reconnects++ }
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var got []string
+
+	// Act
+	err := client.StreamEvents(ctx, func(e *pb.UserEvent) {
+		if got = append(got, e.GetId()); len(got) == 3 {
+			cancel()
+		}
+	})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected nil once cancelled, but got: %v", err)
+	}
+	if strings.Join(got, ",") != "e-1,e-2,e-3" {
+		t.Errorf("Expected each event once, in order, but got %v", got)
+	}
+	if tokens := fake.seenResumeTokens(); len(tokens) != 2 || tokens[0] != "" || tokens[1] != "e-2" {
+		t.Errorf("Expected to resume after e-2, but the streams asked for %q", tokens)
+	}
+	if reconnects != 1 {
+		t.Errorf("Expected 1 reconnect, but got %d", reconnects)
+	}
+}
+
+func TestUserClient_StreamEvents_GivesUpWhenRefused(t *testing.T) {
+	// Arrange
+	fake := &fakeUsers{failures: map[string][]codes.Code{"StreamUserEvents": {codes.PermissionDenied}}}
+	client := newFakeClient(t, fake)
+
+	// Act
+	err := client.StreamEvents(context.Background(), func(*pb.UserEvent) {})
+
+	// Assert
+	if status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Expected PermissionDenied, but got: %v", err)
+	}
+}
@@ -0,0 +1,105 @@
+package tests
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"clean-code-cookbook/go/services/edge/internal/adapter/ws"
+	"github.com/gorilla/websocket"
+)
+
+func newHubServer(t *testing.T, opts ws.Options) (*ws.Hub, string) {
+	t.Helper()
+	hub := ws.NewHub(opts, log.New(io.Discard, "", 0))
+	server := httptest.NewServer(hub.Handler(ws.StaticToken("secret"), nil))
+	t.Cleanup(func() {
+		hub.Close()
+		server.Close()
+	})
+	return hub, "ws" + strings.TrimPrefix(server.URL, "http")
+}
+
+// waitForClients waits until a ws.Hub or sse.Broker has want clients.
+func waitForClients(t *testing.T, hub interface{ Clients() int }, want int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for hub.Clients() != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %d clients, but got %d", want, hub.Clients())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestHub_RejectsHandshakeWithoutToken(t *testing.T) {
+	// Arrange
+	_, url := newHubServer(t, ws.DefaultOptions)
+
+	// Act
+	_, resp, err := websocket.DefaultDialer.Dial(url, nil)
+
+	// Assert
+	if err == nil {
+		t.Fatal("Expected the handshake to fail, but it succeeded")
+	}
+	if resp == nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected status 401, but got %v", resp)
+	}
+}
+
+func TestHub_BroadcastsToEverySocket(t *testing.T) {
+	// Arrange
+	hub, url := newHubServer(t, ws.DefaultOptions)
+	var conns []*websocket.Conn
+	for i := 0; i < 3; i++ {
+		conn, _, err := websocket.DefaultDialer.Dial(url+"?access_token=secret", nil)
+		if err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+		defer conn.Close()
+		conns = append(conns, conn)
+	}
+	waitForClients(t, hub, 3)
+
+	// Act
+	hub.Broadcast(ws.Event{ID: "1", Type: "user_registered", User: &ws.User{Username: "alice"}})
+
+	// Assert
+	for _, conn := range conns {
+		var got ws.Event
+		_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		if err := conn.ReadJSON(&got); err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+		if got.User == nil || got.User.Username != "alice" {
+			t.Errorf("Expected alice's event, but got %+v", got)
+		}
+	}
+}
+
+func TestHub_EvictsSlowConsumer(t *testing.T) {
+	// Arrange: a one-frame buffer and a client that never reads
+	opts := ws.DefaultOptions
+	opts.SendBuffer = 1
+	hub, url := newHubServer(t, opts)
+	conn, _, err := websocket.DefaultDialer.Dial(url+"?access_token=secret", nil)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	defer conn.Close()
+	waitForClients(t, hub, 1)
+
+	// Act: flood faster than the socket can drain
+	big := strings.Repeat("x", 1<<20)
+	for i := 0; i < 64 && hub.Clients() > 0; i++ {
+		hub.Broadcast(ws.Event{ID: big})
+	}
+
+	// Assert
+	waitForClients(t, hub, 0)
+}
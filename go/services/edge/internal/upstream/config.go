@@ -0,0 +1,305 @@
+// Package upstream is the edge's registry of the services it calls. Each
+// upstream is configured once, by name, with where it runs, how to reach
+// it securely and how patiently to call it; the Registry then hands out
+// clients built from that, so no address or policy is wired in code.
+package upstream
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"clean-code-cookbook/go/pkg/tlsconfig"
+	adapter "clean-code-cookbook/go/services/edge/internal/adapter/grpc"
+)
+
+// Schemes an upstream may speak.
+const (
+	SchemeGRPC  = "grpc"
+	SchemeHTTP  = "http"
+	SchemeHTTPS = "https"
+)
+
+// Config describes one upstream.
+type Config struct {
+	Name   string `json:"name"`
+	Scheme string `json:"scheme"`
+	// Addresses are host:port backends, or a single resolver target such
+	// as "dns:///users:50051", for gRPC; an HTTP upstream has exactly one,
+	// host[:port] with an optional path prefix.
+	Addresses []string `json:"addresses"`
+	// Subset and HealthService tune gRPC balancing; see grpc.Upstream.
+	Subset        int    `json:"subset,omitempty"`
+	HealthService string `json:"health_service,omitempty"`
+	TLS           TLS    `json:"tls,omitempty"`
+	// WebServices are the fully qualified gRPC services, such as
+	// "users.v1.UserService", that browsers may call over gRPC-Web.
+	WebServices []string `json:"web_services,omitempty"`
+	// Timeout bounds every attempt (2s if unset). It has to leave room
+	// inside the route's timeout for a retry.
+	Timeout Duration `json:"timeout,omitempty"`
+	Retry   Retry    `json:"retry,omitempty"`
+	// GRPC tunes the connection to a gRPC upstream.
+	GRPC GRPC `json:"grpc,omitempty"`
+}
+
+// GRPC is how a gRPC upstream's connection is kept alive and what its
+// calls may carry. Zero values keep the defaults: a keepalive ping every
+// 30s that may go 10s unanswered, gRPC's message size limits, and no
+// compression.
+type GRPC struct {
+	KeepaliveTime    Duration `json:"keepalive_time,omitempty"`
+	KeepaliveTimeout Duration `json:"keepalive_timeout,omitempty"`
+	MaxRecvBytes     int      `json:"max_recv_bytes,omitempty"`
+	MaxSendBytes     int      `json:"max_send_bytes,omitempty"`
+	// Compression is "gzip" or empty for none.
+	Compression string `json:"compression,omitempty"`
+}
+
+func (g GRPC) tuning() adapter.Tuning {
+	return adapter.Tuning{
+		KeepaliveTime:    time.Duration(g.KeepaliveTime),
+		KeepaliveTimeout: time.Duration(g.KeepaliveTimeout),
+		MaxRecvBytes:     g.MaxRecvBytes,
+		MaxSendBytes:     g.MaxSendBytes,
+		Compression:      g.Compression,
+	}
+}
+
+// TLS points at the mTLS files; leaving them all empty means plaintext.
+type TLS struct {
+	Cert string `json:"cert,omitempty"`
+	Key  string `json:"key,omitempty"`
+	CA   string `json:"ca,omitempty"`
+	// ServerName overrides the name checked against the server
+	// certificate, for resolver targets and raw IPs.
+	ServerName string `json:"server_name,omitempty"`
+	// Peers restricts which server identities (SANs) are accepted.
+	Peers []string `json:"peers,omitempty"`
+}
+
+func (t TLS) files() tlsconfig.Files {
+	return tlsconfig.Files{CertFile: t.Cert, KeyFile: t.Key, CAFile: t.CA, PeerNames: t.Peers}
+}
+
+// Retry is how an upstream's idempotent calls are retried. Zero values
+// keep the client's defaults.
+type Retry struct {
+	// Attempts counts the first call too.
+	Attempts int      `json:"attempts,omitempty"`
+	Backoff  Duration `json:"backoff,omitempty"`
+	// Budget is the share (0..1) of recent requests that may be retried.
+	Budget *float64 `json:"budget,omitempty"`
+}
+
+// Duration is a time.Duration written as a string such as "250ms".
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(raw []byte) error {
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return fmt.Errorf("duration must be a string such as \"2s\": %w", err)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// Load reads {"upstreams": [...]}.
+func Load(r io.Reader) ([]Config, error) {
+	var file struct {
+		Upstreams []Config `json:"upstreams"`
+	}
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&file); err != nil {
+		return nil, fmt.Errorf("upstream: decode: %w", err)
+	}
+	return file.Upstreams, nil
+}
+
+// LoadFile reads Load's format from path.
+func LoadFile(path string) ([]Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("upstream: %w", err)
+	}
+	defer f.Close()
+	return Load(f)
+}
+
+// FromEnv builds the "users" and, when CATALOG_URL is set, "catalog"
+// upstreams from the variables the edge read before it had a registry:
+// USERS_GRPC_ADDR (required), USERS_GRPC_SUBSET, USERS_GRPC_HEALTH_SERVICE,
+// USERS_GRPC_TLS_{CERT,KEY,CA,SERVER_NAME,PEERS}, USERS_GRPC_WEB_SERVICES,
+// USERS_GRPC_{KEEPALIVE_TIME,KEEPALIVE_TIMEOUT,MAX_MSG_BYTES,COMPRESSION},
+// CATALOG_TLS_{CERT,KEY,CA,PEERS}, and
+// {USERS,CATALOG}_{ATTEMPTS,ATTEMPT_TIMEOUT,RETRY_BUDGET}.
+func FromEnv() ([]Config, error) {
+	addr := os.Getenv("USERS_GRPC_ADDR")
+	if addr == "" {
+		return nil, errors.New("upstream: set EDGE_UPSTREAMS_FILE or USERS_GRPC_ADDR")
+	}
+	users := Config{
+		Name:          "users",
+		Scheme:        SchemeGRPC,
+		Addresses:     adapter.ParseTargets(addr),
+		HealthService: os.Getenv("USERS_GRPC_HEALTH_SERVICE"),
+		WebServices:   adapter.ParseTargets(os.Getenv("USERS_GRPC_WEB_SERVICES")),
+		TLS: TLS{
+			Cert:       os.Getenv("USERS_GRPC_TLS_CERT"),
+			Key:        os.Getenv("USERS_GRPC_TLS_KEY"),
+			CA:         os.Getenv("USERS_GRPC_TLS_CA"),
+			ServerName: os.Getenv("USERS_GRPC_TLS_SERVER_NAME"),
+			Peers:      adapter.ParseTargets(os.Getenv("USERS_GRPC_TLS_PEERS")),
+		},
+	}
+	var errs []error
+	if v := os.Getenv("USERS_GRPC_SUBSET"); v != "" {
+		var err error
+		if users.Subset, err = strconv.Atoi(v); err != nil {
+			errs = append(errs, fmt.Errorf("USERS_GRPC_SUBSET: %w", err))
+		}
+	}
+	errs = append(errs, retryFromEnv("USERS", &users), grpcFromEnv("USERS_GRPC", &users.GRPC))
+	configs := []Config{users}
+
+	if raw := os.Getenv("CATALOG_URL"); raw != "" {
+		scheme, address, ok := strings.Cut(strings.TrimSuffix(raw, "/"), "://")
+		if !ok {
+			errs = append(errs, errors.New("CATALOG_URL needs a scheme and host"))
+		}
+		catalog := Config{
+			Name:      "catalog",
+			Scheme:    scheme,
+			Addresses: []string{address},
+			TLS: TLS{
+				Cert:  os.Getenv("CATALOG_TLS_CERT"),
+				Key:   os.Getenv("CATALOG_TLS_KEY"),
+				CA:    os.Getenv("CATALOG_TLS_CA"),
+				Peers: adapter.ParseTargets(os.Getenv("CATALOG_TLS_PEERS")),
+			},
+		}
+		errs = append(errs, retryFromEnv("CATALOG", &catalog))
+		configs = append(configs, catalog)
+	}
+	return configs, errors.Join(errs...)
+}
+
+func retryFromEnv(prefix string, c *Config) error {
+	var errs []error
+	if v := os.Getenv(prefix + "_ATTEMPTS"); v != "" {
+		n, err := strconv.Atoi(v)
+		errs = append(errs, wrapEnv(prefix+"_ATTEMPTS", err))
+		c.Retry.Attempts = n
+	}
+	if v := os.Getenv(prefix + "_ATTEMPT_TIMEOUT"); v != "" {
+		d, err := time.ParseDuration(v)
+		errs = append(errs, wrapEnv(prefix+"_ATTEMPT_TIMEOUT", err))
+		c.Timeout = Duration(d)
+	}
+	if v := os.Getenv(prefix + "_RETRY_BUDGET"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		errs = append(errs, wrapEnv(prefix+"_RETRY_BUDGET", err))
+		c.Retry.Budget = &f
+	}
+	return errors.Join(errs...)
+}
+
+// grpcFromEnv reads prefix_MAX_MSG_BYTES, which caps messages both ways,
+// and the keepalive and compression settings.
+func grpcFromEnv(prefix string, g *GRPC) error {
+	var errs []error
+	for name, d := range map[string]*Duration{"_KEEPALIVE_TIME": &g.KeepaliveTime, "_KEEPALIVE_TIMEOUT": &g.KeepaliveTimeout} {
+		if v := os.Getenv(prefix + name); v != "" {
+			parsed, err := time.ParseDuration(v)
+			errs = append(errs, wrapEnv(prefix+name, err))
+			*d = Duration(parsed)
+		}
+	}
+	if v := os.Getenv(prefix + "_MAX_MSG_BYTES"); v != "" {
+		n, err := strconv.Atoi(v)
+		errs = append(errs, wrapEnv(prefix+"_MAX_MSG_BYTES", err))
+		g.MaxRecvBytes, g.MaxSendBytes = n, n
+	}
+	g.Compression = os.Getenv(prefix + "_COMPRESSION")
+	return errors.Join(errs...)
+}
+
+func wrapEnv(name string, err error) error {
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+// validate checks c on its own and against the route timeout its calls
+// run under.
+func (c Config) validate(route time.Duration) error {
+	var errs []error
+	if c.Name == "" {
+		return errors.New("upstream: every upstream needs a name")
+	}
+	if !slices.Contains([]string{SchemeGRPC, SchemeHTTP, SchemeHTTPS}, c.Scheme) {
+		errs = append(errs, fmt.Errorf("scheme must be grpc, http or https, got %q", c.Scheme))
+	}
+	switch {
+	case len(c.Addresses) == 0:
+		errs = append(errs, errors.New("needs at least one address"))
+	case c.Scheme != SchemeGRPC && len(c.Addresses) != 1:
+		errs = append(errs, errors.New("an HTTP upstream takes exactly one address"))
+	}
+	if t := time.Duration(c.Timeout); t < 0 || (route > 0 && t >= route) {
+		errs = append(errs, fmt.Errorf("timeout (%s) must be shorter than the route timeout (%s)", t, route))
+	}
+	if c.Retry.Attempts < 0 || c.Retry.Backoff < 0 {
+		errs = append(errs, errors.New("retry attempts and backoff cannot be negative"))
+	}
+	if b := c.Retry.Budget; b != nil && (*b < 0 || *b > 1) {
+		errs = append(errs, errors.New("retry budget must be between 0 and 1"))
+	}
+	if len(c.WebServices) > 0 && c.Scheme != SchemeGRPC {
+		errs = append(errs, errors.New("web_services needs scheme grpc"))
+	}
+	for _, service := range c.WebServices {
+		if service == "" || strings.ContainsAny(service, "/ ") {
+			errs = append(errs, fmt.Errorf("web service %q is not a gRPC service name", service))
+		}
+	}
+	if c.GRPC != (GRPC{}) && c.Scheme != SchemeGRPC {
+		errs = append(errs, errors.New("grpc settings need scheme grpc"))
+	}
+	if g := c.GRPC; g.KeepaliveTime < 0 || g.KeepaliveTimeout < 0 || g.MaxRecvBytes < 0 || g.MaxSendBytes < 0 {
+		errs = append(errs, errors.New("grpc keepalive and message sizes cannot be negative"))
+	}
+	// gRPC pings no more often than every 10s whatever it is told.
+	if t := time.Duration(c.GRPC.KeepaliveTime); t > 0 && t < 10*time.Second {
+		errs = append(errs, fmt.Errorf("grpc keepalive_time must be at least 10s, got %s", t))
+	}
+	if comp := c.GRPC.Compression; comp != "" && comp != "gzip" {
+		errs = append(errs, fmt.Errorf("grpc compression must be gzip or empty, got %q", comp))
+	}
+	if c.TLS.Enabled() && c.Scheme == SchemeHTTP {
+		errs = append(errs, errors.New("mTLS needs scheme https"))
+	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("upstream %s: %w", c.Name, err)
+	}
+	return nil
+}
+
+// Enabled reports whether any mTLS file is configured.
+func (t TLS) Enabled() bool { return t.files().Enabled() }
@@ -0,0 +1,239 @@
+package upstream
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"clean-code-cookbook/go/pkg/tlsconfig"
+	"clean-code-cookbook/go/services/edge/internal/adapter/gateway"
+	adapter "clean-code-cookbook/go/services/edge/internal/adapter/grpc"
+	"clean-code-cookbook/go/services/edge/internal/identity"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// ErrUnknown is returned for an upstream the registry has no config for.
+var ErrUnknown = errors.New("upstream: not configured")
+
+// defaultTimeout bounds an attempt when an upstream sets no timeout.
+const defaultTimeout = 2 * time.Second
+
+// Registry hands out clients for configured upstreams by name. Each is
+// built on first use and then shared, so an upstream has one breaker and
+// one retry budget however many handlers call it; Close releases the gRPC
+// connections.
+type Registry struct {
+	// Signer, when set, passes the authenticated caller on to every
+	// upstream as an internal token addressed to the upstream's name.
+	// Set it before asking for clients.
+	Signer *identity.Signer
+
+	configs map[string]Config
+
+	mu         sync.Mutex
+	conns      map[string]*grpc.ClientConn
+	users      map[string]*adapter.UserClient
+	transports map[string]*gateway.ResilientTransport
+}
+
+// NewRegistry validates configs against the route timeout their calls run
+// under and returns a registry for them. An upstream without a timeout
+// gets 2s.
+func NewRegistry(configs []Config, route time.Duration) (*Registry, error) {
+	r := &Registry{
+		configs:    make(map[string]Config, len(configs)),
+		conns:      make(map[string]*grpc.ClientConn),
+		users:      make(map[string]*adapter.UserClient),
+		transports: make(map[string]*gateway.ResilientTransport),
+	}
+	var errs []error
+	for _, c := range configs {
+		if c.Timeout == 0 {
+			c.Timeout = Duration(defaultTimeout)
+		}
+		if err := c.validate(route); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if _, dup := r.configs[c.Name]; dup {
+			errs = append(errs, fmt.Errorf("upstream %s: configured twice", c.Name))
+		}
+		for _, service := range c.WebServices {
+			if other, dup := r.WebServices()[service]; dup {
+				errs = append(errs, fmt.Errorf("upstream %s: %s is already exposed by %s", c.Name, service, other))
+			}
+		}
+		r.configs[c.Name] = c
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Has reports whether name is configured.
+func (r *Registry) Has(name string) bool {
+	_, ok := r.configs[name]
+	return ok
+}
+
+// Names lists the configured upstreams, sorted.
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.configs))
+	for name := range r.configs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// UserClient returns the users-service client for the gRPC upstream name,
+// with its timeout and retry policy applied.
+func (r *Registry) UserClient(name string) (*adapter.UserClient, error) {
+	c, err := r.config(name, SchemeGRPC)
+	if err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if client, ok := r.users[name]; ok {
+		return client, nil
+	}
+	conn, err := r.conn(c)
+	if err != nil {
+		return nil, err
+	}
+	client := adapter.NewUserClient(conn)
+	client.Budget.Ceiling = time.Duration(c.Timeout)
+	if c.Retry.Attempts > 0 {
+		client.Attempts = c.Retry.Attempts
+	}
+	if c.Retry.Backoff > 0 {
+		client.Backoff = time.Duration(c.Retry.Backoff)
+	}
+	if c.Retry.Budget != nil {
+		client.Retries.Ratio = *c.Retry.Budget
+	}
+	r.users[name] = client
+	return client, nil
+}
+
+// Conn returns the connection to the gRPC upstream name, for calls that
+// bypass its typed client, such as gRPC-Web passthrough.
+func (r *Registry) Conn(name string) (*grpc.ClientConn, error) {
+	c, err := r.config(name, SchemeGRPC)
+	if err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.conn(c)
+}
+
+// WebServices maps each gRPC service exposed to browsers to the upstream
+// that serves it.
+func (r *Registry) WebServices() map[string]string {
+	services := make(map[string]string)
+	for name, c := range r.configs {
+		for _, service := range c.WebServices {
+			services[service] = name
+		}
+	}
+	return services
+}
+
+// HTTPTransport returns the guarded transport for the HTTP upstream name
+// and the base URL to send its requests to.
+func (r *Registry) HTTPTransport(name string) (*gateway.ResilientTransport, string, error) {
+	c, err := r.config(name, SchemeHTTP, SchemeHTTPS)
+	if err != nil {
+		return nil, "", err
+	}
+	baseURL := c.Scheme + "://" + c.Addresses[0]
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if transport, ok := r.transports[name]; ok {
+		return transport, baseURL, nil
+	}
+	var base http.RoundTripper = http.DefaultTransport
+	if c.TLS.Enabled() {
+		cfg, err := tlsconfig.Client(c.TLS.files(), c.TLS.ServerName)
+		if err != nil {
+			return nil, "", fmt.Errorf("upstream %s TLS: %w", name, err)
+		}
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = cfg
+		base = transport
+	}
+	transport := gateway.NewResilientTransport(name+"-http", gateway.PropagateIdentity(r.Signer, name, base))
+	transport.Budget.Ceiling = time.Duration(c.Timeout)
+	if c.Retry.Attempts > 0 {
+		transport.Attempts = c.Retry.Attempts
+	}
+	if c.Retry.Backoff > 0 {
+		transport.Backoff = time.Duration(c.Retry.Backoff)
+	}
+	if c.Retry.Budget != nil {
+		transport.Retries.Ratio = *c.Retry.Budget
+	}
+	r.transports[name] = transport
+	return transport, baseURL, nil
+}
+
+// Close closes every gRPC connection dialed; clients handed out stop
+// working.
+func (r *Registry) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var errs []error
+	for name, conn := range r.conns {
+		errs = append(errs, conn.Close())
+		delete(r.conns, name)
+		delete(r.users, name)
+	}
+	return errors.Join(errs...)
+}
+
+func (r *Registry) config(name string, schemes ...string) (Config, error) {
+	c, ok := r.configs[name]
+	if !ok {
+		return Config{}, fmt.Errorf("%w: %s", ErrUnknown, name)
+	}
+	for _, s := range schemes {
+		if c.Scheme == s {
+			return c, nil
+		}
+	}
+	return Config{}, fmt.Errorf("upstream %s: scheme %s cannot serve this client", name, c.Scheme)
+}
+
+// conn dials c once; r.mu must be held.
+func (r *Registry) conn(c Config) (*grpc.ClientConn, error) {
+	if conn, ok := r.conns[c.Name]; ok {
+		return conn, nil
+	}
+
+	creds := insecure.NewCredentials()
+	if c.TLS.Enabled() {
+		cfg, err := tlsconfig.Client(c.TLS.files(), c.TLS.ServerName)
+		if err != nil {
+			return nil, fmt.Errorf("upstream %s TLS: %w", c.Name, err)
+		}
+		creds = credentials.NewTLS(cfg)
+	}
+	opts := []grpc.DialOption{grpc.WithTransportCredentials(creds)}
+	if r.Signer != nil {
+		opts = append(opts, adapter.PropagateIdentity(r.Signer, c.Name)...)
+	}
+	conn, err := adapter.Dial(adapter.Upstream{Targets: c.Addresses, Subset: c.Subset, HealthService: c.HealthService, Tuning: c.GRPC.tuning()}, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("upstream %s: %w", c.Name, err)
+	}
+	r.conns[c.Name] = conn
+	return conn, nil
+}
@@ -0,0 +1,120 @@
+// Package retrybudget caps retries at a share of recent requests. Per-call
+// attempt limits bound how much one request retries, but not how much all
+// of them do: when an upstream starts failing, every caller retrying at
+// once multiplies its load just as it can least take it. A budget lets
+// retries through while they are rare and refuses them once they are not.
+package retrybudget
+
+import (
+	"sync"
+	"time"
+)
+
+// Budget counts requests and retries over a sliding window. Configure the
+// exported fields before the first call; it is safe for concurrent use
+// afterwards.
+type Budget struct {
+	// Ratio is how many retries (0..1) each request in the window earns;
+	// 0.1 allows one retry per ten requests.
+	Ratio float64
+	// MinRetries is allowed in every window regardless of traffic, so a
+	// quiet upstream can still be retried.
+	MinRetries int
+	// Window is how far back requests and retries count, in Buckets
+	// slices.
+	Window  time.Duration
+	Buckets int
+	// Now returns the current time; tests replace it.
+	Now func() time.Time
+
+	mu      sync.Mutex
+	buckets []bucket
+	stats   Stats
+}
+
+type bucket struct {
+	slot              int64
+	requests, retries int
+}
+
+// Stats are cumulative counters, suitable for exporting as metrics.
+type Stats struct {
+	Requests int64 `json:"requests"`
+	Retries  int64 `json:"retries"`
+	// Denied counts retries the budget refused.
+	Denied int64 `json:"denied"`
+}
+
+// New returns a budget allowing ratio retries per request over the last
+// ten seconds, and at least ten retries in that time.
+func New(ratio float64) *Budget {
+	return &Budget{
+		Ratio:      ratio,
+		MinRetries: 10,
+		Window:     10 * time.Second,
+		Buckets:    10,
+		Now:        time.Now,
+	}
+}
+
+// Request records a first attempt, adding to what retries may spend.
+func (b *Budget) Request() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.bucketLocked(b.Now()).requests++
+	b.stats.Requests++
+}
+
+// Retry reports whether one more retry fits the budget, and spends it if
+// so.
+func (b *Budget) Retry() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.Now()
+	requests, retries := b.countsLocked(now)
+	if float64(retries) >= float64(b.MinRetries)+b.Ratio*float64(requests) {
+		b.stats.Denied++
+		return false
+	}
+	b.bucketLocked(now).retries++
+	b.stats.Retries++
+	return true
+}
+
+func (b *Budget) Stats() Stats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.stats
+}
+
+func (b *Budget) bucketWidth() time.Duration {
+	n := max(b.Buckets, 1)
+	return max(b.Window/time.Duration(n), time.Nanosecond)
+}
+
+// bucketLocked returns the bucket counting now, recycling a stale one.
+func (b *Budget) bucketLocked(now time.Time) *bucket {
+	if b.buckets == nil {
+		b.buckets = make([]bucket, max(b.Buckets, 1))
+	}
+	slot := now.UnixNano() / int64(b.bucketWidth())
+	bkt := &b.buckets[slot%int64(len(b.buckets))]
+	if bkt.slot != slot {
+		*bkt = bucket{slot: slot}
+	}
+	return bkt
+}
+
+// countsLocked sums the buckets that still fall inside the window.
+func (b *Budget) countsLocked(now time.Time) (requests, retries int) {
+	current := now.UnixNano() / int64(b.bucketWidth())
+	for _, bkt := range b.buckets {
+		if current-bkt.slot < int64(len(b.buckets)) {
+			requests += bkt.requests
+			retries += bkt.retries
+		}
+	}
+	return requests, retries
+}
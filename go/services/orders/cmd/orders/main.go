@@ -0,0 +1,229 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"clean-code-cookbook/go/pkg/edgetoken"
+	"clean-code-cookbook/go/pkg/signing"
+	"clean-code-cookbook/go/pkg/tlsconfig"
+	"clean-code-cookbook/go/services/orders/internal/adapter/catalog"
+	grpcadapter "clean-code-cookbook/go/services/orders/internal/adapter/grpc"
+	httpadapter "clean-code-cookbook/go/services/orders/internal/adapter/http"
+	"clean-code-cookbook/go/services/orders/internal/adapter/memory"
+	"clean-code-cookbook/go/services/orders/internal/adapter/postgres"
+	"clean-code-cookbook/go/services/orders/internal/adapter/stripe"
+	"clean-code-cookbook/go/services/orders/internal/adapter/webhook"
+	"clean-code-cookbook/go/services/orders/internal/app"
+	"clean-code-cookbook/go/services/orders/internal/domain"
+	"clean-code-cookbook/go/services/orders/internal/ports"
+	pb "github.com/clean-code-coockbook/proto/gen/go/orders/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	logger := log.New(os.Stdout, "[orders] ", log.LstdFlags)
+
+	// 1. Orders and idempotency keys live in Postgres
+	// (ORDERS_DATABASE_URL), or in memory for local runs. The order store
+	// doubles as the outbox of order.placed.
+	var orders orderStore = memory.NewOrderRepository()
+	var keys ports.IdempotencyStore = memory.NewIdempotencyStore()
+	if dsn := os.Getenv("ORDERS_DATABASE_URL"); dsn != "" {
+		db, err := postgres.Open(ctx, dsn)
+		if err != nil {
+			logger.Fatal(err)
+		}
+		defer db.Close()
+		orders, keys = postgres.NewOrderRepository(db), postgres.NewIdempotencyStore(db)
+	}
+
+	// 2. Stock is reserved in the catalog (CATALOG_URL), or in a sample
+	// in-memory inventory for local runs.
+	var inventory ports.Inventory = memory.NewInventory(sampleStock)
+	if catalogURL := os.Getenv("CATALOG_URL"); catalogURL != "" {
+		client, err := catalogClient()
+		if err != nil {
+			logger.Fatalf("catalog TLS: %v", err)
+		}
+		catalogInventory := catalog.NewInventory(catalogURL, env("CATALOG_CURRENCY", "USD"), client)
+		// The catalog only serves calls vouched for with the edge's secret.
+		if secret := os.Getenv("CATALOG_IDENTITY_SECRET"); secret != "" {
+			catalogInventory.Identity = edgetoken.NewSigner([]byte(secret))
+		}
+		inventory = catalogInventory
+	}
+
+	// 3. Cards are charged through the payments API (PAYMENTS_API_KEY), or
+	// a fake gateway that accepts anything but memory.DeclinedMethod.
+	var payments ports.PaymentGateway = memory.NewPaymentGateway()
+	if apiKey := os.Getenv("PAYMENTS_API_KEY"); apiKey != "" {
+		payments = stripe.NewGateway(env("PAYMENTS_URL", stripe.DefaultBaseURL), apiKey, &http.Client{Timeout: 10 * time.Second})
+	}
+
+	// 4. Placed orders are announced to ORDERS_EVENTS_URLS (reporting's
+	// POST /events), signed with ORDERS_EVENTS_SIGNING_KEYS, by a relay
+	// off the checkout path. Without receivers orders stay pending, to be
+	// announced once some are configured.
+	var events ports.EventPublisher
+	var urls []string
+	for _, u := range strings.Split(os.Getenv("ORDERS_EVENTS_URLS"), ",") {
+		if u = strings.TrimSpace(u); u != "" {
+			urls = append(urls, u)
+		}
+	}
+	if len(urls) > 0 {
+		spec := os.Getenv("ORDERS_EVENTS_SIGNING_KEYS")
+		if spec == "" {
+			logger.Fatal("ORDERS_EVENTS_SIGNING_KEYS is required with ORDERS_EVENTS_URLS")
+		}
+		signingKeys, err := signing.ParseKeys(spec)
+		if err != nil {
+			logger.Fatalf("ORDERS_EVENTS_SIGNING_KEYS: %v", err)
+		}
+		events = webhook.NewPublisher(urls, signing.NewKeyring(signingKeys...))
+	}
+	if events != nil {
+		announcer := &app.OrderAnnouncer{Outbox: orders, Events: events, Logger: logger}
+		go announcer.Run(ctx)
+	}
+
+	// 5. Wire the use cases to both inbound adapters
+	place := &app.PlaceOrderCommand{Orders: orders, Inventory: inventory, Payments: payments, Keys: keys}
+	get := &app.GetOrderQuery{Orders: orders}
+	capture := &app.CapturePaymentCommand{Orders: orders, Payments: payments}
+	refund := &app.RefundPaymentCommand{Orders: orders, Payments: payments}
+
+	mux := http.NewServeMux()
+	httpadapter.NewHandler(place, get, capture, refund, logger).Register(mux)
+	server := &http.Server{
+		Addr:              env("ORDERS_HTTP_ADDR", ":8083"),
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	tuning, err := grpcOptions()
+	if err != nil {
+		logger.Fatal(err)
+	}
+	grpcServer := grpc.NewServer(grpcadapter.ServerOptions(tuning)...)
+	pb.RegisterOrderServiceServer(grpcServer, grpcadapter.NewServer(place, get, capture, refund, logger))
+	// Reflection lets grpcurl and the like list and call the service
+	// without its .proto files; ORDERS_GRPC_REFLECTION=false turns it off.
+	if env("ORDERS_GRPC_REFLECTION", "true") != "false" {
+		reflection.Register(grpcServer)
+	}
+	lis, err := net.Listen("tcp", env("ORDERS_GRPC_ADDR", ":9093"))
+	if err != nil {
+		logger.Fatalf("grpc listen: %v", err)
+	}
+
+	// 6. Serve until interrupted, then drain
+	go func() {
+		logger.Printf("HTTP listening on %s", server.Addr)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Fatalf("http server: %v", err)
+		}
+	}()
+	go func() {
+		logger.Printf("gRPC listening on %s", lis.Addr())
+		if err := grpcServer.Serve(lis); err != nil {
+			logger.Fatalf("grpc server: %v", err)
+		}
+	}()
+	<-ctx.Done()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		logger.Printf("shutdown: %v", err)
+	}
+	grpcServer.GracefulStop()
+	logger.Println("Done.")
+}
+
+// orderStore is what the order adapters implement: the repository and the
+// outbox of the orders in it.
+type orderStore interface {
+	ports.OrderRepository
+	ports.OrderOutbox
+}
+
+// sampleStock mirrors the catalog's sample products.
+var sampleStock = map[string]memory.Stock{
+	"sku-1": {Price: domain.Money{Amount: 3999, Currency: "USD"}, Available: 100},
+	"sku-2": {Price: domain.Money{Amount: 4450, Currency: "USD"}, Available: 100},
+}
+
+// catalogClient calls the catalog over mTLS when CATALOG_TLS_CERT, _KEY and
+// _CA are set, as the edge does; CATALOG_TLS_PEERS (comma-separated SANs)
+// restricts which server identities are accepted.
+func catalogClient() (*http.Client, error) {
+	files := tlsconfig.Files{
+		CertFile: os.Getenv("CATALOG_TLS_CERT"),
+		KeyFile:  os.Getenv("CATALOG_TLS_KEY"),
+		CAFile:   os.Getenv("CATALOG_TLS_CA"),
+	}
+	if !files.Enabled() {
+		return &http.Client{Timeout: 5 * time.Second}, nil
+	}
+	if peers := os.Getenv("CATALOG_TLS_PEERS"); peers != "" {
+		files.PeerNames = strings.Split(peers, ",")
+	}
+	cfg, err := tlsconfig.Client(files, "")
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = cfg
+	return &http.Client{Timeout: 5 * time.Second, Transport: transport}, nil
+}
+
+// grpcOptions reads ORDERS_GRPC_{KEEPALIVE_TIME,KEEPALIVE_TIMEOUT,
+// MIN_PING_INTERVAL} and ORDERS_GRPC_MAX_MSG_BYTES, which caps messages
+// both ways.
+func grpcOptions() (grpcadapter.Options, error) {
+	var o grpcadapter.Options
+	var errs []error
+	for name, d := range map[string]*time.Duration{
+		"ORDERS_GRPC_KEEPALIVE_TIME":    &o.KeepaliveTime,
+		"ORDERS_GRPC_KEEPALIVE_TIMEOUT": &o.KeepaliveTimeout,
+		"ORDERS_GRPC_MIN_PING_INTERVAL": &o.MinPingInterval,
+	} {
+		if v := os.Getenv(name); v != "" {
+			parsed, err := time.ParseDuration(v)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			}
+			*d = parsed
+		}
+	}
+	if v := os.Getenv("ORDERS_GRPC_MAX_MSG_BYTES"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("ORDERS_GRPC_MAX_MSG_BYTES: %w", err))
+		}
+		o.MaxRecvBytes, o.MaxSendBytes = n, n
+	}
+	return o, errors.Join(errs...)
+}
+
+func env(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
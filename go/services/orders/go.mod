@@ -0,0 +1,21 @@
+module clean-code-cookbook/go/services/orders
+
+go 1.22
+
+require (
+	clean-code-cookbook/go/pkg v0.0.0
+	github.com/google/uuid v1.6.0
+	github.com/lib/pq v1.10.9
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20230822172742-b8732ec3820d
+	google.golang.org/grpc v1.59.0
+)
+
+require (
+	github.com/golang/protobuf v1.5.3 // indirect
+	golang.org/x/net v0.14.0 // indirect
+	golang.org/x/sys v0.11.0 // indirect
+	golang.org/x/text v0.12.0 // indirect
+	google.golang.org/protobuf v1.31.0 // indirect
+)
+
+replace clean-code-cookbook/go/pkg => ../../pkg
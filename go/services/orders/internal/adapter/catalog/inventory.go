@@ -0,0 +1,141 @@
+// Package catalog reserves stock in the catalog service over HTTP.
+package catalog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"clean-code-cookbook/go/pkg/edgetoken"
+	"clean-code-cookbook/go/services/orders/internal/domain"
+)
+
+type itemDTO struct {
+	SKU      string `json:"sku"`
+	Quantity int    `json:"quantity"`
+}
+
+type reserveRequest struct {
+	OrderID string    `json:"order_id"`
+	Items   []itemDTO `json:"items"`
+}
+
+// reservationDTO is the catalog's answer. Prices are in major units, as
+// everywhere in the catalog.
+type reservationDTO struct {
+	ID    string `json:"id"`
+	Lines []struct {
+		SKU       string  `json:"sku"`
+		Quantity  int     `json:"quantity"`
+		UnitPrice float64 `json:"unit_price"`
+	} `json:"lines"`
+}
+
+// Inventory implements ports.Inventory against the catalog's
+// POST {baseURL}/reservations and DELETE {baseURL}/reservations/{id}. The
+// catalog answers 409 when stock runs short and 422 for an unknown SKU.
+// Reservations are keyed by order ID, so a retried Reserve is safe.
+type Inventory struct {
+	// Currency is what the catalog prices in.
+	Currency string
+	// Identity, when set, vouches for every call with an x-edge-identity
+	// token addressed to "catalog": for the request's caller if the edge
+	// sent one, for "orders" otherwise.
+	Identity *edgetoken.Signer
+
+	baseURL string
+	client  *http.Client
+}
+
+// NewInventory uses client, which should carry a sensible Timeout.
+func NewInventory(baseURL, currency string, client *http.Client) *Inventory {
+	return &Inventory{Currency: currency, baseURL: strings.TrimSuffix(baseURL, "/"), client: client}
+}
+
+func (inv *Inventory) Reserve(ctx context.Context, orderID string, items []domain.Item) (domain.Reservation, error) {
+	body := reserveRequest{OrderID: orderID, Items: make([]itemDTO, len(items))}
+	for i, it := range items {
+		body.Items[i] = itemDTO{SKU: it.SKU, Quantity: it.Quantity}
+	}
+	raw, err := json.Marshal(body)
+	if err != nil {
+		return domain.Reservation{}, err
+	}
+	resp, err := inv.do(ctx, http.MethodPost, "/reservations", bytes.NewReader(raw))
+	if err != nil {
+		return domain.Reservation{}, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated:
+	case http.StatusConflict:
+		return domain.Reservation{}, fmt.Errorf("%w: %s", domain.ErrOutOfStock, reason(resp))
+	case http.StatusUnprocessableEntity:
+		return domain.Reservation{}, fmt.Errorf("%w: %s", domain.ErrUnknownProduct, reason(resp))
+	default:
+		return domain.Reservation{}, fmt.Errorf("catalog returned %s: %s", resp.Status, reason(resp))
+	}
+
+	var dto reservationDTO
+	if err := json.NewDecoder(resp.Body).Decode(&dto); err != nil {
+		return domain.Reservation{}, fmt.Errorf("decode reservation: %w", err)
+	}
+	r := domain.Reservation{ID: dto.ID, Lines: make([]domain.LineItem, len(dto.Lines))}
+	for i, l := range dto.Lines {
+		price, err := domain.NewMoney(int64(math.Round(l.UnitPrice*100)), inv.Currency)
+		if err != nil {
+			return domain.Reservation{}, fmt.Errorf("price of %s: %w", l.SKU, err)
+		}
+		r.Lines[i] = domain.LineItem{SKU: l.SKU, Quantity: l.Quantity, UnitPrice: price}
+	}
+	return r, nil
+}
+
+func (inv *Inventory) Release(ctx context.Context, reservationID string) error {
+	resp, err := inv.do(ctx, http.MethodDelete, "/reservations/"+url.PathEscape(reservationID), nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 && resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("catalog returned %s: %s", resp.Status, reason(resp))
+	}
+	return nil
+}
+
+func (inv *Inventory) do(ctx context.Context, method, path string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, inv.baseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	if inv.Identity != nil {
+		caller, ok := edgetoken.FromContext(ctx)
+		if !ok {
+			caller = edgetoken.Caller{Subject: "orders"}
+		}
+		token, err := inv.Identity.Sign(caller, "catalog")
+		if err != nil {
+			return nil, fmt.Errorf("sign catalog identity: %w", err)
+		}
+		req.Header.Set(edgetoken.Header, token)
+	}
+	return inv.client.Do(req)
+}
+
+// reason is the start of an error response, which is where the catalog
+// says what went wrong.
+func reason(resp *http.Response) string {
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<10))
+	return string(bytes.TrimSpace(raw))
+}
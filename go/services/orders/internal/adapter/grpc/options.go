@@ -0,0 +1,49 @@
+package grpc
+
+import (
+	"time"
+
+	"google.golang.org/grpc"
+	_ "google.golang.org/grpc/encoding/gzip" // answers gzip-compressed calls in kind
+	"google.golang.org/grpc/keepalive"
+)
+
+// Options tunes the server's connections. Zero values keep the defaults.
+type Options struct {
+	// KeepaliveTime is how long a connection may sit idle before the
+	// server pings the client (30s by default), so proxies between them
+	// see traffic and a vanished client is noticed; KeepaliveTimeout is
+	// how long the ping may go unanswered (10s).
+	KeepaliveTime    time.Duration
+	KeepaliveTimeout time.Duration
+	// MinPingInterval is how often clients may ping (10s by default,
+	// gRPC's floor). A client pinging more often is sent GOAWAY.
+	MinPingInterval time.Duration
+	// MaxRecvBytes and MaxSendBytes cap a single message; gRPC's own
+	// defaults are 4MB received and unlimited sent.
+	MaxRecvBytes int
+	MaxSendBytes int
+}
+
+// ServerOptions turns o into options for grpc.NewServer.
+func ServerOptions(o Options) []grpc.ServerOption {
+	params := keepalive.ServerParameters{Time: 30 * time.Second, Timeout: 10 * time.Second}
+	if o.KeepaliveTime > 0 {
+		params.Time = o.KeepaliveTime
+	}
+	if o.KeepaliveTimeout > 0 {
+		params.Timeout = o.KeepaliveTimeout
+	}
+	policy := keepalive.EnforcementPolicy{MinTime: 10 * time.Second, PermitWithoutStream: true}
+	if o.MinPingInterval > 0 {
+		policy.MinTime = o.MinPingInterval
+	}
+	opts := []grpc.ServerOption{grpc.KeepaliveParams(params), grpc.KeepaliveEnforcementPolicy(policy)}
+	if o.MaxRecvBytes > 0 {
+		opts = append(opts, grpc.MaxRecvMsgSize(o.MaxRecvBytes))
+	}
+	if o.MaxSendBytes > 0 {
+		opts = append(opts, grpc.MaxSendMsgSize(o.MaxSendBytes))
+	}
+	return opts
+}
@@ -0,0 +1,146 @@
+// Package grpc serves the order use cases as orders.v1.OrderService.
+package grpc
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"clean-code-cookbook/go/services/orders/internal/app"
+	"clean-code-cookbook/go/services/orders/internal/domain"
+	pb "github.com/clean-code-coockbook/proto/gen/go/orders/v1"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Server implements pb.OrderServiceServer.
+type Server struct {
+	pb.UnimplementedOrderServiceServer
+
+	place   *app.PlaceOrderCommand
+	get     *app.GetOrderQuery
+	capture *app.CapturePaymentCommand
+	refund  *app.RefundPaymentCommand
+	logger  *log.Logger
+}
+
+func NewServer(place *app.PlaceOrderCommand, get *app.GetOrderQuery, capture *app.CapturePaymentCommand, refund *app.RefundPaymentCommand, logger *log.Logger) *Server {
+	return &Server{place: place, get: get, capture: capture, refund: refund, logger: logger}
+}
+
+func (s *Server) PlaceOrder(ctx context.Context, req *pb.PlaceOrderRequest) (*pb.PlaceOrderResponse, error) {
+	items := make([]domain.Item, len(req.GetItems()))
+	for i, it := range req.GetItems() {
+		items[i] = domain.Item{SKU: it.GetSku(), Quantity: int(it.GetQuantity())}
+	}
+	order, err := s.place.Execute(ctx, app.Checkout{
+		UserID:         req.GetUserId(),
+		Items:          items,
+		PaymentMethod:  req.GetPaymentMethod(),
+		IdempotencyKey: req.GetIdempotencyKey(),
+	})
+	if err != nil {
+		return nil, s.toStatus(err)
+	}
+	return &pb.PlaceOrderResponse{Order: toProto(order)}, nil
+}
+
+func (s *Server) GetOrder(ctx context.Context, req *pb.GetOrderRequest) (*pb.GetOrderResponse, error) {
+	order, err := s.get.Execute(ctx, req.GetId())
+	if err != nil {
+		return nil, s.toStatus(err)
+	}
+	return &pb.GetOrderResponse{Order: toProto(order)}, nil
+}
+
+func (s *Server) CapturePayment(ctx context.Context, req *pb.CapturePaymentRequest) (*pb.CapturePaymentResponse, error) {
+	order, err := s.capture.Execute(ctx, req.GetId())
+	if err != nil {
+		return nil, s.toStatus(err)
+	}
+	return &pb.CapturePaymentResponse{Order: toProto(order)}, nil
+}
+
+func (s *Server) RefundPayment(ctx context.Context, req *pb.RefundPaymentRequest) (*pb.RefundPaymentResponse, error) {
+	order, err := s.refund.Execute(ctx, req.GetId())
+	if err != nil {
+		return nil, s.toStatus(err)
+	}
+	return &pb.RefundPaymentResponse{Order: toProto(order)}, nil
+}
+
+// errorDomain is the Domain of the ErrorInfo details the server sends;
+// Reason is only unique within it.
+const errorDomain = "orders.v1"
+
+// toStatus maps domain errors to gRPC codes, as the HTTP adapter maps them
+// to statuses, with an ErrorInfo detail whose Reason clients can switch
+// on and, for invalid checkouts, a BadRequest naming the field. Anything
+// unexpected is logged and reported as Internal.
+func (s *Server) toStatus(err error) error {
+	var field *domain.FieldError
+	switch {
+	case errors.As(err, &field):
+		return withDetails(codes.InvalidArgument, err.Error(), "INVALID_ORDER", &errdetails.BadRequest_FieldViolation{Field: field.Field, Description: field.Reason})
+	case errors.Is(err, domain.ErrInvalidOrder):
+		return withDetails(codes.InvalidArgument, err.Error(), "INVALID_ORDER")
+	case errors.Is(err, domain.ErrUnknownProduct):
+		return withDetails(codes.InvalidArgument, err.Error(), "UNKNOWN_PRODUCT")
+	case errors.Is(err, domain.ErrIdempotencyKeyReused):
+		return withDetails(codes.InvalidArgument, err.Error(), "IDEMPOTENCY_KEY_REUSED", &errdetails.BadRequest_FieldViolation{Field: "idempotency_key", Description: domain.ErrIdempotencyKeyReused.Error()})
+	case errors.Is(err, domain.ErrOutOfStock):
+		return withDetails(codes.FailedPrecondition, err.Error(), "OUT_OF_STOCK")
+	case errors.Is(err, domain.ErrPaymentDeclined):
+		return withDetails(codes.FailedPrecondition, err.Error(), "PAYMENT_DECLINED")
+	case errors.Is(err, domain.ErrPaymentState):
+		return withDetails(codes.FailedPrecondition, err.Error(), "INVALID_PAYMENT_STATE")
+	case errors.Is(err, domain.ErrOrderNotFound):
+		return withDetails(codes.NotFound, domain.ErrOrderNotFound.Error(), "ORDER_NOT_FOUND")
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	default:
+		s.logger.Printf("grpc: %v", err)
+		return status.Error(codes.Internal, "internal error")
+	}
+}
+
+// withDetails returns a status error carrying an ErrorInfo with reason
+// and, given any violations, a BadRequest listing them.
+func withDetails(code codes.Code, msg, reason string, violations ...*errdetails.BadRequest_FieldViolation) error {
+	st := status.New(code, msg)
+	info := &errdetails.ErrorInfo{Reason: reason, Domain: errorDomain}
+	var err error
+	if len(violations) > 0 {
+		st, err = st.WithDetails(info, &errdetails.BadRequest{FieldViolations: violations})
+	} else {
+		st, err = st.WithDetails(info)
+	}
+	if err != nil {
+		// Details only fail to marshal on a bug; the code still stands.
+		return status.Error(code, msg)
+	}
+	return st.Err()
+}
+
+func toProto(o *domain.Order) *pb.Order {
+	out := &pb.Order{
+		Id:            o.ID,
+		UserId:        o.UserID,
+		Status:        string(o.Status),
+		Lines:         make([]*pb.LineItem, len(o.Lines)),
+		Total:         money(o.Total),
+		PlacedAtUnix:  o.PlacedAt.Unix(),
+		PaymentStatus: string(o.Payment.Status),
+	}
+	for i, l := range o.Lines {
+		out.Lines[i] = &pb.LineItem{Sku: l.SKU, Quantity: int32(l.Quantity), UnitPrice: money(l.UnitPrice)}
+	}
+	return out
+}
+
+func money(m domain.Money) *pb.Money {
+	return &pb.Money{Amount: m.Amount, Currency: m.Currency}
+}
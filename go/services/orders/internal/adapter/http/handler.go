@@ -0,0 +1,176 @@
+// Package httpadapter serves the order use cases as a JSON API.
+package httpadapter
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"clean-code-cookbook/go/services/orders/internal/app"
+	"clean-code-cookbook/go/services/orders/internal/domain"
+)
+
+// maxBody bounds a checkout request body.
+const maxBody = 1 << 20
+
+type itemRequest struct {
+	SKU      string `json:"sku"`
+	Quantity int    `json:"quantity"`
+}
+
+type placeOrderRequest struct {
+	UserID        string        `json:"user_id"`
+	Items         []itemRequest `json:"items"`
+	PaymentMethod string        `json:"payment_method"`
+}
+
+type moneyResponse struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+type lineResponse struct {
+	SKU       string        `json:"sku"`
+	Quantity  int           `json:"quantity"`
+	UnitPrice moneyResponse `json:"unit_price"`
+	Total     moneyResponse `json:"total"`
+}
+
+type paymentResponse struct {
+	Status string `json:"status"`
+}
+
+type orderResponse struct {
+	ID       string           `json:"id"`
+	UserID   string           `json:"user_id"`
+	Status   string           `json:"status"`
+	Lines    []lineResponse   `json:"lines"`
+	Total    moneyResponse    `json:"total"`
+	PlacedAt string           `json:"placed_at"`
+	Payment  *paymentResponse `json:"payment,omitempty"`
+}
+
+// Handler serves POST /orders, GET /orders/{id} and the payment steps
+// POST /orders/{id}/capture and POST /orders/{id}/refund.
+type Handler struct {
+	place   *app.PlaceOrderCommand
+	get     *app.GetOrderQuery
+	capture *app.CapturePaymentCommand
+	refund  *app.RefundPaymentCommand
+	logger  *log.Logger
+}
+
+func NewHandler(place *app.PlaceOrderCommand, get *app.GetOrderQuery, capture *app.CapturePaymentCommand, refund *app.RefundPaymentCommand, logger *log.Logger) *Handler {
+	return &Handler{place: place, get: get, capture: capture, refund: refund, logger: logger}
+}
+
+// Register mounts the routes on mux.
+func (h *Handler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("POST /orders", h.Place)
+	mux.HandleFunc("GET /orders/{id}", h.Get)
+	mux.HandleFunc("POST /orders/{id}/capture", h.Capture)
+	mux.HandleFunc("POST /orders/{id}/refund", h.Refund)
+}
+
+// Place handles POST /orders and answers 201 with the order. A request
+// repeated with the same Idempotency-Key header gets the same order back.
+func (h *Handler) Place(w http.ResponseWriter, r *http.Request) {
+	var req placeOrderRequest
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBody))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	items := make([]domain.Item, len(req.Items))
+	for i, it := range req.Items {
+		items[i] = domain.Item{SKU: it.SKU, Quantity: it.Quantity}
+	}
+
+	order, err := h.place.Execute(r.Context(), app.Checkout{
+		UserID:         req.UserID,
+		Items:          items,
+		PaymentMethod:  req.PaymentMethod,
+		IdempotencyKey: r.Header.Get("Idempotency-Key"),
+	})
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	w.Header().Set("Location", "/orders/"+order.ID)
+	writeJSON(w, http.StatusCreated, toResponse(order))
+}
+
+// Get handles GET /orders/{id}.
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	order, err := h.get.Execute(r.Context(), r.PathValue("id"))
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toResponse(order))
+}
+
+// Capture handles POST /orders/{id}/capture.
+func (h *Handler) Capture(w http.ResponseWriter, r *http.Request) {
+	order, err := h.capture.Execute(r.Context(), r.PathValue("id"))
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toResponse(order))
+}
+
+// Refund handles POST /orders/{id}/refund.
+func (h *Handler) Refund(w http.ResponseWriter, r *http.Request) {
+	order, err := h.refund.Execute(r.Context(), r.PathValue("id"))
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toResponse(order))
+}
+
+// writeError maps domain errors to statuses. Anything unexpected is logged
+// and answered with a bare 500, so internals don't leak to clients.
+func (h *Handler) writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidOrder), errors.Is(err, domain.ErrUnknownProduct), errors.Is(err, domain.ErrIdempotencyKeyReused):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	case errors.Is(err, domain.ErrOutOfStock), errors.Is(err, domain.ErrPaymentState):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, domain.ErrPaymentDeclined):
+		http.Error(w, err.Error(), http.StatusPaymentRequired)
+	case errors.Is(err, domain.ErrOrderNotFound):
+		http.Error(w, domain.ErrOrderNotFound.Error(), http.StatusNotFound)
+	default:
+		h.logger.Printf("http: %v", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
+
+func toResponse(o *domain.Order) orderResponse {
+	resp := orderResponse{
+		ID:       o.ID,
+		UserID:   o.UserID,
+		Status:   string(o.Status),
+		Lines:    make([]lineResponse, len(o.Lines)),
+		Total:    moneyResponse(o.Total),
+		PlacedAt: o.PlacedAt.UTC().Format(time.RFC3339),
+	}
+	if o.Payment.Status != domain.PaymentNone {
+		resp.Payment = &paymentResponse{Status: string(o.Payment.Status)}
+	}
+	for i, l := range o.Lines {
+		resp.Lines[i] = lineResponse{SKU: l.SKU, Quantity: l.Quantity, UnitPrice: moneyResponse(l.UnitPrice), Total: moneyResponse(l.Total())}
+	}
+	return resp
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,83 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"clean-code-cookbook/go/services/orders/internal/domain"
+)
+
+// Stock is what Inventory holds of one product.
+type Stock struct {
+	Price     domain.Money
+	Available int
+}
+
+// Inventory implements ports.Inventory on a map of SKU to Stock. Each
+// reservation is named after its order.
+type Inventory struct {
+	mu       sync.Mutex
+	stock    map[string]Stock
+	reserved map[string]domain.Reservation
+}
+
+func NewInventory(stock map[string]Stock) *Inventory {
+	inv := &Inventory{stock: make(map[string]Stock, len(stock)), reserved: make(map[string]domain.Reservation)}
+	for sku, s := range stock {
+		inv.stock[sku] = s
+	}
+	return inv
+}
+
+func (inv *Inventory) Reserve(ctx context.Context, orderID string, items []domain.Item) (domain.Reservation, error) {
+	if err := ctx.Err(); err != nil {
+		return domain.Reservation{}, err
+	}
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	if r, ok := inv.reserved[orderID]; ok {
+		return r, nil
+	}
+	r := domain.Reservation{ID: orderID}
+	for _, it := range items {
+		s, ok := inv.stock[it.SKU]
+		if !ok {
+			return domain.Reservation{}, fmt.Errorf("%w: %s", domain.ErrUnknownProduct, it.SKU)
+		}
+		if s.Available < it.Quantity {
+			return domain.Reservation{}, fmt.Errorf("%w: %s has %d left", domain.ErrOutOfStock, it.SKU, s.Available)
+		}
+		r.Lines = append(r.Lines, domain.LineItem{SKU: it.SKU, Quantity: it.Quantity, UnitPrice: s.Price})
+	}
+	for _, l := range r.Lines {
+		s := inv.stock[l.SKU]
+		s.Available -= l.Quantity
+		inv.stock[l.SKU] = s
+	}
+	inv.reserved[orderID] = r
+	return r, nil
+}
+
+func (inv *Inventory) Release(ctx context.Context, reservationID string) error {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	r, ok := inv.reserved[reservationID]
+	if !ok {
+		return nil
+	}
+	for _, l := range r.Lines {
+		s := inv.stock[l.SKU]
+		s.Available += l.Quantity
+		inv.stock[l.SKU] = s
+	}
+	delete(inv.reserved, reservationID)
+	return nil
+}
+
+// Available reports how much of sku is left to reserve.
+func (inv *Inventory) Available(sku string) int {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	return inv.stock[sku].Available
+}
@@ -0,0 +1,103 @@
+// Package memory provides in-process adapters, so the orders service runs
+// in dev and tests with no database or catalog.
+package memory
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"clean-code-cookbook/go/services/orders/internal/domain"
+)
+
+// OrderRepository implements ports.OrderRepository and ports.OrderOutbox
+// on maps. It is safe for concurrent use.
+type OrderRepository struct {
+	mu      sync.RWMutex
+	orders  map[string]domain.Order
+	pending map[string]bool
+}
+
+func NewOrderRepository() *OrderRepository {
+	return &OrderRepository{orders: make(map[string]domain.Order), pending: make(map[string]bool)}
+}
+
+func (r *OrderRepository) Save(ctx context.Context, o *domain.Order) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.orders[o.ID]; ok {
+		return domain.ErrOrderExists
+	}
+	r.orders[o.ID] = clone(*o)
+	r.pending[o.ID] = true
+	return nil
+}
+
+func (r *OrderRepository) Update(ctx context.Context, o *domain.Order) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.orders[o.ID]
+	if !ok {
+		return domain.ErrOrderNotFound
+	}
+	stored.Status, stored.Payment = o.Status, o.Payment
+	r.orders[o.ID] = stored
+	return nil
+}
+
+// GetByID returns a copy, so callers cannot change the stored order.
+func (r *OrderRepository) GetByID(ctx context.Context, id string) (*domain.Order, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	o, ok := r.orders[id]
+	if !ok {
+		return nil, domain.ErrOrderNotFound
+	}
+	o = clone(o)
+	return &o, nil
+}
+
+func (r *OrderRepository) Unannounced(ctx context.Context, limit int) ([]*domain.Order, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	pending := make([]*domain.Order, 0, len(r.pending))
+	for id := range r.pending {
+		o := clone(r.orders[id])
+		pending = append(pending, &o)
+	}
+	slices.SortFunc(pending, func(a, b *domain.Order) int { return a.PlacedAt.Compare(b.PlacedAt) })
+	if len(pending) > limit {
+		pending = pending[:limit]
+	}
+	return pending, nil
+}
+
+func (r *OrderRepository) MarkAnnounced(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.orders[id]; !ok {
+		return domain.ErrOrderNotFound
+	}
+	delete(r.pending, id)
+	return nil
+}
+
+func clone(o domain.Order) domain.Order {
+	o.Lines = append([]domain.LineItem(nil), o.Lines...)
+	return o
+}
@@ -0,0 +1,121 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"clean-code-cookbook/go/services/orders/internal/domain"
+)
+
+// DeclinedMethod is the payment method PaymentGateway refuses.
+const DeclinedMethod = "pm_card_declined"
+
+// PaymentGateway implements ports.PaymentGateway without moving money. It
+// replays the first answer to a key, as a real gateway does, and counts
+// the charges it actually made so tests can see nothing was billed twice.
+type PaymentGateway struct {
+	mu      sync.Mutex
+	answers map[string]paymentAnswer
+	charged map[string]domain.Money
+	n       int
+}
+
+type paymentAnswer struct {
+	authorizationID string
+	err             error
+}
+
+func NewPaymentGateway() *PaymentGateway {
+	return &PaymentGateway{answers: make(map[string]paymentAnswer), charged: make(map[string]domain.Money)}
+}
+
+func (g *PaymentGateway) Authorize(ctx context.Context, key string, amount domain.Money, method string) (string, error) {
+	return g.once(ctx, key, func() (string, error) {
+		if method == DeclinedMethod {
+			return "", fmt.Errorf("%w: card declined", domain.ErrPaymentDeclined)
+		}
+		g.n++
+		return fmt.Sprintf("auth_%d", g.n), nil
+	})
+}
+
+func (g *PaymentGateway) Capture(ctx context.Context, key, authorizationID string, amount domain.Money) error {
+	_, err := g.once(ctx, key, func() (string, error) {
+		if _, ok := g.charged[authorizationID]; ok {
+			return "", fmt.Errorf("%w: %s already captured", domain.ErrPaymentState, authorizationID)
+		}
+		g.charged[authorizationID] = amount
+		return authorizationID, nil
+	})
+	return err
+}
+
+func (g *PaymentGateway) Refund(ctx context.Context, key, authorizationID string, amount domain.Money) error {
+	_, err := g.once(ctx, key, func() (string, error) {
+		if _, ok := g.charged[authorizationID]; !ok {
+			return "", fmt.Errorf("%w: %s was not captured", domain.ErrPaymentState, authorizationID)
+		}
+		delete(g.charged, authorizationID)
+		return authorizationID, nil
+	})
+	return err
+}
+
+// Charged reports what has been captured and not refunded, by
+// authorization.
+func (g *PaymentGateway) Charged() map[string]domain.Money {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	out := make(map[string]domain.Money, len(g.charged))
+	for id, m := range g.charged {
+		out[id] = m
+	}
+	return out
+}
+
+func (g *PaymentGateway) once(ctx context.Context, key string, do func() (string, error)) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	a, ok := g.answers[key]
+	if !ok {
+		a.authorizationID, a.err = do()
+		g.answers[key] = a
+	}
+	return a.authorizationID, a.err
+}
+
+// IdempotencyStore implements ports.IdempotencyStore on a map.
+type IdempotencyStore struct {
+	mu   sync.Mutex
+	keys map[string]claim
+}
+
+type claim struct {
+	fingerprint string
+	orderID     string
+}
+
+func NewIdempotencyStore() *IdempotencyStore {
+	return &IdempotencyStore{keys: make(map[string]claim)}
+}
+
+func (s *IdempotencyStore) Claim(ctx context.Context, key, fingerprint, orderID string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	bound, ok := s.keys[key]
+	if !ok {
+		s.keys[key] = claim{fingerprint: fingerprint, orderID: orderID}
+		return orderID, nil
+	}
+	if bound.fingerprint != fingerprint {
+		return "", domain.ErrIdempotencyKeyReused
+	}
+	return bound.orderID, nil
+}
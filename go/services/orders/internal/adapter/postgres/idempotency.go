@@ -0,0 +1,39 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"clean-code-cookbook/go/services/orders/internal/domain"
+)
+
+// IdempotencyStore implements ports.IdempotencyStore on the
+// idempotency_keys table.
+type IdempotencyStore struct {
+	db *sql.DB
+}
+
+func NewIdempotencyStore(db *sql.DB) *IdempotencyStore {
+	return &IdempotencyStore{db: db}
+}
+
+// Claim inserts the key unless it exists, then reads back whichever claim
+// won, so two concurrent retries agree on one order.
+func (s *IdempotencyStore) Claim(ctx context.Context, key, fingerprint, orderID string) (string, error) {
+	_, err := s.db.ExecContext(ctx, `INSERT INTO idempotency_keys (key, fingerprint, order_id) VALUES ($1, $2, $3)
+		ON CONFLICT (key) DO NOTHING`, key, fingerprint, orderID)
+	if err != nil {
+		return "", fmt.Errorf("claim idempotency key: %w", err)
+	}
+	var boundFingerprint, boundOrder string
+	err = s.db.QueryRowContext(ctx, `SELECT fingerprint, order_id FROM idempotency_keys WHERE key = $1`, key).
+		Scan(&boundFingerprint, &boundOrder)
+	if err != nil {
+		return "", fmt.Errorf("read idempotency key: %w", err)
+	}
+	if boundFingerprint != fingerprint {
+		return "", domain.ErrIdempotencyKeyReused
+	}
+	return boundOrder, nil
+}
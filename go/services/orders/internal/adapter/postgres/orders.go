@@ -0,0 +1,205 @@
+// Package postgres stores orders and idempotency keys in PostgreSQL
+// through database/sql and the lib/pq driver.
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"clean-code-cookbook/go/services/orders/internal/domain"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// schema keeps amounts in minor units. Lines keep their position, so an
+// order reads back in the order it was placed. Orders placed without a
+// gateway have an empty payment_status. Orders not yet announced have no
+// announced_at; those stored before the column existed were announced as
+// they were placed, so adding it marks them.
+const schema = `
+CREATE TABLE IF NOT EXISTS orders (
+    id             UUID PRIMARY KEY,
+    user_id        UUID NOT NULL,
+    status         TEXT NOT NULL,
+    currency       CHAR(3) NOT NULL,
+    total          BIGINT NOT NULL CHECK (total >= 0),
+    reservation_id TEXT NOT NULL,
+    placed_at      TIMESTAMPTZ NOT NULL,
+    payment_authorization TEXT NOT NULL DEFAULT '',
+    payment_status        TEXT NOT NULL DEFAULT '',
+    payment_updated_at    TIMESTAMPTZ,
+    announced_at          TIMESTAMPTZ
+);
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS announced_at TIMESTAMPTZ DEFAULT now();
+ALTER TABLE orders ALTER COLUMN announced_at DROP DEFAULT;
+CREATE INDEX IF NOT EXISTS orders_user_id_idx ON orders (user_id, placed_at);
+CREATE INDEX IF NOT EXISTS orders_unannounced_idx ON orders (placed_at) WHERE announced_at IS NULL;
+CREATE TABLE IF NOT EXISTS order_lines (
+    order_id   UUID NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    position   INT NOT NULL,
+    sku        TEXT NOT NULL,
+    quantity   INT NOT NULL CHECK (quantity > 0),
+    unit_price BIGINT NOT NULL CHECK (unit_price >= 0),
+    PRIMARY KEY (order_id, position)
+);
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    key         TEXT PRIMARY KEY,
+    fingerprint TEXT NOT NULL,
+    order_id    UUID NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);`
+
+// uniqueViolation is the SQLSTATE of a duplicate key.
+const uniqueViolation = "23505"
+
+// Open connects to dsn and creates the schema if it is missing.
+func Open(ctx context.Context, dsn string) (*sql.DB, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open postgres: %w", err)
+	}
+	if _, err := db.ExecContext(ctx, schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("migrate orders: %w", err)
+	}
+	return db, nil
+}
+
+// OrderRepository implements ports.OrderRepository and ports.OrderOutbox
+// on the orders and order_lines tables.
+type OrderRepository struct {
+	db *sql.DB
+}
+
+func NewOrderRepository(db *sql.DB) *OrderRepository {
+	return &OrderRepository{db: db}
+}
+
+// Save writes the order and its lines in one transaction.
+func (r *OrderRepository) Save(ctx context.Context, o *domain.Order) (err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO orders (id, user_id, status, currency, total, reservation_id, placed_at,
+		payment_authorization, payment_status, payment_updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		o.ID, o.UserID, string(o.Status), o.Total.Currency, o.Total.Amount, o.ReservationID, o.PlacedAt,
+		o.Payment.AuthorizationID, string(o.Payment.Status), nullTime(o.Payment.UpdatedAt))
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		return domain.ErrOrderExists
+	}
+	if err != nil {
+		return fmt.Errorf("insert order: %w", err)
+	}
+	for i, l := range o.Lines {
+		_, err = tx.ExecContext(ctx, `INSERT INTO order_lines (order_id, position, sku, quantity, unit_price) VALUES ($1, $2, $3, $4, $5)`,
+			o.ID, i, l.SKU, l.Quantity, l.UnitPrice.Amount)
+		if err != nil {
+			return fmt.Errorf("insert line %s: %w", l.SKU, err)
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *OrderRepository) Update(ctx context.Context, o *domain.Order) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE orders SET status = $2, payment_authorization = $3, payment_status = $4, payment_updated_at = $5
+		WHERE id = $1`,
+		o.ID, string(o.Status), o.Payment.AuthorizationID, string(o.Payment.Status), nullTime(o.Payment.UpdatedAt))
+	if err != nil {
+		return fmt.Errorf("update order: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return domain.ErrOrderNotFound
+	}
+	return nil
+}
+
+func (r *OrderRepository) GetByID(ctx context.Context, id string) (*domain.Order, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, domain.ErrOrderNotFound // no such row could exist
+	}
+	var o domain.Order
+	var status, paymentStatus string
+	var paymentAt sql.NullTime
+	err := r.db.QueryRowContext(ctx, `SELECT id, user_id, status, currency, total, reservation_id, placed_at,
+		payment_authorization, payment_status, payment_updated_at FROM orders WHERE id = $1`, id).
+		Scan(&o.ID, &o.UserID, &status, &o.Total.Currency, &o.Total.Amount, &o.ReservationID, &o.PlacedAt,
+			&o.Payment.AuthorizationID, &paymentStatus, &paymentAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrOrderNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	o.Status = domain.OrderStatus(status)
+	o.Payment.Status, o.Payment.UpdatedAt = domain.PaymentStatus(paymentStatus), paymentAt.Time
+
+	rows, err := r.db.QueryContext(ctx, `SELECT sku, quantity, unit_price FROM order_lines WHERE order_id = $1 ORDER BY position`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		l := domain.LineItem{UnitPrice: domain.Money{Currency: o.Total.Currency}}
+		if err := rows.Scan(&l.SKU, &l.Quantity, &l.UnitPrice.Amount); err != nil {
+			return nil, err
+		}
+		o.Lines = append(o.Lines, l)
+	}
+	return &o, rows.Err()
+}
+
+func (r *OrderRepository) Unannounced(ctx context.Context, limit int) ([]*domain.Order, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT id FROM orders WHERE announced_at IS NULL ORDER BY placed_at LIMIT $1`, limit)
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	pending := make([]*domain.Order, 0, len(ids))
+	for _, id := range ids {
+		o, err := r.GetByID(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("load order %s: %w", id, err)
+		}
+		pending = append(pending, o)
+	}
+	return pending, nil
+}
+
+func (r *OrderRepository) MarkAnnounced(ctx context.Context, id string) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE orders SET announced_at = COALESCE(announced_at, now()) WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("mark order announced: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return domain.ErrOrderNotFound
+	}
+	return nil
+}
+
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
@@ -0,0 +1,111 @@
+// Package stripe charges cards through a Stripe-style payments API:
+// form-encoded POSTs authenticated with a secret key, each carrying an
+// Idempotency-Key header the provider deduplicates on.
+package stripe
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"clean-code-cookbook/go/services/orders/internal/domain"
+)
+
+// DefaultBaseURL is Stripe's API.
+const DefaultBaseURL = "https://api.stripe.com"
+
+type intentDTO struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+}
+
+type errorDTO struct {
+	Error struct {
+		Type    string `json:"type"`
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// Gateway implements ports.PaymentGateway with payment intents:
+// Authorize confirms one with manual capture, Capture captures it and
+// Refund refunds it. A declined card comes back as 402.
+type Gateway struct {
+	baseURL   string
+	secretKey string
+	client    *http.Client
+}
+
+// NewGateway uses client, which should carry a sensible Timeout.
+func NewGateway(baseURL, secretKey string, client *http.Client) *Gateway {
+	return &Gateway{baseURL: strings.TrimSuffix(baseURL, "/"), secretKey: secretKey, client: client}
+}
+
+func (g *Gateway) Authorize(ctx context.Context, key string, amount domain.Money, method string) (string, error) {
+	form := url.Values{
+		"amount":         {strconv.FormatInt(amount.Amount, 10)},
+		"currency":       {strings.ToLower(amount.Currency)},
+		"payment_method": {method},
+		"capture_method": {"manual"},
+		"confirm":        {"true"},
+	}
+	var intent intentDTO
+	if err := g.post(ctx, key, "/v1/payment_intents", form, &intent); err != nil {
+		return "", err
+	}
+	if intent.Status != "requires_capture" {
+		return "", fmt.Errorf("payment intent %s is %s, not authorized", intent.ID, intent.Status)
+	}
+	return intent.ID, nil
+}
+
+func (g *Gateway) Capture(ctx context.Context, key, authorizationID string, amount domain.Money) error {
+	form := url.Values{"amount_to_capture": {strconv.FormatInt(amount.Amount, 10)}}
+	return g.post(ctx, key, "/v1/payment_intents/"+url.PathEscape(authorizationID)+"/capture", form, nil)
+}
+
+func (g *Gateway) Refund(ctx context.Context, key, authorizationID string, amount domain.Money) error {
+	form := url.Values{
+		"payment_intent": {authorizationID},
+		"amount":         {strconv.FormatInt(amount.Amount, 10)},
+	}
+	return g.post(ctx, key, "/v1/refunds", form, nil)
+}
+
+// post sends form under the idempotency key and decodes a success into
+// out, if it is not nil.
+func (g *Gateway) post(ctx context.Context, key, path string, form url.Values, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.baseURL+path, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+g.secretKey)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Idempotency-Key", key)
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var e errorDTO
+		_ = json.NewDecoder(io.LimitReader(resp.Body, 1<<12)).Decode(&e)
+		if resp.StatusCode == http.StatusPaymentRequired || e.Error.Type == "card_error" {
+			return fmt.Errorf("%w: %s", domain.ErrPaymentDeclined, e.Error.Message)
+		}
+		return fmt.Errorf("payments returned %s: %s %s", resp.Status, e.Error.Code, e.Error.Message)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode %s: %w", path, err)
+	}
+	return nil
+}
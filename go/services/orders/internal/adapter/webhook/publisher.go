@@ -0,0 +1,107 @@
+// Package webhook announces order events to other services by POSTing them
+// as signed JSON, in the envelope clean_go_system's eventcodec writes and
+// reporting reads.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"clean-code-cookbook/go/pkg/signing"
+	"clean-code-cookbook/go/services/orders/internal/domain"
+)
+
+// OrderPlacedEvent is the type of the event announcing a new order.
+const OrderPlacedEvent = "order.placed"
+
+// eventTypeHeader names the event in a delivery, as clean_go_system's
+// webhooks do.
+const eventTypeHeader = "X-Event-Type"
+
+type envelope struct {
+	ID         string    `json:"id"`
+	Type       string    `json:"type"`
+	Version    int       `json:"version"`
+	OccurredAt time.Time `json:"occurred_at"`
+	Payload    any       `json:"payload"`
+}
+
+// orderPlaced is the order.placed payload. Its field names are the wire
+// format, like the Go-named payloads clean_go_system publishes.
+type orderPlaced struct {
+	OrderID  string
+	UserID   string
+	Lines    []orderLine
+	PlacedAt time.Time
+}
+
+type orderLine struct {
+	SKU       string
+	Quantity  int
+	UnitPrice domain.Money
+}
+
+// Publisher implements ports.EventPublisher by POSTing each event to every
+// URL, signed with the keyring's current key.
+type Publisher struct {
+	// Client sends deliveries; it defaults to one with a 5s timeout.
+	Client *http.Client
+
+	urls []string
+	keys *signing.Keyring
+}
+
+func NewPublisher(urls []string, keys *signing.Keyring) *Publisher {
+	return &Publisher{Client: &http.Client{Timeout: 5 * time.Second}, urls: urls, keys: keys}
+}
+
+// OrderPlaced delivers order.placed for o. The event ID is derived from
+// the order ID, so announcing an order again is a repeat receivers drop.
+func (p *Publisher) OrderPlaced(ctx context.Context, o *domain.Order) error {
+	lines := make([]orderLine, 0, len(o.Lines))
+	for _, l := range o.Lines {
+		lines = append(lines, orderLine{SKU: l.SKU, Quantity: l.Quantity, UnitPrice: l.UnitPrice})
+	}
+	body, err := json.Marshal(envelope{
+		ID:         OrderPlacedEvent + ":" + o.ID,
+		Type:       OrderPlacedEvent,
+		Version:    1,
+		OccurredAt: o.PlacedAt,
+		Payload:    orderPlaced{OrderID: o.ID, UserID: o.UserID, Lines: lines, PlacedAt: o.PlacedAt},
+	})
+	if err != nil {
+		return fmt.Errorf("encode %s: %w", OrderPlacedEvent, err)
+	}
+	for _, url := range p.urls {
+		if err := p.deliver(ctx, url, OrderPlacedEvent, body); err != nil {
+			return fmt.Errorf("webhook %s: %w", url, err)
+		}
+	}
+	return nil
+}
+
+func (p *Publisher) deliver(ctx context.Context, url, eventType string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(eventTypeHeader, eventType)
+	p.keys.SignRequest(req, body)
+
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("delivery answered %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"clean-code-cookbook/go/services/orders/internal/ports"
+)
+
+// OrderAnnouncer relays the order outbox: it publishes order.placed for
+// every stored order not yet announced and marks it, so checkouts never
+// wait on, or fail with, the receivers. An order published but not marked
+// (a crash in between) is published again under the same event ID, which
+// receivers drop; several instances may relay side by side for the same
+// reason.
+type OrderAnnouncer struct {
+	Outbox ports.OrderOutbox
+	Events ports.EventPublisher
+	// Interval is the pause between polls once caught up; it defaults to
+	// one second. A failed order is tried again on the next poll.
+	Interval time.Duration
+	// BatchSize caps the orders announced per poll; it defaults to 100.
+	BatchSize int
+	// Logger reports failed announcements; it defaults to discarding them.
+	Logger *log.Logger
+}
+
+// Run announces pending orders until ctx is done.
+func (a *OrderAnnouncer) Run(ctx context.Context) {
+	interval := a.Interval
+	if interval <= 0 {
+		interval = time.Second
+	}
+	for {
+		n, err := a.AnnounceBatch(ctx)
+		if err != nil && ctx.Err() == nil {
+			a.logger().Printf("announce orders: %v", err)
+		}
+		if err == nil && n == a.batchSize() {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// AnnounceBatch announces up to BatchSize pending orders, oldest first,
+// and returns how many it announced. An order the publisher refuses is
+// logged and left pending; the rest of the batch goes ahead.
+func (a *OrderAnnouncer) AnnounceBatch(ctx context.Context) (int, error) {
+	pending, err := a.Outbox.Unannounced(ctx, a.batchSize())
+	if err != nil {
+		return 0, fmt.Errorf("failed to list unannounced orders: %w", err)
+	}
+	announced := 0
+	for _, order := range pending {
+		if err := a.Events.OrderPlaced(ctx, order); err != nil {
+			a.logger().Printf("announce order %s: %v", order.ID, err)
+			continue
+		}
+		if err := a.Outbox.MarkAnnounced(ctx, order.ID); err != nil {
+			return announced, fmt.Errorf("failed to mark order %s announced: %w", order.ID, err)
+		}
+		announced++
+	}
+	return announced, nil
+}
+
+func (a *OrderAnnouncer) batchSize() int {
+	if a.BatchSize <= 0 {
+		return 100
+	}
+	return a.BatchSize
+}
+
+func (a *OrderAnnouncer) logger() *log.Logger {
+	if a.Logger == nil {
+		return log.New(io.Discard, "", 0)
+	}
+	return a.Logger
+}
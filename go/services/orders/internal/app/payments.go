@@ -0,0 +1,75 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"clean-code-cookbook/go/services/orders/internal/domain"
+	"clean-code-cookbook/go/services/orders/internal/ports"
+)
+
+// CapturePaymentCommand collects the payment authorized at checkout, once
+// the order ships.
+type CapturePaymentCommand struct {
+	Orders   ports.OrderRepository
+	Payments ports.PaymentGateway
+	// Now stamps the payment; it defaults to time.Now.
+	Now func() time.Time
+}
+
+// Execute captures the payment of order id. Capturing twice is a no-op.
+func (c *CapturePaymentCommand) Execute(ctx context.Context, id string) (*domain.Order, error) {
+	order, err := c.Orders.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch order %s: %w", id, err)
+	}
+	if order.Payment.Status == domain.PaymentCaptured {
+		return order, nil
+	}
+	if err := order.CapturePayment(now(c.Now)); err != nil {
+		return nil, err
+	}
+
+	// The key is the order's, so if Update fails the retry is answered by
+	// the gateway without collecting again
+	key := domain.PaymentKey(order.ID, "capture")
+	if err := c.Payments.Capture(ctx, key, order.Payment.AuthorizationID, order.Total); err != nil {
+		return nil, fmt.Errorf("failed to capture payment of order %s: %w", id, err)
+	}
+	if err := c.Orders.Update(ctx, order); err != nil {
+		return nil, fmt.Errorf("failed to update order %s: %w", id, err)
+	}
+	return order, nil
+}
+
+// RefundPaymentCommand pays a captured order back in full.
+type RefundPaymentCommand struct {
+	Orders   ports.OrderRepository
+	Payments ports.PaymentGateway
+	// Now stamps the payment; it defaults to time.Now.
+	Now func() time.Time
+}
+
+// Execute refunds the payment of order id. Refunding twice is a no-op.
+func (c *RefundPaymentCommand) Execute(ctx context.Context, id string) (*domain.Order, error) {
+	order, err := c.Orders.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch order %s: %w", id, err)
+	}
+	if order.Payment.Status == domain.PaymentRefunded {
+		return order, nil
+	}
+	if err := order.RefundPayment(now(c.Now)); err != nil {
+		return nil, err
+	}
+
+	key := domain.PaymentKey(order.ID, "refund")
+	if err := c.Payments.Refund(ctx, key, order.Payment.AuthorizationID, order.Total); err != nil {
+		return nil, fmt.Errorf("failed to refund order %s: %w", id, err)
+	}
+	if err := c.Orders.Update(ctx, order); err != nil {
+		return nil, fmt.Errorf("failed to update order %s: %w", id, err)
+	}
+	return order, nil
+}
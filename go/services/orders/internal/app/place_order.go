@@ -0,0 +1,154 @@
+package app
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"clean-code-cookbook/go/services/orders/internal/domain"
+	"clean-code-cookbook/go/services/orders/internal/ports"
+	"github.com/google/uuid"
+)
+
+// Checkout is what a client asks PlaceOrderCommand for.
+type Checkout struct {
+	UserID string
+	Items  []domain.Item
+	// PaymentMethod is the gateway's token for the card to charge.
+	PaymentMethod string
+	// IdempotencyKey is chosen by the client; sending it again with the
+	// same cart returns the order it placed instead of placing another.
+	IdempotencyKey string
+}
+
+// PlaceOrderCommand is the checkout use case: it reserves the items in the
+// catalog, turns the priced reservation into an order, authorizes its
+// total and stores it. If the order cannot be stored, the stock is
+// released again. Storing the order is what queues its announcement;
+// OrderAnnouncer delivers it afterwards.
+//
+// Every step is keyed by the order ID, so a checkout retried under the
+// same idempotency key resumes where it failed: the catalog returns the
+// same reservation, the gateway the same authorization, and an order
+// already stored is returned as it is.
+type PlaceOrderCommand struct {
+	Orders    ports.OrderRepository
+	Inventory ports.Inventory
+	// Payments charges the order; without it orders are placed unpaid.
+	Payments ports.PaymentGateway
+	// Keys remembers idempotency keys; without it they are ignored.
+	Keys ports.IdempotencyStore
+	// NewID names new orders; it defaults to random UUIDs.
+	NewID func() string
+	// Now stamps the order; it defaults to time.Now.
+	Now func() time.Time
+}
+
+// Execute places the order req asks for.
+func (c *PlaceOrderCommand) Execute(ctx context.Context, req Checkout) (*domain.Order, error) {
+	// 1. Check the cart before touching stock
+	if _, err := uuid.Parse(req.UserID); err != nil {
+		return nil, domain.InvalidField("user_id", "user id %q", req.UserID)
+	}
+	if err := domain.ValidateItems(req.Items); err != nil {
+		return nil, err
+	}
+	if c.Payments != nil && req.PaymentMethod == "" {
+		return nil, domain.InvalidField("payment_method", "no payment method")
+	}
+
+	// 2. A retried checkout carries on with the order its key named
+	id := newID(c.NewID)
+	if req.IdempotencyKey != "" && c.Keys != nil {
+		bound, err := c.Keys.Claim(ctx, req.IdempotencyKey, fingerprint(req), id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to claim idempotency key: %w", err)
+		}
+		id = bound
+		order, err := c.Orders.GetByID(ctx, id)
+		if err == nil {
+			return order, nil
+		}
+		if !errors.Is(err, domain.ErrOrderNotFound) {
+			return nil, fmt.Errorf("failed to look up order %s: %w", id, err)
+		}
+	}
+
+	// 3. Reserve; the catalog prices the lines
+	reservation, err := c.Inventory.Reserve(ctx, id, req.Items)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reserve stock for order %s: %w", id, err)
+	}
+
+	// 4. Build, authorize and store the order, handing the stock back on
+	// failure. An authorization left behind is never captured and lapses
+	// at the gateway.
+	order, err := domain.PlaceOrder(id, req.UserID, reservation, now(c.Now))
+	if err == nil && c.Payments != nil {
+		err = c.authorize(ctx, order, req.PaymentMethod)
+	}
+	if err == nil {
+		err = c.Orders.Save(ctx, order)
+	}
+	if errors.Is(err, domain.ErrOrderExists) {
+		// A concurrent retry stored it first; its reservation is ours
+		return c.Orders.GetByID(ctx, id)
+	}
+	if err != nil {
+		if relErr := c.Inventory.Release(context.WithoutCancel(ctx), reservation.ID); relErr != nil {
+			err = errors.Join(err, fmt.Errorf("release reservation %s: %w", reservation.ID, relErr))
+		}
+		return nil, fmt.Errorf("failed to place order %s: %w", id, err)
+	}
+	return order, nil
+}
+
+func (c *PlaceOrderCommand) authorize(ctx context.Context, order *domain.Order, method string) error {
+	authID, err := c.Payments.Authorize(ctx, domain.PaymentKey(order.ID, "authorize"), order.Total, method)
+	if err != nil {
+		return fmt.Errorf("authorize payment: %w", err)
+	}
+	return order.AuthorizePayment(authID, order.PlacedAt)
+}
+
+// fingerprint identifies a checkout's content, so a key reused for a
+// different cart is caught rather than answered with the wrong order.
+func fingerprint(req Checkout) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n%s\n", req.UserID, req.PaymentMethod)
+	for _, it := range req.Items {
+		fmt.Fprintf(h, "%s\t%d\n", it.SKU, it.Quantity)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// GetOrderQuery is a use case that fetches an order.
+type GetOrderQuery struct {
+	Orders ports.OrderRepository
+}
+
+// Execute returns the order with id, or domain.ErrOrderNotFound.
+func (q *GetOrderQuery) Execute(ctx context.Context, id string) (*domain.Order, error) {
+	order, err := q.Orders.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch order %s: %w", id, err)
+	}
+	return order, nil
+}
+
+func newID(gen func() string) string {
+	if gen == nil {
+		return uuid.NewString()
+	}
+	return gen()
+}
+
+func now(clock func() time.Time) time.Time {
+	if clock == nil {
+		return time.Now()
+	}
+	return clock()
+}
@@ -0,0 +1,55 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrOrderNotFound is returned by OrderRepository implementations when
+	// no order has the requested ID.
+	ErrOrderNotFound = errors.New("order not found")
+	// ErrOrderExists is returned by OrderRepository.Save for an ID that is
+	// already stored.
+	ErrOrderExists = errors.New("order already exists")
+	// ErrInvalidOrder is returned for an order that breaks an invariant,
+	// such as having no lines or a zero quantity.
+	ErrInvalidOrder = errors.New("invalid order")
+	// ErrInvalidMoney is returned for a negative amount or an unknown
+	// currency code.
+	ErrInvalidMoney     = errors.New("invalid money")
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+	// ErrOutOfStock is returned by Inventory implementations when a line
+	// cannot be reserved; nothing is reserved then.
+	ErrOutOfStock = errors.New("out of stock")
+	// ErrUnknownProduct is returned by Inventory implementations for a SKU
+	// the catalog does not sell.
+	ErrUnknownProduct = errors.New("unknown product")
+	// ErrPaymentDeclined is returned by PaymentGateway implementations when
+	// the payment method is refused; nothing is charged then.
+	ErrPaymentDeclined = errors.New("payment declined")
+	// ErrPaymentState is returned for a payment step out of turn, such as
+	// refunding a charge that was never captured.
+	ErrPaymentState = errors.New("invalid payment state")
+	// ErrIdempotencyKeyReused is returned when a checkout repeats an
+	// idempotency key with a different cart.
+	ErrIdempotencyKeyReused = errors.New("idempotency key reused with a different request")
+)
+
+// FieldError is an ErrInvalidOrder blamed on one field of the checkout,
+// named as in the API ("items[1].quantity"), so adapters can point the
+// client at it.
+type FieldError struct {
+	Field  string
+	Reason string
+}
+
+// InvalidField returns a FieldError for field, its reason formatted like
+// fmt.Sprintf.
+func InvalidField(field, format string, args ...any) error {
+	return &FieldError{Field: field, Reason: fmt.Sprintf(format, args...)}
+}
+
+func (e *FieldError) Error() string { return ErrInvalidOrder.Error() + ": " + e.Reason }
+
+func (e *FieldError) Unwrap() error { return ErrInvalidOrder }
@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"fmt"
+	"regexp"
+)
+
+var currencyPattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// Money is an amount in the minor unit of its currency (cents for USD),
+// so totals add up exactly. Amounts of different currencies never mix.
+type Money struct {
+	Amount   int64
+	Currency string
+}
+
+// NewMoney checks that currency is an ISO 4217 code and amount is not
+// negative.
+func NewMoney(amount int64, currency string) (Money, error) {
+	if !currencyPattern.MatchString(currency) {
+		return Money{}, fmt.Errorf("%w: currency %q", ErrInvalidMoney, currency)
+	}
+	if amount < 0 {
+		return Money{}, fmt.Errorf("%w: negative amount %d", ErrInvalidMoney, amount)
+	}
+	return Money{Amount: amount, Currency: currency}, nil
+}
+
+// Add sums m and o, which must share a currency.
+func (m Money) Add(o Money) (Money, error) {
+	if m.Currency != o.Currency {
+		return Money{}, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, o.Currency)
+	}
+	return Money{Amount: m.Amount + o.Amount, Currency: m.Currency}, nil
+}
+
+// Times multiplies m by a quantity.
+func (m Money) Times(n int) Money {
+	return Money{Amount: m.Amount * int64(n), Currency: m.Currency}
+}
+
+// String assumes two decimal places, as most currencies have.
+func (m Money) String() string {
+	return fmt.Sprintf("%d.%02d %s", m.Amount/100, m.Amount%100, m.Currency)
+}
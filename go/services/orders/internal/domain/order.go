@@ -0,0 +1,106 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// MaxQuantity bounds one line, so a typo cannot order a warehouse.
+const MaxQuantity = 999
+
+// Item asks for Quantity units of the product with SKU.
+type Item struct {
+	SKU      string
+	Quantity int
+}
+
+// ValidateItems checks a cart before anything is reserved: at least one
+// item, each SKU once, each quantity in [1, MaxQuantity].
+func ValidateItems(items []Item) error {
+	if len(items) == 0 {
+		return InvalidField("items", "no items")
+	}
+	seen := make(map[string]bool, len(items))
+	for i, it := range items {
+		switch {
+		case it.SKU == "":
+			return InvalidField(fmt.Sprintf("items[%d].sku", i), "item without a sku")
+		case seen[it.SKU]:
+			return InvalidField(fmt.Sprintf("items[%d].sku", i), "sku %s listed twice", it.SKU)
+		case it.Quantity < 1 || it.Quantity > MaxQuantity:
+			return InvalidField(fmt.Sprintf("items[%d].quantity", i), "quantity %d of %s is outside 1-%d", it.Quantity, it.SKU, MaxQuantity)
+		}
+		seen[it.SKU] = true
+	}
+	return nil
+}
+
+// Reservation is stock the catalog holds for one order, priced at the
+// moment it was reserved.
+type Reservation struct {
+	ID    string
+	Lines []LineItem
+}
+
+// LineItem is one product on an order, at the price it was sold for.
+type LineItem struct {
+	SKU       string
+	Quantity  int
+	UnitPrice Money
+}
+
+// Total is the line's unit price times its quantity.
+func (l LineItem) Total() Money {
+	return l.UnitPrice.Times(l.Quantity)
+}
+
+// OrderStatus is where an order is in its life.
+type OrderStatus string
+
+const (
+	// OrderPlaced orders have their stock reserved.
+	OrderPlaced OrderStatus = "placed"
+)
+
+// Order is the aggregate a checkout creates: one user's lines, the
+// reservation behind them and their total. Create it with PlaceOrder.
+type Order struct {
+	ID            string
+	UserID        string
+	Status        OrderStatus
+	Lines         []LineItem
+	Total         Money
+	ReservationID string
+	PlacedAt      time.Time
+	Payment       Payment
+}
+
+// PlaceOrder creates an order for userID from the stock r reserved. Every
+// line must be in one currency.
+func PlaceOrder(id, userID string, r Reservation, at time.Time) (*Order, error) {
+	if userID == "" {
+		return nil, fmt.Errorf("%w: no user", ErrInvalidOrder)
+	}
+	if len(r.Lines) == 0 {
+		return nil, fmt.Errorf("%w: no lines", ErrInvalidOrder)
+	}
+	total := Money{Currency: r.Lines[0].UnitPrice.Currency}
+	for _, l := range r.Lines {
+		if l.Quantity < 1 || l.UnitPrice.Amount < 0 {
+			return nil, fmt.Errorf("%w: line %s", ErrInvalidOrder, l.SKU)
+		}
+		var err error
+		if total, err = total.Add(l.Total()); err != nil {
+			return nil, err
+		}
+	}
+	return &Order{
+		ID:            id,
+		UserID:        userID,
+		Status:        OrderPlaced,
+		Lines:         append([]LineItem(nil), r.Lines...),
+		Total:         total,
+		ReservationID: r.ID,
+		PlacedAt:      at,
+	}, nil
+}
@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// PaymentStatus is where an order's payment is. Orders placed without a
+// payment gateway have none.
+type PaymentStatus string
+
+const (
+	PaymentNone       PaymentStatus = ""
+	PaymentAuthorized PaymentStatus = "authorized"
+	PaymentCaptured   PaymentStatus = "captured"
+	PaymentRefunded   PaymentStatus = "refunded"
+)
+
+// Payment is the charge behind an order: authorized at checkout, captured
+// when the order ships, refunded if it comes back.
+type Payment struct {
+	// AuthorizationID is the gateway's handle on the charge.
+	AuthorizationID string
+	Status          PaymentStatus
+	UpdatedAt       time.Time
+}
+
+// PaymentKey is the idempotency key of one gateway operation on an order.
+// It only depends on the order, so a retry sends the same key and the
+// gateway answers it without charging again.
+func PaymentKey(orderID, operation string) string {
+	return "order-" + orderID + "-" + operation
+}
+
+// AuthorizePayment records that the gateway holds the order's total.
+func (o *Order) AuthorizePayment(authorizationID string, at time.Time) error {
+	if o.Payment.Status != PaymentNone {
+		return fmt.Errorf("%w: cannot authorize a %s payment", ErrPaymentState, o.Payment.Status)
+	}
+	o.Payment = Payment{AuthorizationID: authorizationID, Status: PaymentAuthorized, UpdatedAt: at}
+	return nil
+}
+
+// CapturePayment records that the authorized amount was collected.
+func (o *Order) CapturePayment(at time.Time) error {
+	if o.Payment.Status != PaymentAuthorized {
+		return fmt.Errorf("%w: cannot capture a %q payment", ErrPaymentState, o.Payment.Status)
+	}
+	o.Payment.Status, o.Payment.UpdatedAt = PaymentCaptured, at
+	return nil
+}
+
+// RefundPayment records that the captured amount was paid back.
+func (o *Order) RefundPayment(at time.Time) error {
+	if o.Payment.Status != PaymentCaptured {
+		return fmt.Errorf("%w: cannot refund a %q payment", ErrPaymentState, o.Payment.Status)
+	}
+	o.Payment.Status, o.Payment.UpdatedAt = PaymentRefunded, at
+	return nil
+}
@@ -0,0 +1,16 @@
+package ports
+
+import (
+	"context"
+
+	"clean-code-cookbook/go/services/orders/internal/domain"
+)
+
+// EventPublisher is a port for telling other services what happened to
+// orders.
+type EventPublisher interface {
+	// OrderPlaced announces a stored order. It may announce the same
+	// order more than once, always under the same event ID, so receivers
+	// can drop the repeats.
+	OrderPlaced(ctx context.Context, o *domain.Order) error
+}
@@ -0,0 +1,19 @@
+package ports
+
+import (
+	"context"
+
+	"clean-code-cookbook/go/services/orders/internal/domain"
+)
+
+// Inventory is a port for the catalog's stock. Reserving is all or
+// nothing and prices each line, so the order is charged what the catalog
+// asked when the stock was taken.
+type Inventory interface {
+	// Reserve holds items for orderID, or returns domain.ErrOutOfStock or
+	// domain.ErrUnknownProduct and holds nothing. Reserving again for the
+	// same orderID returns the same reservation.
+	Reserve(ctx context.Context, orderID string, items []domain.Item) (domain.Reservation, error)
+	// Release gives a reservation's stock back; releasing twice is a no-op.
+	Release(ctx context.Context, reservationID string) error
+}
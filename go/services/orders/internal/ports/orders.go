@@ -0,0 +1,30 @@
+package ports
+
+import (
+	"context"
+
+	"clean-code-cookbook/go/services/orders/internal/domain"
+)
+
+// OrderRepository is a port for storing orders.
+type OrderRepository interface {
+	// Save stores a new order with its lines, all or nothing. It returns
+	// domain.ErrOrderExists if the ID is taken.
+	Save(ctx context.Context, o *domain.Order) error
+	// Update stores the status and payment of an order saved before, or
+	// returns domain.ErrOrderNotFound.
+	Update(ctx context.Context, o *domain.Order) error
+	// GetByID returns domain.ErrOrderNotFound for an unknown id.
+	GetByID(ctx context.Context, id string) (*domain.Order, error)
+}
+
+// OrderOutbox is the announcing side of the order store. A saved order is
+// pending until marked announced, so storing it also queues order.placed,
+// in the same write.
+type OrderOutbox interface {
+	// Unannounced returns up to limit pending orders, oldest first.
+	Unannounced(ctx context.Context, limit int) ([]*domain.Order, error)
+	// MarkAnnounced takes order id out of the pending ones, or returns
+	// domain.ErrOrderNotFound.
+	MarkAnnounced(ctx context.Context, id string) error
+}
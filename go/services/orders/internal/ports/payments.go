@@ -0,0 +1,30 @@
+package ports
+
+import (
+	"context"
+
+	"clean-code-cookbook/go/services/orders/internal/domain"
+)
+
+// PaymentGateway is a port for a card processor. Every call carries an
+// idempotency key, and the gateway answers a key it has seen before with
+// its first answer, so a retried call never charges twice.
+type PaymentGateway interface {
+	// Authorize holds amount on method and returns the gateway's handle on
+	// the charge, or domain.ErrPaymentDeclined.
+	Authorize(ctx context.Context, key string, amount domain.Money, method string) (authorizationID string, err error)
+	// Capture collects an authorized amount.
+	Capture(ctx context.Context, key, authorizationID string, amount domain.Money) error
+	// Refund pays a captured amount back.
+	Refund(ctx context.Context, key, authorizationID string, amount domain.Money) error
+}
+
+// IdempotencyStore persists the idempotency keys clients send with a
+// checkout, so a retried checkout finds the order it already started.
+type IdempotencyStore interface {
+	// Claim binds key to orderID and returns orderID. A key that is
+	// already bound returns the order it was bound to instead, or
+	// domain.ErrIdempotencyKeyReused if fingerprint differs from the
+	// request it was first used for.
+	Claim(ctx context.Context, key, fingerprint, orderID string) (string, error)
+}
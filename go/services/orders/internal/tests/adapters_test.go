@@ -0,0 +1,268 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"clean-code-cookbook/go/pkg/edgetoken"
+	"clean-code-cookbook/go/pkg/signing"
+	"clean-code-cookbook/go/services/orders/internal/adapter/catalog"
+	grpcadapter "clean-code-cookbook/go/services/orders/internal/adapter/grpc"
+	httpadapter "clean-code-cookbook/go/services/orders/internal/adapter/http"
+	"clean-code-cookbook/go/services/orders/internal/adapter/memory"
+	"clean-code-cookbook/go/services/orders/internal/adapter/webhook"
+	"clean-code-cookbook/go/services/orders/internal/app"
+	"clean-code-cookbook/go/services/orders/internal/domain"
+	pb "github.com/clean-code-coockbook/proto/gen/go/orders/v1"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func quietLogger() *log.Logger {
+	return log.New(io.Discard, "", 0)
+}
+
+func TestHTTPHandler_PlaceAndGetOrder(t *testing.T) {
+	// Arrange
+	orders := memory.NewOrderRepository()
+	mux := http.NewServeMux()
+	httpadapter.NewHandler(newPlaceOrder(orders, newInventory()), &app.GetOrderQuery{Orders: orders}, nil, nil, quietLogger()).Register(mux)
+	body := `{"user_id":"` + userID + `","items":[{"sku":"sku-1","quantity":2}]}`
+
+	// Act
+	placed := httptest.NewRecorder()
+	mux.ServeHTTP(placed, httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body)))
+	fetched := httptest.NewRecorder()
+	mux.ServeHTTP(fetched, httptest.NewRequest(http.MethodGet, placed.Header().Get("Location"), nil))
+	short := httptest.NewRecorder()
+	mux.ServeHTTP(short, httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(`{"user_id":"`+userID+`","items":[{"sku":"sku-2","quantity":9}]}`)))
+	missing := httptest.NewRecorder()
+	mux.ServeHTTP(missing, httptest.NewRequest(http.MethodGet, "/orders/nope", nil))
+
+	// Assert
+	if placed.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, but got %d: %s", http.StatusCreated, placed.Code, placed.Body)
+	}
+	var got struct {
+		Total struct {
+			Amount   int64  `json:"amount"`
+			Currency string `json:"currency"`
+		} `json:"total"`
+	}
+	if err := json.NewDecoder(fetched.Body).Decode(&got); err != nil || fetched.Code != http.StatusOK {
+		t.Fatalf("Expected the order back, but got %d, %v", fetched.Code, err)
+	}
+	if got.Total.Amount != 7998 || got.Total.Currency != "USD" {
+		t.Errorf("Expected a total of 79.98 USD, but got %+v", got.Total)
+	}
+	if short.Code != http.StatusConflict || missing.Code != http.StatusNotFound {
+		t.Errorf("Expected statuses 409 and 404, but got %d and %d", short.Code, missing.Code)
+	}
+}
+
+func TestCatalogInventory_ReservesOverHTTP(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/reservations":
+			var req struct {
+				OrderID string `json:"order_id"`
+				Items   []struct {
+					SKU      string `json:"sku"`
+					Quantity int    `json:"quantity"`
+				} `json:"items"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			switch req.Items[0].SKU {
+			case "sku-2":
+				http.Error(w, "out of stock: sku-2 has 0 left", http.StatusConflict)
+				return
+			case "sku-404":
+				http.Error(w, "product not found: sku-404", http.StatusUnprocessableEntity)
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id":"res-` + req.OrderID + `","lines":[{"sku":"sku-1","quantity":2,"unit_price":39.99}]}`))
+		case r.Method == http.MethodDelete && r.URL.Path == "/reservations/res-gone":
+			http.NotFound(w, r)
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+	inventory := catalog.NewInventory(server.URL, "USD", server.Client())
+
+	// Act
+	r, err := inventory.Reserve(context.Background(), "o-1", []domain.Item{{SKU: "sku-1", Quantity: 2}})
+	_, shortErr := inventory.Reserve(context.Background(), "o-2", []domain.Item{{SKU: "sku-2", Quantity: 1}})
+	_, unknownErr := inventory.Reserve(context.Background(), "o-3", []domain.Item{{SKU: "sku-404", Quantity: 1}})
+	releaseErr := inventory.Release(context.Background(), "res-gone")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if r.ID != "res-o-1" || len(r.Lines) != 1 || r.Lines[0].UnitPrice != usd(3999) {
+		t.Errorf("Expected sku-1 reserved at 39.99 USD, but got %+v", r)
+	}
+	if !errors.Is(shortErr, domain.ErrOutOfStock) {
+		t.Errorf("Expected error '%v', but got '%v'", domain.ErrOutOfStock, shortErr)
+	}
+	if !errors.Is(unknownErr, domain.ErrUnknownProduct) {
+		t.Errorf("Expected error '%v', but got '%v'", domain.ErrUnknownProduct, unknownErr)
+	}
+	if releaseErr != nil {
+		t.Errorf("Expected releasing a gone reservation to succeed, but got: %v", releaseErr)
+	}
+}
+
+func TestCatalogInventory_VouchesForItsCalls(t *testing.T) {
+	// Arrange: a catalog that, like the real one, only serves signed calls
+	signer := edgetoken.NewSigner([]byte("s3cret"))
+	var caller edgetoken.Caller
+	server := httptest.NewServer(signer.Middleware("catalog", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		caller, _ = edgetoken.FromContext(r.Context())
+		w.WriteHeader(http.StatusNoContent)
+	})))
+	defer server.Close()
+	unsigned := catalog.NewInventory(server.URL, "USD", server.Client())
+	signed := catalog.NewInventory(server.URL, "USD", server.Client())
+	signed.Identity = signer
+
+	// Act
+	unsignedErr := unsigned.Release(context.Background(), "res-1")
+	ctx := edgetoken.WithCaller(context.Background(), edgetoken.Caller{Subject: "user-1"})
+	signedErr := signed.Release(ctx, "res-1")
+
+	// Assert
+	if unsignedErr == nil || !strings.Contains(unsignedErr.Error(), "401") {
+		t.Errorf("Expected the catalog to refuse an unsigned call, but got: %v", unsignedErr)
+	}
+	if signedErr != nil {
+		t.Fatalf("Expected no error, but got: %v", signedErr)
+	}
+	if caller.Subject != "user-1" {
+		t.Errorf("Expected the call to speak for user-1, but got %+v", caller)
+	}
+}
+
+func TestWebhookPublisher_DeliversSignedOrderPlaced(t *testing.T) {
+	// Arrange: a receiver that, like reporting, only accepts signed events
+	keys := signing.NewKeyring(signing.Key{ID: "k1", Secret: []byte("s3cret")})
+	type delivery struct {
+		ID      string `json:"id"`
+		Type    string `json:"type"`
+		Version int    `json:"version"`
+		Payload struct {
+			OrderID string
+			UserID  string
+			Lines   []struct {
+				SKU       string
+				Quantity  int
+				UnitPrice struct {
+					Amount   int64
+					Currency string
+				}
+			}
+		} `json:"payload"`
+	}
+	var received []delivery
+	srv := httptest.NewServer(keys.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var d delivery
+		if err := json.NewDecoder(r.Body).Decode(&d); err != nil {
+			t.Errorf("Expected a JSON envelope, but got: %v", err)
+		}
+		received = append(received, d)
+		w.WriteHeader(http.StatusNoContent)
+	})))
+	defer srv.Close()
+	order := &domain.Order{ID: "o-1", UserID: userID, Lines: []domain.LineItem{{SKU: "sku-1", Quantity: 2, UnitPrice: usd(3999)}}, PlacedAt: placedAt}
+
+	// Act
+	err := webhook.NewPublisher([]string{srv.URL + "/events"}, keys).OrderPlaced(context.Background(), order)
+	againErr := webhook.NewPublisher([]string{srv.URL + "/events"}, keys).OrderPlaced(context.Background(), order)
+	unsignedErr := webhook.NewPublisher([]string{srv.URL + "/events"}, signing.NewKeyring(signing.Key{ID: "k1", Secret: []byte("wrong")})).OrderPlaced(context.Background(), order)
+
+	// Assert
+	if err != nil || againErr != nil {
+		t.Fatalf("Expected no errors, but got: %v, %v", err, againErr)
+	}
+	if unsignedErr == nil {
+		t.Error("Expected a delivery with the wrong key to be refused, but it was accepted")
+	}
+	if len(received) != 2 || received[0].ID != received[1].ID {
+		t.Fatalf("Expected two deliveries under one event ID, but got %+v", received)
+	}
+	got := received[0]
+	if got.Type != webhook.OrderPlacedEvent || got.Version != 1 || got.Payload.OrderID != "o-1" || got.Payload.UserID != userID {
+		t.Errorf("Unexpected envelope %+v", got)
+	}
+	if len(got.Payload.Lines) != 1 || got.Payload.Lines[0].SKU != "sku-1" || got.Payload.Lines[0].Quantity != 2 || got.Payload.Lines[0].UnitPrice.Amount != 3999 || got.Payload.Lines[0].UnitPrice.Currency != "USD" {
+		t.Errorf("Unexpected lines %+v", got.Payload.Lines)
+	}
+}
+
+func TestGRPCServer_MapsErrorsToCodes(t *testing.T) {
+	// Arrange
+	orders := memory.NewOrderRepository()
+	server := grpcadapter.NewServer(newPlaceOrder(orders, newInventory()), &app.GetOrderQuery{Orders: orders}, nil, nil, quietLogger())
+
+	// Act
+	placed, err := server.PlaceOrder(context.Background(), &pb.PlaceOrderRequest{UserId: userID, Items: []*pb.Item{{Sku: "sku-1", Quantity: 1}}})
+	_, invalidErr := server.PlaceOrder(context.Background(), &pb.PlaceOrderRequest{UserId: userID})
+	_, missingErr := server.GetOrder(context.Background(), &pb.GetOrderRequest{Id: "nope"})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if placed.GetOrder().GetTotal().GetAmount() != 3999 || placed.GetOrder().GetUserId() != userID {
+		t.Errorf("Unexpected order %+v", placed.GetOrder())
+	}
+	if status.Code(invalidErr) != codes.InvalidArgument || status.Code(missingErr) != codes.NotFound {
+		t.Errorf("Expected InvalidArgument and NotFound, but got %v and %v", status.Code(invalidErr), status.Code(missingErr))
+	}
+}
+
+func TestGRPCServer_AttachesErrorDetails(t *testing.T) {
+	// Arrange
+	orders := memory.NewOrderRepository()
+	server := grpcadapter.NewServer(newPlaceOrder(orders, newInventory()), &app.GetOrderQuery{Orders: orders}, nil, nil, quietLogger())
+
+	// Act
+	_, invalidErr := server.PlaceOrder(context.Background(), &pb.PlaceOrderRequest{UserId: userID, Items: []*pb.Item{{Sku: "sku-1", Quantity: 1}, {Sku: "sku-2", Quantity: 0}}})
+	_, shortErr := server.PlaceOrder(context.Background(), &pb.PlaceOrderRequest{UserId: userID, Items: []*pb.Item{{Sku: "sku-2", Quantity: 9}}})
+	_, missingErr := server.GetOrder(context.Background(), &pb.GetOrderRequest{Id: "nope"})
+
+	// Assert
+	for name, tc := range map[string]struct {
+		err    error
+		reason string
+		field  string
+	}{
+		"invalid": {invalidErr, "INVALID_ORDER", "items[1].quantity"},
+		"short":   {shortErr, "OUT_OF_STOCK", ""},
+		"missing": {missingErr, "ORDER_NOT_FOUND", ""},
+	} {
+		var reason, field string
+		for _, d := range status.Convert(tc.err).Details() {
+			switch d := d.(type) {
+			case *errdetails.ErrorInfo:
+				reason = d.GetReason()
+			case *errdetails.BadRequest:
+				field = d.GetFieldViolations()[0].GetField()
+			}
+		}
+		if reason != tc.reason || field != tc.field {
+			t.Errorf("%s: expected reason %q and field %q, but got %q and %q", name, tc.reason, tc.field, reason, field)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"clean-code-cookbook/go/services/orders/internal/adapter/memory"
+	"clean-code-cookbook/go/services/orders/internal/adapter/stripe"
+	"clean-code-cookbook/go/services/orders/internal/app"
+	"clean-code-cookbook/go/services/orders/internal/domain"
+)
+
+// newPaidCheckout wires PlaceOrderCommand to a fake gateway and a key
+// store.
+func newPaidCheckout(orders *memory.OrderRepository, inventory *memory.Inventory) (*app.PlaceOrderCommand, *memory.PaymentGateway) {
+	gateway := memory.NewPaymentGateway()
+	place := newPlaceOrder(orders, inventory)
+	place.Payments, place.Keys = gateway, memory.NewIdempotencyStore()
+	return place, gateway
+}
+
+func cart(method, key string) app.Checkout {
+	return app.Checkout{UserID: userID, Items: []domain.Item{{SKU: "sku-1", Quantity: 2}}, PaymentMethod: method, IdempotencyKey: key}
+}
+
+func TestPlaceOrderCommand_AuthorizesTheTotal(t *testing.T) {
+	// Arrange
+	place, _ := newPaidCheckout(memory.NewOrderRepository(), newInventory())
+
+	// Act
+	order, err := place.Execute(context.Background(), cart("pm_card_visa", ""))
+	_, noMethodErr := place.Execute(context.Background(), cart("", ""))
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if order.Payment.Status != domain.PaymentAuthorized || order.Payment.AuthorizationID == "" {
+		t.Errorf("Expected the payment authorized, but got %+v", order.Payment)
+	}
+	if !errors.Is(noMethodErr, domain.ErrInvalidOrder) {
+		t.Errorf("Expected error '%v', but got '%v'", domain.ErrInvalidOrder, noMethodErr)
+	}
+}
+
+func TestPlaceOrderCommand_DeclinedPaymentReleasesStock(t *testing.T) {
+	// Arrange
+	inventory := newInventory()
+	place, _ := newPaidCheckout(memory.NewOrderRepository(), inventory)
+
+	// Act
+	_, err := place.Execute(context.Background(), cart(memory.DeclinedMethod, ""))
+
+	// Assert
+	if !errors.Is(err, domain.ErrPaymentDeclined) {
+		t.Fatalf("Expected error '%v', but got '%v'", domain.ErrPaymentDeclined, err)
+	}
+	if inventory.Available("sku-1") != 5 {
+		t.Errorf("Expected the reservation released, but %d are left", inventory.Available("sku-1"))
+	}
+}
+
+func TestPlaceOrderCommand_RetriedKeyChargesOnce(t *testing.T) {
+	// Arrange: the first attempt authorizes, then fails to store the order
+	orders, inventory := memory.NewOrderRepository(), newInventory()
+	place, gateway := newPaidCheckout(orders, inventory)
+	place.Orders = failingOrders{orders}
+	_, failErr := place.Execute(context.Background(), cart("pm_card_visa", "checkout-1"))
+	place.Orders = orders
+
+	// Act
+	first, err := place.Execute(context.Background(), cart("pm_card_visa", "checkout-1"))
+	again, againErr := place.Execute(context.Background(), cart("pm_card_visa", "checkout-1"))
+	_, reusedErr := place.Execute(context.Background(), app.Checkout{UserID: userID, Items: []domain.Item{{SKU: "sku-2", Quantity: 1}}, PaymentMethod: "pm_card_visa", IdempotencyKey: "checkout-1"})
+	_, captureErr := (&app.CapturePaymentCommand{Orders: orders, Payments: gateway}).Execute(context.Background(), first.ID)
+
+	// Assert
+	if failErr == nil {
+		t.Fatal("Expected the first attempt to fail, but it succeeded")
+	}
+	if err != nil || againErr != nil || captureErr != nil {
+		t.Fatalf("Expected no errors, but got: %v, %v, %v", err, againErr, captureErr)
+	}
+	if again.ID != first.ID || again.Payment.AuthorizationID != first.Payment.AuthorizationID {
+		t.Errorf("Expected the retry to return order %s, but got %s", first.ID, again.ID)
+	}
+	if inventory.Available("sku-1") != 3 {
+		t.Errorf("Expected 2 reserved once, but %d are left", inventory.Available("sku-1"))
+	}
+	if charged := gateway.Charged(); len(charged) != 1 || charged[first.Payment.AuthorizationID] != usd(2*3999) {
+		t.Errorf("Expected one charge of 79.98 USD, but got %v", charged)
+	}
+	if !errors.Is(reusedErr, domain.ErrIdempotencyKeyReused) {
+		t.Errorf("Expected error '%v', but got '%v'", domain.ErrIdempotencyKeyReused, reusedErr)
+	}
+}
+
+func TestCaptureAndRefund(t *testing.T) {
+	// Arrange
+	orders := memory.NewOrderRepository()
+	place, gateway := newPaidCheckout(orders, newInventory())
+	capture := &app.CapturePaymentCommand{Orders: orders, Payments: gateway}
+	refund := &app.RefundPaymentCommand{Orders: orders, Payments: gateway}
+	order, err := place.Execute(context.Background(), cart("pm_card_visa", ""))
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+
+	// Act
+	_, earlyErr := refund.Execute(context.Background(), order.ID)
+	_, captureErr := capture.Execute(context.Background(), order.ID)
+	_, recaptureErr := capture.Execute(context.Background(), order.ID)
+	refunded, refundErr := refund.Execute(context.Background(), order.ID)
+	stored, _ := orders.GetByID(context.Background(), order.ID)
+
+	// Assert
+	if !errors.Is(earlyErr, domain.ErrPaymentState) {
+		t.Errorf("Expected error '%v', but got '%v'", domain.ErrPaymentState, earlyErr)
+	}
+	if captureErr != nil || recaptureErr != nil || refundErr != nil {
+		t.Fatalf("Expected no errors, but got: %v, %v, %v", captureErr, recaptureErr, refundErr)
+	}
+	if refunded.Payment.Status != domain.PaymentRefunded || stored.Payment.Status != domain.PaymentRefunded {
+		t.Errorf("Expected the payment refunded, but got %q and %q", refunded.Payment.Status, stored.Payment.Status)
+	}
+	if len(gateway.Charged()) != 0 {
+		t.Errorf("Expected nothing left charged, but got %v", gateway.Charged())
+	}
+}
+
+func TestStripeGateway_SendsIdempotencyKeys(t *testing.T) {
+	// Arrange
+	var keys []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		switch {
+		case r.Header.Get("Authorization") != "Bearer sk_test":
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.URL.Path == "/v1/payment_intents" && r.PostForm.Get("payment_method") == "pm_card_declined":
+			w.WriteHeader(http.StatusPaymentRequired)
+			_, _ = w.Write([]byte(`{"error":{"type":"card_error","code":"card_declined","message":"Your card was declined."}}`))
+		case r.URL.Path == "/v1/payment_intents" && r.PostForm.Get("amount") == "7998" && r.PostForm.Get("capture_method") == "manual":
+			_, _ = w.Write([]byte(`{"id":"pi_1","status":"requires_capture"}`))
+		case r.URL.Path == "/v1/payment_intents/pi_1/capture":
+			_, _ = w.Write([]byte(`{"id":"pi_1","status":"succeeded"}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+	gateway := stripe.NewGateway(server.URL, "sk_test", server.Client())
+
+	// Act
+	authID, err := gateway.Authorize(context.Background(), "k-auth", usd(7998), "pm_card_visa")
+	captureErr := gateway.Capture(context.Background(), "k-capture", authID, usd(7998))
+	_, declinedErr := gateway.Authorize(context.Background(), "k-declined", usd(7998), "pm_card_declined")
+
+	// Assert
+	if err != nil || captureErr != nil {
+		t.Fatalf("Expected no errors, but got: %v, %v", err, captureErr)
+	}
+	if authID != "pi_1" {
+		t.Errorf("Expected authorization pi_1, but got %q", authID)
+	}
+	if !errors.Is(declinedErr, domain.ErrPaymentDeclined) || !strings.Contains(declinedErr.Error(), "declined") {
+		t.Errorf("Expected error '%v', but got '%v'", domain.ErrPaymentDeclined, declinedErr)
+	}
+	if strings.Join(keys, ",") != "k-auth,k-capture,k-declined" {
+		t.Errorf("Expected each call to carry its key, but got %v", keys)
+	}
+}
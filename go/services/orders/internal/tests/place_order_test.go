@@ -0,0 +1,235 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"clean-code-cookbook/go/services/orders/internal/adapter/memory"
+	"clean-code-cookbook/go/services/orders/internal/app"
+	"clean-code-cookbook/go/services/orders/internal/domain"
+)
+
+const userID = "6f1c1f3e-2a44-4b55-8d1e-3c4b5a6f7e80"
+
+var placedAt = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func usd(cents int64) domain.Money {
+	return domain.Money{Amount: cents, Currency: "USD"}
+}
+
+func newInventory() *memory.Inventory {
+	return memory.NewInventory(map[string]memory.Stock{
+		"sku-1": {Price: usd(3999), Available: 5},
+		"sku-2": {Price: usd(4450), Available: 1},
+	})
+}
+
+// newPlaceOrder numbers its orders, so each gets its own reservation.
+func newPlaceOrder(orders *memory.OrderRepository, inventory *memory.Inventory) *app.PlaceOrderCommand {
+	n := 0
+	return &app.PlaceOrderCommand{
+		Orders:    orders,
+		Inventory: inventory,
+		NewID: func() string {
+			n++
+			return fmt.Sprintf("a3b8f0d2-7c1e-4f5a-9b6d-%012d", n)
+		},
+		Now: func() time.Time { return placedAt },
+	}
+}
+
+// failingOrders stands in for a database that is down.
+type failingOrders struct{ *memory.OrderRepository }
+
+func (failingOrders) Save(context.Context, *domain.Order) error {
+	return errors.New("connection refused")
+}
+
+// recordingEvents records the orders announced to it, failing while err
+// is set.
+type recordingEvents struct {
+	placed []string
+	err    error
+}
+
+func (r *recordingEvents) OrderPlaced(_ context.Context, o *domain.Order) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.placed = append(r.placed, o.ID)
+	return nil
+}
+
+func TestMoney_AddRejectsMixedCurrencies(t *testing.T) {
+	// Act
+	sum, sumErr := usd(150).Add(usd(250))
+	_, mixErr := usd(150).Add(domain.Money{Amount: 1, Currency: "EUR"})
+	_, badErr := domain.NewMoney(1, "usd")
+
+	// Assert
+	if sumErr != nil || sum != usd(400) {
+		t.Errorf("Expected 4.00 USD, but got %v, %v", sum, sumErr)
+	}
+	if !errors.Is(mixErr, domain.ErrCurrencyMismatch) {
+		t.Errorf("Expected error '%v', but got '%v'", domain.ErrCurrencyMismatch, mixErr)
+	}
+	if !errors.Is(badErr, domain.ErrInvalidMoney) {
+		t.Errorf("Expected error '%v', but got '%v'", domain.ErrInvalidMoney, badErr)
+	}
+}
+
+func TestValidateItems(t *testing.T) {
+	cases := []struct {
+		name  string
+		items []domain.Item
+		ok    bool
+	}{
+		{"one line", []domain.Item{{SKU: "sku-1", Quantity: 2}}, true},
+		{"empty cart", nil, false},
+		{"zero quantity", []domain.Item{{SKU: "sku-1"}}, false},
+		{"too many", []domain.Item{{SKU: "sku-1", Quantity: domain.MaxQuantity + 1}}, false},
+		{"duplicate sku", []domain.Item{{SKU: "sku-1", Quantity: 1}, {SKU: "sku-1", Quantity: 1}}, false},
+		{"missing sku", []domain.Item{{Quantity: 1}}, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Act
+			err := domain.ValidateItems(c.items)
+
+			// Assert
+			if c.ok && err != nil {
+				t.Errorf("Expected no error, but got: %v", err)
+			}
+			if !c.ok && !errors.Is(err, domain.ErrInvalidOrder) {
+				t.Errorf("Expected error '%v', but got '%v'", domain.ErrInvalidOrder, err)
+			}
+		})
+	}
+}
+
+func TestPlaceOrderCommand_ReservesAndTotals(t *testing.T) {
+	// Arrange
+	orders, inventory := memory.NewOrderRepository(), newInventory()
+	place := newPlaceOrder(orders, inventory)
+
+	// Act
+	order, err := place.Execute(context.Background(), app.Checkout{UserID: userID, Items: []domain.Item{{SKU: "sku-1", Quantity: 2}, {SKU: "sku-2", Quantity: 1}}})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if order.Total != usd(2*3999+4450) || order.Status != domain.OrderPlaced || order.UserID != userID || !order.PlacedAt.Equal(placedAt) {
+		t.Errorf("Unexpected order %+v", order)
+	}
+	if inventory.Available("sku-1") != 3 || inventory.Available("sku-2") != 0 {
+		t.Errorf("Expected 3 and 0 left, but got %d and %d", inventory.Available("sku-1"), inventory.Available("sku-2"))
+	}
+	stored, err := orders.GetByID(context.Background(), order.ID)
+	if err != nil || len(stored.Lines) != 2 || stored.Total != order.Total {
+		t.Errorf("Expected the order stored, but got %+v, %v", stored, err)
+	}
+}
+
+func TestPlaceOrderCommand_OutOfStockReservesNothing(t *testing.T) {
+	// Arrange
+	inventory := newInventory()
+	place := newPlaceOrder(memory.NewOrderRepository(), inventory)
+
+	// Act
+	_, err := place.Execute(context.Background(), app.Checkout{UserID: userID, Items: []domain.Item{{SKU: "sku-1", Quantity: 1}, {SKU: "sku-2", Quantity: 2}}})
+
+	// Assert
+	if !errors.Is(err, domain.ErrOutOfStock) {
+		t.Fatalf("Expected error '%v', but got '%v'", domain.ErrOutOfStock, err)
+	}
+	if inventory.Available("sku-1") != 5 {
+		t.Errorf("Expected sku-1 untouched, but %d are left", inventory.Available("sku-1"))
+	}
+}
+
+func TestPlaceOrderCommand_ReleasesStockWhenSaveFails(t *testing.T) {
+	// Arrange
+	inventory := newInventory()
+	place := newPlaceOrder(memory.NewOrderRepository(), inventory)
+	place.Orders = failingOrders{memory.NewOrderRepository()}
+
+	// Act
+	_, err := place.Execute(context.Background(), app.Checkout{UserID: userID, Items: []domain.Item{{SKU: "sku-1", Quantity: 2}}})
+
+	// Assert
+	if err == nil {
+		t.Fatal("Expected an error, but got none")
+	}
+	if inventory.Available("sku-1") != 5 {
+		t.Errorf("Expected the reservation released, but %d are left", inventory.Available("sku-1"))
+	}
+}
+
+func TestPlaceOrderCommand_RejectsBadInput(t *testing.T) {
+	// Arrange
+	place := newPlaceOrder(memory.NewOrderRepository(), newInventory())
+
+	// Act
+	_, userErr := place.Execute(context.Background(), app.Checkout{UserID: "not-a-uuid", Items: []domain.Item{{SKU: "sku-1", Quantity: 1}}})
+	_, skuErr := place.Execute(context.Background(), app.Checkout{UserID: userID, Items: []domain.Item{{SKU: "sku-404", Quantity: 1}}})
+
+	// Assert
+	if !errors.Is(userErr, domain.ErrInvalidOrder) {
+		t.Errorf("Expected error '%v', but got '%v'", domain.ErrInvalidOrder, userErr)
+	}
+	if !errors.Is(skuErr, domain.ErrUnknownProduct) {
+		t.Errorf("Expected error '%v', but got '%v'", domain.ErrUnknownProduct, skuErr)
+	}
+}
+
+func TestOrderAnnouncer_AnnouncesStoredOrdersOffTheCheckout(t *testing.T) {
+	// Arrange: receivers that are down while the order is placed
+	orders := memory.NewOrderRepository()
+	place, _ := newPaidCheckout(orders, newInventory())
+	events := &recordingEvents{err: errors.New("connection refused")}
+	announcer := &app.OrderAnnouncer{Outbox: orders, Events: events}
+
+	// Act
+	order, err := place.Execute(context.Background(), cart("pm_card_visa", "checkout-1"))
+	downCount, downErr := announcer.AnnounceBatch(context.Background())
+	events.err = nil
+	upCount, upErr := announcer.AnnounceBatch(context.Background())
+	againCount, againErr := announcer.AnnounceBatch(context.Background())
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected the checkout to succeed with the receivers down, but got: %v", err)
+	}
+	if err := errors.Join(downErr, upErr, againErr); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if downCount != 0 || upCount != 1 || againCount != 0 {
+		t.Errorf("Expected 0, 1 and 0 orders announced, but got %d, %d and %d", downCount, upCount, againCount)
+	}
+	if len(events.placed) != 1 || events.placed[0] != order.ID {
+		t.Errorf("Expected order %s announced once, but got %v", order.ID, events.placed)
+	}
+}
+
+func TestPlaceOrderCommand_RetryDoesNotQueueTheOrderTwice(t *testing.T) {
+	// Arrange
+	orders := memory.NewOrderRepository()
+	place, _ := newPaidCheckout(orders, newInventory())
+	first, firstErr := place.Execute(context.Background(), cart("pm_card_visa", "checkout-1"))
+
+	// Act
+	retry, err := place.Execute(context.Background(), cart("pm_card_visa", "checkout-1"))
+	pending, pendingErr := orders.Unannounced(context.Background(), 10)
+
+	// Assert
+	if err := errors.Join(firstErr, err, pendingErr); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if retry.ID != first.ID || len(pending) != 1 || pending[0].ID != first.ID {
+		t.Errorf("Expected order %s returned and pending once, but got %s and %d pending", first.ID, retry.ID, len(pending))
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"clean-code-cookbook/go/pkg/signing"
+	httpadapter "clean-code-cookbook/go/services/reporting/internal/adapter/http"
+	"clean-code-cookbook/go/services/reporting/internal/adapter/memory"
+	"clean-code-cookbook/go/services/reporting/internal/adapter/postgres"
+	"clean-code-cookbook/go/services/reporting/internal/app"
+	"clean-code-cookbook/go/services/reporting/internal/ports"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	logger := log.New(os.Stdout, "[reporting] ", log.LstdFlags)
+
+	// 1. The event log and read models live in Postgres
+	// (REPORTING_DATABASE_URL), or in memory for local runs.
+	var events ports.EventLog = memory.NewEventLog()
+	var models ports.ReadModels = memory.NewReadModels()
+	if dsn := os.Getenv("REPORTING_DATABASE_URL"); dsn != "" {
+		db, err := postgres.Open(ctx, dsn)
+		if err != nil {
+			logger.Fatal(err)
+		}
+		defer db.Close()
+		events, models = postgres.NewEventLog(db), postgres.NewReadModels(db)
+	}
+
+	// 2. Deliveries are verified with REPORTING_SIGNING_KEYS, the keys
+	// the producers sign their webhooks with (WEBHOOK_SIGNING_KEYS in
+	// clean_go_system, ORDERS_EVENTS_SIGNING_KEYS in orders). Only
+	// APP_ENV=dev may run without them.
+	var keyring *signing.Keyring
+	switch spec := os.Getenv("REPORTING_SIGNING_KEYS"); {
+	case spec != "":
+		keys, err := signing.ParseKeys(spec)
+		if err != nil {
+			logger.Fatalf("REPORTING_SIGNING_KEYS: %v", err)
+		}
+		keyring = signing.NewKeyring(keys...)
+	case os.Getenv("APP_ENV") == "dev":
+		logger.Println("REPORTING_SIGNING_KEYS not set; accepting unsigned events (APP_ENV=dev)")
+	default:
+		logger.Fatal("REPORTING_SIGNING_KEYS is required; set APP_ENV=dev to accept unsigned events locally")
+	}
+
+	// 3. Wire the use cases. A projection that stopped short of the log
+	// (a crash mid catch-up) carries on before serving.
+	projector := &app.Projector{Log: events, Models: models}
+	if err := projector.CatchUp(ctx); err != nil {
+		logger.Printf("catch up: %v", err)
+	}
+	handler := httpadapter.NewHandler(projector, &app.SignupsQuery{Models: models}, &app.RevenueQuery{Models: models}, keyring, logger)
+	// The /projections routes need REPORTING_ADMIN_TOKEN; APP_ENV=dev may
+	// leave it out, which turns them off.
+	switch handler.AdminToken = os.Getenv("REPORTING_ADMIN_TOKEN"); {
+	case handler.AdminToken != "":
+	case os.Getenv("APP_ENV") == "dev":
+		logger.Println("REPORTING_ADMIN_TOKEN not set; /projections is disabled (APP_ENV=dev)")
+	default:
+		logger.Fatal("REPORTING_ADMIN_TOKEN is required; set APP_ENV=dev to run without the admin routes locally")
+	}
+	mux := http.NewServeMux()
+	handler.Register(mux)
+
+	server := &http.Server{
+		Addr:              env("REPORTING_HTTP_ADDR", ":8085"),
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	// 4. Serve until interrupted, then drain
+	go func() {
+		logger.Printf("HTTP listening on %s", server.Addr)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Fatalf("http server: %v", err)
+		}
+	}()
+	<-ctx.Done()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		logger.Printf("shutdown: %v", err)
+	}
+	logger.Println("Done.")
+}
+
+func env(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
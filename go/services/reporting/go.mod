@@ -0,0 +1,10 @@
+module clean-code-cookbook/go/services/reporting
+
+go 1.22
+
+require (
+	clean-code-cookbook/go/pkg v0.0.0
+	github.com/lib/pq v1.10.9
+)
+
+replace clean-code-cookbook/go/pkg => ../../pkg
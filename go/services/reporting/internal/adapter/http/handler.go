@@ -0,0 +1,232 @@
+// Package httpadapter accepts events from other services and serves the
+// reports as a JSON API.
+package httpadapter
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"clean-code-cookbook/go/pkg/signing"
+	"clean-code-cookbook/go/services/reporting/internal/app"
+	"clean-code-cookbook/go/services/reporting/internal/domain"
+)
+
+// maxBody bounds one event delivery.
+const maxBody = 1 << 20
+
+// defaultDays is the period a report covers when the query names none.
+const defaultDays = 30
+
+// envelope is the wire format clean_go_system's eventcodec writes.
+type envelope struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	Version    int             `json:"version"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Payload    json.RawMessage `json:"payload"`
+}
+
+type signupsResponse struct {
+	Day   string `json:"day"`
+	Count int64  `json:"count"`
+}
+
+type revenueResponse struct {
+	SKU      string `json:"sku"`
+	Currency string `json:"currency"`
+	Units    int64  `json:"units"`
+	Orders   int64  `json:"orders"`
+	Amount   int64  `json:"amount"`
+}
+
+type statusResponse struct {
+	Projection string `json:"projection"`
+	Checkpoint int64  `json:"checkpoint"`
+	Lag        int64  `json:"lag"`
+}
+
+// Handler serves POST /events, GET /reports/signups, GET /reports/revenue,
+// GET /projections and POST /projections/{name}/rebuild.
+type Handler struct {
+	// AdminToken is the bearer token the /projections routes require.
+	// Without one they answer 403 to everyone.
+	AdminToken string
+
+	projector *app.Projector
+	signups   *app.SignupsQuery
+	revenue   *app.RevenueQuery
+	keys      *signing.Keyring
+	logger    *log.Logger
+}
+
+// NewHandler builds the handler. Deliveries to POST /events must be signed
+// with one of keys; a nil keyring accepts them unsigned, for local runs.
+func NewHandler(projector *app.Projector, signups *app.SignupsQuery, revenue *app.RevenueQuery, keys *signing.Keyring, logger *log.Logger) *Handler {
+	return &Handler{projector: projector, signups: signups, revenue: revenue, keys: keys, logger: logger}
+}
+
+// Register mounts the routes on mux.
+func (h *Handler) Register(mux *http.ServeMux) {
+	var events http.Handler = http.HandlerFunc(h.Record)
+	if h.keys != nil {
+		events = h.keys.Middleware(events)
+	}
+	mux.Handle("POST /events", events)
+	mux.HandleFunc("GET /reports/signups", h.Signups)
+	mux.HandleFunc("GET /reports/revenue", h.Revenue)
+	mux.HandleFunc("GET /projections", h.admin(h.Status))
+	mux.HandleFunc("POST /projections/{name}/rebuild", h.admin(h.Rebuild))
+}
+
+// Record handles POST /events. It answers 204 once the event is in the
+// log, and 503 when the sender should retry.
+func (h *Handler) Record(w http.ResponseWriter, r *http.Request) {
+	var env envelope
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBody)).Decode(&env); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	if env.ID == "" || env.Type == "" || len(env.Payload) == 0 {
+		http.Error(w, "an event needs an id, a type and a payload", http.StatusBadRequest)
+		return
+	}
+	err := h.projector.Record(r.Context(), domain.Event{
+		ID:         env.ID,
+		Type:       env.Type,
+		Version:    env.Version,
+		OccurredAt: env.OccurredAt,
+		Payload:    env.Payload,
+	})
+	switch {
+	case errors.Is(err, domain.ErrMalformedEvent), errors.Is(err, domain.ErrUnsupportedVersion):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	case err != nil:
+		h.logger.Printf("http: record %s %s: %v", env.Type, env.ID, err)
+		http.Error(w, "try again later", http.StatusServiceUnavailable)
+	default:
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// Signups handles GET /reports/signups?from=2024-01-01&to=2024-01-31.
+func (h *Handler) Signups(w http.ResponseWriter, r *http.Request) {
+	from, to, ok := period(w, r)
+	if !ok {
+		return
+	}
+	days, err := h.signups.Execute(r.Context(), from, to)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	resp := make([]signupsResponse, 0, len(days))
+	for _, d := range days {
+		resp = append(resp, signupsResponse{Day: d.Day.Format(time.DateOnly), Count: d.Count})
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// Revenue handles GET /reports/revenue?from=2024-01-01&to=2024-01-31.
+func (h *Handler) Revenue(w http.ResponseWriter, r *http.Request) {
+	from, to, ok := period(w, r)
+	if !ok {
+		return
+	}
+	products, err := h.revenue.Execute(r.Context(), from, to)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	resp := make([]revenueResponse, 0, len(products))
+	for _, p := range products {
+		resp = append(resp, revenueResponse{SKU: p.SKU, Currency: p.Currency, Units: p.Units, Orders: p.Orders, Amount: p.Amount})
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// Status handles GET /projections.
+func (h *Handler) Status(w http.ResponseWriter, r *http.Request) {
+	statuses, err := h.projector.Status(r.Context())
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	resp := make([]statusResponse, 0, len(statuses))
+	for _, s := range statuses {
+		resp = append(resp, statusResponse{Projection: s.Projection, Checkpoint: s.Checkpoint, Lag: s.Lag})
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// Rebuild handles POST /projections/{name}/rebuild and answers 204 once
+// the read model has replayed the whole log.
+func (h *Handler) Rebuild(w http.ResponseWriter, r *http.Request) {
+	if err := h.projector.Rebuild(r.Context(), r.PathValue("name")); err != nil {
+		h.writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// admin guards next with AdminToken, and shuts it off without one.
+func (h *Handler) admin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if h.AdminToken == "" {
+			http.Error(w, "admin routes are disabled", http.StatusForbidden)
+			return
+		}
+		got := []byte(r.Header.Get("Authorization"))
+		if subtle.ConstantTimeCompare(got, []byte("Bearer "+h.AdminToken)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+func (h *Handler) writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidRange):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, domain.ErrUnknownProjection):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		h.logger.Printf("http: %v", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
+
+// period reads ?from= and ?to= as dates; to defaults to today and from to
+// defaultDays before to.
+func period(w http.ResponseWriter, r *http.Request) (from, to time.Time, ok bool) {
+	to = time.Now().UTC()
+	if v := r.URL.Query().Get("to"); v != "" {
+		t, err := time.Parse(time.DateOnly, v)
+		if err != nil {
+			http.Error(w, "to must be a date like 2024-01-31", http.StatusBadRequest)
+			return time.Time{}, time.Time{}, false
+		}
+		to = t
+	}
+	from = to.AddDate(0, 0, 1-defaultDays)
+	if v := r.URL.Query().Get("from"); v != "" {
+		t, err := time.Parse(time.DateOnly, v)
+		if err != nil {
+			http.Error(w, "from must be a date like 2024-01-01", http.StatusBadRequest)
+			return time.Time{}, time.Time{}, false
+		}
+		from = t
+	}
+	return from, to, true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
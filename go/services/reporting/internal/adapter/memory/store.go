@@ -0,0 +1,158 @@
+// Package memory keeps the event log and read models in process, for tests
+// and local runs.
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"clean-code-cookbook/go/services/reporting/internal/domain"
+)
+
+// EventLog implements ports.EventLog.
+type EventLog struct {
+	mu     sync.Mutex
+	events []domain.Event
+	ids    map[string]bool
+}
+
+func NewEventLog() *EventLog {
+	return &EventLog{ids: make(map[string]bool)}
+}
+
+func (l *EventLog) Append(ctx context.Context, e domain.Event) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.ids[e.ID] {
+		return false, nil
+	}
+	e.Seq = int64(len(l.events)) + 1
+	l.events = append(l.events, e)
+	l.ids[e.ID] = true
+	return true, nil
+}
+
+func (l *EventLog) Read(ctx context.Context, after int64, limit int) ([]domain.Event, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	// Seq n is at index n-1
+	if after >= int64(len(l.events)) {
+		return nil, nil
+	}
+	end := min(int(after)+limit, len(l.events))
+	return append([]domain.Event(nil), l.events[after:end]...), nil
+}
+
+func (l *EventLog) Head(ctx context.Context) (int64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return int64(len(l.events)), nil
+}
+
+type revenueKey struct {
+	day      time.Time
+	sku      string
+	currency string
+}
+
+// ReadModels implements ports.ReadModels.
+type ReadModels struct {
+	mu          sync.Mutex
+	checkpoints map[string]int64
+	signups     map[time.Time]int64
+	revenue     map[revenueKey]domain.ProductRevenue
+}
+
+func NewReadModels() *ReadModels {
+	return &ReadModels{
+		checkpoints: make(map[string]int64),
+		signups:     make(map[time.Time]int64),
+		revenue:     make(map[revenueKey]domain.ProductRevenue),
+	}
+}
+
+func (m *ReadModels) Checkpoint(ctx context.Context, projection string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.checkpoints[projection], nil
+}
+
+func (m *ReadModels) Apply(ctx context.Context, projection string, from, seq int64, d domain.Delta) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.checkpoints[projection] != from {
+		return domain.ErrCheckpointMoved
+	}
+	for _, s := range d.Signups {
+		m.signups[s.Day] += s.Count
+	}
+	for _, r := range d.Revenue {
+		k := revenueKey{day: r.Day, sku: r.SKU, currency: r.Currency}
+		sum := m.revenue[k]
+		sum.SKU, sum.Currency = r.SKU, r.Currency
+		sum.Units += r.Units
+		sum.Orders += r.Orders
+		sum.Amount += r.Amount
+		m.revenue[k] = sum
+	}
+	m.checkpoints[projection] = seq
+	return nil
+}
+
+func (m *ReadModels) Reset(ctx context.Context, projection string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	switch projection {
+	case domain.DailySignupsProjection:
+		clear(m.signups)
+	case domain.ProductRevenueProjection:
+		clear(m.revenue)
+	default:
+		return domain.ErrUnknownProjection
+	}
+	m.checkpoints[projection] = 0
+	return nil
+}
+
+func (m *ReadModels) DailySignups(ctx context.Context, p domain.Period) ([]domain.DailySignups, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []domain.DailySignups
+	for day, n := range m.signups {
+		if !day.Before(p.From) && !day.After(p.To) {
+			out = append(out, domain.DailySignups{Day: day, Count: n})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Day.Before(out[j].Day) })
+	return out, nil
+}
+
+func (m *ReadModels) RevenueByProduct(ctx context.Context, p domain.Period) ([]domain.ProductRevenue, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	sums := make(map[[2]string]domain.ProductRevenue)
+	for k, r := range m.revenue {
+		if k.day.Before(p.From) || k.day.After(p.To) {
+			continue
+		}
+		sum := sums[[2]string{k.sku, k.currency}]
+		sum.SKU, sum.Currency = r.SKU, r.Currency
+		sum.Units += r.Units
+		sum.Orders += r.Orders
+		sum.Amount += r.Amount
+		sums[[2]string{k.sku, k.currency}] = sum
+	}
+	out := make([]domain.ProductRevenue, 0, len(sums))
+	for _, r := range sums {
+		out = append(out, r)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Amount != out[j].Amount {
+			return out[i].Amount > out[j].Amount
+		}
+		return out[i].SKU < out[j].SKU
+	})
+	return out, nil
+}
@@ -0,0 +1,271 @@
+// Package postgres keeps the event log and read models in PostgreSQL
+// through database/sql and the lib/pq driver.
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"clean-code-cookbook/go/services/reporting/internal/domain"
+	_ "github.com/lib/pq"
+)
+
+// schema: the read tables are keyed by day, so a report over any period
+// is a range scan, and a rebuild only has to truncate them.
+const schema = `
+CREATE TABLE IF NOT EXISTS event_log (
+    seq         BIGSERIAL PRIMARY KEY,
+    id          TEXT NOT NULL UNIQUE,
+    type        TEXT NOT NULL,
+    version     INT NOT NULL,
+    occurred_at TIMESTAMPTZ NOT NULL,
+    payload     JSONB NOT NULL,
+    received_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE TABLE IF NOT EXISTS projection_checkpoints (
+    projection TEXT PRIMARY KEY,
+    seq        BIGINT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS daily_signups (
+    day     DATE PRIMARY KEY,
+    signups BIGINT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS product_revenue (
+    day      DATE NOT NULL,
+    sku      TEXT NOT NULL,
+    currency CHAR(3) NOT NULL,
+    units    BIGINT NOT NULL,
+    orders   BIGINT NOT NULL,
+    amount   BIGINT NOT NULL,
+    PRIMARY KEY (day, sku, currency)
+);`
+
+// appendLock serializes appends. Sequence values are handed out before
+// commit, so two concurrent appends could commit out of Seq order and a
+// catch-up that already read the higher Seq would never see the lower.
+const appendLock = 7_402_001
+
+// Open connects to dsn and creates the schema if it is missing.
+func Open(ctx context.Context, dsn string) (*sql.DB, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open postgres: %w", err)
+	}
+	if _, err := db.ExecContext(ctx, schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("migrate reporting: %w", err)
+	}
+	return db, nil
+}
+
+// EventLog implements ports.EventLog on the event_log table.
+type EventLog struct {
+	db *sql.DB
+}
+
+func NewEventLog(db *sql.DB) *EventLog {
+	return &EventLog{db: db}
+}
+
+func (l *EventLog) Append(ctx context.Context, e domain.Event) (appended bool, err error) {
+	tx, err := l.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if _, err = tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, appendLock); err != nil {
+		return false, fmt.Errorf("lock event log: %w", err)
+	}
+	res, err := tx.ExecContext(ctx, `INSERT INTO event_log (id, type, version, occurred_at, payload)
+		VALUES ($1, $2, $3, $4, $5) ON CONFLICT (id) DO NOTHING`,
+		e.ID, e.Type, e.Version, e.OccurredAt, []byte(e.Payload))
+	if err != nil {
+		return false, fmt.Errorf("insert event: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, tx.Commit()
+}
+
+func (l *EventLog) Read(ctx context.Context, after int64, limit int) ([]domain.Event, error) {
+	rows, err := l.db.QueryContext(ctx, `SELECT seq, id, type, version, occurred_at, payload FROM event_log
+		WHERE seq > $1 ORDER BY seq LIMIT $2`, after, limit)
+	if err != nil {
+		return nil, fmt.Errorf("read event log: %w", err)
+	}
+	defer rows.Close()
+	var out []domain.Event
+	for rows.Next() {
+		var e domain.Event
+		var payload []byte
+		if err := rows.Scan(&e.Seq, &e.ID, &e.Type, &e.Version, &e.OccurredAt, &payload); err != nil {
+			return nil, err
+		}
+		e.Payload = payload
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+func (l *EventLog) Head(ctx context.Context) (int64, error) {
+	var head int64
+	err := l.db.QueryRowContext(ctx, `SELECT COALESCE(MAX(seq), 0) FROM event_log`).Scan(&head)
+	return head, err
+}
+
+// tables names the read tables of each projection.
+var tables = map[string]string{
+	domain.DailySignupsProjection:   "daily_signups",
+	domain.ProductRevenueProjection: "product_revenue",
+}
+
+// ReadModels implements ports.ReadModels. The checkpoint row is locked for
+// the length of each change, so a change and its checkpoint move together.
+type ReadModels struct {
+	db *sql.DB
+}
+
+func NewReadModels(db *sql.DB) *ReadModels {
+	return &ReadModels{db: db}
+}
+
+func (m *ReadModels) Checkpoint(ctx context.Context, projection string) (int64, error) {
+	var seq int64
+	err := m.db.QueryRowContext(ctx, `SELECT seq FROM projection_checkpoints WHERE projection = $1`, projection).Scan(&seq)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return seq, err
+}
+
+func (m *ReadModels) Apply(ctx context.Context, projection string, from, seq int64, d domain.Delta) (err error) {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	current, err := lockCheckpoint(ctx, tx, projection)
+	if err != nil {
+		return err
+	}
+	if current != from {
+		return domain.ErrCheckpointMoved
+	}
+	for _, s := range d.Signups {
+		_, err = tx.ExecContext(ctx, `INSERT INTO daily_signups (day, signups) VALUES ($1, $2)
+			ON CONFLICT (day) DO UPDATE SET signups = daily_signups.signups + EXCLUDED.signups`, s.Day, s.Count)
+		if err != nil {
+			return fmt.Errorf("add signups: %w", err)
+		}
+	}
+	for _, r := range d.Revenue {
+		_, err = tx.ExecContext(ctx, `INSERT INTO product_revenue (day, sku, currency, units, orders, amount)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (day, sku, currency) DO UPDATE SET
+				units = product_revenue.units + EXCLUDED.units,
+				orders = product_revenue.orders + EXCLUDED.orders,
+				amount = product_revenue.amount + EXCLUDED.amount`,
+			r.Day, r.SKU, r.Currency, r.Units, r.Orders, r.Amount)
+		if err != nil {
+			return fmt.Errorf("add revenue of %s: %w", r.SKU, err)
+		}
+	}
+	if _, err = tx.ExecContext(ctx, `UPDATE projection_checkpoints SET seq = $2 WHERE projection = $1`, projection, seq); err != nil {
+		return fmt.Errorf("move checkpoint: %w", err)
+	}
+	return tx.Commit()
+}
+
+func (m *ReadModels) Reset(ctx context.Context, projection string) (err error) {
+	table, ok := tables[projection]
+	if !ok {
+		return domain.ErrUnknownProjection
+	}
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if _, err = lockCheckpoint(ctx, tx, projection); err != nil {
+		return err
+	}
+	// DELETE rather than TRUNCATE: it waits only for the checkpoint lock
+	// already held, not for readers of the table.
+	if _, err = tx.ExecContext(ctx, `DELETE FROM `+table); err != nil {
+		return fmt.Errorf("empty %s: %w", table, err)
+	}
+	if _, err = tx.ExecContext(ctx, `UPDATE projection_checkpoints SET seq = 0 WHERE projection = $1`, projection); err != nil {
+		return fmt.Errorf("reset checkpoint: %w", err)
+	}
+	return tx.Commit()
+}
+
+// lockCheckpoint creates projection's checkpoint row if needed, then locks
+// it until tx ends and returns its seq.
+func lockCheckpoint(ctx context.Context, tx *sql.Tx, projection string) (int64, error) {
+	if _, err := tx.ExecContext(ctx, `INSERT INTO projection_checkpoints (projection, seq) VALUES ($1, 0)
+		ON CONFLICT (projection) DO NOTHING`, projection); err != nil {
+		return 0, fmt.Errorf("create checkpoint: %w", err)
+	}
+	var seq int64
+	err := tx.QueryRowContext(ctx, `SELECT seq FROM projection_checkpoints WHERE projection = $1 FOR UPDATE`, projection).Scan(&seq)
+	if err != nil {
+		return 0, fmt.Errorf("lock checkpoint: %w", err)
+	}
+	return seq, nil
+}
+
+func (m *ReadModels) DailySignups(ctx context.Context, p domain.Period) ([]domain.DailySignups, error) {
+	rows, err := m.db.QueryContext(ctx, `SELECT day, signups FROM daily_signups
+		WHERE day BETWEEN $1 AND $2 ORDER BY day`, p.From, p.To)
+	if err != nil {
+		return nil, fmt.Errorf("query daily signups: %w", err)
+	}
+	defer rows.Close()
+	var out []domain.DailySignups
+	for rows.Next() {
+		var s domain.DailySignups
+		if err := rows.Scan(&s.Day, &s.Count); err != nil {
+			return nil, err
+		}
+		s.Day = domain.Day(s.Day)
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
+
+func (m *ReadModels) RevenueByProduct(ctx context.Context, p domain.Period) ([]domain.ProductRevenue, error) {
+	rows, err := m.db.QueryContext(ctx, `SELECT sku, currency, SUM(units), SUM(orders), SUM(amount) FROM product_revenue
+		WHERE day BETWEEN $1 AND $2 GROUP BY sku, currency ORDER BY SUM(amount) DESC, sku`, p.From, p.To)
+	if err != nil {
+		return nil, fmt.Errorf("query revenue by product: %w", err)
+	}
+	defer rows.Close()
+	var out []domain.ProductRevenue
+	for rows.Next() {
+		var r domain.ProductRevenue
+		if err := rows.Scan(&r.SKU, &r.Currency, &r.Units, &r.Orders, &r.Amount); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,151 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"clean-code-cookbook/go/services/reporting/internal/domain"
+	"clean-code-cookbook/go/services/reporting/internal/ports"
+)
+
+// defaultBatch is how many events a catch-up reads at a time.
+const defaultBatch = 500
+
+// Projector keeps the read models up to date with the event log. Events
+// are first appended to the log, then every projection catches up from its
+// own checkpoint, so a crash between the two loses nothing: the next event
+// or the next start picks up where the projection stopped.
+type Projector struct {
+	Log    ports.EventLog
+	Models ports.ReadModels
+	// Projections defaults to domain.Projections.
+	Projections []domain.Projection
+	// Batch is how many events are read at a time; it defaults to 500.
+	Batch int
+}
+
+// Record accepts an event from another service. Events the projections
+// cannot read are refused before they reach the log, where they would
+// stall every rebuild; a redelivered event is accepted and ignored.
+func (p *Projector) Record(ctx context.Context, e domain.Event) error {
+	if err := e.CheckVersion(); err != nil {
+		return err
+	}
+	for _, proj := range p.projections() {
+		if _, err := proj.Project(e); err != nil {
+			return err
+		}
+	}
+	if _, err := p.Log.Append(ctx, e); err != nil {
+		return fmt.Errorf("failed to append event %s: %w", e.ID, err)
+	}
+	return p.CatchUp(ctx)
+}
+
+// CatchUp applies every logged event each projection has not seen yet.
+func (p *Projector) CatchUp(ctx context.Context) error {
+	var errs []error
+	for _, proj := range p.projections() {
+		if err := p.catchUp(ctx, proj); err != nil {
+			errs = append(errs, fmt.Errorf("projection %s: %w", proj.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Rebuild empties the read model called name and replays the whole log
+// into it. Queries see a partial model until it finishes.
+func (p *Projector) Rebuild(ctx context.Context, name string) error {
+	proj, err := p.find(name)
+	if err != nil {
+		return err
+	}
+	if err := p.Models.Reset(ctx, proj.Name); err != nil {
+		return fmt.Errorf("failed to reset projection %s: %w", proj.Name, err)
+	}
+	if err := p.catchUp(ctx, proj); err != nil {
+		return fmt.Errorf("failed to rebuild projection %s: %w", proj.Name, err)
+	}
+	return nil
+}
+
+// Status is how far one projection is behind the log.
+type Status struct {
+	Projection string
+	Checkpoint int64
+	Lag        int64
+}
+
+// Status reports every projection's checkpoint against the log's head.
+func (p *Projector) Status(ctx context.Context) ([]Status, error) {
+	head, err := p.Log.Head(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read log head: %w", err)
+	}
+	out := make([]Status, 0, len(p.projections()))
+	for _, proj := range p.projections() {
+		cp, err := p.Models.Checkpoint(ctx, proj.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read checkpoint of %s: %w", proj.Name, err)
+		}
+		out = append(out, Status{Projection: proj.Name, Checkpoint: cp, Lag: head - cp})
+	}
+	return out, nil
+}
+
+// catchUp applies events one at a time, each with the checkpoint it
+// moves. If another run moved the checkpoint first (a concurrent catch-up,
+// or a rebuild that reset it), this one stops and leaves the work to it.
+func (p *Projector) catchUp(ctx context.Context, proj domain.Projection) error {
+	cp, err := p.Models.Checkpoint(ctx, proj.Name)
+	if err != nil {
+		return err
+	}
+	for {
+		events, err := p.Log.Read(ctx, cp, p.batch())
+		if err != nil {
+			return err
+		}
+		for _, e := range events {
+			d, err := proj.Project(e)
+			if err != nil {
+				return fmt.Errorf("event %d: %w", e.Seq, err)
+			}
+			err = p.Models.Apply(ctx, proj.Name, cp, e.Seq, d)
+			if errors.Is(err, domain.ErrCheckpointMoved) {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("event %d: %w", e.Seq, err)
+			}
+			cp = e.Seq
+		}
+		if len(events) < p.batch() {
+			return nil
+		}
+	}
+}
+
+func (p *Projector) find(name string) (domain.Projection, error) {
+	for _, proj := range p.projections() {
+		if proj.Name == name {
+			return proj, nil
+		}
+	}
+	return domain.Projection{}, fmt.Errorf("%w: %q", domain.ErrUnknownProjection, name)
+}
+
+func (p *Projector) projections() []domain.Projection {
+	if p.Projections == nil {
+		return domain.Projections
+	}
+	return p.Projections
+}
+
+func (p *Projector) batch() int {
+	if p.Batch <= 0 {
+		return defaultBatch
+	}
+	return p.Batch
+}
@@ -0,0 +1,55 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"clean-code-cookbook/go/services/reporting/internal/domain"
+	"clean-code-cookbook/go/services/reporting/internal/ports"
+)
+
+// SignupsQuery reports registrations per day.
+type SignupsQuery struct {
+	Models ports.ReadModels
+}
+
+// Execute returns one entry per day from from to to, days without signups
+// included as zero, so the result charts without gaps.
+func (q *SignupsQuery) Execute(ctx context.Context, from, to time.Time) ([]domain.DailySignups, error) {
+	period, err := domain.NewPeriod(from, to)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := q.Models.DailySignups(ctx, period)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read daily signups: %w", err)
+	}
+	counts := make(map[time.Time]int64, len(rows))
+	for _, r := range rows {
+		counts[r.Day] = r.Count
+	}
+	out := make([]domain.DailySignups, 0, period.Days())
+	for day := period.From; !day.After(period.To); day = day.AddDate(0, 0, 1) {
+		out = append(out, domain.DailySignups{Day: day, Count: counts[day]})
+	}
+	return out, nil
+}
+
+// RevenueQuery reports revenue per product.
+type RevenueQuery struct {
+	Models ports.ReadModels
+}
+
+// Execute returns each product's revenue from from to to, highest first.
+func (q *RevenueQuery) Execute(ctx context.Context, from, to time.Time) ([]domain.ProductRevenue, error) {
+	period, err := domain.NewPeriod(from, to)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := q.Models.RevenueByProduct(ctx, period)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read revenue by product: %w", err)
+	}
+	return rows, nil
+}
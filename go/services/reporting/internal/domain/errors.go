@@ -0,0 +1,17 @@
+package domain
+
+import "errors"
+
+var (
+	// ErrMalformedEvent means an event reporting reads could not be decoded.
+	ErrMalformedEvent = errors.New("malformed event")
+	// ErrUnsupportedVersion means an event's schema is newer than we read.
+	ErrUnsupportedVersion = errors.New("unsupported event version")
+	// ErrUnknownProjection means no projection has the given name.
+	ErrUnknownProjection = errors.New("unknown projection")
+	// ErrCheckpointMoved means another run advanced or reset a projection
+	// first; the caller should stop and leave it to that run.
+	ErrCheckpointMoved = errors.New("projection checkpoint moved")
+	// ErrInvalidRange means a report was asked for an impossible period.
+	ErrInvalidRange = errors.New("invalid date range")
+)
@@ -0,0 +1,78 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Event types reporting reads. Any other type is kept in the log but
+// projects to nothing.
+const (
+	UserRegisteredEvent = "user.registered"
+	OrderPlacedEvent    = "order.placed"
+)
+
+// supportedVersions is the newest schema version of each event we read.
+var supportedVersions = map[string]int{
+	UserRegisteredEvent: 1,
+	OrderPlacedEvent:    1,
+}
+
+// Event is one entry of the event log: the envelope another service sent,
+// plus Seq, its position in the log. Seq only grows, so a projection's
+// progress is the Seq of the last event it applied.
+type Event struct {
+	Seq        int64
+	ID         string
+	Type       string
+	Version    int
+	OccurredAt time.Time
+	Payload    json.RawMessage
+}
+
+// UserRegistered is the user.registered payload clean_go_system publishes.
+// Only the fields reporting needs are decoded.
+type UserRegistered struct {
+	UserID string
+	At     time.Time
+}
+
+// OrderPlaced is the order.placed payload: the lines of a new order at the
+// prices they were sold for.
+type OrderPlaced struct {
+	OrderID  string
+	UserID   string
+	Lines    []OrderLine
+	PlacedAt time.Time
+}
+
+// OrderLine is one product on an order. UnitPrice is in minor units.
+type OrderLine struct {
+	SKU       string
+	Quantity  int64
+	UnitPrice Money
+}
+
+// Money is an amount in the minor unit of its currency.
+type Money struct {
+	Amount   int64
+	Currency string
+}
+
+// CheckVersion rejects events whose schema is newer than this service
+// reads, so they are retried after an upgrade instead of misread now.
+func (e Event) CheckVersion() error {
+	if v, ok := supportedVersions[e.Type]; ok && e.Version > v {
+		return fmt.Errorf("%w: %s version %d, newest is %d", ErrUnsupportedVersion, e.Type, e.Version, v)
+	}
+	return nil
+}
+
+// decode unmarshals e's payload into into.
+func (e Event) decode(into any) error {
+	if err := json.Unmarshal(e.Payload, into); err != nil {
+		return fmt.Errorf("%w: %s %s: %v", ErrMalformedEvent, e.Type, e.ID, err)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package domain
+
+import "fmt"
+
+// Projection names; they also name the read tables behind them.
+const (
+	DailySignupsProjection   = "daily_signups"
+	ProductRevenueProjection = "product_revenue"
+)
+
+// Delta is what one event adds to the read models. Applying the same delta
+// twice counts the event twice, so stores apply it together with the
+// projection's checkpoint.
+type Delta struct {
+	Signups []DailySignups
+	Revenue []DailyRevenue
+}
+
+// Empty reports whether d changes nothing.
+func (d Delta) Empty() bool {
+	return len(d.Signups) == 0 && len(d.Revenue) == 0
+}
+
+// Projection folds events into one read model. Project must be a pure
+// function of the event: rebuilding a read model replays the log through
+// it, and has to arrive where the live run did.
+type Projection struct {
+	Name    string
+	Project func(Event) (Delta, error)
+}
+
+// Projections are the read models this service maintains.
+var Projections = []Projection{
+	{Name: DailySignupsProjection, Project: projectSignups},
+	{Name: ProductRevenueProjection, Project: projectRevenue},
+}
+
+// projectSignups counts a registration on the day it happened.
+func projectSignups(e Event) (Delta, error) {
+	if e.Type != UserRegisteredEvent {
+		return Delta{}, nil
+	}
+	var p UserRegistered
+	if err := e.decode(&p); err != nil {
+		return Delta{}, err
+	}
+	at := p.At
+	if at.IsZero() {
+		at = e.OccurredAt
+	}
+	return Delta{Signups: []DailySignups{{Day: Day(at), Count: 1}}}, nil
+}
+
+// projectRevenue books every line of a placed order against its product
+// on the day the order was placed.
+func projectRevenue(e Event) (Delta, error) {
+	if e.Type != OrderPlacedEvent {
+		return Delta{}, nil
+	}
+	var p OrderPlaced
+	if err := e.decode(&p); err != nil {
+		return Delta{}, err
+	}
+	at := p.PlacedAt
+	if at.IsZero() {
+		at = e.OccurredAt
+	}
+	d := Delta{Revenue: make([]DailyRevenue, 0, len(p.Lines))}
+	for _, l := range p.Lines {
+		if l.SKU == "" || l.Quantity < 1 || l.UnitPrice.Currency == "" {
+			return Delta{}, fmt.Errorf("%w: order %s has an invalid line", ErrMalformedEvent, p.OrderID)
+		}
+		d.Revenue = append(d.Revenue, DailyRevenue{Day: Day(at), ProductRevenue: ProductRevenue{
+			SKU:      l.SKU,
+			Currency: l.UnitPrice.Currency,
+			Units:    l.Quantity,
+			Orders:   1,
+			Amount:   l.Quantity * l.UnitPrice.Amount,
+		}})
+	}
+	return d, nil
+}
@@ -0,0 +1,62 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// MaxRangeDays bounds one report, so a query cannot scan years of rows.
+const MaxRangeDays = 366
+
+// DailySignups is how many users registered on Day (UTC midnight).
+type DailySignups struct {
+	Day   time.Time
+	Count int64
+}
+
+// ProductRevenue is what one product sold for in one currency: Units sold
+// across Orders, for Amount minor units.
+type ProductRevenue struct {
+	SKU      string
+	Currency string
+	Units    int64
+	Orders   int64
+	Amount   int64
+}
+
+// DailyRevenue is a product's revenue on one day; the revenue read model
+// is kept per day so reports can cover any period.
+type DailyRevenue struct {
+	Day time.Time
+	ProductRevenue
+}
+
+// Period is the days from From to To, both included.
+type Period struct {
+	From time.Time
+	To   time.Time
+}
+
+// NewPeriod truncates from and to to UTC days and checks that they are in
+// order and at most MaxRangeDays apart.
+func NewPeriod(from, to time.Time) (Period, error) {
+	p := Period{From: Day(from), To: Day(to)}
+	if p.To.Before(p.From) {
+		return Period{}, fmt.Errorf("%w: %s is before %s", ErrInvalidRange, p.To.Format(time.DateOnly), p.From.Format(time.DateOnly))
+	}
+	if days := p.Days(); days > MaxRangeDays {
+		return Period{}, fmt.Errorf("%w: %d days, at most %d", ErrInvalidRange, days, MaxRangeDays)
+	}
+	return p, nil
+}
+
+// Days is how many days p spans.
+func (p Period) Days() int {
+	return int(p.To.Sub(p.From).Hours()/24) + 1
+}
+
+// Day is the UTC midnight starting the day t falls on.
+func Day(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
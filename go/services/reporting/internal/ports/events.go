@@ -0,0 +1,20 @@
+package ports
+
+import (
+	"context"
+
+	"clean-code-cookbook/go/services/reporting/internal/domain"
+)
+
+// EventLog keeps every event reporting has accepted, in arrival order.
+// It is the source of truth the read models are projected from, so any of
+// them can be dropped and rebuilt.
+type EventLog interface {
+	// Append stores e and assigns its Seq. It returns false, and stores
+	// nothing, if an event with e.ID is already in the log.
+	Append(ctx context.Context, e domain.Event) (bool, error)
+	// Read returns up to limit events with a Seq above after, in Seq order.
+	Read(ctx context.Context, after int64, limit int) ([]domain.Event, error)
+	// Head returns the Seq of the newest event, or 0 for an empty log.
+	Head(ctx context.Context) (int64, error)
+}
@@ -0,0 +1,26 @@
+package ports
+
+import (
+	"context"
+
+	"clean-code-cookbook/go/services/reporting/internal/domain"
+)
+
+// ReadModels stores the projected tables and how far each projection got.
+type ReadModels interface {
+	// Checkpoint returns the Seq of the last event projection applied, or
+	// 0 if it has applied none.
+	Checkpoint(ctx context.Context, projection string) (int64, error)
+	// Apply adds d and moves the checkpoint from from to seq, atomically.
+	// It returns domain.ErrCheckpointMoved, changing nothing, if the
+	// checkpoint is no longer at from.
+	Apply(ctx context.Context, projection string, from, seq int64, d domain.Delta) error
+	// Reset empties projection's tables and sets its checkpoint to 0.
+	Reset(ctx context.Context, projection string) error
+
+	// DailySignups returns the days of p that had signups, oldest first.
+	DailySignups(ctx context.Context, p domain.Period) ([]domain.DailySignups, error)
+	// RevenueByProduct sums each product's revenue over p, highest
+	// amount first.
+	RevenueByProduct(ctx context.Context, p domain.Period) ([]domain.ProductRevenue, error)
+}
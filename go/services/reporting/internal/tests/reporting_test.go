@@ -0,0 +1,302 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"clean-code-cookbook/go/pkg/signing"
+	httpadapter "clean-code-cookbook/go/services/reporting/internal/adapter/http"
+	"clean-code-cookbook/go/services/reporting/internal/adapter/memory"
+	"clean-code-cookbook/go/services/reporting/internal/app"
+	"clean-code-cookbook/go/services/reporting/internal/domain"
+)
+
+var day1 = time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+
+func quietLogger() *log.Logger {
+	return log.New(io.Discard, "", 0)
+}
+
+func newProjector() (*app.Projector, *memory.ReadModels) {
+	models := memory.NewReadModels()
+	return &app.Projector{Log: memory.NewEventLog(), Models: models, Batch: 2}, models
+}
+
+func userRegistered(id string, at time.Time) domain.Event {
+	return domain.Event{
+		ID: "evt-" + id, Type: domain.UserRegisteredEvent, Version: 1, OccurredAt: at,
+		Payload: json.RawMessage(fmt.Sprintf(`{"UserID":%q,"Email":"%s@example.com","At":%q}`, id, id, at.Format(time.RFC3339))),
+	}
+}
+
+func orderPlaced(id string, at time.Time, lines ...domain.OrderLine) domain.Event {
+	payload, _ := json.Marshal(domain.OrderPlaced{OrderID: id, UserID: "u1", Lines: lines, PlacedAt: at})
+	return domain.Event{ID: "evt-" + id, Type: domain.OrderPlacedEvent, Version: 1, OccurredAt: at, Payload: payload}
+}
+
+func line(sku string, qty, price int64) domain.OrderLine {
+	return domain.OrderLine{SKU: sku, Quantity: qty, UnitPrice: domain.Money{Amount: price, Currency: "USD"}}
+}
+
+func record(t *testing.T, p *app.Projector, events ...domain.Event) {
+	t.Helper()
+	for _, e := range events {
+		if err := p.Record(context.Background(), e); err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+	}
+}
+
+func TestSignupsQuery_CountsPerDayWithoutGaps(t *testing.T) {
+	// Arrange
+	p, models := newProjector()
+	record(t, p,
+		userRegistered("a", day1),
+		userRegistered("b", day1.Add(2*time.Hour)),
+		userRegistered("c", day1.AddDate(0, 0, 2)),
+	)
+
+	// Act
+	days, err := (&app.SignupsQuery{Models: models}).Execute(context.Background(), day1, day1.AddDate(0, 0, 2))
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	want := []int64{2, 0, 1}
+	if len(days) != len(want) {
+		t.Fatalf("Expected %d days, but got %d", len(want), len(days))
+	}
+	for i, d := range days {
+		if d.Count != want[i] || !d.Day.Equal(domain.Day(day1).AddDate(0, 0, i)) {
+			t.Errorf("Expected day %d to count %d, but got %+v", i, want[i], d)
+		}
+	}
+}
+
+func TestRevenueQuery_SumsProductsOverThePeriod(t *testing.T) {
+	// Arrange
+	p, models := newProjector()
+	record(t, p,
+		orderPlaced("o1", day1, line("mug", 2, 1200), line("tee", 1, 2500)),
+		orderPlaced("o2", day1.AddDate(0, 0, 1), line("mug", 1, 1200)),
+		orderPlaced("o3", day1.AddDate(0, 0, 5), line("tee", 10, 2500)),
+	)
+
+	// Act
+	products, err := (&app.RevenueQuery{Models: models}).Execute(context.Background(), day1, day1.AddDate(0, 0, 1))
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	want := []domain.ProductRevenue{
+		{SKU: "mug", Currency: "USD", Units: 3, Orders: 2, Amount: 3600},
+		{SKU: "tee", Currency: "USD", Units: 1, Orders: 1, Amount: 2500},
+	}
+	if len(products) != len(want) {
+		t.Fatalf("Expected %d products, but got %+v", len(want), products)
+	}
+	for i := range want {
+		if products[i] != want[i] {
+			t.Errorf("Expected %+v, but got %+v", want[i], products[i])
+		}
+	}
+}
+
+func TestProjector_IgnoresRedeliveredEvents(t *testing.T) {
+	// Arrange
+	p, models := newProjector()
+	e := userRegistered("a", day1)
+
+	// Act
+	record(t, p, e, e)
+
+	// Assert
+	days, _ := (&app.SignupsQuery{Models: models}).Execute(context.Background(), day1, day1)
+	if days[0].Count != 1 {
+		t.Errorf("Expected a redelivery to count once, but got %d", days[0].Count)
+	}
+}
+
+func TestProjector_RefusesMalformedAndNewerEvents(t *testing.T) {
+	// Arrange
+	p, _ := newProjector()
+	malformed := orderPlaced("o1", day1, line("", 1, 100))
+	newer := userRegistered("a", day1)
+	newer.Version = 2
+
+	// Act
+	malformedErr := p.Record(context.Background(), malformed)
+	newerErr := p.Record(context.Background(), newer)
+
+	// Assert
+	if !errors.Is(malformedErr, domain.ErrMalformedEvent) {
+		t.Errorf("Expected error '%v', but got '%v'", domain.ErrMalformedEvent, malformedErr)
+	}
+	if !errors.Is(newerErr, domain.ErrUnsupportedVersion) {
+		t.Errorf("Expected error '%v', but got '%v'", domain.ErrUnsupportedVersion, newerErr)
+	}
+	if head, _ := p.Log.Head(context.Background()); head != 0 {
+		t.Errorf("Expected refused events to stay out of the log, but the head is %d", head)
+	}
+}
+
+func TestProjector_RebuildReplaysTheLog(t *testing.T) {
+	// Arrange: a read model that drifted, e.g. after a projection bug fix
+	p, models := newProjector()
+	record(t, p, userRegistered("a", day1), userRegistered("b", day1), userRegistered("c", day1), orderPlaced("o1", day1, line("mug", 1, 1200)))
+	cp, _ := models.Checkpoint(context.Background(), domain.DailySignupsProjection)
+	drift := domain.Delta{Signups: []domain.DailySignups{{Day: domain.Day(day1), Count: 40}}}
+	if err := models.Apply(context.Background(), domain.DailySignupsProjection, cp, cp, drift); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+
+	// Act
+	err := p.Rebuild(context.Background(), domain.DailySignupsProjection)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	days, _ := (&app.SignupsQuery{Models: models}).Execute(context.Background(), day1, day1)
+	if days[0].Count != 3 {
+		t.Errorf("Expected 3 signups after the rebuild, but got %d", days[0].Count)
+	}
+	statuses, _ := p.Status(context.Background())
+	for _, s := range statuses {
+		if s.Checkpoint != 4 || s.Lag != 0 {
+			t.Errorf("Expected %s at checkpoint 4 with no lag, but got %+v", s.Projection, s)
+		}
+	}
+}
+
+func TestProjector_RebuildRejectsUnknownProjection(t *testing.T) {
+	// Arrange
+	p, _ := newProjector()
+
+	// Act
+	err := p.Rebuild(context.Background(), "nope")
+
+	// Assert
+	if !errors.Is(err, domain.ErrUnknownProjection) {
+		t.Errorf("Expected error '%v', but got '%v'", domain.ErrUnknownProjection, err)
+	}
+}
+
+func TestReadModels_ApplyRefusesAStaleCheckpoint(t *testing.T) {
+	// Arrange: another run already applied event 1
+	models := memory.NewReadModels()
+	d := domain.Delta{Signups: []domain.DailySignups{{Day: domain.Day(day1), Count: 1}}}
+	if err := models.Apply(context.Background(), domain.DailySignupsProjection, 0, 1, d); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+
+	// Act
+	err := models.Apply(context.Background(), domain.DailySignupsProjection, 0, 1, d)
+
+	// Assert
+	if !errors.Is(err, domain.ErrCheckpointMoved) {
+		t.Errorf("Expected error '%v', but got '%v'", domain.ErrCheckpointMoved, err)
+	}
+}
+
+func TestSignupsQuery_RejectsInvalidRange(t *testing.T) {
+	// Arrange
+	q := &app.SignupsQuery{Models: memory.NewReadModels()}
+
+	// Act
+	_, backwards := q.Execute(context.Background(), day1, day1.AddDate(0, 0, -1))
+	_, tooLong := q.Execute(context.Background(), day1, day1.AddDate(2, 0, 0))
+
+	// Assert
+	if !errors.Is(backwards, domain.ErrInvalidRange) || !errors.Is(tooLong, domain.ErrInvalidRange) {
+		t.Errorf("Expected error '%v' twice, but got '%v' and '%v'", domain.ErrInvalidRange, backwards, tooLong)
+	}
+}
+
+func TestHTTPHandler_RecordsSignedEventsAndServesReports(t *testing.T) {
+	// Arrange
+	p, models := newProjector()
+	keys := signing.NewKeyring(signing.Key{ID: "k1", Secret: []byte("secret")})
+	handler := httpadapter.NewHandler(p, &app.SignupsQuery{Models: models}, &app.RevenueQuery{Models: models}, keys, quietLogger())
+	handler.AdminToken = "ops"
+	mux := http.NewServeMux()
+	handler.Register(mux)
+	deliver := func(body string, sign bool) int {
+		req := httptest.NewRequest(http.MethodPost, "/events", strings.NewReader(body))
+		if sign {
+			keys.SignRequest(req, []byte(body))
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	event := `{"id":"e1","type":"order.placed","version":1,"occurred_at":"2024-03-01T10:00:00Z",` +
+		`"payload":{"OrderID":"o1","Lines":[{"SKU":"mug","Quantity":2,"UnitPrice":{"Amount":1200,"Currency":"USD"}}],"PlacedAt":"2024-03-01T10:00:00Z"}}`
+
+	// Act
+	unsigned := deliver(event, false)
+	signed := deliver(event, true)
+	malformed := deliver(`{"id":"e2","type":"order.placed","version":1,"payload":{"Lines":"mug"}}`, true)
+	report := httptest.NewRecorder()
+	mux.ServeHTTP(report, httptest.NewRequest(http.MethodGet, "/reports/revenue?from=2024-03-01&to=2024-03-01", nil))
+	anonymous := httptest.NewRecorder()
+	mux.ServeHTTP(anonymous, httptest.NewRequest(http.MethodPost, "/projections/product_revenue/rebuild", nil))
+	rebuild := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/projections/product_revenue/rebuild", nil)
+	req.Header.Set("Authorization", "Bearer ops")
+	mux.ServeHTTP(rebuild, req)
+
+	// Assert
+	for _, c := range []struct {
+		name string
+		got  int
+		want int
+	}{
+		{"unsigned delivery", unsigned, http.StatusUnauthorized},
+		{"signed delivery", signed, http.StatusNoContent},
+		{"malformed delivery", malformed, http.StatusUnprocessableEntity},
+		{"report", report.Code, http.StatusOK},
+		{"anonymous rebuild", anonymous.Code, http.StatusUnauthorized},
+		{"rebuild", rebuild.Code, http.StatusNoContent},
+	} {
+		if c.got != c.want {
+			t.Errorf("%s: expected status %d, but got %d", c.name, c.want, c.got)
+		}
+	}
+	want := `[{"sku":"mug","currency":"USD","units":2,"orders":1,"amount":2400}]`
+	if got := strings.TrimSpace(report.Body.String()); got != want {
+		t.Errorf("Expected body %s, but got %s", want, got)
+	}
+}
+
+func TestHTTPHandler_DisablesAdminRoutesWithoutAToken(t *testing.T) {
+	// Arrange
+	p, models := newProjector()
+	handler := httpadapter.NewHandler(p, &app.SignupsQuery{Models: models}, &app.RevenueQuery{Models: models}, nil, quietLogger())
+	mux := http.NewServeMux()
+	handler.Register(mux)
+
+	// Act
+	status := httptest.NewRecorder()
+	mux.ServeHTTP(status, httptest.NewRequest(http.MethodGet, "/projections", nil))
+	rebuild := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/projections/product_revenue/rebuild", nil)
+	req.Header.Set("Authorization", "Bearer ")
+	mux.ServeHTTP(rebuild, req)
+
+	// Assert
+	if status.Code != http.StatusForbidden || rebuild.Code != http.StatusForbidden {
+		t.Errorf("Expected statuses 403 and 403, but got %d and %d", status.Code, rebuild.Code)
+	}
+}
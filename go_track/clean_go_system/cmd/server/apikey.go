@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+
+	"clean_go_system/internal/domain"
+	"github.com/google/uuid"
+)
+
+// runAPIKey manages API keys from the command line, which is how the
+// first keys:admin key is issued before anyone can call /admin/keys.
+func runAPIKey(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("apikey", flag.ExitOnError)
+	name := fs.String("name", "", "who the key is for (create)")
+	scopes := fs.String("scopes", "", "comma-separated scopes, e.g. users:write,keys:admin (create)")
+	rate := fs.Float64("rate", 0, "requests per second, 0 for unlimited (create)")
+	id := fs.String("id", "", "key ID (revoke)")
+	tenant := fs.String("tenant", string(domain.DefaultTenant), "tenant the key belongs to")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: apikey [flags] create|revoke")
+	}
+
+	a, err := bootstrap()
+	if err != nil {
+		return err
+	}
+	if a.cfg.DatabaseDriver != "postgres" {
+		return fmt.Errorf("driver %s keeps API keys in memory; apikey needs postgres", a.cfg.DatabaseDriver)
+	}
+	defer a.db.Close()
+	ctx = domain.WithTenant(ctx, domain.TenantID(*tenant))
+
+	switch action := fs.Arg(0); action {
+	case "create":
+		secret, key, err := a.keys.Create(ctx, *name, splitScopes(*scopes), *rate)
+		if err != nil {
+			return err
+		}
+		// The secret is printed on its own line; it cannot be shown again.
+		a.log.Printf("apikey: created %s (%s)", key.ID, key.Name)
+		fmt.Println(secret)
+	case "revoke":
+		keyID, err := uuid.Parse(*id)
+		if err != nil {
+			return fmt.Errorf("-id: %w", err)
+		}
+		if err := a.keys.Revoke(ctx, keyID); err != nil {
+			return err
+		}
+		a.log.Printf("apikey: revoked %s", keyID)
+	default:
+		return fmt.Errorf("unknown action %q (want create or revoke)", action)
+	}
+	return nil
+}
+
+func splitScopes(s string) []string {
+	var out []string
+	for _, scope := range strings.Split(s, ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			out = append(out, scope)
+		}
+	}
+	return out
+}
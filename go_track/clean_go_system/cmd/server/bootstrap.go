@@ -0,0 +1,539 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"clean-code-cookbook/go/pkg/budget"
+	"clean-code-cookbook/go/pkg/bulkhead"
+	"clean-code-cookbook/go/pkg/edgetoken"
+	"clean-code-cookbook/go/pkg/signing"
+	"clean_go_system/internal/adapter/authsvc"
+	"clean_go_system/internal/adapter/authz"
+	"clean_go_system/internal/adapter/cache"
+	"clean_go_system/internal/adapter/catalogsvc"
+	"clean_go_system/internal/adapter/chaos"
+	"clean_go_system/internal/adapter/email"
+	"clean_go_system/internal/adapter/eventbus"
+	"clean_go_system/internal/adapter/eventcodec"
+	"clean_go_system/internal/adapter/fallback"
+	"clean_go_system/internal/adapter/fieldcrypt"
+	"clean_go_system/internal/adapter/kafka"
+	"clean_go_system/internal/adapter/limited"
+	"clean_go_system/internal/adapter/memory"
+	mongoadapter "clean_go_system/internal/adapter/mongo"
+	natsadapter "clean_go_system/internal/adapter/nats"
+	"clean_go_system/internal/adapter/notify"
+	"clean_go_system/internal/adapter/postgres"
+	"clean_go_system/internal/adapter/rabbitmq"
+	redisadapter "clean_go_system/internal/adapter/redis"
+	sqliteadapter "clean_go_system/internal/adapter/sqlite"
+	"clean_go_system/internal/adapter/webhook"
+	"clean_go_system/internal/config"
+	"clean_go_system/internal/core"
+	"clean_go_system/internal/domain"
+	"clean_go_system/pkg/faults"
+	"clean_go_system/pkg/lifecycle"
+	"clean_go_system/pkg/logger"
+	"clean_go_system/pkg/policy"
+	"clean_go_system/pkg/scheduler"
+	_ "github.com/lib/pq" // Postgres Driver
+	"github.com/nats-io/nats.go"
+	goredis "github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// app is the shared composition root every subcommand starts from.
+type app struct {
+	cfg      config.Config
+	log      *log.Logger
+	db       *sql.DB // set for the postgres and sqlite drivers
+	mongo    *mongo.Client
+	events   *eventbus.Bus
+	kafka    *kafka.Publisher
+	redis    *goredis.Client
+	dedup    eventbus.DedupStore
+	dedupTx  domain.Transactor     // joins dedup claims with the handlers' writes
+	relay    *postgres.OutboxRelay // nil without a database
+	jobs     *scheduler.Scheduler  // nil when there is nothing to schedule
+	users    *core.UserService
+	keys     *core.APIKeyService
+	logins   *core.FederatedLogin
+	sessions *core.SessionService  // nil unless AUTH_SESSION_MODE=server
+	chaos    *faults.Injector      // nil unless CHAOS_ENABLED
+	products domain.ProductCatalog // nil without CATALOG_URL
+
+	authorizer domain.Authorizer
+	// caches are the user caches operators may flush, by name.
+	caches map[string]core.CacheFlusher
+	// deliveries records the emails sent to users; suppressions holds the
+	// addresses that bounced or complained.
+	deliveries   domain.DeliveryRepository
+	suppressions domain.SuppressionList
+	// preferences are the users' notification choices; digestItems holds
+	// the notifications waiting for their digest.
+	preferences *core.NotificationPreferences
+	digestItems domain.DigestRepository
+
+	// ensureSchema prepares storage that is not migrated by the migrate
+	// command (sqlite tables, mongo indexes) when the database starts.
+	ensureSchema func(ctx context.Context) error
+	// emails renders the HTML emails; notification events without one
+	// fall back to the plain text templates.
+	emails domain.MessageTemplates
+	// tokenSecret signs session and email verification tokens.
+	tokenSecret []byte
+	// notify routes and renders the notifications; subscribe adds the
+	// channels they go out on.
+	notify *core.Notifications
+	// passwords builds the service mailing reset tokens once the email
+	// queue exists; nil without an auth service to keep the passwords.
+	// Verification tokens are mailed through the outbox.
+	passwords    func(queue core.EmailQueue) *core.PasswordService
+	verification *core.EmailVerification
+
+	// Email job backend, chosen by setupEmail.
+	emailQueue    core.EmailQueue
+	emailPool     *core.WorkerPool
+	smtp          *email.SMTPSender // nil unless EMAIL_PROVIDER=smtp
+	mailbox       *email.Mailbox    // nil unless EMAIL_PROVIDER=mailbox
+	amqpQueue     *rabbitmq.EmailQueue
+	emailConsumer *rabbitmq.Consumer
+	amqp          *rabbitmq.Conn
+	emailThrottle *core.EmailThrottle // nil unless a send limit is set
+
+	// Image uploads, set by setupImages unless they are off.
+	images    *core.ImageService
+	imagePool *core.Pool[core.ResizeJob]
+}
+
+func bootstrap() (*app, error) {
+	appLog := logger.New()
+
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	appLog.Printf("profile %s, database %s", cfg.Profile, cfg.DatabaseDriver)
+
+	a := &app{cfg: cfg, log: appLog, events: eventbus.New(appLog, 2, 256), caches: make(map[string]core.CacheFlusher)}
+
+	// 1. Infrastructure: events reach the bus (and Kafka, and webhooks)
+	// through the outbox relay when there is a database, directly otherwise.
+	// Requested emails are for the email workers only.
+	outbound := eventbus.Publishers{a.events}
+	if len(cfg.KafkaBrokers) > 0 {
+		a.kafka = kafka.NewPublisher(cfg.KafkaBrokers, cfg.KafkaTopic)
+		outbound = append(outbound, eventbus.Except(a.kafka, domain.EmailRequested{}.EventName()))
+	}
+	if len(cfg.Webhooks.URLs) > 0 {
+		keys, err := signing.ParseKeys(cfg.Webhooks.SigningKeys)
+		if err != nil {
+			return nil, err
+		}
+		outbound = append(outbound, eventbus.Except(webhook.NewPublisher(cfg.Webhooks.URLs, signing.NewKeyring(keys...)), domain.EmailRequested{}.EventName()))
+	}
+
+	// 2. Wiring Layers (The "Composition Root")
+	var (
+		repo       domain.UserRepository
+		lister     domain.UserLister
+		batch      domain.UserBatchReader
+		stream     domain.UserStreamer
+		purger     domain.UserPurger
+		publisher  domain.EventPublisher
+		tx         domain.Transactor
+		keys       domain.APIKeyRepository   = memory.NewAPIKeyRepository()
+		identities domain.IdentityRepository = memory.NewIdentityRepository()
+
+		resets     domain.PasswordResetRepository = memory.NewPasswordResetRepository()
+		sessions   domain.SessionRepository       = memory.NewSessionRepository()
+		deliveries domain.DeliveryRepository      = memory.NewDeliveryRepository()
+
+		suppressions domain.SuppressionList  = memory.NewSuppressionList()
+		digestItems  domain.DigestRepository = memory.NewDigestRepository()
+
+		preferences domain.NotificationPreferencesRepository = memory.NewNotificationPreferencesRepository()
+	)
+	switch cfg.DatabaseDriver {
+	case "postgres":
+		db, err := sql.Open("postgres", cfg.DatabaseURL)
+		if err != nil {
+			return nil, err
+		}
+		a.db = db
+		a.dedup = postgres.NewDedupStore(db)
+		a.relay = postgres.NewOutboxRelay(db, outbound, time.Second, 100, appLog)
+		a.relay.Locker = postgres.NewLocker(db)
+		users := postgres.NewPostgresRepository(db)
+		users.Unprepared = cfg.DatabaseUnprepared
+		if users.PII, err = newPIICodec(cfg.PII); err != nil {
+			return nil, err
+		}
+		if users.PII != nil {
+			eventcodec.Default.PII = users.PII
+		}
+		repo, lister, batch, stream, purger, publisher, tx = users, users, users, users, users, postgres.NewOutbox(db), postgres.NewTransactor(db)
+		keys, identities = postgres.NewAPIKeyRepository(db), postgres.NewIdentityRepository(db)
+		resets = postgres.NewPasswordResetRepository(db)
+		sessions, deliveries = postgres.NewSessionRepository(db), postgres.NewDeliveryRepository(db)
+		suppressed := postgres.NewSuppressionList(db)
+		suppressed.PII, suppressions = users.PII, suppressed
+		held := postgres.NewDigestRepository(db)
+		held.PII, digestItems = users.PII, held
+		preferences = postgres.NewNotificationPreferencesRepository(db)
+	case "sqlite":
+		db, err := sqliteadapter.Open(cfg.DatabaseURL)
+		if err != nil {
+			return nil, err
+		}
+		a.db = db
+		a.ensureSchema = func(ctx context.Context) error { return sqliteadapter.Migrate(ctx, db) }
+		// Local development: no outbox, so events go straight to the bus
+		// once the transaction that recorded them has committed.
+		a.dedup = sqliteadapter.NewDedupStore(db)
+		users := sqliteadapter.NewUserRepository(db)
+		deferred := eventbus.NewAfterCommit(sqliteadapter.NewTransactor(db), outbound)
+		repo, lister, batch, stream, purger, publisher, tx = users, users, users, users, users, deferred, deferred
+	case "mongodb":
+		client, db, err := mongoadapter.Open(cfg.DatabaseURL)
+		if err != nil {
+			return nil, err
+		}
+		users := mongoadapter.NewUserRepository(db, 5*time.Second)
+		a.mongo, a.ensureSchema = client, users.EnsureIndexes
+		// No outbox here yet: events are published once the transaction
+		// that recorded them has committed, and lost if the process dies
+		// in between. Dedup claims are kept in memory, beside Mongo's
+		// transactions rather than in them.
+		a.dedup, a.dedupTx = memory.NewDedupStore(), memory.NewTransactor()
+		deferred := eventbus.NewAfterCommit(mongoadapter.NewTransactor(client), outbound)
+		repo, lister, batch, stream, purger, publisher, tx = users, users, users, users, users, deferred, deferred
+	case "memory":
+		a.dedup = memory.NewDedupStore()
+		users := memory.NewUserRepository()
+		deferred := eventbus.NewAfterCommit(memory.NewTransactor(), outbound)
+		repo, lister, batch, stream, purger, publisher, tx = users, users, users, users, users, deferred, deferred
+	default:
+		return nil, fmt.Errorf("database driver %q is not available in this build (profile %s); set DATABASE_DRIVER to one of %s", cfg.DatabaseDriver, cfg.Profile, strings.Join(config.DatabaseDrivers, ", "))
+	}
+	if a.dedupTx == nil {
+		a.dedupTx = tx
+	}
+
+	// Faults go beneath the cache, where a real database outage would be.
+	if cfg.ChaosEnabled {
+		a.chaos = faults.NewInjector(cfg.Dynamic.Chaos)
+		repo = chaos.NewUserRepository(repo, a.chaos)
+		appLog.Printf("chaos: fault injection enabled")
+	}
+
+	// The database bulkhead sits beneath the cache, so hits never queue,
+	// and above injected faults, so those hold slots like a slow database.
+	// Each database call gets 60% of what is left of the request.
+	queueTimeout := time.Duration(cfg.Bulkheads.QueueTimeoutMS) * time.Millisecond
+	dbRepo := limited.NewUserRepository(repo, bulkhead.New("database", cfg.Bulkheads.Database, queueTimeout))
+	dbRepo.Budget = budget.Budget{Share: 0.6, Floor: 50 * time.Millisecond, Ceiling: 5 * time.Second}
+	repo = dbRepo
+
+	if cfg.RedisURL != "" {
+		if a.redis, err = redisadapter.NewClient(cfg.RedisURL); err != nil {
+			return nil, err
+		}
+		if a.relay != nil {
+			a.relay.Locker = redisadapter.NewLocker(a.redis)
+		}
+		ttl := time.Duration(cfg.CacheTTLSeconds) * time.Second
+		users := cache.NewUserCache(redisadapter.NewStore[domain.User](a.redis, ttl), appLog)
+		repo, a.caches["users"] = cache.NewRepository(repo, users), users
+		// Sessions are read on every request and expire on their own:
+		// Redis suits them better than the database.
+		sessions = redisadapter.NewSessionRepository(a.redis)
+	}
+
+	// Soft-deleted users go for good once retention has passed.
+	if cfg.DeletedRetentionSeconds > 0 {
+		purge := core.NewUserPurge(purger, time.Duration(cfg.DeletedRetentionSeconds)*time.Second, appLog)
+		if err := a.schedule("user-purge", cfg.PurgeSchedule, 5*time.Minute, purge.Run); err != nil {
+			return nil, err
+		}
+	}
+
+	// Outermost, so a cache hit still beats a stale copy.
+	if cfg.StaleTTLSeconds > 0 {
+		lastGood := fallback.NewUserRepository(repo, memory.NewStore[domain.User](time.Duration(cfg.StaleTTLSeconds)*time.Second))
+		lastGood.OnFallback = func(key string, err error) { appLog.Printf("serving stale %s: %v", key, err) }
+		repo, a.caches["users-stale"] = lastGood, lastGood
+	}
+
+	authorizer, err := newAuthorizer(cfg.PolicyFile)
+	if err != nil {
+		return nil, err
+	}
+	a.authorizer = authorizer
+	emails, err := notify.BuiltinEmailTemplates(cfg.Notifications.DefaultLocale)
+	if err != nil {
+		return nil, fmt.Errorf("email templates: %w", err)
+	}
+	// Validated with the config already
+	emails.Fallbacks, _ = domain.ParseLocaleFallbacks(cfg.Notifications.LocaleFallbacks)
+	a.emails = emails
+	if a.notify, err = a.notificationRoutes(); err != nil {
+		return nil, err
+	}
+	a.tokenSecret = []byte(cfg.Auth.TokenSecret)
+	if len(a.tokenSecret) == 0 {
+		a.tokenSecret = make([]byte, 32)
+		if _, err := rand.Read(a.tokenSecret); err != nil {
+			return nil, err
+		}
+	}
+	// The welcome and verification emails are composed in the transaction
+	// storing the user, so they sit in the outbox with it. Verification
+	// confirms through the user service in turn.
+	welcome := a.notify.Compose
+	verify := func(ctx context.Context, e domain.DomainEvent) (domain.EmailRequested, bool, error) {
+		return a.verification.Compose(ctx, e)
+	}
+	a.users = core.NewUserService(repo, publisher, tx, core.WithAuthorizer(authorizer), core.WithLister(lister), core.WithStreamer(stream), core.WithLoader(core.NewUserLoader(batch)), core.WithRegistrationEmails(welcome, verify))
+	a.verification = core.NewEmailVerification(a.users, a.emails, a.tokenSecret)
+	// API keys, IdP links, reset tokens, email deliveries, suppressions
+	// and digests persist only in Postgres; other drivers keep them until the
+	// process exits.
+	a.keys = core.NewAPIKeyService(keys)
+	a.deliveries, a.suppressions = deliveries, suppressions
+	a.preferences, a.digestItems = core.NewNotificationPreferences(preferences), digestItems
+	a.logins = core.NewFederatedLogin(a.users, repo, identities, tx)
+	if cfg.Auth.ServerSessions() {
+		a.sessions = core.NewSessionService(sessions)
+		a.sessions.IdleTTL = time.Duration(cfg.Auth.SessionIdleTTLSeconds) * time.Second
+		a.sessions.MaxTTL = time.Duration(cfg.Auth.SessionMaxTTLSeconds) * time.Second
+	}
+	if cfg.Catalog.URL != "" {
+		catalog := catalogsvc.NewCatalog(cfg.Catalog.URL)
+		if cfg.Catalog.IdentitySecret != "" {
+			catalog.Identity = edgetoken.NewSigner([]byte(cfg.Catalog.IdentitySecret))
+		}
+		a.products = catalog
+	}
+	if cfg.Auth.ServiceURL != "" {
+		authKeys, err := signing.ParseKeys(cfg.Auth.ServiceSigningKeys)
+		if err != nil {
+			return nil, err
+		}
+		credentials := authsvc.NewCredentialStore(cfg.Auth.ServiceURL, signing.NewKeyring(authKeys...))
+		a.passwords = func(queue core.EmailQueue) *core.PasswordService {
+			passwords := core.NewPasswordService(repo, credentials, resets, tx, a.emails, queue)
+			passwords.Sessions, passwords.Logger = a.sessions, appLog
+			return passwords
+		}
+	}
+	return a, nil
+}
+
+// broker returns the Kafka publisher as a component, so it is flushed
+// after the relay stops; nil when Kafka is not configured.
+func (a *app) broker() lifecycle.Component {
+	if a.kafka == nil {
+		return nil
+	}
+	return lifecycle.Func{OnStop: func(context.Context) error { return a.kafka.Close() }}
+}
+
+// cache returns the Redis client as a component so it is closed on
+// shutdown; nil when caching is disabled.
+func (a *app) cache() lifecycle.Component {
+	if a.redis == nil {
+		return nil
+	}
+	return lifecycle.Func{OnStop: func(context.Context) error { return a.redis.Close() }}
+}
+
+// inbound returns the NATS subscriber feeding other services' events into
+// the local bus, plus its connection; nil when NATS is not configured.
+func (a *app) inbound() (lifecycle.Component, error) {
+	if a.cfg.NATSURL == "" {
+		return nil, nil
+	}
+	conn, err := nats.Connect(a.cfg.NATSURL, nats.Name("clean_go_system"))
+	if err != nil {
+		return nil, fmt.Errorf("connect to nats: %w", err)
+	}
+	sub := natsadapter.NewSubscriber(conn, a.cfg.NATSSubject, a.cfg.NATSQueue, a.events, a.log)
+	return lifecycle.Func{
+		OnStart: sub.Start,
+		OnStop: func(ctx context.Context) error {
+			defer conn.Close()
+			return sub.Stop(ctx)
+		},
+	}, nil
+}
+
+// subscribe wires the side effects of domain events. Handlers stay free
+// of them: registering a user only records UserRegistered and its emails
+// in the outbox, and the relay feeds them to the bus, which hands the
+// emails to the email queue. The drivers without an outbox hand events to
+// the bus once the transaction that recorded them has committed.
+//
+// A failing sync subscriber fails the relay attempt, so the event is
+// retried rather than lost; Idempotent keeps those retries from sending
+// a notification twice.
+func (a *app) subscribe() error {
+	notifications, err := a.notifications()
+	if err != nil {
+		return err
+	}
+	for ch := range notifications.Notifiers {
+		eventbus.SubscribeAll(a.events, eventbus.Sync, eventbus.Idempotent(a.dedup, a.dedupTx, "notify-"+string(ch), notifications.Channel(ch)))
+	}
+	eventbus.Subscribe(a.events, eventbus.Sync, eventbus.Idempotent(a.dedup, a.dedupTx, "send-email", core.EnqueueRequested(a.emailQueue, time.Second)))
+	eventbus.SubscribeAll(a.events, eventbus.Sync, core.AuditLog(a.log))
+	return nil
+}
+
+// lockElector elects the instance holding the job's lock in l. Losing
+// the lock means another instance runs the job this time.
+func lockElector(l domain.Locker) scheduler.ElectorFunc {
+	return func(ctx context.Context, job string, lease time.Duration) (func(), error) {
+		lock, err := l.Acquire(ctx, job, lease)
+		if errors.Is(err, domain.ErrLockHeld) {
+			return nil, scheduler.ErrNotLeader
+		}
+		if err != nil {
+			return nil, err
+		}
+		//nolint:errcheck // the lease expires anyway
+		return func() { lock.Release(context.WithoutCancel(ctx)) }, nil
+	}
+}
+
+// messageTemplates renders notifications: from the HTML emails where
+// there is one, otherwise from the plain text templates.
+func (a *app) messageTemplates() (domain.MessageTemplates, error) {
+	cfg := a.cfg.Notifications
+	templates, err := notify.BuiltinTemplates(cfg.DefaultLocale)
+	if cfg.TemplatesDir != "" {
+		templates, err = notify.LoadTemplates(os.DirFS(cfg.TemplatesDir), cfg.DefaultLocale)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("notification templates: %w", err)
+	}
+	// Validated with the config already
+	templates.Fallbacks, _ = domain.ParseLocaleFallbacks(cfg.LocaleFallbacks)
+	return notify.Chain{a.emails, templates}, nil
+}
+
+// schedule adds a job to the scheduler, creating it on first use.
+// Scheduled jobs share the relay's lock backend, so one instance runs
+// each.
+func (a *app) schedule(name, spec string, timeout time.Duration, run func(ctx context.Context) error) error {
+	schedule, err := scheduler.Parse(spec)
+	if err != nil {
+		return err
+	}
+	if a.jobs == nil {
+		a.jobs = scheduler.New(1, a.log)
+		if a.relay != nil {
+			a.jobs.Elector = lockElector(a.relay.Locker)
+		}
+	}
+	return a.jobs.Add(scheduler.Job{Name: name, Schedule: schedule, Timeout: timeout, Run: run})
+}
+
+// notificationRoutes loads the message templates and the routes of the
+// notifications. The welcome email is composed at registration, in the
+// transaction storing the user.
+func (a *app) notificationRoutes() (*core.Notifications, error) {
+	templates, err := a.messageTemplates()
+	if err != nil {
+		return nil, err
+	}
+	n := &core.Notifications{
+		Templates:     templates,
+		Routes:        make(map[string][]domain.Channel, len(a.cfg.Notifications.Routes)),
+		Transactional: []string{domain.UserRegistered{}.EventName()},
+	}
+	for event, channels := range a.cfg.Notifications.Routes {
+		for _, ch := range channels {
+			n.Routes[event] = append(n.Routes[event], domain.Channel(ch))
+		}
+	}
+	return n, nil
+}
+
+// notifications adds the channels to send notifications on; the webhook
+// channel only exists with NOTIFY_WEBHOOK_URL. With NOTIFY_DIGEST_EVENTS,
+// emails go through the digests, whose flushes are scheduled here.
+func (a *app) notifications() (*core.Notifications, error) {
+	cfg := a.cfg.Notifications
+	n := a.notify
+	n.Notifiers = map[domain.Channel]domain.Notifier{
+		domain.ChannelEmail: notify.NewEmail(a.emailQueue, time.Second),
+		domain.ChannelSMS:   notify.NewSMS(a.log),
+	}
+	if cfg.WebhookURL != "" {
+		hook := notify.NewWebhook(cfg.WebhookURL)
+		if a.cfg.Webhooks.SigningKeys != "" {
+			keys, err := signing.ParseKeys(a.cfg.Webhooks.SigningKeys)
+			if err != nil {
+				return nil, err
+			}
+			hook.Keys = signing.NewKeyring(keys...)
+		}
+		n.Notifiers[domain.ChannelWebhook] = hook
+	}
+	if len(cfg.DigestEvents) > 0 {
+		digests := core.NewDigests(n.Notifiers[domain.ChannelEmail], a.preferences, a.digestItems, n.Templates, cfg.DigestEvents)
+		digests.Logger = a.log
+		n.Notifiers[domain.ChannelEmail] = digests
+		if err := a.schedule("digest-daily", cfg.DailyDigestSchedule, 10*time.Minute, digests.Job(domain.DigestDaily)); err != nil {
+			return nil, err
+		}
+		if err := a.schedule("digest-weekly", cfg.WeeklyDigestSchedule, 10*time.Minute, digests.Job(domain.DigestWeekly)); err != nil {
+			return nil, err
+		}
+	}
+	return n, nil
+}
+
+// newAuthorizer evaluates the built-in policies plus those in file, if set.
+func newAuthorizer(file string) (*authz.PolicyAuthorizer, error) {
+	policies := authz.DefaultPolicies()
+	if file != "" {
+		extra, err := policy.LoadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, extra...)
+	}
+	return authz.NewPolicyAuthorizer(policies...)
+}
+
+// newPIICodec returns the codec for personal fields, or nil when PII
+// encryption is off.
+func newPIICodec(cfg config.PII) (*fieldcrypt.Codec, error) {
+	if !cfg.Enabled() {
+		return nil, nil
+	}
+	keys, err := fieldcrypt.ParseKeys(cfg.Keys)
+	if err != nil {
+		return nil, fmt.Errorf("PII_KEYS: %w", err)
+	}
+	index, err := base64.StdEncoding.DecodeString(cfg.IndexKey)
+	if err != nil {
+		return nil, fmt.Errorf("PII_INDEX_KEY: %w", err)
+	}
+	provider, err := fieldcrypt.NewStaticKeys(index, keys...)
+	if err != nil {
+		return nil, err
+	}
+	return fieldcrypt.NewCodec(provider), nil
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"clean-code-cookbook/go/pkg/bulkhead"
+	"clean_go_system/internal/adapter/email"
+	"clean_go_system/internal/adapter/limited"
+	"clean_go_system/internal/adapter/rabbitmq"
+	"clean_go_system/internal/core"
+	"clean_go_system/internal/domain"
+	"clean_go_system/pkg/lifecycle"
+)
+
+// deadLetters is how many failed jobs an in-memory pool keeps for an
+// operator to retry.
+const deadLetters = 1000
+
+// setupEmail picks the email job backend: RabbitMQ when AMQP_URL is set,
+// so jobs are shared by every worker process, otherwise the in-memory pool.
+// Either way, each email to a user is recorded from queueing to sending.
+func (a *app) setupEmail() error {
+	sender, err := a.emailSender()
+	if err != nil {
+		return err
+	}
+	// Workers of either backend share one bulkhead towards the provider.
+	// It covers single sends, so a job waiting to retry holds no slot.
+	pool := bulkhead.New("email", a.cfg.Bulkheads.Email, time.Duration(a.cfg.Bulkheads.QueueTimeoutMS)*time.Millisecond)
+	delivery := core.NewEmailDelivery(limited.NewEmailSender(sender, pool))
+	delivery.Deliveries, delivery.Suppressions, delivery.Logger = a.deliveries, a.suppressions, a.log
+	process := delivery.Process
+
+	if a.cfg.AMQPURL == "" {
+		a.emailPool = core.NewWorkerPool(a.cfg.EmailWorkers, a.cfg.EmailQueueSize)
+		a.emailPool.DeadLetters = core.NewDeadLetters[core.EmailJob](deadLetters)
+		if a.emailPool.Process, err = a.throttle(a.emailPool, process); err != nil {
+			return err
+		}
+		if a.emailThrottle != nil {
+			a.emailThrottle.OnFailure = a.emailPool.DeadLetters.Add
+		}
+		a.emailQueue = core.NewTrackedQueue(a.emailPool, a.deliveries)
+		return nil
+	}
+
+	conn, err := rabbitmq.Dial(a.cfg.AMQPURL)
+	if err != nil {
+		return fmt.Errorf("connect to rabbitmq: %w", err)
+	}
+	queue, err := rabbitmq.NewEmailQueue(conn, a.cfg.AMQPQueue)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	a.amqp = conn
+	a.amqpQueue = queue
+	if process, err = a.throttle(queue, process); err != nil {
+		return err
+	}
+	a.emailQueue = core.NewTrackedQueue(queue, a.deliveries)
+	a.emailConsumer = rabbitmq.NewConsumer(conn, a.cfg.AMQPQueue, a.cfg.AMQPPrefetch, a.cfg.EmailWorkers, process, a.log)
+	return nil
+}
+
+// throttle paces process by the email send limits, deferring the jobs
+// over one to queue. Without limits it returns process as it is.
+func (a *app) throttle(queue core.EmailQueue, process func(context.Context, core.EmailJob) error) (func(context.Context, core.EmailJob) error, error) {
+	cfg := a.cfg.Email
+	domains, err := cfg.DomainRateLimits()
+	if err != nil {
+		return nil, err
+	}
+	if cfg.RatePerMinute == 0 && len(domains) == 0 {
+		return process, nil
+	}
+	perMinute := func(n int) domain.RateLimit {
+		return domain.RateLimit{Count: n, Period: time.Minute, Burst: cfg.RateBurst}
+	}
+	a.emailThrottle = core.NewEmailThrottle(a.rateLimiter(), queue, process)
+	a.emailThrottle.Global = perMinute(cfg.RatePerMinute)
+	a.emailThrottle.Domains = make(map[string]domain.RateLimit, len(domains))
+	for name, n := range domains {
+		a.emailThrottle.Domains[name] = perMinute(n)
+	}
+	a.emailThrottle.Logger = a.log
+	return a.emailThrottle.Process, nil
+}
+
+// emailSender builds the provider chosen by EMAIL_PROVIDER. The SMTP
+// sender keeps a connection per worker open between sends.
+func (a *app) emailSender() (domain.EmailSender, error) {
+	cfg := a.cfg.Email
+	timeout := time.Duration(cfg.TimeoutMS) * time.Millisecond
+	switch cfg.Provider {
+	case "dryrun":
+		a.log.Printf("email: dry run, messages are logged instead of sent")
+		return email.NewDryRun(cfg.From, a.log)
+	case "mailbox":
+		a.log.Printf("email: messages are kept in memory, see /dev/mailbox")
+		mailbox, err := email.NewMailbox(cfg.From, 100)
+		if err != nil {
+			return nil, err
+		}
+		a.mailbox = mailbox
+		return mailbox, nil
+	case "ses":
+		sender, err := email.NewSESSender(email.SESConfig{
+			Region:          cfg.SESRegion,
+			AccessKeyID:     cfg.SESAccessKey,
+			SecretAccessKey: cfg.SESSecretKey,
+			SessionToken:    cfg.SESSessionToken,
+			Endpoint:        cfg.APIEndpoint,
+		}, cfg.From)
+		if err != nil {
+			return nil, err
+		}
+		sender.Client.Timeout = timeout
+		return sender, nil
+	case "sendgrid":
+		sender, err := email.NewSendGridSender(cfg.SendGridAPIKey, cfg.APIEndpoint, cfg.From)
+		if err != nil {
+			return nil, err
+		}
+		sender.Client.Timeout = timeout
+		return sender, nil
+	}
+	sender, err := email.NewSMTPSender(email.SMTPConfig{
+		Host:     cfg.SMTPHost,
+		Port:     cfg.SMTPPort,
+		Username: cfg.SMTPUsername,
+		Password: cfg.SMTPPassword,
+		TLS:      cfg.SMTPTLS,
+		Timeout:  timeout,
+		MaxIdle:  a.cfg.EmailWorkers,
+	}, cfg.From)
+	if err != nil {
+		return nil, err
+	}
+	a.smtp = sender
+	return sender, nil
+}
+
+// emailEvents receives the bounces and complaints of the provider, when
+// it is configured to report them; nil otherwise.
+func (a *app) emailEvents() (http.Handler, error) {
+	cfg := a.cfg.Email
+	bounces := core.NewBounces(a.suppressions, a.deliveries)
+	switch {
+	case cfg.Provider == "ses" && cfg.SESTopicARN != "":
+		events, err := email.NewSESEvents(cfg.SESTopicARN, bounces.Handle, a.log)
+		if err != nil {
+			return nil, err
+		}
+		return events.Handler(), nil
+	case cfg.Provider == "sendgrid" && cfg.SendGridWebhookKey != "":
+		events, err := email.NewSendGridEvents(cfg.SendGridWebhookKey, bounces.Handle, a.log)
+		if err != nil {
+			return nil, err
+		}
+		return events.Handler(), nil
+	}
+	return nil, nil
+}
+
+// workers runs the consumers of whichever backend setupEmail chose.
+// Throttled jobs go back to the queue before it closes.
+func (a *app) workers() lifecycle.Component {
+	if a.emailConsumer == nil {
+		return lifecycle.Func{
+			OnStart: func(context.Context) error { a.emailPool.Start(); return nil },
+			OnStop: func(ctx context.Context) error {
+				err := a.stopThrottle(ctx)
+				a.emailPool.Stop()
+				return errors.Join(err, a.closeSender())
+			},
+		}
+	}
+	return lifecycle.Func{
+		OnStart: a.emailConsumer.Start,
+		OnStop: func(ctx context.Context) error {
+			err := a.stopThrottle(ctx)
+			return errors.Join(err, a.emailConsumer.Stop(ctx), a.amqpQueue.Close(), a.amqp.Close(), a.closeSender())
+		},
+	}
+}
+
+// stopThrottle queues the jobs the email throttle is holding back.
+func (a *app) stopThrottle(ctx context.Context) error {
+	if a.emailThrottle == nil {
+		return nil
+	}
+	return a.emailThrottle.Stop(ctx)
+}
+
+// closeSender hangs up the SMTP connections kept for reuse, once the
+// workers are done with them.
+func (a *app) closeSender() error {
+	if a.smtp == nil {
+		return nil
+	}
+	return a.smtp.Close()
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"clean_go_system/internal/adapter/filesystem"
+	"clean_go_system/internal/adapter/imaging"
+	"clean_go_system/internal/adapter/s3"
+	"clean_go_system/internal/core"
+	"clean_go_system/internal/domain"
+	"clean_go_system/pkg/lifecycle"
+)
+
+// setupImages builds the image service and the in-memory pool resizing
+// its uploads, when uploads are configured. The filesystem store also
+// returns the handler serving its signed links and the path to mount it
+// at; S3 serves its own.
+func (a *app) setupImages() (blobs http.Handler, mountPath string, err error) {
+	cfg := a.cfg.Uploads
+	if !cfg.Enabled() {
+		return nil, "", nil
+	}
+
+	var store domain.BlobStore
+	if cfg.S3Bucket != "" {
+		if store, err = s3.NewBlobStore(cfg.S3Endpoint, cfg.S3AccessKey, cfg.S3SecretKey, cfg.S3Bucket, cfg.S3UseSSL); err != nil {
+			return nil, "", err
+		}
+	} else {
+		base, err := url.Parse(cfg.BlobBaseURL)
+		if err != nil {
+			return nil, "", fmt.Errorf("UPLOAD_BLOB_BASE_URL: %w", err)
+		}
+		files, err := filesystem.NewBlobStore(cfg.BlobDir, cfg.BlobBaseURL, []byte(cfg.BlobSecret))
+		if err != nil {
+			return nil, "", err
+		}
+		store, blobs, mountPath = files, files.Handler(), strings.TrimSuffix(base.Path, "/")
+	}
+
+	a.imagePool = core.NewPool[core.ResizeJob]("images", cfg.Workers, cfg.QueueSize, nil)
+	a.images = core.NewImageService(store, imaging.NewResizer(), a.imagePool, a.authorizer)
+	a.images.MaxBytes = int64(cfg.MaxBytes)
+	a.images.URLExpiry = time.Duration(cfg.URLTTLSeconds) * time.Second
+	a.imagePool.Process = a.images.ProcessResizeJob
+	a.imagePool.DeadLetters = core.NewDeadLetters[core.ResizeJob](deadLetters)
+	return blobs, mountPath, nil
+}
+
+// imageWorkers runs the resize pool; nil when uploads are off. Queued
+// jobs are finished before it stops.
+func (a *app) imageWorkers() lifecycle.Component {
+	if a.imagePool == nil {
+		return nil
+	}
+	return lifecycle.Func{
+		OnStart: func(context.Context) error { a.imagePool.Start(); return nil },
+		OnStop:  func(context.Context) error { a.imagePool.Stop(); return nil },
+	}
+}
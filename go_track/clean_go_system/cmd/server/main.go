@@ -1,44 +1,50 @@
 package main
 
 import (
-	"database/sql"
+	"context"
+	"fmt"
 	"log"
-	"net/http"
-
-	"clean_go_system/internal/adapter/postgres"
-	"clean_go_system/internal/core"
-	_ "github.com/lib/pq" // Postgres Driver
+	"os"
 )
 
+// command is one subcommand of the service binary.
+type command struct {
+	name    string
+	summary string
+	run     func(ctx context.Context, args []string) error
+}
+
+var commands = []command{
+	{name: "serve", summary: "run the HTTP API and background workers", run: runServe},
+	{name: "migrate", summary: "apply (up) or revert (down) database migrations", run: runMigrate},
+	{name: "worker", summary: "run only the email job consumers", run: runWorker},
+	{name: "seed", summary: "load fixture users", run: runSeed},
+	{name: "apikey", summary: "create or revoke API keys for service callers", run: runAPIKey},
+}
+
 func main() {
-	// 1. Infrastructure
-	connStr := "user=postgres dbname=mydb sslmode=disable"
-	db, err := sql.Open("postgres", connStr)
-	if err != nil {
-		log.Fatal(err)
+	// `go run ./cmd/server` with no arguments keeps its old meaning: serve.
+	name, args := "serve", os.Args[1:]
+	if len(args) > 0 {
+		name, args = args[0], args[1:]
 	}
-	defer db.Close()
 
-	// 2. Wiring Layers (The "Composition Root")
-	repo := postgres.NewPostgresRepository(db)
-	svc := core.NewUserService(repo)
-
-	// 3. Background Workers
-	emailPool := core.NewWorkerPool(5, 100) // 5 Workers, Buffer of 100
-	emailPool.Start()
-	defer emailPool.Stop()
+	for _, c := range commands {
+		if c.name == name {
+			if err := c.run(context.Background(), args); err != nil {
+				log.Fatalf("%s: %v", name, err)
+			}
+			return
+		}
+	}
 
-	// 4. HTTP Handlers (Using Standard Lib or Chi/Gin)
-	http.HandleFunc("/register", func(w http.ResponseWriter, r *http.Request) {
-		// Parse JSON...
-		// Call svc.Register()...
-		// Send Email Job to Pool: emailPool.JobQueue <- job
-		w.WriteHeader(http.StatusCreated)
-	})
+	usage()
+	os.Exit(2)
+}
 
-	// 5. Start Server
-	log.Println("Server starting on :8080")
-	if err := http.ListenAndServe(":8080", nil); err != nil {
-		log.Fatal(err)
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: %s <command> [flags]\n\ncommands:\n", os.Args[0])
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %-8s %s\n", c.name, c.summary)
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"clean_go_system/internal/adapter/postgres"
+)
+
+func runMigrate(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	steps := fs.Int("steps", 1, "number of migrations to revert (down only)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: migrate [-steps n] up|down")
+	}
+
+	a, err := bootstrap()
+	if err != nil {
+		return err
+	}
+	if a.cfg.DatabaseDriver != "postgres" {
+		return fmt.Errorf("driver %s migrates itself on start; migrate is for postgres", a.cfg.DatabaseDriver)
+	}
+	defer a.db.Close()
+
+	switch direction := fs.Arg(0); direction {
+	case "up":
+		err = postgres.MigrateUp(ctx, a.db)
+	case "down":
+		err = postgres.MigrateDown(ctx, a.db, *steps)
+	default:
+		return fmt.Errorf("unknown direction %q (want up or down)", direction)
+	}
+	if err != nil {
+		return err
+	}
+	a.log.Printf("migrate %s: done", fs.Arg(0))
+	return nil
+}
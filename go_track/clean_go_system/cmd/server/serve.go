@@ -0,0 +1,504 @@
+package main
+
+import (
+	"context"
+	"expvar"
+	"flag"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"clean-code-cookbook/go/pkg/budget"
+	"clean-code-cookbook/go/pkg/signing"
+	"clean-code-cookbook/go/pkg/tlsconfig"
+	graphqladapter "clean_go_system/internal/adapter/graphql"
+	httpadapter "clean_go_system/internal/adapter/http"
+	"clean_go_system/internal/adapter/memory"
+	"clean_go_system/internal/adapter/notify"
+	"clean_go_system/internal/adapter/oidc"
+	"clean_go_system/internal/adapter/postgres"
+	redisadapter "clean_go_system/internal/adapter/redis"
+	"clean_go_system/internal/adapter/webhook"
+	"clean_go_system/internal/config"
+	"clean_go_system/internal/core"
+	"clean_go_system/internal/domain"
+	"clean_go_system/pkg/compression"
+	"clean_go_system/pkg/faults"
+	"clean_go_system/pkg/lifecycle"
+	"clean_go_system/pkg/logger"
+	"clean_go_system/pkg/tracing"
+)
+
+func runServe(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	a, err := bootstrap()
+	if err != nil {
+		return err
+	}
+
+	reloader := config.NewReloader(a.cfg, 5*time.Second, a.log)
+	reloader.Subscribe(func(d config.Dynamic) {
+		a.log.Printf("live settings: log_level=%s chaos=%v", d.LogLevel, d.Chaos)
+		if a.chaos != nil {
+			a.chaos.Set(d.Chaos)
+		}
+	})
+
+	if err := a.setupEmail(); err != nil {
+		return err
+	}
+	if err := a.subscribe(); err != nil {
+		return err
+	}
+	blobs, blobPath, err := a.setupImages()
+	if err != nil {
+		return err
+	}
+
+	// HTTP Handlers (Using Standard Lib or Chi/Gin)
+	handler := httpadapter.NewHandler(a.users, a.log)
+	keyAuth := httpadapter.NewAPIKeyAuth(a.keys, a.log)
+	keyAdmin := httpadapter.NewAPIKeyHandler(a.keys, a.log)
+	mux := http.NewServeMux()
+	var register http.Handler = http.HandlerFunc(handler.Register)
+	if limit := a.rateLimit("register", func(r config.RateLimits) int { return r.RegisterPerMinute }); limit != nil {
+		register = limit.Middleware(register)
+	}
+	if a.cfg.APIKeysRequired {
+		register = keyAuth.Require(domain.ScopeUsersWrite, register)
+	}
+	mux.Handle("/register", register)
+	mux.Handle("POST /admin/keys", keyAuth.Require(domain.ScopeKeysAdmin, http.HandlerFunc(keyAdmin.Create)))
+	mux.Handle("DELETE /admin/keys/{id}", keyAuth.Require(domain.ScopeKeysAdmin, http.HandlerFunc(keyAdmin.Revoke)))
+	a.adminRoutes(mux, keyAuth)
+	if a.cfg.Webhooks.CallbackKeys != "" {
+		keys, err := signing.ParseKeys(a.cfg.Webhooks.CallbackKeys)
+		if err != nil {
+			return err
+		}
+		mux.Handle("POST /webhooks/events", webhook.NewReceiver(signing.NewKeyring(keys...), a.events, a.log).Handler())
+	}
+
+	// Users: session tokens, optionally obtained through an OIDC login.
+	// In server session mode logins hand out opaque tokens instead, which
+	// users can list and revoke.
+	sessions, idp := a.authentication()
+	if idp != nil {
+		oidcHandler := httpadapter.NewOIDCHandler(idp, a.logins, sessions, a.log)
+		oidcHandler.Sessions = a.sessions
+		mux.HandleFunc("GET /auth/oidc/login", oidcHandler.Login)
+		mux.HandleFunc("GET /auth/oidc/callback", oidcHandler.Callback)
+	}
+	bearer := httpadapter.NewBearerAuth(sessions, idp, a.logins, a.log)
+	bearer.Sessions = a.sessions
+	mux.Handle("GET /me", bearer.Middleware(http.HandlerFunc(handler.Me)))
+	mux.Handle("PUT /me/locale", bearer.Middleware(http.HandlerFunc(handler.SetLocale)))
+	mux.Handle("GET /users", bearer.Middleware(a.queryRateLimited("users.list", a.compressed(http.HandlerFunc(handler.List)))))
+	mux.Handle("GET /users/{id}", bearer.Middleware(http.HandlerFunc(handler.Get)))
+	mux.Handle("DELETE /users/{id}", bearer.Middleware(http.HandlerFunc(handler.Delete)))
+	mux.Handle("POST /graphql", bearer.Middleware(a.queryRateLimited("graphql", a.compressed(graphqladapter.NewHandler(a.users, a.products, graphqladapter.Limits{
+		MaxDepth:      a.cfg.GraphQL.MaxDepth,
+		MaxComplexity: a.cfg.GraphQL.MaxComplexity,
+		Introspection: a.cfg.GraphQL.Introspection,
+	}, a.log)))))
+	notificationPrefs := httpadapter.NewNotificationPreferencesHandler(a.preferences, a.log)
+	mux.Handle("GET /me/notifications", bearer.Middleware(http.HandlerFunc(notificationPrefs.Get)))
+	mux.Handle("PUT /me/notifications", bearer.Middleware(http.HandlerFunc(notificationPrefs.Put)))
+	if a.sessions != nil {
+		sessionAdmin := httpadapter.NewSessionHandler(a.sessions, a.log)
+		mux.Handle("GET /sessions", bearer.Middleware(http.HandlerFunc(sessionAdmin.List)))
+		mux.Handle("DELETE /sessions/{id}", bearer.Middleware(http.HandlerFunc(sessionAdmin.Revoke)))
+	}
+
+	// Uploads: their bodies may exceed MaxBodyBytes, up to the image cap
+	// plus room for the multipart framing.
+	uploads := []string{"POST /me/avatar", "POST /products/{sku}/images"}
+	if a.images != nil {
+		images := httpadapter.NewImageHandler(a.images, a.log)
+		mux.Handle(uploads[0], bearer.Middleware(http.HandlerFunc(images.UploadAvatar)))
+		mux.Handle(uploads[1], bearer.Middleware(http.HandlerFunc(images.UploadProductImage)))
+		mux.HandleFunc("GET /images/{kind}/{owner}/{id}", images.URL)
+	}
+
+	var passwords *core.PasswordService
+	if a.passwords != nil {
+		passwords = a.passwords(a.emailQueue)
+		reset := httpadapter.NewPasswordHandler(passwords, a.log)
+		mux.HandleFunc("POST /password/reset-request", reset.RequestReset)
+		mux.HandleFunc("POST /password/reset", reset.Reset)
+	}
+	verification := httpadapter.NewVerificationHandler(a.verification, a.log)
+	mux.HandleFunc("POST /email/verify", verification.Verify)
+
+	// Load shedding thresholds and counters are published with expvar.
+	shedder := httpadapter.NewLoadShedder(a.cfg.Shedding.MaxInFlight, time.Duration(a.cfg.Shedding.MaxP99MS)*time.Millisecond)
+	expvar.Publish("load_shedder", expvar.Func(func() any { return shedder.Stats() }))
+	// So are the Postgres connection pool and the latency of each query.
+	if a.cfg.DatabaseDriver == "postgres" {
+		expvar.Publish("db_pool", expvar.Func(func() any { return postgres.Pool(a.db) }))
+		expvar.Publish("db_queries", expvar.Func(func() any { return postgres.QueryLatencies() }))
+	}
+
+	// The API's routes are documented at /openapi.json, and checked
+	// against the document where OPENAPI_VALIDATE is set.
+	spec, err := httpadapter.NewOpenAPI()
+	if err != nil {
+		return err
+	}
+	var api http.Handler = mux
+	if a.cfg.OpenAPIValidate {
+		api = spec.Validate(mux)
+	}
+
+	// With tenancy on, every route but the API docs, provider events, the
+	// dev tools and signed blob links serves one tenant.
+	tenanted := api
+	if a.cfg.Tenancy.Enabled() {
+		tenanted = httpadapter.NewTenantResolver(a.cfg.Tenancy.BaseDomain, a.cfg.Tenancy.IDs()...).Middleware(api)
+	}
+	routes := http.NewServeMux()
+	routes.HandleFunc("GET /openapi.json", spec.ServeSpec)
+	routes.HandleFunc("GET /docs", spec.ServeDocs)
+	// Providers post bounces for every tenant to one address.
+	events, err := a.emailEvents()
+	if err != nil {
+		return err
+	}
+	if events != nil {
+		routes.Handle("POST /email/events", events)
+	}
+	if blobs != nil {
+		routes.Handle("GET "+blobPath+"/", http.StripPrefix(blobPath, blobs))
+	}
+	if err := a.devRoutes(routes); err != nil {
+		return err
+	}
+	routes.Handle("/", tenanted)
+
+	limits := http.NewServeMux()
+	limits.Handle("/", httpadapter.LimitBody(int64(a.cfg.MaxBodyBytes), routes))
+	if a.images != nil {
+		for _, pattern := range uploads {
+			limits.Handle(pattern, httpadapter.LimitBody(int64(a.cfg.Uploads.MaxBytes)+64<<10, routes))
+		}
+	}
+
+	// Middleware, innermost first: body limit, chaos, the request deadline,
+	// shedding (so rejected requests cost next to nothing), the access log
+	// (which sees the shed ones too), tracing.
+	var root http.Handler = limits
+	root = faults.Middleware(a.chaos, root)
+	root = budget.Middleware(time.Duration(a.cfg.RequestTimeoutMS)*time.Millisecond, root)
+	root = shedder.Middleware(root)
+	// LOG_LEVEL gates the access log, live: it logs at info, so warn (the
+	// test profile's) turns it off.
+	root = logger.Middleware(logger.NewStructured(os.Stdout, reloader.LogLevel()).With(slog.String("component", "http")), root)
+	root = tracing.Middleware(root)
+
+	server := &http.Server{
+		Addr:              a.cfg.HTTPAddr,
+		Handler:           root,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	httpServer, redirect, err := a.httpServers(server)
+	if err != nil {
+		return err
+	}
+
+	// Lifecycle: started top to bottom, stopped bottom to top, so the
+	// server drains before the relay, the relay before the bus it feeds,
+	// the bus before the pool, and the pool before the DB.
+	runner := lifecycle.NewRunner(a.log)
+	runner.Add("config-reloader", reloader, time.Second)
+	runner.Add("dependencies", a.verifier(), 0)
+	if db := a.database(); db != nil {
+		runner.Add("database", db, 5*time.Second)
+	}
+	if cache := a.cache(); cache != nil {
+		runner.Add("redis", cache, time.Second)
+	}
+	runner.Add("email-workers", a.workers(), 15*time.Second)
+	if passwords != nil {
+		runner.Add("password-resets", lifecycle.Func{OnStop: passwords.Stop}, 5*time.Second)
+	}
+	if images := a.imageWorkers(); images != nil {
+		runner.Add("image-workers", images, 30*time.Second)
+	}
+	runner.Add("event-bus", a.events, 5*time.Second)
+	inbound, err := a.inbound()
+	if err != nil {
+		return err
+	}
+	if inbound != nil {
+		runner.Add("nats-subscriber", inbound, 5*time.Second)
+	}
+	if broker := a.broker(); broker != nil {
+		runner.Add("kafka", broker, 5*time.Second)
+	}
+	if a.relay != nil {
+		runner.Add("outbox-relay", a.relay, 5*time.Second)
+	}
+	if a.jobs != nil {
+		runner.Add("scheduler", a.jobs, 5*time.Second)
+	}
+	runner.Add("http-server", httpServer, 10*time.Second)
+	if redirect != nil {
+		runner.Add("http-redirect", redirect, 5*time.Second)
+	}
+	// The expvar counters stay off the public listener.
+	if a.cfg.AdminAddr != "" {
+		admin := http.NewServeMux()
+		admin.Handle("/debug/vars", expvar.Handler())
+		runner.Add("admin-server", lifecycle.NewHTTPServer(&http.Server{
+			Addr:              a.cfg.AdminAddr,
+			Handler:           admin,
+			ReadHeaderTimeout: 5 * time.Second,
+		}), 5*time.Second)
+	}
+
+	a.log.Printf("Server starting on %s", a.cfg.HTTPAddr)
+	return runner.Run(ctx)
+}
+
+// adminRoutes mounts the operator endpoints behind API keys with the admin
+// scope. With RabbitMQ the email queue is the broker's to manage, so only
+// the in-memory pools are offered.
+func (a *app) adminRoutes(mux *http.ServeMux, keyAuth *httpadapter.APIKeyAuth) {
+	admin := core.NewAdmin(a.users, a.authorizer)
+	admin.Sessions = a.sessions
+	admin.Deliveries = a.deliveries
+	admin.Suppressions = a.suppressions
+	if a.emailPool != nil {
+		admin.AddPool("email", a.emailPool)
+	}
+	if a.imagePool != nil {
+		admin.AddPool("images", a.imagePool)
+	}
+	for name, c := range a.caches {
+		admin.AddCache(name, c)
+	}
+
+	h := httpadapter.NewAdminHandler(admin, a.log)
+	routes := map[string]http.HandlerFunc{
+		"GET /admin/users":                              h.SearchUsers,
+		"GET /admin/users/export":                       h.ExportUsers,
+		"POST /admin/users/{id}/suspend":                h.SuspendUser,
+		"POST /admin/users/{id}/verify":                 h.VerifyUser,
+		"GET /admin/emails":                             h.EmailDeliveries,
+		"DELETE /admin/suppressions/{email}":            h.LiftSuppression,
+		"GET /admin/workers":                            h.Pools,
+		"POST /admin/workers/{name}/pause":              h.PausePool,
+		"POST /admin/workers/{name}/resume":             h.ResumePool,
+		"POST /admin/workers/{name}/drain":              h.DrainPool,
+		"POST /admin/workers/{name}/retry-dead-letters": h.RetryDeadLetters,
+		"POST /admin/caches/{name}/flush":               h.FlushCache,
+	}
+	for pattern, handler := range routes {
+		var route http.Handler = handler
+		if strings.HasPrefix(pattern, "GET /admin/users") {
+			route = a.compressed(route)
+		}
+		if pattern == "GET /admin/users" {
+			route = a.queryRateLimited("admin.users", route)
+		}
+		mux.Handle(pattern, keyAuth.Require(domain.ScopeAdmin, route))
+	}
+}
+
+// compressed compresses the responses of h, a list, search or export
+// endpoint, as COMPRESSION_* allow. Small single-record answers are not
+// worth it.
+func (a *app) compressed(h http.Handler) http.Handler {
+	if len(a.cfg.Compression.Types) == 0 {
+		return h
+	}
+	return compression.Middleware(compression.Config{MinBytes: a.cfg.Compression.MinBytes, Types: a.cfg.Compression.Types}, h)
+}
+
+// devRoutes mounts the tools for working on emails: template previews in
+// the dev profile, and the mailbox when EMAIL_PROVIDER=mailbox. The
+// mailbox holds what this process sent; with RabbitMQ, that is the
+// worker's to send.
+func (a *app) devRoutes(mux *http.ServeMux) error {
+	if a.cfg.Profile == config.ProfileDev {
+		templates, err := a.messageTemplates()
+		if err != nil {
+			return err
+		}
+		preview := notify.NewPreview(templates)
+		mux.HandleFunc("GET /dev/emails", preview.Index)
+		mux.HandleFunc("GET /dev/emails/{template}", preview.Show)
+	}
+	if a.mailbox != nil {
+		mux.HandleFunc("GET /dev/mailbox", a.mailbox.List)
+		mux.HandleFunc("GET /dev/mailbox/{id}", a.mailbox.Show)
+		mux.HandleFunc("DELETE /dev/mailbox", a.mailbox.Clear)
+	}
+	return nil
+}
+
+// authentication returns the session token issuer and, when OIDC is
+// configured, the identity provider; nil otherwise.
+func (a *app) authentication() (*httpadapter.SessionTokens, httpadapter.IdentityProvider) {
+	if a.cfg.Auth.TokenSecret == "" {
+		a.log.Printf("auth: AUTH_TOKEN_SECRET unset, session and verification tokens are valid on this process only")
+	}
+	sessions := httpadapter.NewSessionTokens("clean_go_system", a.tokenSecret, time.Duration(a.cfg.Auth.TokenTTLSeconds)*time.Second)
+	if !a.cfg.Auth.OIDCEnabled() {
+		return sessions, nil
+	}
+	return sessions, oidc.NewProvider(oidc.Config{
+		Issuer:       a.cfg.Auth.OIDCIssuer,
+		ClientID:     a.cfg.Auth.OIDCClientID,
+		ClientSecret: a.cfg.Auth.OIDCClientSecret,
+		RedirectURL:  a.cfg.Auth.OIDCRedirectURL,
+		Scopes:       []string{"email", "profile"},
+	})
+}
+
+// rateLimiter shares limits across instances through Redis when there is
+// one; otherwise each instance counts on its own.
+func (a *app) rateLimiter() domain.RateLimiter {
+	if a.redis != nil {
+		return redisadapter.NewRateLimiter(a.redis)
+	}
+	return memory.NewRateLimiter()
+}
+
+// rateLimit caps the endpoint called name per caller at the per-minute
+// rate perMinute picks from the global limits, or from a tenant's own
+// where it has them; nil when no limit applies at all.
+func (a *app) rateLimit(name string, perMinute func(config.RateLimits) int) *httpadapter.RateLimit {
+	limitOf := func(r config.RateLimits) domain.RateLimit {
+		return domain.RateLimit{Count: perMinute(r), Period: time.Minute, Burst: r.Burst}
+	}
+	perTenant := make(map[domain.TenantID]domain.RateLimit)
+	for id, t := range a.cfg.Tenancy.Tenants {
+		if t.RateLimits != nil {
+			perTenant[domain.TenantID(id)] = limitOf(*t.RateLimits)
+		}
+	}
+	limit := limitOf(a.cfg.RateLimits)
+	if limit.Count == 0 && len(perTenant) == 0 {
+		return nil
+	}
+	l := httpadapter.NewRateLimit(a.rateLimiter(), name, limit, a.log)
+	l.PerTenant = perTenant
+	return l
+}
+
+// queryRateLimited caps h, a list or search endpoint, at QueryPerMinute
+// in a bucket of its own called name. It must run inside the
+// authentication middleware, so callers are told apart by who they are.
+func (a *app) queryRateLimited(name string, h http.Handler) http.Handler {
+	l := a.rateLimit(name, func(r config.RateLimits) int { return r.QueryPerMinute })
+	if l == nil {
+		return h
+	}
+	return l.Middleware(h)
+}
+
+// httpServers enables TLS on server when configured and returns the
+// optional plaintext listener that redirects to HTTPS (and answers ACME
+// http-01 challenges in autocert mode).
+func (a *app) httpServers(server *http.Server) (primary, redirect *lifecycle.HTTPServer, err error) {
+	tlsCfg := a.cfg.TLS
+	primary = lifecycle.NewHTTPServer(server)
+	if !tlsCfg.Enabled() {
+		return primary, nil, nil
+	}
+
+	_, httpsPort, _ := net.SplitHostPort(a.cfg.HTTPAddr)
+	redirectHandler := httpadapter.RedirectToHTTPS(httpsPort)
+
+	if tlsCfg.Autocert() {
+		cfg, manager := httpadapter.NewAutocertTLSConfig(tlsCfg.AutocertDomains, tlsCfg.AutocertCacheDir)
+		server.TLSConfig = cfg
+		redirectHandler = manager.HTTPHandler(redirectHandler)
+	} else if tlsCfg.MutualTLS() {
+		// The key pair is loaded into the config, so CertFile stays empty.
+		server.TLSConfig, err = tlsconfig.Server(tlsconfig.Files{
+			CertFile:  tlsCfg.CertFile,
+			KeyFile:   tlsCfg.KeyFile,
+			CAFile:    tlsCfg.ClientCAFile,
+			PeerNames: tlsCfg.ClientNames,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+	} else {
+		server.TLSConfig = httpadapter.NewTLSConfig()
+		primary.CertFile, primary.KeyFile = tlsCfg.CertFile, tlsCfg.KeyFile
+	}
+
+	if tlsCfg.RedirectAddr == "" {
+		return primary, nil, nil
+	}
+	return primary, lifecycle.NewHTTPServer(&http.Server{
+		Addr:              tlsCfg.RedirectAddr,
+		Handler:           redirectHandler,
+		ReadHeaderTimeout: 5 * time.Second,
+	}), nil
+}
+
+// verifier probes the database and every configured upstream before the
+// servers start. Upstreams are optional in degraded mode; the DB never is.
+func (a *app) verifier() lifecycle.Component {
+	policy := lifecycle.DefaultRetryPolicy
+	policy.Attempts = a.cfg.StartupAttempts
+
+	var deps []lifecycle.Dependency
+	switch {
+	case a.db != nil:
+		deps = append(deps, lifecycle.Dependency{Name: "database", Check: a.db.PingContext})
+	case a.mongo != nil:
+		deps = append(deps, lifecycle.Dependency{Name: "database", Check: func(ctx context.Context) error {
+			return a.mongo.Ping(ctx, nil)
+		}})
+	}
+	for _, broker := range a.cfg.KafkaBrokers {
+		deps = append(deps, lifecycle.Dependency{Name: "kafka " + broker, Check: lifecycle.TCPCheck(broker)})
+	}
+	if a.redis != nil {
+		// The cache degrades to direct reads, so it never blocks startup.
+		deps = append(deps, lifecycle.Dependency{
+			Name:     "redis",
+			Check:    func(ctx context.Context) error { return a.redis.Ping(ctx).Err() },
+			Optional: true,
+		})
+	}
+	for name, addr := range a.cfg.Upstreams {
+		deps = append(deps, lifecycle.Dependency{
+			Name:     "upstream " + name,
+			Check:    lifecycle.TCPCheck(addr),
+			Optional: a.cfg.StartupDegraded,
+		})
+	}
+	return lifecycle.NewVerifier(policy, a.log, deps...)
+}
+
+// database prepares the store on start and closes its pool on shutdown;
+// nil for the memory driver.
+func (a *app) database() lifecycle.Component {
+	switch {
+	case a.db != nil:
+		return lifecycle.Func{
+			OnStart: a.ensureSchema,
+			OnStop:  func(context.Context) error { return a.db.Close() },
+		}
+	case a.mongo != nil:
+		return lifecycle.Func{
+			OnStart: a.ensureSchema,
+			OnStop:  a.mongo.Disconnect,
+		}
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"flag"
+
+	"clean_go_system/pkg/lifecycle"
+)
+
+// runWorker runs the job consumers without the HTTP API: the outbox relay
+// feeds pending events through the bus into the email workers, so workers
+// scale separately from the API.
+func runWorker(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("worker", flag.ExitOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	a, err := bootstrap()
+	if err != nil {
+		return err
+	}
+
+	if err := a.setupEmail(); err != nil {
+		return err
+	}
+	if err := a.subscribe(); err != nil {
+		return err
+	}
+
+	runner := lifecycle.NewRunner(a.log)
+	runner.Add("dependencies", a.verifier(), 0)
+	if db := a.database(); db != nil {
+		runner.Add("database", db, 0)
+	}
+	if cache := a.cache(); cache != nil {
+		runner.Add("redis", cache, 0)
+	}
+	runner.Add("email-workers", a.workers(), 0)
+	runner.Add("event-bus", a.events, 0)
+	inbound, err := a.inbound()
+	if err != nil {
+		return err
+	}
+	if inbound != nil {
+		runner.Add("nats-subscriber", inbound, 0)
+	}
+	if broker := a.broker(); broker != nil {
+		runner.Add("kafka", broker, 0)
+	}
+	if a.relay != nil {
+		runner.Add("outbox-relay", a.relay, 0)
+	}
+	if a.jobs != nil {
+		runner.Add("scheduler", a.jobs, 0)
+	}
+
+	a.log.Printf("Worker starting with %d consumers", a.cfg.EmailWorkers)
+	return runner.Run(ctx)
+}
@@ -0,0 +1,67 @@
+// Package authsvc talks to the auth service (go/services/auth), which owns
+// every password: its /login is the only place one is checked.
+package authsvc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"clean-code-cookbook/go/pkg/signing"
+	"clean_go_system/internal/domain"
+	"clean_go_system/pkg/tracing"
+)
+
+type setPasswordRequest struct {
+	Email        string `json:"email"`
+	PasswordHash []byte `json:"password_hash"`
+}
+
+// CredentialStore implements domain.CredentialRepository by PUTting the
+// hash to the auth service's /credentials/{user_id}, signed with keys.
+type CredentialStore struct {
+	// Client sends the requests; it defaults to one with a 5s timeout.
+	Client *http.Client
+
+	baseURL string
+	keys    *signing.Keyring
+}
+
+func NewCredentialStore(baseURL string, keys *signing.Keyring) *CredentialStore {
+	return &CredentialStore{Client: &http.Client{Timeout: 5 * time.Second}, baseURL: strings.TrimSuffix(baseURL, "/"), keys: keys}
+}
+
+// SetPassword replaces the password user signs in with at the auth
+// service, which also revokes their refresh tokens there.
+func (s *CredentialStore) SetPassword(ctx context.Context, user domain.User, hash []byte) error {
+	body, err := json.Marshal(setPasswordRequest{Email: user.Email, PasswordHash: hash})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.baseURL+"/credentials/"+url.PathEscape(user.ID.String()), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if tp := tracing.TraceParent(ctx); tp != "" {
+		req.Header.Set(tracing.Header, tp)
+	}
+	s.keys.SignRequest(req, body)
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("auth service: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("auth service answered %s", resp.Status)
+	}
+	return nil
+}
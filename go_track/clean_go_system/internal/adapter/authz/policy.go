@@ -0,0 +1,87 @@
+// Package authz implements domain.Authorizer with the pkg/policy engine.
+package authz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+
+	"clean_go_system/internal/domain"
+	"clean_go_system/pkg/policy"
+)
+
+// DefaultPolicies are the rules that hold in every deployment: a user may
+// read and delete their own profile and upload their own avatar, and an
+// API key with the admin scope may run the operator actions of
+// core.Admin. Deployments add to them with a policy file, e.g. to say who may upload
+// product images.
+func DefaultPolicies() []policy.Policy {
+	self := []policy.Condition{
+		{Attr: "subject.kind", Op: "eq", Value: domain.ActorUser},
+		{Attr: "resource.id", Op: "eq", Ref: "subject.id"},
+	}
+	return []policy.Policy{{
+		ID:        "users-read-self",
+		Effect:    policy.Allow,
+		Actions:   []string{"users:read"},
+		Resources: []string{"user"},
+		When:      self,
+	}, {
+		ID:        "users-delete-self",
+		Effect:    policy.Allow,
+		Actions:   []string{"users:delete"},
+		Resources: []string{"user"},
+		When:      self,
+	}, {
+		ID:        "images-upload-own-avatar",
+		Effect:    policy.Allow,
+		Actions:   []string{"images:upload"},
+		Resources: []string{"avatar"},
+		When:      self,
+	}, {
+		ID:        "admin-keys",
+		Effect:    policy.Allow,
+		Actions:   []string{"users:*", "workers:*", "caches:*", "emails:*"},
+		Resources: []string{"*"},
+		When: []policy.Condition{
+			{Attr: "subject.kind", Op: "eq", Value: domain.ActorAPIKey},
+			{Attr: "subject.scopes", Op: "contains", Value: domain.ScopeAdmin},
+		},
+	}}
+}
+
+// PolicyAuthorizer evaluates requests against a policy.Engine. Actors and
+// resources become attribute maps with their Kind/Type and ID added as
+// "kind"/"type" and "id".
+type PolicyAuthorizer struct {
+	engine *policy.Engine
+}
+
+func NewPolicyAuthorizer(policies ...policy.Policy) (*PolicyAuthorizer, error) {
+	engine, err := policy.New(policies...)
+	if err != nil {
+		return nil, err
+	}
+	return &PolicyAuthorizer{engine: engine}, nil
+}
+
+func (a *PolicyAuthorizer) Authorize(ctx context.Context, actor domain.Actor, action string, resource domain.Resource) error {
+	subject := maps.Clone(actor.Attrs)
+	if subject == nil {
+		subject = policy.Attributes{}
+	}
+	subject["kind"], subject["id"] = actor.Kind, actor.ID
+
+	target := maps.Clone(resource.Attrs)
+	if target == nil {
+		target = policy.Attributes{}
+	}
+	target["type"], target["id"] = resource.Type, resource.ID
+
+	err := a.engine.Check(policy.Request{Subject: subject, Action: action, Resource: target})
+	if errors.Is(err, policy.ErrDenied) {
+		return fmt.Errorf("%w: %v", domain.ErrForbidden, err)
+	}
+	return err
+}
@@ -0,0 +1,62 @@
+// Package cache decorates a domain.UserRepository with cache-aside reads.
+package cache
+
+import (
+	"context"
+
+	"clean_go_system/internal/domain"
+	"github.com/google/uuid"
+)
+
+// Repository composes a UserRepository with a UserCache: reads go through
+// the cache, writes go to the repository and then invalidate.
+//
+// Writes invalidate rather than populate, so the next read loads the row as
+// committed. A reader racing an uncommitted write may still cache the old
+// row; the cache's TTL bounds how long that can last.
+//
+// Lookups that include soft-deleted users (domain.WithDeleted) bypass the
+// cache, so a deleted user is never cached for the lookups that hide it.
+type Repository struct {
+	repo  domain.UserRepository
+	cache domain.UserCache
+}
+
+func NewRepository(repo domain.UserRepository, cache domain.UserCache) *Repository {
+	return &Repository{repo: repo, cache: cache}
+}
+
+func (r *Repository) Save(ctx context.Context, u domain.User) error {
+	if err := r.repo.Save(ctx, u); err != nil {
+		return err
+	}
+	r.cache.Invalidate(ctx, u)
+	return nil
+}
+
+func (r *Repository) Update(ctx context.Context, u domain.User) error {
+	// The email may change: drop the entry cached under the old one while
+	// the cache still knows it.
+	r.cache.Invalidate(ctx, u)
+	if err := r.repo.Update(ctx, u); err != nil {
+		return err
+	}
+	r.cache.Invalidate(ctx, u)
+	return nil
+}
+
+func (r *Repository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
+	load := func(ctx context.Context) (*domain.User, error) { return r.repo.GetByEmail(ctx, email) }
+	if domain.IncludesDeleted(ctx) {
+		return load(ctx)
+	}
+	return r.cache.GetByEmail(ctx, email, load)
+}
+
+func (r *Repository) GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	load := func(ctx context.Context) (*domain.User, error) { return r.repo.GetByID(ctx, id) }
+	if domain.IncludesDeleted(ctx) {
+		return load(ctx)
+	}
+	return r.cache.GetByID(ctx, id, load)
+}
@@ -0,0 +1,79 @@
+package cache
+
+import (
+	"context"
+	"log"
+
+	"clean-code-cookbook/go/pkg/cacheaside"
+	"clean_go_system/internal/domain"
+	"github.com/google/uuid"
+)
+
+// UserCache implements domain.UserCache over any cacheaside.Store, such as
+// the Redis or in-memory one. Users are cached under one key per lookup
+// path, so GetByEmail and GetByID both hit after either one loaded.
+//
+// Keys carry the tenant, so tenants sharing a store never see each other's
+// users.
+type UserCache struct {
+	cache *cacheaside.Cache[domain.User]
+}
+
+var _ domain.UserCache = (*UserCache)(nil)
+
+func NewUserCache(store cacheaside.Store[domain.User], logger *log.Logger) *UserCache {
+	return &UserCache{cache: cacheaside.New(store, logger)}
+}
+
+func tenantPrefix(ctx context.Context) string {
+	return "user:" + string(domain.TenantOf(ctx)) + ":"
+}
+
+func emailKey(ctx context.Context, email string) string {
+	return tenantPrefix(ctx) + "email:" + email
+}
+
+func idKey(ctx context.Context, id uuid.UUID) string {
+	return tenantPrefix(ctx) + "id:" + id.String()
+}
+
+// keysIn lists every key u is cached under in ctx's tenant.
+func keysIn(ctx context.Context) func(u domain.User) []string {
+	return func(u domain.User) []string { return []string{emailKey(ctx, u.Email), idKey(ctx, u.ID)} }
+}
+
+func (c *UserCache) GetByEmail(ctx context.Context, email string, load func(context.Context) (*domain.User, error)) (*domain.User, error) {
+	return c.get(ctx, emailKey(ctx, email), load)
+}
+
+func (c *UserCache) GetByID(ctx context.Context, id uuid.UUID, load func(context.Context) (*domain.User, error)) (*domain.User, error) {
+	return c.get(ctx, idKey(ctx, id), load)
+}
+
+func (c *UserCache) get(ctx context.Context, key string, load func(context.Context) (*domain.User, error)) (*domain.User, error) {
+	u, err := c.cache.Get(ctx, key, func(ctx context.Context) (domain.User, error) {
+		u, err := load(ctx)
+		if err != nil {
+			return domain.User{}, err
+		}
+		return *u, nil
+	}, keysIn(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+func (c *UserCache) Invalidate(ctx context.Context, u domain.User) {
+	keys := keysIn(ctx)(u)
+	if old, ok := c.cache.Peek(ctx, idKey(ctx, u.ID)); ok && old.Email != u.Email {
+		keys = append(keys, emailKey(ctx, old.Email))
+	}
+	c.cache.Invalidate(ctx, keys...)
+}
+
+// Flush drops every user cached for ctx's tenant and returns how many
+// keys went; a user cached by email and ID counts twice.
+func (c *UserCache) Flush(ctx context.Context) (int, error) {
+	return c.cache.Flush(ctx, tenantPrefix(ctx))
+}
@@ -0,0 +1,144 @@
+// Package catalogsvc reads products from the catalog service
+// (go/services/catalog) through its HTTP read API.
+package catalogsvc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"clean-code-cookbook/go/pkg/edgetoken"
+	"clean-code-cookbook/go/pkg/page"
+	httpadapter "clean_go_system/internal/adapter/http"
+	"clean_go_system/internal/domain"
+	"clean_go_system/pkg/tracing"
+)
+
+// productDTO is the catalog's JSON shape; the domain model has no tags.
+type productDTO struct {
+	ID    string  `json:"id"`
+	Name  string  `json:"name"`
+	Price float64 `json:"price"`
+}
+
+func (d productDTO) toDomain() domain.Product {
+	return domain.Product{ID: d.ID, Name: d.Name, Price: d.Price}
+}
+
+type searchResponse struct {
+	Items []productDTO `json:"items"`
+	Next  page.Cursor  `json:"next"`
+}
+
+// Catalog implements domain.ProductCatalog with the catalog's
+// GET /products/{id} and GET /products.
+type Catalog struct {
+	// Client sends the requests; it defaults to one with a 5s timeout.
+	Client *http.Client
+	// Identity, when set, vouches for the signed-in caller with an
+	// x-edge-identity token addressed to "catalog", as a catalog started
+	// with CATALOG_IDENTITY_SECRET requires.
+	Identity *edgetoken.Signer
+
+	baseURL string
+}
+
+func NewCatalog(baseURL string) *Catalog {
+	return &Catalog{Client: &http.Client{Timeout: 5 * time.Second}, baseURL: strings.TrimSuffix(baseURL, "/")}
+}
+
+// GetProduct returns the product with id.
+func (c *Catalog) GetProduct(ctx context.Context, id string) (*domain.Product, error) {
+	var dto productDTO
+	if err := c.get(ctx, "/products/"+url.PathEscape(id), &dto); err != nil {
+		return nil, err
+	}
+	product := dto.toDomain()
+	return &product, nil
+}
+
+// SearchProducts returns one page of the products matching search. The
+// catalog clamps the page size and checks the sort.
+func (c *Catalog) SearchProducts(ctx context.Context, search domain.ProductSearch) (page.Page[domain.Product], error) {
+	q := url.Values{}
+	if search.Query != "" {
+		q.Set("q", search.Query)
+	}
+	if search.MinPrice != 0 {
+		q.Set("min_price", strconv.FormatFloat(search.MinPrice, 'f', -1, 64))
+	}
+	if search.MaxPrice != 0 {
+		q.Set("max_price", strconv.FormatFloat(search.MaxPrice, 'f', -1, 64))
+	}
+	if search.Page.Size > 0 {
+		q.Set("size", strconv.Itoa(search.Page.Size))
+	}
+	if search.Page.After != "" {
+		q.Set("after", string(search.Page.After))
+	}
+	if search.Page.Sort.Field != "" {
+		q.Set("sort", search.Page.Sort.String())
+	}
+
+	var resp searchResponse
+	if err := c.get(ctx, "/products?"+q.Encode(), &resp); err != nil {
+		return page.Page[domain.Product]{}, err
+	}
+	products := page.Page[domain.Product]{Items: make([]domain.Product, len(resp.Items)), Next: resp.Next}
+	for i, dto := range resp.Items {
+		products.Items[i] = dto.toDomain()
+	}
+	return products, nil
+}
+
+// get decodes the JSON answer to GET path into into, mapping the
+// catalog's error statuses to domain errors.
+func (c *Catalog) get(ctx context.Context, path string, into any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if tp := tracing.TraceParent(ctx); tp != "" {
+		req.Header.Set(tracing.Header, tp)
+	}
+	if c.Identity != nil {
+		p, ok := httpadapter.PrincipalFrom(ctx)
+		if !ok {
+			return fmt.Errorf("catalog service: no signed-in caller to vouch for")
+		}
+		token, err := c.Identity.Sign(edgetoken.Caller{Subject: p.UserID.String()}, "catalog")
+		if err != nil {
+			return err
+		}
+		req.Header.Set(edgetoken.Header, token)
+	}
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", domain.ErrCatalogUnavailable, err)
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		if err := json.NewDecoder(resp.Body).Decode(into); err != nil {
+			return fmt.Errorf("catalog service: %w", err)
+		}
+		return nil
+	case http.StatusNotFound:
+		return domain.ErrProductNotFound
+	case http.StatusBadRequest:
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%w: %s", domain.ErrInvalidProductSearch, strings.TrimSpace(string(msg)))
+	case http.StatusServiceUnavailable:
+		return fmt.Errorf("%w: %s", domain.ErrCatalogUnavailable, resp.Status)
+	default:
+		return fmt.Errorf("catalog service answered %s", resp.Status)
+	}
+}
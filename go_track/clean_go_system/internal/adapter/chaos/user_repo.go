@@ -0,0 +1,61 @@
+// Package chaos decorates ports with fault injection from pkg/faults, for
+// resilience testing in staging.
+package chaos
+
+import (
+	"context"
+
+	"clean_go_system/internal/domain"
+	"clean_go_system/pkg/faults"
+	"github.com/google/uuid"
+)
+
+// UserRepoTarget is the faults target for every repository call.
+const UserRepoTarget = "users.repo"
+
+// UserRepository injects the "users.repo" fault into every call of repo.
+type UserRepository struct {
+	repo     domain.UserRepository
+	injector *faults.Injector
+}
+
+func NewUserRepository(repo domain.UserRepository, injector *faults.Injector) *UserRepository {
+	return &UserRepository{repo: repo, injector: injector}
+}
+
+func (r *UserRepository) Save(ctx context.Context, u domain.User) error {
+	return r.injector.Do(ctx, UserRepoTarget, func(ctx context.Context) error {
+		return r.repo.Save(ctx, u)
+	})
+}
+
+func (r *UserRepository) Update(ctx context.Context, u domain.User) error {
+	return r.injector.Do(ctx, UserRepoTarget, func(ctx context.Context) error {
+		return r.repo.Update(ctx, u)
+	})
+}
+
+func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
+	return r.get(ctx, func(ctx context.Context) (*domain.User, error) {
+		return r.repo.GetByEmail(ctx, email)
+	})
+}
+
+func (r *UserRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	return r.get(ctx, func(ctx context.Context) (*domain.User, error) {
+		return r.repo.GetByID(ctx, id)
+	})
+}
+
+func (r *UserRepository) get(ctx context.Context, load func(ctx context.Context) (*domain.User, error)) (*domain.User, error) {
+	var u *domain.User
+	err := r.injector.Do(ctx, UserRepoTarget, func(ctx context.Context) error {
+		var err error
+		u, err = load(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return u, nil
+}
@@ -0,0 +1,153 @@
+// Package composite chains several UserRepositories into one, falling back
+// along the chain when a source fails.
+package composite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"clean_go_system/internal/domain"
+	"github.com/google/uuid"
+)
+
+// Source is one link of the chain.
+type Source struct {
+	Name string
+	Repo domain.UserRepository
+	// Timeout bounds each read from this source; zero means only the
+	// caller's deadline applies.
+	Timeout time.Duration
+}
+
+// SourceHealth is a snapshot of a source's circuit, for logs and metrics.
+type SourceHealth struct {
+	Name                string
+	Healthy             bool
+	ConsecutiveFailures int
+}
+
+type source struct {
+	Source
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+}
+
+// UserRepository reads from the primary and, when it fails, from each
+// fallback in turn (e.g. a read replica, then a stale snapshot). Writes only
+// ever go to the primary: a fallback is a copy, not a place to commit.
+//
+// A source that fails FailureThreshold reads in a row is skipped for
+// Cooldown; if every source is cooling down, all are tried anyway.
+// domain.ErrUserNotFound is an answer, not a failure, and ends the chain.
+type UserRepository struct {
+	FailureThreshold int
+	Cooldown         time.Duration
+
+	primary domain.UserRepository
+	sources []*source
+}
+
+func NewUserRepository(primary Source, fallbacks ...Source) *UserRepository {
+	r := &UserRepository{FailureThreshold: 3, Cooldown: 30 * time.Second, primary: primary.Repo}
+	for _, s := range append([]Source{primary}, fallbacks...) {
+		r.sources = append(r.sources, &source{Source: s})
+	}
+	return r
+}
+
+func (r *UserRepository) Save(ctx context.Context, u domain.User) error {
+	return r.primary.Save(ctx, u)
+}
+
+func (r *UserRepository) Update(ctx context.Context, u domain.User) error {
+	return r.primary.Update(ctx, u)
+}
+
+func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
+	return r.read(ctx, func(ctx context.Context, repo domain.UserRepository) (*domain.User, error) {
+		return repo.GetByEmail(ctx, email)
+	})
+}
+
+func (r *UserRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	return r.read(ctx, func(ctx context.Context, repo domain.UserRepository) (*domain.User, error) {
+		return repo.GetByID(ctx, id)
+	})
+}
+
+// Health reports every source's state, in chain order.
+func (r *UserRepository) Health() []SourceHealth {
+	now := time.Now()
+	out := make([]SourceHealth, 0, len(r.sources))
+	for _, s := range r.sources {
+		s.mu.Lock()
+		out = append(out, SourceHealth{Name: s.Name, Healthy: now.After(s.openUntil), ConsecutiveFailures: s.failures})
+		s.mu.Unlock()
+	}
+	return out
+}
+
+func (r *UserRepository) read(ctx context.Context, get func(context.Context, domain.UserRepository) (*domain.User, error)) (*domain.User, error) {
+	// 1. Pick the sources worth trying
+	now := time.Now()
+	var candidates []*source
+	for _, s := range r.sources {
+		if s.healthy(now) {
+			candidates = append(candidates, s)
+		}
+	}
+	if len(candidates) == 0 {
+		candidates = r.sources
+	}
+
+	// 2. Walk the chain
+	var errs []error
+	for _, s := range candidates {
+		u, err := s.get(ctx, get)
+		if err == nil || errors.Is(err, domain.ErrUserNotFound) {
+			s.succeed()
+			return u, err
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err() // the caller gave up; not the source's fault
+		}
+		s.fail(time.Now(), r.FailureThreshold, r.Cooldown)
+		errs = append(errs, fmt.Errorf("%s: %w", s.Name, err))
+	}
+	return nil, fmt.Errorf("all user sources failed: %w", errors.Join(errs...))
+}
+
+func (s *source) get(ctx context.Context, get func(context.Context, domain.UserRepository) (*domain.User, error)) (*domain.User, error) {
+	if s.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.Timeout)
+		defer cancel()
+	}
+	return get(ctx, s.Repo)
+}
+
+func (s *source) healthy(now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return now.After(s.openUntil)
+}
+
+func (s *source) succeed() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = 0
+}
+
+func (s *source) fail(now time.Time, threshold int, cooldown time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures++
+	if s.failures >= threshold {
+		s.openUntil = now.Add(cooldown)
+	}
+}
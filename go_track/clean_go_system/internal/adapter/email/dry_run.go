@@ -0,0 +1,34 @@
+package email
+
+import (
+	"context"
+	"log"
+	"net/mail"
+
+	"clean_go_system/internal/domain"
+)
+
+// DryRun logs every email instead of sending it, for development. It
+// checks addresses like SMTPSender, so bad ones fail here too.
+type DryRun struct {
+	from   *mail.Address
+	logger *log.Logger
+}
+
+func NewDryRun(from string, logger *log.Logger) (*DryRun, error) {
+	sender, err := address("from", from)
+	if err != nil {
+		return nil, err
+	}
+	return &DryRun{from: sender, logger: logger}, nil
+}
+
+// Send logs msg. There is no provider, so there is no message ID either.
+func (d *DryRun) Send(ctx context.Context, msg domain.Email) (string, error) {
+	from, to, err := addresses(d.from, msg)
+	if err != nil {
+		return "", err
+	}
+	d.logger.Printf("email (dry run) from %s to %s: %q (html: %d bytes)\n%s", from, to, msg.Subject, len(msg.HTML), msg.Text)
+	return "", nil
+}
@@ -0,0 +1,49 @@
+package email
+
+import (
+	"errors"
+	"fmt"
+	"net/textproto"
+
+	"clean_go_system/internal/domain"
+)
+
+// APIError is an HTTP mail API's refusal of a send.
+type APIError struct {
+	Provider string
+	Status   int
+	// Code is the provider's name for the error, when it gives one.
+	Code    string
+	Message string
+}
+
+func (e *APIError) Error() string {
+	if e.Code != "" {
+		return fmt.Sprintf("%s: %d %s: %s", e.Provider, e.Status, e.Code, e.Message)
+	}
+	return fmt.Sprintf("%s: %d: %s", e.Provider, e.Status, e.Message)
+}
+
+// classify wraps err in class, unless class is nil.
+func classify(class, err error) error {
+	if class == nil {
+		return err
+	}
+	return fmt.Errorf("%w: %w", class, err)
+}
+
+// smtpClass sorts an SMTP reply: 421 and 450-452 ask to come back later,
+// any 5xx is final. Other failures (network, timeouts) are left alone.
+func smtpClass(err error) error {
+	var reply *textproto.Error
+	if !errors.As(err, &reply) {
+		return nil
+	}
+	switch {
+	case reply.Code >= 500:
+		return domain.ErrEmailRejected
+	case reply.Code == 421, reply.Code >= 450 && reply.Code <= 452:
+		return domain.ErrEmailThrottled
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package email
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"clean_go_system/internal/domain"
+)
+
+var (
+	// ErrEventSignature means a provider event did not carry a valid
+	// signature of the provider.
+	ErrEventSignature = errors.New("invalid event signature")
+	// ErrMalformedEvent means a provider event could not be decoded.
+	ErrMalformedEvent = errors.New("malformed event")
+)
+
+// maxEventBody bounds the request bodies of provider events. Providers
+// batch events, but stay far below this.
+const maxEventBody = 1 << 20
+
+// BounceHandler acts on the bounce and complaint reports of one provider
+// request, usually a core.Bounces' Handle.
+type BounceHandler func(ctx context.Context, reports []domain.BounceReport) error
+
+// serveEvents reads a provider's event request and hands the reports
+// parse finds to handle. It answers 204 once handle accepted them, 401 for
+// a bad signature and 400 for an undecodable request; a 503, for any
+// other failure, asks the provider to deliver the events again later.
+func serveEvents(w http.ResponseWriter, r *http.Request, provider string, parse func(ctx context.Context, header http.Header, body []byte) ([]domain.BounceReport, error), handle BounceHandler, logger *log.Logger) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxEventBody))
+	if err != nil {
+		http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	reports, err := parse(r.Context(), r.Header, body)
+	switch {
+	case errors.Is(err, ErrEventSignature):
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	case errors.Is(err, ErrMalformedEvent):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case err == nil && len(reports) > 0:
+		err = handle(r.Context(), reports)
+	}
+	if err != nil {
+		logger.Printf("%s events: %v", provider, err)
+		http.Error(w, "try again later", http.StatusServiceUnavailable)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,131 @@
+package email
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/mail"
+	"strconv"
+	"sync"
+	"time"
+
+	"clean_go_system/internal/domain"
+	"clean_go_system/pkg/clock"
+)
+
+// MailboxMessage is an email kept by a Mailbox.
+type MailboxMessage struct {
+	ID      string    `json:"id"`
+	From    string    `json:"from"`
+	To      string    `json:"to"`
+	Subject string    `json:"subject"`
+	Text    string    `json:"text"`
+	HTML    string    `json:"html,omitempty"`
+	At      time.Time `json:"at"`
+}
+
+// Mailbox keeps the latest emails in memory instead of sending them, for
+// development: they can be listed and opened through its handlers. It
+// checks addresses like SMTPSender, so bad ones fail here too.
+type Mailbox struct {
+	// Clock stamps messages; it defaults to the wall clock.
+	Clock domain.Clock
+
+	from *mail.Address
+	size int
+
+	mu       sync.Mutex
+	messages []MailboxMessage // oldest first
+	sent     int
+}
+
+// NewMailbox keeps the last size messages.
+func NewMailbox(from string, size int) (*Mailbox, error) {
+	sender, err := address("from", from)
+	if err != nil {
+		return nil, err
+	}
+	return &Mailbox{Clock: clock.System, from: sender, size: max(size, 1)}, nil
+}
+
+// Send keeps msg, dropping the oldest message when the mailbox is full.
+func (m *Mailbox) Send(ctx context.Context, msg domain.Email) (string, error) {
+	from, to, err := addresses(m.from, msg)
+	if err != nil {
+		return "", err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent++
+	kept := MailboxMessage{
+		ID:      "mailbox-" + strconv.Itoa(m.sent),
+		From:    from.String(),
+		To:      to.String(),
+		Subject: msg.Subject,
+		Text:    msg.Text,
+		HTML:    msg.HTML,
+		At:      m.Clock.Now(),
+	}
+	if len(m.messages) == m.size {
+		m.messages = m.messages[1:]
+	}
+	m.messages = append(m.messages, kept)
+	return kept.ID, nil
+}
+
+// Messages returns the kept messages, newest first.
+func (m *Mailbox) Messages() []MailboxMessage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make([]MailboxMessage, len(m.messages))
+	for i, msg := range m.messages {
+		out[len(out)-1-i] = msg
+	}
+	return out
+}
+
+// List handles GET /dev/mailbox, answering {"items": [...]}, newest
+// first. With to set, only messages to that address are listed.
+func (m *Mailbox) List(w http.ResponseWriter, r *http.Request) {
+	items := []MailboxMessage{}
+	to := r.URL.Query().Get("to")
+	for _, msg := range m.Messages() {
+		if to != "" {
+			if addr, err := mail.ParseAddress(msg.To); err != nil || domain.NormalizeEmail(addr.Address) != domain.NormalizeEmail(to) {
+				continue
+			}
+		}
+		items = append(items, msg)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(map[string][]MailboxMessage{"items": items})
+}
+
+// Show handles GET /dev/mailbox/{id}, showing the message as the HTML it
+// carries or, with format=text or without HTML, as plain text.
+func (m *Mailbox) Show(w http.ResponseWriter, r *http.Request) {
+	for _, msg := range m.Messages() {
+		if msg.ID != r.PathValue("id") {
+			continue
+		}
+		w.Header().Set("Cache-Control", "no-store")
+		if r.URL.Query().Get("format") == "text" || msg.HTML == "" {
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			_, _ = w.Write([]byte("From: " + msg.From + "\nTo: " + msg.To + "\nSubject: " + msg.Subject + "\n\n" + msg.Text))
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(msg.HTML))
+		return
+	}
+	http.Error(w, "message not found", http.StatusNotFound)
+}
+
+// Clear handles DELETE /dev/mailbox, emptying the mailbox.
+func (m *Mailbox) Clear(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	m.messages = nil
+	m.mu.Unlock()
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,107 @@
+// Package email implements domain.EmailSender: over SMTP, through the AWS
+// SES or SendGrid APIs, as a dry run that logs what it would send, or into
+// an in-memory mailbox for development.
+package email
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"clean_go_system/internal/domain"
+)
+
+// address parses addr, rejecting the header injection a raw CR or LF
+// would allow.
+func address(field, addr string) (*mail.Address, error) {
+	parsed, err := mail.ParseAddress(addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s address %q: %w", field, addr, err)
+	}
+	return parsed, nil
+}
+
+// addresses returns msg's sender, or from when it names none, and its
+// recipient. A bad address is the message's fault, so it is rejected.
+func addresses(from *mail.Address, msg domain.Email) (sender, to *mail.Address, err error) {
+	sender = from
+	if msg.From != "" {
+		if sender, err = address("from", msg.From); err != nil {
+			return nil, nil, fmt.Errorf("%w: %w", domain.ErrEmailRejected, err)
+		}
+	}
+	if to, err = address("to", msg.To); err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", domain.ErrEmailRejected, err)
+	}
+	return sender, to, nil
+}
+
+// render writes msg as an RFC 5322 message with CRLF line endings, and
+// returns it with its Message-ID. The body is quoted-printable UTF-8 text
+// or, when msg has HTML, a multipart/alternative of the text and the HTML.
+func render(msg domain.Email, from, to *mail.Address, now time.Time) (message []byte, id string, err error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return nil, "", fmt.Errorf("generate message id: %w", err)
+	}
+	host := from.Address[strings.LastIndex(from.Address, "@")+1:]
+	id = hex.EncodeToString(random) + "@" + host
+
+	var b bytes.Buffer
+	header := func(key, value string) { fmt.Fprintf(&b, "%s: %s\r\n", key, value) }
+	header("From", from.String())
+	header("To", to.String())
+	header("Subject", mime.QEncoding.Encode("utf-8", strings.NewReplacer("\r", "", "\n", " ").Replace(msg.Subject)))
+	header("Date", now.Format(time.RFC1123Z))
+	header("Message-ID", "<"+id+">")
+	header("MIME-Version", "1.0")
+	if msg.HTML == "" {
+		header("Content-Type", `text/plain; charset="utf-8"`)
+		header("Content-Transfer-Encoding", "quoted-printable")
+		b.WriteString("\r\n")
+		if err := writeQP(&b, msg.Text); err != nil {
+			return nil, "", err
+		}
+		return b.Bytes(), id, nil
+	}
+
+	parts := multipart.NewWriter(&b)
+	header("Content-Type", mime.FormatMediaType("multipart/alternative", map[string]string{"boundary": parts.Boundary()}))
+	b.WriteString("\r\n")
+	// Clients show the last part they understand, so the text goes first.
+	for _, part := range []struct{ contentType, body string }{{"text/plain", msg.Text}, {"text/html", msg.HTML}} {
+		w, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType + `; charset="utf-8"`},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, "", err
+		}
+		if err := writeQP(w, part.body); err != nil {
+			return nil, "", err
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return nil, "", err
+	}
+	return b.Bytes(), id, nil
+}
+
+// writeQP writes s quoted-printable with CRLF line endings.
+func writeQP(w io.Writer, s string) error {
+	qp := quotedprintable.NewWriter(w)
+	s = strings.ReplaceAll(strings.ReplaceAll(s, "\r\n", "\n"), "\n", "\r\n")
+	if _, err := qp.Write([]byte(s)); err != nil {
+		return err
+	}
+	return qp.Close()
+}
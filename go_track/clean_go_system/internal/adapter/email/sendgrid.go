@@ -0,0 +1,135 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/mail"
+	"strings"
+	"time"
+
+	"clean_go_system/internal/domain"
+)
+
+// SendGridSender sends through the SendGrid v3 Mail Send API.
+type SendGridSender struct {
+	// Client sends the requests; it defaults to one with a 10s timeout.
+	Client *http.Client
+
+	endpoint string
+	apiKey   string
+	from     *mail.Address
+}
+
+// NewSendGridSender sends as from, which must be a verified SendGrid
+// sender, unless a message names its own. endpoint replaces
+// https://api.sendgrid.com when set, e.g. for the EU region.
+func NewSendGridSender(apiKey, endpoint, from string) (*SendGridSender, error) {
+	sender, err := address("from", from)
+	if err != nil {
+		return nil, err
+	}
+	if apiKey == "" {
+		return nil, fmt.Errorf("sendgrid: an API key is required")
+	}
+	if endpoint == "" {
+		endpoint = "https://api.sendgrid.com"
+	}
+	return &SendGridSender{
+		Client:   &http.Client{Timeout: 10 * time.Second},
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		apiKey:   apiKey,
+		from:     sender,
+	}, nil
+}
+
+type sendGridAddress struct {
+	Email string `json:"email"`
+	Name  string `json:"name,omitempty"`
+}
+
+type sendGridPersonalization struct {
+	To []sendGridAddress `json:"to"`
+}
+
+type sendGridContent struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type sendGridRequest struct {
+	Personalizations []sendGridPersonalization `json:"personalizations"`
+	From             sendGridAddress           `json:"from"`
+	Subject          string                    `json:"subject"`
+	Content          []sendGridContent         `json:"content"`
+}
+
+// Send returns the X-Message-Id SendGrid answers with. Its event webhook
+// reports sg_message_id as this ID plus a suffix.
+func (s *SendGridSender) Send(ctx context.Context, msg domain.Email) (string, error) {
+	from, to, err := addresses(s.from, msg)
+	if err != nil {
+		return "", err
+	}
+	// SendGrid wants the text/plain content first.
+	content := []sendGridContent{{Type: "text/plain", Value: msg.Text}}
+	if msg.HTML != "" {
+		content = append(content, sendGridContent{Type: "text/html", Value: msg.HTML})
+	}
+	body, err := json.Marshal(sendGridRequest{
+		Personalizations: []sendGridPersonalization{{To: []sendGridAddress{{Email: to.Address, Name: to.Name}}}},
+		From:             sendGridAddress{Email: from.Address, Name: from.Name},
+		Subject:          msg.Subject,
+		Content:          content,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint+"/v3/mail/send", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+s.apiKey)
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("sendgrid: %w", err)
+	}
+	defer resp.Body.Close()
+	answer, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode >= 300 {
+		return "", sendGridError(resp.StatusCode, answer)
+	}
+	return resp.Header.Get("X-Message-Id"), nil
+}
+
+// sendGridError classifies a refusal: 429 means over the rate limit, 400
+// and 413 mean the message itself will never go out. A bad key (401, 403)
+// or an outage (5xx) is left for the queue to retry and, eventually,
+// dead-letter.
+func sendGridError(status int, body []byte) error {
+	var answer struct {
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	_ = json.Unmarshal(body, &answer)
+	messages := make([]string, 0, len(answer.Errors))
+	for _, e := range answer.Errors {
+		messages = append(messages, e.Message)
+	}
+	err := &APIError{Provider: "sendgrid", Status: status, Message: strings.Join(messages, "; ")}
+
+	switch status {
+	case http.StatusTooManyRequests:
+		return classify(domain.ErrEmailThrottled, err)
+	case http.StatusBadRequest, http.StatusRequestEntityTooLarge:
+		return classify(domain.ErrEmailRejected, err)
+	}
+	return err
+}
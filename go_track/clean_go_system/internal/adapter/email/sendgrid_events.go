@@ -0,0 +1,132 @@
+package email
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"clean_go_system/internal/domain"
+	"clean_go_system/pkg/clock"
+)
+
+// Headers of SendGrid's signed event webhook.
+const (
+	sendGridSignatureHeader = "X-Twilio-Email-Event-Webhook-Signature"
+	sendGridTimestampHeader = "X-Twilio-Email-Event-Webhook-Timestamp"
+)
+
+// SendGridEvents receives SendGrid's signed event webhook. Each request
+// is checked against the webhook's verification key: an ECDSA signature
+// over the timestamp header followed by the body.
+type SendGridEvents struct {
+	// Clock checks timestamps; it defaults to the wall clock.
+	Clock domain.Clock
+	// Window is how far a timestamp may be from now, either way, so a
+	// captured request cannot be replayed later.
+	Window time.Duration
+
+	key    *ecdsa.PublicKey
+	handle BounceHandler
+	logger *log.Logger
+}
+
+// NewSendGridEvents verifies requests with publicKey, the base64
+// verification key SendGrid shows for the signed event webhook.
+func NewSendGridEvents(publicKey string, handle BounceHandler, logger *log.Logger) (*SendGridEvents, error) {
+	der, err := base64.StdEncoding.DecodeString(strings.TrimSpace(publicKey))
+	if err != nil {
+		return nil, fmt.Errorf("sendgrid: webhook verification key: %w", err)
+	}
+	parsed, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("sendgrid: webhook verification key: %w", err)
+	}
+	key, ok := parsed.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("sendgrid: webhook verification key is a %T, not an ECDSA key", parsed)
+	}
+	return &SendGridEvents{Clock: clock.System, Window: 10 * time.Minute, key: key, handle: handle, logger: logger}, nil
+}
+
+// Handler serves the webhook; see serveEvents for its answers.
+func (e *SendGridEvents) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serveEvents(w, r, "sendgrid", e.parse, e.handle, e.logger)
+	})
+}
+
+type sendGridEvent struct {
+	Email       string `json:"email"`
+	Timestamp   int64  `json:"timestamp"`
+	Event       string `json:"event"`
+	Type        string `json:"type"`
+	Reason      string `json:"reason"`
+	SGMessageID string `json:"sg_message_id"`
+}
+
+func (e *SendGridEvents) parse(_ context.Context, header http.Header, body []byte) ([]domain.BounceReport, error) {
+	if err := e.verify(header, body); err != nil {
+		return nil, err
+	}
+	var events []sendGridEvent
+	if err := json.Unmarshal(body, &events); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMalformedEvent, err)
+	}
+
+	var reports []domain.BounceReport
+	for _, ev := range events {
+		var kind domain.BounceKind
+		switch {
+		case ev.Event == "bounce" && ev.Type != "blocked":
+			kind = domain.BounceHard
+		case ev.Event == "bounce", ev.Event == "blocked":
+			kind = domain.BounceSoft
+		case ev.Event == "spamreport":
+			kind = domain.BounceComplaint
+		default:
+			// Deliveries, opens, deferrals (SendGrid keeps retrying those)
+			// and drops of addresses it already suppresses.
+			continue
+		}
+		// sg_message_id is the X-Message-Id of the send plus a suffix
+		// naming the message within it.
+		id, _, _ := strings.Cut(ev.SGMessageID, ".")
+		reports = append(reports, domain.BounceReport{
+			Kind:       kind,
+			Email:      ev.Email,
+			ProviderID: id,
+			Detail:     ev.Reason,
+			At:         time.Unix(ev.Timestamp, 0).UTC(),
+		})
+	}
+	return reports, nil
+}
+
+func (e *SendGridEvents) verify(header http.Header, body []byte) error {
+	ts := header.Get(sendGridTimestampHeader)
+	sig, err := base64.StdEncoding.DecodeString(header.Get(sendGridSignatureHeader))
+	if ts == "" || err != nil || len(sig) == 0 {
+		return fmt.Errorf("sendgrid: %w: missing or malformed", ErrEventSignature)
+	}
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return fmt.Errorf("sendgrid: %w: malformed timestamp", ErrEventSignature)
+	}
+	if age := e.Clock.Now().Sub(time.Unix(unix, 0)); age > e.Window || age < -e.Window {
+		return fmt.Errorf("sendgrid: %w: timestamp outside the replay window", ErrEventSignature)
+	}
+	digest := sha256.Sum256(append([]byte(ts), body...))
+	if !ecdsa.VerifyASN1(e.key, digest[:], sig) {
+		return fmt.Errorf("sendgrid: %w", ErrEventSignature)
+	}
+	return nil
+}
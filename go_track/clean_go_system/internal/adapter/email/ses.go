@@ -0,0 +1,207 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/mail"
+	"slices"
+	"strings"
+	"time"
+
+	"clean_go_system/internal/domain"
+	"clean_go_system/pkg/clock"
+)
+
+// SESConfig is the AWS account SESSender sends through.
+type SESConfig struct {
+	Region          string
+	AccessKeyID     string
+	SecretAccessKey string
+	// SessionToken is only set for temporary credentials.
+	SessionToken string
+	// Endpoint replaces https://email.<Region>.amazonaws.com, e.g. for a
+	// VPC endpoint.
+	Endpoint string
+}
+
+// SESSender sends through the SES v2 SendEmail API, signing requests with
+// AWS Signature Version 4.
+type SESSender struct {
+	// Client sends the requests; it defaults to one with a 10s timeout.
+	Client *http.Client
+	// Clock stamps the signatures; it defaults to the wall clock.
+	Clock domain.Clock
+
+	cfg  SESConfig
+	from *mail.Address
+}
+
+// NewSESSender sends as from, which SES must have verified, unless a
+// message names its own sender.
+func NewSESSender(cfg SESConfig, from string) (*SESSender, error) {
+	sender, err := address("from", from)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Region == "" || cfg.AccessKeyID == "" || cfg.SecretAccessKey == "" {
+		return nil, fmt.Errorf("ses: region and credentials are required")
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = "https://email." + cfg.Region + ".amazonaws.com"
+	}
+	cfg.Endpoint = strings.TrimSuffix(cfg.Endpoint, "/")
+	return &SESSender{Client: &http.Client{Timeout: 10 * time.Second}, Clock: clock.System, cfg: cfg, from: sender}, nil
+}
+
+type sesContent struct {
+	Data    string `json:"Data"`
+	Charset string `json:"Charset"`
+}
+
+type sesRequest struct {
+	FromEmailAddress string `json:"FromEmailAddress"`
+	Destination      struct {
+		ToAddresses []string `json:"ToAddresses"`
+	} `json:"Destination"`
+	Content struct {
+		Simple struct {
+			Subject sesContent `json:"Subject"`
+			Body    struct {
+				Text sesContent  `json:"Text"`
+				HTML *sesContent `json:"Html,omitempty"`
+			} `json:"Body"`
+		} `json:"Simple"`
+	} `json:"Content"`
+}
+
+func (s *SESSender) Send(ctx context.Context, msg domain.Email) (string, error) {
+	from, to, err := addresses(s.from, msg)
+	if err != nil {
+		return "", err
+	}
+	var payload sesRequest
+	payload.FromEmailAddress = from.String()
+	payload.Destination.ToAddresses = []string{to.String()}
+	payload.Content.Simple.Subject = sesContent{Data: msg.Subject, Charset: "UTF-8"}
+	payload.Content.Simple.Body.Text = sesContent{Data: msg.Text, Charset: "UTF-8"}
+	if msg.HTML != "" {
+		payload.Content.Simple.Body.HTML = &sesContent{Data: msg.HTML, Charset: "UTF-8"}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.cfg.Endpoint+"/v2/email/outbound-emails", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.cfg.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.cfg.SessionToken)
+	}
+	s.sign(req, body, s.Clock.Now())
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("ses: %w", err)
+	}
+	defer resp.Body.Close()
+	answer, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode >= 300 {
+		return "", sesError(resp, answer)
+	}
+	var sent struct {
+		MessageID string `json:"MessageId"`
+	}
+	_ = json.Unmarshal(answer, &sent)
+	return sent.MessageID, nil
+}
+
+// sesError reads the error type from the X-Amzn-ErrorType header (or the
+// body's __type) and classifies it: throttling and exhausted quotas are
+// worth retrying, a rejected message or a bad request is not.
+func sesError(resp *http.Response, body []byte) error {
+	var answer struct {
+		Type    string `json:"__type"`
+		Message string `json:"message"`
+	}
+	_ = json.Unmarshal(body, &answer)
+	code := resp.Header.Get("X-Amzn-ErrorType")
+	if code == "" {
+		code = answer.Type
+	}
+	// Either may carry a ":<url>" suffix or a "<namespace>#" prefix.
+	code, _, _ = strings.Cut(code, ":")
+	code = code[strings.LastIndex(code, "#")+1:]
+	err := &APIError{Provider: "ses", Status: resp.StatusCode, Code: code, Message: answer.Message}
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests, code == "TooManyRequestsException", code == "LimitExceededException", code == "ThrottlingException":
+		return classify(domain.ErrEmailThrottled, err)
+	case code == "MessageRejected", code == "BadRequestException", code == "MailFromDomainNotVerifiedException":
+		return classify(domain.ErrEmailRejected, err)
+	}
+	return err
+}
+
+// sign adds AWS Signature Version 4 headers for the "ses" service to req,
+// covering its Host, its Content-Type and X-Amz-* headers, and body.
+func (s *SESSender) sign(req *http.Request, body []byte, now time.Time) {
+	now = now.UTC()
+	stamp, day := now.Format("20060102T150405Z"), now.Format("20060102")
+	req.Header.Set("X-Amz-Date", stamp)
+	payloadHash := sha256Hex(body)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for key, values := range req.Header {
+		if k := strings.ToLower(key); k == "content-type" || strings.HasPrefix(k, "x-amz-") {
+			headers[k] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signed := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonical := strings.Join([]string{req.Method, path, req.URL.RawQuery, canonicalHeaders.String(), signed, payloadHash}, "\n")
+	scope := day + "/" + s.cfg.Region + "/ses/aws4_request"
+	toSign := "AWS4-HMAC-SHA256\n" + stamp + "\n" + scope + "\n" + sha256Hex([]byte(canonical))
+
+	key := hmacSHA256([]byte("AWS4"+s.cfg.SecretAccessKey), day)
+	for _, part := range []string{s.cfg.Region, "ses", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, toSign))
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+s.cfg.AccessKeyID+"/"+scope+
+		", SignedHeaders="+signed+", Signature="+signature)
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
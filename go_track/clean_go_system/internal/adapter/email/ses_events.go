@@ -0,0 +1,285 @@
+package email
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	_ "crypto/sha1" // SNS signature version 1
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"clean_go_system/internal/domain"
+)
+
+// snsHost matches the hosts SNS serves signing certificates and
+// subscription confirmations from.
+var snsHost = regexp.MustCompile(`^sns\.[a-z0-9-]+\.amazonaws\.com(\.cn)?$`)
+
+// SESEvents receives the bounce and complaint notifications SES publishes
+// to an SNS topic, with the endpoint subscribed to the topic over HTTPS.
+// Every message is checked against the SNS signing certificate it names,
+// which must be served by SNS itself, and must come from the topic.
+type SESEvents struct {
+	// Client fetches signing certificates and confirms the subscription;
+	// it defaults to one with a 10s timeout.
+	Client *http.Client
+
+	topicARN string
+	handle   BounceHandler
+	logger   *log.Logger
+
+	mu    sync.Mutex
+	certs map[string]*x509.Certificate
+}
+
+// NewSESEvents accepts messages from the SNS topic topicARN only.
+func NewSESEvents(topicARN string, handle BounceHandler, logger *log.Logger) (*SESEvents, error) {
+	if !strings.HasPrefix(topicARN, "arn:aws") {
+		return nil, fmt.Errorf("ses: topic %q is not an SNS topic ARN", topicARN)
+	}
+	return &SESEvents{
+		Client:   &http.Client{Timeout: 10 * time.Second},
+		topicARN: topicARN,
+		handle:   handle,
+		logger:   logger,
+		certs:    make(map[string]*x509.Certificate),
+	}, nil
+}
+
+// Handler serves the subscription; see serveEvents for its answers. The
+// subscription confirmation SNS sends first is confirmed on receipt.
+func (e *SESEvents) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serveEvents(w, r, "ses", e.parse, e.handle, e.logger)
+	})
+}
+
+type snsMessage struct {
+	Type             string
+	MessageId        string
+	Token            string
+	TopicArn         string
+	Subject          string
+	Message          string
+	SubscribeURL     string
+	Timestamp        string
+	SignatureVersion string
+	Signature        string
+	SigningCertURL   string
+}
+
+type sesRecipient struct {
+	EmailAddress   string `json:"emailAddress"`
+	DiagnosticCode string `json:"diagnosticCode"`
+}
+
+type sesNotification struct {
+	NotificationType string `json:"notificationType"`
+	// EventType replaces NotificationType in events published through
+	// a configuration set.
+	EventType string `json:"eventType"`
+	Mail      struct {
+		MessageID string `json:"messageId"`
+	} `json:"mail"`
+	Bounce struct {
+		BounceType        string         `json:"bounceType"`
+		BounceSubType     string         `json:"bounceSubType"`
+		BouncedRecipients []sesRecipient `json:"bouncedRecipients"`
+		Timestamp         time.Time      `json:"timestamp"`
+	} `json:"bounce"`
+	Complaint struct {
+		ComplainedRecipients  []sesRecipient `json:"complainedRecipients"`
+		ComplaintFeedbackType string         `json:"complaintFeedbackType"`
+		Timestamp             time.Time      `json:"timestamp"`
+	} `json:"complaint"`
+}
+
+func (e *SESEvents) parse(ctx context.Context, _ http.Header, body []byte) ([]domain.BounceReport, error) {
+	var msg snsMessage
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMalformedEvent, err)
+	}
+	if msg.TopicArn != e.topicARN {
+		return nil, fmt.Errorf("ses: %w: message from topic %q", ErrEventSignature, msg.TopicArn)
+	}
+	if err := e.verify(ctx, msg); err != nil {
+		return nil, err
+	}
+
+	switch msg.Type {
+	case "SubscriptionConfirmation":
+		return nil, e.confirm(ctx, msg.SubscribeURL)
+	case "Notification":
+	default:
+		return nil, nil
+	}
+	var n sesNotification
+	if err := json.Unmarshal([]byte(msg.Message), &n); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMalformedEvent, err)
+	}
+
+	var reports []domain.BounceReport
+	add := func(kind domain.BounceKind, recipients []sesRecipient, detail string, at time.Time) {
+		for _, r := range recipients {
+			report := domain.BounceReport{Kind: kind, Email: r.EmailAddress, ProviderID: n.Mail.MessageID, Detail: detail, At: at}
+			if r.DiagnosticCode != "" {
+				report.Detail = r.DiagnosticCode
+			}
+			reports = append(reports, report)
+		}
+	}
+	switch n.NotificationType + n.EventType {
+	case "Bounce":
+		kind := domain.BounceSoft
+		if n.Bounce.BounceType == "Permanent" {
+			kind = domain.BounceHard
+		}
+		add(kind, n.Bounce.BouncedRecipients, n.Bounce.BounceType+" "+n.Bounce.BounceSubType, n.Bounce.Timestamp)
+	case "Complaint":
+		add(domain.BounceComplaint, n.Complaint.ComplainedRecipients, n.Complaint.ComplaintFeedbackType, n.Complaint.Timestamp)
+	}
+	return reports, nil
+}
+
+// verify checks msg's signature with the certificate it names.
+func (e *SESEvents) verify(ctx context.Context, msg snsMessage) error {
+	var hash crypto.Hash
+	switch msg.SignatureVersion {
+	case "1":
+		hash = crypto.SHA1
+	case "2":
+		hash = crypto.SHA256
+	default:
+		return fmt.Errorf("ses: %w: signature version %q", ErrEventSignature, msg.SignatureVersion)
+	}
+	sig, err := base64.StdEncoding.DecodeString(msg.Signature)
+	if err != nil {
+		return fmt.Errorf("ses: %w: %w", ErrEventSignature, err)
+	}
+	cert, err := e.cert(ctx, msg.SigningCertURL)
+	if err != nil {
+		return err
+	}
+	key, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return fmt.Errorf("ses: %w: signing certificate has no RSA key", ErrEventSignature)
+	}
+
+	h := hash.New()
+	h.Write(snsStringToSign(msg))
+	if err := rsa.VerifyPKCS1v15(key, hash, h.Sum(nil), sig); err != nil {
+		return fmt.Errorf("ses: %w", ErrEventSignature)
+	}
+	return nil
+}
+
+// snsStringToSign lists the signed fields of msg, name and value each on
+// a line, in the order SNS signs them. An empty Subject is left out.
+func snsStringToSign(msg snsMessage) []byte {
+	names := []string{"Message", "MessageId", "SubscribeURL", "Timestamp", "Token", "TopicArn", "Type"}
+	if msg.Type == "Notification" {
+		names = []string{"Message", "MessageId", "Subject", "Timestamp", "TopicArn", "Type"}
+	}
+	values := map[string]string{
+		"Message":      msg.Message,
+		"MessageId":    msg.MessageId,
+		"Subject":      msg.Subject,
+		"SubscribeURL": msg.SubscribeURL,
+		"Timestamp":    msg.Timestamp,
+		"Token":        msg.Token,
+		"TopicArn":     msg.TopicArn,
+		"Type":         msg.Type,
+	}
+	var b strings.Builder
+	for _, name := range names {
+		if name == "Subject" && msg.Subject == "" {
+			continue
+		}
+		b.WriteString(name + "\n" + values[name] + "\n")
+	}
+	return []byte(b.String())
+}
+
+// cert returns the signing certificate at rawURL, fetching it once. Only
+// certificates served by SNS over HTTPS are trusted.
+func (e *SESEvents) cert(ctx context.Context, rawURL string) (*x509.Certificate, error) {
+	if err := checkSNSURL(rawURL); err != nil {
+		return nil, fmt.Errorf("ses: %w: signing certificate %w", ErrEventSignature, err)
+	}
+	e.mu.Lock()
+	cert, ok := e.certs[rawURL]
+	e.mu.Unlock()
+	if ok {
+		return cert, nil
+	}
+
+	body, err := e.get(ctx, rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("ses: fetch signing certificate: %w", err)
+	}
+	block, _ := pem.Decode(body)
+	if block == nil {
+		return nil, fmt.Errorf("ses: signing certificate is not PEM")
+	}
+	if cert, err = x509.ParseCertificate(block.Bytes); err != nil {
+		return nil, fmt.Errorf("ses: signing certificate: %w", err)
+	}
+	e.mu.Lock()
+	e.certs[rawURL] = cert
+	e.mu.Unlock()
+	return cert, nil
+}
+
+// confirm subscribes the endpoint by visiting the confirmation link.
+func (e *SESEvents) confirm(ctx context.Context, rawURL string) error {
+	if err := checkSNSURL(rawURL); err != nil {
+		return fmt.Errorf("ses: %w: subscribe URL %w", ErrEventSignature, err)
+	}
+	if _, err := e.get(ctx, rawURL); err != nil {
+		return fmt.Errorf("ses: confirm subscription: %w", err)
+	}
+	e.logger.Printf("ses events: confirmed subscription to %s", e.topicARN)
+	return nil
+}
+
+func (e *SESEvents) get(ctx context.Context, rawURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := e.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", rawURL, resp.Status)
+	}
+	return body, nil
+}
+
+func checkSNSURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "https" || !snsHost.MatchString(u.Hostname()) {
+		return fmt.Errorf("%q is not served by SNS", rawURL)
+	}
+	return nil
+}
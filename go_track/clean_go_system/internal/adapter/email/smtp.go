@@ -0,0 +1,293 @@
+package email
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"sync"
+	"time"
+
+	"clean_go_system/internal/domain"
+	"clean_go_system/pkg/clock"
+)
+
+// TLS modes of an SMTP connection.
+const (
+	// TLSStartTLS upgrades a plain connection (usually to port 587) and
+	// refuses servers that cannot.
+	TLSStartTLS = "starttls"
+	// TLSImplicit speaks TLS from the first byte (usually port 465).
+	TLSImplicit = "tls"
+	// TLSNone sends in the clear; net/smtp then refuses to send a password
+	// to anything but localhost.
+	TLSNone = "none"
+)
+
+// SMTPConfig is how SMTPSender reaches its server.
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	TLS      string
+	// Timeout bounds dialing and each send, unless the context ends
+	// sooner.
+	Timeout time.Duration
+	// MaxIdle connections are kept open for the next sends, for up to
+	// IdleTimeout each; servers drop idle clients after a while anyway.
+	MaxIdle     int
+	IdleTimeout time.Duration
+}
+
+// SMTPSender sends over SMTP with PLAIN auth, reusing connections between
+// sends. It is safe for concurrent use; each send holds a connection of
+// its own.
+type SMTPSender struct {
+	// TLSConfig, if set, replaces the default client TLS settings, e.g.
+	// to trust a private CA.
+	TLSConfig *tls.Config
+	// Clock stamps the Date header; it defaults to the wall clock.
+	Clock domain.Clock
+
+	cfg  SMTPConfig
+	from *mail.Address
+
+	mu   sync.Mutex
+	idle []*smtpConn
+}
+
+type smtpConn struct {
+	conn     net.Conn
+	client   *smtp.Client
+	lastUsed time.Time
+}
+
+// NewSMTPSender sends as from unless a message names its own sender.
+func NewSMTPSender(cfg SMTPConfig, from string) (*SMTPSender, error) {
+	sender, err := address("from", from)
+	if err != nil {
+		return nil, err
+	}
+	switch cfg.TLS {
+	case "":
+		cfg.TLS = TLSStartTLS
+	case TLSStartTLS, TLSImplicit, TLSNone:
+	default:
+		return nil, fmt.Errorf("smtp: unknown tls mode %q", cfg.TLS)
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if cfg.IdleTimeout <= 0 {
+		cfg.IdleTimeout = 30 * time.Second
+	}
+	return &SMTPSender{Clock: clock.System, cfg: cfg, from: sender}, nil
+}
+
+// Send returns the Message-ID it gave msg, which bounces quote.
+func (s *SMTPSender) Send(ctx context.Context, msg domain.Email) (string, error) {
+	from, to, err := addresses(s.from, msg)
+	if err != nil {
+		return "", err
+	}
+	body, id, err := render(msg, from, to, s.Clock.Now())
+	if err != nil {
+		return "", err
+	}
+
+	c, err := s.conn(ctx)
+	if err != nil {
+		return "", err
+	}
+	if err := s.deliver(ctx, c, from.Address, to.Address, body); err != nil {
+		// The session is in an unknown state: start afresh next time.
+		c.conn.Close()
+		return "", err
+	}
+	s.release(c)
+	return id, nil
+}
+
+// deliver runs one mail transaction on c, giving up at the context's
+// deadline or after Timeout, whichever comes first.
+func (s *SMTPSender) deliver(ctx context.Context, c *smtpConn, from, to string, body []byte) error {
+	stop := s.bound(ctx, c.conn)
+	defer stop()
+
+	err := func() error {
+		if err := c.client.Mail(from); err != nil {
+			return err
+		}
+		if err := c.client.Rcpt(to); err != nil {
+			return err
+		}
+		w, err := c.client.Data()
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(body); err != nil {
+			return err
+		}
+		return w.Close()
+	}()
+	if err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("smtp: send: %w", ctx.Err())
+		}
+		return classify(smtpClass(err), fmt.Errorf("smtp: send: %w", err))
+	}
+	return nil
+}
+
+// conn returns an idle connection that still answers, or dials a new one.
+func (s *SMTPSender) conn(ctx context.Context) (*smtpConn, error) {
+	for {
+		c := s.takeIdle()
+		if c == nil {
+			break
+		}
+		stop := s.bound(ctx, c.conn)
+		err := c.client.Noop()
+		stop()
+		if err == nil {
+			return c, nil
+		}
+		c.conn.Close()
+	}
+	return s.dial(ctx)
+}
+
+func (s *SMTPSender) takeIdle() *smtpConn {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.Clock.Now()
+	for len(s.idle) > 0 {
+		c := s.idle[len(s.idle)-1]
+		s.idle = s.idle[:len(s.idle)-1]
+		if now.Sub(c.lastUsed) < s.cfg.IdleTimeout {
+			return c
+		}
+		c.conn.Close()
+	}
+	return nil
+}
+
+// release keeps c for the next send, or closes it when MaxIdle are kept.
+func (s *SMTPSender) release(c *smtpConn) {
+	c.lastUsed = s.Clock.Now()
+	s.mu.Lock()
+	if len(s.idle) < s.cfg.MaxIdle {
+		s.idle = append(s.idle, c)
+		c = nil
+	}
+	s.mu.Unlock()
+	if c != nil {
+		s.quit(c)
+	}
+}
+
+func (s *SMTPSender) dial(ctx context.Context) (*smtpConn, error) {
+	addr := net.JoinHostPort(s.cfg.Host, strconv.Itoa(s.cfg.Port))
+	dialer := net.Dialer{Timeout: s.cfg.Timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("smtp: dial %s: %w", addr, err)
+	}
+	stop := s.bound(ctx, conn)
+	defer stop()
+
+	client, err := s.handshake(conn)
+	if err != nil {
+		conn.Close()
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		err = fmt.Errorf("smtp: %s: %w", addr, err)
+		// A refused login is the configuration's fault, not the message's.
+		if smtpClass(err) == domain.ErrEmailThrottled {
+			err = classify(domain.ErrEmailThrottled, err)
+		}
+		return nil, err
+	}
+	return &smtpConn{conn: conn, client: client}, nil
+}
+
+// handshake greets the server, secures the connection and logs in.
+func (s *SMTPSender) handshake(conn net.Conn) (*smtp.Client, error) {
+	if s.cfg.TLS == TLSImplicit {
+		tlsConn := tls.Client(conn, s.tlsConfig())
+		if err := tlsConn.Handshake(); err != nil {
+			return nil, err
+		}
+		conn = tlsConn
+	}
+	client, err := smtp.NewClient(conn, s.cfg.Host)
+	if err != nil {
+		return nil, err
+	}
+	if s.cfg.TLS == TLSStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return nil, errors.New("server does not offer STARTTLS")
+		}
+		if err := client.StartTLS(s.tlsConfig()); err != nil {
+			return nil, err
+		}
+	}
+	if s.cfg.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.cfg.Host)); err != nil {
+			return nil, err
+		}
+	}
+	return client, nil
+}
+
+func (s *SMTPSender) tlsConfig() *tls.Config {
+	if s.TLSConfig != nil {
+		cfg := s.TLSConfig.Clone()
+		if cfg.ServerName == "" {
+			cfg.ServerName = s.cfg.Host
+		}
+		return cfg
+	}
+	return &tls.Config{ServerName: s.cfg.Host, MinVersion: tls.VersionTLS12}
+}
+
+// bound sets conn's deadline to Timeout from now or ctx's deadline, and
+// cuts the connection short if ctx ends first. The returned func undoes
+// the latter.
+func (s *SMTPSender) bound(ctx context.Context, conn net.Conn) (stop func()) {
+	deadline := time.Now().Add(s.cfg.Timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	conn.SetDeadline(deadline) //nolint:errcheck // only fails on a closed conn
+	cancel := context.AfterFunc(ctx, func() {
+		conn.SetDeadline(time.Now()) //nolint:errcheck // same
+	})
+	return func() { cancel() }
+}
+
+func (s *SMTPSender) quit(c *smtpConn) {
+	c.conn.SetDeadline(time.Now().Add(time.Second)) //nolint:errcheck // best effort
+	if err := c.client.Quit(); err != nil {
+		c.conn.Close()
+	}
+}
+
+// Close says goodbye on every idle connection. Sends after Close dial
+// afresh.
+func (s *SMTPSender) Close() error {
+	s.mu.Lock()
+	idle := s.idle
+	s.idle = nil
+	s.mu.Unlock()
+	for _, c := range idle {
+		s.quit(c)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package eventbus
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"clean_go_system/internal/domain"
+)
+
+// AfterCommit is a Transactor and EventPublisher for drivers without an
+// outbox. Events published inside one of its transactions are held and
+// only handed to the publisher once the transaction has committed, so
+// subscribers never see a write that was rolled back, or one that a
+// driver retrying the transaction (Mongo does) makes twice. Outside a
+// transaction events are published at once.
+//
+// Without an outbox nothing is durable: events are lost if the process
+// dies between commit and publish.
+type AfterCommit struct {
+	tx domain.Transactor
+	p  domain.EventPublisher
+}
+
+func NewAfterCommit(tx domain.Transactor, p domain.EventPublisher) *AfterCommit {
+	return &AfterCommit{tx: tx, p: p}
+}
+
+type heldKey struct{}
+
+type held struct {
+	mu     sync.Mutex
+	events []domain.DomainEvent
+}
+
+// WithinTransaction runs fn in a transaction of the wrapped Transactor and
+// publishes what fn published once it commits. A failure to publish is
+// returned, though the writes stay committed. Nested calls join the outer
+// transaction and publish with it.
+func (a *AfterCommit) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(heldKey{}).(*held); ok {
+		return a.tx.WithinTransaction(ctx, fn)
+	}
+
+	h := &held{}
+	err := a.tx.WithinTransaction(context.WithValue(ctx, heldKey{}, h), func(ctx context.Context) error {
+		h.mu.Lock()
+		h.events = nil // a retried transaction publishes only its last run
+		h.mu.Unlock()
+		return fn(ctx)
+	})
+	if err != nil || len(h.events) == 0 {
+		return err
+	}
+	if err := a.p.Publish(context.WithValue(ctx, heldKey{}, nil), h.events...); err != nil {
+		return fmt.Errorf("publish after commit: %w", err)
+	}
+	return nil
+}
+
+// Publish holds events until the transaction in ctx commits, or publishes
+// them now when there is none.
+func (a *AfterCommit) Publish(ctx context.Context, events ...domain.DomainEvent) error {
+	if h, ok := ctx.Value(heldKey{}).(*held); ok && h != nil {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.events = append(h.events, events...)
+		return nil
+	}
+	return a.p.Publish(ctx, events...)
+}
@@ -0,0 +1,228 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sync"
+
+	"clean_go_system/internal/adapter/eventcodec"
+	"clean_go_system/internal/domain"
+)
+
+// ErrClosed is returned when publishing to a stopped bus.
+var ErrClosed = errors.New("event bus is closed")
+
+// Mode selects how a subscriber is invoked.
+type Mode int
+
+const (
+	// Sync handlers run inside Publish; their errors are returned to the publisher.
+	Sync Mode = iota
+	// Async handlers run on the bus workers; their errors are logged.
+	Async
+)
+
+type handlerFunc func(ctx context.Context, e domain.DomainEvent) error
+
+type subscription struct {
+	name   string
+	mode   Mode
+	handle handlerFunc
+}
+
+type delivery struct {
+	ctx   context.Context
+	event domain.DomainEvent
+	sub   subscription
+}
+
+// Bus is an in-process publish/subscribe bus for domain events.
+// It implements domain.EventPublisher and lifecycle.Component.
+type Bus struct {
+	mu       sync.RWMutex
+	byName   map[string][]subscription
+	wildcard []subscription
+	closed   bool
+
+	queue   chan delivery
+	workers int
+	wg      sync.WaitGroup
+	logger  *log.Logger
+}
+
+// New creates a bus whose async handlers run on `workers` goroutines
+// with up to `buffer` deliveries waiting.
+func New(logger *log.Logger, workers, buffer int) *Bus {
+	return &Bus{
+		byName:  make(map[string][]subscription),
+		queue:   make(chan delivery, buffer),
+		workers: workers,
+		logger:  logger,
+	}
+}
+
+// Subscribe registers a handler for the event type E, e.g.
+//
+//	eventbus.Subscribe(bus, eventbus.Async, func(ctx context.Context, e domain.UserRegistered) error { ... })
+func Subscribe[E domain.DomainEvent](b *Bus, mode Mode, handler func(ctx context.Context, event E) error) {
+	var zero E
+	name := zero.EventName()
+	sub := subscription{name: name, mode: mode, handle: func(ctx context.Context, e domain.DomainEvent) error {
+		typed, ok := e.(E)
+		if !ok {
+			return fmt.Errorf("event %s delivered as %T", name, e)
+		}
+		return handler(ctx, typed)
+	}}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.byName[name] = append(b.byName[name], sub)
+}
+
+// SubscribeAll registers a handler for every event (e.g., audit logging).
+func SubscribeAll(b *Bus, mode Mode, handler func(ctx context.Context, event domain.DomainEvent) error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.wildcard = append(b.wildcard, subscription{name: "*", mode: mode, handle: handler})
+}
+
+// Publish dispatches events to their subscribers. Sync handlers run in
+// order and their errors are joined; async deliveries are queued, waiting
+// for room until ctx is done. The events are new, so handlers see no
+// event ID, even when ctx carries the ID of an event being handled.
+func (b *Bus) Publish(ctx context.Context, events ...domain.DomainEvent) error {
+	return b.dispatch(eventcodec.WithEventID(ctx, ""), events...)
+}
+
+// Forward dispatches e like Publish, with id for handlers to deduplicate
+// by. It makes Bus an eventcodec.Forwarder.
+func (b *Bus) Forward(ctx context.Context, id string, e domain.DomainEvent) error {
+	return b.dispatch(eventcodec.WithEventID(ctx, id), e)
+}
+
+func (b *Bus) dispatch(ctx context.Context, events ...domain.DomainEvent) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.closed {
+		return ErrClosed
+	}
+
+	var errs []error
+	for _, e := range events {
+		subs := append(append([]subscription(nil), b.byName[e.EventName()]...), b.wildcard...)
+		for _, sub := range subs {
+			if sub.mode == Sync {
+				if err := sub.handle(ctx, e); err != nil {
+					errs = append(errs, fmt.Errorf("%s handler: %w", e.EventName(), err))
+				}
+				continue
+			}
+			// Async work must outlive the request that triggered it.
+			d := delivery{ctx: context.WithoutCancel(ctx), event: e, sub: sub}
+			select {
+			case b.queue <- d:
+			case <-ctx.Done():
+				errs = append(errs, fmt.Errorf("%s: enqueue async handler: %w", e.EventName(), ctx.Err()))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Start launches the async workers.
+func (b *Bus) Start(context.Context) error {
+	for i := 0; i < b.workers; i++ {
+		b.wg.Add(1)
+		go b.work()
+	}
+	return nil
+}
+
+// Stop rejects new events, drains queued deliveries and waits for the workers.
+func (b *Bus) Stop(ctx context.Context) error {
+	b.mu.Lock()
+	if !b.closed {
+		b.closed = true
+		close(b.queue)
+	}
+	b.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		b.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (b *Bus) work() {
+	defer b.wg.Done()
+	for d := range b.queue {
+		if err := d.sub.handle(d.ctx, d.event); err != nil {
+			b.logger.Printf("async %s handler failed: %v", d.event.EventName(), err)
+		}
+	}
+}
+
+// Publishers fans each Publish out to several publishers in order, e.g. the
+// local bus for in-process side effects and a broker for other services.
+type Publishers []domain.EventPublisher
+
+func (ps Publishers) Publish(ctx context.Context, events ...domain.DomainEvent) error {
+	for _, p := range ps {
+		if err := p.Publish(ctx, events...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Forward passes e on to each publisher in order, under id where it can.
+func (ps Publishers) Forward(ctx context.Context, id string, e domain.DomainEvent) error {
+	for _, p := range ps {
+		if err := eventcodec.Forward(ctx, p, id, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Except publishes to p every event but those named, e.g. to keep events
+// meant for in-process consumers away from a broker.
+func Except(p domain.EventPublisher, names ...string) domain.EventPublisher {
+	return except{p: p, names: names}
+}
+
+type except struct {
+	p     domain.EventPublisher
+	names []string
+}
+
+func (x except) Publish(ctx context.Context, events ...domain.DomainEvent) error {
+	kept := make([]domain.DomainEvent, 0, len(events))
+	for _, e := range events {
+		if !slices.Contains(x.names, e.EventName()) {
+			kept = append(kept, e)
+		}
+	}
+	if len(kept) == 0 {
+		return nil
+	}
+	return x.p.Publish(ctx, kept...)
+}
+
+func (x except) Forward(ctx context.Context, id string, e domain.DomainEvent) error {
+	if slices.Contains(x.names, e.EventName()) {
+		return nil
+	}
+	return eventcodec.Forward(ctx, x.p, id, e)
+}
@@ -0,0 +1,54 @@
+package eventbus
+
+import (
+	"context"
+	"fmt"
+
+	"clean_go_system/internal/adapter/eventcodec"
+	"clean_go_system/internal/domain"
+)
+
+// DedupStore remembers which events each consumer has already handled.
+type DedupStore interface {
+	// Claim records id for consumer and reports false if it was already recorded.
+	Claim(ctx context.Context, consumer, id string) (bool, error)
+	// Release forgets a claim whose handler failed, so a redelivery can retry it.
+	Release(ctx context.Context, consumer, id string) error
+}
+
+// Idempotent wraps handler so each event ID is handled once per consumer,
+// turning the at-least-once delivery of the outbox and brokers into
+// effectively-once side effects (one welcome email, one projection write).
+// Events without an ID (new in this process, or legacy payloads) are
+// always handled.
+//
+// The claim and the handler run in one transaction of tx, so a claim only
+// sticks once the handler has succeeded: a handler error or a crash
+// before commit leaves the event to be redelivered rather than lost. With
+// a store tx cannot roll back, the claim is released on error instead.
+func Idempotent[E domain.DomainEvent](store DedupStore, tx domain.Transactor, consumer string, handler func(ctx context.Context, event E) error) func(ctx context.Context, event E) error {
+	return func(ctx context.Context, event E) error {
+		id := eventcodec.EventID(ctx)
+		if id == "" {
+			return handler(ctx, event)
+		}
+
+		return tx.WithinTransaction(ctx, func(ctx context.Context) error {
+			claimed, err := store.Claim(ctx, consumer, id)
+			if err != nil {
+				return fmt.Errorf("%s: claim event %s: %w", consumer, id, err)
+			}
+			if !claimed {
+				return nil // duplicate delivery
+			}
+
+			if err := handler(ctx, event); err != nil {
+				if releaseErr := store.Release(ctx, consumer, id); releaseErr != nil {
+					return fmt.Errorf("%w (and releasing claim failed: %v)", err, releaseErr)
+				}
+				return err
+			}
+			return nil
+		})
+	}
+}
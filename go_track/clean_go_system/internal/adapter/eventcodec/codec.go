@@ -0,0 +1,303 @@
+package eventcodec
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"clean_go_system/internal/domain"
+	"github.com/google/uuid"
+)
+
+// Envelope is the wire format of every event that crosses a process
+// boundary (outbox, brokers). ID is unique per event occurrence and stays
+// the same across redeliveries; Version is the payload schema version.
+// Sealed names the payload fields that hold ciphertext (see Registry.PII).
+type Envelope struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	Version    int             `json:"version"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Payload    json.RawMessage `json:"payload"`
+	Sealed     []string        `json:"sealed,omitempty"`
+}
+
+// Upcaster rewrites a payload from one schema version to the next.
+type Upcaster func(payload json.RawMessage) (json.RawMessage, error)
+
+// FieldCipher encrypts single string values; *fieldcrypt.Codec is one.
+type FieldCipher interface {
+	Encrypt(ctx context.Context, plaintext string, aad []byte) ([]byte, error)
+	Decrypt(ctx context.Context, ciphertext, aad []byte) (string, error)
+}
+
+type schema struct {
+	version   int
+	decode    func([]byte) (domain.DomainEvent, error)
+	upcasters map[int]Upcaster // keyed by the version they upgrade from
+	personal  []string         // payload fields PII seals
+}
+
+// Registry knows the current schema version of each event type and how to
+// upgrade older payloads, so consumers keep working when events gain fields.
+type Registry struct {
+	// PII, when set, encrypts the fields Personal marks in every payload
+	// encoded, so email addresses reach neither the outbox table nor a
+	// broker in the clear, and decrypts them again when decoding. Every
+	// consumer of sealed events needs the same keys. Set it at startup,
+	// before the first event is encoded.
+	PII FieldCipher
+
+	schemas map[string]schema
+}
+
+func NewRegistry() *Registry {
+	return &Registry{schemas: make(map[string]schema)}
+}
+
+// Register declares E at its current version. upcasters[n] must turn a
+// version-n payload into version n+1, for every n below version.
+func Register[E domain.DomainEvent](r *Registry, version int, upcasters map[int]Upcaster) {
+	var zero E
+	r.schemas[zero.EventName()] = schema{
+		version:   version,
+		decode:    decodeAs[E],
+		upcasters: upcasters,
+	}
+}
+
+// Personal marks string fields of E, already registered, as personal data
+// for PII to seal.
+func Personal[E domain.DomainEvent](r *Registry, fields ...string) {
+	var zero E
+	s := r.schemas[zero.EventName()]
+	s.personal = fields
+	r.schemas[zero.EventName()] = s
+}
+
+// Default holds every event this service produces or consumes.
+var Default = func() *Registry {
+	r := NewRegistry()
+	Register[domain.UserRegistered](r, 1, nil)
+	Register[domain.UserEmailChanged](r, 1, nil)
+	Register[domain.UserDeactivated](r, 1, nil)
+	Register[domain.UserVerified](r, 1, nil)
+	Register[domain.UserDeleted](r, 1, nil)
+	Register[domain.UserLocaleChanged](r, 1, nil)
+	Register[domain.UserUpdated](r, 1, nil)
+	Register[domain.EmailRequested](r, 1, nil)
+	Personal[domain.UserRegistered](r, "Email")
+	Personal[domain.UserEmailChanged](r, "OldEmail", "NewEmail")
+	Personal[domain.UserDeactivated](r, "Email")
+	Personal[domain.UserVerified](r, "Email")
+	Personal[domain.UserDeleted](r, "Email")
+	Personal[domain.UserUpdated](r, "Email")
+	Personal[domain.EmailRequested](r, "Email")
+	return r
+}()
+
+// Marshal wraps e in an Envelope stamped with its current version and a
+// fresh ID: e is a new occurrence, whatever event ctx is handling.
+func (r *Registry) Marshal(ctx context.Context, e domain.DomainEvent) ([]byte, error) {
+	return r.MarshalWithID(ctx, e, uuid.NewString())
+}
+
+// MarshalWithID is Marshal for an event that already has an ID, such as
+// one the outbox relay passes on to a broker, so the ID survives the hop.
+func (r *Registry) MarshalWithID(ctx context.Context, e domain.DomainEvent, id string) ([]byte, error) {
+	s, ok := r.schemas[e.EventName()]
+	if !ok {
+		return nil, fmt.Errorf("unknown event type %q", e.EventName())
+	}
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return nil, fmt.Errorf("encode %s: %w", e.EventName(), err)
+	}
+	var sealed []string
+	if r.PII != nil && len(s.personal) > 0 {
+		if payload, sealed, err = r.seal(ctx, id, payload, s.personal); err != nil {
+			return nil, fmt.Errorf("seal %s: %w", e.EventName(), err)
+		}
+	}
+	return json.Marshal(Envelope{
+		ID:         id,
+		Type:       e.EventName(),
+		Version:    s.version,
+		OccurredAt: e.OccurredAt(),
+		Payload:    payload,
+		Sealed:     sealed,
+	})
+}
+
+// Unmarshal decodes an Envelope, upcasting older payloads to the current
+// version. It returns the event and its ID.
+func (r *Registry) Unmarshal(ctx context.Context, data []byte) (domain.DomainEvent, string, error) {
+	var env Envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, "", fmt.Errorf("decode envelope: %w", err)
+	}
+	e, err := r.decodeEnvelope(ctx, env)
+	return e, env.ID, err
+}
+
+// Decode is for transports that carry the type beside the data (outbox
+// column, broker header). It also accepts bare, un-enveloped payloads
+// written before envelopes existed, treating them as version 1 with no ID.
+func (r *Registry) Decode(ctx context.Context, eventType string, data []byte) (domain.DomainEvent, string, error) {
+	var env Envelope
+	if err := json.Unmarshal(data, &env); err == nil && env.Type != "" && len(env.Payload) > 0 {
+		e, err := r.decodeEnvelope(ctx, env)
+		return e, env.ID, err
+	}
+	e, err := r.decodeEnvelope(ctx, Envelope{Type: eventType, Version: 1, Payload: bytes.TrimSpace(data)})
+	return e, "", err
+}
+
+func (r *Registry) decodeEnvelope(ctx context.Context, env Envelope) (domain.DomainEvent, error) {
+	s, ok := r.schemas[env.Type]
+	if !ok {
+		return nil, fmt.Errorf("unknown event type %q", env.Type)
+	}
+	if env.Version > s.version {
+		return nil, fmt.Errorf("%s version %d is newer than supported version %d", env.Type, env.Version, s.version)
+	}
+
+	payload := env.Payload
+	if len(env.Sealed) > 0 {
+		if r.PII == nil {
+			return nil, fmt.Errorf("%s is sealed, but no PII keys are set", env.Type)
+		}
+		var err error
+		if payload, err = r.open(ctx, env.ID, payload, env.Sealed); err != nil {
+			return nil, fmt.Errorf("open %s: %w", env.Type, err)
+		}
+	}
+	for v := env.Version; v < s.version; v++ {
+		up, ok := s.upcasters[v]
+		if !ok {
+			return nil, fmt.Errorf("%s: no upcaster from version %d", env.Type, v)
+		}
+		var err error
+		if payload, err = up(payload); err != nil {
+			return nil, fmt.Errorf("%s: upcast from version %d: %w", env.Type, v, err)
+		}
+	}
+
+	e, err := s.decode(payload)
+	if err != nil {
+		return nil, fmt.Errorf("decode %s: %w", env.Type, err)
+	}
+	return e, nil
+}
+
+// seal encrypts the non-empty fields of payload among personal, bound to
+// the event ID and field name, and returns the fields it sealed.
+func (r *Registry) seal(ctx context.Context, id string, payload []byte, personal []string) ([]byte, []string, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(payload, &fields); err != nil {
+		return nil, nil, err
+	}
+	var sealed []string
+	for _, name := range personal {
+		var plaintext string
+		if err := json.Unmarshal(fields[name], &plaintext); err != nil || plaintext == "" {
+			continue
+		}
+		ciphertext, err := r.PII.Encrypt(ctx, plaintext, sealedAAD(id, name))
+		if err != nil {
+			return nil, nil, err
+		}
+		if fields[name], err = json.Marshal(ciphertext); err != nil {
+			return nil, nil, err
+		}
+		sealed = append(sealed, name)
+	}
+	payload, err := json.Marshal(fields)
+	return payload, sealed, err
+}
+
+// open reverses seal.
+func (r *Registry) open(ctx context.Context, id string, payload []byte, sealed []string) ([]byte, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(payload, &fields); err != nil {
+		return nil, err
+	}
+	for _, name := range sealed {
+		var ciphertext []byte
+		if err := json.Unmarshal(fields[name], &ciphertext); err != nil {
+			return nil, fmt.Errorf("field %s: %w", name, err)
+		}
+		plaintext, err := r.PII.Decrypt(ctx, ciphertext, sealedAAD(id, name))
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", name, err)
+		}
+		if fields[name], err = json.Marshal(plaintext); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(fields)
+}
+
+// sealedAAD binds a sealed value to its event and field, so it cannot be
+// moved to another.
+func sealedAAD(id, field string) []byte {
+	return []byte("event:" + id + ":" + field)
+}
+
+// Encode serializes e with the Default registry, under a fresh ID.
+func Encode(ctx context.Context, e domain.DomainEvent) ([]byte, error) {
+	return Default.Marshal(ctx, e)
+}
+
+// EncodeWithID serializes e with the Default registry, keeping its ID.
+func EncodeWithID(ctx context.Context, e domain.DomainEvent, id string) ([]byte, error) {
+	return Default.MarshalWithID(ctx, e, id)
+}
+
+// Decode deserializes an event of eventType with the Default registry.
+func Decode(ctx context.Context, eventType string, data []byte) (domain.DomainEvent, string, error) {
+	return Default.Decode(ctx, eventType, data)
+}
+
+type eventIDKey struct{}
+
+// WithEventID attaches the ID of the event being handled to ctx, so
+// consumers can deduplicate redeliveries (see eventbus.Idempotent). It is
+// never read when encoding: events published by the handler get IDs of
+// their own.
+func WithEventID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, eventIDKey{}, id)
+}
+
+// EventID returns the ID attached by WithEventID, or "".
+func EventID(ctx context.Context) string {
+	id, _ := ctx.Value(eventIDKey{}).(string)
+	return id
+}
+
+// Forwarder is implemented by publishers that can pass on an event which
+// already has an ID, as the outbox relay and the inbound transports do,
+// keeping that ID so consumers downstream can deduplicate redeliveries.
+type Forwarder interface {
+	Forward(ctx context.Context, id string, e domain.DomainEvent) error
+}
+
+// Forward passes e on to p under id, through p.Forward when p is a
+// Forwarder. Other publishers, and events without an ID, are published
+// as new.
+func Forward(ctx context.Context, p domain.EventPublisher, id string, e domain.DomainEvent) error {
+	if f, ok := p.(Forwarder); ok && id != "" {
+		return f.Forward(ctx, id, e)
+	}
+	return p.Publish(ctx, e)
+}
+
+func decodeAs[E domain.DomainEvent](raw []byte) (domain.DomainEvent, error) {
+	var e E
+	if err := json.Unmarshal(raw, &e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
@@ -0,0 +1,118 @@
+// Package fallback decorates ports so failing storage degrades to stale
+// data instead of an error.
+package fallback
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"clean-code-cookbook/go/pkg/cacheaside"
+	"clean_go_system/internal/domain"
+	"github.com/google/uuid"
+)
+
+// UserRepository remembers every user repo returned or stored. When a
+// lookup fails, it serves the last-known-good copy flagged Stale instead.
+// Not-found and the caller's own cancellation are passed through, and
+// writes are never faked: they fail as repo fails.
+type UserRepository struct {
+	// OnFallback, if set, is called whenever a stale copy is served.
+	OnFallback func(key string, err error)
+
+	repo     domain.UserRepository
+	lastGood cacheaside.Store[domain.User]
+}
+
+// NewUserRepository keeps last-known-good copies in lastGood, whose TTL
+// bounds how old a served copy can be.
+func NewUserRepository(repo domain.UserRepository, lastGood cacheaside.Store[domain.User]) *UserRepository {
+	return &UserRepository{repo: repo, lastGood: lastGood}
+}
+
+// Keys carry the tenant, like the cache's, so a stale copy is only ever
+// served to the tenant it belongs to.
+func emailKey(ctx context.Context, email string) string {
+	return "user:" + string(domain.TenantOf(ctx)) + ":email:" + email
+}
+
+func idKey(ctx context.Context, id uuid.UUID) string {
+	return "user:" + string(domain.TenantOf(ctx)) + ":id:" + id.String()
+}
+
+func (r *UserRepository) Save(ctx context.Context, u domain.User) error {
+	if err := r.repo.Save(ctx, u); err != nil {
+		return err
+	}
+	r.remember(ctx, u)
+	return nil
+}
+
+func (r *UserRepository) Update(ctx context.Context, u domain.User) error {
+	if err := r.repo.Update(ctx, u); err != nil {
+		return err
+	}
+	// The email may have changed: forget the copy under the old one.
+	if old, err := r.lastGood.Get(ctx, idKey(ctx, u.ID)); err == nil && old.Email != u.Email {
+		r.lastGood.Delete(ctx, emailKey(ctx, old.Email)) //nolint:errcheck // best effort
+	}
+	r.remember(ctx, u)
+	return nil
+}
+
+func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
+	u, err := r.repo.GetByEmail(ctx, email)
+	return r.lookedUp(ctx, emailKey(ctx, email), u, err)
+}
+
+func (r *UserRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	u, err := r.repo.GetByID(ctx, id)
+	return r.lookedUp(ctx, idKey(ctx, id), u, err)
+}
+
+// lookedUp records a successful lookup, or replaces a failed one by the
+// copy remembered under key.
+func (r *UserRepository) lookedUp(ctx context.Context, key string, u *domain.User, err error) (*domain.User, error) {
+	switch {
+	case err == nil:
+		r.remember(ctx, *u)
+		return u, nil
+	case errors.Is(err, domain.ErrUserNotFound):
+		r.lastGood.Delete(ctx, key) //nolint:errcheck // best effort
+		return nil, err
+	case errors.Is(err, context.Canceled):
+		return nil, err
+	}
+
+	stale, lastErr := r.lastGood.Get(ctx, key)
+	if lastErr != nil {
+		return nil, err
+	}
+	if r.OnFallback != nil {
+		r.OnFallback(key, err)
+	}
+	stale.Stale = true
+	return &stale, nil
+}
+
+// remember keeps u for later failures. A deleted user is forgotten
+// instead, so an outage cannot bring it back.
+func (r *UserRepository) remember(ctx context.Context, u domain.User) {
+	if u.Deleted() {
+		r.lastGood.Delete(ctx, emailKey(ctx, u.Email), idKey(ctx, u.ID)) //nolint:errcheck // best effort
+		return
+	}
+	u.Stale = false
+	r.lastGood.Set(ctx, emailKey(ctx, u.Email), u) //nolint:errcheck // best effort
+	r.lastGood.Set(ctx, idKey(ctx, u.ID), u)       //nolint:errcheck // best effort
+}
+
+// Flush forgets every copy kept for ctx's tenant, e.g. after fixing rows
+// by hand, so an outage cannot serve them as they were.
+func (r *UserRepository) Flush(ctx context.Context) (int, error) {
+	d, ok := r.lastGood.(cacheaside.PrefixDeleter)
+	if !ok {
+		return 0, fmt.Errorf("fallback: flush: %w", errors.ErrUnsupported)
+	}
+	return d.DeletePrefix(ctx, "user:"+string(domain.TenantOf(ctx))+":")
+}
@@ -0,0 +1,171 @@
+// Package fieldcrypt encrypts individual column values with AES-256-GCM
+// and computes blind indexes over them, so a storage adapter can keep
+// personal data encrypted at rest and still look rows up by it.
+package fieldcrypt
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"clean_go_system/internal/domain"
+)
+
+// ErrCiphertext means a value is truncated, was tampered with, or belongs
+// to another row or column.
+var ErrCiphertext = errors.New("fieldcrypt: ciphertext cannot be decrypted")
+
+// Codec encrypts and indexes field values. A ciphertext is the key
+// version (4 bytes, big endian), a random nonce and the sealed value.
+type Codec struct {
+	keys domain.KeyProvider
+}
+
+func NewCodec(keys domain.KeyProvider) *Codec {
+	return &Codec{keys: keys}
+}
+
+// Encrypt seals plaintext under the current key. aad binds the result to
+// where it is stored (say, the column and row ID): the same aad must be
+// passed to Decrypt, so ciphertexts cannot be swapped between rows.
+func (c *Codec) Encrypt(ctx context.Context, plaintext string, aad []byte) ([]byte, error) {
+	key, err := c.keys.Current(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("fieldcrypt: current key: %w", err)
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, 4+aead.NonceSize(), 4+aead.NonceSize()+len(plaintext)+aead.Overhead())
+	binary.BigEndian.PutUint32(out, key.Version)
+	if _, err := rand.Read(out[4:]); err != nil {
+		return nil, fmt.Errorf("fieldcrypt: nonce: %w", err)
+	}
+	return aead.Seal(out, out[4:], []byte(plaintext), aad), nil
+}
+
+// Decrypt opens a value sealed by Encrypt under any key version the
+// provider still has.
+func (c *Codec) Decrypt(ctx context.Context, ciphertext, aad []byte) (string, error) {
+	if len(ciphertext) < 4 {
+		return "", ErrCiphertext
+	}
+	key, err := c.keys.Version(ctx, binary.BigEndian.Uint32(ciphertext))
+	if err != nil {
+		return "", fmt.Errorf("fieldcrypt: key version %d: %w", binary.BigEndian.Uint32(ciphertext), err)
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", err
+	}
+	rest := ciphertext[4:]
+	if len(rest) < aead.NonceSize() {
+		return "", ErrCiphertext
+	}
+	plaintext, err := aead.Open(nil, rest[:aead.NonceSize()], rest[aead.NonceSize():], aad)
+	if err != nil {
+		return "", ErrCiphertext
+	}
+	return string(plaintext), nil
+}
+
+// BlindIndex returns a keyed hash of value for equality lookups. field
+// keeps indexes of different columns unrelated, so equal values in two
+// columns do not show up as equal hashes.
+func (c *Codec) BlindIndex(ctx context.Context, field, value string) ([]byte, error) {
+	key, err := c.keys.IndexKey(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("fieldcrypt: index key: %w", err)
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(field))
+	mac.Write([]byte{0})
+	mac.Write([]byte(value))
+	return mac.Sum(nil), nil
+}
+
+func newAEAD(key domain.DataKey) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key.Secret)
+	if err != nil {
+		return nil, fmt.Errorf("fieldcrypt: key version %d: %w", key.Version, err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// StaticKeys is a KeyProvider over keys held in configuration. The
+// highest version is current.
+type StaticKeys struct {
+	keys    map[uint32]domain.DataKey
+	current uint32
+	index   []byte
+}
+
+// NewStaticKeys needs at least one 32-byte data key and an index key of at
+// least 32 bytes.
+func NewStaticKeys(index []byte, keys ...domain.DataKey) (*StaticKeys, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("fieldcrypt: no data keys")
+	}
+	if len(index) < 32 {
+		return nil, errors.New("fieldcrypt: index key must be at least 32 bytes")
+	}
+	s := &StaticKeys{keys: make(map[uint32]domain.DataKey, len(keys)), index: index}
+	for _, k := range keys {
+		if len(k.Secret) != 32 {
+			return nil, fmt.Errorf("fieldcrypt: key version %d must be 32 bytes, got %d", k.Version, len(k.Secret))
+		}
+		if _, dup := s.keys[k.Version]; dup {
+			return nil, fmt.Errorf("fieldcrypt: key version %d given twice", k.Version)
+		}
+		s.keys[k.Version] = k
+		s.current = max(s.current, k.Version)
+	}
+	return s, nil
+}
+
+// ParseKeys reads "version:base64key,..." as found in PII_KEYS.
+func ParseKeys(s string) ([]domain.DataKey, error) {
+	var keys []domain.DataKey
+	for _, pair := range strings.Split(s, ",") {
+		version, secret, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		if !ok {
+			return nil, errors.New("fieldcrypt: key is not version:base64")
+		}
+		v, err := strconv.ParseUint(version, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("fieldcrypt: key version %q: %w", version, err)
+		}
+		raw, err := base64.StdEncoding.DecodeString(secret)
+		if err != nil {
+			return nil, fmt.Errorf("fieldcrypt: key version %d: %w", v, err)
+		}
+		keys = append(keys, domain.DataKey{Version: uint32(v), Secret: raw})
+	}
+	return keys, nil
+}
+
+func (s *StaticKeys) Current(context.Context) (domain.DataKey, error) {
+	return s.keys[s.current], nil
+}
+
+func (s *StaticKeys) Version(_ context.Context, v uint32) (domain.DataKey, error) {
+	k, ok := s.keys[v]
+	if !ok {
+		return domain.DataKey{}, domain.ErrUnknownKeyVersion
+	}
+	return k, nil
+}
+
+func (s *StaticKeys) IndexKey(context.Context) ([]byte, error) {
+	return s.index, nil
+}
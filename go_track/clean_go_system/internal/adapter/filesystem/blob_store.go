@@ -0,0 +1,189 @@
+// Package filesystem implements domain.BlobStore on a local directory, for
+// development and single-node deployments.
+package filesystem
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"clean_go_system/internal/domain"
+	"clean_go_system/pkg/clock"
+)
+
+// BlobStore keeps each object in a file under root. Signed URLs point at
+// baseURL and are verified by Handler with an HMAC over key and expiry, so
+// they work without a separate auth layer.
+//
+// The content type is derived from the key's extension; Put's contentType
+// is not persisted.
+type BlobStore struct {
+	// Clock stamps and checks link expiry; it defaults to the wall clock.
+	Clock domain.Clock
+
+	root    string
+	baseURL string
+	secret  []byte
+}
+
+func NewBlobStore(root, baseURL string, secret []byte) (*BlobStore, error) {
+	if len(secret) == 0 {
+		return nil, errors.New("filesystem blob store: signing secret is required")
+	}
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, fmt.Errorf("create blob root: %w", err)
+	}
+	return &BlobStore{Clock: clock.System, root: root, baseURL: strings.TrimSuffix(baseURL, "/"), secret: secret}, nil
+}
+
+// Put writes to a temporary file and renames it into place, so readers
+// never observe a partial object.
+func (s *BlobStore) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) //nolint:errcheck // no-op after Rename
+
+	if _, err := io.Copy(tmp, &ctxReader{ctx: ctx, r: r}); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write blob %s: %w", key, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), p)
+}
+
+func (s *BlobStore) Get(ctx context.Context, key string) (io.ReadCloser, domain.BlobInfo, error) {
+	f, info, err := s.open(key)
+	if err != nil {
+		return nil, domain.BlobInfo{}, err
+	}
+	return f, info, nil
+}
+
+func (s *BlobStore) Delete(ctx context.Context, key string) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *BlobStore) SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	if _, err := s.path(key); err != nil {
+		return "", err
+	}
+	expires := strconv.FormatInt(s.Clock.Now().Add(expiry).Unix(), 10)
+	q := url.Values{"expires": {expires}, "signature": {s.sign(key, expires)}}
+	return s.baseURL + "/" + (&url.URL{Path: key}).EscapedPath() + "?" + q.Encode(), nil
+}
+
+// Handler serves signed URLs. Mount it at baseURL's path with
+// http.StripPrefix. Range requests are supported, so large files stream.
+func (s *BlobStore) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimPrefix(r.URL.Path, "/")
+		expires := r.URL.Query().Get("expires")
+		signature := r.URL.Query().Get("signature")
+
+		// 1. Verify signature and expiry
+		unix, err := strconv.ParseInt(expires, 10, 64)
+		if err != nil || !hmac.Equal([]byte(signature), []byte(s.sign(key, expires))) {
+			http.Error(w, "invalid signature", http.StatusForbidden)
+			return
+		}
+		if s.Clock.Now().Unix() > unix {
+			http.Error(w, "link expired", http.StatusForbidden)
+			return
+		}
+
+		// 2. Stream the file
+		f, info, err := s.open(key)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		defer f.Close()
+		w.Header().Set("Content-Type", info.ContentType)
+		http.ServeContent(w, r, key, info.ModTime, f)
+	})
+}
+
+func (s *BlobStore) open(key string) (*os.File, domain.BlobInfo, error) {
+	p, err := s.path(key)
+	if err != nil {
+		return nil, domain.BlobInfo{}, err
+	}
+	f, err := os.Open(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, domain.BlobInfo{}, domain.ErrBlobNotFound
+	}
+	if err != nil {
+		return nil, domain.BlobInfo{}, err
+	}
+	stat, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, domain.BlobInfo{}, err
+	}
+	contentType := mime.TypeByExtension(path.Ext(key))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	return f, domain.BlobInfo{Size: stat.Size(), ContentType: contentType, ModTime: stat.ModTime()}, nil
+}
+
+// path maps a slash-separated key into root, rejecting keys that would
+// escape it.
+func (s *BlobStore) path(key string) (string, error) {
+	if key == "" || strings.HasPrefix(key, "/") || path.Clean(key) != key || key == ".." || strings.HasPrefix(key, "../") {
+		return "", fmt.Errorf("%w: %q", domain.ErrInvalidKey, key)
+	}
+	return filepath.Join(s.root, filepath.FromSlash(key)), nil
+}
+
+func (s *BlobStore) sign(key, expires string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(key + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ctxReader stops a long copy once ctx is done.
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c *ctxReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
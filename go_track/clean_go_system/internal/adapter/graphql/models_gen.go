@@ -0,0 +1,23 @@
+// Code generated by github.com/99designs/gqlgen, DO NOT EDIT.
+
+package graphqladapter
+
+import (
+	"clean_go_system/internal/domain"
+)
+
+type Mutation struct {
+}
+
+type ProductPage struct {
+	Items []*domain.Product `json:"items"`
+	Next  *string           `json:"next,omitempty"`
+}
+
+type Query struct {
+}
+
+type UserPage struct {
+	Items []*domain.User `json:"items"`
+	Next  *string        `json:"next,omitempty"`
+}
@@ -0,0 +1,209 @@
+// Package graphqladapter serves the GraphQL API, an alternative read API
+// for frontends that want several users, or only some of their fields, in
+// one round trip. Resolvers call core.UserService like the REST handlers
+// do, so authorization and tenancy apply the same way; products come from
+// the catalog service through a domain.ProductCatalog.
+//
+// generated.go and models_gen.go come from schema.graphqls; after editing
+// the schema, run go generate and fill in the new stubs.
+package graphqladapter
+
+//go:generate go run github.com/99designs/gqlgen generate --config gqlgen.yml
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"runtime/debug"
+	"strings"
+
+	"clean-code-cookbook/go/pkg/bulkhead"
+	"clean-code-cookbook/go/pkg/page"
+	httpadapter "clean_go_system/internal/adapter/http"
+	"clean_go_system/internal/core"
+	"clean_go_system/internal/domain"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/lru"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/google/uuid"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// Resolver is the root the generated executor calls into.
+type Resolver struct {
+	users *core.UserService
+	// products is nil when no catalog is configured.
+	products domain.ProductCatalog
+}
+
+// Limits bound what one operation may ask for, so a single request cannot
+// fan out into thousands of lookups. Both are checked before anything runs.
+type Limits struct {
+	// MaxDepth caps how deeply fields nest, not counting introspection.
+	MaxDepth int
+	// MaxComplexity caps the operation's cost: a field costs one, and a
+	// list of users or products costs its length times the fields asked of
+	// each.
+	MaxComplexity int
+	// Introspection lets clients query the schema, as code generators and
+	// GraphiQL do.
+	Introspection bool
+}
+
+// NewHandler returns the handler for POST /graphql. Mount it behind
+// BearerAuth; every field but the user's own is then authorized by the
+// user service. products may be nil, which leaves the product fields
+// answering NOT_IMPLEMENTED.
+func NewHandler(users *core.UserService, products domain.ProductCatalog, limits Limits, logger *log.Logger) http.Handler {
+	cfg := Config{Resolvers: &Resolver{users: users, products: products}}
+	cfg.Complexity.Query.Users = func(childComplexity int, size *int, _, _ *string) int {
+		return pageSize(size) * childComplexity
+	}
+	cfg.Complexity.Query.UsersByIds = func(childComplexity int, ids []uuid.UUID) int {
+		return len(ids) * childComplexity
+	}
+	cfg.Complexity.Query.Products = func(childComplexity int, _ *string, _, _ *float64, size *int, _, _ *string) int {
+		return pageSize(size) * childComplexity
+	}
+
+	srv := handler.New(NewExecutableSchema(cfg))
+	srv.AddTransport(transport.POST{})
+	srv.SetQueryCache(lru.New(1000))
+	srv.Use(extension.FixedComplexityLimit(limits.MaxComplexity))
+	srv.Use(depthLimit(limits.MaxDepth))
+	if limits.Introspection {
+		srv.Use(extension.Introspection{})
+	}
+	srv.SetErrorPresenter(presentError(logger))
+	srv.SetRecoverFunc(func(_ context.Context, v any) error {
+		logger.Printf("graphql: panic: %v\n%s", v, debug.Stack())
+		return gqlerror.Errorf("internal server error")
+	})
+	return srv
+}
+
+// pageSize is how many items a users or products field returns at most,
+// as UserService.List and the catalog's search clamp it.
+func pageSize(size *int) int {
+	switch {
+	case size == nil || *size < 1:
+		return 20
+	case *size > 100:
+		return 100
+	default:
+		return *size
+	}
+}
+
+// errUnauthenticated is returned by me when the request has no principal,
+// which only happens when the handler is mounted without BearerAuth.
+var errUnauthenticated = errors.New("missing bearer token")
+
+// errNoCatalog is returned by the product fields when no catalog is
+// configured.
+var errNoCatalog = fmt.Errorf("no catalog configured: %w", errors.ErrUnsupported)
+
+// presentError maps domain errors to GraphQL errors with a code in their
+// extensions, the way the REST handlers map them to statuses. Anything
+// unexpected is logged and answered without its details.
+func presentError(logger *log.Logger) graphql.ErrorPresenterFunc {
+	return func(ctx context.Context, err error) *gqlerror.Error {
+		gqlErr := graphql.DefaultErrorPresenter(ctx, err)
+		if gqlErr.Err == nil {
+			// Parse and validation errors, and the limits': already fit
+			// for the client.
+			return gqlErr
+		}
+		err = gqlErr.Err
+		var invalid *domain.ValidationError
+		switch {
+		case errors.As(err, &invalid):
+			gqlErr.Message = "validation failed"
+			gqlErr.Extensions = map[string]any{"code": "BAD_USER_INPUT", "fields": invalid.Fields}
+			return gqlErr
+		case errors.Is(err, domain.ErrInvalidLocale), errors.Is(err, domain.ErrInvalidProductSearch),
+			errors.Is(err, page.ErrInvalidCursor), errors.Is(err, page.ErrInvalidSort):
+			errcode.Set(gqlErr, "BAD_USER_INPUT")
+		case errors.Is(err, domain.ErrUserNotFound):
+			gqlErr.Message = domain.ErrUserNotFound.Error()
+			errcode.Set(gqlErr, "NOT_FOUND")
+		case errors.Is(err, domain.ErrForbidden):
+			gqlErr.Message = domain.ErrForbidden.Error()
+			errcode.Set(gqlErr, "FORBIDDEN")
+		case errors.Is(err, errUnauthenticated):
+			errcode.Set(gqlErr, "UNAUTHENTICATED")
+		case errors.Is(err, errors.ErrUnsupported):
+			gqlErr.Message = "not supported by this deployment"
+			errcode.Set(gqlErr, "NOT_IMPLEMENTED")
+		case errors.Is(err, core.ErrQueueFull), errors.Is(err, bulkhead.ErrFull), errors.Is(err, domain.ErrCatalogUnavailable):
+			gqlErr.Message = "service busy, retry later"
+			errcode.Set(gqlErr, "UNAVAILABLE")
+		default:
+			logger.Printf("graphql: %s: %v", gqlErr.Path, err)
+			gqlErr.Message = "internal server error"
+			errcode.Set(gqlErr, "INTERNAL_SERVER_ERROR")
+		}
+		return gqlErr
+	}
+}
+
+// principal returns the caller, as BearerAuth authenticated them.
+func principal(ctx context.Context) (httpadapter.Principal, error) {
+	p, ok := httpadapter.PrincipalFrom(ctx)
+	if !ok {
+		return httpadapter.Principal{}, errUnauthenticated
+	}
+	return p, nil
+}
+
+// depthLimit rejects operations whose fields nest deeper than its value.
+// Introspection fields are not counted: the query clients send for the
+// schema follows type references a dozen levels down.
+type depthLimit int
+
+func (depthLimit) ExtensionName() string { return "DepthLimit" }
+
+func (depthLimit) Validate(graphql.ExecutableSchema) error { return nil }
+
+func (d depthLimit) MutateOperationContext(_ context.Context, oc *graphql.OperationContext) *gqlerror.Error {
+	op := oc.Doc.Operations.ForName(oc.OperationName)
+	if op == nil {
+		return nil
+	}
+	if depth := selectionDepth(op.SelectionSet); depth > int(d) {
+		err := gqlerror.Errorf("operation has depth %d, which exceeds the limit of %d", depth, int(d))
+		errcode.Set(err, "DEPTH_LIMIT_EXCEEDED")
+		return err
+	}
+	return nil
+}
+
+// selectionDepth is how many fields deep set goes, through fragments.
+// Validation has already ruled out fragments that spread themselves.
+func selectionDepth(set ast.SelectionSet) int {
+	deepest := 0
+	for _, sel := range set {
+		depth := 0
+		switch sel := sel.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(sel.Name, "__") {
+				continue
+			}
+			depth = 1 + selectionDepth(sel.SelectionSet)
+		case *ast.InlineFragment:
+			depth = selectionDepth(sel.SelectionSet)
+		case *ast.FragmentSpread:
+			if sel.Definition != nil {
+				depth = selectionDepth(sel.Definition.SelectionSet)
+			}
+		}
+		deepest = max(deepest, depth)
+	}
+	return deepest
+}
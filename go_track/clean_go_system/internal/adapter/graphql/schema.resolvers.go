@@ -0,0 +1,148 @@
+package graphqladapter
+
+import (
+	"clean-code-cookbook/go/pkg/concurrent"
+	"clean-code-cookbook/go/pkg/page"
+	"clean_go_system/internal/domain"
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+// SetLocale is the resolver for the setLocale field.
+func (r *mutationResolver) SetLocale(ctx context.Context, locale string) (*domain.User, error) {
+	p, err := principal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return r.users.ChangeLocale(ctx, p.UserID, locale)
+}
+
+// DeleteUser is the resolver for the deleteUser field.
+func (r *mutationResolver) DeleteUser(ctx context.Context, id uuid.UUID) (bool, error) {
+	if err := r.users.Delete(ctx, id); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Me is the resolver for the me field.
+func (r *queryResolver) Me(ctx context.Context) (*domain.User, error) {
+	p, err := principal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return r.users.Get(ctx, p.UserID)
+}
+
+// User is the resolver for the user field.
+func (r *queryResolver) User(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	// gqlgen resolves the fields of a query concurrently, so aliased user
+	// fields, like the items of usersByIds, share the loader's batch.
+	user, err := r.users.Load(ctx, id)
+	if errors.Is(err, domain.ErrUserNotFound) {
+		return nil, nil
+	}
+	return user, err
+}
+
+// UsersByIds is the resolver for the usersByIds field.
+func (r *queryResolver) UsersByIds(ctx context.Context, ids []uuid.UUID) ([]*domain.User, error) {
+	return concurrent.Map(ctx, concurrent.Options{}, ids, r.User)
+}
+
+// Users is the resolver for the users field.
+func (r *queryResolver) Users(ctx context.Context, size *int, after *string, sort *string) (*UserPage, error) {
+	var req page.Request
+	if size != nil {
+		req.Size = *size
+	}
+	if after != nil {
+		req.After = page.Cursor(*after)
+	}
+	if sort != nil {
+		var err error
+		if req.Sort, err = page.ParseSort(*sort, domain.UserSortFields...); err != nil {
+			return nil, err
+		}
+	}
+	users, err := r.users.List(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &UserPage{Items: make([]*domain.User, len(users.Items))}
+	for i := range users.Items {
+		resp.Items[i] = &users.Items[i]
+	}
+	if users.Next != "" {
+		next := string(users.Next)
+		resp.Next = &next
+	}
+	return resp, nil
+}
+
+// Product is the resolver for the product field.
+func (r *queryResolver) Product(ctx context.Context, id string) (*domain.Product, error) {
+	if r.products == nil {
+		return nil, errNoCatalog
+	}
+	product, err := r.products.GetProduct(ctx, id)
+	if errors.Is(err, domain.ErrProductNotFound) {
+		return nil, nil
+	}
+	return product, err
+}
+
+// Products is the resolver for the products field.
+func (r *queryResolver) Products(ctx context.Context, q *string, minPrice *float64, maxPrice *float64, size *int, after *string, sort *string) (*ProductPage, error) {
+	if r.products == nil {
+		return nil, errNoCatalog
+	}
+	var search domain.ProductSearch
+	if q != nil {
+		search.Query = *q
+	}
+	if minPrice != nil {
+		search.MinPrice = *minPrice
+	}
+	if maxPrice != nil {
+		search.MaxPrice = *maxPrice
+	}
+	if size != nil {
+		search.Page.Size = *size
+	}
+	if after != nil {
+		search.Page.After = page.Cursor(*after)
+	}
+	if sort != nil {
+		var err error
+		if search.Page.Sort, err = page.ParseSort(*sort, domain.ProductSortFields...); err != nil {
+			return nil, err
+		}
+	}
+	products, err := r.products.SearchProducts(ctx, search)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &ProductPage{Items: make([]*domain.Product, len(products.Items))}
+	for i := range products.Items {
+		resp.Items[i] = &products.Items[i]
+	}
+	if products.Next != "" {
+		next := string(products.Next)
+		resp.Next = &next
+	}
+	return resp, nil
+}
+
+// Mutation returns MutationResolver implementation.
+func (r *Resolver) Mutation() MutationResolver { return &mutationResolver{r} }
+
+// Query returns QueryResolver implementation.
+func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
+
+type mutationResolver struct{ *Resolver }
+type queryResolver struct{ *Resolver }
@@ -0,0 +1,7 @@
+//go:build tools
+
+package graphqladapter
+
+// gqlgen is pinned in go.mod through this import, so go generate runs the
+// version the generated code was written for.
+import _ "github.com/99designs/gqlgen"
@@ -0,0 +1,335 @@
+package httpadapter
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"clean_go_system/internal/core"
+	"clean_go_system/internal/domain"
+	"github.com/google/uuid"
+)
+
+// AdminHandler serves the /admin operator endpoints. Mount it behind
+// APIKeyAuth.Require(domain.ScopeAdmin, ...); each action is then
+// authorized by core.Admin.
+type AdminHandler struct {
+	// DrainWait bounds how long a drain request waits for the pool to
+	// empty before answering 202 with the pool still draining.
+	DrainWait time.Duration
+
+	admin  *core.Admin
+	logger *log.Logger
+}
+
+func NewAdminHandler(admin *core.Admin, logger *log.Logger) *AdminHandler {
+	return &AdminHandler{DrainWait: 5 * time.Second, admin: admin, logger: logger}
+}
+
+type adminUserResponse struct {
+	ID         string     `json:"id"`
+	Email      string     `json:"email"`
+	Username   string     `json:"username"`
+	Active     bool       `json:"active"`
+	VerifiedAt *time.Time `json:"verified_at,omitempty"`
+	DeletedAt  *time.Time `json:"deleted_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func toAdminUser(u domain.User) adminUserResponse {
+	return adminUserResponse{
+		ID:         u.ID.String(),
+		Email:      u.Email,
+		Username:   u.Username,
+		Active:     u.Active,
+		VerifiedAt: u.VerifiedAt,
+		DeletedAt:  u.DeletedAt,
+		CreatedAt:  u.CreatedAt,
+	}
+}
+
+type poolResponse struct {
+	Name        string `json:"name"`
+	Workers     int    `json:"workers"`
+	Queued      int    `json:"queued"`
+	Busy        int    `json:"busy"`
+	Paused      bool   `json:"paused"`
+	Draining    bool   `json:"draining"`
+	DeadLetters int    `json:"dead_letters"`
+}
+
+func toPool(s core.PoolStatus) poolResponse {
+	return poolResponse{
+		Name:        s.Name,
+		Workers:     s.Workers,
+		Queued:      s.Queued,
+		Busy:        s.Busy,
+		Paused:      s.Paused,
+		Draining:    s.Draining,
+		DeadLetters: s.DeadLetters,
+	}
+}
+
+type deliveryResponse struct {
+	ID         string    `json:"id"`
+	Template   string    `json:"template"`
+	Subject    string    `json:"subject"`
+	Status     string    `json:"status"`
+	Attempts   int       `json:"attempts"`
+	ProviderID string    `json:"provider_id,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+func toDelivery(d domain.Delivery) deliveryResponse {
+	return deliveryResponse{
+		ID:         d.ID.String(),
+		Template:   d.Template,
+		Subject:    d.Subject,
+		Status:     string(d.Status),
+		Attempts:   d.Attempts,
+		ProviderID: d.ProviderID,
+		Error:      d.Error,
+		CreatedAt:  d.CreatedAt,
+		UpdatedAt:  d.UpdatedAt,
+	}
+}
+
+// SearchUsers handles GET /admin/users?id=&email=, an exact lookup that
+// includes soft-deleted users.
+func (h *AdminHandler) SearchUsers(w http.ResponseWriter, r *http.Request) {
+	q := core.UserQuery{Email: r.URL.Query().Get("email")}
+	if id := r.URL.Query().Get("id"); id != "" {
+		var err error
+		if q.ID, err = uuid.Parse(id); err != nil {
+			http.Error(w, "invalid user id", http.StatusBadRequest)
+			return
+		}
+	}
+	users, err := h.admin.SearchUsers(r.Context(), q)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	items := make([]adminUserResponse, 0, len(users))
+	for _, u := range users {
+		items = append(items, toAdminUser(u))
+	}
+	writeAdminJSON(w, http.StatusOK, map[string]any{"items": items})
+}
+
+// exportColumns heads the CSV of ExportUsers.
+var exportColumns = []string{"id", "email", "username", "locale", "active", "verified_at", "deleted_at", "created_at"}
+
+// ExportUsers handles GET /admin/users/export: every user as CSV, streamed
+// as it is read. A failure once rows went out aborts the response, so the
+// client sees a broken download rather than a short file.
+func (h *AdminHandler) ExportUsers(w http.ResponseWriter, r *http.Request) {
+	out := csv.NewWriter(w)
+	started := false
+	start := func() error {
+		started = true
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="users.csv"`)
+		w.WriteHeader(http.StatusOK)
+		return out.Write(exportColumns)
+	}
+	err := h.admin.ExportUsers(r.Context(), func(u domain.User) error {
+		if !started {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		return out.Write([]string{
+			u.ID.String(), csvCell(u.Email), csvCell(u.Username), u.Locale, strconv.FormatBool(u.Active),
+			csvTime(u.VerifiedAt), csvTime(u.DeletedAt), u.CreatedAt.UTC().Format(time.RFC3339),
+		})
+	})
+	if err == nil && !started {
+		err = start()
+	}
+	if err == nil {
+		out.Flush()
+		err = out.Error()
+	}
+	switch {
+	case err == nil:
+	case !started:
+		h.writeError(w, err)
+	default:
+		h.logger.Printf("http: user export: %v", err)
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// csvCell keeps a user-chosen value from being read as a formula by
+// spreadsheets.
+func csvCell(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+func csvTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// EmailDeliveries handles GET /admin/emails?user=, where user is the
+// user's ID or email address.
+func (h *AdminHandler) EmailDeliveries(w http.ResponseWriter, r *http.Request) {
+	user := r.URL.Query().Get("user")
+	q := core.UserQuery{Email: user}
+	if id, err := uuid.Parse(user); err == nil {
+		q = core.UserQuery{ID: id}
+	}
+	deliveries, err := h.admin.EmailDeliveries(r.Context(), q)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	items := make([]deliveryResponse, 0, len(deliveries))
+	for _, d := range deliveries {
+		items = append(items, toDelivery(d))
+	}
+	writeAdminJSON(w, http.StatusOK, map[string]any{"items": items})
+}
+
+// LiftSuppression handles DELETE /admin/suppressions/{email}.
+func (h *AdminHandler) LiftSuppression(w http.ResponseWriter, r *http.Request) {
+	if err := h.admin.LiftSuppression(r.Context(), r.PathValue("email")); err != nil {
+		h.writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// SuspendUser handles POST /admin/users/{id}/suspend.
+func (h *AdminHandler) SuspendUser(w http.ResponseWriter, r *http.Request) {
+	h.changeUser(w, r, h.admin.SuspendUser)
+}
+
+// VerifyUser handles POST /admin/users/{id}/verify.
+func (h *AdminHandler) VerifyUser(w http.ResponseWriter, r *http.Request) {
+	h.changeUser(w, r, h.admin.VerifyUser)
+}
+
+func (h *AdminHandler) changeUser(w http.ResponseWriter, r *http.Request, change func(context.Context, uuid.UUID) (*domain.User, error)) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, domain.ErrUserNotFound.Error(), http.StatusNotFound)
+		return
+	}
+	user, err := change(r.Context(), id)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	writeAdminJSON(w, http.StatusOK, toAdminUser(*user))
+}
+
+// Pools handles GET /admin/workers.
+func (h *AdminHandler) Pools(w http.ResponseWriter, r *http.Request) {
+	statuses, err := h.admin.Pools(r.Context())
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	items := make([]poolResponse, 0, len(statuses))
+	for _, s := range statuses {
+		items = append(items, toPool(s))
+	}
+	writeAdminJSON(w, http.StatusOK, map[string]any{"items": items})
+}
+
+// PausePool handles POST /admin/workers/{name}/pause.
+func (h *AdminHandler) PausePool(w http.ResponseWriter, r *http.Request) {
+	h.controlPool(w, r, h.admin.PausePool)
+}
+
+// ResumePool handles POST /admin/workers/{name}/resume.
+func (h *AdminHandler) ResumePool(w http.ResponseWriter, r *http.Request) {
+	h.controlPool(w, r, h.admin.ResumePool)
+}
+
+func (h *AdminHandler) controlPool(w http.ResponseWriter, r *http.Request, control func(context.Context, string) (core.PoolStatus, error)) {
+	status, err := control(r.Context(), r.PathValue("name"))
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	writeAdminJSON(w, http.StatusOK, toPool(status))
+}
+
+// DrainPool handles POST /admin/workers/{name}/drain. It answers 200 once
+// the pool is empty, or 202 if it is still draining after DrainWait; the
+// pool keeps draining until resumed either way.
+func (h *AdminHandler) DrainPool(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), h.DrainWait)
+	defer cancel()
+	status, drained, err := h.admin.DrainPool(ctx, r.PathValue("name"))
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	code := http.StatusOK
+	if !drained {
+		code = http.StatusAccepted
+	}
+	writeAdminJSON(w, code, toPool(status))
+}
+
+// RetryDeadLetters handles POST /admin/workers/{name}/retry-dead-letters.
+func (h *AdminHandler) RetryDeadLetters(w http.ResponseWriter, r *http.Request) {
+	n, err := h.admin.RetryDeadLetters(r.Context(), r.PathValue("name"))
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	writeAdminJSON(w, http.StatusOK, map[string]int{"retried": n})
+}
+
+// FlushCache handles POST /admin/caches/{name}/flush.
+func (h *AdminHandler) FlushCache(w http.ResponseWriter, r *http.Request) {
+	n, err := h.admin.FlushCache(r.Context(), r.PathValue("name"))
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	writeAdminJSON(w, http.StatusOK, map[string]int{"flushed": n})
+}
+
+func writeAdminJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Cache-Control", "no-store")
+	WriteJSON(w, status, v)
+}
+
+func (h *AdminHandler) writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, core.ErrEmptyUserQuery):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, domain.ErrUserNotFound):
+		http.Error(w, domain.ErrUserNotFound.Error(), http.StatusNotFound)
+	case errors.Is(err, core.ErrUnknownPool), errors.Is(err, core.ErrUnknownCache):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, domain.ErrForbidden):
+		http.Error(w, domain.ErrForbidden.Error(), http.StatusForbidden)
+	case errors.Is(err, errors.ErrUnsupported):
+		http.Error(w, "not supported by this deployment", http.StatusNotImplemented)
+	case errors.Is(err, core.ErrQueueFull):
+		w.Header().Set("Retry-After", "1")
+		http.Error(w, "service busy, retry later", http.StatusServiceUnavailable)
+	default:
+		h.logger.Printf("http: %v", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,199 @@
+package httpadapter
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"clean_go_system/internal/core"
+	"clean_go_system/internal/domain"
+	"github.com/google/uuid"
+)
+
+// APIKeyHeader carries the secret of a service caller's API key.
+const APIKeyHeader = "X-API-Key"
+
+// APIKeyAuth authenticates service callers by API key and enforces each
+// key's scopes and rate limit.
+type APIKeyAuth struct {
+	// Now drives the rate limiter; it defaults to time.Now.
+	Now func() time.Time
+
+	keys   *core.APIKeyService
+	logger *log.Logger
+
+	mu      sync.Mutex
+	buckets map[uuid.UUID]*tokenBucket
+}
+
+func NewAPIKeyAuth(keys *core.APIKeyService, logger *log.Logger) *APIKeyAuth {
+	return &APIKeyAuth{
+		Now:     time.Now,
+		keys:    keys,
+		logger:  logger,
+		buckets: make(map[uuid.UUID]*tokenBucket),
+	}
+}
+
+type apiKeyContextKey struct{}
+
+// APIKeyFrom returns the key that authenticated the request, if any.
+func APIKeyFrom(ctx context.Context) (*domain.APIKey, bool) {
+	key, ok := ctx.Value(apiKeyContextKey{}).(*domain.APIKey)
+	return key, ok
+}
+
+// Require lets a request through to next only with a live key granted
+// scope: 401 without one, 403 for a key lacking the scope and 429 once the
+// key is over its rate limit.
+func (a *APIKeyAuth) Require(scope string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		secret := r.Header.Get(APIKeyHeader)
+		if secret == "" {
+			w.Header().Set("WWW-Authenticate", "APIKey")
+			http.Error(w, "missing api key", http.StatusUnauthorized)
+			return
+		}
+		key, err := a.keys.Authenticate(r.Context(), secret)
+		if errors.Is(err, domain.ErrInvalidAPIKey) {
+			w.Header().Set("WWW-Authenticate", "APIKey")
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		if err != nil {
+			a.logger.Printf("http: %v", err)
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+		if !key.Allows(scope) {
+			http.Error(w, "api key lacks scope "+scope, http.StatusForbidden)
+			return
+		}
+		if !a.allow(key) {
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
+			return
+		}
+		ctx := context.WithValue(r.Context(), apiKeyContextKey{}, key)
+		ctx = core.WithActor(ctx, domain.Actor{
+			Kind:  domain.ActorAPIKey,
+			ID:    key.ID.String(),
+			Attrs: map[string]string{"scopes": strings.Join(key.Scopes, ",")},
+		})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// allow takes a token from key's bucket. Buckets live in this process, so
+// with several replicas a key may make RateLimit requests per second on
+// each of them.
+func (a *APIKeyAuth) allow(key *domain.APIKey) bool {
+	if key.RateLimit <= 0 {
+		return true
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	b, ok := a.buckets[key.ID]
+	if !ok {
+		b = &tokenBucket{}
+		a.buckets[key.ID] = b
+	}
+	return b.take(key.RateLimit, a.Now())
+}
+
+// tokenBucket refills at rate tokens per second and holds up to one
+// second's worth (at least one token), so a key may burst that much.
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func (b *tokenBucket) take(rate float64, now time.Time) bool {
+	burst := max(rate, 1)
+	if b.last.IsZero() {
+		b.tokens = burst
+	} else {
+		b.tokens = min(burst, b.tokens+now.Sub(b.last).Seconds()*rate)
+	}
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// APIKeyHandler serves the admin endpoints that manage API keys.
+type APIKeyHandler struct {
+	keys   *core.APIKeyService
+	logger *log.Logger
+}
+
+func NewAPIKeyHandler(keys *core.APIKeyService, logger *log.Logger) *APIKeyHandler {
+	return &APIKeyHandler{keys: keys, logger: logger}
+}
+
+type createKeyRequest struct {
+	Name      string   `json:"name"`
+	Scopes    []string `json:"scopes"`
+	RateLimit float64  `json:"rate_limit"`
+}
+
+type createKeyResponse struct {
+	ID        string   `json:"id"`
+	Name      string   `json:"name"`
+	Key       string   `json:"key"`
+	Scopes    []string `json:"scopes"`
+	RateLimit float64  `json:"rate_limit"`
+}
+
+// Create handles POST /admin/keys. The response is the only time the
+// secret is shown.
+func (h *APIKeyHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var payload createKeyRequest
+	if !decodeJSON(w, r, &payload) {
+		return
+	}
+
+	secret, key, err := h.keys.Create(r.Context(), payload.Name, payload.Scopes, payload.RateLimit)
+	if writeInvalid(w, err, http.StatusBadRequest) {
+		return
+	}
+	if err != nil {
+		h.logger.Printf("http: %v", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	WriteJSON(w, http.StatusCreated, createKeyResponse{
+		ID:        key.ID.String(),
+		Name:      key.Name,
+		Key:       secret,
+		Scopes:    key.Scopes,
+		RateLimit: key.RateLimit,
+	})
+}
+
+// Revoke handles DELETE /admin/keys/{id}.
+func (h *APIKeyHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid key id", http.StatusBadRequest)
+		return
+	}
+	err = h.keys.Revoke(r.Context(), id)
+	switch {
+	case errors.Is(err, domain.ErrAPIKeyNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case err != nil:
+		h.logger.Printf("http: %v", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	default:
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,321 @@
+package httpadapter
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"clean-code-cookbook/go/pkg/jwt"
+	"clean_go_system/internal/core"
+	"clean_go_system/internal/domain"
+	"github.com/google/uuid"
+)
+
+// Principal is the user a bearer token speaks for.
+type Principal struct {
+	UserID uuid.UUID
+	// Issuer says who vouched for the user: this service or the IdP.
+	Issuer string
+	// SessionID is the server-side session behind the token, if any.
+	SessionID uuid.UUID
+}
+
+type principalContextKey struct{}
+
+// PrincipalFrom returns the authenticated user of the request, if any.
+func PrincipalFrom(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalContextKey{}).(Principal)
+	return p, ok
+}
+
+// SessionTokens issues and checks this service's own HS256 tokens.
+type SessionTokens struct {
+	Issuer string
+	Secret []byte
+	TTL    time.Duration
+	// Now stamps and checks expiry; it defaults to time.Now.
+	Now func() time.Time
+}
+
+func NewSessionTokens(issuer string, secret []byte, ttl time.Duration) *SessionTokens {
+	return &SessionTokens{Issuer: issuer, Secret: secret, TTL: ttl, Now: time.Now}
+}
+
+// Issue returns a token for user, valid only within the user's tenant,
+// which it names as the audience.
+func (s *SessionTokens) Issue(user *domain.User) (string, error) {
+	tenant := user.TenantID
+	if tenant == "" {
+		tenant = domain.DefaultTenant
+	}
+	now := s.Now()
+	return jwt.SignHS256(jwt.Claims{
+		Issuer:    s.Issuer,
+		Subject:   user.ID.String(),
+		Audience:  jwt.Audience{string(tenant)},
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(s.TTL).Unix(),
+	}, s.Secret)
+}
+
+func (s *SessionTokens) verify(ctx context.Context, token *jwt.Token) (Principal, error) {
+	if err := token.VerifyHS256(s.Secret); err != nil {
+		return Principal{}, err
+	}
+	expect := jwt.Expect{Issuer: s.Issuer, Audience: string(domain.TenantOf(ctx)), Now: s.Now()}
+	if len(token.Claims.Audience) == 0 && domain.TenantOf(ctx) == domain.DefaultTenant {
+		// Issued before tokens named their tenant.
+		expect.Audience = ""
+	}
+	if err := token.Claims.Validate(expect); err != nil {
+		return Principal{}, err
+	}
+	id, err := uuid.Parse(token.Claims.Subject)
+	if err != nil {
+		return Principal{}, jwt.ErrClaims
+	}
+	return Principal{UserID: id, Issuer: s.Issuer}, nil
+}
+
+// IdentityProvider is the OpenID Connect provider users log in with;
+// oidc.Provider implements it.
+type IdentityProvider interface {
+	Issuer() string
+	AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error)
+	Exchange(ctx context.Context, code, verifier string) (string, error)
+	// Verify validates an ID token and, for a non-empty nonce, that it
+	// belongs to the login attempt.
+	Verify(ctx context.Context, raw, nonce string) (*jwt.Claims, error)
+}
+
+// BearerAuth accepts either a session token or an ID token straight from
+// the identity provider, as long as that provider account is linked to a
+// user. The unverified "iss" claim only picks which check applies.
+// Opaque server-side session tokens are accepted too once Sessions is set.
+type BearerAuth struct {
+	// Sessions resolves "sess_" tokens; nil turns them away.
+	Sessions *core.SessionService
+
+	sessions *SessionTokens
+	idp      IdentityProvider // nil without OIDC
+	logins   *core.FederatedLogin
+	logger   *log.Logger
+}
+
+func NewBearerAuth(sessions *SessionTokens, idp IdentityProvider, logins *core.FederatedLogin, logger *log.Logger) *BearerAuth {
+	return &BearerAuth{sessions: sessions, idp: idp, logins: logins, logger: logger}
+}
+
+// Middleware answers 401 unless the request carries a valid bearer token,
+// and hands the Principal to next.
+func (a *BearerAuth) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || raw == "" {
+			unauthorized(w, "missing bearer token")
+			return
+		}
+		principal, err := a.authenticate(r.Context(), raw)
+		switch {
+		case errors.Is(err, jwt.ErrMalformed), errors.Is(err, jwt.ErrSignature), errors.Is(err, jwt.ErrExpired),
+			errors.Is(err, jwt.ErrClaims), errors.Is(err, domain.ErrIdentityUnknown), errors.Is(err, domain.ErrInvalidSession):
+			unauthorized(w, "invalid token")
+		case err != nil:
+			a.logger.Printf("http: %v", err)
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+		default:
+			ctx := context.WithValue(r.Context(), principalContextKey{}, principal)
+			ctx = core.WithActor(ctx, domain.Actor{Kind: domain.ActorUser, ID: principal.UserID.String()})
+			next.ServeHTTP(w, r.WithContext(ctx))
+		}
+	})
+}
+
+func (a *BearerAuth) authenticate(ctx context.Context, raw string) (Principal, error) {
+	if strings.HasPrefix(raw, core.SessionTokenPrefix) {
+		if a.Sessions == nil {
+			return Principal{}, domain.ErrInvalidSession
+		}
+		session, err := a.Sessions.Authenticate(ctx, raw)
+		if err != nil {
+			return Principal{}, err
+		}
+		return Principal{UserID: session.UserID, Issuer: a.sessions.Issuer, SessionID: session.ID}, nil
+	}
+	token, err := jwt.Parse(raw)
+	if err != nil {
+		return Principal{}, err
+	}
+	switch {
+	case token.Claims.Issuer == a.sessions.Issuer:
+		return a.sessions.verify(ctx, token)
+	case a.idp != nil && token.Claims.Issuer == a.idp.Issuer():
+		claims, err := a.idp.Verify(ctx, raw, "")
+		if err != nil {
+			return Principal{}, err
+		}
+		user, err := a.logins.Resolve(ctx, claims.Issuer, claims.Subject)
+		if err != nil {
+			return Principal{}, err
+		}
+		return Principal{UserID: user.ID, Issuer: claims.Issuer}, nil
+	default:
+		return Principal{}, jwt.ErrClaims
+	}
+}
+
+func unauthorized(w http.ResponseWriter, msg string) {
+	w.Header().Set("WWW-Authenticate", "Bearer")
+	http.Error(w, msg, http.StatusUnauthorized)
+}
+
+// loginCookie carries state, nonce and PKCE verifier from the login
+// redirect to the callback.
+const loginCookie = "oidc_login"
+
+// OIDCHandler runs the authorization code flow and answers the callback
+// with a session token.
+type OIDCHandler struct {
+	// Sessions, when set, makes the callback start a server-side session
+	// instead of signing a JWT.
+	Sessions *core.SessionService
+
+	idp      IdentityProvider
+	logins   *core.FederatedLogin
+	sessions *SessionTokens
+	logger   *log.Logger
+}
+
+func NewOIDCHandler(idp IdentityProvider, logins *core.FederatedLogin, sessions *SessionTokens, logger *log.Logger) *OIDCHandler {
+	return &OIDCHandler{idp: idp, logins: logins, sessions: sessions, logger: logger}
+}
+
+// Login handles GET /auth/oidc/login by redirecting to the provider.
+func (h *OIDCHandler) Login(w http.ResponseWriter, r *http.Request) {
+	state, nonce, verifier := randomToken(), randomToken(), randomToken()
+	target, err := h.idp.AuthCodeURL(r.Context(), state, nonce, verifier)
+	if err != nil {
+		h.logger.Printf("http: %v", err)
+		http.Error(w, "identity provider unavailable", http.StatusBadGateway)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     loginCookie,
+		Value:    state + "." + nonce + "." + verifier,
+		Path:     "/auth/oidc",
+		MaxAge:   int((10 * time.Minute).Seconds()),
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, target, http.StatusFound)
+}
+
+type loginResponse struct {
+	Token     string `json:"token"`
+	ExpiresIn int    `json:"expires_in"`
+	UserID    string `json:"user_id"`
+	Email     string `json:"email"`
+	Username  string `json:"username"`
+}
+
+// Callback handles GET /auth/oidc/callback: it checks state, trades the
+// code for an ID token, signs the user in and returns a session token.
+func (h *OIDCHandler) Callback(w http.ResponseWriter, r *http.Request) {
+	// 1. The callback must answer the login this browser started
+	cookie, err := r.Cookie(loginCookie)
+	if err != nil {
+		http.Error(w, "login expired, start again", http.StatusBadRequest)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{Name: loginCookie, Path: "/auth/oidc", MaxAge: -1})
+	parts := strings.Split(cookie.Value, ".")
+	state := r.URL.Query().Get("state")
+	if len(parts) != 3 || subtle.ConstantTimeCompare([]byte(parts[0]), []byte(state)) != 1 {
+		http.Error(w, "state mismatch", http.StatusBadRequest)
+		return
+	}
+	if e := r.URL.Query().Get("error"); e != "" {
+		http.Error(w, "login failed: "+e, http.StatusUnauthorized)
+		return
+	}
+	nonce, verifier := parts[1], parts[2]
+
+	// 2. Code for ID token
+	raw, err := h.idp.Exchange(r.Context(), r.URL.Query().Get("code"), verifier)
+	if err != nil {
+		h.logger.Printf("http: %v", err)
+		http.Error(w, "login failed", http.StatusBadGateway)
+		return
+	}
+	claims, err := h.idp.Verify(r.Context(), raw, nonce)
+	if err != nil {
+		h.logger.Printf("http: id token: %v", err)
+		http.Error(w, "login failed", http.StatusUnauthorized)
+		return
+	}
+
+	// 3. Link or create the local user
+	user, err := h.logins.SignIn(r.Context(), core.ExternalProfile{
+		Issuer:        claims.Issuer,
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+		Username:      claims.PreferredUsername,
+	})
+	switch {
+	case writeInvalid(w, err, http.StatusUnprocessableEntity):
+		return
+	case errors.Is(err, domain.ErrInvalidEmail), errors.Is(err, domain.ErrInvalidUsername):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	case err != nil:
+		h.logger.Printf("http: %v", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	// 4. Our own session token
+	token, ttl, err := h.issue(r, user)
+	if err != nil {
+		h.logger.Printf("http: %v", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	WriteJSON(w, http.StatusOK, loginResponse{
+		Token:     token,
+		ExpiresIn: int(ttl.Seconds()),
+		UserID:    user.ID.String(),
+		Email:     user.Email,
+		Username:  user.Username,
+	})
+}
+
+// issue returns a token for user and how long it is good for: a JWT, or
+// with Sessions a server-side session recording the caller's device.
+func (h *OIDCHandler) issue(r *http.Request, user *domain.User) (string, time.Duration, error) {
+	if h.Sessions == nil {
+		token, err := h.sessions.Issue(user)
+		return token, h.sessions.TTL, err
+	}
+	token, session, err := h.Sessions.Create(r.Context(), user.ID, core.Device{UserAgent: r.UserAgent(), IP: clientIP(r)})
+	if err != nil {
+		return "", 0, err
+	}
+	return token, session.ExpiresAt.Sub(session.LastSeenAt), nil
+}
+
+func randomToken() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err) // crypto/rand does not fail on supported platforms
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,127 @@
+package httpadapter
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+
+	"clean_go_system/internal/domain"
+)
+
+// MaxJSONDepth bounds how deeply objects and arrays may nest in a request.
+// Our payloads are flat; deep nesting only serves to burn CPU and stack in
+// the decoder.
+const MaxJSONDepth = 32
+
+// LimitBody caps every request body at maxBytes: a declared Content-Length
+// above it is refused with 413 before anything is read, and reads past it
+// fail, so a handler never buffers more than that.
+func LimitBody(maxBytes int64, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength > maxBytes {
+			http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+		next.ServeHTTP(w, r)
+	})
+}
+
+var (
+	errNotJSON      = errors.New("content type must be application/json")
+	errTooDeep      = fmt.Errorf("json nested deeper than %d levels", MaxJSONDepth)
+	errTrailingData = errors.New("unexpected data after json value")
+)
+
+// decodeJSON reads exactly one JSON value into dst, refusing other content
+// types, unknown fields and excessive nesting. On failure it has already
+// answered with 400, 413 or 415 and returns false.
+func decodeJSON(w http.ResponseWriter, r *http.Request, dst any) bool {
+	err := strictDecode(r, dst)
+	var tooLarge *http.MaxBytesError
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, errNotJSON):
+		http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+	case errors.As(err, &tooLarge):
+		http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+	default:
+		http.Error(w, "invalid payload", http.StatusBadRequest)
+	}
+	return false
+}
+
+// validationResponse lists every field a request got wrong, so a client
+// can point at each one instead of parsing a sentence.
+type validationResponse struct {
+	Error  string              `json:"error"`
+	Fields []domain.FieldError `json:"fields"`
+}
+
+// writeInvalid answers a domain.ValidationError with its fields as JSON
+// and reports whether err was one.
+func writeInvalid(w http.ResponseWriter, err error, status int) bool {
+	var invalid *domain.ValidationError
+	if !errors.As(err, &invalid) {
+		return false
+	}
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	WriteJSON(w, status, validationResponse{Error: "validation failed", Fields: invalid.Fields})
+	return true
+}
+
+func strictDecode(r *http.Request, dst any) error {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "application/json" {
+		return errNotJSON
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	if jsonDepth(body) > MaxJSONDepth {
+		return errTooDeep
+	}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(dst); err != nil {
+		return err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return errTrailingData
+	}
+	return nil
+}
+
+// jsonDepth returns the deepest nesting of objects and arrays in data,
+// skipping brackets inside strings. Malformed input is left to the decoder.
+func jsonDepth(data []byte) int {
+	var depth, deepest int
+	inString, escaped := false, false
+	for _, c := range data {
+		switch {
+		case escaped:
+			escaped = false
+		case inString:
+			switch c {
+			case '\\':
+				escaped = true
+			case '"':
+				inString = false
+			}
+		case c == '"':
+			inString = true
+		case c == '{' || c == '[':
+			depth++
+			deepest = max(deepest, depth)
+		case c == '}' || c == ']':
+			depth--
+		}
+	}
+	return deepest
+}
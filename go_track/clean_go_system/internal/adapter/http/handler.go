@@ -1,22 +1,27 @@
 package httpadapter
 
 import (
-	"encoding/json"
+	"errors"
+	"log"
 	"net/http"
+	"strconv"
 
+	"clean-code-cookbook/go/pkg/bulkhead"
+	"clean-code-cookbook/go/pkg/page"
 	"clean_go_system/internal/core"
 	"clean_go_system/internal/domain"
+	"github.com/google/uuid"
 )
 
 type Handler struct {
 	userService *core.UserService
-	emailPool   *core.WorkerPool
+	logger      *log.Logger
 }
 
-func NewHandler(userService *core.UserService, emailPool *core.WorkerPool) *Handler {
+func NewHandler(userService *core.UserService, logger *log.Logger) *Handler {
 	return &Handler{
 		userService: userService,
-		emailPool:   emailPool,
+		logger:      logger,
 	}
 }
 
@@ -29,44 +34,172 @@ type registerResponse struct {
 	ID       string `json:"id"`
 	Email    string `json:"email"`
 	Username string `json:"username"`
+	Locale   string `json:"locale,omitempty"`
+}
+
+func userResponse(u *domain.User) registerResponse {
+	return registerResponse{ID: u.ID.String(), Email: u.Email, Username: u.Username, Locale: u.Locale}
 }
 
 func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
 	var payload registerRequest
-	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		http.Error(w, "invalid payload", http.StatusBadRequest)
+	if !decodeJSON(w, r, &payload) {
 		return
 	}
 
-	user, err := h.userService.Register(r.Context(), payload.Email, payload.Username)
+	// The welcome message is written in the language the client asked for
+	ctx := domain.WithLocale(r.Context(), PreferredLocale(r.Header.Get("Accept-Language")))
+	user, err := h.userService.Register(ctx, payload.Email, payload.Username)
 	if err != nil {
-		status := http.StatusInternalServerError
-		if err == domain.ErrInvalidEmail {
-			status = http.StatusBadRequest
-		} else if err == domain.ErrUserExists {
-			status = http.StatusConflict
-		}
-		http.Error(w, err.Error(), status)
+		h.writeError(w, err)
 		return
 	}
 
-	// Kick off a background email send.
-	select {
-	case h.emailPool.JobQueue <- core.EmailJob{Email: user.Email, Body: "welcome aboard"}:
-	default:
-		// If the queue is full, we drop the job to protect latency.
+	WriteJSON(w, http.StatusCreated, userResponse(user))
+}
+
+// Me handles GET /me behind BearerAuth and returns the caller's profile.
+func (h *Handler) Me(w http.ResponseWriter, r *http.Request) {
+	principal, ok := PrincipalFrom(r.Context())
+	if !ok {
+		unauthorized(w, "missing bearer token")
+		return
+	}
+	user, err := h.userService.Get(r.Context(), principal.UserID)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, userResponse(user))
+}
+
+type localeRequest struct {
+	Locale string `json:"locale"`
+}
+
+// SetLocale handles PUT /me/locale behind BearerAuth with {"locale":
+// "pt-BR"}, the language the caller's emails are written in; "" goes
+// back to the default. It answers with the profile.
+func (h *Handler) SetLocale(w http.ResponseWriter, r *http.Request) {
+	principal, ok := PrincipalFrom(r.Context())
+	if !ok {
+		unauthorized(w, "missing bearer token")
+		return
+	}
+	var payload localeRequest
+	if !decodeJSON(w, r, &payload) {
+		return
+	}
+	user, err := h.userService.ChangeLocale(r.Context(), principal.UserID, payload.Locale)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, userResponse(user))
+}
+
+// Get handles GET /users/{id}. Who may read whom is up to the user
+// service's authorizer.
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, domain.ErrUserNotFound.Error(), http.StatusNotFound)
+		return
+	}
+	user, err := h.userService.Get(r.Context(), id)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, userResponse(user))
+}
+
+type listResponse struct {
+	Items []registerResponse `json:"items"`
+	Next  page.Cursor        `json:"next,omitempty"`
+}
+
+// List handles GET /users?size=&sort=&after=, one page of the tenant's
+// users. sort is created_at or updated_at, "-" first for descending;
+// after is the next cursor of the previous page.
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	req := page.Request{After: page.Cursor(q.Get("after"))}
+	if size := q.Get("size"); size != "" {
+		n, err := strconv.Atoi(size)
+		if err != nil || n < 1 {
+			http.Error(w, "size must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		req.Size = n
+	}
+	var err error
+	if req.Sort, err = page.ParseSort(q.Get("sort"), domain.UserSortFields...); err != nil {
+		h.writeError(w, err)
+		return
+	}
+	users, err := h.userService.List(r.Context(), req)
+	if err != nil {
+		h.writeError(w, err)
+		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	_ = json.NewEncoder(w).Encode(registerResponse{
-		ID:       user.ID.String(),
-		Email:    user.Email,
-		Username: user.Username,
+	resp := page.Map(users, func(u domain.User) registerResponse {
+		return userResponse(&u)
 	})
+	WriteJSON(w, http.StatusOK, listResponse{Items: resp.Items, Next: resp.Next})
+}
+
+// Delete handles DELETE /users/{id}. The user is soft-deleted: gone from
+// every lookup at once, purged later.
+func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, domain.ErrUserNotFound.Error(), http.StatusNotFound)
+		return
+	}
+	if err := h.userService.Delete(r.Context(), id); err != nil {
+		h.writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeError maps domain errors to statuses. Anything unexpected is logged
+// and answered with a bare 500, so internals don't leak to clients.
+func (h *Handler) writeError(w http.ResponseWriter, err error) {
+	if writeInvalid(w, err, http.StatusBadRequest) {
+		return
+	}
+	switch {
+	case errors.Is(err, domain.ErrInvalidEmail), errors.Is(err, domain.ErrInvalidUsername), errors.Is(err, domain.ErrInvalidLocale):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, domain.ErrUserExists):
+		http.Error(w, domain.ErrUserExists.Error(), http.StatusConflict)
+	case errors.Is(err, domain.ErrUserNotFound):
+		http.Error(w, domain.ErrUserNotFound.Error(), http.StatusNotFound)
+	case errors.Is(err, page.ErrInvalidCursor), errors.Is(err, page.ErrInvalidSort):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, domain.ErrForbidden):
+		http.Error(w, domain.ErrForbidden.Error(), http.StatusForbidden)
+	case errors.Is(err, errors.ErrUnsupported):
+		http.Error(w, "not supported by this deployment", http.StatusNotImplemented)
+	case errors.Is(err, core.ErrQueueFull), errors.Is(err, bulkhead.ErrFull):
+		// Back-pressure from the email queue or a saturated dependency:
+		// ask the client to come back.
+		w.Header().Set("Retry-After", "1")
+		http.Error(w, "service busy, retry later", http.StatusServiceUnavailable)
+	default:
+		h.logger.Printf("http: %v", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
 }
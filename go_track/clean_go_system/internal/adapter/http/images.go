@@ -0,0 +1,144 @@
+package httpadapter
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"time"
+
+	"clean_go_system/internal/core"
+	"clean_go_system/internal/domain"
+)
+
+// ImageField is the multipart form field uploads are read from.
+const ImageField = "image"
+
+var (
+	errNotMultipart = errors.New("content type must be multipart/form-data")
+	errBadMultipart = errors.New(`request must be a multipart form with an "image" file field`)
+)
+
+// ImageHandler serves avatar and product image uploads and the signed
+// links they are fetched through.
+type ImageHandler struct {
+	images *core.ImageService
+	logger *log.Logger
+}
+
+func NewImageHandler(images *core.ImageService, logger *log.Logger) *ImageHandler {
+	return &ImageHandler{images: images, logger: logger}
+}
+
+type imageResponse struct {
+	ID    string `json:"id"`
+	Kind  string `json:"kind"`
+	Owner string `json:"owner"`
+}
+
+type imageURLResponse struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// UploadAvatar handles POST /me/avatar behind BearerAuth: a multipart form
+// whose "image" field becomes the caller's new avatar.
+func (h *ImageHandler) UploadAvatar(w http.ResponseWriter, r *http.Request) {
+	principal, ok := PrincipalFrom(r.Context())
+	if !ok {
+		unauthorized(w, "missing bearer token")
+		return
+	}
+	h.upload(w, r, domain.ImageAvatar, principal.UserID.String())
+}
+
+// UploadProductImage handles POST /products/{sku}/images behind
+// BearerAuth. Who may upload for which product is up to the authorizer.
+func (h *ImageHandler) UploadProductImage(w http.ResponseWriter, r *http.Request) {
+	h.upload(w, r, domain.ImageProduct, r.PathValue("sku"))
+}
+
+// upload streams the image field straight from the request into the image
+// service; the body is never buffered whole. It answers 202, as the
+// resized variants appear once their job has run.
+func (h *ImageHandler) upload(w http.ResponseWriter, r *http.Request, kind domain.ImageKind, owner string) {
+	part, err := imagePart(r)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	defer part.Close()
+
+	img, err := h.images.Upload(r.Context(), kind, owner, part)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Location", "/images/"+string(img.Kind)+"/"+img.Owner+"/"+img.ID)
+	WriteJSON(w, http.StatusAccepted, imageResponse{ID: img.ID, Kind: string(img.Kind), Owner: img.Owner})
+}
+
+// URL handles GET /images/{kind}/{owner}/{id}?variant=, a signed link to
+// one size of an image; without variant, to the original.
+func (h *ImageHandler) URL(w http.ResponseWriter, r *http.Request) {
+	img := domain.Image{Kind: domain.ImageKind(r.PathValue("kind")), Owner: r.PathValue("owner"), ID: r.PathValue("id")}
+	variant := r.URL.Query().Get("variant")
+	if variant == "" {
+		variant = domain.OriginalVariant
+	}
+	url, expires, err := h.images.URL(r.Context(), img, variant)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	WriteJSON(w, http.StatusOK, imageURLResponse{URL: url, ExpiresAt: expires})
+}
+
+// imagePart returns the image field of a multipart request, skipping any
+// fields before it.
+func imagePart(r *http.Request) (*multipart.Part, error) {
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return nil, errNotMultipart
+	}
+	for {
+		part, err := mr.NextPart()
+		if errors.Is(err, io.EOF) {
+			return nil, errBadMultipart
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", errBadMultipart, err)
+		}
+		if part.FormName() == ImageField && part.FileName() != "" {
+			return part, nil
+		}
+		part.Close()
+	}
+}
+
+func (h *ImageHandler) writeError(w http.ResponseWriter, err error) {
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.Is(err, errNotMultipart), errors.Is(err, domain.ErrUnsupportedImage):
+		http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+	case errors.Is(err, domain.ErrImageTooLarge), errors.As(err, &tooLarge):
+		http.Error(w, domain.ErrImageTooLarge.Error(), http.StatusRequestEntityTooLarge)
+	case errors.Is(err, errBadMultipart):
+		http.Error(w, errBadMultipart.Error(), http.StatusBadRequest)
+	case errors.Is(err, domain.ErrImageNotFound):
+		http.Error(w, domain.ErrImageNotFound.Error(), http.StatusNotFound)
+	case errors.Is(err, domain.ErrForbidden):
+		http.Error(w, domain.ErrForbidden.Error(), http.StatusForbidden)
+	case errors.Is(err, core.ErrQueueFull):
+		w.Header().Set("Retry-After", "1")
+		http.Error(w, "service busy, retry later", http.StatusServiceUnavailable)
+	default:
+		h.logger.Printf("http: %v", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,52 @@
+package httpadapter
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+// maxPooledJSON is the largest buffer put back in jsonBuffers; a rare huge
+// response would otherwise pin its memory for the life of the pool.
+const maxPooledJSON = 64 << 10
+
+// jsonBuffer is a buffer with an encoder bound to it, pooled together so a
+// response allocates neither.
+type jsonBuffer struct {
+	buf bytes.Buffer
+	enc *json.Encoder
+}
+
+var jsonBuffers = sync.Pool{New: func() any {
+	b := &jsonBuffer{}
+	b.buf.Grow(1 << 10)
+	b.enc = json.NewEncoder(&b.buf)
+	return b
+}}
+
+// WriteJSON answers with v encoded as JSON and status, and headers the
+// caller set before. The body is encoded into a pooled buffer first, so it
+// goes out in one write with its Content-Length, and a value that fails to
+// encode answers 500 instead of half a body.
+func WriteJSON(w http.ResponseWriter, status int, v any) {
+	b := jsonBuffers.Get().(*jsonBuffer)
+	defer func() {
+		if b.buf.Cap() <= maxPooledJSON {
+			b.buf.Reset()
+			jsonBuffers.Put(b)
+		}
+	}()
+	if err := b.enc.Encode(v); err != nil {
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	h := w.Header()
+	if h.Get("Content-Type") == "" {
+		h.Set("Content-Type", "application/json")
+	}
+	h.Set("Content-Length", strconv.Itoa(b.buf.Len()))
+	w.WriteHeader(status)
+	_, _ = w.Write(b.buf.Bytes())
+}
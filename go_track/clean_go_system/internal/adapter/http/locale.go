@@ -0,0 +1,30 @@
+package httpadapter
+
+import (
+	"strconv"
+	"strings"
+
+	"clean_go_system/internal/domain"
+)
+
+// PreferredLocale picks the tag with the highest weight from an
+// Accept-Language header, such as "pt-BR" from "pt-BR, en;q=0.8". It
+// returns "" for an empty header, "*", or no valid tag.
+func PreferredLocale(header string) string {
+	best, bestQ := "", 0.0
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if locale := domain.NormalizeLocale(tag); locale != "" && q > bestQ {
+			best, bestQ = locale, q
+		}
+	}
+	return best
+}
@@ -0,0 +1,76 @@
+package httpadapter
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"clean_go_system/internal/core"
+	"clean_go_system/internal/domain"
+)
+
+// NotificationPreferencesHandler lets signed-in users choose how their
+// notifications reach them. It runs behind BearerAuth.
+type NotificationPreferencesHandler struct {
+	prefs  *core.NotificationPreferences
+	logger *log.Logger
+}
+
+func NewNotificationPreferencesHandler(prefs *core.NotificationPreferences, logger *log.Logger) *NotificationPreferencesHandler {
+	return &NotificationPreferencesHandler{prefs: prefs, logger: logger}
+}
+
+type notificationPreferences struct {
+	// Digest is "immediate", "daily" or "weekly".
+	Digest    domain.DigestFrequency `json:"digest"`
+	UpdatedAt *time.Time             `json:"updated_at,omitempty"`
+}
+
+// Get handles GET /me/notifications.
+func (h *NotificationPreferencesHandler) Get(w http.ResponseWriter, r *http.Request) {
+	principal, ok := PrincipalFrom(r.Context())
+	if !ok {
+		unauthorized(w, "missing bearer token")
+		return
+	}
+	prefs, err := h.prefs.Get(r.Context(), principal.UserID)
+	if err != nil {
+		h.logger.Printf("http: %v", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	h.write(w, prefs)
+}
+
+// Put handles PUT /me/notifications with {"digest": "daily"}.
+func (h *NotificationPreferencesHandler) Put(w http.ResponseWriter, r *http.Request) {
+	principal, ok := PrincipalFrom(r.Context())
+	if !ok {
+		unauthorized(w, "missing bearer token")
+		return
+	}
+	var payload notificationPreferences
+	if !decodeJSON(w, r, &payload) {
+		return
+	}
+	prefs, err := h.prefs.SetDigest(r.Context(), principal.UserID, payload.Digest)
+	switch {
+	case errors.Is(err, domain.ErrInvalidDigestFrequency):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case err != nil:
+		h.logger.Printf("http: %v", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	default:
+		h.write(w, prefs)
+	}
+}
+
+func (h *NotificationPreferencesHandler) write(w http.ResponseWriter, prefs domain.NotificationPreferences) {
+	out := notificationPreferences{Digest: prefs.Digest}
+	if !prefs.UpdatedAt.IsZero() {
+		out.UpdatedAt = &prefs.UpdatedAt
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	WriteJSON(w, http.StatusOK, out)
+}
@@ -0,0 +1,148 @@
+package httpadapter
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"clean_go_system/internal/domain"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+)
+
+// openAPISpec documents every route serve mounts but the dev tools and
+// provider callbacks. A route added there belongs here too.
+//
+//go:embed openapi.yaml
+var openAPISpec []byte
+
+// OpenAPI serves the API's OpenAPI 3 document and checks requests
+// against it.
+type OpenAPI struct {
+	router routers.Router
+	json   []byte
+}
+
+// NewOpenAPI loads the embedded document, failing if it is not valid
+// OpenAPI.
+func NewOpenAPI() (*OpenAPI, error) {
+	doc, err := openapi3.NewLoader().LoadFromData(openAPISpec)
+	if err != nil {
+		return nil, fmt.Errorf("openapi: %w", err)
+	}
+	if err := doc.Validate(context.Background()); err != nil {
+		return nil, fmt.Errorf("openapi: %w", err)
+	}
+	router, err := gorillamux.NewRouter(doc)
+	if err != nil {
+		return nil, fmt.Errorf("openapi: %w", err)
+	}
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("openapi: %w", err)
+	}
+	return &OpenAPI{router: router, json: raw}, nil
+}
+
+// ServeSpec handles GET /openapi.json.
+func (o *OpenAPI) ServeSpec(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	_, _ = w.Write(o.json)
+}
+
+// swaggerUI loads Swagger UI from a CDN rather than this binary, pointed
+// at /openapi.json.
+const swaggerUI = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>clean_go_system API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js" crossorigin></script>
+  <script>
+    window.onload = () => { window.ui = SwaggerUIBundle({ url: "/openapi.json", dom_id: "#swagger-ui" }); };
+  </script>
+</body>
+</html>
+`
+
+// ServeDocs handles GET /docs, the document browsable in Swagger UI.
+func (o *OpenAPI) ServeDocs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(swaggerUI))
+}
+
+// Validate answers 400 with the broken fields, like the handlers' own
+// validation, to a request whose parameters or JSON body break the
+// document, and passes the rest to next. Routes and methods the document
+// does not know go through unchecked, as do multipart bodies, which the
+// upload handlers check as they stream them. Authentication is left to
+// the routes' middleware.
+func (o *OpenAPI) Validate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route, params, err := o.router.FindRoute(r)
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		input := &openapi3filter.RequestValidationInput{
+			Request:    r,
+			PathParams: params,
+			Route:      route,
+			Options: &openapi3filter.Options{
+				MultiError:         true,
+				AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+				ExcludeRequestBody: strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/"),
+			},
+		}
+		err = openapi3filter.ValidateRequest(r.Context(), input)
+		var tooLarge *http.MaxBytesError
+		switch {
+		case err == nil:
+			next.ServeHTTP(w, r)
+		case errors.As(err, &tooLarge):
+			http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+		default:
+			w.Header().Set("X-Content-Type-Options", "nosniff")
+			WriteJSON(w, http.StatusBadRequest, validationResponse{Error: "validation failed", Fields: fieldErrors("body", err)})
+		}
+	})
+}
+
+// fieldErrors flattens what ValidateRequest returned into one FieldError
+// per broken rule, named by parameter or by path into the body.
+func fieldErrors(field string, err error) []domain.FieldError {
+	switch e := err.(type) {
+	case openapi3.MultiError:
+		var out []domain.FieldError
+		for _, err := range e {
+			out = append(out, fieldErrors(field, err)...)
+		}
+		return out
+	case *openapi3filter.RequestError:
+		if e.Parameter != nil {
+			field = e.Parameter.Name
+		}
+		if e.Err == nil {
+			return []domain.FieldError{{Field: field, Rule: "invalid", Message: e.Reason}}
+		}
+		return fieldErrors(field, e.Err)
+	case *openapi3.SchemaError:
+		if path := e.JSONPointer(); len(path) > 0 {
+			field = strings.Join(path, ".")
+		}
+		return []domain.FieldError{{Field: field, Rule: e.SchemaField, Message: e.Reason}}
+	default:
+		return []domain.FieldError{{Field: field, Rule: "invalid", Message: err.Error()}}
+	}
+}
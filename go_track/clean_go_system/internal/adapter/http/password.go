@@ -0,0 +1,72 @@
+package httpadapter
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"clean_go_system/internal/core"
+	"clean_go_system/internal/domain"
+)
+
+// PasswordHandler serves the password reset endpoints.
+type PasswordHandler struct {
+	passwords *core.PasswordService
+	logger    *log.Logger
+}
+
+func NewPasswordHandler(passwords *core.PasswordService, logger *log.Logger) *PasswordHandler {
+	return &PasswordHandler{passwords: passwords, logger: logger}
+}
+
+type resetRequest struct {
+	Email string `json:"email"`
+}
+
+// RequestReset handles POST /password/reset-request. It answers 202
+// whether or not the email belongs to an account.
+func (h *PasswordHandler) RequestReset(w http.ResponseWriter, r *http.Request) {
+	var payload resetRequest
+	if !decodeJSON(w, r, &payload) {
+		return
+	}
+	if err := h.passwords.RequestPasswordReset(r.Context(), payload.Email); err != nil {
+		h.writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+type resetPassword struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+// Reset handles POST /password/reset.
+func (h *PasswordHandler) Reset(w http.ResponseWriter, r *http.Request) {
+	var payload resetPassword
+	if !decodeJSON(w, r, &payload) {
+		return
+	}
+	if err := h.passwords.ResetPassword(r.Context(), payload.Token, payload.Password); err != nil {
+		h.writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *PasswordHandler) writeError(w http.ResponseWriter, err error) {
+	if writeInvalid(w, err, http.StatusBadRequest) {
+		return
+	}
+	switch {
+	case errors.Is(err, domain.ErrWeakPassword), errors.Is(err, domain.ErrInvalidResetToken):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, core.ErrQueueFull):
+		w.Header().Set("Retry-After", "1")
+		http.Error(w, "service busy, retry later", http.StatusServiceUnavailable)
+	default:
+		h.logger.Printf("http: %v", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
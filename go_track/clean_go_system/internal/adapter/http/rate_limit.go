@@ -0,0 +1,83 @@
+package httpadapter
+
+import (
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+
+	"clean_go_system/internal/domain"
+)
+
+// RateLimit caps one endpoint per caller. The caller is the authenticated
+// user, else the API key, else the client IP, within the request's
+// tenant; with a shared limiter (Redis) the cap holds across all
+// instances.
+type RateLimit struct {
+	// PerTenant overrides the limit for some tenants; a Count of 0 lifts
+	// it for that tenant.
+	PerTenant map[domain.TenantID]domain.RateLimit
+
+	limiter domain.RateLimiter
+	name    string
+	limit   domain.RateLimit
+	logger  *log.Logger
+}
+
+// NewRateLimit limits the endpoint called name (part of the key, so
+// endpoints have separate budgets).
+func NewRateLimit(limiter domain.RateLimiter, name string, limit domain.RateLimit, logger *log.Logger) *RateLimit {
+	return &RateLimit{limiter: limiter, name: name, limit: limit, logger: logger}
+}
+
+// Middleware answers 429 with Retry-After once the caller is over the
+// limit. If the limiter fails, the request goes through: an outage of the
+// limiter should not take the endpoint down with it.
+func (l *RateLimit) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenant := domain.TenantOf(r.Context())
+		limit, custom := l.PerTenant[tenant]
+		if !custom {
+			limit = l.limit
+		}
+		if limit.Count == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		key := l.name + ":" + string(tenant) + ":" + caller(r)
+		ok, retryAfter, err := l.limiter.Allow(r.Context(), key, limit)
+		if err != nil {
+			l.logger.Printf("http: %v", err)
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// caller identifies who is making the request for rate limiting. Run
+// behind the authentication middleware, so the principal is known.
+func caller(r *http.Request) string {
+	if p, ok := PrincipalFrom(r.Context()); ok {
+		return "user:" + p.UserID.String()
+	}
+	if key, ok := APIKeyFrom(r.Context()); ok {
+		return "key:" + key.ID.String()
+	}
+	return "ip:" + clientIP(r)
+}
+
+// clientIP is the address of the peer, without the port.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
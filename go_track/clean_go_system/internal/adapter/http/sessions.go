@@ -0,0 +1,89 @@
+package httpadapter
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"clean_go_system/internal/core"
+	"clean_go_system/internal/domain"
+	"github.com/google/uuid"
+)
+
+// SessionHandler lets signed-in users see and end their own server-side
+// sessions. It runs behind BearerAuth.
+type SessionHandler struct {
+	sessions *core.SessionService
+	logger   *log.Logger
+}
+
+func NewSessionHandler(sessions *core.SessionService, logger *log.Logger) *SessionHandler {
+	return &SessionHandler{sessions: sessions, logger: logger}
+}
+
+type sessionResponse struct {
+	ID         string    `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	// Current marks the session the request itself came in on.
+	Current bool `json:"current"`
+}
+
+// List handles GET /sessions.
+func (h *SessionHandler) List(w http.ResponseWriter, r *http.Request) {
+	principal, ok := PrincipalFrom(r.Context())
+	if !ok {
+		unauthorized(w, "missing bearer token")
+		return
+	}
+	sessions, err := h.sessions.List(r.Context(), principal.UserID)
+	if err != nil {
+		h.logger.Printf("http: %v", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	out := make([]sessionResponse, 0, len(sessions))
+	for _, s := range sessions {
+		out = append(out, sessionResponse{
+			ID:         s.ID.String(),
+			UserAgent:  s.UserAgent,
+			IP:         s.IP,
+			CreatedAt:  s.CreatedAt,
+			LastSeenAt: s.LastSeenAt,
+			ExpiresAt:  s.ExpiresAt,
+			Current:    s.ID == principal.SessionID,
+		})
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	WriteJSON(w, http.StatusOK, out)
+}
+
+// Revoke handles DELETE /sessions/{id}. Revoking the current session
+// signs the caller out.
+func (h *SessionHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	principal, ok := PrincipalFrom(r.Context())
+	if !ok {
+		unauthorized(w, "missing bearer token")
+		return
+	}
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, domain.ErrSessionNotFound.Error(), http.StatusNotFound)
+		return
+	}
+	err = h.sessions.Revoke(r.Context(), principal.UserID, id)
+	switch {
+	case errors.Is(err, domain.ErrSessionNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case err != nil:
+		h.logger.Printf("http: %v", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	default:
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
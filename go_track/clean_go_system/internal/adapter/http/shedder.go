@@ -0,0 +1,114 @@
+package httpadapter
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// LoadShedder rejects requests with 503 before they reach a handler once
+// MaxInFlight requests are being served. When the p99 latency of recent
+// requests exceeds MaxP99, the limit halves: a slow backend gets less
+// concurrent work until it recovers, instead of a growing queue.
+type LoadShedder struct {
+	MaxInFlight int
+	// MaxP99 enables the adaptive limit; zero disables it.
+	MaxP99 time.Duration
+	// RetryAfter is advertised to shed clients.
+	RetryAfter time.Duration
+
+	inFlight atomic.Int64
+	shed     atomic.Int64
+	p99      atomic.Int64 // nanoseconds, refreshed every p99Every samples
+
+	mu        sync.Mutex
+	latencies []time.Duration // ring of the last len(latencies) requests
+	next      int
+	samples   int
+}
+
+const (
+	latencyWindow = 512
+	p99Every      = 32
+)
+
+func NewLoadShedder(maxInFlight int, maxP99 time.Duration) *LoadShedder {
+	return &LoadShedder{
+		MaxInFlight: maxInFlight,
+		MaxP99:      maxP99,
+		RetryAfter:  time.Second,
+		latencies:   make([]time.Duration, 0, latencyWindow),
+	}
+}
+
+// ShedderStats is a snapshot for metrics.
+type ShedderStats struct {
+	InFlight    int64 `json:"in_flight"`
+	Limit       int   `json:"limit"`
+	P99MS       int64 `json:"p99_ms"`
+	Shed        int64 `json:"shed_total"`
+	MaxInFlight int   `json:"max_in_flight"`
+	MaxP99MS    int64 `json:"max_p99_ms"`
+}
+
+func (s *LoadShedder) Stats() ShedderStats {
+	return ShedderStats{
+		InFlight:    s.inFlight.Load(),
+		Limit:       s.limit(),
+		P99MS:       time.Duration(s.p99.Load()).Milliseconds(),
+		Shed:        s.shed.Load(),
+		MaxInFlight: s.MaxInFlight,
+		MaxP99MS:    s.MaxP99.Milliseconds(),
+	}
+}
+
+// limit is the in-flight cap right now.
+func (s *LoadShedder) limit() int {
+	if s.MaxP99 > 0 && time.Duration(s.p99.Load()) > s.MaxP99 {
+		return max(s.MaxInFlight/2, 1)
+	}
+	return s.MaxInFlight
+}
+
+// Middleware sheds load in front of next. A shedder without MaxInFlight
+// is a pass-through.
+func (s *LoadShedder) Middleware(next http.Handler) http.Handler {
+	if s == nil || s.MaxInFlight <= 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if n := s.inFlight.Add(1); n > int64(s.limit()) {
+			s.inFlight.Add(-1)
+			s.shed.Add(1)
+			w.Header().Set("Retry-After", strconv.Itoa(max(int(s.RetryAfter.Seconds()), 1)))
+			http.Error(w, "service overloaded, retry later", http.StatusServiceUnavailable)
+			return
+		}
+		defer s.inFlight.Add(-1)
+
+		start := time.Now()
+		next.ServeHTTP(w, r)
+		s.record(time.Since(start))
+	})
+}
+
+func (s *LoadShedder) record(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.latencies) < cap(s.latencies) {
+		s.latencies = append(s.latencies, d)
+	} else {
+		s.latencies[s.next] = d
+	}
+	s.next = (s.next + 1) % cap(s.latencies)
+
+	if s.samples++; s.samples%p99Every == 0 {
+		sorted := slices.Clone(s.latencies)
+		slices.Sort(sorted)
+		s.p99.Store(int64(sorted[len(sorted)*99/100]))
+	}
+}
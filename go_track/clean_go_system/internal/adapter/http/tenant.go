@@ -0,0 +1,69 @@
+package httpadapter
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"clean_go_system/internal/domain"
+)
+
+// TenantHeader names the tenant a request is for, ahead of the subdomain.
+const TenantHeader = "X-Tenant-ID"
+
+// TenantResolver scopes each request to one tenant, so the repositories
+// behind the handlers only see that tenant's data. The tenant comes from
+// Header, else from the subdomain of BaseDomain the request was sent to
+// (acme.example.com is tenant acme). Only known tenants are let through.
+type TenantResolver struct {
+	// Header defaults to TenantHeader.
+	Header string
+	// BaseDomain, when set, makes subdomains of it name tenants.
+	BaseDomain string
+
+	known map[domain.TenantID]bool
+}
+
+func NewTenantResolver(baseDomain string, tenants ...domain.TenantID) *TenantResolver {
+	known := make(map[domain.TenantID]bool, len(tenants))
+	for _, t := range tenants {
+		known[t] = true
+	}
+	return &TenantResolver{Header: TenantHeader, BaseDomain: baseDomain, known: known}
+}
+
+// Middleware answers 400 when no tenant can be told from the request and
+// 404 for a tenant that is not configured.
+func (t *TenantResolver) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenant, ok := t.Resolve(r)
+		if !ok {
+			http.Error(w, "tenant required", http.StatusBadRequest)
+			return
+		}
+		if !t.known[tenant] {
+			http.Error(w, domain.ErrUnknownTenant.Error(), http.StatusNotFound)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(domain.WithTenant(r.Context(), tenant)))
+	})
+}
+
+// Resolve reads the tenant off r without checking it is known.
+func (t *TenantResolver) Resolve(r *http.Request) (domain.TenantID, bool) {
+	if id := r.Header.Get(t.Header); id != "" {
+		return domain.TenantID(strings.ToLower(id)), true
+	}
+	if t.BaseDomain == "" {
+		return "", false
+	}
+	host, _, err := net.SplitHostPort(r.Host)
+	if err != nil {
+		host = r.Host
+	}
+	sub, ok := strings.CutSuffix(strings.ToLower(host), "."+strings.ToLower(t.BaseDomain))
+	if !ok || sub == "" || strings.Contains(sub, ".") {
+		return "", false
+	}
+	return domain.TenantID(sub), true
+}
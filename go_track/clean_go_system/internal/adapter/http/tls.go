@@ -0,0 +1,57 @@
+package httpadapter
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// NewTLSConfig returns modern server defaults: TLS 1.2+ with AEAD-only
+// cipher suites (TLS 1.3 suites are not configurable and always safe).
+func NewTLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion:       tls.VersionTLS12,
+		CurvePreferences: []tls.CurveID{tls.X25519, tls.CurveP256},
+		CipherSuites: []uint16{
+			tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256,
+		},
+	}
+}
+
+// NewAutocertTLSConfig obtains certificates from an ACME CA (Let's Encrypt)
+// for the given domains, caching them in cacheDir. The returned manager's
+// HTTPHandler must be served on :80 to answer http-01 challenges.
+func NewAutocertTLSConfig(domains []string, cacheDir string) (*tls.Config, *autocert.Manager) {
+	m := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(domains...),
+		Cache:      autocert.DirCache(cacheDir),
+	}
+	cfg := NewTLSConfig()
+	cfg.GetCertificate = m.GetCertificate
+	cfg.NextProtos = []string{"h2", "http/1.1", acme.ALPNProto}
+	return cfg, m
+}
+
+// RedirectToHTTPS permanently redirects plaintext requests to the same
+// host and path over HTTPS. httpsPort is omitted from the URL when it is 443.
+func RedirectToHTTPS(httpsPort string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(r.Host); err == nil {
+			host = h
+		}
+		if httpsPort != "" && httpsPort != "443" {
+			host = net.JoinHostPort(host, httpsPort)
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusMovedPermanently)
+	})
+}
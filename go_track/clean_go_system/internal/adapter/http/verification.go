@@ -0,0 +1,43 @@
+package httpadapter
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"clean_go_system/internal/core"
+	"clean_go_system/internal/domain"
+)
+
+// VerificationHandler serves the email verification endpoint.
+type VerificationHandler struct {
+	verification *core.EmailVerification
+	logger       *log.Logger
+}
+
+func NewVerificationHandler(verification *core.EmailVerification, logger *log.Logger) *VerificationHandler {
+	return &VerificationHandler{verification: verification, logger: logger}
+}
+
+type verifyEmail struct {
+	Token string `json:"token"`
+}
+
+// Verify handles POST /email/verify with the token mailed on
+// registration.
+func (h *VerificationHandler) Verify(w http.ResponseWriter, r *http.Request) {
+	var payload verifyEmail
+	if !decodeJSON(w, r, &payload) {
+		return
+	}
+	if _, err := h.verification.Confirm(r.Context(), payload.Token); err != nil {
+		if errors.Is(err, domain.ErrInvalidVerificationToken) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		h.logger.Printf("http: %v", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,105 @@
+// Package imaging implements domain.ImageResizer with the standard
+// library's JPEG and PNG codecs and a box filter.
+package imaging
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+
+	"clean_go_system/internal/domain"
+)
+
+// DefaultMaxPixels refuses images whose header promises more than 40
+// megapixels: a small file can declare a huge canvas, and decoding it
+// would allocate all of it.
+const DefaultMaxPixels = 40_000_000
+
+// Resizer scales by averaging every source pixel a target pixel covers,
+// which keeps downscaled photos free of aliasing.
+type Resizer struct {
+	// MaxPixels bounds the decoded size; it defaults to DefaultMaxPixels.
+	MaxPixels int
+	// Quality is the JPEG quality; it defaults to 85.
+	Quality int
+}
+
+func NewResizer() *Resizer {
+	return &Resizer{MaxPixels: DefaultMaxPixels, Quality: 85}
+}
+
+func (r *Resizer) Resize(ctx context.Context, src io.Reader, contentType string, maxSide int, dst io.Writer) error {
+	// 1. Check the declared size before decoding anything. Originals are
+	// capped at upload, so reading one whole is bounded.
+	data, err := io.ReadAll(src)
+	if err != nil {
+		return err
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("%w: %v", domain.ErrUnsupportedImage, err)
+	}
+	if cfg.Width*cfg.Height > r.MaxPixels {
+		return fmt.Errorf("%w: %dx%d pixels", domain.ErrImageTooLarge, cfg.Width, cfg.Height)
+	}
+
+	// 2. Decode and scale
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("%w: %v", domain.ErrUnsupportedImage, err)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	scaled := scale(img, maxSide)
+
+	// 3. Encode in the original format
+	switch contentType {
+	case "image/jpeg":
+		return jpeg.Encode(dst, scaled, &jpeg.Options{Quality: r.Quality})
+	case "image/png":
+		return png.Encode(dst, scaled)
+	default:
+		return fmt.Errorf("%w: cannot encode %s", domain.ErrUnsupportedImage, contentType)
+	}
+}
+
+// scale returns img shrunk so its longest side is maxSide, or img itself
+// when it is already small enough.
+func scale(img image.Image, maxSide int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= maxSide && h <= maxSide {
+		return img
+	}
+	tw, th := maxSide, h*maxSide/w
+	if h > w {
+		tw, th = w*maxSide/h, maxSide
+	}
+	tw, th = max(tw, 1), max(th, 1)
+
+	out := image.NewNRGBA(image.Rect(0, 0, tw, th))
+	for ty := 0; ty < th; ty++ {
+		y0, y1 := b.Min.Y+ty*h/th, b.Min.Y+(ty+1)*h/th
+		for tx := 0; tx < tw; tx++ {
+			x0, x1 := b.Min.X+tx*w/tw, b.Min.X+(tx+1)*w/tw
+			var rs, gs, bs, as, n uint64
+			for y := y0; y < max(y1, y0+1); y++ {
+				for x := x0; x < max(x1, x0+1); x++ {
+					c := color.NRGBA64Model.Convert(img.At(x, y)).(color.NRGBA64)
+					rs, gs, bs, as = rs+uint64(c.R), gs+uint64(c.G), bs+uint64(c.B), as+uint64(c.A)
+					n++
+				}
+			}
+			out.SetNRGBA(tx, ty, color.NRGBA{
+				R: uint8(rs / n >> 8), G: uint8(gs / n >> 8), B: uint8(bs / n >> 8), A: uint8(as / n >> 8),
+			})
+		}
+	}
+	return out
+}
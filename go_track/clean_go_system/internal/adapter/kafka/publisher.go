@@ -0,0 +1,85 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"clean_go_system/internal/adapter/eventcodec"
+	"clean_go_system/internal/domain"
+	"clean_go_system/pkg/tracing"
+	"github.com/segmentio/kafka-go"
+)
+
+// Publisher implements domain.EventPublisher on a Kafka topic. Messages are
+// keyed by aggregate (user) ID so one user's events land on one partition
+// and keep their order.
+type Publisher struct {
+	writer *kafka.Writer
+}
+
+func NewPublisher(brokers []string, topic string) *Publisher {
+	return &Publisher{writer: &kafka.Writer{
+		Addr:         kafka.TCP(brokers...),
+		Topic:        topic,
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireAll,
+		BatchTimeout: 10 * time.Millisecond,
+	}}
+}
+
+// Publish writes all events synchronously; an error means none or only some
+// were acknowledged, and the outbox relay will retry the batch.
+func (p *Publisher) Publish(ctx context.Context, events ...domain.DomainEvent) error {
+	msgs := make([]kafka.Message, 0, len(events))
+	for _, e := range events {
+		value, err := eventcodec.Encode(ctx, e)
+		if err != nil {
+			return err
+		}
+		msgs = append(msgs, message(ctx, e, value))
+	}
+	return p.write(ctx, msgs...)
+}
+
+// Forward writes e under the ID it already has, as the outbox relay asks,
+// so consumers see the same ID on every redelivery.
+func (p *Publisher) Forward(ctx context.Context, id string, e domain.DomainEvent) error {
+	value, err := eventcodec.EncodeWithID(ctx, e, id)
+	if err != nil {
+		return err
+	}
+	return p.write(ctx, message(ctx, e, value))
+}
+
+func (p *Publisher) write(ctx context.Context, msgs ...kafka.Message) error {
+	if err := p.writer.WriteMessages(ctx, msgs...); err != nil {
+		return fmt.Errorf("kafka write: %w", err)
+	}
+	return nil
+}
+
+func message(ctx context.Context, e domain.DomainEvent, value []byte) kafka.Message {
+	return kafka.Message{
+		Key:     []byte(e.AggregateID()),
+		Value:   value,
+		Time:    e.OccurredAt(),
+		Headers: headers(ctx, e),
+	}
+}
+
+// Close flushes pending writes and releases connections.
+func (p *Publisher) Close() error {
+	return p.writer.Close()
+}
+
+func headers(ctx context.Context, e domain.DomainEvent) []kafka.Header {
+	h := []kafka.Header{
+		{Key: "event_type", Value: []byte(e.EventName())},
+		{Key: "occurred_at", Value: []byte(e.OccurredAt().UTC().Format(time.RFC3339Nano))},
+	}
+	if tp := tracing.TraceParent(ctx); tp != "" {
+		h = append(h, kafka.Header{Key: tracing.Header, Value: []byte(tp)})
+	}
+	return h
+}
@@ -0,0 +1,27 @@
+package limited
+
+import (
+	"context"
+
+	"clean-code-cookbook/go/pkg/bulkhead"
+	"clean_go_system/internal/domain"
+)
+
+// EmailSender runs every send inside the email bulkhead. Retries around
+// it wait without holding a slot.
+type EmailSender struct {
+	sender domain.EmailSender
+	pool   *bulkhead.Bulkhead
+}
+
+func NewEmailSender(sender domain.EmailSender, pool *bulkhead.Bulkhead) *EmailSender {
+	return &EmailSender{sender: sender, pool: pool}
+}
+
+func (s *EmailSender) Send(ctx context.Context, msg domain.Email) (id string, err error) {
+	err = s.pool.Do(ctx, func(ctx context.Context) error {
+		id, err = s.sender.Send(ctx, msg)
+		return err
+	})
+	return id, err
+}
@@ -0,0 +1,61 @@
+// Package limited decorates ports with a pkg/bulkhead, so a slow dependency
+// holds at most its own pool's worth of requests, and with a pkg/budget
+// share of the request's deadline.
+package limited
+
+import (
+	"context"
+
+	"clean-code-cookbook/go/pkg/budget"
+	"clean-code-cookbook/go/pkg/bulkhead"
+	"clean_go_system/internal/domain"
+	"github.com/google/uuid"
+)
+
+// UserRepository runs every call of repo inside the database bulkhead.
+type UserRepository struct {
+	// Budget bounds each call, queueing included; the zero value leaves
+	// the caller's context alone.
+	Budget budget.Budget
+
+	repo domain.UserRepository
+	pool *bulkhead.Bulkhead
+}
+
+func NewUserRepository(repo domain.UserRepository, pool *bulkhead.Bulkhead) *UserRepository {
+	return &UserRepository{repo: repo, pool: pool}
+}
+
+func (r *UserRepository) Save(ctx context.Context, u domain.User) error {
+	ctx, cancel := r.Budget.Apply(ctx)
+	defer cancel()
+	return r.pool.Do(ctx, func(ctx context.Context) error { return r.repo.Save(ctx, u) })
+}
+
+func (r *UserRepository) Update(ctx context.Context, u domain.User) error {
+	ctx, cancel := r.Budget.Apply(ctx)
+	defer cancel()
+	return r.pool.Do(ctx, func(ctx context.Context) error { return r.repo.Update(ctx, u) })
+}
+
+func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
+	ctx, cancel := r.Budget.Apply(ctx)
+	defer cancel()
+	release, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return r.repo.GetByEmail(ctx, email)
+}
+
+func (r *UserRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	ctx, cancel := r.Budget.Apply(ctx)
+	defer cancel()
+	release, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return r.repo.GetByID(ctx, id)
+}
@@ -0,0 +1,70 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"clean_go_system/internal/domain"
+	"github.com/google/uuid"
+)
+
+// APIKeyRepository implements domain.APIKeyRepository on two maps.
+type APIKeyRepository struct {
+	mu     sync.RWMutex
+	byID   map[uuid.UUID]domain.APIKey
+	byHash map[string]uuid.UUID
+}
+
+func NewAPIKeyRepository() *APIKeyRepository {
+	return &APIKeyRepository{
+		byID:   make(map[uuid.UUID]domain.APIKey),
+		byHash: make(map[string]uuid.UUID),
+	}
+}
+
+func (r *APIKeyRepository) Save(ctx context.Context, k domain.APIKey) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	k.TenantID = domain.TenantOf(ctx)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.byID[k.ID] = k
+	r.byHash[string(k.Hash)] = k.ID
+	return nil
+}
+
+func (r *APIKeyRepository) GetByHash(ctx context.Context, hash []byte) (*domain.APIKey, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	id, ok := r.byHash[string(hash)]
+	if !ok {
+		return nil, domain.ErrAPIKeyNotFound
+	}
+	k := r.byID[id]
+	if k.TenantID != domain.TenantOf(ctx) {
+		return nil, domain.ErrAPIKeyNotFound
+	}
+	return &k, nil
+}
+
+func (r *APIKeyRepository) Revoke(ctx context.Context, id uuid.UUID, at time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	k, ok := r.byID[id]
+	if !ok || k.TenantID != domain.TenantOf(ctx) {
+		return domain.ErrAPIKeyNotFound
+	}
+	if k.RevokedAt == nil {
+		k.RevokedAt = &at
+		r.byID[id] = k
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package memory
+
+import (
+	"context"
+	"sync"
+)
+
+// DedupStore implements eventbus.DedupStore on a set of (consumer, id) pairs.
+type DedupStore struct {
+	mu      sync.Mutex
+	claimed map[[2]string]struct{}
+}
+
+func NewDedupStore() *DedupStore {
+	return &DedupStore{claimed: make(map[[2]string]struct{})}
+}
+
+func (s *DedupStore) Claim(ctx context.Context, consumer, id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := [2]string{consumer, id}
+	if _, ok := s.claimed[key]; ok {
+		return false, nil
+	}
+	s.claimed[key] = struct{}{}
+	return true, nil
+}
+
+func (s *DedupStore) Release(ctx context.Context, consumer, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.claimed, [2]string{consumer, id})
+	return nil
+}
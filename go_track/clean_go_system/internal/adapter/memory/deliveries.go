@@ -0,0 +1,73 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"clean_go_system/internal/domain"
+	"github.com/google/uuid"
+)
+
+// DeliveryRepository implements domain.DeliveryRepository on a map.
+type DeliveryRepository struct {
+	mu   sync.RWMutex
+	byID map[uuid.UUID]domain.Delivery
+}
+
+func NewDeliveryRepository() *DeliveryRepository {
+	return &DeliveryRepository{byID: make(map[uuid.UUID]domain.Delivery)}
+}
+
+func (r *DeliveryRepository) Record(ctx context.Context, d domain.Delivery) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if old, ok := r.byID[d.ID]; ok {
+		old.Status, old.ProviderID, old.Error, old.UpdatedAt = d.Status, d.ProviderID, d.Error, d.UpdatedAt
+		old.Attempts += d.Attempts
+		d = old
+	}
+	r.byID[d.ID] = d
+	return nil
+}
+
+func (r *DeliveryRepository) GetByProviderID(ctx context.Context, providerID string) (*domain.Delivery, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, d := range r.byID {
+		if d.ProviderID != "" && d.ProviderID == providerID {
+			return &d, nil
+		}
+	}
+	return nil, domain.ErrDeliveryNotFound
+}
+
+func (r *DeliveryRepository) ListByUser(ctx context.Context, userID uuid.UUID, limit int) ([]domain.Delivery, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var out []domain.Delivery
+	for _, d := range r.byID {
+		if d.UserID == userID {
+			out = append(out, d)
+		}
+	}
+	slices.SortFunc(out, func(a, b domain.Delivery) int {
+		if c := b.CreatedAt.Compare(a.CreatedAt); c != 0 {
+			return c
+		}
+		return slices.Compare(b.ID[:], a.ID[:])
+	})
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
@@ -0,0 +1,101 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"clean_go_system/internal/domain"
+	"github.com/google/uuid"
+)
+
+// NotificationPreferencesRepository implements
+// domain.NotificationPreferencesRepository on a map.
+type NotificationPreferencesRepository struct {
+	mu     sync.RWMutex
+	byUser map[uuid.UUID]domain.NotificationPreferences
+}
+
+func NewNotificationPreferencesRepository() *NotificationPreferencesRepository {
+	return &NotificationPreferencesRepository{byUser: make(map[uuid.UUID]domain.NotificationPreferences)}
+}
+
+func (r *NotificationPreferencesRepository) Save(ctx context.Context, p domain.NotificationPreferences) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.byUser[p.UserID] = p
+	return nil
+}
+
+func (r *NotificationPreferencesRepository) Get(ctx context.Context, userID uuid.UUID) (*domain.NotificationPreferences, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	p, ok := r.byUser[userID]
+	if !ok {
+		return nil, nil
+	}
+	return &p, nil
+}
+
+// DigestRepository implements domain.DigestRepository on a map.
+type DigestRepository struct {
+	mu   sync.RWMutex
+	byID map[uuid.UUID]domain.DigestItem
+}
+
+func NewDigestRepository() *DigestRepository {
+	return &DigestRepository{byID: make(map[uuid.UUID]domain.DigestItem)}
+}
+
+func (r *DigestRepository) Add(ctx context.Context, item domain.DigestItem) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.byID[item.ID] = item
+	return nil
+}
+
+func (r *DigestRepository) Pending(ctx context.Context, frequency domain.DigestFrequency, before time.Time, limit int) ([]domain.DigestItem, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var out []domain.DigestItem
+	for _, item := range r.byID {
+		if item.Frequency == frequency && item.CreatedAt.Before(before) {
+			out = append(out, item)
+		}
+	}
+	slices.SortFunc(out, func(a, b domain.DigestItem) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return slices.Compare(a.ID[:], b.ID[:])
+	})
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+func (r *DigestRepository) Delete(ctx context.Context, ids []uuid.UUID) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, id := range ids {
+		delete(r.byID, id)
+	}
+	return nil
+}
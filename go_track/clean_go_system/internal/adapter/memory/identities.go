@@ -0,0 +1,46 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"clean_go_system/internal/domain"
+)
+
+// IdentityRepository implements domain.IdentityRepository on a map keyed
+// by issuer and subject.
+type IdentityRepository struct {
+	mu    sync.RWMutex
+	links map[[2]string]domain.FederatedIdentity
+}
+
+func NewIdentityRepository() *IdentityRepository {
+	return &IdentityRepository{links: make(map[[2]string]domain.FederatedIdentity)}
+}
+
+func (r *IdentityRepository) Link(ctx context.Context, id domain.FederatedIdentity) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := [2]string{id.Issuer, id.Subject}
+	if _, ok := r.links[key]; ok {
+		return domain.ErrIdentityExists
+	}
+	r.links[key] = id
+	return nil
+}
+
+func (r *IdentityRepository) Find(ctx context.Context, issuer, subject string) (*domain.FederatedIdentity, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	id, ok := r.links[[2]string{issuer, subject}]
+	if !ok {
+		return nil, domain.ErrIdentityUnknown
+	}
+	return &id, nil
+}
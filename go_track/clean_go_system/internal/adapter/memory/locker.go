@@ -0,0 +1,51 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"clean_go_system/internal/domain"
+	"clean_go_system/pkg/clock"
+)
+
+// Locker implements domain.Locker within one process.
+type Locker struct {
+	// Clock decides when leases expire; it defaults to the wall clock.
+	Clock domain.Clock
+
+	mu     sync.Mutex
+	leases map[string]*lease
+}
+
+func NewLocker() *Locker {
+	return &Locker{Clock: clock.System, leases: make(map[string]*lease)}
+}
+
+type lease struct {
+	locker  *Locker
+	key     string
+	expires time.Time
+}
+
+func (l *Locker) Acquire(ctx context.Context, key string, ttl time.Duration) (domain.Lock, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.Clock.Now()
+	if held, ok := l.leases[key]; ok && now.Before(held.expires) {
+		return nil, domain.ErrLockHeld
+	}
+	le := &lease{locker: l, key: key, expires: now.Add(ttl)}
+	l.leases[key] = le
+	return le, nil
+}
+
+// Release is a no-op if the lease already expired and was taken over.
+func (le *lease) Release(ctx context.Context) error {
+	le.locker.mu.Lock()
+	defer le.locker.mu.Unlock()
+	if le.locker.leases[le.key] == le {
+		delete(le.locker.leases, le.key)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"clean_go_system/internal/domain"
+	"github.com/google/uuid"
+)
+
+// PasswordResetRepository implements domain.PasswordResetRepository on a
+// map.
+type PasswordResetRepository struct {
+	mu     sync.Mutex
+	resets map[uuid.UUID]domain.PasswordReset
+}
+
+func NewPasswordResetRepository() *PasswordResetRepository {
+	return &PasswordResetRepository{resets: make(map[uuid.UUID]domain.PasswordReset)}
+}
+
+func (r *PasswordResetRepository) Save(ctx context.Context, reset domain.PasswordReset) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.resets[reset.ID] = reset
+	return nil
+}
+
+func (r *PasswordResetRepository) Get(ctx context.Context, id uuid.UUID) (*domain.PasswordReset, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	reset, ok := r.resets[id]
+	if !ok {
+		return nil, domain.ErrInvalidResetToken
+	}
+	return &reset, nil
+}
+
+func (r *PasswordResetRepository) MarkUsed(ctx context.Context, id uuid.UUID, at time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	reset, ok := r.resets[id]
+	if !ok || reset.UsedAt != nil {
+		return domain.ErrInvalidResetToken
+	}
+	reset.UsedAt = &at
+	r.resets[id] = reset
+	return nil
+}
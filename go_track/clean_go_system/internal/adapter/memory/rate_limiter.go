@@ -0,0 +1,41 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"clean_go_system/internal/domain"
+	"clean_go_system/pkg/clock"
+)
+
+// RateLimiter implements domain.RateLimiter within one process, with the
+// same GCRA as the Redis limiter.
+type RateLimiter struct {
+	// Clock decides when budget frees up; it defaults to the wall clock.
+	Clock domain.Clock
+
+	mu sync.Mutex
+	// tats holds each key's theoretical arrival time: when its budget is
+	// back to full if nothing else happens.
+	tats map[string]time.Time
+}
+
+func NewRateLimiter() *RateLimiter {
+	return &RateLimiter{Clock: clock.System, tats: make(map[string]time.Time)}
+}
+
+func (l *RateLimiter) Allow(ctx context.Context, key string, limit domain.RateLimit) (bool, time.Duration, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.Clock.Now()
+	tat := l.tats[key]
+	if tat.Before(now) {
+		tat = now
+	}
+	if wait := tat.Sub(now) - limit.Tolerance(); wait > 0 {
+		return false, wait, nil
+	}
+	l.tats[key] = tat.Add(limit.Interval())
+	return true, 0, nil
+}
@@ -0,0 +1,95 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"clean_go_system/internal/domain"
+	"github.com/google/uuid"
+)
+
+// SessionRepository implements domain.SessionRepository on two maps.
+type SessionRepository struct {
+	mu     sync.RWMutex
+	byID   map[uuid.UUID]domain.Session
+	byHash map[string]uuid.UUID
+}
+
+func NewSessionRepository() *SessionRepository {
+	return &SessionRepository{
+		byID:   make(map[uuid.UUID]domain.Session),
+		byHash: make(map[string]uuid.UUID),
+	}
+}
+
+func (r *SessionRepository) Save(ctx context.Context, s domain.Session) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.byID[s.ID] = s
+	r.byHash[string(s.Hash)] = s.ID
+	return nil
+}
+
+func (r *SessionRepository) GetByHash(ctx context.Context, hash []byte) (*domain.Session, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	id, ok := r.byHash[string(hash)]
+	if !ok {
+		return nil, domain.ErrSessionNotFound
+	}
+	s := r.byID[id]
+	return &s, nil
+}
+
+func (r *SessionRepository) Touch(ctx context.Context, id uuid.UUID, lastSeen, expires time.Time) error {
+	return r.update(ctx, id, func(s *domain.Session) {
+		s.LastSeenAt, s.ExpiresAt = lastSeen, expires
+	})
+}
+
+func (r *SessionRepository) ListByUser(ctx context.Context, userID uuid.UUID, now time.Time) ([]domain.Session, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var out []domain.Session
+	for _, s := range r.byID {
+		if s.UserID == userID && s.Active(now) {
+			out = append(out, s)
+		}
+	}
+	slices.SortFunc(out, func(a, b domain.Session) int { return b.LastSeenAt.Compare(a.LastSeenAt) })
+	return out, nil
+}
+
+func (r *SessionRepository) Revoke(ctx context.Context, id uuid.UUID, at time.Time) error {
+	return r.update(ctx, id, func(s *domain.Session) {
+		if s.RevokedAt == nil {
+			s.RevokedAt = &at
+		}
+	})
+}
+
+func (r *SessionRepository) update(ctx context.Context, id uuid.UUID, change func(*domain.Session)) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s, ok := r.byID[id]
+	if !ok {
+		return domain.ErrSessionNotFound
+	}
+	change(&s)
+	r.byID[id] = s
+	return nil
+}
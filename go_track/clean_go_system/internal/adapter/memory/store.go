@@ -0,0 +1,74 @@
+package memory
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"clean-code-cookbook/go/pkg/cacheaside"
+	"clean_go_system/internal/domain"
+	"clean_go_system/pkg/clock"
+)
+
+type entry[V any] struct {
+	value   V
+	expires time.Time
+}
+
+// Store implements cacheaside.Store in process, with a TTL per entry.
+// Expired entries are dropped lazily when read.
+type Store[V any] struct {
+	// Clock decides when entries expire; it defaults to the wall clock.
+	Clock domain.Clock
+
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[string]entry[V]
+}
+
+func NewStore[V any](ttl time.Duration) *Store[V] {
+	return &Store[V]{Clock: clock.System, ttl: ttl, entries: make(map[string]entry[V])}
+}
+
+func (s *Store[V]) Get(ctx context.Context, key string) (V, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.entries[key]
+	if !ok || s.Clock.Now().After(e.expires) {
+		delete(s.entries, key)
+		var zero V
+		return zero, cacheaside.ErrMiss
+	}
+	return e.value, nil
+}
+
+func (s *Store[V]) Set(ctx context.Context, key string, value V) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[key] = entry[V]{value: value, expires: s.Clock.Now().Add(s.ttl)}
+	return nil
+}
+
+func (s *Store[V]) Delete(ctx context.Context, keys ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, k := range keys {
+		delete(s.entries, k)
+	}
+	return nil
+}
+
+func (s *Store[V]) DeletePrefix(ctx context.Context, prefix string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for k := range s.entries {
+		if strings.HasPrefix(k, prefix) {
+			delete(s.entries, k)
+			n++
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,52 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"clean_go_system/internal/domain"
+)
+
+// SuppressionList implements domain.SuppressionList on a map keyed by the
+// normalized address.
+type SuppressionList struct {
+	mu      sync.RWMutex
+	byEmail map[string]domain.Suppression
+}
+
+func NewSuppressionList() *SuppressionList {
+	return &SuppressionList{byEmail: make(map[string]domain.Suppression)}
+}
+
+func (l *SuppressionList) Suppress(ctx context.Context, s domain.Suppression) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.byEmail[domain.NormalizeEmail(s.Email)] = s
+	return nil
+}
+
+func (l *SuppressionList) Suppressed(ctx context.Context, email string) (*domain.Suppression, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	s, ok := l.byEmail[domain.NormalizeEmail(email)]
+	if !ok {
+		return nil, nil
+	}
+	return &s, nil
+}
+
+func (l *SuppressionList) Lift(ctx context.Context, email string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.byEmail, domain.NormalizeEmail(email))
+	return nil
+}
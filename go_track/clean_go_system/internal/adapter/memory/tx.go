@@ -0,0 +1,29 @@
+package memory
+
+import (
+	"context"
+	"sync"
+)
+
+type txKey struct{}
+
+// Transactor implements domain.Transactor by running units of work one at
+// a time. That isolates them from each other but is not atomic: writes made
+// before fn fails are not rolled back.
+type Transactor struct {
+	mu sync.Mutex
+}
+
+func NewTransactor() *Transactor {
+	return &Transactor{}
+}
+
+// WithinTransaction runs fn under the lock. Nested calls join the outer one.
+func (t *Transactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if ctx.Value(txKey{}) != nil {
+		return fn(ctx)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return fn(context.WithValue(ctx, txKey{}, true))
+}
@@ -0,0 +1,171 @@
+// Package memory provides thread-safe in-process adapters so the service
+// runs, and tests pass, without any infrastructure. Nothing survives a
+// restart.
+package memory
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"clean-code-cookbook/go/pkg/page"
+	"clean_go_system/internal/domain"
+	"github.com/google/uuid"
+)
+
+// UserRepository implements domain.UserRepository, domain.UserLister,
+// domain.UserBatchReader, domain.UserStreamer and domain.UserPurger on two maps. Emails are unique per tenant, mirroring the
+// users_tenant_email_key constraint in Postgres, and a cancelled context
+// fails the call as database/sql would.
+type UserRepository struct {
+	mu      sync.RWMutex
+	byID    map[uuid.UUID]domain.User
+	byEmail map[tenantEmail]uuid.UUID
+}
+
+type tenantEmail struct {
+	tenant domain.TenantID
+	email  string
+}
+
+func NewUserRepository() *UserRepository {
+	return &UserRepository{
+		byID:    make(map[uuid.UUID]domain.User),
+		byEmail: make(map[tenantEmail]uuid.UUID),
+	}
+}
+
+func (r *UserRepository) Save(ctx context.Context, u domain.User) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	u.TenantID = domain.TenantOf(ctx)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.byEmail[tenantEmail{u.TenantID, u.Email}]; ok {
+		return domain.ErrUserExists
+	}
+	if _, ok := r.byID[u.ID]; ok {
+		return domain.ErrUserExists
+	}
+	r.byID[u.ID] = u
+	r.byEmail[tenantEmail{u.TenantID, u.Email}] = u.ID
+	return nil
+}
+
+func (r *UserRepository) Update(ctx context.Context, u domain.User) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	u.TenantID = domain.TenantOf(ctx)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	old, ok := r.byID[u.ID]
+	if !ok || old.TenantID != u.TenantID {
+		return domain.ErrUserNotFound
+	}
+	if owner, taken := r.byEmail[tenantEmail{u.TenantID, u.Email}]; taken && owner != u.ID {
+		return domain.ErrUserExists
+	}
+	delete(r.byEmail, tenantEmail{old.TenantID, old.Email})
+	r.byID[u.ID] = u
+	r.byEmail[tenantEmail{u.TenantID, u.Email}] = u.ID
+	return nil
+}
+
+func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	id, ok := r.byEmail[tenantEmail{domain.TenantOf(ctx), email}]
+	if !ok {
+		return nil, domain.ErrUserNotFound
+	}
+	u := r.byID[id]
+	if u.Deleted() && !domain.IncludesDeleted(ctx) {
+		return nil, domain.ErrUserNotFound
+	}
+	return &u, nil
+}
+
+func (r *UserRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	u, ok := r.byID[id]
+	if !ok || u.TenantID != domain.TenantOf(ctx) || u.Deleted() && !domain.IncludesDeleted(ctx) {
+		return nil, domain.ErrUserNotFound
+	}
+	return &u, nil
+}
+
+func (r *UserRepository) GetByIDs(ctx context.Context, ids []uuid.UUID) ([]domain.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	users := make([]domain.User, 0, len(ids))
+	for _, id := range ids {
+		u, ok := r.byID[id]
+		if ok && u.TenantID == domain.TenantOf(ctx) && (!u.Deleted() || domain.IncludesDeleted(ctx)) {
+			users = append(users, u)
+		}
+	}
+	return users, nil
+}
+
+// StreamUsers pages through ListUsers, 500 users at a time.
+func (r *UserRepository) StreamUsers(ctx context.Context, fn func(domain.User) error) error {
+	return page.Each(ctx, page.Request{Size: 500}, r.ListUsers, fn)
+}
+
+func (r *UserRepository) PurgeDeleted(ctx context.Context, cutoff time.Time) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := 0
+	for id, u := range r.byID {
+		if u.Deleted() && u.DeletedAt.Before(cutoff) {
+			delete(r.byID, id)
+			delete(r.byEmail, tenantEmail{u.TenantID, u.Email})
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *UserRepository) ListUsers(ctx context.Context, req page.Request) (page.Page[domain.User], error) {
+	if err := ctx.Err(); err != nil {
+		return page.Page[domain.User]{}, err
+	}
+	after, resume, err := domain.ParseUserCursor(req.After, req.Sort)
+	if err != nil {
+		return page.Page[domain.User]{}, err
+	}
+	tenant, includeDeleted := domain.TenantOf(ctx), domain.IncludesDeleted(ctx)
+	r.mu.RLock()
+	var out []domain.User
+	for _, u := range r.byID {
+		if u.TenantID != tenant || u.Deleted() && !includeDeleted || resume && !after.After(u) {
+			continue
+		}
+		out = append(out, u)
+	}
+	r.mu.RUnlock()
+	slices.SortFunc(out, func(a, b domain.User) int { return domain.CompareUsers(a, b, req.Sort) })
+
+	var next page.Cursor
+	if req.Size > 0 && len(out) > req.Size {
+		out = out[:req.Size]
+		next = domain.CursorAfter(out[req.Size-1], req.Sort)
+	}
+	return page.Page[domain.User]{Items: out, Next: next}, nil
+}
@@ -0,0 +1,33 @@
+// Package mongo stores users in MongoDB, as an alternative to the postgres
+// adapter behind the same domain.UserRepository port.
+package mongo
+
+import (
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"go.mongodb.org/mongo-driver/v2/x/mongo/driver/connstring"
+)
+
+// DefaultDatabase is used when the connection string names no database.
+const DefaultDatabase = "clean_go_system"
+
+// Open creates a client for uri and returns the database named in its path.
+// The driver connects lazily, so Open does not touch the network.
+func Open(uri string) (*mongo.Client, *mongo.Database, error) {
+	cs, err := connstring.ParseAndValidate(uri)
+	if err != nil {
+		return nil, nil, fmt.Errorf("parse mongodb uri: %w", err)
+	}
+	name := cs.Database
+	if name == "" {
+		name = DefaultDatabase
+	}
+
+	client, err := mongo.Connect(options.Client().ApplyURI(uri))
+	if err != nil {
+		return nil, nil, fmt.Errorf("connect to mongodb: %w", err)
+	}
+	return client, client.Database(name), nil
+}
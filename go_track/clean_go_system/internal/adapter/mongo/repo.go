@@ -0,0 +1,265 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"clean-code-cookbook/go/pkg/page"
+	"clean_go_system/internal/domain"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// userDoc is the stored shape of a user. The domain entity stays free of
+// bson tags; IDs are kept as strings so documents are readable in the shell.
+type userDoc struct {
+	ID         string     `bson:"_id"`
+	TenantID   string     `bson:"tenant_id"`
+	Email      string     `bson:"email"`
+	Username   string     `bson:"username"`
+	Active     bool       `bson:"active"`
+	CreatedAt  time.Time  `bson:"created_at"`
+	UpdatedAt  time.Time  `bson:"updated_at"`
+	VerifiedAt *time.Time `bson:"verified_at"`
+	DeletedAt  *time.Time `bson:"deleted_at"`
+	Locale     string     `bson:"locale,omitempty"`
+}
+
+func toDoc(u domain.User) userDoc {
+	return userDoc{ID: u.ID.String(), TenantID: string(u.TenantID), Email: u.Email, Username: u.Username, Active: u.Active, CreatedAt: u.CreatedAt, UpdatedAt: u.UpdatedAt, VerifiedAt: u.VerifiedAt, DeletedAt: u.DeletedAt, Locale: u.Locale}
+}
+
+func (d userDoc) toDomain() (*domain.User, error) {
+	id, err := uuid.Parse(d.ID)
+	if err != nil {
+		return nil, fmt.Errorf("user document %q: %w", d.ID, err)
+	}
+	u := &domain.User{ID: id, TenantID: domain.TenantID(d.TenantID), Email: d.Email, Username: d.Username, Active: d.Active, CreatedAt: d.CreatedAt, UpdatedAt: d.UpdatedAt, VerifiedAt: d.VerifiedAt, DeletedAt: d.DeletedAt, Locale: d.Locale}
+	if u.UpdatedAt.IsZero() {
+		// Written before updated_at existed.
+		u.UpdatedAt = u.CreatedAt
+	}
+	return u, nil
+}
+
+// UserRepository implements domain.UserRepository, domain.UserLister,
+// domain.UserBatchReader, domain.UserStreamer and domain.UserPurger on a "users" collection.
+// Every call is bounded by timeout, on top of the caller's own deadline.
+type UserRepository struct {
+	users   *mongo.Collection
+	timeout time.Duration
+}
+
+func NewUserRepository(db *mongo.Database, timeout time.Duration) *UserRepository {
+	return &UserRepository{users: db.Collection("users"), timeout: timeout}
+}
+
+// EnsureIndexes creates the unique per-tenant email index Save relies on
+// to reject duplicates. Documents from before tenants existed join the
+// default tenant, and the old global email index is dropped. It is
+// idempotent.
+func (r *UserRepository) EnsureIndexes(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	_, err := r.users.UpdateMany(ctx,
+		bson.M{"tenant_id": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"tenant_id": string(domain.DefaultTenant)}})
+	if err != nil {
+		return fmt.Errorf("backfill tenant_id: %w", err)
+	}
+	_, err = r.users.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "tenant_id", Value: 1}, {Key: "email", Value: 1}},
+		Options: options.Index().SetUnique(true).SetName("users_tenant_email_key"),
+	})
+	if err != nil {
+		return fmt.Errorf("create users_tenant_email_key index: %w", err)
+	}
+	// 26 NamespaceNotFound, 27 IndexNotFound: nothing left to drop.
+	var cmdErr mongo.CommandError
+	if err := r.users.Indexes().DropOne(ctx, "users_email_key"); err != nil &&
+		!(errors.As(err, &cmdErr) && (cmdErr.HasErrorCode(26) || cmdErr.HasErrorCode(27))) {
+		return fmt.Errorf("drop users_email_key index: %w", err)
+	}
+	return nil
+}
+
+func (r *UserRepository) Save(ctx context.Context, u domain.User) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	u.TenantID = domain.TenantOf(ctx)
+	_, err := r.users.InsertOne(ctx, toDoc(u))
+	return mapError(err)
+}
+
+func (r *UserRepository) Update(ctx context.Context, u domain.User) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	doc := toDoc(u)
+	filter := bson.M{"_id": doc.ID, "tenant_id": string(domain.TenantOf(ctx))}
+	res, err := r.users.UpdateOne(ctx, filter, bson.M{"$set": bson.M{
+		"email":       doc.Email,
+		"username":    doc.Username,
+		"active":      doc.Active,
+		"updated_at":  doc.UpdatedAt,
+		"verified_at": doc.VerifiedAt,
+		"deleted_at":  doc.DeletedAt,
+		"locale":      doc.Locale,
+	}})
+	if err != nil {
+		return mapError(err)
+	}
+	if res.MatchedCount == 0 {
+		return domain.ErrUserNotFound
+	}
+	return nil
+}
+
+func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
+	return r.findOne(ctx, bson.M{"email": email})
+}
+
+func (r *UserRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	return r.findOne(ctx, bson.M{"_id": id.String()})
+}
+
+func (r *UserRepository) GetByIDs(ctx context.Context, ids []uuid.UUID) ([]domain.User, error) {
+	in := make(bson.A, 0, len(ids))
+	for _, id := range ids {
+		in = append(in, id.String())
+	}
+	filter := bson.M{"_id": bson.M{"$in": in}, "tenant_id": string(domain.TenantOf(ctx))}
+	if !domain.IncludesDeleted(ctx) {
+		filter["deleted_at"] = nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	cur, err := r.users.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	var docs []userDoc
+	if err := cur.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	users := make([]domain.User, 0, len(docs))
+	for _, doc := range docs {
+		u, err := doc.toDomain()
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, *u)
+	}
+	return users, nil
+}
+
+// ListUsers pages by keyset on the sort field and _id. Documents written
+// before updated_at existed sort first by it.
+func (r *UserRepository) ListUsers(ctx context.Context, req page.Request) (page.Page[domain.User], error) {
+	after, resume, err := domain.ParseUserCursor(req.After, req.Sort)
+	if err != nil {
+		return page.Page[domain.User]{}, err
+	}
+	field, dir, op := "created_at", 1, "$gt"
+	if req.Sort.Field == "updated_at" {
+		field = "updated_at"
+	}
+	if req.Sort.Desc {
+		dir, op = -1, "$lt"
+	}
+	filter := bson.M{"tenant_id": string(domain.TenantOf(ctx))}
+	if !domain.IncludesDeleted(ctx) {
+		filter["deleted_at"] = nil
+	}
+	if resume {
+		filter["$or"] = bson.A{
+			bson.M{field: bson.M{op: after.At}},
+			bson.M{field: after.At, "_id": bson.M{op: after.ID.String()}},
+		}
+	}
+	opts := options.Find().SetSort(bson.D{{Key: field, Value: dir}, {Key: "_id", Value: dir}})
+	if req.Size > 0 {
+		opts.SetLimit(int64(req.Size) + 1) // one more, to tell whether there is a next page
+	}
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	cur, err := r.users.Find(ctx, filter, opts)
+	if err != nil {
+		return page.Page[domain.User]{}, err
+	}
+	var docs []userDoc
+	if err := cur.All(ctx, &docs); err != nil {
+		return page.Page[domain.User]{}, err
+	}
+	users := make([]domain.User, 0, len(docs))
+	for _, doc := range docs {
+		u, err := doc.toDomain()
+		if err != nil {
+			return page.Page[domain.User]{}, err
+		}
+		users = append(users, *u)
+	}
+	var next page.Cursor
+	if req.Size > 0 && len(users) > req.Size {
+		users = users[:req.Size]
+		next = domain.CursorAfter(users[req.Size-1], req.Sort)
+	}
+	return page.Page[domain.User]{Items: users, Next: next}, nil
+}
+
+// StreamUsers pages through ListUsers, 500 users at a time.
+func (r *UserRepository) StreamUsers(ctx context.Context, fn func(domain.User) error) error {
+	return page.Each(ctx, page.Request{Size: 500}, r.ListUsers, fn)
+}
+
+func (r *UserRepository) PurgeDeleted(ctx context.Context, cutoff time.Time) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	res, err := r.users.DeleteMany(ctx, bson.M{"deleted_at": bson.M{"$lt": cutoff}})
+	if err != nil {
+		return 0, err
+	}
+	return int(res.DeletedCount), nil
+}
+
+// findOne keeps to ctx's tenant and hides soft-deleted users unless ctx
+// asks for them; a nil deleted_at matches documents written before the
+// field existed too.
+func (r *UserRepository) findOne(ctx context.Context, filter bson.M) (*domain.User, error) {
+	filter["tenant_id"] = string(domain.TenantOf(ctx))
+	if !domain.IncludesDeleted(ctx) {
+		filter["deleted_at"] = nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var doc userDoc
+	if err := r.users.FindOne(ctx, filter).Decode(&doc); err != nil {
+		return nil, mapError(err)
+	}
+	return doc.toDomain()
+}
+
+// mapError translates driver errors into domain errors; anything else is
+// returned unchanged.
+func mapError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return domain.ErrUserNotFound
+	case mongo.IsDuplicateKeyError(err):
+		return domain.ErrUserExists
+	default:
+		return err
+	}
+}
@@ -0,0 +1,39 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// Transactor implements domain.Transactor with multi-document transactions.
+// The session travels in ctx, so repository calls made with it join the
+// transaction. Transactions need a replica set (a single-node one is fine).
+type Transactor struct {
+	client *mongo.Client
+}
+
+func NewTransactor(client *mongo.Client) *Transactor {
+	return &Transactor{client: client}
+}
+
+// WithinTransaction commits when fn returns nil and aborts otherwise; the
+// driver retries fn on transient transaction errors. Nested calls join the
+// outer transaction.
+func (t *Transactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if mongo.SessionFromContext(ctx) != nil {
+		return fn(ctx)
+	}
+
+	session, err := t.client.StartSession()
+	if err != nil {
+		return fmt.Errorf("start session: %w", err)
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(ctx context.Context) (any, error) {
+		return nil, fn(ctx)
+	})
+	return err
+}
@@ -0,0 +1,104 @@
+package nats
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"clean_go_system/internal/adapter/eventcodec"
+	"clean_go_system/internal/domain"
+	"clean_go_system/pkg/tracing"
+	"github.com/nats-io/nats.go"
+)
+
+// EventTypeHeader names the event carried by a message. Without it the
+// subject suffix is used (see Subscriber.eventType).
+const EventTypeHeader = "event_type"
+
+// Subscriber is an inbound adapter: it consumes user events published by
+// other instances or services over NATS and dispatches them to local
+// handlers through sink (normally the in-process event bus). Subscribing
+// with a queue group means each message goes to one instance of the
+// service, so consumers scale out.
+//
+// It decodes only the events eventcodec.Default registers, which are the
+// user events. Product events are not among them: the catalog service
+// publishes none, and this service keeps no product data to update.
+// Messages of any other type are logged and dropped.
+type Subscriber struct {
+	conn    *nats.Conn
+	subject string
+	queue   string
+	sink    domain.EventPublisher
+	logger  *log.Logger
+
+	ctx context.Context
+	sub *nats.Subscription
+}
+
+// NewSubscriber listens on subject (wildcards allowed, e.g. "users.events.>")
+// as a member of the queue group.
+func NewSubscriber(conn *nats.Conn, subject, queue string, sink domain.EventPublisher, logger *log.Logger) *Subscriber {
+	return &Subscriber{conn: conn, subject: subject, queue: queue, sink: sink, logger: logger}
+}
+
+// Start subscribes. It satisfies lifecycle.Component.
+func (s *Subscriber) Start(ctx context.Context) error {
+	s.ctx = ctx
+	sub, err := s.conn.QueueSubscribe(s.subject, s.queue, s.handle)
+	if err != nil {
+		return err
+	}
+	s.sub = sub
+	return nil
+}
+
+// Stop drains the subscription: in-flight messages finish, new ones go to
+// other members of the queue group.
+func (s *Subscriber) Stop(ctx context.Context) error {
+	if s.sub == nil {
+		return nil
+	}
+	if err := s.sub.Drain(); err != nil {
+		return err
+	}
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	for s.sub.IsValid() {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+func (s *Subscriber) handle(msg *nats.Msg) {
+	eventType := s.eventType(msg)
+	event, id, err := eventcodec.Decode(s.ctx, eventType, msg.Data)
+	if err != nil {
+		s.logger.Printf("nats %s: dropping undecodable message: %v", msg.Subject, err)
+		return
+	}
+
+	ctx := s.ctx
+	if tp := msg.Header.Get(tracing.Header); tp != "" {
+		ctx = tracing.WithTraceParent(ctx, tp)
+	}
+	if err := eventcodec.Forward(ctx, s.sink, id, event); err != nil {
+		s.logger.Printf("nats %s: %s handlers failed: %v", msg.Subject, eventType, err)
+	}
+}
+
+// eventType prefers the header and falls back to the subject suffix after
+// the subscription prefix ("users.events.>" + "users.events.user.registered"
+// yields "user.registered").
+func (s *Subscriber) eventType(msg *nats.Msg) string {
+	if t := msg.Header.Get(EventTypeHeader); t != "" {
+		return t
+	}
+	prefix := strings.TrimSuffix(strings.TrimSuffix(s.subject, ">"), "*")
+	return strings.TrimPrefix(msg.Subject, prefix)
+}
@@ -0,0 +1,98 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"clean-code-cookbook/go/pkg/signing"
+	"clean_go_system/internal/core"
+	"clean_go_system/internal/domain"
+)
+
+// Email implements domain.Notifier by queueing an email job, waiting up
+// to wait for room. A queue that stays full is reported as
+// core.ErrQueueFull so the outbox relay keeps the event pending and
+// retries it (or the handler answers 503), instead of the email being
+// silently dropped.
+type Email struct {
+	queue core.EmailQueue
+	wait  time.Duration
+}
+
+func NewEmail(queue core.EmailQueue, wait time.Duration) *Email {
+	return &Email{queue: queue, wait: wait}
+}
+
+func (e *Email) Notify(ctx context.Context, n domain.Notification) error {
+	ctx, cancel := context.WithTimeout(ctx, e.wait)
+	defer cancel()
+	return e.queue.Enqueue(ctx, core.EmailJob{UserID: n.UserID, Template: n.Event, Email: n.To, Subject: n.Subject, Body: n.Body, HTML: n.HTML})
+}
+
+// SMS is a stand-in for a text message provider: it logs what it would
+// send.
+type SMS struct {
+	logger *log.Logger
+}
+
+func NewSMS(logger *log.Logger) *SMS {
+	return &SMS{logger: logger}
+}
+
+func (s *SMS) Notify(ctx context.Context, n domain.Notification) error {
+	s.logger.Printf("sms to %s (%s): %s", n.To, n.Event, n.Body)
+	return nil
+}
+
+type webhookPayload struct {
+	Event   string `json:"event"`
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+// Webhook implements domain.Notifier by POSTing each notification as JSON
+// to one URL, such as a chat channel's incoming webhook. With Keys set,
+// deliveries are signed like the event webhooks.
+type Webhook struct {
+	// Client sends deliveries; it defaults to one with a 5s timeout.
+	Client *http.Client
+	// Keys, if set, signs every delivery.
+	Keys *signing.Keyring
+
+	url string
+}
+
+func NewWebhook(url string) *Webhook {
+	return &Webhook{Client: &http.Client{Timeout: 5 * time.Second}, url: url}
+}
+
+func (w *Webhook) Notify(ctx context.Context, n domain.Notification) error {
+	body, err := json.Marshal(webhookPayload{Event: n.Event, Subject: n.Subject, Body: n.Body})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if w.Keys != nil {
+		w.Keys.SignRequest(req, body)
+	}
+	resp, err := w.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("notification webhook answered %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package notify
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	xhtml "golang.org/x/net/html"
+)
+
+// cssRule is one selector of a style sheet rule with its declarations.
+type cssRule struct {
+	tag     string   // "" matches any element
+	classes []string // all must be present
+	decls   [][2]string
+	// specificity orders rules like a browser would, for the selectors
+	// supported: ten per class, one for the tag.
+	specificity int
+}
+
+var (
+	selectorPattern = regexp.MustCompile(`^([a-z][a-z0-9]*)?((?:\.[A-Za-z0-9_-]+)*)$`)
+	cssComment      = regexp.MustCompile(`(?s)/\*.*?\*/`)
+)
+
+// parseCSS reads a style sheet of plain rules whose selectors are a tag,
+// classes, or a tag with classes, such as "p", ".button" or "td.code".
+// Anything a style attribute cannot express, such as descendant
+// selectors, pseudo-classes or @media, is an error rather than silently
+// lost.
+func parseCSS(css string) ([]cssRule, error) {
+	css = cssComment.ReplaceAllString(css, "")
+	var rules []cssRule
+	blocks := strings.Split(css, "}")
+	if rest := strings.TrimSpace(blocks[len(blocks)-1]); rest != "" {
+		return nil, fmt.Errorf("unterminated rule %q", rest)
+	}
+	for _, block := range blocks[:len(blocks)-1] {
+		selectors, body, ok := strings.Cut(block, "{")
+		if !ok || strings.Contains(body, "{") {
+			return nil, fmt.Errorf("malformed rule %q", strings.TrimSpace(block))
+		}
+		var decls [][2]string
+		for _, decl := range strings.Split(body, ";") {
+			if strings.TrimSpace(decl) == "" {
+				continue
+			}
+			prop, value, ok := strings.Cut(decl, ":")
+			if !ok {
+				return nil, fmt.Errorf("malformed declaration %q", strings.TrimSpace(decl))
+			}
+			decls = append(decls, [2]string{strings.ToLower(strings.TrimSpace(prop)), strings.TrimSpace(value)})
+		}
+		for _, sel := range strings.Split(selectors, ",") {
+			sel = strings.TrimSpace(sel)
+			m := selectorPattern.FindStringSubmatch(sel)
+			if sel == "" || m == nil {
+				return nil, fmt.Errorf("unsupported selector %q", sel)
+			}
+			rule := cssRule{tag: m[1], decls: decls}
+			if m[2] != "" {
+				rule.classes = strings.Split(m[2][1:], ".")
+			}
+			rule.specificity = 10 * len(rule.classes)
+			if rule.tag != "" {
+				rule.specificity++
+			}
+			rules = append(rules, rule)
+		}
+	}
+	// Stable by specificity, then as written: later declarations win.
+	slices.SortStableFunc(rules, func(a, b cssRule) int { return a.specificity - b.specificity })
+	return rules, nil
+}
+
+func (r cssRule) matches(n *xhtml.Node) bool {
+	if r.tag != "" && r.tag != n.Data {
+		return false
+	}
+	if len(r.classes) == 0 {
+		return true
+	}
+	classes := strings.Fields(attr(n, "class"))
+	for _, c := range r.classes {
+		if !slices.Contains(classes, c) {
+			return false
+		}
+	}
+	return true
+}
+
+// inline writes the declarations of the rules matching each element of
+// doc into its style attribute, ahead of the declarations already there,
+// which still win.
+func inline(doc *xhtml.Node, rules []cssRule) {
+	var walk func(n *xhtml.Node)
+	walk = func(n *xhtml.Node) {
+		if n.Type == xhtml.ElementNode {
+			var (
+				props []string
+				value = map[string]string{}
+			)
+			set := func(prop, v string) {
+				if _, ok := value[prop]; !ok {
+					props = append(props, prop)
+				}
+				value[prop] = v
+			}
+			for _, r := range rules {
+				if r.matches(n) {
+					for _, d := range r.decls {
+						set(d[0], d[1])
+					}
+				}
+			}
+			for _, decl := range strings.Split(attr(n, "style"), ";") {
+				if prop, v, ok := strings.Cut(decl, ":"); ok {
+					set(strings.ToLower(strings.TrimSpace(prop)), strings.TrimSpace(v))
+				}
+			}
+			if len(props) > 0 {
+				decls := make([]string, len(props))
+				for i, p := range props {
+					decls[i] = p + ": " + value[p]
+				}
+				setAttr(n, "style", strings.Join(decls, "; "))
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+}
+
+func attr(n *xhtml.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func setAttr(n *xhtml.Node, key, val string) {
+	for i, a := range n.Attr {
+		if a.Key == key {
+			n.Attr[i].Val = val
+			return
+		}
+	}
+	n.Attr = append(n.Attr, xhtml.Attribute{Key: key, Val: val})
+}
@@ -0,0 +1,157 @@
+package notify
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"html"
+	"html/template"
+	"io/fs"
+	"path"
+	"strings"
+
+	"clean_go_system/internal/domain"
+	xhtml "golang.org/x/net/html"
+)
+
+//go:embed emails
+var builtinEmails embed.FS
+
+// EmailTemplates implements domain.MessageTemplates with HTML emails. An
+// fs holds:
+//
+//   - layout.html, defining the "layout" every email is rendered in;
+//   - style.css, inlined into the style attributes of the rendered
+//     email, since many mail clients drop <style> elements;
+//   - partials/*.html, templates any email may call;
+//   - <event>/<locale>.html, such as user.registered/de.html, defining
+//     the "subject" and the "content" the layout wraps. They may also
+//     redefine the layout's blocks, such as "footer".
+//
+// Every template may call plural (see pluralFuncs), in the locale of the
+// email. Rendered messages carry the HTML and a plain text version of it.
+type EmailTemplates struct {
+	// Fallbacks are the locales tried before the default when a locale
+	// has no email.
+	Fallbacks domain.LocaleFallbacks
+
+	defaultLocale string
+	css           []cssRule
+	set           map[string]*template.Template // by "<event>/<locale>"
+}
+
+// BuiltinEmailTemplates returns the HTML emails shipped with the service.
+func BuiltinEmailTemplates(defaultLocale string) (*EmailTemplates, error) {
+	sub, err := fs.Sub(builtinEmails, "emails")
+	if err != nil {
+		return nil, err
+	}
+	return LoadEmailTemplates(sub, defaultLocale)
+}
+
+// LoadEmailTemplates parses the layout, the stylesheet and every email in
+// fsys, so a broken one fails at startup instead of when its event comes
+// along.
+func LoadEmailTemplates(fsys fs.FS, defaultLocale string) (*EmailTemplates, error) {
+	css, err := fs.ReadFile(fsys, "style.css")
+	if err != nil {
+		return nil, err
+	}
+	rules, err := parseCSS(string(css))
+	if err != nil {
+		return nil, fmt.Errorf("style.css: %w", err)
+	}
+	defaultLocale = domain.NormalizeLocale(defaultLocale)
+	base, err := template.New("layout.html").Funcs(pluralFuncs(defaultLocale)).Option("missingkey=error").ParseFS(fsys, "layout.html")
+	if err != nil {
+		return nil, err
+	}
+	if base.Lookup("layout") == nil {
+		return nil, errors.New(`layout.html: must define "layout"`)
+	}
+	if partials, _ := fs.Glob(fsys, "partials/*.html"); len(partials) > 0 {
+		if base, err = base.ParseFS(fsys, partials...); err != nil {
+			return nil, err
+		}
+	}
+
+	paths, err := fs.Glob(fsys, "*/*.html")
+	if err != nil {
+		return nil, err
+	}
+	t := &EmailTemplates{defaultLocale: defaultLocale, css: rules, set: make(map[string]*template.Template, len(paths))}
+	for _, p := range paths {
+		event := path.Dir(p)
+		if event == "partials" {
+			continue
+		}
+		locale := domain.NormalizeLocale(strings.TrimSuffix(path.Base(p), ".html"))
+		if locale == "" {
+			return nil, fmt.Errorf("template %s: file name is not a locale", p)
+		}
+		layout, err := base.Clone()
+		if err != nil {
+			return nil, err
+		}
+		tmpl, err := layout.Funcs(pluralFuncs(locale)).ParseFS(fsys, p)
+		if err != nil {
+			return nil, fmt.Errorf("template %s: %w", p, err)
+		}
+		if tmpl.Lookup("subject") == nil || tmpl.Lookup("content") == nil {
+			return nil, fmt.Errorf("template %s: must define \"subject\" and \"content\"", p)
+		}
+		t.set[event+"/"+locale] = tmpl
+	}
+	return t, nil
+}
+
+// Render tries the locales of Fallbacks.Chain, like Templates.
+func (t *EmailTemplates) Render(event, locale string, data any) (domain.Message, error) {
+	for _, l := range t.Fallbacks.Chain(locale, t.defaultLocale) {
+		if tmpl, ok := t.set[event+"/"+l]; ok {
+			return t.render(tmpl, data)
+		}
+	}
+	return domain.Message{}, fmt.Errorf("%w for %s in %q", domain.ErrNoTemplate, event, domain.NormalizeLocale(locale))
+}
+
+func (t *EmailTemplates) render(tmpl *template.Template, data any) (domain.Message, error) {
+	var subject, page bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return domain.Message{}, err
+	}
+	if err := tmpl.ExecuteTemplate(&page, "layout", data); err != nil {
+		return domain.Message{}, err
+	}
+	doc, err := xhtml.Parse(&page)
+	if err != nil {
+		return domain.Message{}, err
+	}
+	inline(doc, t.css)
+	var out bytes.Buffer
+	if err := xhtml.Render(&out, doc); err != nil {
+		return domain.Message{}, err
+	}
+	return domain.Message{
+		// The subject went through HTML escaping like the rest.
+		Subject: strings.Join(strings.Fields(html.UnescapeString(subject.String())), " "),
+		Body:    plaintext(doc),
+		HTML:    out.String(),
+	}, nil
+}
+
+// Chain renders with the first of its templates that has one for the
+// event, so HTML emails can take over events one by one from the plain
+// text templates.
+type Chain []domain.MessageTemplates
+
+func (c Chain) Render(event, locale string, data any) (domain.Message, error) {
+	for _, templates := range c {
+		msg, err := templates.Render(event, locale, data)
+		if !errors.Is(err, domain.ErrNoTemplate) {
+			return msg, err
+		}
+	}
+	return domain.Message{}, fmt.Errorf("%w for %s in %q", domain.ErrNoTemplate, event, locale)
+}
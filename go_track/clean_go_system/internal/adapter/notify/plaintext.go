@@ -0,0 +1,74 @@
+package notify
+
+import (
+	"regexp"
+	"strings"
+
+	xhtml "golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var whitespace = regexp.MustCompile(`\s+`)
+
+// plaintext is the text alternative of an HTML email: the body's text
+// with paragraphs, line breaks and list items kept, and each link's
+// address after its text.
+func plaintext(doc *xhtml.Node) string {
+	var b strings.Builder
+	var walk func(n *xhtml.Node)
+	children := func(n *xhtml.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk = func(n *xhtml.Node) {
+		switch n.Type {
+		case xhtml.TextNode:
+			// Source line breaks are layout, not text.
+			b.WriteString(whitespace.ReplaceAllString(n.Data, " "))
+			return
+		case xhtml.ElementNode:
+		default:
+			children(n)
+			return
+		}
+
+		switch n.DataAtom {
+		case atom.Head, atom.Style, atom.Script:
+		case atom.Br:
+			b.WriteString("\n")
+		case atom.Hr:
+			b.WriteString("\n\n---\n\n")
+		case atom.Li:
+			b.WriteString("\n- ")
+			children(n)
+			b.WriteString("\n")
+		case atom.A:
+			start := b.Len()
+			children(n)
+			text := strings.TrimSpace(b.String()[start:])
+			if href := attr(n, "href"); href != "" && href != text && !strings.HasPrefix(href, "#") {
+				b.WriteString(" (" + strings.TrimPrefix(href, "mailto:") + ")")
+			}
+		case atom.P, atom.Div, atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6,
+			atom.Table, atom.Tr, atom.Ul, atom.Ol, atom.Blockquote, atom.Pre:
+			b.WriteString("\n\n")
+			children(n)
+			b.WriteString("\n\n")
+		default:
+			children(n)
+		}
+	}
+	walk(doc)
+
+	// Trim every line and keep at most one blank line in a row.
+	var lines []string
+	for _, line := range strings.Split(b.String(), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" && (len(lines) == 0 || lines[len(lines)-1] == "") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,75 @@
+package notify
+
+import (
+	"strconv"
+	"strings"
+)
+
+// pluralFuncs are the functions every template can call, bound to the
+// locale of its file:
+//
+//	{{plural .ValidHours "# hour" "# hours"}}
+//
+// plural picks the form the language of the locale uses for n, with "#"
+// replaced by n. Languages take as many forms as their rule below knows,
+// in CLDR order (one, few, many); missing ones fall back to the last
+// form given.
+func pluralFuncs(locale string) map[string]any {
+	language, _, _ := strings.Cut(locale, "-")
+	return map[string]any{
+		"plural": func(n int, forms ...string) string {
+			if len(forms) == 0 {
+				return ""
+			}
+			form := forms[min(pluralForm(language, n), len(forms)-1)]
+			return strings.ReplaceAll(form, "#", strconv.Itoa(n))
+		},
+	}
+}
+
+// pluralForm is the index of the form language uses for n, after the
+// CLDR cardinal rules for integers.
+func pluralForm(language string, n int) int {
+	if n < 0 {
+		n = -n
+	}
+	mod10, mod100 := n%10, n%100
+	few := mod10 >= 2 && mod10 <= 4 && (mod100 < 12 || mod100 > 14)
+	switch language {
+	case "ja", "ko", "zh", "th", "vi", "id":
+		return 0
+	case "fr", "pt":
+		if n <= 1 {
+			return 0
+		}
+		return 1
+	case "ru", "uk", "be", "hr", "sr", "bs":
+		switch {
+		case mod10 == 1 && mod100 != 11:
+			return 0
+		case few:
+			return 1
+		}
+		return 2
+	case "pl":
+		switch {
+		case n == 1:
+			return 0
+		case few:
+			return 1
+		}
+		return 2
+	case "cs", "sk":
+		switch {
+		case n == 1:
+			return 0
+		case n >= 2 && n <= 4:
+			return 1
+		}
+		return 2
+	}
+	if n == 1 {
+		return 0
+	}
+	return 1
+}
@@ -0,0 +1,101 @@
+package notify
+
+import (
+	"errors"
+	"html/template"
+	"net/http"
+	"slices"
+	"time"
+
+	"clean_go_system/internal/core"
+	"clean_go_system/internal/domain"
+	"github.com/google/uuid"
+)
+
+// Samples returns made-up data for every message the service sends, by
+// template name, for previews.
+func Samples() map[string]any {
+	at := time.Date(2024, 3, 4, 9, 30, 0, 0, time.UTC)
+	userID := uuid.MustParse("00000000-0000-7000-8000-000000000001")
+	return map[string]any{
+		"user.registered":    domain.UserRegistered{UserID: userID, Email: "alice@example.com", Username: "alice", At: at},
+		"user.email_changed": domain.UserEmailChanged{UserID: userID, OldEmail: "alice@example.com", NewEmail: "alice@example.org", At: at},
+		"user.deactivated":   domain.UserDeactivated{UserID: userID, Email: "alice@example.com", At: at},
+		"user.deleted":       domain.UserDeleted{UserID: userID, Email: "alice@example.com", At: at},
+		"email.verification": core.VerificationEmail{Username: "alice", Email: "alice@example.com", Token: "eyJhbGciOiJIUzI1NiJ9.sample.token", ValidHours: 48},
+		"password.reset":     core.PasswordResetEmail{Username: "alice", Token: "3f9a6c2e8b7d41d0a5e6f7c8b9a0d1e2", ValidMinutes: 30},
+		"notification.digest": core.DigestEmail{Frequency: domain.DigestDaily, Items: []core.DigestEntry{
+			{Subject: "Your email address was changed", Body: "Your account now uses alice@example.org instead of alice@example.com.", At: at},
+			{Subject: "Your account was deactivated", Body: "The account for alice@example.org has been deactivated and can no longer sign in.", At: at.Add(3 * time.Hour)},
+		}},
+	}
+}
+
+// Preview renders messages with sample data in the browser, so templates
+// can be worked on without sending anything. It is meant for development
+// only: the samples are made up, but the templates are the real ones.
+type Preview struct {
+	// Samples is the data each template is rendered with, by name;
+	// templates without one cannot be previewed.
+	Samples map[string]any
+
+	templates domain.MessageTemplates
+}
+
+func NewPreview(templates domain.MessageTemplates) *Preview {
+	return &Preview{Samples: Samples(), templates: templates}
+}
+
+var previewIndex = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Email previews</title></head>
+<body>
+<h1>Email previews</h1>
+<ul>
+{{range .}}<li>{{.}}: <a href="emails/{{.}}">HTML</a> &middot; <a href="emails/{{.}}?format=text">text</a></li>
+{{end}}</ul>
+<p>Add <code>locale=de</code> (or any other) to the query to preview a translation.</p>
+</body>
+</html>
+`))
+
+// Index handles GET /dev/emails, listing the previews.
+func (p *Preview) Index(w http.ResponseWriter, r *http.Request) {
+	names := make([]string, 0, len(p.Samples))
+	for name := range p.Samples {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_ = previewIndex.Execute(w, names)
+}
+
+// Show handles GET /dev/emails/{template}, rendering the template in the
+// locale query parameter, as HTML or, with format=text, as the plain text
+// the email carries. Messages without an HTML version show as text.
+func (p *Preview) Show(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("template")
+	sample, ok := p.Samples[name]
+	if !ok {
+		http.Error(w, "no sample data for "+name, http.StatusNotFound)
+		return
+	}
+	msg, err := p.templates.Render(name, r.URL.Query().Get("locale"), sample)
+	if errors.Is(err, domain.ErrNoTemplate) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		// The error is the point of a preview: show it.
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	if r.URL.Query().Get("format") == "text" || msg.HTML == "" {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = w.Write([]byte("Subject: " + msg.Subject + "\n\n" + msg.Body))
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(msg.HTML))
+}
@@ -0,0 +1,93 @@
+// Package notify renders notifications from templates, plain text ones or
+// HTML emails, and sends them by email, SMS or webhook.
+package notify
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+	"text/template"
+
+	"clean_go_system/internal/domain"
+)
+
+//go:embed templates
+var builtin embed.FS
+
+// Templates implements domain.MessageTemplates on text/template files
+// named <event>/<locale>.tmpl, such as user.registered/pt-br.tmpl. Each
+// file defines a "subject" and a "body" template, executed with the event,
+// and may call plural (see pluralFuncs).
+type Templates struct {
+	// Fallbacks are the locales tried before the default when a locale
+	// has no template.
+	Fallbacks domain.LocaleFallbacks
+
+	defaultLocale string
+	set           map[string]*template.Template // by "<event>/<locale>"
+}
+
+// BuiltinTemplates returns the templates shipped with the service.
+func BuiltinTemplates(defaultLocale string) (*Templates, error) {
+	sub, err := fs.Sub(builtin, "templates")
+	if err != nil {
+		return nil, err
+	}
+	return LoadTemplates(sub, defaultLocale)
+}
+
+// LoadTemplates parses every template in fsys, so a broken one fails at
+// startup instead of when its event comes along.
+func LoadTemplates(fsys fs.FS, defaultLocale string) (*Templates, error) {
+	paths, err := fs.Glob(fsys, "*/*.tmpl")
+	if err != nil {
+		return nil, err
+	}
+	t := &Templates{defaultLocale: domain.NormalizeLocale(defaultLocale), set: make(map[string]*template.Template, len(paths))}
+	for _, p := range paths {
+		event, locale := path.Dir(p), domain.NormalizeLocale(strings.TrimSuffix(path.Base(p), ".tmpl"))
+		if locale == "" {
+			return nil, fmt.Errorf("template %s: file name is not a locale", p)
+		}
+		tmpl, err := template.New(path.Base(p)).Funcs(pluralFuncs(locale)).Option("missingkey=error").ParseFS(fsys, p)
+		if err != nil {
+			return nil, fmt.Errorf("template %s: %w", p, err)
+		}
+		if tmpl.Lookup("subject") == nil || tmpl.Lookup("body") == nil {
+			return nil, fmt.Errorf("template %s: must define \"subject\" and \"body\"", p)
+		}
+		t.set[event+"/"+locale] = tmpl
+	}
+	return t, nil
+}
+
+// Render tries the locales of Fallbacks.Chain: locale, then its language
+// ("pt" for "pt-br"), with their fallbacks, then the default locale.
+func (t *Templates) Render(event, locale string, data any) (domain.Message, error) {
+	for _, l := range t.Fallbacks.Chain(locale, t.defaultLocale) {
+		tmpl, ok := t.set[event+"/"+l]
+		if !ok {
+			continue
+		}
+		subject, err := execute(tmpl, "subject", data)
+		if err != nil {
+			return domain.Message{}, err
+		}
+		body, err := execute(tmpl, "body", data)
+		if err != nil {
+			return domain.Message{}, err
+		}
+		return domain.Message{Subject: subject, Body: body}, nil
+	}
+	return domain.Message{}, fmt.Errorf("%w for %s in %q", domain.ErrNoTemplate, event, domain.NormalizeLocale(locale))
+}
+
+func execute(tmpl *template.Template, name string, data any) (string, error) {
+	var sb strings.Builder
+	if err := tmpl.ExecuteTemplate(&sb, name, data); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(sb.String()), nil
+}
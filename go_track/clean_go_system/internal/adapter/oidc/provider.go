@@ -0,0 +1,253 @@
+// Package oidc signs users in with an OpenID Connect provider: discovery,
+// the authorization code flow with PKCE, and ID token validation against
+// the provider's JWKS.
+package oidc
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"clean-code-cookbook/go/pkg/jwt"
+)
+
+// ErrUnknownKey is returned for an ID token signed with a key the provider
+// does not publish.
+var ErrUnknownKey = errors.New("oidc: unknown signing key")
+
+// Config identifies this service as a client of the provider.
+type Config struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	// Scopes are requested on top of "openid".
+	Scopes []string
+}
+
+// Provider talks to one OpenID Connect provider. Discovery and keys are
+// fetched on first use, so an unreachable provider breaks logins but not
+// startup.
+type Provider struct {
+	// Client makes the calls to the provider.
+	Client *http.Client
+	// Now is checked against token expiry; it defaults to time.Now.
+	Now func() time.Time
+
+	cfg Config
+
+	mu        sync.Mutex
+	meta      *metadata
+	keys      map[string]*rsa.PublicKey
+	keysFetch time.Time
+}
+
+// keysRefreshEvery throttles refetching the JWKS for unknown key IDs, so
+// forged tokens cannot make us hammer the provider.
+const keysRefreshEvery = time.Minute
+
+func NewProvider(cfg Config) *Provider {
+	return &Provider{
+		Client: &http.Client{Timeout: 10 * time.Second},
+		Now:    time.Now,
+		cfg:    cfg,
+	}
+}
+
+// Issuer is the provider's issuer URL, the "iss" of its tokens.
+func (p *Provider) Issuer() string { return p.cfg.Issuer }
+
+type metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+func (p *Provider) discover(ctx context.Context) (*metadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.meta != nil {
+		return p.meta, nil
+	}
+	var m metadata
+	if err := p.getJSON(ctx, strings.TrimSuffix(p.cfg.Issuer, "/")+"/.well-known/openid-configuration", &m); err != nil {
+		return nil, fmt.Errorf("oidc discovery: %w", err)
+	}
+	// The spec requires an exact match; anything else is a misconfigured
+	// or impersonated provider.
+	if m.Issuer != p.cfg.Issuer {
+		return nil, fmt.Errorf("oidc discovery: issuer %q does not match %q", m.Issuer, p.cfg.Issuer)
+	}
+	p.meta = &m
+	return p.meta, nil
+}
+
+// AuthCodeURL is where to send the browser to log in. state and nonce are
+// echoed back to tie the callback and the ID token to this attempt;
+// verifier is the PKCE secret later passed to Exchange.
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error) {
+	m, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	challenge := sha256.Sum256([]byte(verifier))
+	q := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.cfg.ClientID},
+		"redirect_uri":          {p.cfg.RedirectURL},
+		"scope":                 {strings.Join(append([]string{"openid"}, p.cfg.Scopes...), " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+	return m.AuthorizationEndpoint + "?" + q.Encode(), nil
+}
+
+// Exchange trades the authorization code for the raw ID token.
+func (p *Provider) Exchange(ctx context.Context, code, verifier string) (string, error) {
+	m, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.cfg.RedirectURL},
+		"code_verifier": {verifier},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("oidc token exchange: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("oidc token exchange: status %d", resp.StatusCode)
+	}
+	var body struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("oidc token exchange: %w", err)
+	}
+	if body.IDToken == "" {
+		return "", errors.New("oidc token exchange: no id_token in response")
+	}
+	return body.IDToken, nil
+}
+
+// Verify checks an ID token's signature, issuer, audience and expiry and,
+// when nonce is not empty, that it was issued for this login attempt.
+func (p *Provider) Verify(ctx context.Context, raw, nonce string) (*jwt.Claims, error) {
+	token, err := jwt.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	key, err := p.key(ctx, token.Header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if err := token.VerifyRS256(key); err != nil {
+		return nil, err
+	}
+	expect := jwt.Expect{Issuer: p.cfg.Issuer, Audience: p.cfg.ClientID, Now: p.Now(), Leeway: time.Minute}
+	if err := token.Claims.Validate(expect); err != nil {
+		return nil, err
+	}
+	if nonce != "" && token.Claims.Nonce != nonce {
+		return nil, fmt.Errorf("%w: nonce mismatch", jwt.ErrClaims)
+	}
+	return &token.Claims, nil
+}
+
+// key returns the provider's public key kid, refetching the JWKS when the
+// provider may have rotated keys since the last fetch.
+func (p *Provider) key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	m, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+	if !p.keysFetch.IsZero() && p.Now().Sub(p.keysFetch) < keysRefreshEvery {
+		return nil, ErrUnknownKey
+	}
+	keys, err := p.fetchKeys(ctx, m.JWKSURI)
+	if err != nil {
+		return nil, err
+	}
+	p.keys, p.keysFetch = keys, p.Now()
+	if key, ok := keys[kid]; ok {
+		return key, nil
+	}
+	return nil, ErrUnknownKey
+}
+
+type jwks struct {
+	Keys []struct {
+		Kid string `json:"kid"`
+		Kty string `json:"kty"`
+		Use string `json:"use"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+	} `json:"keys"`
+}
+
+// fetchKeys reads the RSA signing keys of the JWKS; other key types are
+// skipped.
+func (p *Provider) fetchKeys(ctx context.Context, uri string) (map[string]*rsa.PublicKey, error) {
+	var set jwks
+	if err := p.getJSON(ctx, uri, &set); err != nil {
+		return nil, fmt.Errorf("oidc jwks: %w", err)
+	}
+	keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+		n, errN := base64.RawURLEncoding.DecodeString(k.N)
+		e, errE := base64.RawURLEncoding.DecodeString(k.E)
+		if errN != nil || errE != nil {
+			return nil, fmt.Errorf("oidc jwks: key %s is not valid base64url", k.Kid)
+		}
+		keys[k.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	}
+	return keys, nil
+}
+
+func (p *Provider) getJSON(ctx context.Context, uri string, into any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: status %d", uri, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(into)
+}
@@ -0,0 +1,66 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"clean_go_system/internal/domain"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// APIKeyRepository implements domain.APIKeyRepository on the api_keys
+// table, looking keys up by the unique key_hash column within the tenant.
+type APIKeyRepository struct {
+	db *sql.DB
+}
+
+func NewAPIKeyRepository(db *sql.DB) *APIKeyRepository {
+	return &APIKeyRepository{db: db}
+}
+
+func (r *APIKeyRepository) Save(ctx context.Context, k domain.APIKey) error {
+	query := `INSERT INTO api_keys (id, tenant_id, name, key_hash, scopes, rate_limit, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, k.ID, domain.TenantOf(ctx), k.Name, k.Hash, pq.Array(k.Scopes), k.RateLimit, k.CreatedAt)
+	return err
+}
+
+func (r *APIKeyRepository) GetByHash(ctx context.Context, hash []byte) (*domain.APIKey, error) {
+	query := `SELECT id, tenant_id, name, key_hash, scopes, rate_limit, created_at, revoked_at FROM api_keys WHERE key_hash = $1 AND tenant_id = $2`
+
+	var (
+		k       domain.APIKey
+		revoked sql.NullTime
+	)
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, hash, domain.TenantOf(ctx)).
+		Scan(&k.ID, &k.TenantID, &k.Name, &k.Hash, pq.Array(&k.Scopes), &k.RateLimit, &k.CreatedAt, &revoked)
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if revoked.Valid {
+		k.RevokedAt = &revoked.Time
+	}
+	return &k, nil
+}
+
+func (r *APIKeyRepository) Revoke(ctx context.Context, id uuid.UUID, at time.Time) error {
+	query := `UPDATE api_keys SET revoked_at = COALESCE(revoked_at, $2) WHERE id = $1 AND tenant_id = $3`
+
+	res, err := conn(ctx, r.db).ExecContext(ctx, query, id, at, domain.TenantOf(ctx))
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return domain.ErrAPIKeyNotFound
+	}
+	return nil
+}
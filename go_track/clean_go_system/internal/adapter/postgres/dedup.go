@@ -0,0 +1,38 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+)
+
+// DedupStore implements eventbus.DedupStore on the processed_events table.
+// Inside a transaction (see Transactor) the claim commits together with
+// the consumer's own writes, which makes projections exactly-once.
+type DedupStore struct {
+	db *sql.DB
+}
+
+func NewDedupStore(db *sql.DB) *DedupStore {
+	return &DedupStore{db: db}
+}
+
+func (s *DedupStore) Claim(ctx context.Context, consumer, id string) (bool, error) {
+	query := `INSERT INTO processed_events (consumer, event_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`
+
+	res, err := conn(ctx, s.db).ExecContext(ctx, query, consumer, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+func (s *DedupStore) Release(ctx context.Context, consumer, id string) error {
+	query := `DELETE FROM processed_events WHERE consumer = $1 AND event_id = $2`
+
+	_, err := conn(ctx, s.db).ExecContext(ctx, query, consumer, id)
+	return err
+}
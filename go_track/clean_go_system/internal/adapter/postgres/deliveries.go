@@ -0,0 +1,74 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"clean_go_system/internal/domain"
+	"github.com/google/uuid"
+)
+
+// DeliveryRepository implements domain.DeliveryRepository on the
+// email_deliveries table.
+type DeliveryRepository struct {
+	db *sql.DB
+}
+
+func NewDeliveryRepository(db *sql.DB) *DeliveryRepository {
+	return &DeliveryRepository{db: db}
+}
+
+const deliveryColumns = `id, user_id, template, subject, status, attempts, provider_id, error, created_at, updated_at`
+
+func (r *DeliveryRepository) Record(ctx context.Context, d domain.Delivery) error {
+	query := `INSERT INTO email_deliveries (` + deliveryColumns + `) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (id) DO UPDATE SET
+			status = EXCLUDED.status,
+			attempts = email_deliveries.attempts + EXCLUDED.attempts,
+			provider_id = EXCLUDED.provider_id,
+			error = EXCLUDED.error,
+			updated_at = EXCLUDED.updated_at`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, d.ID, d.UserID, d.Template, d.Subject, d.Status, d.Attempts, d.ProviderID, d.Error, d.CreatedAt, d.UpdatedAt)
+	return err
+}
+
+func (r *DeliveryRepository) GetByProviderID(ctx context.Context, providerID string) (*domain.Delivery, error) {
+	query := `SELECT ` + deliveryColumns + ` FROM email_deliveries WHERE provider_id = $1 AND provider_id <> ''`
+
+	d, err := scanDelivery(conn(ctx, r.db).QueryRowContext(ctx, query, providerID))
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrDeliveryNotFound
+	}
+	return d, err
+}
+
+func (r *DeliveryRepository) ListByUser(ctx context.Context, userID uuid.UUID, limit int) ([]domain.Delivery, error) {
+	query := `SELECT ` + deliveryColumns + ` FROM email_deliveries
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []domain.Delivery
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *d)
+	}
+	return out, rows.Err()
+}
+
+func scanDelivery(row interface{ Scan(...any) error }) (*domain.Delivery, error) {
+	var d domain.Delivery
+	if err := row.Scan(&d.ID, &d.UserID, &d.Template, &d.Subject, &d.Status, &d.Attempts, &d.ProviderID, &d.Error, &d.CreatedAt, &d.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
@@ -0,0 +1,137 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"clean_go_system/internal/adapter/fieldcrypt"
+	"clean_go_system/internal/domain"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// NotificationPreferencesRepository implements
+// domain.NotificationPreferencesRepository on the notification_preferences
+// table.
+type NotificationPreferencesRepository struct {
+	db *sql.DB
+}
+
+func NewNotificationPreferencesRepository(db *sql.DB) *NotificationPreferencesRepository {
+	return &NotificationPreferencesRepository{db: db}
+}
+
+func (r *NotificationPreferencesRepository) Save(ctx context.Context, p domain.NotificationPreferences) error {
+	query := `INSERT INTO notification_preferences (user_id, digest, updated_at) VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE SET digest = EXCLUDED.digest, updated_at = EXCLUDED.updated_at`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, p.UserID, p.Digest, p.UpdatedAt)
+	return err
+}
+
+func (r *NotificationPreferencesRepository) Get(ctx context.Context, userID uuid.UUID) (*domain.NotificationPreferences, error) {
+	query := `SELECT digest, updated_at FROM notification_preferences WHERE user_id = $1`
+
+	p := domain.NotificationPreferences{UserID: userID}
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, userID).Scan(&p.Digest, &p.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// DigestRepository implements domain.DigestRepository on the digest_items
+// table.
+type DigestRepository struct {
+	// PII encrypts the address and body of held items; nil stores them in
+	// plaintext.
+	PII *fieldcrypt.Codec
+
+	db *sql.DB
+}
+
+func NewDigestRepository(db *sql.DB) *DigestRepository {
+	return &DigestRepository{db: db}
+}
+
+const digestColumns = `id, user_id, email, email_ciphertext, frequency, event, locale, subject, body, body_ciphertext, created_at`
+
+func (r *DigestRepository) Add(ctx context.Context, item domain.DigestItem) error {
+	email, body := sql.NullString{String: item.Email, Valid: true}, sql.NullString{String: item.Body, Valid: true}
+	var emailCt, bodyCt []byte
+	if r.PII != nil {
+		var err error
+		if emailCt, err = r.PII.Encrypt(ctx, item.Email, fieldAAD("digest_items.email", item.ID)); err != nil {
+			return err
+		}
+		if bodyCt, err = r.PII.Encrypt(ctx, item.Body, fieldAAD("digest_items.body", item.ID)); err != nil {
+			return err
+		}
+		email, body = sql.NullString{}, sql.NullString{}
+	}
+	query := `INSERT INTO digest_items (` + digestColumns + `) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, item.ID, item.UserID, email, emailCt, item.Frequency, item.Event, item.Locale, item.Subject, body, bodyCt, item.CreatedAt)
+	return err
+}
+
+func (r *DigestRepository) Pending(ctx context.Context, frequency domain.DigestFrequency, before time.Time, limit int) ([]domain.DigestItem, error) {
+	query := `SELECT ` + digestColumns + ` FROM digest_items
+		WHERE frequency = $1 AND created_at < $2
+		ORDER BY created_at, id
+		LIMIT $3`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, frequency, before, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []domain.DigestItem
+	for rows.Next() {
+		item, err := r.scan(ctx, rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, item)
+	}
+	return out, rows.Err()
+}
+
+func (r *DigestRepository) Delete(ctx context.Context, ids []uuid.UUID) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `DELETE FROM digest_items WHERE id = ANY($1)`, pq.Array(ids))
+	return err
+}
+
+func (r *DigestRepository) scan(ctx context.Context, rows *sql.Rows) (domain.DigestItem, error) {
+	var item domain.DigestItem
+	var email, body sql.NullString
+	var emailCt, bodyCt []byte
+	if err := rows.Scan(&item.ID, &item.UserID, &email, &emailCt, &item.Frequency, &item.Event, &item.Locale, &item.Subject, &body, &bodyCt, &item.CreatedAt); err != nil {
+		return item, err
+	}
+	var err error
+	if item.Email, err = r.decrypt(ctx, email, emailCt, "digest_items.email", item.ID); err != nil {
+		return item, err
+	}
+	item.Body, err = r.decrypt(ctx, body, bodyCt, "digest_items.body", item.ID)
+	return item, err
+}
+
+func (r *DigestRepository) decrypt(ctx context.Context, plain sql.NullString, ciphertext []byte, field string, id uuid.UUID) (string, error) {
+	if ciphertext == nil {
+		return plain.String, nil
+	}
+	if r.PII == nil {
+		return "", fmt.Errorf("%s of digest item %s is encrypted and no key is configured", field, id)
+	}
+	v, err := r.PII.Decrypt(ctx, ciphertext, fieldAAD(field, id))
+	if err != nil {
+		return "", fmt.Errorf("decrypt %s of digest item %s: %w", field, id, err)
+	}
+	return v, nil
+}
@@ -0,0 +1,45 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"clean_go_system/internal/domain"
+	"github.com/lib/pq"
+)
+
+// IdentityRepository implements domain.IdentityRepository on the
+// user_identities table.
+type IdentityRepository struct {
+	db *sql.DB
+}
+
+func NewIdentityRepository(db *sql.DB) *IdentityRepository {
+	return &IdentityRepository{db: db}
+}
+
+func (r *IdentityRepository) Link(ctx context.Context, id domain.FederatedIdentity) error {
+	query := `INSERT INTO user_identities (issuer, subject, user_id, linked_at) VALUES ($1, $2, $3, $4)`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, id.Issuer, id.Subject, id.UserID, id.LinkedAt)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return domain.ErrIdentityExists
+	}
+	return err
+}
+
+func (r *IdentityRepository) Find(ctx context.Context, issuer, subject string) (*domain.FederatedIdentity, error) {
+	query := `SELECT issuer, subject, user_id, linked_at FROM user_identities WHERE issuer = $1 AND subject = $2`
+
+	var id domain.FederatedIdentity
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, issuer, subject).Scan(&id.Issuer, &id.Subject, &id.UserID, &id.LinkedAt)
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrIdentityUnknown
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
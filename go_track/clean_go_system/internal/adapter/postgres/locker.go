@@ -0,0 +1,52 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"clean_go_system/internal/domain"
+)
+
+// Locker implements domain.Locker with session-level advisory locks. Each
+// held lock pins one pooled connection until it is released.
+//
+// The ttl is not enforced: Postgres frees the lock when the session ends,
+// so a crashed owner releases it as soon as its connection drops.
+type Locker struct {
+	db *sql.DB
+}
+
+func NewLocker(db *sql.DB) *Locker {
+	return &Locker{db: db}
+}
+
+func (l *Locker) Acquire(ctx context.Context, key string, ttl time.Duration) (domain.Lock, error) {
+	c, err := l.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var ok bool
+	if err := c.QueryRowContext(ctx, `SELECT pg_try_advisory_lock(hashtext($1))`, key).Scan(&ok); err != nil {
+		c.Close()
+		return nil, fmt.Errorf("acquire lock %s: %w", key, err)
+	}
+	if !ok {
+		c.Close()
+		return nil, domain.ErrLockHeld
+	}
+	return &advisoryLock{conn: c, key: key}, nil
+}
+
+type advisoryLock struct {
+	conn *sql.Conn
+	key  string
+}
+
+func (l *advisoryLock) Release(ctx context.Context) error {
+	defer l.conn.Close()
+	_, err := l.conn.ExecContext(ctx, `SELECT pg_advisory_unlock(hashtext($1))`, l.key)
+	return err
+}
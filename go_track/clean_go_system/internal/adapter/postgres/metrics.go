@@ -0,0 +1,191 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// latencyBuckets are the upper bounds of the query latency histograms.
+var latencyBuckets = []time.Duration{
+	time.Millisecond, 2 * time.Millisecond, 5 * time.Millisecond,
+	10 * time.Millisecond, 25 * time.Millisecond, 50 * time.Millisecond,
+	100 * time.Millisecond, 250 * time.Millisecond, 500 * time.Millisecond,
+	time.Second, 2500 * time.Millisecond, 5 * time.Second,
+}
+
+// queries holds the latency of every query the adapters ran in this
+// process, by name. Like expvar, it is process-wide: every repository on
+// every database reports into it.
+var queries = &queryMetrics{byName: make(map[string]*queryHistogram)}
+
+type queryMetrics struct {
+	mu     sync.Mutex
+	byName map[string]*queryHistogram
+	names  sync.Map // caller stack → query name
+}
+
+// queryHistogram counts the queries of one name per latency bucket; the
+// last bucket holds those slower than every bound.
+type queryHistogram struct {
+	count, errors int64
+	total         time.Duration
+	buckets       []int64
+}
+
+// QueryLatency is the latency histogram of one query. Buckets are
+// cumulative, as in Prometheus: each counts the queries that took at most
+// its LeMS milliseconds.
+type QueryLatency struct {
+	Count   int64          `json:"count"`
+	Errors  int64          `json:"errors"`
+	TotalMS float64        `json:"total_ms"`
+	Buckets []LatencyCount `json:"buckets"`
+}
+
+// LatencyCount is one bucket of a QueryLatency.
+type LatencyCount struct {
+	LeMS  float64 `json:"le_ms"`
+	Count int64   `json:"count"`
+}
+
+// QueryLatencies returns the latency of every query run so far, by name.
+// A query is named after the repository method running it, such as
+// "PostgresRepository.GetByEmail". It covers the wait for a pool connection
+// and the round trip to Postgres, not reading the rows.
+func QueryLatencies() map[string]QueryLatency {
+	queries.mu.Lock()
+	defer queries.mu.Unlock()
+	out := make(map[string]QueryLatency, len(queries.byName))
+	for name, h := range queries.byName {
+		l := QueryLatency{Count: h.count, Errors: h.errors, TotalMS: ms(h.total), Buckets: make([]LatencyCount, len(latencyBuckets))}
+		var seen int64
+		for i, bound := range latencyBuckets {
+			seen += h.buckets[i]
+			l.Buckets[i] = LatencyCount{LeMS: ms(bound), Count: seen}
+		}
+		out[name] = l
+	}
+	return out
+}
+
+// PoolStats is a snapshot of a connection pool. WaitCount and WaitMS
+// growing while InUse sits at MaxOpen is the pool running dry: queries
+// queue for a connection and time out without Postgres ever being slow.
+type PoolStats struct {
+	MaxOpen           int     `json:"max_open"`
+	Open              int     `json:"open"`
+	InUse             int     `json:"in_use"`
+	Idle              int     `json:"idle"`
+	WaitCount         int64   `json:"wait_count"`
+	WaitMS            float64 `json:"wait_ms"`
+	MaxIdleClosed     int64   `json:"max_idle_closed"`
+	MaxIdleTimeClosed int64   `json:"max_idle_time_closed"`
+	MaxLifetimeClosed int64   `json:"max_lifetime_closed"`
+}
+
+// Pool returns the connection pool statistics of db.
+func Pool(db *sql.DB) PoolStats {
+	s := db.Stats()
+	return PoolStats{
+		MaxOpen:           s.MaxOpenConnections,
+		Open:              s.OpenConnections,
+		InUse:             s.InUse,
+		Idle:              s.Idle,
+		WaitCount:         s.WaitCount,
+		WaitMS:            ms(s.WaitDuration),
+		MaxIdleClosed:     s.MaxIdleClosed,
+		MaxIdleTimeClosed: s.MaxIdleTimeClosed,
+		MaxLifetimeClosed: s.MaxLifetimeClosed,
+	}
+}
+
+func (m *queryMetrics) observe(name string, took time.Duration, err error) {
+	i := 0
+	for i < len(latencyBuckets) && took > latencyBuckets[i] {
+		i++
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	h, ok := m.byName[name]
+	if !ok {
+		h = &queryHistogram{buckets: make([]int64, len(latencyBuckets)+1)}
+		m.byName[name] = h
+	}
+	h.count++
+	h.total += took
+	h.buckets[i]++
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		h.errors++
+	}
+}
+
+// name is the query name of the code skip frames above its caller: the
+// nearest exported function of this package on the stack, so a query a
+// helper runs counts for the method calling it. A method of
+// *PostgresRepository is named "PostgresRepository.GetByID".
+func (m *queryMetrics) name(skip int) string {
+	var stack [8]uintptr
+	runtime.Callers(skip+2, stack[:])
+	if name, ok := m.names.Load(stack); ok {
+		return name.(string)
+	}
+	name := "unknown"
+	frames := runtime.CallersFrames(stack[:])
+	for {
+		frame, more := frames.Next()
+		fn, ok := strings.CutPrefix(frame.Function, "clean_go_system/internal/adapter/postgres.")
+		if ok {
+			// Type.Method, Type.Method.func1 for its closures, or Func.
+			parts := strings.Split(strings.NewReplacer("(*", "", ")", "").Replace(fn), ".")
+			if len(parts) > 1 && unicode.IsUpper(rune(parts[1][0])) {
+				name = parts[0] + "." + parts[1]
+				break
+			}
+			if name == "unknown" {
+				name = parts[0]
+			}
+		}
+		if !more {
+			break
+		}
+	}
+	m.names.Store(stack, name)
+	return name
+}
+
+// timedQuerier records the latency of every query run through it under
+// the name of the function that ran it.
+type timedQuerier struct {
+	q querier
+}
+
+func (t timedQuerier) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	name, start := queries.name(1), time.Now()
+	res, err := t.q.ExecContext(ctx, query, args...)
+	queries.observe(name, time.Since(start), err)
+	return res, err
+}
+
+func (t timedQuerier) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	name, start := queries.name(1), time.Now()
+	rows, err := t.q.QueryContext(ctx, query, args...)
+	queries.observe(name, time.Since(start), err)
+	return rows, err
+}
+
+func (t timedQuerier) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	name, start := queries.name(1), time.Now()
+	row := t.q.QueryRowContext(ctx, query, args...)
+	queries.observe(name, time.Since(start), row.Err())
+	return row
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
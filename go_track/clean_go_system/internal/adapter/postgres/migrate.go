@@ -0,0 +1,150 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migration is one numbered schema change with its up and down scripts.
+type migration struct {
+	version int
+	up      string
+	down    string
+}
+
+// MigrateUp applies every migration that has not been recorded yet.
+func MigrateUp(ctx context.Context, db *sql.DB) error {
+	migrations, applied, err := prepare(ctx, db)
+	if err != nil {
+		return err
+	}
+	for _, m := range migrations {
+		if applied[m.version] {
+			continue
+		}
+		if err := apply(ctx, db, m.up, `INSERT INTO schema_migrations (version) VALUES ($1)`, m.version); err != nil {
+			return fmt.Errorf("migration %d up: %w", m.version, err)
+		}
+	}
+	return nil
+}
+
+// MigrateDown reverts the latest `steps` applied migrations.
+func MigrateDown(ctx context.Context, db *sql.DB, steps int) error {
+	migrations, applied, err := prepare(ctx, db)
+	if err != nil {
+		return err
+	}
+	for i := len(migrations) - 1; i >= 0 && steps > 0; i-- {
+		m := migrations[i]
+		if !applied[m.version] {
+			continue
+		}
+		if err := apply(ctx, db, m.down, `DELETE FROM schema_migrations WHERE version = $1`, m.version); err != nil {
+			return fmt.Errorf("migration %d down: %w", m.version, err)
+		}
+		steps--
+	}
+	return nil
+}
+
+// apply runs a script and its bookkeeping statement in one transaction.
+func apply(ctx context.Context, db *sql.DB, script, record string, version int) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck // no-op after Commit
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, record, version); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func prepare(ctx context.Context, db *sql.DB) ([]migration, map[int]bool, error) {
+	const ddl = `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    BIGINT PRIMARY KEY,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`
+	if _, err := db.ExecContext(ctx, ddl); err != nil {
+		return nil, nil, fmt.Errorf("create schema_migrations: %w", err)
+	}
+
+	migrations, err := loadMigrations()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, `SELECT version FROM schema_migrations`)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]bool)
+	for rows.Next() {
+		var v int
+		if err := rows.Scan(&v); err != nil {
+			return nil, nil, err
+		}
+		applied[v] = true
+	}
+	return migrations, applied, rows.Err()
+}
+
+// loadMigrations pairs NNNN_name.up.sql with NNNN_name.down.sql, ordered by NNNN.
+func loadMigrations() ([]migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*migration)
+	for _, e := range entries {
+		name := e.Name()
+		prefix, _, ok := strings.Cut(name, "_")
+		if !ok {
+			return nil, fmt.Errorf("migration %s: missing version prefix", name)
+		}
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: %w", name, err)
+		}
+		body, err := migrationFiles.ReadFile("migrations/" + name)
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &migration{version: version}
+			byVersion[version] = m
+		}
+		switch {
+		case strings.HasSuffix(name, ".up.sql"):
+			m.up = string(body)
+		case strings.HasSuffix(name, ".down.sql"):
+			m.down = string(body)
+		}
+	}
+
+	migrations := make([]migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].version < migrations[j].version })
+	return migrations, nil
+}
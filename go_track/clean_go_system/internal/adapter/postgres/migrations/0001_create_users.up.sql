@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS users (
+    id         UUID PRIMARY KEY,
+    email      TEXT NOT NULL UNIQUE,
+    username   TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
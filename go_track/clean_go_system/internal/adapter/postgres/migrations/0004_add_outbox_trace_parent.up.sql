@@ -0,0 +1 @@
+ALTER TABLE outbox ADD COLUMN trace_parent TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS user_identities (
+    issuer    TEXT NOT NULL,
+    subject   TEXT NOT NULL,
+    user_id   UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    linked_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (issuer, subject)
+);
+
+CREATE INDEX IF NOT EXISTS user_identities_user_idx ON user_identities (user_id);
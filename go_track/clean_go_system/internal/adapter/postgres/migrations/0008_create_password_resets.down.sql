@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS password_resets;
+DROP TABLE IF EXISTS user_passwords;
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS user_passwords (
+    user_id    UUID PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    hash       BYTEA NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS password_resets (
+    id         UUID PRIMARY KEY,
+    user_id    UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    token_hash BYTEA NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at    TIMESTAMPTZ
+);
@@ -0,0 +1,10 @@
+-- Fails while encrypted rows exist: their plaintext is not recoverable in
+-- SQL, and dropping the ciphertext would lose it.
+ALTER TABLE users
+    ALTER COLUMN email SET NOT NULL,
+    ALTER COLUMN username SET NOT NULL,
+    DROP CONSTRAINT users_email_present,
+    DROP CONSTRAINT users_username_present,
+    DROP COLUMN email_ciphertext,
+    DROP COLUMN email_index,
+    DROP COLUMN username_ciphertext;
@@ -0,0 +1,13 @@
+-- Email and username move to encrypted columns as rows are written with a
+-- key configured; rows from before keep their plaintext until updated.
+-- email_index is a keyed hash of the email and takes over uniqueness.
+ALTER TABLE users
+    ALTER COLUMN email DROP NOT NULL,
+    ALTER COLUMN username DROP NOT NULL,
+    ADD COLUMN email_ciphertext BYTEA,
+    ADD COLUMN email_index BYTEA,
+    ADD COLUMN username_ciphertext BYTEA,
+    ADD CONSTRAINT users_email_present CHECK (email IS NOT NULL OR email_ciphertext IS NOT NULL),
+    ADD CONSTRAINT users_username_present CHECK (username IS NOT NULL OR username_ciphertext IS NOT NULL);
+
+CREATE UNIQUE INDEX IF NOT EXISTS users_email_index_key ON users (email_index);
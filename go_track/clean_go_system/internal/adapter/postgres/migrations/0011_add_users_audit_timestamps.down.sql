@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS users_deleted_at_idx;
+
+ALTER TABLE users
+    DROP COLUMN deleted_at,
+    DROP COLUMN updated_at;
@@ -0,0 +1,11 @@
+-- updated_at starts at created_at for existing rows; deleted_at marks a
+-- soft delete, and the partial index serves the purge job's scan.
+ALTER TABLE users
+    ADD COLUMN updated_at TIMESTAMPTZ,
+    ADD COLUMN deleted_at TIMESTAMPTZ;
+
+UPDATE users SET updated_at = created_at;
+
+ALTER TABLE users ALTER COLUMN updated_at SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS users_deleted_at_idx ON users (deleted_at) WHERE deleted_at IS NOT NULL;
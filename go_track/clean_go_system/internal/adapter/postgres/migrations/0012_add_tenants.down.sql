@@ -0,0 +1,9 @@
+-- Fails if two tenants share an email: merge or delete those users first.
+ALTER TABLE api_keys DROP COLUMN tenant_id;
+
+DROP INDEX IF EXISTS users_tenant_email_index_key;
+CREATE UNIQUE INDEX IF NOT EXISTS users_email_index_key ON users (email_index);
+
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_tenant_email_key;
+ALTER TABLE users ADD CONSTRAINT users_email_key UNIQUE (email);
+ALTER TABLE users DROP COLUMN tenant_id;
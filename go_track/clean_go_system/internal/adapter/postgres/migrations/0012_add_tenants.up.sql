@@ -0,0 +1,10 @@
+-- Users and API keys belong to a tenant; existing rows join the default
+-- one. Emails (and their blind index) become unique per tenant.
+ALTER TABLE users ADD COLUMN tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_email_key;
+ALTER TABLE users ADD CONSTRAINT users_tenant_email_key UNIQUE (tenant_id, email);
+
+DROP INDEX IF EXISTS users_email_index_key;
+CREATE UNIQUE INDEX IF NOT EXISTS users_tenant_email_index_key ON users (tenant_id, email_index);
+
+ALTER TABLE api_keys ADD COLUMN tenant_id TEXT NOT NULL DEFAULT 'default';
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS users_tenant_updated_at_idx;
+DROP INDEX IF EXISTS users_tenant_created_at_idx;
@@ -0,0 +1,4 @@
+-- User listings seek on (tenant_id, <sort column>, id); these keep every
+-- page an index range scan.
+CREATE INDEX IF NOT EXISTS users_tenant_created_at_idx ON users (tenant_id, created_at, id);
+CREATE INDEX IF NOT EXISTS users_tenant_updated_at_idx ON users (tenant_id, updated_at, id);
@@ -0,0 +1,3 @@
+-- verified_at records when a user's email was verified; existing users
+-- start unverified.
+ALTER TABLE users ADD COLUMN verified_at TIMESTAMPTZ;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS email_deliveries_provider_id_idx;
+DROP TABLE IF EXISTS email_suppressions;
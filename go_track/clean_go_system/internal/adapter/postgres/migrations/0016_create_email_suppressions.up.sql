@@ -0,0 +1,10 @@
+-- Addresses that bounced for good or complained. They are kept as a hash
+-- (the PII blind index when configured), never in plaintext.
+CREATE TABLE IF NOT EXISTS email_suppressions (
+    email_hash BYTEA PRIMARY KEY,
+    reason     TEXT NOT NULL,
+    detail     TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS email_deliveries_provider_id_idx ON email_deliveries (provider_id) WHERE provider_id <> '';
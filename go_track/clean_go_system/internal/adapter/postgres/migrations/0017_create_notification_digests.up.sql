@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    user_id    UUID PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    digest     TEXT NOT NULL DEFAULT 'immediate',
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+-- Notifications held for a digest. With PII encryption on, the address
+-- and the body (which may quote addresses) are only kept encrypted.
+CREATE TABLE IF NOT EXISTS digest_items (
+    id               UUID PRIMARY KEY,
+    user_id          UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    email            TEXT,
+    email_ciphertext BYTEA,
+    frequency        TEXT NOT NULL,
+    event            TEXT NOT NULL,
+    subject          TEXT NOT NULL DEFAULT '',
+    body             TEXT,
+    body_ciphertext  BYTEA,
+    created_at       TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS digest_items_pending_idx ON digest_items (frequency, created_at, id);
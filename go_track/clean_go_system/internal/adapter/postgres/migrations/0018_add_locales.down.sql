@@ -0,0 +1,2 @@
+ALTER TABLE digest_items DROP COLUMN IF EXISTS locale;
+ALTER TABLE users DROP COLUMN IF EXISTS locale;
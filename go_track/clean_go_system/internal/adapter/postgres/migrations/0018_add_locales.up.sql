@@ -0,0 +1,2 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS locale TEXT NOT NULL DEFAULT '';
+ALTER TABLE digest_items ADD COLUMN IF NOT EXISTS locale TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS outbox_dead_letters;
+ALTER TABLE outbox DROP COLUMN claimed_until;
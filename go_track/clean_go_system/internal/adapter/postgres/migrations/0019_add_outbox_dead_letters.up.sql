@@ -0,0 +1,17 @@
+-- The relay leases rows while it publishes them, outside any transaction;
+-- a lease left by a crashed relay lapses and the row is tried again.
+ALTER TABLE outbox ADD COLUMN claimed_until TIMESTAMPTZ;
+
+-- Events the relay gave up on after too many attempts, kept to inspect
+-- and replay by hand.
+CREATE TABLE IF NOT EXISTS outbox_dead_letters (
+    id           BIGINT PRIMARY KEY,
+    event_type   TEXT NOT NULL,
+    payload      JSONB NOT NULL,
+    occurred_at  TIMESTAMPTZ NOT NULL,
+    created_at   TIMESTAMPTZ NOT NULL,
+    trace_parent TEXT NOT NULL DEFAULT '',
+    attempts     INT NOT NULL,
+    last_error   TEXT,
+    failed_at    TIMESTAMPTZ NOT NULL DEFAULT now()
+);
@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS user_passwords (
+    user_id    UUID PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    hash       BYTEA NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
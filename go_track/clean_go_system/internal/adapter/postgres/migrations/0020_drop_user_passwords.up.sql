@@ -0,0 +1,2 @@
+-- Passwords are kept by the auth service; nothing read this table.
+DROP TABLE IF EXISTS user_passwords;
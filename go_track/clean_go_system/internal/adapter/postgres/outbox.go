@@ -0,0 +1,40 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"clean_go_system/internal/adapter/eventcodec"
+	"clean_go_system/internal/domain"
+	"clean_go_system/pkg/tracing"
+)
+
+// Outbox implements domain.EventPublisher by inserting events into the
+// outbox table. Called inside Transactor.WithinTransaction, the insert
+// commits atomically with the state change that produced the event;
+// OutboxRelay delivers the rows afterwards. The caller's trace context is
+// stored with the row so the relay can carry it to the broker.
+type Outbox struct {
+	db *sql.DB
+}
+
+func NewOutbox(db *sql.DB) *Outbox {
+	return &Outbox{db: db}
+}
+
+func (o *Outbox) Publish(ctx context.Context, events ...domain.DomainEvent) error {
+	query := `INSERT INTO outbox (event_type, payload, occurred_at, trace_parent) VALUES ($1, $2, $3, $4)`
+	traceParent := tracing.TraceParent(ctx)
+
+	for _, e := range events {
+		payload, err := eventcodec.Encode(ctx, e)
+		if err != nil {
+			return err
+		}
+		if _, err := conn(ctx, o.db).ExecContext(ctx, query, e.EventName(), payload, e.OccurredAt(), traceParent); err != nil {
+			return fmt.Errorf("insert %s into outbox: %w", e.EventName(), err)
+		}
+	}
+	return nil
+}
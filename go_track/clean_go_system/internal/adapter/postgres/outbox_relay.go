@@ -0,0 +1,251 @@
+package postgres
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"slices"
+	"time"
+
+	"clean-code-cookbook/go/pkg/retry"
+	"clean_go_system/internal/adapter/eventcodec"
+	"clean_go_system/internal/domain"
+	"clean_go_system/pkg/clock"
+	"clean_go_system/pkg/tracing"
+)
+
+// OutboxRelay polls the outbox and hands pending events to a publisher
+// (the in-process bus or a broker). A row is marked published only after
+// the publisher accepted it, so delivery is at-least-once: consumers must
+// tolerate duplicates after a crash between publish and marking.
+//
+// Rows are claimed with a short lease and published outside any
+// transaction, so no row lock is held while the publisher talks to the
+// network. SKIP LOCKED and the lease let several instances relay
+// concurrently without delivering the same row twice in the happy path.
+// Concurrent relays can reorder events, though; set Locker to keep a
+// single active relay across instances.
+type OutboxRelay struct {
+	// Locker, when set, serializes batches across instances.
+	Locker domain.Locker
+	// Clock schedules the polling; it defaults to the wall clock.
+	Clock domain.Clock
+	// Retry covers brief publisher hiccups within a batch. A row that still
+	// fails keeps its lease, so it is tried again once Lease has passed
+	// rather than at the head of every batch.
+	Retry retry.Policy
+	// Lease is how long a claimed row is hidden from other batches.
+	Lease time.Duration
+	// MaxAttempts is how many batches may fail to publish a row before it
+	// is moved to outbox_dead_letters, so a poison event cannot hold up
+	// the ones behind it forever.
+	MaxAttempts int
+
+	db        *sql.DB
+	publisher domain.EventPublisher
+	interval  time.Duration
+	batchSize int
+	logger    *log.Logger
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewOutboxRelay(db *sql.DB, publisher domain.EventPublisher, interval time.Duration, batchSize int, logger *log.Logger) *OutboxRelay {
+	return &OutboxRelay{
+		Clock: clock.System,
+		Retry: retry.Policy{
+			Attempts: 3,
+			Backoff:  retry.Jitter(retry.Exponential(50*time.Millisecond, 500*time.Millisecond)),
+		},
+		Lease:       30 * time.Second,
+		MaxAttempts: 10,
+		db:          db,
+		publisher:   publisher,
+		interval:    interval,
+		batchSize:   batchSize,
+		logger:      logger,
+	}
+}
+
+// Start launches the polling loop. It satisfies lifecycle.Component.
+func (r *OutboxRelay) Start(ctx context.Context) error {
+	ctx, r.cancel = context.WithCancel(ctx)
+	r.done = make(chan struct{})
+	go r.loop(ctx)
+	return nil
+}
+
+// Stop finishes the in-flight batch and ends the loop.
+func (r *OutboxRelay) Stop(ctx context.Context) error {
+	if r.cancel == nil {
+		return nil
+	}
+	r.cancel()
+	select {
+	case <-r.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (r *OutboxRelay) loop(ctx context.Context) {
+	defer close(r.done)
+
+	for {
+		// Drain full batches back-to-back; wait only once we are caught up.
+		n, err := r.relayLocked(ctx)
+		if err != nil && ctx.Err() == nil {
+			r.logger.Printf("outbox relay: %v", err)
+		}
+		if n == r.batchSize {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-r.Clock.After(r.interval):
+		}
+	}
+}
+
+// relayLockTTL outlives any sane batch; it only matters if an instance dies
+// while holding the lock.
+const relayLockTTL = 30 * time.Second
+
+// relayLocked runs one batch, under Locker when set. Losing the lock is not
+// an error: another instance is relaying.
+func (r *OutboxRelay) relayLocked(ctx context.Context) (int, error) {
+	if r.Locker == nil {
+		return r.RelayBatch(ctx)
+	}
+	lock, err := r.Locker.Acquire(ctx, "outbox-relay", relayLockTTL)
+	if errors.Is(err, domain.ErrLockHeld) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer lock.Release(context.WithoutCancel(ctx)) //nolint:errcheck // the lease expires anyway
+	return r.RelayBatch(ctx)
+}
+
+type outboxRow struct {
+	id          int64
+	eventType   string
+	payload     []byte
+	traceParent string
+	attempts    int
+}
+
+// RelayBatch claims up to batchSize pending rows, publishes them and
+// returns how many were published. A row that fails has its attempt
+// counted and, past MaxAttempts, is dead-lettered.
+func (r *OutboxRelay) RelayBatch(ctx context.Context) (int, error) {
+	batch, err := r.claim(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	published := 0
+	for _, row := range batch {
+		if err := r.publish(ctx, row); err != nil {
+			r.logger.Printf("outbox relay: event %d (%s): %v", row.id, row.eventType, err)
+			if err := r.fail(ctx, row, err); err != nil {
+				return published, err
+			}
+			continue
+		}
+		if err := r.markPublished(ctx, row); err != nil {
+			return published, err
+		}
+		published++
+	}
+	return published, nil
+}
+
+// claim leases the oldest pending rows no other batch holds, in one
+// statement that commits before anything is published.
+func (r *OutboxRelay) claim(ctx context.Context) ([]outboxRow, error) {
+	rows, err := timedQuerier{r.db}.QueryContext(ctx, `
+		WITH next AS (
+			SELECT id FROM outbox
+			WHERE published_at IS NULL AND (claimed_until IS NULL OR claimed_until < now())
+			ORDER BY id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE outbox SET claimed_until = now() + make_interval(secs => $2)
+		FROM next WHERE outbox.id = next.id
+		RETURNING outbox.id, outbox.event_type, outbox.payload, outbox.trace_parent, outbox.attempts`,
+		r.batchSize, r.Lease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var batch []outboxRow
+	for rows.Next() {
+		var row outboxRow
+		if err := rows.Scan(&row.id, &row.eventType, &row.payload, &row.traceParent, &row.attempts); err != nil {
+			return nil, err
+		}
+		batch = append(batch, row)
+	}
+	// RETURNING keeps no order; publish in the order events were written.
+	slices.SortFunc(batch, func(a, b outboxRow) int { return cmp.Compare(a.id, b.id) })
+	return batch, rows.Err()
+}
+
+func (r *OutboxRelay) markPublished(ctx context.Context, row outboxRow) error {
+	_, err := timedQuerier{r.db}.ExecContext(ctx, `
+		UPDATE outbox SET published_at = now(), attempts = attempts + 1, last_error = NULL, claimed_until = NULL
+		WHERE id = $1`, row.id)
+	return err
+}
+
+// fail counts a failed attempt on row, keeping its lease as the delay
+// before the next one, or moves it to outbox_dead_letters once it has
+// used up MaxAttempts.
+func (r *OutboxRelay) fail(ctx context.Context, row outboxRow, cause error) error {
+	if row.attempts+1 < r.MaxAttempts {
+		_, err := timedQuerier{r.db}.ExecContext(ctx, `UPDATE outbox SET attempts = attempts + 1, last_error = $2 WHERE id = $1`, row.id, cause.Error())
+		return err
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck // no-op after Commit
+	q := timedQuerier{tx}
+	if _, err := q.ExecContext(ctx, `
+		INSERT INTO outbox_dead_letters (id, event_type, payload, occurred_at, created_at, trace_parent, attempts, last_error)
+		SELECT id, event_type, payload, occurred_at, created_at, trace_parent, attempts + 1, $2 FROM outbox WHERE id = $1`,
+		row.id, cause.Error()); err != nil {
+		return err
+	}
+	if _, err := q.ExecContext(ctx, `DELETE FROM outbox WHERE id = $1`, row.id); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	r.logger.Printf("outbox relay: event %d (%s) dead-lettered after %d attempts", row.id, row.eventType, row.attempts+1)
+	return nil
+}
+
+func (r *OutboxRelay) publish(ctx context.Context, row outboxRow) error {
+	event, id, err := eventcodec.Decode(ctx, row.eventType, row.payload)
+	if err != nil {
+		return err
+	}
+	if row.traceParent != "" {
+		ctx = tracing.WithTraceParent(ctx, row.traceParent)
+	}
+	return retry.Do(ctx, r.Retry, func(ctx context.Context) error {
+		return eventcodec.Forward(ctx, r.publisher, id, event)
+	})
+}
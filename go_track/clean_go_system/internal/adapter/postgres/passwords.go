@@ -0,0 +1,64 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"clean_go_system/internal/domain"
+	"github.com/google/uuid"
+)
+
+// PasswordResetRepository implements domain.PasswordResetRepository on the
+// password_resets table.
+type PasswordResetRepository struct {
+	db *sql.DB
+}
+
+func NewPasswordResetRepository(db *sql.DB) *PasswordResetRepository {
+	return &PasswordResetRepository{db: db}
+}
+
+func (r *PasswordResetRepository) Save(ctx context.Context, reset domain.PasswordReset) error {
+	query := `INSERT INTO password_resets (id, user_id, token_hash, expires_at) VALUES ($1, $2, $3, $4)`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, reset.ID, reset.UserID, reset.Hash, reset.ExpiresAt)
+	return err
+}
+
+func (r *PasswordResetRepository) Get(ctx context.Context, id uuid.UUID) (*domain.PasswordReset, error) {
+	query := `SELECT id, user_id, token_hash, expires_at, used_at FROM password_resets WHERE id = $1`
+
+	var (
+		reset domain.PasswordReset
+		used  sql.NullTime
+	)
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, id).Scan(&reset.ID, &reset.UserID, &reset.Hash, &reset.ExpiresAt, &used)
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrInvalidResetToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	if used.Valid {
+		reset.UsedAt = &used.Time
+	}
+	return &reset, nil
+}
+
+func (r *PasswordResetRepository) MarkUsed(ctx context.Context, id uuid.UUID, at time.Time) error {
+	query := `UPDATE password_resets SET used_at = $2 WHERE id = $1 AND used_at IS NULL`
+
+	res, err := conn(ctx, r.db).ExecContext(ctx, query, id, at)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return domain.ErrInvalidResetToken
+	}
+	return nil
+}
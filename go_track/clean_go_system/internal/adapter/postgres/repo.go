@@ -3,37 +3,352 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"clean-code-cookbook/go/pkg/page"
+	"clean_go_system/internal/adapter/fieldcrypt"
 	"clean_go_system/internal/domain"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+// PostgresRepository stores, lists, streams and purges users. With PII set, email and username are
+// written encrypted and email is found through its blind index; rows
+// written before that stay readable and are encrypted when next updated.
 type PostgresRepository struct {
-	db *sql.DB
+	// PII encrypts personal fields; nil stores them in plaintext.
+	PII *fieldcrypt.Codec
+	// Unprepared sends the lookups and writes as plain queries instead of
+	// prepared statements, for poolers such as PgBouncer in transaction
+	// mode, which hand each transaction a different server connection.
+	Unprepared bool
+
+	db    *sql.DB
+	stmts *statements
 }
 
 func NewPostgresRepository(db *sql.DB) *PostgresRepository {
-	return &PostgresRepository{db: db}
+	return &PostgresRepository{db: db, stmts: newStatements(db)}
 }
 
+// prepared returns the querier for the hot queries, the ones by key and
+// the writes: prepared statements unless Unprepared.
+func (r *PostgresRepository) prepared(ctx context.Context) querier {
+	if r.Unprepared {
+		return conn(ctx, r.db)
+	}
+	return r.stmts.conn(ctx)
+}
+
+const userColumns = `id, tenant_id, email, username, email_ciphertext, username_ciphertext, locale, active, created_at, updated_at, verified_at, deleted_at`
+
 func (r *PostgresRepository) Save(ctx context.Context, u domain.User) error {
-	query := `INSERT INTO users (id, email, username, created_at) VALUES ($1, $2, $3, $4)`
-	
+	f, err := r.encode(ctx, u)
+	if err != nil {
+		return err
+	}
+	query := `INSERT INTO users (id, tenant_id, email, username, email_ciphertext, email_index, username_ciphertext, locale, active, created_at, updated_at, verified_at, deleted_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`
+
 	// ExecContext is crucial for handling timeouts/cancellations
-	_, err := r.db.ExecContext(ctx, query, u.ID, u.Email, u.Username, u.CreatedAt)
-	return err
+	_, err = r.prepared(ctx).ExecContext(ctx, query, u.ID, domain.TenantOf(ctx), f.email, f.username, f.emailCiphertext, f.emailIndex, f.usernameCiphertext, u.Locale, u.Active, u.CreatedAt, updatedAt(u), u.VerifiedAt, u.DeletedAt)
+	return mapError(err)
+}
+
+func (r *PostgresRepository) Update(ctx context.Context, u domain.User) error {
+	f, err := r.encode(ctx, u)
+	if err != nil {
+		return err
+	}
+	query := `UPDATE users SET email = $2, username = $3, email_ciphertext = $4, email_index = $5, username_ciphertext = $6, active = $7,
+		updated_at = $8, verified_at = $9, deleted_at = $10, locale = $12
+		WHERE id = $1 AND tenant_id = $11`
+
+	res, err := r.prepared(ctx).ExecContext(ctx, query, u.ID, f.email, f.username, f.emailCiphertext, f.emailIndex, f.usernameCiphertext, u.Active, updatedAt(u), u.VerifiedAt, u.DeletedAt, domain.TenantOf(ctx), u.Locale)
+	if err != nil {
+		return mapError(err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return domain.ErrUserNotFound
+	}
+	return nil
 }
 
 func (r *PostgresRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
-	query := `SELECT id, email, username, created_at FROM users WHERE email = $1`
-	
-	row := r.db.QueryRowContext(ctx, query, email)
-	
-	var u domain.User
-	err := row.Scan(&u.ID, &u.Email, &u.Username, &u.CreatedAt)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, domain.ErrUserNotFound
+	if r.PII == nil {
+		query := `SELECT ` + userColumns + ` FROM users WHERE email = $1 AND tenant_id = $2` + notDeleted(ctx)
+		return r.getOne(ctx, query, email, domain.TenantOf(ctx))
+	}
+	index, err := r.PII.BlindIndex(ctx, "users.email", email)
+	if err != nil {
+		return nil, err
+	}
+	query := `SELECT ` + userColumns + ` FROM users WHERE (email_index = $1 OR email = $2) AND tenant_id = $3` + notDeleted(ctx)
+	return r.getOne(ctx, query, index, email, domain.TenantOf(ctx))
+}
+
+func (r *PostgresRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE id = $1 AND tenant_id = $2` + notDeleted(ctx)
+	return r.getOne(ctx, query, id, domain.TenantOf(ctx))
+}
+
+func (r *PostgresRepository) GetByIDs(ctx context.Context, ids []uuid.UUID) ([]domain.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE id = ANY($1) AND tenant_id = $2` + notDeleted(ctx)
+
+	rows, err := r.prepared(ctx).QueryContext(ctx, query, pq.Array(ids), domain.TenantOf(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	users := make([]domain.User, 0, len(ids))
+	for rows.Next() {
+		u, err := r.scan(ctx, rows)
+		if err != nil {
+			return nil, err
 		}
+		users = append(users, *u)
+	}
+	return users, rows.Err()
+}
+
+// ListUsers pages by keyset on the sort column and id, so a page costs the
+// same however deep into the listing it is.
+func (r *PostgresRepository) ListUsers(ctx context.Context, req page.Request) (page.Page[domain.User], error) {
+	after, resume, err := domain.ParseUserCursor(req.After, req.Sort)
+	if err != nil {
+		return page.Page[domain.User]{}, err
+	}
+	column, dir, op := sortColumn(req.Sort)
+	query := `SELECT ` + userColumns + ` FROM users WHERE tenant_id = $1` + notDeleted(ctx)
+	args := []any{domain.TenantOf(ctx)}
+	if resume {
+		query += ` AND (` + column + `, id) ` + op + ` ($2, $3)`
+		args = append(args, after.At, after.ID)
+	}
+	query += ` ORDER BY ` + column + dir + `, id` + dir
+	if req.Size > 0 {
+		// One more, to tell whether there is a next page.
+		query += fmt.Sprintf(` LIMIT %d`, req.Size+1)
+	}
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return page.Page[domain.User]{}, err
+	}
+	defer rows.Close()
+	var users []domain.User
+	for rows.Next() {
+		u, err := r.scan(ctx, rows)
+		if err != nil {
+			return page.Page[domain.User]{}, err
+		}
+		users = append(users, *u)
+	}
+	if err := rows.Err(); err != nil {
+		return page.Page[domain.User]{}, err
+	}
+	var next page.Cursor
+	if req.Size > 0 && len(users) > req.Size {
+		users = users[:req.Size]
+		next = domain.CursorAfter(users[req.Size-1], req.Sort)
+	}
+	return page.Page[domain.User]{Items: users, Next: next}, nil
+}
+
+func (r *PostgresRepository) PurgeDeleted(ctx context.Context, cutoff time.Time) (int, error) {
+	res, err := conn(ctx, r.db).ExecContext(ctx, `DELETE FROM users WHERE deleted_at < $1`, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+// notDeleted is the filter that hides soft-deleted rows, unless ctx asks
+// for them.
+func notDeleted(ctx context.Context) string {
+	if domain.IncludesDeleted(ctx) {
+		return ""
+	}
+	return ` AND deleted_at IS NULL`
+}
+
+// updatedAt falls back to CreatedAt for users built without UpdatedAt.
+func updatedAt(u domain.User) time.Time {
+	if u.UpdatedAt.IsZero() {
+		return u.CreatedAt
+	}
+	return u.UpdatedAt
+}
+
+// sortColumn maps s onto a column, its ORDER BY direction and the keyset
+// comparison that resumes after a row. Only known columns come out, so
+// they are safe to splice into SQL.
+// streamBatch is how many rows StreamUsers fetches per round trip.
+const streamBatch = 500
+
+// StreamUsers reads through a server-side cursor, in ctx's transaction or
+// a read-only one of its own. Each batch is read in full before fn sees
+// it, so fn never waits on an open result set.
+func (r *PostgresRepository) StreamUsers(ctx context.Context, fn func(domain.User) error) error {
+	if _, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return r.stream(ctx, fn)
+	}
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck // no-op after Commit
+	if err := r.stream(context.WithValue(ctx, txKey{}, tx), fn); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *PostgresRepository) stream(ctx context.Context, fn func(domain.User) error) error {
+	q := conn(ctx, r.db)
+	query := `DECLARE users_stream NO SCROLL CURSOR FOR
+		SELECT ` + userColumns + ` FROM users WHERE tenant_id = $1` + notDeleted(ctx) + ` ORDER BY created_at, id`
+	if _, err := q.ExecContext(ctx, query, domain.TenantOf(ctx)); err != nil {
+		return err
+	}
+	batch := make([]domain.User, 0, streamBatch)
+	for {
+		rows, err := q.QueryContext(ctx, fmt.Sprintf(`FETCH %d FROM users_stream`, streamBatch))
+		if err != nil {
+			return err
+		}
+		batch = batch[:0]
+		for rows.Next() {
+			u, err := r.scan(ctx, rows)
+			if err != nil {
+				rows.Close()
+				return err
+			}
+			batch = append(batch, *u)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		for _, u := range batch {
+			if err := fn(u); err != nil {
+				return err
+			}
+		}
+		if len(batch) < streamBatch {
+			break
+		}
+	}
+	_, err := q.ExecContext(ctx, `CLOSE users_stream`)
+	return err
+}
+
+func sortColumn(s page.Sort) (column, dir, op string) {
+	column, dir, op = "created_at", "", ">"
+	if s.Field == "updated_at" {
+		column = "updated_at"
+	}
+	if s.Desc {
+		dir, op = " DESC", "<"
+	}
+	return column, dir, op
+}
+
+func (r *PostgresRepository) getOne(ctx context.Context, query string, args ...any) (*domain.User, error) {
+	u, err := r.scan(ctx, r.prepared(ctx).QueryRowContext(ctx, query, args...))
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrUserNotFound
+	}
+	return u, err
+}
+
+// scan reads one row of userColumns, decrypting the personal fields.
+func (r *PostgresRepository) scan(ctx context.Context, row interface{ Scan(...any) error }) (*domain.User, error) {
+	var (
+		u                   domain.User
+		email, username     sql.NullString
+		emailCt, usernameCt []byte
+		verifiedAt          sql.NullTime
+		deletedAt           sql.NullTime
+	)
+	err := row.Scan(&u.ID, &u.TenantID, &email, &username, &emailCt, &usernameCt, &u.Locale, &u.Active, &u.CreatedAt, &u.UpdatedAt, &verifiedAt, &deletedAt)
+	if err != nil {
+		return nil, err
+	}
+	if verifiedAt.Valid {
+		u.VerifiedAt = &verifiedAt.Time
+	}
+	if deletedAt.Valid {
+		u.DeletedAt = &deletedAt.Time
+	}
+	if u.Email, err = r.decrypt(ctx, email, emailCt, "users.email", u.ID); err != nil {
+		return nil, err
+	}
+	if u.Username, err = r.decrypt(ctx, username, usernameCt, "users.username", u.ID); err != nil {
 		return nil, err
 	}
 	return &u, nil
-}
\ No newline at end of file
+}
+
+// userFields are the personal columns of a row: plaintext without PII,
+// ciphertext and index with it.
+type userFields struct {
+	email, username                     sql.NullString
+	emailCiphertext, usernameCiphertext []byte
+	emailIndex                          []byte
+}
+
+func (r *PostgresRepository) encode(ctx context.Context, u domain.User) (userFields, error) {
+	if r.PII == nil {
+		return userFields{
+			email:    sql.NullString{String: u.Email, Valid: true},
+			username: sql.NullString{String: u.Username, Valid: true},
+		}, nil
+	}
+	var f userFields
+	var err error
+	if f.emailCiphertext, err = r.PII.Encrypt(ctx, u.Email, fieldAAD("users.email", u.ID)); err != nil {
+		return f, err
+	}
+	if f.usernameCiphertext, err = r.PII.Encrypt(ctx, u.Username, fieldAAD("users.username", u.ID)); err != nil {
+		return f, err
+	}
+	f.emailIndex, err = r.PII.BlindIndex(ctx, "users.email", u.Email)
+	return f, err
+}
+
+func (r *PostgresRepository) decrypt(ctx context.Context, plain sql.NullString, ciphertext []byte, field string, id uuid.UUID) (string, error) {
+	if ciphertext == nil {
+		return plain.String, nil
+	}
+	if r.PII == nil {
+		return "", fmt.Errorf("%s of user %s is encrypted and no key is configured", field, id)
+	}
+	v, err := r.PII.Decrypt(ctx, ciphertext, fieldAAD(field, id))
+	if err != nil {
+		return "", fmt.Errorf("decrypt %s of user %s: %w", field, id, err)
+	}
+	return v, nil
+}
+
+// fieldAAD ties a ciphertext to its column and row.
+func fieldAAD(field string, id uuid.UUID) []byte {
+	return append([]byte(field+":"), id[:]...)
+}
+
+// mapError turns the per-tenant email (or email_index) unique violation
+// (SQLSTATE 23505) into ErrUserExists.
+func mapError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return domain.ErrUserExists
+	}
+	return err
+}
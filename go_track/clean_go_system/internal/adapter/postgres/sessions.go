@@ -0,0 +1,101 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"clean_go_system/internal/domain"
+	"github.com/google/uuid"
+)
+
+// SessionRepository implements domain.SessionRepository on the sessions
+// table, looking sessions up by the unique token_hash column.
+type SessionRepository struct {
+	db *sql.DB
+}
+
+func NewSessionRepository(db *sql.DB) *SessionRepository {
+	return &SessionRepository{db: db}
+}
+
+const sessionColumns = `id, user_id, token_hash, user_agent, ip, created_at, last_seen_at, expires_at, revoked_at`
+
+func (r *SessionRepository) Save(ctx context.Context, s domain.Session) error {
+	query := `INSERT INTO sessions (id, user_id, token_hash, user_agent, ip, created_at, last_seen_at, expires_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, s.ID, s.UserID, s.Hash, s.UserAgent, s.IP, s.CreatedAt, s.LastSeenAt, s.ExpiresAt)
+	return err
+}
+
+func (r *SessionRepository) GetByHash(ctx context.Context, hash []byte) (*domain.Session, error) {
+	query := `SELECT ` + sessionColumns + ` FROM sessions WHERE token_hash = $1`
+
+	s, err := scanSession(conn(ctx, r.db).QueryRowContext(ctx, query, hash))
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrSessionNotFound
+	}
+	return s, err
+}
+
+func (r *SessionRepository) Touch(ctx context.Context, id uuid.UUID, lastSeen, expires time.Time) error {
+	query := `UPDATE sessions SET last_seen_at = $2, expires_at = $3 WHERE id = $1`
+
+	return r.exec(ctx, query, id, lastSeen, expires)
+}
+
+func (r *SessionRepository) ListByUser(ctx context.Context, userID uuid.UUID, now time.Time) ([]domain.Session, error) {
+	query := `SELECT ` + sessionColumns + ` FROM sessions
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > $2
+		ORDER BY last_seen_at DESC`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, userID, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []domain.Session
+	for rows.Next() {
+		s, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *s)
+	}
+	return out, rows.Err()
+}
+
+func (r *SessionRepository) Revoke(ctx context.Context, id uuid.UUID, at time.Time) error {
+	query := `UPDATE sessions SET revoked_at = COALESCE(revoked_at, $2) WHERE id = $1`
+
+	return r.exec(ctx, query, id, at)
+}
+
+func (r *SessionRepository) exec(ctx context.Context, query string, args ...any) error {
+	res, err := conn(ctx, r.db).ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return domain.ErrSessionNotFound
+	}
+	return nil
+}
+
+func scanSession(row interface{ Scan(...any) error }) (*domain.Session, error) {
+	var (
+		s       domain.Session
+		revoked sql.NullTime
+	)
+	if err := row.Scan(&s.ID, &s.UserID, &s.Hash, &s.UserAgent, &s.IP, &s.CreatedAt, &s.LastSeenAt, &s.ExpiresAt, &revoked); err != nil {
+		return nil, err
+	}
+	if revoked.Valid {
+		s.RevokedAt = &revoked.Time
+	}
+	return &s, nil
+}
@@ -0,0 +1,99 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+)
+
+// statements prepares queries the first time they run and reuses them.
+// database/sql prepares a statement on each pooled connection the first
+// time it runs there, so after warm-up a query is one round trip (bind
+// and execute) instead of lib/pq's parse, describe, bind and execute.
+type statements struct {
+	db *sql.DB
+
+	mu      sync.Mutex
+	byQuery map[string]*sql.Stmt
+}
+
+func newStatements(db *sql.DB) *statements {
+	return &statements{db: db, byQuery: make(map[string]*sql.Stmt)}
+}
+
+// get returns the statement for query, preparing it if no caller has yet.
+func (s *statements) get(ctx context.Context, query string) (*sql.Stmt, error) {
+	s.mu.Lock()
+	stmt, ok := s.byQuery[query]
+	s.mu.Unlock()
+	if ok {
+		return stmt, nil
+	}
+	// Prepared outside the lock, so a slow prepare holds up only the
+	// callers of its own query; the loser of a race closes its copy.
+	stmt, err := s.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if existing, ok := s.byQuery[query]; ok {
+		_ = stmt.Close()
+		return existing, nil
+	}
+	s.byQuery[query] = stmt
+	return stmt, nil
+}
+
+// conn is like the package's conn, but runs queries as prepared
+// statements, joining the transaction carried by ctx if there is one.
+func (s *statements) conn(ctx context.Context) querier {
+	p := preparedQuerier{stmts: s, direct: s.db}
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		p.tx, p.direct = tx, tx
+	}
+	return timedQuerier{p}
+}
+
+// preparedQuerier runs each query through its prepared statement. A query
+// that fails to prepare runs directly instead, so its error is the one
+// the database gives for running it.
+type preparedQuerier struct {
+	stmts  *statements
+	tx     *sql.Tx
+	direct querier
+}
+
+func (p preparedQuerier) stmt(ctx context.Context, query string) (*sql.Stmt, bool) {
+	stmt, err := p.stmts.get(ctx, query)
+	if err != nil {
+		return nil, false
+	}
+	if p.tx != nil {
+		// Closed with the transaction; reuses the statement already
+		// prepared on the transaction's connection, if any.
+		stmt = p.tx.StmtContext(ctx, stmt)
+	}
+	return stmt, true
+}
+
+func (p preparedQuerier) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	if stmt, ok := p.stmt(ctx, query); ok {
+		return stmt.ExecContext(ctx, args...)
+	}
+	return p.direct.ExecContext(ctx, query, args...)
+}
+
+func (p preparedQuerier) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	if stmt, ok := p.stmt(ctx, query); ok {
+		return stmt.QueryContext(ctx, args...)
+	}
+	return p.direct.QueryContext(ctx, query, args...)
+}
+
+func (p preparedQuerier) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	if stmt, ok := p.stmt(ctx, query); ok {
+		return stmt.QueryRowContext(ctx, args...)
+	}
+	return p.direct.QueryRowContext(ctx, query, args...)
+}
@@ -0,0 +1,74 @@
+package postgres
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+
+	"clean_go_system/internal/adapter/fieldcrypt"
+	"clean_go_system/internal/domain"
+)
+
+// SuppressionList implements domain.SuppressionList on the
+// email_suppressions table. Addresses are stored as a hash, so the table
+// cannot be read back as a list of addresses.
+type SuppressionList struct {
+	// PII, if set, keys the hash with the blind index key, so it cannot
+	// be matched against guessed addresses without that key either.
+	// Suppressions made before it was set no longer match.
+	PII *fieldcrypt.Codec
+
+	db *sql.DB
+}
+
+func NewSuppressionList(db *sql.DB) *SuppressionList {
+	return &SuppressionList{db: db}
+}
+
+func (l *SuppressionList) Suppress(ctx context.Context, s domain.Suppression) error {
+	hash, err := l.hash(ctx, s.Email)
+	if err != nil {
+		return err
+	}
+	query := `INSERT INTO email_suppressions (email_hash, reason, detail, created_at) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (email_hash) DO UPDATE SET reason = EXCLUDED.reason, detail = EXCLUDED.detail, created_at = EXCLUDED.created_at`
+
+	_, err = conn(ctx, l.db).ExecContext(ctx, query, hash, s.Reason, s.Detail, s.At)
+	return err
+}
+
+func (l *SuppressionList) Suppressed(ctx context.Context, email string) (*domain.Suppression, error) {
+	hash, err := l.hash(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	query := `SELECT reason, detail, created_at FROM email_suppressions WHERE email_hash = $1`
+
+	s := domain.Suppression{Email: email}
+	err = conn(ctx, l.db).QueryRowContext(ctx, query, hash).Scan(&s.Reason, &s.Detail, &s.At)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (l *SuppressionList) Lift(ctx context.Context, email string) error {
+	hash, err := l.hash(ctx, email)
+	if err != nil {
+		return err
+	}
+	_, err = conn(ctx, l.db).ExecContext(ctx, `DELETE FROM email_suppressions WHERE email_hash = $1`, hash)
+	return err
+}
+
+func (l *SuppressionList) hash(ctx context.Context, email string) ([]byte, error) {
+	email = domain.NormalizeEmail(email)
+	if l.PII != nil {
+		return l.PII.BlindIndex(ctx, "email_suppressions.email", email)
+	}
+	sum := sha256.Sum256([]byte(email))
+	return sum[:], nil
+}
@@ -0,0 +1,56 @@
+package rabbitmq
+
+import (
+	"fmt"
+	"sync"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// Conn is an AMQP connection that is redialed when the broker drops it.
+// Publishers and consumers take channels from it rather than from a
+// connection, so they recover once the broker is back.
+type Conn struct {
+	url string
+
+	mu     sync.Mutex
+	conn   *amqp.Connection
+	closed bool
+}
+
+// Dial connects to url; later connections are dialed on demand.
+func Dial(url string) (*Conn, error) {
+	conn, err := amqp.Dial(url)
+	if err != nil {
+		return nil, err
+	}
+	return &Conn{url: url, conn: conn}, nil
+}
+
+// Channel opens a channel, redialing first if the connection was lost.
+func (c *Conn) Channel() (*amqp.Channel, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil, amqp.ErrClosed
+	}
+	if c.conn.IsClosed() {
+		conn, err := amqp.Dial(c.url)
+		if err != nil {
+			return nil, fmt.Errorf("redial: %w", err)
+		}
+		c.conn = conn
+	}
+	return c.conn.Channel()
+}
+
+// Close closes the connection for good; Channel fails from then on.
+func (c *Conn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	if c.conn.IsClosed() {
+		return nil
+	}
+	return c.conn.Close()
+}
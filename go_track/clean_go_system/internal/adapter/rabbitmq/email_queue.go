@@ -0,0 +1,284 @@
+package rabbitmq
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"clean-code-cookbook/go/pkg/retry"
+	"clean_go_system/internal/core"
+	"clean_go_system/internal/domain"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// EmailQueue is an AMQP-backed core.EmailQueue. Jobs are persistent
+// messages on a durable queue, so they survive broker restarts and can be
+// consumed by any number of worker processes.
+type EmailQueue struct {
+	conn  *Conn
+	queue string
+
+	mu      sync.Mutex // amqp channels are not safe for concurrent publishing
+	publish *amqp.Channel
+}
+
+// NewEmailQueue declares the queue and opens a publishing channel in
+// confirm mode.
+func NewEmailQueue(conn *Conn, queue string) (*EmailQueue, error) {
+	ch, err := openPublisher(conn, queue)
+	if err != nil {
+		return nil, err
+	}
+	return &EmailQueue{conn: conn, queue: queue, publish: ch}, nil
+}
+
+func openPublisher(conn *Conn, queue string) (*amqp.Channel, error) {
+	ch, err := conn.Channel()
+	if err != nil {
+		return nil, fmt.Errorf("open channel: %w", err)
+	}
+	if _, err := ch.QueueDeclare(queue, true, false, false, false, nil); err != nil {
+		return nil, fmt.Errorf("declare queue %s: %w", queue, err)
+	}
+	if err := ch.Confirm(false); err != nil {
+		return nil, fmt.Errorf("enable publisher confirms: %w", err)
+	}
+	return ch, nil
+}
+
+// Enqueue publishes job and waits for the broker's confirm, so a nil error
+// means the job is safely stored. A publishing channel the broker closed
+// is reopened first, redialing if the connection went with it.
+func (q *EmailQueue) Enqueue(ctx context.Context, job core.EmailJob) error {
+	body, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+
+	q.mu.Lock()
+	if q.publish.IsClosed() {
+		ch, err := openPublisher(q.conn, q.queue)
+		if err != nil {
+			q.mu.Unlock()
+			return fmt.Errorf("reopen publisher: %w", err)
+		}
+		q.publish = ch
+	}
+	confirm, err := q.publish.PublishWithDeferredConfirmWithContext(ctx, "", q.queue, false, false, amqp.Publishing{
+		ContentType:  "application/json",
+		DeliveryMode: amqp.Persistent,
+		Body:         body,
+	})
+	q.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("publish email job: %w", err)
+	}
+
+	acked, err := confirm.WaitContext(ctx)
+	if err != nil {
+		return fmt.Errorf("await confirm: %w", err)
+	}
+	if !acked {
+		// A nack means the queue is at its max-length with reject-publish.
+		return fmt.Errorf("broker rejected email job: %w", core.ErrQueueFull)
+	}
+	return nil
+}
+
+// Close releases the publishing channel.
+func (q *EmailQueue) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.publish.IsClosed() {
+		return nil
+	}
+	return q.publish.Close()
+}
+
+// Consumer processes email jobs from the queue. Prefetch bounds how many
+// unacknowledged jobs a process holds, so work spreads across processes.
+// A failed (or panicking) job is requeued once; if it fails again after
+// redelivery it is rejected (and dead-lettered, if the queue has a DLX).
+// A job whose email the provider rejected for good is rejected at once.
+//
+// When the broker closes the channel or drops the connection, the
+// consumer resubscribes on a fresh channel, backing off between attempts,
+// until it is stopped. Jobs in flight at the time are redelivered.
+type Consumer struct {
+	// Reconnect paces the attempts to resubscribe; by default it keeps
+	// trying, backing off up to 30 seconds between attempts.
+	Reconnect retry.Policy
+
+	conn     *Conn
+	queue    string
+	prefetch int
+	workers  int
+	handle   func(ctx context.Context, job core.EmailJob) error
+	logger   *log.Logger
+
+	mu      sync.Mutex
+	ch      *amqp.Channel
+	stopped bool
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+func NewConsumer(conn *Conn, queue string, prefetch, workers int, handle func(ctx context.Context, job core.EmailJob) error, logger *log.Logger) *Consumer {
+	c := &Consumer{
+		conn:     conn,
+		queue:    queue,
+		prefetch: prefetch,
+		workers:  workers,
+		handle:   handle,
+		logger:   logger,
+	}
+	c.Reconnect = retry.Policy{
+		Attempts: math.MaxInt,
+		Backoff:  retry.Jitter(retry.Exponential(time.Second, 30*time.Second)),
+		OnRetry: func(attempt int, err error, wait time.Duration) {
+			c.logger.Printf("rabbitmq: resubscribe attempt %d failed, retrying in %s: %v", attempt, wait, err)
+		},
+	}
+	return c
+}
+
+// Start begins consuming. It fails if the first subscription does; later
+// ones are retried. It satisfies lifecycle.Component.
+func (c *Consumer) Start(ctx context.Context) error {
+	ch, deliveries, err := c.subscribe()
+	if err != nil {
+		return err
+	}
+	c.ch = ch
+
+	ctx, c.cancel = context.WithCancel(ctx)
+	c.wg.Add(1)
+	go c.run(ctx, ch, deliveries)
+	return nil
+}
+
+func (c *Consumer) subscribe() (*amqp.Channel, <-chan amqp.Delivery, error) {
+	ch, err := c.conn.Channel()
+	if err != nil {
+		return nil, nil, fmt.Errorf("open channel: %w", err)
+	}
+	if err := ch.Qos(c.prefetch, 0, false); err != nil {
+		ch.Close()
+		return nil, nil, fmt.Errorf("set prefetch: %w", err)
+	}
+	deliveries, err := ch.Consume(c.queue, "", false, false, false, false, nil)
+	if err != nil {
+		ch.Close()
+		return nil, nil, fmt.Errorf("consume %s: %w", c.queue, err)
+	}
+	return ch, deliveries, nil
+}
+
+// run feeds deliveries to the workers and, once the channel closes,
+// resubscribes until ctx ends.
+func (c *Consumer) run(ctx context.Context, ch *amqp.Channel, deliveries <-chan amqp.Delivery) {
+	defer c.wg.Done()
+	for {
+		closed := ch.NotifyClose(make(chan *amqp.Error, 1))
+		var workers sync.WaitGroup
+		for i := 0; i < c.workers; i++ {
+			workers.Add(1)
+			go func() {
+				defer workers.Done()
+				c.work(ctx, deliveries)
+			}()
+		}
+		workers.Wait()
+
+		// Deliveries also end when the broker cancels the consumer, say
+		// because the queue was deleted; close the channel to start over.
+		_ = ch.Close()
+		reason := <-closed
+		if ctx.Err() != nil {
+			return
+		}
+		c.logger.Printf("rabbitmq: consumer channel closed, resubscribing: %v", reason)
+
+		err := retry.Do(ctx, c.Reconnect, func(context.Context) error {
+			var err error
+			ch, deliveries, err = c.subscribe()
+			return err
+		})
+		if err != nil {
+			c.logger.Printf("rabbitmq: gave up resubscribing to %s: %v", c.queue, err)
+			return
+		}
+		if !c.swap(ch) {
+			_ = ch.Close()
+			return
+		}
+		c.logger.Printf("rabbitmq: resubscribed to %s", c.queue)
+	}
+}
+
+// swap makes ch the channel Stop closes, unless Stop already ran.
+func (c *Consumer) swap(ch *amqp.Channel) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.stopped {
+		return false
+	}
+	c.ch = ch
+	return true
+}
+
+// Stop closes the channel; unacknowledged jobs return to the queue.
+func (c *Consumer) Stop(ctx context.Context) error {
+	c.mu.Lock()
+	ch := c.ch
+	c.stopped = true
+	c.mu.Unlock()
+	if ch == nil {
+		return nil
+	}
+	c.cancel()
+	var err error
+	if !ch.IsClosed() {
+		err = ch.Close()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		c.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *Consumer) work(ctx context.Context, deliveries <-chan amqp.Delivery) {
+	for d := range deliveries {
+		var job core.EmailJob
+		if err := json.Unmarshal(d.Body, &job); err != nil {
+			c.logger.Printf("rabbitmq: rejecting malformed job: %v", err)
+			_ = d.Nack(false, false)
+			continue
+		}
+
+		if err := core.RunJob(ctx, job, c.handle); err != nil {
+			requeue := !d.Redelivered && !errors.Is(err, domain.ErrEmailRejected)
+			c.logger.Printf("rabbitmq: job for %s failed (requeue=%v): %v", job.Email, requeue, err)
+			var panicked *core.PanicError
+			if errors.As(err, &panicked) {
+				c.logger.Printf("rabbitmq: job for %s panic stack:\n%s", job.Email, panicked.Stack)
+			}
+			_ = d.Nack(false, requeue)
+			continue
+		}
+		_ = d.Ack(false)
+	}
+}
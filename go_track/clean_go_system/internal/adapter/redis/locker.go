@@ -0,0 +1,50 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"clean_go_system/internal/domain"
+	"github.com/google/uuid"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// releaseScript deletes the key only while it still holds our token, so a
+// lease that expired and was taken over is not released by its old owner.
+var releaseScript = goredis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+// Locker implements domain.Locker with SET NX PX on a single Redis node.
+type Locker struct {
+	client goredis.UniversalClient
+}
+
+func NewLocker(client goredis.UniversalClient) *Locker {
+	return &Locker{client: client}
+}
+
+func (l *Locker) Acquire(ctx context.Context, key string, ttl time.Duration) (domain.Lock, error) {
+	token := uuid.NewString()
+	ok, err := l.client.SetNX(ctx, "lock:"+key, token, ttl).Result()
+	if err != nil {
+		return nil, fmt.Errorf("acquire lock %s: %w", key, err)
+	}
+	if !ok {
+		return nil, domain.ErrLockHeld
+	}
+	return &lock{client: l.client, key: "lock:" + key, token: token}, nil
+}
+
+type lock struct {
+	client goredis.UniversalClient
+	key    string
+	token  string
+}
+
+func (l *lock) Release(ctx context.Context) error {
+	return releaseScript.Run(ctx, l.client, []string{l.key}, l.token).Err()
+}
@@ -0,0 +1,55 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"clean_go_system/internal/domain"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// gcraScript runs one GCRA step atomically on the server, timed by the
+// server clock so replicas with skewed clocks agree. KEYS[1] holds the
+// theoretical arrival time in microseconds; ARGV are the limit's interval
+// and tolerance in microseconds. It returns 0 when allowed, otherwise the
+// microseconds to wait.
+var gcraScript = goredis.NewScript(`
+redis.replicate_commands()
+local t = redis.call("TIME")
+local now = tonumber(t[1]) * 1000000 + tonumber(t[2])
+local interval = tonumber(ARGV[1])
+local tolerance = tonumber(ARGV[2])
+local tat = tonumber(redis.call("GET", KEYS[1])) or now
+if tat < now then
+	tat = now
+end
+local wait = tat - now - tolerance
+if wait > 0 then
+	return wait
+end
+local new_tat = tat + interval
+redis.call("SET", KEYS[1], new_tat, "PX", math.ceil((new_tat - now) / 1000))
+return 0`)
+
+// RateLimiter implements domain.RateLimiter with GCRA on a single Redis
+// node: one key and one round trip per check, whatever the limit.
+type RateLimiter struct {
+	client goredis.UniversalClient
+}
+
+func NewRateLimiter(client goredis.UniversalClient) *RateLimiter {
+	return &RateLimiter{client: client}
+}
+
+func (l *RateLimiter) Allow(ctx context.Context, key string, limit domain.RateLimit) (bool, time.Duration, error) {
+	wait, err := gcraScript.Run(ctx, l.client, []string{"ratelimit:" + key},
+		limit.Interval().Microseconds(), limit.Tolerance().Microseconds()).Int64()
+	if err != nil {
+		return false, 0, fmt.Errorf("rate limit %s: %w", key, err)
+	}
+	if wait > 0 {
+		return false, time.Duration(wait) * time.Microsecond, nil
+	}
+	return true, 0, nil
+}
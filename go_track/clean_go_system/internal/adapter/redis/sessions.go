@@ -0,0 +1,219 @@
+package redis
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"clean_go_system/internal/domain"
+	"github.com/google/uuid"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// Session keys: a hash per session, a pointer from token hash to session
+// ID, and a set of session IDs per user. All of them expire with the
+// session, so Redis forgets sessions nobody uses.
+func sessionKey(id uuid.UUID) string      { return "session:" + id.String() }
+func sessionHashKey(hash []byte) string   { return "session:hash:" + hex.EncodeToString(hash) }
+func userSessionsKey(id uuid.UUID) string { return "sessions:user:" + id.String() }
+
+// extendScript pushes the expiry of KEYS[1] out to ARGV[1] (unix ms), never
+// pulling it in, so a user's set outlives every session in it.
+const extendScript = `
+redis.replicate_commands()
+local function extend(key, at)
+	local ttl = redis.call("PTTL", key)
+	if ttl < 0 or redis.call("TIME")[1] * 1000 + ttl < at then
+		redis.call("PEXPIREAT", key, at)
+	end
+end
+`
+
+// saveSessionScript writes KEYS[1] (session), KEYS[2] (hash pointer) and
+// adds ARGV[1] (the ID) to KEYS[3] (user set); ARGV[2] is the expiry in
+// unix ms and the rest are field/value pairs.
+var saveSessionScript = goredis.NewScript(extendScript + `
+local at = tonumber(ARGV[2])
+redis.call("HSET", KEYS[1], unpack(ARGV, 3))
+redis.call("PEXPIREAT", KEYS[1], at)
+redis.call("SET", KEYS[2], ARGV[1], "PXAT", at)
+redis.call("SADD", KEYS[3], ARGV[1])
+extend(KEYS[3], at)
+return 1`)
+
+// touchSessionScript updates last use and expiry of KEYS[1] if it still
+// exists, moving its hash pointer and KEYS[2] (user set) along. ARGV are
+// last_seen_at, expires_at and the expiry in unix ms.
+var touchSessionScript = goredis.NewScript(extendScript + `
+if redis.call("EXISTS", KEYS[1]) == 0 then
+	return 0
+end
+local at = tonumber(ARGV[3])
+redis.call("HSET", KEYS[1], "last_seen_at", ARGV[1], "expires_at", ARGV[2])
+redis.call("PEXPIREAT", KEYS[1], at)
+redis.call("PEXPIREAT", "session:hash:" .. redis.call("HGET", KEYS[1], "hash"), at)
+extend(KEYS[2], at)
+return 1`)
+
+// revokeSessionScript stamps KEYS[1] revoked at ARGV[1] unless it already
+// is. The session stays until it expires so a stolen token keeps failing.
+var revokeSessionScript = goredis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 0 then
+	return 0
+end
+redis.call("HSETNX", KEYS[1], "revoked_at", ARGV[1])
+return 1`)
+
+// SessionRepository implements domain.SessionRepository on Redis hashes.
+// Sessions vanish when they expire, which is all ListByUser needs.
+type SessionRepository struct {
+	client goredis.UniversalClient
+}
+
+func NewSessionRepository(client goredis.UniversalClient) *SessionRepository {
+	return &SessionRepository{client: client}
+}
+
+func (r *SessionRepository) Save(ctx context.Context, s domain.Session) error {
+	args := []any{
+		s.ID.String(), s.ExpiresAt.UnixMilli(),
+		"user_id", s.UserID.String(),
+		"hash", hex.EncodeToString(s.Hash),
+		"user_agent", s.UserAgent,
+		"ip", s.IP,
+		"created_at", formatTime(s.CreatedAt),
+		"last_seen_at", formatTime(s.LastSeenAt),
+		"expires_at", formatTime(s.ExpiresAt),
+	}
+	keys := []string{sessionKey(s.ID), sessionHashKey(s.Hash), userSessionsKey(s.UserID)}
+	return saveSessionScript.Run(ctx, r.client, keys, args...).Err()
+}
+
+func (r *SessionRepository) GetByHash(ctx context.Context, hash []byte) (*domain.Session, error) {
+	raw, err := r.client.Get(ctx, sessionHashKey(hash)).Result()
+	if errors.Is(err, goredis.Nil) {
+		return nil, domain.ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	id, err := uuid.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("decode %s: %w", sessionHashKey(hash), err)
+	}
+	return r.get(ctx, id)
+}
+
+func (r *SessionRepository) Touch(ctx context.Context, id uuid.UUID, lastSeen, expires time.Time) error {
+	s, err := r.get(ctx, id)
+	if err != nil {
+		return err
+	}
+	keys := []string{sessionKey(id), userSessionsKey(s.UserID)}
+	ok, err := touchSessionScript.Run(ctx, r.client, keys, formatTime(lastSeen), formatTime(expires), expires.UnixMilli()).Bool()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return domain.ErrSessionNotFound
+	}
+	return nil
+}
+
+func (r *SessionRepository) ListByUser(ctx context.Context, userID uuid.UUID, now time.Time) ([]domain.Session, error) {
+	ids, err := r.client.SMembers(ctx, userSessionsKey(userID)).Result()
+	if err != nil {
+		return nil, err
+	}
+	pipe := r.client.Pipeline()
+	cmds := make([]*goredis.MapStringStringCmd, len(ids))
+	for i, id := range ids {
+		cmds[i] = pipe.HGetAll(ctx, "session:"+id)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	var out []domain.Session
+	var gone []any
+	for i, cmd := range cmds {
+		if len(cmd.Val()) == 0 {
+			gone = append(gone, ids[i]) // expired since it was added
+			continue
+		}
+		s, err := decodeSession(ids[i], cmd.Val())
+		if err != nil {
+			return nil, err
+		}
+		if s.Active(now) {
+			out = append(out, *s)
+		}
+	}
+	if len(gone) > 0 {
+		_ = r.client.SRem(ctx, userSessionsKey(userID), gone...).Err() // best effort
+	}
+	slices.SortFunc(out, func(a, b domain.Session) int { return b.LastSeenAt.Compare(a.LastSeenAt) })
+	return out, nil
+}
+
+func (r *SessionRepository) Revoke(ctx context.Context, id uuid.UUID, at time.Time) error {
+	ok, err := revokeSessionScript.Run(ctx, r.client, []string{sessionKey(id)}, formatTime(at)).Bool()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return domain.ErrSessionNotFound
+	}
+	return nil
+}
+
+func (r *SessionRepository) get(ctx context.Context, id uuid.UUID) (*domain.Session, error) {
+	fields, err := r.client.HGetAll(ctx, sessionKey(id)).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return nil, domain.ErrSessionNotFound
+	}
+	return decodeSession(id.String(), fields)
+}
+
+func decodeSession(id string, fields map[string]string) (*domain.Session, error) {
+	var s domain.Session
+	var err error
+	parse := func(dst *time.Time, field string) {
+		if err == nil {
+			*dst, err = time.Parse(time.RFC3339Nano, fields[field])
+		}
+	}
+	if s.ID, err = uuid.Parse(id); err != nil {
+		return nil, fmt.Errorf("decode session %s: %w", id, err)
+	}
+	if s.UserID, err = uuid.Parse(fields["user_id"]); err != nil {
+		return nil, fmt.Errorf("decode session %s: %w", id, err)
+	}
+	if s.Hash, err = hex.DecodeString(fields["hash"]); err != nil {
+		return nil, fmt.Errorf("decode session %s: %w", id, err)
+	}
+	s.UserAgent, s.IP = fields["user_agent"], fields["ip"]
+	parse(&s.CreatedAt, "created_at")
+	parse(&s.LastSeenAt, "last_seen_at")
+	parse(&s.ExpiresAt, "expires_at")
+	if v, ok := fields["revoked_at"]; ok && err == nil {
+		var at time.Time
+		if at, err = time.Parse(time.RFC3339Nano, v); err == nil {
+			s.RevokedAt = &at
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("decode session %s: %w", id, err)
+	}
+	return &s, nil
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
@@ -0,0 +1,92 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"clean-code-cookbook/go/pkg/cacheaside"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// Store implements cacheaside.Store by storing values as JSON with a TTL.
+type Store[V any] struct {
+	client goredis.UniversalClient
+	ttl    time.Duration
+}
+
+func NewStore[V any](client goredis.UniversalClient, ttl time.Duration) *Store[V] {
+	return &Store[V]{client: client, ttl: ttl}
+}
+
+// NewClient parses a redis:// URL with short timeouts: a slow cache must
+// cost a request a few milliseconds, never seconds.
+func NewClient(url string) (*goredis.Client, error) {
+	opts, err := goredis.ParseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("parse redis url: %w", err)
+	}
+	opts.DialTimeout = 200 * time.Millisecond
+	opts.ReadTimeout = 100 * time.Millisecond
+	opts.WriteTimeout = 100 * time.Millisecond
+	opts.MaxRetries = 0
+	return goredis.NewClient(opts), nil
+}
+
+func (s *Store[V]) Get(ctx context.Context, key string) (V, error) {
+	var v V
+	raw, err := s.client.Get(ctx, key).Bytes()
+	if errors.Is(err, goredis.Nil) {
+		return v, cacheaside.ErrMiss
+	}
+	if err != nil {
+		return v, err
+	}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return v, fmt.Errorf("decode cached %s: %w", key, err)
+	}
+	return v, nil
+}
+
+func (s *Store[V]) Set(ctx context.Context, key string, value V) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("encode %s: %w", key, err)
+	}
+	return s.client.Set(ctx, key, raw, s.ttl).Err()
+}
+
+func (s *Store[V]) Delete(ctx context.Context, keys ...string) error {
+	return s.client.Del(ctx, keys...).Err()
+}
+
+// DeletePrefix scans for the keys rather than using KEYS, so a large
+// keyspace does not block the server; keys written during the scan may
+// survive it.
+func (s *Store[V]) DeletePrefix(ctx context.Context, prefix string) (int, error) {
+	n := 0
+	iter := s.client.Scan(ctx, 0, prefix+"*", 500).Iterator()
+	batch := make([]string, 0, 500)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		deleted, err := s.client.Del(ctx, batch...).Result()
+		n += int(deleted)
+		batch = batch[:0]
+		return err
+	}
+	for iter.Next(ctx) {
+		if batch = append(batch, iter.Val()); len(batch) == cap(batch) {
+			if err := flush(); err != nil {
+				return n, err
+			}
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return n, err
+	}
+	return n, flush()
+}
@@ -0,0 +1,84 @@
+// Package s3 implements domain.BlobStore on any S3-compatible object store
+// (AWS S3, MinIO, R2, ...).
+package s3
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"clean_go_system/internal/domain"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// partSize bounds memory for uploads of unknown size: the client buffers one
+// part at a time. Its default for size -1 is 512 MiB per upload.
+const partSize = 16 << 20
+
+type BlobStore struct {
+	client *minio.Client
+	bucket string
+}
+
+// NewBlobStore connects to endpoint (host[:port], no scheme) with static
+// credentials. The bucket must already exist.
+func NewBlobStore(endpoint, accessKey, secretKey, bucket string, useSSL bool) (*BlobStore, error) {
+	client, err := minio.New(endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(accessKey, secretKey, ""),
+		Secure: useSSL,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create s3 client: %w", err)
+	}
+	return &BlobStore{client: client, bucket: bucket}, nil
+}
+
+func (s *BlobStore) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	opts := minio.PutObjectOptions{ContentType: contentType}
+	if size < 0 {
+		opts.PartSize = partSize
+	}
+	if _, err := s.client.PutObject(ctx, s.bucket, key, r, size, opts); err != nil {
+		return fmt.Errorf("put %s: %w", key, err)
+	}
+	return nil
+}
+
+// Get stats the object first, so a missing key fails here with
+// domain.ErrBlobNotFound rather than on the first Read.
+func (s *BlobStore) Get(ctx context.Context, key string) (io.ReadCloser, domain.BlobInfo, error) {
+	obj, err := s.client.GetObject(ctx, s.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, domain.BlobInfo{}, mapError(key, err)
+	}
+	stat, err := obj.Stat()
+	if err != nil {
+		obj.Close()
+		return nil, domain.BlobInfo{}, mapError(key, err)
+	}
+	return obj, domain.BlobInfo{Size: stat.Size, ContentType: stat.ContentType, ModTime: stat.LastModified}, nil
+}
+
+func (s *BlobStore) Delete(ctx context.Context, key string) error {
+	if err := s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{}); err != nil {
+		return mapError(key, err)
+	}
+	return nil
+}
+
+func (s *BlobStore) SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	u, err := s.client.PresignedGetObject(ctx, s.bucket, key, expiry, nil)
+	if err != nil {
+		return "", fmt.Errorf("presign %s: %w", key, err)
+	}
+	return u.String(), nil
+}
+
+func mapError(key string, err error) error {
+	if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+		return domain.ErrBlobNotFound
+	}
+	return fmt.Errorf("%s: %w", key, err)
+}
@@ -0,0 +1,36 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+)
+
+// DedupStore implements eventbus.DedupStore on the processed_events table.
+type DedupStore struct {
+	db *sql.DB
+}
+
+func NewDedupStore(db *sql.DB) *DedupStore {
+	return &DedupStore{db: db}
+}
+
+func (s *DedupStore) Claim(ctx context.Context, consumer, id string) (bool, error) {
+	query := `INSERT OR IGNORE INTO processed_events (consumer, event_id) VALUES (?, ?)`
+
+	res, err := conn(ctx, s.db).ExecContext(ctx, query, consumer, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+func (s *DedupStore) Release(ctx context.Context, consumer, id string) error {
+	query := `DELETE FROM processed_events WHERE consumer = ? AND event_id = ?`
+
+	_, err := conn(ctx, s.db).ExecContext(ctx, query, consumer, id)
+	return err
+}
@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS processed_events (
+    consumer     TEXT NOT NULL,
+    event_id     TEXT NOT NULL,
+    processed_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (consumer, event_id)
+);
@@ -0,0 +1,4 @@
+ALTER TABLE users ADD COLUMN updated_at DATETIME;
+ALTER TABLE users ADD COLUMN deleted_at DATETIME;
+UPDATE users SET updated_at = created_at;
+CREATE INDEX IF NOT EXISTS users_deleted_at_idx ON users (deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,19 @@
+-- Emails become unique per tenant. SQLite cannot drop the column's UNIQUE
+-- constraint in place, so the table is rebuilt; existing users join the
+-- default tenant.
+CREATE TABLE users_new (
+    id         TEXT PRIMARY KEY,
+    tenant_id  TEXT NOT NULL DEFAULT 'default',
+    email      TEXT NOT NULL,
+    username   TEXT NOT NULL,
+    active     BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME,
+    deleted_at DATETIME,
+    UNIQUE (tenant_id, email)
+);
+INSERT INTO users_new (id, email, username, active, created_at, updated_at, deleted_at)
+    SELECT id, email, username, active, created_at, updated_at, deleted_at FROM users;
+DROP TABLE users;
+ALTER TABLE users_new RENAME TO users;
+CREATE INDEX IF NOT EXISTS users_deleted_at_idx ON users (deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1 @@
+ALTER TABLE users ADD COLUMN verified_at DATETIME;
@@ -0,0 +1,30 @@
+// Package sqlite stores users in a local SQLite file, so the service runs on
+// a laptop without Postgres. It uses the pure-Go modernc driver (no cgo).
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	_ "modernc.org/sqlite" // registers the "sqlite" driver
+)
+
+// Open opens dsn (e.g. "file:clean_go_system.db") with foreign keys on, WAL
+// journaling and a busy timeout. SQLite allows one writer at a time, so the
+// pool is limited to one connection: writers queue in Go instead of failing
+// with SQLITE_BUSY.
+func Open(dsn string) (*sql.DB, error) {
+	sep := "?"
+	if strings.Contains(dsn, "?") {
+		sep = "&"
+	}
+	dsn += sep + "_pragma=foreign_keys(1)&_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)"
+
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open sqlite: %w", err)
+	}
+	db.SetMaxOpenConns(1)
+	return db, nil
+}
@@ -0,0 +1,219 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"clean-code-cookbook/go/pkg/page"
+	"clean_go_system/internal/domain"
+	"github.com/google/uuid"
+)
+
+// UserRepository implements domain.UserRepository, domain.UserLister,
+// domain.UserBatchReader, domain.UserStreamer and domain.UserPurger.
+type UserRepository struct {
+	db *sql.DB
+}
+
+func NewUserRepository(db *sql.DB) *UserRepository {
+	return &UserRepository{db: db}
+}
+
+func (r *UserRepository) Save(ctx context.Context, u domain.User) error {
+	query := `INSERT INTO users (id, tenant_id, email, username, locale, active, created_at, updated_at, verified_at, deleted_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, u.ID.String(), domain.TenantOf(ctx), u.Email, u.Username, u.Locale, u.Active, u.CreatedAt.UTC(), updatedAt(u), utc(u.VerifiedAt), utc(u.DeletedAt))
+	return mapError(err)
+}
+
+func (r *UserRepository) Update(ctx context.Context, u domain.User) error {
+	query := `UPDATE users SET email = ?, username = ?, locale = ?, active = ?, updated_at = ?, verified_at = ?, deleted_at = ? WHERE id = ? AND tenant_id = ?`
+
+	res, err := conn(ctx, r.db).ExecContext(ctx, query, u.Email, u.Username, u.Locale, u.Active, updatedAt(u), utc(u.VerifiedAt), utc(u.DeletedAt), u.ID.String(), domain.TenantOf(ctx))
+	if err != nil {
+		return mapError(err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return domain.ErrUserNotFound
+	}
+	return nil
+}
+
+func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE email = ? AND tenant_id = ?` + notDeleted(ctx)
+	return r.getOne(ctx, query, email, domain.TenantOf(ctx))
+}
+
+func (r *UserRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE id = ? AND tenant_id = ?` + notDeleted(ctx)
+	return r.getOne(ctx, query, id.String(), domain.TenantOf(ctx))
+}
+
+func (r *UserRepository) GetByIDs(ctx context.Context, ids []uuid.UUID) ([]domain.User, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	args := []any{domain.TenantOf(ctx)}
+	for _, id := range ids {
+		args = append(args, id.String())
+	}
+	query := `SELECT ` + userColumns + ` FROM users WHERE tenant_id = ? AND id IN (?` + strings.Repeat(`, ?`, len(ids)-1) + `)` + notDeleted(ctx)
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	users := make([]domain.User, 0, len(ids))
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, *u)
+	}
+	return users, rows.Err()
+}
+
+// ListUsers pages by keyset on the sort column and id, so a page costs the
+// same however deep into the listing it is.
+func (r *UserRepository) ListUsers(ctx context.Context, req page.Request) (page.Page[domain.User], error) {
+	after, resume, err := domain.ParseUserCursor(req.After, req.Sort)
+	if err != nil {
+		return page.Page[domain.User]{}, err
+	}
+	column, dir, op := sortColumn(req.Sort)
+	query := `SELECT ` + userColumns + ` FROM users WHERE tenant_id = ?` + notDeleted(ctx)
+	args := []any{domain.TenantOf(ctx)}
+	if resume {
+		query += ` AND (` + column + `, id) ` + op + ` (?, ?)`
+		args = append(args, after.At.UTC(), after.ID.String())
+	}
+	query += ` ORDER BY ` + column + dir + `, id` + dir + ` LIMIT ?`
+	limit := -1 // no limit
+	if req.Size > 0 {
+		limit = req.Size + 1 // one more, to tell whether there is a next page
+	}
+	args = append(args, limit)
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return page.Page[domain.User]{}, err
+	}
+	defer rows.Close()
+	var users []domain.User
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return page.Page[domain.User]{}, err
+		}
+		users = append(users, *u)
+	}
+	if err := rows.Err(); err != nil {
+		return page.Page[domain.User]{}, err
+	}
+	var next page.Cursor
+	if req.Size > 0 && len(users) > req.Size {
+		users = users[:req.Size]
+		next = domain.CursorAfter(users[req.Size-1], req.Sort)
+	}
+	return page.Page[domain.User]{Items: users, Next: next}, nil
+}
+
+// StreamUsers pages through ListUsers, 500 users at a time.
+func (r *UserRepository) StreamUsers(ctx context.Context, fn func(domain.User) error) error {
+	return page.Each(ctx, page.Request{Size: 500}, r.ListUsers, fn)
+}
+
+func (r *UserRepository) PurgeDeleted(ctx context.Context, cutoff time.Time) (int, error) {
+	res, err := conn(ctx, r.db).ExecContext(ctx, `DELETE FROM users WHERE deleted_at < ?`, cutoff.UTC())
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+const userColumns = `id, tenant_id, email, username, locale, active, created_at, updated_at, verified_at, deleted_at`
+
+// notDeleted is the filter that hides soft-deleted rows, unless ctx asks
+// for them.
+func notDeleted(ctx context.Context) string {
+	if domain.IncludesDeleted(ctx) {
+		return ""
+	}
+	return ` AND deleted_at IS NULL`
+}
+
+// updatedAt falls back to CreatedAt for users built without UpdatedAt.
+func updatedAt(u domain.User) time.Time {
+	if u.UpdatedAt.IsZero() {
+		return u.CreatedAt.UTC()
+	}
+	return u.UpdatedAt.UTC()
+}
+
+// utc stores optional timestamps in UTC, like the others.
+func utc(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	at := t.UTC()
+	return &at
+}
+
+// sortColumn maps s onto a column, its ORDER BY direction and the keyset
+// comparison that resumes after a row. Only known columns come out, so
+// they are safe to splice into SQL.
+func sortColumn(s page.Sort) (column, dir, op string) {
+	column, dir, op = "created_at", "", ">"
+	if s.Field == "updated_at" {
+		column = "updated_at"
+	}
+	if s.Desc {
+		dir, op = " DESC", "<"
+	}
+	return column, dir, op
+}
+
+func (r *UserRepository) getOne(ctx context.Context, query string, args ...any) (*domain.User, error) {
+	u, err := scanUser(conn(ctx, r.db).QueryRowContext(ctx, query, args...))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrUserNotFound
+	}
+	return u, err
+}
+
+// scanUser reads one row of userColumns.
+func scanUser(row interface{ Scan(...any) error }) (*domain.User, error) {
+	var (
+		u                 domain.User
+		verified, deleted sql.NullTime
+	)
+	err := row.Scan(&u.ID, &u.TenantID, &u.Email, &u.Username, &u.Locale, &u.Active, &u.CreatedAt, &u.UpdatedAt, &verified, &deleted)
+	if err != nil {
+		return nil, err
+	}
+	if verified.Valid {
+		u.VerifiedAt = &verified.Time
+	}
+	if deleted.Valid {
+		u.DeletedAt = &deleted.Time
+	}
+	return &u, nil
+}
+
+// mapError turns the users (tenant_id, email) UNIQUE violation into ErrUserExists.
+func mapError(err error) error {
+	if err != nil && strings.Contains(err.Error(), "UNIQUE constraint failed") {
+		return domain.ErrUserExists
+	}
+	return err
+}
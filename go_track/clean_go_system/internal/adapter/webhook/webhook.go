@@ -0,0 +1,146 @@
+// Package webhook carries domain events over plain HTTP: Publisher POSTs
+// them to subscriber URLs, Receiver accepts them from other services. Both
+// directions are signed with pkg/signing, so a receiver can tell our
+// deliveries from forgeries and replays.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"clean-code-cookbook/go/pkg/retry"
+	"clean-code-cookbook/go/pkg/signing"
+	"clean_go_system/internal/adapter/eventcodec"
+	"clean_go_system/internal/domain"
+	"clean_go_system/pkg/tracing"
+)
+
+// EventTypeHeader names the event in a delivery, as on the brokers.
+const EventTypeHeader = "X-Event-Type"
+
+// Publisher implements domain.EventPublisher by POSTing each event, in the
+// eventcodec envelope, to every URL.
+type Publisher struct {
+	// Client sends deliveries; it defaults to one with a 5s timeout.
+	Client *http.Client
+	// Retry covers network errors and 5xx answers of one delivery.
+	Retry retry.Policy
+
+	urls []string
+	keys *signing.Keyring
+}
+
+func NewPublisher(urls []string, keys *signing.Keyring) *Publisher {
+	return &Publisher{
+		Client: &http.Client{Timeout: 5 * time.Second},
+		Retry: retry.Policy{
+			Attempts: 3,
+			Backoff:  retry.Jitter(retry.Exponential(200*time.Millisecond, 2*time.Second)),
+		},
+		urls: urls,
+		keys: keys,
+	}
+}
+
+// Publish delivers every event to every URL and returns the first error.
+func (p *Publisher) Publish(ctx context.Context, events ...domain.DomainEvent) error {
+	for _, e := range events {
+		body, err := eventcodec.Encode(ctx, e)
+		if err != nil {
+			return err
+		}
+		if err := p.deliverAll(ctx, e.EventName(), body); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Forward delivers e under the ID it already has, so receivers can
+// deduplicate the relay's retries.
+func (p *Publisher) Forward(ctx context.Context, id string, e domain.DomainEvent) error {
+	body, err := eventcodec.EncodeWithID(ctx, e, id)
+	if err != nil {
+		return err
+	}
+	return p.deliverAll(ctx, e.EventName(), body)
+}
+
+func (p *Publisher) deliverAll(ctx context.Context, eventType string, body []byte) error {
+	for _, url := range p.urls {
+		err := retry.Do(ctx, p.Retry, func(ctx context.Context) error {
+			return p.deliver(ctx, url, eventType, body)
+		})
+		if err != nil {
+			return fmt.Errorf("webhook %s: %w", url, err)
+		}
+	}
+	return nil
+}
+
+func (p *Publisher) deliver(ctx context.Context, url, eventType string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventTypeHeader, eventType)
+	if tp := tracing.TraceParent(ctx); tp != "" {
+		req.Header.Set(tracing.Header, tp)
+	}
+	// Signed last and on each attempt, so retries carry a fresh timestamp.
+	p.keys.SignRequest(req, body)
+
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("delivery answered %s", resp.Status)
+	}
+	return nil
+}
+
+// Receiver is an inbound adapter: it verifies signed deliveries from other
+// services and dispatches them to local handlers through sink, like the
+// NATS subscriber does for the broker.
+type Receiver struct {
+	keys   *signing.Keyring
+	sink   domain.EventPublisher
+	logger *log.Logger
+}
+
+func NewReceiver(keys *signing.Keyring, sink domain.EventPublisher, logger *log.Logger) *Receiver {
+	return &Receiver{keys: keys, sink: sink, logger: logger}
+}
+
+// Handler answers 204 once sink accepted the event, 400 for undecodable
+// events and 401 for bad signatures. A 5xx asks the sender to retry.
+func (rc *Receiver) Handler() http.Handler {
+	return rc.keys.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "unreadable body", http.StatusBadRequest)
+			return
+		}
+		event, id, err := eventcodec.Decode(r.Context(), r.Header.Get(EventTypeHeader), body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := eventcodec.Forward(r.Context(), rc.sink, id, event); err != nil {
+			rc.logger.Printf("webhook: %s handlers failed: %v", event.EventName(), err)
+			http.Error(w, "try again later", http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+}
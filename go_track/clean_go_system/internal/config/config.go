@@ -0,0 +1,981 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"clean-code-cookbook/go/pkg/signing"
+	"clean_go_system/internal/domain"
+	"clean_go_system/pkg/faults"
+	"clean_go_system/pkg/scheduler"
+)
+
+// Config is the full application configuration.
+// Static settings are read once at boot; Dynamic settings may be reloaded.
+type Config struct {
+	Profile        Profile `json:"-"`
+	HTTPAddr       string  `json:"http_addr"`
+	DatabaseDriver string  `json:"database_driver"`
+	DatabaseURL    string  `json:"database_url"`
+	EmailWorkers   int     `json:"email_workers"`
+	EmailQueueSize int     `json:"email_queue_size"`
+
+	// DatabaseUnprepared turns off the Postgres user repository's prepared
+	// statements, for PgBouncer in transaction mode.
+	DatabaseUnprepared bool `json:"database_unprepared"`
+
+	// StartupAttempts bounds how often each dependency is probed at boot.
+	StartupAttempts int `json:"startup_attempts"`
+	// StartupDegraded lets the service start while optional dependencies
+	// (upstreams) are unreachable; the database is always required.
+	StartupDegraded bool `json:"startup_degraded"`
+	// Upstreams maps a name to the host:port of a service this one calls;
+	// each is probed at startup.
+	Upstreams map[string]string `json:"upstreams"`
+
+	// AdminAddr serves the operator endpoints, /debug/vars, on a listener
+	// of their own that should not be reachable from outside; empty turns
+	// them off.
+	AdminAddr string `json:"admin_addr"`
+
+	TLS TLS `json:"tls"`
+
+	// KafkaBrokers enables publishing domain events to KafkaTopic.
+	KafkaBrokers []string `json:"kafka_brokers"`
+	KafkaTopic   string   `json:"kafka_topic"`
+
+	// NATSURL enables consuming user events from NATSSubject as a member
+	// of the NATSQueue group.
+	NATSURL     string `json:"nats_url"`
+	NATSSubject string `json:"nats_subject"`
+	NATSQueue   string `json:"nats_queue"`
+
+	// AMQPURL moves email jobs from the in-memory pool to a RabbitMQ queue
+	// shared by all worker processes.
+	AMQPURL      string `json:"amqp_url"`
+	AMQPQueue    string `json:"amqp_queue"`
+	AMQPPrefetch int    `json:"amqp_prefetch"`
+
+	// RedisURL puts a read-through cache in front of user lookups; entries
+	// expire after CacheTTLSeconds.
+	RedisURL        string `json:"redis_url"`
+	CacheTTLSeconds int    `json:"cache_ttl_seconds"`
+
+	// StaleTTLSeconds keeps last-known-good users in memory for that long,
+	// to answer lookups while the database fails. Zero disables it.
+	StaleTTLSeconds int `json:"stale_ttl_seconds"`
+
+	// DeletedRetentionSeconds is how long soft-deleted users are kept
+	// before the purge job removes them for good. Zero never purges.
+	DeletedRetentionSeconds int `json:"deleted_retention_seconds"`
+	// PurgeSchedule is the cron expression the purge job runs on.
+	PurgeSchedule string `json:"purge_schedule"`
+
+	// MaxBodyBytes caps every request body; larger ones get 413.
+	MaxBodyBytes int `json:"max_body_bytes"`
+
+	// OpenAPIValidate checks requests against the OpenAPI document served
+	// at /openapi.json before they reach the handlers. It is off in every
+	// profile; set OPENAPI_VALIDATE to opt in.
+	OpenAPIValidate bool `json:"openapi_validate"`
+
+	// RequestTimeoutMS is each HTTP request's deadline; downstream calls get
+	// a share of what is left of it. Zero disables it.
+	RequestTimeoutMS int `json:"request_timeout_ms"`
+
+	// Shedding rejects HTTP requests beyond these thresholds with 503.
+	Shedding Shedding `json:"shedding"`
+
+	// Compression is which responses of the list, search and export
+	// endpoints are sent compressed.
+	Compression Compression `json:"compression"`
+
+	// GraphQL bounds what one query to /graphql may ask for.
+	GraphQL GraphQL `json:"graphql"`
+
+	// Catalog is the catalog service the product queries read from.
+	Catalog Catalog `json:"catalog"`
+
+	// Bulkheads caps concurrent calls per dependency.
+	Bulkheads Bulkheads `json:"bulkheads"`
+
+	// Auth configures bearer tokens and OIDC login.
+	Auth Auth `json:"auth"`
+
+	// RateLimits caps expensive endpoints per caller.
+	RateLimits RateLimits `json:"rate_limits"`
+
+	// Tenancy serves several isolated customers from one deployment.
+	Tenancy Tenancy `json:"tenancy"`
+
+	// Webhooks delivers events to, and accepts them from, other services
+	// over signed HTTP.
+	Webhooks Webhooks `json:"webhooks"`
+
+	// PII encrypts users' personal fields at rest.
+	PII PII `json:"pii"`
+
+	// Notifications tells users about events on their account.
+	Notifications Notifications `json:"notifications"`
+
+	// Uploads stores avatars and product images.
+	Uploads Uploads `json:"uploads"`
+
+	// Email chooses how email jobs are sent.
+	Email Email `json:"email"`
+
+	// PolicyFile, if set, is a JSON array of authorization policies
+	// (pkg/policy) added to the built-in ones.
+	PolicyFile string `json:"policy_file"`
+
+	// APIKeysRequired makes /register demand an API key with the
+	// users:write scope, for deployments only other services call.
+	APIKeysRequired bool `json:"api_keys_required"`
+
+	// ChaosEnabled turns on fault injection: Dynamic.Chaos and per-request
+	// X-Chaos-* headers. It is refused in the prod profile.
+	ChaosEnabled bool `json:"chaos_enabled"`
+
+	// File is the optional JSON file the config was read from (CONFIG_FILE).
+	File string `json:"-"`
+
+	Dynamic Dynamic `json:"dynamic"`
+}
+
+// TLS configures HTTPS termination. Either a cert/key pair or a list of
+// autocert (ACME) domains enables it; both empty means plaintext HTTP.
+type TLS struct {
+	CertFile         string   `json:"cert_file"`
+	KeyFile          string   `json:"key_file"`
+	AutocertDomains  []string `json:"autocert_domains"`
+	AutocertCacheDir string   `json:"autocert_cache_dir"`
+	// RedirectAddr, if set, serves a plaintext HTTP→HTTPS redirect there.
+	RedirectAddr string `json:"redirect_addr"`
+	// ClientCAFile turns on mutual TLS: callers must present a certificate
+	// issued by this CA and, if ClientNames is set, carrying one of those
+	// DNS or URI SANs.
+	ClientCAFile string   `json:"client_ca_file"`
+	ClientNames  []string `json:"client_names"`
+}
+
+// MutualTLS reports whether callers must present a client certificate.
+func (t TLS) MutualTLS() bool {
+	return t.ClientCAFile != ""
+}
+
+// Enabled reports whether the HTTP server should speak TLS.
+func (t TLS) Enabled() bool {
+	return t.CertFile != "" || len(t.AutocertDomains) > 0
+}
+
+// Autocert reports whether certificates come from an ACME CA.
+func (t TLS) Autocert() bool {
+	return len(t.AutocertDomains) > 0
+}
+
+func (t TLS) validate() error {
+	if (t.CertFile == "") != (t.KeyFile == "") {
+		return fmt.Errorf("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+	if t.CertFile != "" && t.Autocert() {
+		return fmt.Errorf("TLS_CERT_FILE and TLS_AUTOCERT_DOMAINS are mutually exclusive")
+	}
+	if t.MutualTLS() && t.CertFile == "" {
+		return fmt.Errorf("TLS_CLIENT_CA_FILE requires TLS_CERT_FILE and TLS_KEY_FILE")
+	}
+	return nil
+}
+
+// Shedding configures the HTTP load shedder. MaxInFlight of 0 disables
+// it; MaxP99MS of 0 keeps the in-flight limit fixed instead of halving it
+// while the p99 latency is above that.
+type Shedding struct {
+	MaxInFlight int `json:"max_in_flight"`
+	MaxP99MS    int `json:"max_p99_ms"`
+}
+
+// Compression configures response compression: bodies of at least
+// MinBytes and one of Types go out as zstd or gzip, as the client accepts.
+// An empty Types turns it off.
+type Compression struct {
+	MinBytes int      `json:"min_bytes"`
+	Types    []string `json:"types"`
+}
+
+// GraphQL limits /graphql operations: fields nested at most MaxDepth deep
+// and a cost of at most MaxComplexity, where a list of users counts once
+// per user. Introspection serves the schema to clients that ask.
+type GraphQL struct {
+	MaxDepth      int  `json:"max_depth"`
+	MaxComplexity int  `json:"max_complexity"`
+	Introspection bool `json:"introspection"`
+}
+
+// Catalog points at the catalog service's read API (go/services/catalog).
+// Without a URL, the product queries of /graphql answer NOT_IMPLEMENTED.
+// IdentitySecret is the catalog's CATALOG_IDENTITY_SECRET, for catalogs
+// that only serve requests vouched for with an x-edge-identity token.
+type Catalog struct {
+	URL            string `json:"url"`
+	IdentitySecret string `json:"identity_secret"`
+}
+
+// Bulkheads sizes the per-dependency concurrency pools. A limit of 0 means
+// unlimited; callers wait up to QueueTimeoutMS for a slot before failing.
+type Bulkheads struct {
+	Database       int `json:"database"`
+	Email          int `json:"email"`
+	QueueTimeoutMS int `json:"queue_timeout_ms"`
+}
+
+func (b Bulkheads) validate() error {
+	if b.Database < 0 || b.Email < 0 || b.QueueTimeoutMS < 0 {
+		return fmt.Errorf("bulkhead limits and BULKHEAD_QUEUE_TIMEOUT_MS must be >= 0")
+	}
+	return nil
+}
+
+// RateLimits caps expensive endpoints per caller (user, API key or client
+// IP), shared by all instances through Redis when RedisURL is set. A
+// per-minute rate of 0 disables that endpoint's limit; Burst requests may
+// come back to back.
+type RateLimits struct {
+	RegisterPerMinute int `json:"register_per_minute"`
+	// QueryPerMinute caps each of the list and search endpoints (GET
+	// /users, POST /graphql, GET /admin/users) with a budget of its own.
+	QueryPerMinute int `json:"query_per_minute"`
+	Burst          int `json:"burst"`
+}
+
+func (r RateLimits) validate() error {
+	if r.RegisterPerMinute < 0 || r.QueryPerMinute < 0 || r.Burst < 0 {
+		return fmt.Errorf("RATE_LIMIT_REGISTER_PER_MINUTE, RATE_LIMIT_QUERY_PER_MINUTE and RATE_LIMIT_BURST must be >= 0")
+	}
+	return nil
+}
+
+// Tenancy lists the tenants a multi-tenant deployment serves. With none,
+// everything belongs to domain.DefaultTenant. Otherwise each request names
+// its tenant in the X-Tenant-ID header or as a subdomain of BaseDomain,
+// and only the listed tenants are served.
+type Tenancy struct {
+	BaseDomain string `json:"base_domain"`
+	// Tenants maps each tenant ID to its own settings.
+	Tenants map[string]Tenant `json:"tenants"`
+}
+
+// Tenant overrides the global settings for one tenant; nil fields inherit
+// them.
+type Tenant struct {
+	RateLimits *RateLimits `json:"rate_limits"`
+}
+
+// Enabled reports whether requests are resolved to tenants.
+func (t Tenancy) Enabled() bool {
+	return len(t.Tenants) > 0
+}
+
+// IDs lists the configured tenants in order.
+func (t Tenancy) IDs() []domain.TenantID {
+	ids := make([]domain.TenantID, 0, len(t.Tenants))
+	for id := range t.Tenants {
+		ids = append(ids, domain.TenantID(id))
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+func (t Tenancy) validate() error {
+	for id, tenant := range t.Tenants {
+		if err := domain.ValidateTenantID(domain.TenantID(id)); err != nil {
+			return fmt.Errorf("tenant %q: %w (want a lowercase DNS label)", id, err)
+		}
+		if tenant.RateLimits != nil {
+			if err := tenant.RateLimits.validate(); err != nil {
+				return fmt.Errorf("tenant %q: %w", id, err)
+			}
+		}
+	}
+	if t.BaseDomain != "" && !t.Enabled() {
+		return fmt.Errorf("TENANT_BASE_DOMAIN needs TENANTS")
+	}
+	return nil
+}
+
+// Webhooks configures event delivery over HTTP. Every domain event is
+// POSTed to URLs, signed with the first of SigningKeys; CallbackKeys opens
+// POST /webhooks/events to services signing with any of those. Both key
+// lists read "id:secret,id:secret", so keys rotate without downtime.
+type Webhooks struct {
+	URLs         []string `json:"urls"`
+	SigningKeys  string   `json:"signing_keys"`
+	CallbackKeys string   `json:"callback_keys"`
+}
+
+func (w Webhooks) validate() error {
+	if len(w.URLs) > 0 && w.SigningKeys == "" {
+		return fmt.Errorf("WEBHOOK_URLS needs WEBHOOK_SIGNING_KEYS")
+	}
+	for name, keys := range map[string]string{"WEBHOOK_SIGNING_KEYS": w.SigningKeys, "WEBHOOK_CALLBACK_KEYS": w.CallbackKeys} {
+		if keys == "" {
+			continue
+		}
+		if _, err := signing.ParseKeys(keys); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Notifications configures the messages sent for domain events. Routes
+// maps an event type to the channels it goes out on (email, sms, webhook).
+// Messages are rendered from the built-in HTML emails where there is one
+// (user.registered), otherwise from TemplatesDir, laid out as
+// <event>/<locale>.tmpl, or from the built-in templates when it is empty,
+// in the user's locale or DefaultLocale. LocaleFallbacks, such as
+// "pt-br:pt,es;ca:es", are the locales tried before DefaultLocale when
+// the user's has no template. WebhookURL receives the webhook channel.
+//
+// The emails of DigestEvents are held for users who chose a daily or
+// weekly digest, and sent as one email on DailyDigestSchedule or
+// WeeklyDigestSchedule.
+type Notifications struct {
+	TemplatesDir    string              `json:"templates_dir"`
+	DefaultLocale   string              `json:"default_locale"`
+	LocaleFallbacks string              `json:"locale_fallbacks"`
+	WebhookURL      string              `json:"webhook_url"`
+	Routes          map[string][]string `json:"routes"`
+
+	DigestEvents         []string `json:"digest_events"`
+	DailyDigestSchedule  string   `json:"daily_digest_schedule"`
+	WeeklyDigestSchedule string   `json:"weekly_digest_schedule"`
+}
+
+func (n Notifications) validate() error {
+	if domain.NormalizeLocale(n.DefaultLocale) == "" {
+		return fmt.Errorf("NOTIFY_DEFAULT_LOCALE %q is not a locale", n.DefaultLocale)
+	}
+	if _, err := domain.ParseLocaleFallbacks(n.LocaleFallbacks); err != nil {
+		return fmt.Errorf("NOTIFY_LOCALE_FALLBACKS: %w", err)
+	}
+	for event, channels := range n.Routes {
+		for _, ch := range channels {
+			switch domain.Channel(ch) {
+			case domain.ChannelEmail, domain.ChannelSMS:
+			case domain.ChannelWebhook:
+				if n.WebhookURL == "" {
+					return fmt.Errorf("notification route %s: the webhook channel needs NOTIFY_WEBHOOK_URL", event)
+				}
+			default:
+				return fmt.Errorf("notification route %s: unknown channel %q", event, ch)
+			}
+		}
+	}
+	if len(n.DigestEvents) > 0 {
+		if _, err := scheduler.Parse(n.DailyDigestSchedule); err != nil {
+			return fmt.Errorf("NOTIFY_DAILY_DIGEST_SCHEDULE: %w", err)
+		}
+		if _, err := scheduler.Parse(n.WeeklyDigestSchedule); err != nil {
+			return fmt.Errorf("NOTIFY_WEEKLY_DIGEST_SCHEDULE: %w", err)
+		}
+	}
+	return nil
+}
+
+// Email configures the sender of email jobs: Provider "smtp" sends
+// through SMTPHost, "ses" through the AWS SES API in SESRegion, "sendgrid"
+// through the SendGrid API, "dryrun" only logs messages and "mailbox"
+// keeps the latest in memory, listed at /dev/mailbox. Messages come
+// from From. SMTPTLS is "starttls", "tls" (implicit, usually port 465) or
+// "none". APIEndpoint, if set, replaces the SES or SendGrid default. Each
+// send gives up after TimeoutMS.
+//
+// Bounces and complaints are taken at POST /email/events: from SES
+// through the SNS topic SESTopicARN, from SendGrid's signed event webhook
+// with SendGridWebhookKey as its verification key. Unset, the endpoint is
+// off.
+//
+// RatePerMinute caps the emails sent through the provider by every worker
+// together, DomainRates those to each recipient domain, per minute, such
+// as "gmail.com:600,yahoo.com:300"; RateBurst of them may go out back to
+// back. Jobs over a cap wait instead of failing. 0 or unset is no cap.
+type Email struct {
+	Provider string `json:"provider"`
+	From     string `json:"from"`
+
+	SMTPHost     string `json:"smtp_host"`
+	SMTPPort     int    `json:"smtp_port"`
+	SMTPUsername string `json:"smtp_username"`
+	SMTPPassword string `json:"smtp_password"`
+	SMTPTLS      string `json:"smtp_tls"`
+
+	SESRegion       string `json:"ses_region"`
+	SESAccessKey    string `json:"ses_access_key"`
+	SESSecretKey    string `json:"ses_secret_key"`
+	SESSessionToken string `json:"ses_session_token"`
+	SESTopicARN     string `json:"ses_topic_arn"`
+
+	SendGridAPIKey     string `json:"sendgrid_api_key"`
+	SendGridWebhookKey string `json:"sendgrid_webhook_key"`
+
+	APIEndpoint string `json:"api_endpoint"`
+
+	TimeoutMS int `json:"timeout_ms"`
+
+	RatePerMinute int    `json:"rate_per_minute"`
+	DomainRates   string `json:"domain_rates"`
+	RateBurst     int    `json:"rate_burst"`
+}
+
+// DomainRateLimits parses DomainRates into sends per minute by domain.
+func (e Email) DomainRateLimits() (map[string]int, error) {
+	limits := make(map[string]int)
+	for _, entry := range strings.Split(e.DomainRates, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, rate, ok := strings.Cut(entry, ":")
+		perMinute, err := strconv.Atoi(strings.TrimSpace(rate))
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !ok || err != nil || perMinute <= 0 || name == "" || strings.Contains(name, "@") {
+			return nil, fmt.Errorf("%q: want domain:sends-per-minute", entry)
+		}
+		limits[name] = perMinute
+	}
+	return limits, nil
+}
+
+func (e Email) validate() error {
+	if _, err := mail.ParseAddress(e.From); err != nil {
+		return fmt.Errorf("EMAIL_FROM %q: %w", e.From, err)
+	}
+	if e.TimeoutMS <= 0 {
+		return fmt.Errorf("EMAIL_TIMEOUT_MS must be > 0")
+	}
+	if e.RatePerMinute < 0 || e.RateBurst < 0 {
+		return fmt.Errorf("EMAIL_RATE_PER_MINUTE and EMAIL_RATE_BURST must be >= 0")
+	}
+	if _, err := e.DomainRateLimits(); err != nil {
+		return fmt.Errorf("EMAIL_DOMAIN_RATES: %w", err)
+	}
+	switch e.Provider {
+	case "dryrun", "mailbox":
+		return nil
+	case "ses":
+		if e.SESRegion == "" || e.SESAccessKey == "" || e.SESSecretKey == "" {
+			return fmt.Errorf("EMAIL_PROVIDER=ses needs EMAIL_SES_REGION, EMAIL_SES_ACCESS_KEY and EMAIL_SES_SECRET_KEY")
+		}
+		return nil
+	case "sendgrid":
+		if e.SendGridAPIKey == "" {
+			return fmt.Errorf("EMAIL_PROVIDER=sendgrid needs EMAIL_SENDGRID_API_KEY")
+		}
+		return nil
+	case "smtp":
+	default:
+		return fmt.Errorf("EMAIL_PROVIDER %q: want smtp, ses, sendgrid, dryrun or mailbox", e.Provider)
+	}
+	if e.SMTPHost == "" || e.SMTPPort <= 0 {
+		return fmt.Errorf("EMAIL_PROVIDER=smtp needs EMAIL_SMTP_HOST and EMAIL_SMTP_PORT")
+	}
+	if !slices.Contains([]string{"starttls", "tls", "none"}, e.SMTPTLS) {
+		return fmt.Errorf("EMAIL_SMTP_TLS %q: want starttls, tls or none", e.SMTPTLS)
+	}
+	if (e.SMTPUsername == "") != (e.SMTPPassword == "") {
+		return fmt.Errorf("EMAIL_SMTP_USERNAME and EMAIL_SMTP_PASSWORD must be set together")
+	}
+	return nil
+}
+
+// Uploads configures avatar and product image uploads, which are off
+// until a store is chosen: the S3Bucket when set, otherwise files under
+// BlobDir, served by this process at BlobBaseURL through links signed with
+// BlobSecret. Uploads over MaxBytes are refused; Workers resize the rest
+// in the background, with room for QueueSize waiting jobs. Links to
+// images stay valid for URLTTLSeconds.
+type Uploads struct {
+	BlobDir     string `json:"blob_dir"`
+	BlobBaseURL string `json:"blob_base_url"`
+	BlobSecret  string `json:"blob_secret"`
+
+	S3Endpoint  string `json:"s3_endpoint"`
+	S3AccessKey string `json:"s3_access_key"`
+	S3SecretKey string `json:"s3_secret_key"`
+	S3Bucket    string `json:"s3_bucket"`
+	S3UseSSL    bool   `json:"s3_use_ssl"`
+
+	MaxBytes      int `json:"max_bytes"`
+	Workers       int `json:"workers"`
+	QueueSize     int `json:"queue_size"`
+	URLTTLSeconds int `json:"url_ttl_seconds"`
+}
+
+// Enabled reports whether uploads are accepted.
+func (u Uploads) Enabled() bool {
+	return u.BlobDir != "" || u.S3Bucket != ""
+}
+
+func (u Uploads) validate() error {
+	if !u.Enabled() {
+		return nil
+	}
+	if u.BlobDir != "" && u.S3Bucket != "" {
+		return fmt.Errorf("UPLOAD_BLOB_DIR and UPLOAD_S3_BUCKET are mutually exclusive")
+	}
+	if u.BlobDir != "" && len(u.BlobSecret) < 32 {
+		return fmt.Errorf("UPLOAD_BLOB_DIR needs an UPLOAD_BLOB_SECRET of at least 32 bytes")
+	}
+	if base, err := url.Parse(u.BlobBaseURL); u.BlobDir != "" && (err != nil || strings.Trim(base.Path, "/") == "") {
+		return fmt.Errorf("UPLOAD_BLOB_BASE_URL %q needs a path to serve blobs under, e.g. /blobs", u.BlobBaseURL)
+	}
+	if u.S3Bucket != "" && u.S3Endpoint == "" {
+		return fmt.Errorf("UPLOAD_S3_BUCKET needs UPLOAD_S3_ENDPOINT")
+	}
+	if u.MaxBytes <= 0 || u.Workers <= 0 || u.QueueSize < 0 || u.URLTTLSeconds <= 0 {
+		return fmt.Errorf("UPLOAD_MAX_BYTES, UPLOAD_WORKERS and UPLOAD_URL_TTL_SECONDS must be > 0, UPLOAD_QUEUE_SIZE >= 0")
+	}
+	return nil
+}
+
+// PII configures field encryption in the Postgres user store and of the
+// email addresses in published events. Keys reads "version:base64,..."
+// with 32-byte keys; the highest version encrypts and the others only
+// decrypt, so a new key is added and the old one dropped once every row
+// has been rewritten and the brokers no longer hold older events. IndexKey
+// keys the blind index on email and must not change. The Redis user cache
+// is not covered.
+type PII struct {
+	Keys     string `json:"keys"`
+	IndexKey string `json:"index_key"`
+}
+
+// Enabled reports whether personal fields are encrypted.
+func (p PII) Enabled() bool {
+	return p.Keys != ""
+}
+
+func (p PII) validate(driver string) error {
+	if (p.Keys == "") != (p.IndexKey == "") {
+		return fmt.Errorf("PII_KEYS and PII_INDEX_KEY must be set together")
+	}
+	if p.Enabled() && driver != "postgres" {
+		return fmt.Errorf("PII_KEYS needs DATABASE_DRIVER=postgres, got %s", driver)
+	}
+	return nil
+}
+
+// Auth configures the session tokens this service signs and, when
+// OIDCIssuer is set, login through that OpenID Connect provider.
+type Auth struct {
+	// TokenSecret signs session tokens. Empty means a random secret per
+	// process: tokens then die with it and are not shared by replicas.
+	TokenSecret     string `json:"token_secret"`
+	TokenTTLSeconds int    `json:"token_ttl_seconds"`
+
+	// SessionMode is "jwt" for stateless tokens or "server" for opaque
+	// tokens backed by revocable server-side sessions.
+	SessionMode           string `json:"session_mode"`
+	SessionIdleTTLSeconds int    `json:"session_idle_ttl_seconds"`
+	SessionMaxTTLSeconds  int    `json:"session_max_ttl_seconds"`
+
+	OIDCIssuer       string `json:"oidc_issuer"`
+	OIDCClientID     string `json:"oidc_client_id"`
+	OIDCClientSecret string `json:"oidc_client_secret"`
+	OIDCRedirectURL  string `json:"oidc_redirect_url"`
+
+	// ServiceURL is the auth service, which keeps users' passwords; the
+	// password reset endpoints are off without it. Calls to it are signed
+	// with the first of ServiceSigningKeys, "id:secret,id:secret".
+	ServiceURL         string `json:"service_url"`
+	ServiceSigningKeys string `json:"service_signing_keys"`
+}
+
+// ServerSessions reports whether logins start server-side sessions.
+func (a Auth) ServerSessions() bool {
+	return a.SessionMode == "server"
+}
+
+// OIDCEnabled reports whether users may log in through an IdP.
+func (a Auth) OIDCEnabled() bool {
+	return a.OIDCIssuer != ""
+}
+
+func (a Auth) validate(p Profile) error {
+	if a.TokenTTLSeconds <= 0 {
+		return fmt.Errorf("AUTH_TOKEN_TTL_SECONDS must be > 0")
+	}
+	if a.TokenSecret != "" && len(a.TokenSecret) < 32 {
+		return fmt.Errorf("AUTH_TOKEN_SECRET must be at least 32 bytes")
+	}
+	if a.SessionMode != "jwt" && a.SessionMode != "server" {
+		return fmt.Errorf("AUTH_SESSION_MODE must be jwt or server, got %q", a.SessionMode)
+	}
+	if a.SessionIdleTTLSeconds <= 0 || a.SessionMaxTTLSeconds < a.SessionIdleTTLSeconds {
+		return fmt.Errorf("AUTH_SESSION_IDLE_TTL_SECONDS must be > 0 and at most AUTH_SESSION_MAX_TTL_SECONDS")
+	}
+	if a.ServiceURL != "" {
+		if a.ServiceSigningKeys == "" {
+			return fmt.Errorf("AUTH_SERVICE_URL needs AUTH_SERVICE_SIGNING_KEYS")
+		}
+		if _, err := signing.ParseKeys(a.ServiceSigningKeys); err != nil {
+			return fmt.Errorf("AUTH_SERVICE_SIGNING_KEYS: %w", err)
+		}
+	}
+	if !a.OIDCEnabled() {
+		return nil
+	}
+	if a.OIDCClientID == "" || a.OIDCRedirectURL == "" {
+		return fmt.Errorf("OIDC_ISSUER needs OIDC_CLIENT_ID and OIDC_REDIRECT_URL")
+	}
+	if (p == ProfileStaging || p == ProfileProd) && a.TokenSecret == "" {
+		return fmt.Errorf("AUTH_TOKEN_SECRET is required for OIDC in the %s profile", p)
+	}
+	return nil
+}
+
+// Dynamic holds the settings that take effect without a restart: each
+// has a consumer that follows the Reloader.
+type Dynamic struct {
+	// LogLevel drives the access log through Reloader.LogLevel.
+	LogLevel string `json:"log_level"`
+	// Chaos maps a target ("http", "users.repo" or "*") to the fault
+	// injected into it; ignored unless ChaosEnabled.
+	Chaos map[string]faults.Fault `json:"chaos"`
+}
+
+// dynamicFromEnv applies the environment overrides for d; the Reloader
+// reapplies them after every reload so the environment keeps precedence.
+func dynamicFromEnv(d *Dynamic) {
+	d.LogLevel = envString("LOG_LEVEL", d.LogLevel)
+}
+
+// Validate rejects values that would put the process in a broken state.
+func (d Dynamic) Validate() error {
+	switch d.LogLevel {
+	case "debug", "info", "warn", "error":
+	default:
+		return fmt.Errorf("invalid log_level %q", d.LogLevel)
+	}
+	for target, f := range d.Chaos {
+		if f.LatencyMS < 0 || f.JitterMS < 0 || f.ErrorRate < 0 || f.ErrorRate > 1 || f.PartialRate < 0 || f.PartialRate > 1 {
+			return fmt.Errorf("chaos %q: latencies must be >= 0 and rates within [0, 1]", target)
+		}
+	}
+	return nil
+}
+
+// Load builds the configuration from the APP_ENV profile defaults, the
+// optional CONFIG_FILE, and finally environment variables (highest precedence).
+// APP_ENV has no default, so a deployment that forgets it fails to start
+// rather than running with dev's defaults.
+func Load() (Config, error) {
+	profile := os.Getenv("APP_ENV")
+	if profile == "" {
+		return Config{}, fmt.Errorf("APP_ENV is required (dev, test, staging or prod)")
+	}
+	cfg, err := defaultsFor(Profile(profile))
+	if err != nil {
+		return Config{}, err
+	}
+
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		if err := readFile(path, &cfg); err != nil {
+			return Config{}, err
+		}
+		cfg.File = path
+	}
+
+	cfg.HTTPAddr = envString("HTTP_ADDR", cfg.HTTPAddr)
+	cfg.AdminAddr = envString("ADMIN_ADDR", cfg.AdminAddr)
+	cfg.DatabaseDriver = envString("DATABASE_DRIVER", cfg.DatabaseDriver)
+	cfg.DatabaseURL = envString("DATABASE_URL", cfg.DatabaseURL)
+	dynamicFromEnv(&cfg.Dynamic)
+	cfg.KafkaBrokers = envList("KAFKA_BROKERS", cfg.KafkaBrokers)
+	cfg.KafkaTopic = envString("KAFKA_TOPIC", cfg.KafkaTopic)
+	cfg.NATSURL = envString("NATS_URL", cfg.NATSURL)
+	cfg.NATSSubject = envString("NATS_SUBJECT", cfg.NATSSubject)
+	cfg.NATSQueue = envString("NATS_QUEUE", cfg.NATSQueue)
+	cfg.AMQPURL = envString("AMQP_URL", cfg.AMQPURL)
+	cfg.AMQPQueue = envString("AMQP_QUEUE", cfg.AMQPQueue)
+	cfg.RedisURL = envString("REDIS_URL", cfg.RedisURL)
+	cfg.TLS.CertFile = envString("TLS_CERT_FILE", cfg.TLS.CertFile)
+	cfg.TLS.KeyFile = envString("TLS_KEY_FILE", cfg.TLS.KeyFile)
+	cfg.TLS.AutocertDomains = envList("TLS_AUTOCERT_DOMAINS", cfg.TLS.AutocertDomains)
+	cfg.TLS.AutocertCacheDir = envString("TLS_AUTOCERT_CACHE", cfg.TLS.AutocertCacheDir)
+	cfg.TLS.RedirectAddr = envString("HTTP_REDIRECT_ADDR", cfg.TLS.RedirectAddr)
+	cfg.TLS.ClientCAFile = envString("TLS_CLIENT_CA_FILE", cfg.TLS.ClientCAFile)
+	cfg.TLS.ClientNames = envList("TLS_CLIENT_NAMES", cfg.TLS.ClientNames)
+	cfg.PolicyFile = envString("POLICY_FILE", cfg.PolicyFile)
+	cfg.PII.Keys = envString("PII_KEYS", cfg.PII.Keys)
+	cfg.PII.IndexKey = envString("PII_INDEX_KEY", cfg.PII.IndexKey)
+	cfg.Webhooks.URLs = envList("WEBHOOK_URLS", cfg.Webhooks.URLs)
+	cfg.Webhooks.SigningKeys = envString("WEBHOOK_SIGNING_KEYS", cfg.Webhooks.SigningKeys)
+	cfg.Webhooks.CallbackKeys = envString("WEBHOOK_CALLBACK_KEYS", cfg.Webhooks.CallbackKeys)
+	cfg.Notifications.TemplatesDir = envString("NOTIFY_TEMPLATES_DIR", cfg.Notifications.TemplatesDir)
+	cfg.Notifications.DefaultLocale = envString("NOTIFY_DEFAULT_LOCALE", cfg.Notifications.DefaultLocale)
+	cfg.Notifications.LocaleFallbacks = envString("NOTIFY_LOCALE_FALLBACKS", cfg.Notifications.LocaleFallbacks)
+	cfg.Notifications.WebhookURL = envString("NOTIFY_WEBHOOK_URL", cfg.Notifications.WebhookURL)
+	cfg.Notifications.DigestEvents = envList("NOTIFY_DIGEST_EVENTS", cfg.Notifications.DigestEvents)
+	cfg.Notifications.DailyDigestSchedule = envString("NOTIFY_DAILY_DIGEST_SCHEDULE", cfg.Notifications.DailyDigestSchedule)
+	cfg.Notifications.WeeklyDigestSchedule = envString("NOTIFY_WEEKLY_DIGEST_SCHEDULE", cfg.Notifications.WeeklyDigestSchedule)
+	cfg.Uploads.BlobDir = envString("UPLOAD_BLOB_DIR", cfg.Uploads.BlobDir)
+	cfg.Uploads.BlobBaseURL = envString("UPLOAD_BLOB_BASE_URL", cfg.Uploads.BlobBaseURL)
+	cfg.Uploads.BlobSecret = envString("UPLOAD_BLOB_SECRET", cfg.Uploads.BlobSecret)
+	cfg.Uploads.S3Endpoint = envString("UPLOAD_S3_ENDPOINT", cfg.Uploads.S3Endpoint)
+	cfg.Uploads.S3AccessKey = envString("UPLOAD_S3_ACCESS_KEY", cfg.Uploads.S3AccessKey)
+	cfg.Uploads.S3SecretKey = envString("UPLOAD_S3_SECRET_KEY", cfg.Uploads.S3SecretKey)
+	cfg.Uploads.S3Bucket = envString("UPLOAD_S3_BUCKET", cfg.Uploads.S3Bucket)
+	cfg.Email.Provider = envString("EMAIL_PROVIDER", cfg.Email.Provider)
+	cfg.Email.From = envString("EMAIL_FROM", cfg.Email.From)
+	cfg.Email.SMTPHost = envString("EMAIL_SMTP_HOST", cfg.Email.SMTPHost)
+	cfg.Email.SMTPUsername = envString("EMAIL_SMTP_USERNAME", cfg.Email.SMTPUsername)
+	cfg.Email.SMTPPassword = envString("EMAIL_SMTP_PASSWORD", cfg.Email.SMTPPassword)
+	cfg.Email.SMTPTLS = envString("EMAIL_SMTP_TLS", cfg.Email.SMTPTLS)
+	cfg.Email.SESRegion = envString("EMAIL_SES_REGION", cfg.Email.SESRegion)
+	cfg.Email.SESAccessKey = envString("EMAIL_SES_ACCESS_KEY", cfg.Email.SESAccessKey)
+	cfg.Email.SESSecretKey = envString("EMAIL_SES_SECRET_KEY", cfg.Email.SESSecretKey)
+	cfg.Email.SESSessionToken = envString("EMAIL_SES_SESSION_TOKEN", cfg.Email.SESSessionToken)
+	cfg.Email.SESTopicARN = envString("EMAIL_SES_TOPIC_ARN", cfg.Email.SESTopicARN)
+	cfg.Email.SendGridAPIKey = envString("EMAIL_SENDGRID_API_KEY", cfg.Email.SendGridAPIKey)
+	cfg.Email.SendGridWebhookKey = envString("EMAIL_SENDGRID_WEBHOOK_KEY", cfg.Email.SendGridWebhookKey)
+	cfg.Email.APIEndpoint = envString("EMAIL_API_ENDPOINT", cfg.Email.APIEndpoint)
+	cfg.Email.DomainRates = envString("EMAIL_DOMAIN_RATES", cfg.Email.DomainRates)
+	cfg.Auth.TokenSecret = envString("AUTH_TOKEN_SECRET", cfg.Auth.TokenSecret)
+	cfg.Auth.SessionMode = envString("AUTH_SESSION_MODE", cfg.Auth.SessionMode)
+	cfg.Auth.OIDCIssuer = envString("OIDC_ISSUER", cfg.Auth.OIDCIssuer)
+	cfg.Auth.OIDCClientID = envString("OIDC_CLIENT_ID", cfg.Auth.OIDCClientID)
+	cfg.Auth.OIDCClientSecret = envString("OIDC_CLIENT_SECRET", cfg.Auth.OIDCClientSecret)
+	cfg.Auth.OIDCRedirectURL = envString("OIDC_REDIRECT_URL", cfg.Auth.OIDCRedirectURL)
+	cfg.Auth.ServiceURL = envString("AUTH_SERVICE_URL", cfg.Auth.ServiceURL)
+	cfg.Auth.ServiceSigningKeys = envString("AUTH_SERVICE_SIGNING_KEYS", cfg.Auth.ServiceSigningKeys)
+	cfg.Catalog.URL = envString("CATALOG_URL", cfg.Catalog.URL)
+	cfg.Catalog.IdentitySecret = envString("CATALOG_IDENTITY_SECRET", cfg.Catalog.IdentitySecret)
+	cfg.Tenancy.BaseDomain = envString("TENANT_BASE_DOMAIN", cfg.Tenancy.BaseDomain)
+	for _, id := range envList("TENANTS", nil) {
+		if _, ok := cfg.Tenancy.Tenants[id]; !ok {
+			if cfg.Tenancy.Tenants == nil {
+				cfg.Tenancy.Tenants = make(map[string]Tenant)
+			}
+			cfg.Tenancy.Tenants[id] = Tenant{}
+		}
+	}
+
+	if cfg.DatabaseUnprepared, err = envBool("DATABASE_UNPREPARED", cfg.DatabaseUnprepared); err != nil {
+		return Config{}, err
+	}
+	if cfg.StartupAttempts, err = envInt("STARTUP_ATTEMPTS", cfg.StartupAttempts); err != nil {
+		return Config{}, err
+	}
+	if cfg.StartupDegraded, err = envBool("STARTUP_DEGRADED", cfg.StartupDegraded); err != nil {
+		return Config{}, err
+	}
+	if cfg.AMQPPrefetch, err = envInt("AMQP_PREFETCH", cfg.AMQPPrefetch); err != nil {
+		return Config{}, err
+	}
+	if cfg.ChaosEnabled, err = envBool("CHAOS_ENABLED", cfg.ChaosEnabled); err != nil {
+		return Config{}, err
+	}
+	if cfg.Auth.TokenTTLSeconds, err = envInt("AUTH_TOKEN_TTL_SECONDS", cfg.Auth.TokenTTLSeconds); err != nil {
+		return Config{}, err
+	}
+	if cfg.Auth.SessionIdleTTLSeconds, err = envInt("AUTH_SESSION_IDLE_TTL_SECONDS", cfg.Auth.SessionIdleTTLSeconds); err != nil {
+		return Config{}, err
+	}
+	if cfg.Auth.SessionMaxTTLSeconds, err = envInt("AUTH_SESSION_MAX_TTL_SECONDS", cfg.Auth.SessionMaxTTLSeconds); err != nil {
+		return Config{}, err
+	}
+	if cfg.APIKeysRequired, err = envBool("API_KEYS_REQUIRED", cfg.APIKeysRequired); err != nil {
+		return Config{}, err
+	}
+	if cfg.RateLimits.RegisterPerMinute, err = envInt("RATE_LIMIT_REGISTER_PER_MINUTE", cfg.RateLimits.RegisterPerMinute); err != nil {
+		return Config{}, err
+	}
+	if cfg.RateLimits.QueryPerMinute, err = envInt("RATE_LIMIT_QUERY_PER_MINUTE", cfg.RateLimits.QueryPerMinute); err != nil {
+		return Config{}, err
+	}
+	if cfg.RateLimits.Burst, err = envInt("RATE_LIMIT_BURST", cfg.RateLimits.Burst); err != nil {
+		return Config{}, err
+	}
+	if cfg.MaxBodyBytes, err = envInt("MAX_BODY_BYTES", cfg.MaxBodyBytes); err != nil {
+		return Config{}, err
+	}
+	if cfg.OpenAPIValidate, err = envBool("OPENAPI_VALIDATE", cfg.OpenAPIValidate); err != nil {
+		return Config{}, err
+	}
+	if cfg.RequestTimeoutMS, err = envInt("REQUEST_TIMEOUT_MS", cfg.RequestTimeoutMS); err != nil {
+		return Config{}, err
+	}
+	if cfg.Shedding.MaxInFlight, err = envInt("SHED_MAX_IN_FLIGHT", cfg.Shedding.MaxInFlight); err != nil {
+		return Config{}, err
+	}
+	if cfg.Shedding.MaxP99MS, err = envInt("SHED_MAX_P99_MS", cfg.Shedding.MaxP99MS); err != nil {
+		return Config{}, err
+	}
+	if cfg.Compression.MinBytes, err = envInt("COMPRESSION_MIN_BYTES", cfg.Compression.MinBytes); err != nil {
+		return Config{}, err
+	}
+	cfg.Compression.Types = envList("COMPRESSION_TYPES", cfg.Compression.Types)
+	if cfg.GraphQL.MaxDepth, err = envInt("GRAPHQL_MAX_DEPTH", cfg.GraphQL.MaxDepth); err != nil {
+		return Config{}, err
+	}
+	if cfg.GraphQL.MaxComplexity, err = envInt("GRAPHQL_MAX_COMPLEXITY", cfg.GraphQL.MaxComplexity); err != nil {
+		return Config{}, err
+	}
+	if cfg.GraphQL.Introspection, err = envBool("GRAPHQL_INTROSPECTION", cfg.GraphQL.Introspection); err != nil {
+		return Config{}, err
+	}
+	if cfg.Bulkheads.Database, err = envInt("BULKHEAD_DATABASE", cfg.Bulkheads.Database); err != nil {
+		return Config{}, err
+	}
+	if cfg.Bulkheads.Email, err = envInt("BULKHEAD_EMAIL", cfg.Bulkheads.Email); err != nil {
+		return Config{}, err
+	}
+	if cfg.Bulkheads.QueueTimeoutMS, err = envInt("BULKHEAD_QUEUE_TIMEOUT_MS", cfg.Bulkheads.QueueTimeoutMS); err != nil {
+		return Config{}, err
+	}
+	if cfg.StaleTTLSeconds, err = envInt("STALE_TTL_SECONDS", cfg.StaleTTLSeconds); err != nil {
+		return Config{}, err
+	}
+	if cfg.CacheTTLSeconds, err = envInt("CACHE_TTL_SECONDS", cfg.CacheTTLSeconds); err != nil {
+		return Config{}, err
+	}
+	if cfg.DeletedRetentionSeconds, err = envInt("DELETED_RETENTION_SECONDS", cfg.DeletedRetentionSeconds); err != nil {
+		return Config{}, err
+	}
+	cfg.PurgeSchedule = envString("PURGE_SCHEDULE", cfg.PurgeSchedule)
+	if cfg.Uploads.S3UseSSL, err = envBool("UPLOAD_S3_USE_SSL", cfg.Uploads.S3UseSSL); err != nil {
+		return Config{}, err
+	}
+	if cfg.Uploads.MaxBytes, err = envInt("UPLOAD_MAX_BYTES", cfg.Uploads.MaxBytes); err != nil {
+		return Config{}, err
+	}
+	if cfg.Uploads.Workers, err = envInt("UPLOAD_WORKERS", cfg.Uploads.Workers); err != nil {
+		return Config{}, err
+	}
+	if cfg.Uploads.QueueSize, err = envInt("UPLOAD_QUEUE_SIZE", cfg.Uploads.QueueSize); err != nil {
+		return Config{}, err
+	}
+	if cfg.Uploads.URLTTLSeconds, err = envInt("UPLOAD_URL_TTL_SECONDS", cfg.Uploads.URLTTLSeconds); err != nil {
+		return Config{}, err
+	}
+	if cfg.Email.SMTPPort, err = envInt("EMAIL_SMTP_PORT", cfg.Email.SMTPPort); err != nil {
+		return Config{}, err
+	}
+	if cfg.Email.TimeoutMS, err = envInt("EMAIL_TIMEOUT_MS", cfg.Email.TimeoutMS); err != nil {
+		return Config{}, err
+	}
+	if cfg.Email.RatePerMinute, err = envInt("EMAIL_RATE_PER_MINUTE", cfg.Email.RatePerMinute); err != nil {
+		return Config{}, err
+	}
+	if cfg.Email.RateBurst, err = envInt("EMAIL_RATE_BURST", cfg.Email.RateBurst); err != nil {
+		return Config{}, err
+	}
+	if cfg.EmailWorkers, err = envInt("EMAIL_WORKERS", cfg.EmailWorkers); err != nil {
+		return Config{}, err
+	}
+	if cfg.EmailQueueSize, err = envInt("EMAIL_QUEUE_SIZE", cfg.EmailQueueSize); err != nil {
+		return Config{}, err
+	}
+
+	if err := cfg.TLS.validate(); err != nil {
+		return Config{}, err
+	}
+	if err := cfg.Bulkheads.validate(); err != nil {
+		return Config{}, err
+	}
+	if err := cfg.Auth.validate(cfg.Profile); err != nil {
+		return Config{}, err
+	}
+	if err := cfg.RateLimits.validate(); err != nil {
+		return Config{}, err
+	}
+	if err := cfg.Tenancy.validate(); err != nil {
+		return Config{}, err
+	}
+	if err := cfg.Webhooks.validate(); err != nil {
+		return Config{}, err
+	}
+	if err := cfg.Notifications.validate(); err != nil {
+		return Config{}, err
+	}
+	if err := cfg.Uploads.validate(); err != nil {
+		return Config{}, err
+	}
+	if err := cfg.Email.validate(); err != nil {
+		return Config{}, err
+	}
+	if err := cfg.PII.validate(cfg.DatabaseDriver); err != nil {
+		return Config{}, err
+	}
+	if err := cfg.validateStatic(); err != nil {
+		return Config{}, err
+	}
+	if err := cfg.Dynamic.Validate(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+func readFile(path string, into *Config) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config file: %w", err)
+	}
+	if err := json.Unmarshal(raw, into); err != nil {
+		return fmt.Errorf("parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+func envString(key, fallback string) string {
+	if v, ok := os.LookupEnv(key); ok {
+		return v
+	}
+	return fallback
+}
+
+// envList reads a comma-separated list, ignoring empty items.
+func envList(key string, fallback []string) []string {
+	v, ok := os.LookupEnv(key)
+	if !ok {
+		return fallback
+	}
+	var out []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+func envInt(key string, fallback int) (int, error) {
+	v, ok := os.LookupEnv(key)
+	if !ok {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be an integer: %w", key, err)
+	}
+	return n, nil
+}
+
+func envBool(key string, fallback bool) (bool, error) {
+	v, ok := os.LookupEnv(key)
+	if !ok {
+		return fallback, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("%s must be a boolean: %w", key, err)
+	}
+	return b, nil
+}
@@ -0,0 +1,129 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"clean_go_system/pkg/scheduler"
+)
+
+// Profile selects a set of defaults for an environment (APP_ENV).
+type Profile string
+
+const (
+	ProfileDev     Profile = "dev"
+	ProfileTest    Profile = "test"
+	ProfileStaging Profile = "staging"
+	ProfileProd    Profile = "prod"
+)
+
+// DatabaseDrivers are the stores the server can run on. Every profile
+// defaults to one of them, so a plain `serve` works in each.
+var DatabaseDrivers = []string{"postgres", "sqlite", "mongodb", "memory"}
+
+// defaultsFor returns the baseline configuration of a profile. Every value
+// can still be overridden per key by the config file or the environment.
+func defaultsFor(p Profile) (Config, error) {
+	base := Config{
+		Profile:          p,
+		HTTPAddr:         ":8080",
+		AdminAddr:        "127.0.0.1:6060",
+		EmailWorkers:     5,
+		EmailQueueSize:   100,
+		RequestTimeoutMS: 10_000,
+		MaxBodyBytes:     1 << 20,
+
+		StartupAttempts: 5,
+		KafkaTopic:      "users.events",
+		NATSSubject:     "events.>",
+		NATSQueue:       "clean_go_system",
+		AMQPQueue:       "email_jobs",
+		AMQPPrefetch:    10,
+		CacheTTLSeconds: 300,
+		StaleTTLSeconds: 3600,
+		Bulkheads:       Bulkheads{Database: 50, Email: 10, QueueTimeoutMS: 100},
+		Shedding:        Shedding{MaxInFlight: 512, MaxP99MS: 2000},
+		Compression:     Compression{MinBytes: 1024, Types: []string{"application/json", "text/csv"}},
+		GraphQL:         GraphQL{MaxDepth: 8, MaxComplexity: 1000},
+		RateLimits:      RateLimits{RegisterPerMinute: 20, QueryPerMinute: 120, Burst: 5},
+		TLS:             TLS{AutocertCacheDir: "autocert-cache"},
+		Auth:            Auth{TokenTTLSeconds: 3600, SessionMode: "jwt", SessionIdleTTLSeconds: 7 * 24 * 3600, SessionMaxTTLSeconds: 30 * 24 * 3600},
+		Dynamic:         Dynamic{LogLevel: "info"},
+
+		// Only the welcome message until more routes are configured.
+		// Digests go out at 08:00, weekly ones on Mondays.
+		Notifications: Notifications{
+			DefaultLocale:        "en",
+			Routes:               map[string][]string{"user.registered": {"email"}},
+			DailyDigestSchedule:  "0 8 * * *",
+			WeeklyDigestSchedule: "0 8 * * mon",
+		},
+
+		// Nothing is sent until a provider is configured.
+		Email: Email{Provider: "dryrun", From: "no-reply@localhost", SMTPPort: 587, SMTPTLS: "starttls", TimeoutMS: 10_000},
+
+		Uploads: Uploads{BlobBaseURL: "/blobs", S3UseSSL: true, MaxBytes: 5 << 20, Workers: 2, QueueSize: 64, URLTTLSeconds: 900},
+
+		// Thirty days to change one's mind, or for support to restore.
+		DeletedRetentionSeconds: 30 * 24 * 3600,
+		PurgeSchedule:           "@hourly",
+	}
+
+	switch p {
+	case ProfileDev:
+		// Zero infrastructure and chatty logs on a laptop.
+		base.DatabaseDriver = "sqlite"
+		base.DatabaseURL = "file:clean_go_system.db"
+		base.StartupDegraded = true
+		base.Dynamic.LogLevel = "debug"
+		base.GraphQL.Introspection = true
+	case ProfileTest:
+		// In-process adapters: tests need no database at all.
+		base.DatabaseDriver = "memory"
+		base.EmailWorkers = 1
+		base.StartupAttempts = 1
+		base.Dynamic.LogLevel = "warn"
+	case ProfileStaging, ProfileProd:
+		// DatabaseURL is deliberately left empty: it must come from the
+		// environment, as must prod's mutual TLS (see validateStatic).
+		base.DatabaseDriver = "postgres"
+	default:
+		return Config{}, fmt.Errorf("unknown APP_ENV %q (want dev, test, staging or prod)", p)
+	}
+	return base, nil
+}
+
+// validateStatic enforces the rules that make a profile safe to run.
+func (c Config) validateStatic() error {
+	if !slices.Contains(DatabaseDrivers, c.DatabaseDriver) {
+		return fmt.Errorf("DATABASE_DRIVER must be one of %s, got %q", strings.Join(DatabaseDrivers, ", "), c.DatabaseDriver)
+	}
+	if c.DatabaseURL == "" && c.DatabaseDriver != "memory" {
+		return fmt.Errorf("DATABASE_URL is required in the %s profile", c.Profile)
+	}
+	if c.MaxBodyBytes <= 0 {
+		return fmt.Errorf("MAX_BODY_BYTES must be > 0")
+	}
+	if c.Compression.MinBytes < 0 {
+		return fmt.Errorf("COMPRESSION_MIN_BYTES must be >= 0")
+	}
+	if c.GraphQL.MaxDepth <= 0 || c.GraphQL.MaxComplexity <= 0 {
+		return fmt.Errorf("GRAPHQL_MAX_DEPTH and GRAPHQL_MAX_COMPLEXITY must be > 0")
+	}
+	if c.DeletedRetentionSeconds > 0 {
+		if _, err := scheduler.Parse(c.PurgeSchedule); err != nil {
+			return fmt.Errorf("PURGE_SCHEDULE: %w", err)
+		}
+	}
+	if c.Profile == ProfileProd && !c.TLS.MutualTLS() {
+		return fmt.Errorf("the prod profile requires mutual TLS: set TLS_CERT_FILE, TLS_KEY_FILE and TLS_CLIENT_CA_FILE")
+	}
+	if c.Profile == ProfileProd && c.ChaosEnabled {
+		return fmt.Errorf("CHAOS_ENABLED cannot be enabled in the prod profile")
+	}
+	if c.Profile == ProfileProd && c.Email.Provider == "mailbox" {
+		return fmt.Errorf("EMAIL_PROVIDER=mailbox cannot be used in the prod profile")
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+package config
+
+import (
+	"context"
+	"log"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"clean_go_system/pkg/logger"
+)
+
+// Reloader owns the live Dynamic settings. It re-reads the config file on
+// SIGHUP or when the file's modification time changes, and notifies
+// subscribers so components can react without a restart.
+type Reloader struct {
+	path     string
+	profile  Profile
+	interval time.Duration
+	logger   *log.Logger
+
+	current atomic.Pointer[Dynamic]
+	modTime time.Time
+
+	mu          sync.Mutex
+	nextID      int
+	subscribers map[int]func(Dynamic)
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewReloader starts from cfg.Dynamic and watches cfg.File (if any),
+// polling for changes every interval.
+func NewReloader(cfg Config, interval time.Duration, logger *log.Logger) *Reloader {
+	r := &Reloader{
+		path:        cfg.File,
+		profile:     cfg.Profile,
+		interval:    interval,
+		logger:      logger,
+		subscribers: make(map[int]func(Dynamic)),
+	}
+	initial := cfg.Dynamic
+	r.current.Store(&initial)
+	if info, err := os.Stat(r.path); err == nil {
+		r.modTime = info.ModTime()
+	}
+	return r
+}
+
+// Current returns the live settings. Safe for concurrent use.
+func (r *Reloader) Current() Dynamic {
+	return *r.current.Load()
+}
+
+// Subscribe registers fn to be called with the new settings after every
+// successful reload. The returned func removes the subscription.
+func (r *Reloader) Subscribe(fn func(Dynamic)) (unsubscribe func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	id := r.nextID
+	r.nextID++
+	r.subscribers[id] = fn
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		delete(r.subscribers, id)
+	}
+}
+
+// LogLevel returns a slog.Leveler that follows the live log_level, so a
+// logger built with it speaks up or quietens as soon as a reload lands.
+func (r *Reloader) LogLevel() slog.Leveler {
+	return liveLevel{r}
+}
+
+type liveLevel struct{ r *Reloader }
+
+func (l liveLevel) Level() slog.Level {
+	level, err := logger.ParseLevel(l.r.Current().LogLevel)
+	if err != nil {
+		return slog.LevelInfo
+	}
+	return level
+}
+
+// Reload rebuilds the Dynamic section the way Load does: the profile
+// defaults, then the file, then the environment. Invalid files are
+// rejected and the previous settings stay in effect. Static settings in
+// the file are ignored until the next restart.
+func (r *Reloader) Reload() error {
+	if r.path == "" {
+		return nil
+	}
+	// Start from fresh defaults (never the live section: json.Unmarshal
+	// would merge into the shared maps) so removed flags really disappear
+	// and fields the file leaves out fall back to the profile's values.
+	fresh, err := defaultsFor(r.profile)
+	if err != nil {
+		return err
+	}
+	if err := readFile(r.path, &fresh); err != nil {
+		return err
+	}
+	dynamicFromEnv(&fresh.Dynamic)
+	if err := fresh.Dynamic.Validate(); err != nil {
+		return err
+	}
+
+	r.current.Store(&fresh.Dynamic)
+
+	r.mu.Lock()
+	subs := make([]func(Dynamic), 0, len(r.subscribers))
+	for _, fn := range r.subscribers {
+		subs = append(subs, fn)
+	}
+	r.mu.Unlock()
+
+	for _, fn := range subs {
+		fn(fresh.Dynamic)
+	}
+	return nil
+}
+
+// Start begins watching for SIGHUP and file changes. It satisfies lifecycle.Component.
+func (r *Reloader) Start(ctx context.Context) error {
+	ctx, r.cancel = context.WithCancel(ctx)
+	r.done = make(chan struct{})
+	go r.watch(ctx)
+	return nil
+}
+
+// Stop ends the watch loop.
+func (r *Reloader) Stop(ctx context.Context) error {
+	if r.cancel == nil {
+		return nil
+	}
+	r.cancel()
+	select {
+	case <-r.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (r *Reloader) watch(ctx context.Context) {
+	defer close(r.done)
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			r.reloadAndLog("SIGHUP")
+		case <-ticker.C:
+			if r.fileChanged() {
+				r.reloadAndLog("file change")
+			}
+		}
+	}
+}
+
+func (r *Reloader) fileChanged() bool {
+	if r.path == "" {
+		return false
+	}
+	info, err := os.Stat(r.path)
+	if err != nil || !info.ModTime().After(r.modTime) {
+		return false
+	}
+	r.modTime = info.ModTime()
+	return true
+}
+
+func (r *Reloader) reloadAndLog(trigger string) {
+	if err := r.Reload(); err != nil {
+		r.logger.Printf("config reload (%s) rejected: %v", trigger, err)
+		return
+	}
+	r.logger.Printf("config reloaded (%s)", trigger)
+}
@@ -0,0 +1,226 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"clean_go_system/internal/domain"
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrUnknownPool is returned for a worker pool Admin does not manage.
+	ErrUnknownPool = errors.New("unknown worker pool")
+	// ErrUnknownCache is returned for a cache Admin does not manage.
+	ErrUnknownCache = errors.New("unknown cache")
+)
+
+// CacheFlusher is a cache operators can empty, e.g. after fixing rows by
+// hand. Flush clears what is cached for ctx's tenant and returns how many
+// keys went.
+type CacheFlusher interface {
+	Flush(ctx context.Context) (int, error)
+}
+
+// Admin runs the operator actions that would otherwise need direct access
+// to the database or the process: user support, worker pool control and
+// cache flushes. Every action is authorized like any other use case.
+type Admin struct {
+	// Sessions, if set, has a suspended user's server sessions revoked.
+	Sessions *SessionService
+	// Deliveries, if set, answers which emails a user was sent.
+	Deliveries domain.DeliveryRepository
+	// Suppressions, if set, lets operators lift suppressed addresses.
+	Suppressions domain.SuppressionList
+
+	users  *UserService
+	authz  domain.Authorizer
+	pools  map[string]ManagedPool
+	caches map[string]CacheFlusher
+}
+
+func NewAdmin(users *UserService, authz domain.Authorizer) *Admin {
+	return &Admin{
+		users:  users,
+		authz:  authz,
+		pools:  make(map[string]ManagedPool),
+		caches: make(map[string]CacheFlusher),
+	}
+}
+
+// AddPool puts pool under operator control as name.
+func (a *Admin) AddPool(name string, pool ManagedPool) {
+	a.pools[name] = pool
+}
+
+// AddCache lets operators flush cache as name.
+func (a *Admin) AddCache(name string, cache CacheFlusher) {
+	a.caches[name] = cache
+}
+
+// SearchUsers finds users by exact ID or email, soft-deleted ones
+// included.
+func (a *Admin) SearchUsers(ctx context.Context, q UserQuery) ([]domain.User, error) {
+	return a.users.Search(ctx, q)
+}
+
+// ExportUsers calls fn for every user, soft-deleted ones included.
+func (a *Admin) ExportUsers(ctx context.Context, fn func(domain.User) error) error {
+	return a.users.Export(ctx, fn)
+}
+
+// SuspendUser deactivates the user with id and revokes their server
+// sessions. Tokens already issued as JWTs stay valid until they expire.
+func (a *Admin) SuspendUser(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	user, err := a.users.Suspend(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if a.Sessions != nil {
+		if _, err := a.Sessions.RevokeAll(ctx, id); err != nil {
+			return nil, err
+		}
+	}
+	return user, nil
+}
+
+// VerifyUser marks the email of the user with id as verified.
+func (a *Admin) VerifyUser(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	return a.users.Verify(ctx, id)
+}
+
+// emailDeliveries caps how many of a user's emails EmailDeliveries
+// returns.
+const emailDeliveries = 50
+
+// EmailDeliveries returns the latest emails sent to the user q finds,
+// newest first, so support can tell whether one went out.
+func (a *Admin) EmailDeliveries(ctx context.Context, q UserQuery) ([]domain.Delivery, error) {
+	if err := authorize(ctx, a.authz, ActionReadEmails, domain.Resource{Type: "email_delivery"}); err != nil {
+		return nil, err
+	}
+	if a.Deliveries == nil {
+		return nil, fmt.Errorf("email deliveries are not recorded: %w", errors.ErrUnsupported)
+	}
+	users, err := a.users.Search(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	if len(users) == 0 {
+		return nil, domain.ErrUserNotFound
+	}
+	deliveries, err := a.Deliveries.ListByUser(ctx, users[0].ID, emailDeliveries)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list email deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+// LiftSuppression sends email to address again, e.g. once its owner has
+// fixed the mailbox that bounced.
+func (a *Admin) LiftSuppression(ctx context.Context, address string) error {
+	if err := authorize(ctx, a.authz, ActionLiftEmail, domain.Resource{Type: "email_suppression"}); err != nil {
+		return err
+	}
+	if a.Suppressions == nil {
+		return fmt.Errorf("email suppressions are not kept: %w", errors.ErrUnsupported)
+	}
+	if err := a.Suppressions.Lift(ctx, address); err != nil {
+		return fmt.Errorf("failed to lift suppression: %w", err)
+	}
+	return nil
+}
+
+// Pools returns the status of every managed pool, by name.
+func (a *Admin) Pools(ctx context.Context) ([]PoolStatus, error) {
+	if err := authorize(ctx, a.authz, ActionReadWorkers, domain.Resource{Type: "worker_pool"}); err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(a.pools))
+	for name := range a.pools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	statuses := make([]PoolStatus, 0, len(names))
+	for _, name := range names {
+		statuses = append(statuses, a.status(name))
+	}
+	return statuses, nil
+}
+
+// PausePool stops the pool name from starting jobs.
+func (a *Admin) PausePool(ctx context.Context, name string) (PoolStatus, error) {
+	pool, err := a.pool(ctx, name)
+	if err != nil {
+		return PoolStatus{}, err
+	}
+	pool.Pause()
+	return a.status(name), nil
+}
+
+// ResumePool undoes PausePool and DrainPool.
+func (a *Admin) ResumePool(ctx context.Context, name string) (PoolStatus, error) {
+	pool, err := a.pool(ctx, name)
+	if err != nil {
+		return PoolStatus{}, err
+	}
+	pool.Resume()
+	return a.status(name), nil
+}
+
+// DrainPool turns new jobs for the pool name away and waits, until ctx
+// ends, for the queued ones. drained reports whether the pool emptied.
+func (a *Admin) DrainPool(ctx context.Context, name string) (status PoolStatus, drained bool, err error) {
+	pool, err := a.pool(ctx, name)
+	if err != nil {
+		return PoolStatus{}, false, err
+	}
+	drained = pool.Drain(ctx)
+	return a.status(name), drained, nil
+}
+
+// RetryDeadLetters queues the failed jobs parked by the pool name again
+// and returns how many were queued.
+func (a *Admin) RetryDeadLetters(ctx context.Context, name string) (int, error) {
+	pool, err := a.pool(ctx, name)
+	if err != nil {
+		return 0, err
+	}
+	return pool.RetryDeadLetters(ctx)
+}
+
+// FlushCache empties the cache name for ctx's tenant and returns how
+// many keys went.
+func (a *Admin) FlushCache(ctx context.Context, name string) (int, error) {
+	if err := authorize(ctx, a.authz, ActionFlushCache, domain.Resource{Type: "cache", ID: name}); err != nil {
+		return 0, err
+	}
+	cache, ok := a.caches[name]
+	if !ok {
+		return 0, fmt.Errorf("%w %q", ErrUnknownCache, name)
+	}
+	n, err := cache.Flush(ctx)
+	if err != nil {
+		return n, fmt.Errorf("failed to flush %s cache: %w", name, err)
+	}
+	return n, nil
+}
+
+func (a *Admin) pool(ctx context.Context, name string) (ManagedPool, error) {
+	if err := authorize(ctx, a.authz, ActionManagePool, domain.Resource{Type: "worker_pool", ID: name}); err != nil {
+		return nil, err
+	}
+	pool, ok := a.pools[name]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownPool, name)
+	}
+	return pool, nil
+}
+
+func (a *Admin) status(name string) PoolStatus {
+	s := a.pools[name].Status()
+	s.Name = name
+	return s
+}
@@ -0,0 +1,51 @@
+package core
+
+import (
+	"context"
+
+	"clean_go_system/internal/domain"
+)
+
+// Actions checked by the use cases.
+const (
+	ActionReadUser    = "users:read"
+	ActionListUsers   = "users:list"
+	ActionDeleteUser  = "users:delete"
+	ActionUploadImage = "images:upload"
+	ActionSearchUsers = "users:search"
+	ActionExportUsers = "users:export"
+	ActionSuspendUser = "users:suspend"
+	ActionVerifyUser  = "users:verify"
+	ActionReadWorkers = "workers:read"
+	ActionManagePool  = "workers:manage"
+	ActionFlushCache  = "caches:flush"
+	ActionReadEmails  = "emails:read"
+	ActionLiftEmail   = "emails:lift"
+)
+
+type actorContextKey struct{}
+
+// WithActor records who the use cases called with ctx act for. Inbound
+// adapters set it once they have authenticated the caller.
+func WithActor(ctx context.Context, actor domain.Actor) context.Context {
+	return context.WithValue(ctx, actorContextKey{}, actor)
+}
+
+// ActorFrom returns the actor recorded by WithActor, if any.
+func ActorFrom(ctx context.Context) (domain.Actor, bool) {
+	actor, ok := ctx.Value(actorContextKey{}).(domain.Actor)
+	return actor, ok
+}
+
+// authorize asks authz whether ctx's actor may perform action on
+// resource. A nil authz allows everything; without an actor, nothing is.
+func authorize(ctx context.Context, authz domain.Authorizer, action string, resource domain.Resource) error {
+	if authz == nil {
+		return nil
+	}
+	actor, ok := ActorFrom(ctx)
+	if !ok {
+		return domain.ErrForbidden
+	}
+	return authz.Authorize(ctx, actor, action, resource)
+}
@@ -0,0 +1,64 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"clean_go_system/internal/domain"
+)
+
+// Bounces acts on the bounce and complaint reports of the email provider:
+// addresses that bounced for good or complained are suppressed, so no
+// more email goes to them, and the delivery of the reported message is
+// marked.
+type Bounces struct {
+	suppressions domain.SuppressionList
+	deliveries   domain.DeliveryRepository
+}
+
+// NewBounces returns Bounces over suppressions. deliveries may be nil, in
+// which case only the suppression list is kept.
+func NewBounces(suppressions domain.SuppressionList, deliveries domain.DeliveryRepository) *Bounces {
+	return &Bounces{suppressions: suppressions, deliveries: deliveries}
+}
+
+// Handle applies reports in order. Every step is idempotent, so a batch
+// that failed halfway can be delivered again as a whole.
+func (b *Bounces) Handle(ctx context.Context, reports []domain.BounceReport) error {
+	for _, r := range reports {
+		if err := b.handle(ctx, r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *Bounces) handle(ctx context.Context, r domain.BounceReport) error {
+	if r.Undeliverable() && r.Email != "" {
+		err := b.suppressions.Suppress(ctx, domain.Suppression{Email: r.Email, Reason: r.Kind, Detail: r.Detail, At: r.At})
+		if err != nil {
+			return fmt.Errorf("failed to suppress address: %w", err)
+		}
+	}
+	if b.deliveries == nil || r.ProviderID == "" {
+		return nil
+	}
+
+	d, err := b.deliveries.GetByProviderID(ctx, r.ProviderID)
+	if errors.Is(err, domain.ErrDeliveryNotFound) {
+		// Not ours to track, e.g. sent before deliveries were recorded.
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to find delivery: %w", err)
+	}
+	d.Status, d.Error, d.Attempts, d.UpdatedAt = domain.DeliveryBounced, r.Detail, 0, r.At
+	if r.Kind == domain.BounceComplaint {
+		d.Status = domain.DeliveryComplained
+	}
+	if err := b.deliveries.Record(ctx, *d); err != nil {
+		return fmt.Errorf("failed to record bounce of delivery %s: %w", d.ID, err)
+	}
+	return nil
+}
@@ -0,0 +1,200 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"slices"
+	"time"
+
+	"clean_go_system/internal/domain"
+	"clean_go_system/pkg/clock"
+	"clean_go_system/pkg/idgen"
+	"github.com/google/uuid"
+)
+
+// NotificationPreferences lets users choose how their notifications reach
+// them.
+type NotificationPreferences struct {
+	// Clock stamps changes; it defaults to the wall clock.
+	Clock domain.Clock
+
+	prefs domain.NotificationPreferencesRepository
+}
+
+func NewNotificationPreferences(prefs domain.NotificationPreferencesRepository) *NotificationPreferences {
+	return &NotificationPreferences{Clock: clock.System, prefs: prefs}
+}
+
+// Get returns what userID chose, or the defaults if they never did.
+func (p *NotificationPreferences) Get(ctx context.Context, userID uuid.UUID) (domain.NotificationPreferences, error) {
+	saved, err := p.prefs.Get(ctx, userID)
+	if err != nil || saved == nil {
+		return domain.NotificationPreferences{UserID: userID, Digest: domain.DigestImmediate}, err
+	}
+	return *saved, nil
+}
+
+// SetDigest sets how often userID gets their digest. Items already held
+// are still sent at the frequency they were held for.
+func (p *NotificationPreferences) SetDigest(ctx context.Context, userID uuid.UUID, frequency domain.DigestFrequency) (domain.NotificationPreferences, error) {
+	if !frequency.Valid() {
+		return domain.NotificationPreferences{}, domain.ErrInvalidDigestFrequency
+	}
+	prefs := domain.NotificationPreferences{UserID: userID, Digest: frequency, UpdatedAt: p.Clock.Now()}
+	return prefs, p.prefs.Save(ctx, prefs)
+}
+
+// DigestEmail is what the "notification.digest" template is rendered
+// with.
+type DigestEmail struct {
+	Frequency domain.DigestFrequency
+	Items     []DigestEntry
+}
+
+// DigestEntry is one notification within a digest.
+type DigestEntry struct {
+	Subject string
+	Body    string
+	At      time.Time
+}
+
+// Digests implements domain.Notifier around the email notifier: the
+// low-priority events it is given are held for users who chose a daily or
+// weekly digest, and sent to them as one email per digest when Flush runs
+// on schedule. Everything else goes straight through.
+type Digests struct {
+	// Clock stamps held items and bounds each flush; it defaults to the
+	// wall clock.
+	Clock domain.Clock
+	// IDs mints item IDs; it defaults to UUIDv7.
+	IDs domain.IDGenerator
+	// Batch is how many items a flush reads at a time. A user with more
+	// pending than that gets them in more than one email.
+	Batch int
+	// Logger reports flushes that sent anything; it defaults to discarding.
+	Logger *log.Logger
+
+	next      domain.Notifier
+	prefs     *NotificationPreferences
+	items     domain.DigestRepository
+	templates domain.MessageTemplates
+	events    []string
+}
+
+// NewDigests holds the notifications of events for digests, and sends the
+// rest and the digests through next.
+func NewDigests(next domain.Notifier, prefs *NotificationPreferences, items domain.DigestRepository, templates domain.MessageTemplates, events []string) *Digests {
+	return &Digests{
+		Clock:     clock.System,
+		IDs:       idgen.UUIDv7{},
+		Batch:     500,
+		Logger:    log.New(io.Discard, "", 0),
+		next:      next,
+		prefs:     prefs,
+		items:     items,
+		templates: templates,
+		events:    events,
+	}
+}
+
+// Notify holds n for its user's digest, or sends it now if its event is
+// not a digest event or the user wants notifications immediately.
+func (d *Digests) Notify(ctx context.Context, n domain.Notification) error {
+	if n.UserID == uuid.Nil || !slices.Contains(d.events, n.Event) {
+		return d.next.Notify(ctx, n)
+	}
+	prefs, err := d.prefs.Get(ctx, n.UserID)
+	if err != nil {
+		return err
+	}
+	if prefs.Digest == domain.DigestImmediate {
+		return d.next.Notify(ctx, n)
+	}
+	return d.items.Add(ctx, domain.DigestItem{
+		ID:        d.IDs.NewID(),
+		UserID:    n.UserID,
+		Email:     n.To,
+		Frequency: prefs.Digest,
+		Event:     n.Event,
+		Locale:    n.Locale,
+		Subject:   n.Subject,
+		Body:      n.Body,
+		CreatedAt: d.Clock.Now(),
+	})
+}
+
+// Job returns the scheduled job flushing frequency's digests.
+func (d *Digests) Job(frequency domain.DigestFrequency) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		n, err := d.Flush(ctx, frequency)
+		if n > 0 {
+			d.Logger.Printf("digests: sent %d %s digests", n, frequency)
+		}
+		return err
+	}
+}
+
+// Flush sends every user with items held at frequency one email listing
+// them, and returns how many went out. Items are dropped once their email
+// is queued; a user whose email could not be queued keeps theirs for the
+// next flush.
+func (d *Digests) Flush(ctx context.Context, frequency domain.DigestFrequency) (int, error) {
+	// Items held while flushing wait for the next run, so a steady
+	// stream of them cannot keep the flush going.
+	before := d.Clock.Now()
+	sent := 0
+	for {
+		items, err := d.items.Pending(ctx, frequency, before, d.Batch)
+		if err != nil {
+			return sent, err
+		}
+		for _, userItems := range byUser(items) {
+			if err := d.send(ctx, frequency, userItems); err != nil {
+				return sent, err
+			}
+			sent++
+		}
+		if len(items) == 0 || len(items) < d.Batch {
+			return sent, nil
+		}
+	}
+}
+
+func (d *Digests) send(ctx context.Context, frequency domain.DigestFrequency, items []domain.DigestItem) error {
+	data := DigestEmail{Frequency: frequency, Items: make([]DigestEntry, 0, len(items))}
+	ids := make([]uuid.UUID, 0, len(items))
+	for _, item := range items {
+		data.Items = append(data.Items, DigestEntry{Subject: item.Subject, Body: item.Body, At: item.CreatedAt})
+		ids = append(ids, item.ID)
+	}
+	// The address and language are those of the latest item, in case the
+	// user changed theirs in between.
+	last := items[len(items)-1]
+	msg, err := d.templates.Render("notification.digest", last.Locale, data)
+	if err != nil {
+		return fmt.Errorf("render notification.digest: %w", err)
+	}
+	err = d.next.Notify(ctx, domain.Notification{Channel: domain.ChannelEmail, To: last.Email, UserID: last.UserID, Event: "notification.digest", Locale: last.Locale, Message: msg})
+	if err != nil {
+		return fmt.Errorf("digest for user %s: %w", last.UserID, err)
+	}
+	return d.items.Delete(ctx, ids)
+}
+
+// byUser groups items by user, keeping the order of both.
+func byUser(items []domain.DigestItem) [][]domain.DigestItem {
+	var groups [][]domain.DigestItem
+	index := make(map[uuid.UUID]int)
+	for _, item := range items {
+		i, ok := index[item.UserID]
+		if !ok {
+			i = len(groups)
+			index[item.UserID] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], item)
+	}
+	return groups
+}
@@ -0,0 +1,74 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"clean_go_system/internal/domain"
+	"clean_go_system/pkg/clock"
+	"clean_go_system/pkg/idgen"
+	"github.com/google/uuid"
+)
+
+// TrackedQueue records a queued delivery for every job sent to a user
+// before handing it to the queue, so support can see emails that never
+// left it. An EmailDelivery with the same repository records the rest.
+type TrackedQueue struct {
+	// Clock stamps delivery records; it defaults to the wall clock.
+	Clock domain.Clock
+	// IDs mints delivery IDs; it defaults to UUIDv7.
+	IDs domain.IDGenerator
+
+	queue      EmailQueue
+	deliveries domain.DeliveryRepository
+}
+
+func NewTrackedQueue(queue EmailQueue, deliveries domain.DeliveryRepository) *TrackedQueue {
+	return &TrackedQueue{
+		Clock:      clock.System,
+		IDs:        idgen.UUIDv7{},
+		queue:      queue,
+		deliveries: deliveries,
+	}
+}
+
+// Enqueue records job as queued and queues it. Jobs that name no user
+// are queued untracked. A job the queue turns away is recorded as failed.
+func (q *TrackedQueue) Enqueue(ctx context.Context, job EmailJob) error {
+	if job.UserID == uuid.Nil {
+		return q.queue.Enqueue(ctx, job)
+	}
+	job.ID = q.IDs.NewID()
+	if err := recordDelivery(ctx, q.deliveries, q.Clock.Now(), job, domain.DeliveryQueued, 0, "", nil); err != nil {
+		return err
+	}
+	if err := q.queue.Enqueue(ctx, job); err != nil {
+		// ctx may be why the queue gave up; the record must not.
+		recErr := recordDelivery(context.WithoutCancel(ctx), q.deliveries, q.Clock.Now(), job, domain.DeliveryFailed, 0, "", err)
+		return errors.Join(err, recErr)
+	}
+	return nil
+}
+
+func recordDelivery(ctx context.Context, deliveries domain.DeliveryRepository, now time.Time, job EmailJob, status domain.DeliveryStatus, attempts int, providerID string, cause error) error {
+	d := domain.Delivery{
+		ID:         job.ID,
+		UserID:     job.UserID,
+		Template:   job.Template,
+		Subject:    job.Subject,
+		Status:     status,
+		Attempts:   attempts,
+		ProviderID: providerID,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if cause != nil {
+		d.Error = cause.Error()
+	}
+	if err := deliveries.Record(ctx, d); err != nil {
+		return fmt.Errorf("failed to record delivery %s: %w", job.ID, err)
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"clean_go_system/internal/domain"
+	"clean_go_system/pkg/clock"
+)
+
+// ErrEmailThrottled fails a job that hit a send limit after its
+// EmailThrottle stopped deferring, so the queue keeps it for later.
+var ErrEmailThrottled = errors.New("email send limit reached")
+
+// EmailThrottle paces the sends of an email job handler: Global caps them
+// through the provider, Domains per recipient domain (e.g. 600 a minute
+// to gmail.com), metered by a RateLimiter shared by every worker process.
+// A job over a limit is not failed but deferred: it goes back to the
+// queue once the limit frees up, so the provider never sees the burst.
+//
+// Deferred jobs wait in this process. Stop hands them back to the queue
+// straight away, so they reach it before the process exits.
+type EmailThrottle struct {
+	// Global applies to every job; a zero Count leaves it off.
+	Global domain.RateLimit
+	// Domains applies to jobs to each domain, keyed in lower case.
+	Domains map[string]domain.RateLimit
+	// QueueWait is how long a deferred job waits for room in the queue.
+	QueueWait time.Duration
+	// OnFailure receives the deferred jobs the queue turned away, e.g. to
+	// park them in a dead-letter store. They are logged either way.
+	OnFailure func(job EmailJob, err error)
+	// Clock times deferrals; it defaults to the wall clock.
+	Clock  domain.Clock
+	Logger *log.Logger
+
+	limiter domain.RateLimiter
+	queue   EmailQueue
+	next    func(ctx context.Context, job EmailJob) error
+
+	mu       sync.Mutex
+	stopped  bool
+	stopping chan struct{}
+	wg       sync.WaitGroup
+}
+
+// NewEmailThrottle paces next, usually an EmailDelivery's Process, and
+// defers the jobs over a limit to queue. Queue should be the one feeding
+// next, not a TrackedQueue, so a deferred job keeps its delivery record.
+func NewEmailThrottle(limiter domain.RateLimiter, queue EmailQueue, next func(ctx context.Context, job EmailJob) error) *EmailThrottle {
+	return &EmailThrottle{
+		QueueWait: 5 * time.Second,
+		Clock:     clock.System,
+		Logger:    log.New(io.Discard, "", 0),
+		limiter:   limiter,
+		queue:     queue,
+		next:      next,
+		stopping:  make(chan struct{}),
+	}
+}
+
+// Process hands job to the next handler when its domain and the provider
+// have room for it, and defers it otherwise. The domain limit is checked
+// first, so jobs to a throttled domain leave the global budget to others.
+// A limiter that fails lets the job through: the provider's own limits
+// still hold.
+func (t *EmailThrottle) Process(ctx context.Context, job EmailJob) error {
+	to := recipientDomain(job.Email)
+	if limit, ok := t.Domains[to]; ok {
+		if wait := t.allow(ctx, "email:domain:"+to, limit); wait > 0 {
+			return t.deferJob(job, wait)
+		}
+	}
+	if wait := t.allow(ctx, "email:provider", t.Global); wait > 0 {
+		return t.deferJob(job, wait)
+	}
+	return t.next(ctx, job)
+}
+
+// allow reports how long key has to wait under limit, 0 when it may go.
+func (t *EmailThrottle) allow(ctx context.Context, key string, limit domain.RateLimit) time.Duration {
+	if limit.Count <= 0 {
+		return 0
+	}
+	ok, retryAfter, err := t.limiter.Allow(ctx, key, limit)
+	if err != nil {
+		t.Logger.Printf("email: %s not throttled: %v", key, err)
+		return 0
+	}
+	if ok {
+		return 0
+	}
+	// A limiter may round a wait down to nothing; the job still has to.
+	return max(retryAfter, time.Millisecond)
+}
+
+// deferJob queues job again after wait, or as soon as Stop is called.
+func (t *EmailThrottle) deferJob(job EmailJob, wait time.Duration) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.stopped {
+		return fmt.Errorf("%w: try again in %s", ErrEmailThrottled, wait)
+	}
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		select {
+		case <-t.Clock.After(wait):
+		case <-t.stopping:
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), t.QueueWait)
+		defer cancel()
+		if err := t.queue.Enqueue(ctx, job); err != nil {
+			t.Logger.Printf("email: throttled job %s (%s) not queued again: %v", job.ID, job.Template, err)
+			if t.OnFailure != nil {
+				t.OnFailure(job, err)
+			}
+		}
+	}()
+	return nil
+}
+
+// Stop queues every deferred job again now and waits until they are in
+// the queue or ctx ends. Jobs over a limit after Stop fail with
+// ErrEmailThrottled. Call it before the queue stops taking jobs.
+func (t *EmailThrottle) Stop(ctx context.Context) error {
+	t.mu.Lock()
+	if !t.stopped {
+		t.stopped = true
+		close(t.stopping)
+	}
+	t.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		t.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("throttled emails not queued again: %w", ctx.Err())
+	}
+}
+
+// recipientDomain is the lower-cased domain of an address, "" if it has
+// none.
+func recipientDomain(address string) string {
+	at := strings.LastIndexByte(address, '@')
+	if at < 0 {
+		return ""
+	}
+	return strings.ToLower(address[at+1:])
+}
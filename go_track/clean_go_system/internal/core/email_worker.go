@@ -1,50 +1,143 @@
 package core
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"sync"
+	"io"
+	"log"
+	"time"
+
+	"clean-code-cookbook/go/pkg/retry"
+	"clean_go_system/internal/domain"
+	"clean_go_system/pkg/clock"
+	"github.com/google/uuid"
 )
 
 // Job represents the work to be done
 type EmailJob struct {
-	Email string
-	Body  string
+	// ID is the job's delivery record, set by a TrackedQueue. Jobs without
+	// one are sent untracked.
+	ID       uuid.UUID `json:"id,omitempty"`
+	UserID   uuid.UUID `json:"user_id,omitempty"`
+	Template string    `json:"template,omitempty"`
+	Email    string    `json:"email"`
+	Subject  string    `json:"subject,omitempty"`
+	Body     string    `json:"body"`
+	HTML     string    `json:"html,omitempty"`
+}
+
+// EmailQueue accepts email jobs for background delivery. The in-memory
+// WorkerPool and broker-backed queues (RabbitMQ) both implement it.
+type EmailQueue interface {
+	Enqueue(ctx context.Context, job EmailJob) error
+}
+
+// EnqueueRequested returns the handler feeding EmailRequested events,
+// relayed from the outbox, to queue. It waits up to wait for room and
+// fails with the queue's error otherwise, so the relay retries the event.
+func EnqueueRequested(queue EmailQueue, wait time.Duration) func(ctx context.Context, e domain.EmailRequested) error {
+	return func(ctx context.Context, e domain.EmailRequested) error {
+		ctx, cancel := context.WithTimeout(ctx, wait)
+		defer cancel()
+		return queue.Enqueue(ctx, EmailJob{UserID: e.UserID, Template: e.Template, Email: e.Email, Subject: e.Subject, Body: e.Body, HTML: e.HTML})
+	}
+}
+
+// EmailDelivery sends email jobs, whichever queue delivered them, through
+// an EmailSender.
+type EmailDelivery struct {
+	// Retry covers a throttled provider, outages and network errors of one
+	// send. A rejected message is never retried.
+	Retry retry.Policy
+	// Deliveries, if set, records how each tracked job went.
+	Deliveries domain.DeliveryRepository
+	// Suppressions, if set, skips jobs to addresses on the list.
+	Suppressions domain.SuppressionList
+	// Clock stamps delivery records; it defaults to the wall clock.
+	Clock domain.Clock
+	// Logger reports sent emails whose delivery could not be recorded;
+	// failing the job over it would send them twice.
+	Logger *log.Logger
+
+	sender domain.EmailSender
+}
+
+func NewEmailDelivery(sender domain.EmailSender) *EmailDelivery {
+	return &EmailDelivery{
+		Retry: retry.Policy{
+			Attempts: 3,
+			Backoff:  retry.Jitter(retry.Exponential(time.Second, 30*time.Second)),
+		},
+		Clock:  clock.System,
+		Logger: log.New(io.Discard, "", 0),
+		sender: sender,
+	}
 }
 
-// WorkerPool manages concurrency
-type WorkerPool struct {
-	JobQueue chan EmailJob
-	Workers  int
-	wg       sync.WaitGroup
+// Process sends one job. Its error is the sender's last, so the queue can
+// retry the job later or, for domain.ErrEmailRejected, dead-letter it
+// straight away. A job to a suppressed address is done without a send.
+func (d *EmailDelivery) Process(ctx context.Context, job EmailJob) error {
+	if d.Suppressions != nil {
+		s, err := d.Suppressions.Suppressed(ctx, job.Email)
+		if err != nil {
+			return fmt.Errorf("failed to check suppression list: %w", err)
+		}
+		if s != nil {
+			cause := fmt.Errorf("address suppressed after %s on %s", s.Reason, s.At.Format(time.DateOnly))
+			return d.record(ctx, job, domain.DeliverySuppressed, 0, "", cause)
+		}
+	}
+
+	policy := d.Retry
+	retryIf := policy.RetryIf
+	policy.RetryIf = func(err error) bool {
+		return !errors.Is(err, domain.ErrEmailRejected) && (retryIf == nil || retryIf(err))
+	}
+	msg := domain.Email{To: job.Email, Subject: job.Subject, Text: job.Body, HTML: job.HTML}
+	var (
+		attempts   int
+		providerID string
+	)
+	err := retry.Do(ctx, policy, func(ctx context.Context) (err error) {
+		attempts++
+		providerID, err = d.sender.Send(ctx, msg)
+		return err
+	})
+	if err != nil {
+		err = fmt.Errorf("failed to send email: %w", err)
+	}
+
+	status := domain.DeliverySent
+	switch {
+	case errors.Is(err, domain.ErrEmailRejected):
+		status = domain.DeliveryBounced
+	case err != nil:
+		status = domain.DeliveryFailed
+	}
+	if recErr := d.record(ctx, job, status, attempts, providerID, err); recErr != nil {
+		if err != nil {
+			return errors.Join(err, recErr)
+		}
+		d.Logger.Printf("email: %v", recErr)
+	}
+	return err
+}
+
+// record saves how the tracked job went, if deliveries are recorded.
+func (d *EmailDelivery) record(ctx context.Context, job EmailJob, status domain.DeliveryStatus, attempts int, providerID string, sendErr error) error {
+	if d.Deliveries == nil || job.ID == uuid.Nil {
+		return nil
+	}
+	return recordDelivery(ctx, d.Deliveries, d.Clock.Now(), job, status, attempts, providerID, sendErr)
 }
 
+// WorkerPool is the in-memory email queue.
+type WorkerPool = Pool[EmailJob]
+
+// NewWorkerPool returns an email pool; set its Process, usually an
+// EmailDelivery's, before starting it.
 func NewWorkerPool(workers int, bufferSize int) *WorkerPool {
-	return &WorkerPool{
-		JobQueue: make(chan EmailJob, bufferSize), // Buffered Channel
-		Workers:  workers,
-	}
-}
-
-func (wp *WorkerPool) Start() {
-	for i := 0; i < wp.Workers; i++ {
-		wp.wg.Add(1)
-		go func(workerID int) {
-			defer wp.wg.Done()
-			fmt.Printf("Worker %d started\n", workerID)
-			
-			// Range over channel: This loop blocks until a job comes in
-			// It exits when the channel is closed.
-			for job := range wp.JobQueue {
-				fmt.Printf("Worker %d processing email to %s\n", workerID, job.Email)
-				// Simulate sending email
-				// time.Sleep(100 * time.Millisecond) 
-			}
-			fmt.Printf("Worker %d stopped\n", workerID)
-		}(i)
-	}
-}
-
-func (wp *WorkerPool) Stop() {
-	close(wp.JobQueue) // This signals all workers to finish current loop and exit
-	wp.wg.Wait()       // Wait for all goroutines to finish
-}
\ No newline at end of file
+	return NewPool[EmailJob]("email", workers, bufferSize, nil)
+}
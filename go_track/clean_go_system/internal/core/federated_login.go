@@ -0,0 +1,96 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"clean_go_system/internal/domain"
+	"clean_go_system/pkg/clock"
+)
+
+// ExternalProfile is what an identity provider vouched for at login.
+type ExternalProfile struct {
+	Issuer        string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Username      string
+}
+
+// FederatedLogin signs users in through an external identity provider,
+// linking the provider account to a local user on first login.
+type FederatedLogin struct {
+	// Clock stamps new links; it defaults to the wall clock.
+	Clock domain.Clock
+
+	users      *UserService
+	repo       domain.UserRepository
+	identities domain.IdentityRepository
+	tx         domain.Transactor
+}
+
+func NewFederatedLogin(users *UserService, repo domain.UserRepository, identities domain.IdentityRepository, tx domain.Transactor) *FederatedLogin {
+	return &FederatedLogin{Clock: clock.System, users: users, repo: repo, identities: identities, tx: tx}
+}
+
+// SignIn returns the user linked to the provider account. An unlinked
+// account is linked to the user with the same email, provided the
+// provider verified it, or else registered as a new user.
+func (f *FederatedLogin) SignIn(ctx context.Context, p ExternalProfile) (*domain.User, error) {
+	// 1. Known account
+	user, err := f.Resolve(ctx, p.Issuer, p.Subject)
+	if !errors.Is(err, domain.ErrIdentityUnknown) {
+		return user, err
+	}
+
+	// 2. An unverified email could belong to someone else: never link it
+	if !p.EmailVerified {
+		invalid := domain.ValidationError{Fields: []domain.FieldError{{
+			Field: "email", Rule: "verified", Message: "provider did not verify " + p.Email, Err: domain.ErrInvalidEmail,
+		}}}
+		return nil, &invalid
+	}
+
+	// 3. Link an existing user or register one, atomically with the link
+	err = f.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		user, err = f.repo.GetByEmail(ctx, p.Email)
+		if errors.Is(err, domain.ErrUserNotFound) {
+			user, err = f.users.Register(ctx, p.Email, usernameFor(p))
+		}
+		if err != nil {
+			return err
+		}
+		return f.identities.Link(ctx, domain.FederatedIdentity{
+			Issuer:   p.Issuer,
+			Subject:  p.Subject,
+			UserID:   user.ID,
+			LinkedAt: f.Clock.Now(),
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to link identity: %w", err)
+	}
+	return user, nil
+}
+
+// Resolve returns the user linked to the provider account, or
+// ErrIdentityUnknown.
+func (f *FederatedLogin) Resolve(ctx context.Context, issuer, subject string) (*domain.User, error) {
+	link, err := f.identities.Find(ctx, issuer, subject)
+	if err != nil {
+		return nil, err
+	}
+	return f.repo.GetByID(ctx, link.UserID)
+}
+
+// usernameFor prefers the provider's username and falls back to the
+// email's local part when that is not a valid local username.
+func usernameFor(p ExternalProfile) string {
+	if domain.ValidateUsername(p.Username) == nil {
+		return p.Username
+	}
+	local, _, _ := strings.Cut(p.Email, "@")
+	return local
+}
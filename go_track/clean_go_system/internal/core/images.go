@@ -0,0 +1,176 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"clean_go_system/internal/domain"
+	"clean_go_system/pkg/clock"
+)
+
+// ResizeJob asks for the resized variants of an uploaded image.
+type ResizeJob struct {
+	Image domain.Image `json:"image"`
+}
+
+// ImageQueue accepts resize jobs for background processing. The in-memory
+// Pool[ResizeJob] implements it.
+type ImageQueue interface {
+	Enqueue(ctx context.Context, job ResizeJob) error
+}
+
+// ImageService stores uploaded avatars and product images, has them
+// resized in the background and hands out signed links to them.
+type ImageService struct {
+	// Clock stamps link expiry; it defaults to the wall clock.
+	Clock domain.Clock
+	// MaxBytes caps the size of an upload.
+	MaxBytes int64
+	// URLExpiry is how long a link from URL stays valid.
+	URLExpiry time.Duration
+	// QueueWait bounds how long an upload waits for room in the resize
+	// queue before failing with ErrQueueFull.
+	QueueWait time.Duration
+
+	blobs   domain.BlobStore
+	resizer domain.ImageResizer
+	queue   ImageQueue
+	authz   domain.Authorizer
+}
+
+func NewImageService(blobs domain.BlobStore, resizer domain.ImageResizer, queue ImageQueue, authz domain.Authorizer) *ImageService {
+	return &ImageService{
+		Clock:     clock.System,
+		MaxBytes:  5 << 20,
+		URLExpiry: 15 * time.Minute,
+		QueueWait: time.Second,
+		blobs:     blobs,
+		resizer:   resizer,
+		queue:     queue,
+		authz:     authz,
+	}
+}
+
+// Upload streams r into storage as a new image of kind for owner (a user
+// ID or a product SKU) and queues it for resizing. The format is sniffed
+// from the content; anything but JPEG and PNG fails with
+// ErrUnsupportedImage, anything over MaxBytes with ErrImageTooLarge.
+func (s *ImageService) Upload(ctx context.Context, kind domain.ImageKind, owner string, r io.Reader) (domain.Image, error) {
+	// 1. Authorize
+	if err := authorize(ctx, s.authz, ActionUploadImage, domain.Resource{Type: string(kind), ID: owner}); err != nil {
+		return domain.Image{}, err
+	}
+
+	// 2. Sniff the format
+	br := bufio.NewReader(r)
+	head, err := br.Peek(8)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return domain.Image{}, fmt.Errorf("failed to read image: %w", err)
+	}
+	img, err := domain.NewImage(kind, owner, domain.SniffImageType(head))
+	if err != nil {
+		return domain.Image{}, err
+	}
+
+	// 3. Store the original
+	body := &cappedReader{r: br, left: s.MaxBytes}
+	if err := s.blobs.Put(ctx, img.Key(domain.OriginalVariant), body, -1, img.ContentType()); err != nil {
+		if body.exceeded {
+			return domain.Image{}, domain.ErrImageTooLarge
+		}
+		return domain.Image{}, fmt.Errorf("failed to store image: %w", err)
+	}
+
+	// 4. Queue the resize
+	qctx, cancel := context.WithTimeout(ctx, s.QueueWait)
+	defer cancel()
+	if err := s.queue.Enqueue(qctx, ResizeJob{Image: img}); err != nil {
+		// Without its job the original would never get variants.
+		_ = s.blobs.Delete(context.WithoutCancel(ctx), img.Key(domain.OriginalVariant))
+		return domain.Image{}, err
+	}
+	return img, nil
+}
+
+// ProcessResizeJob makes every variant of the job's image from its
+// original. Variants are independent objects, so a retried job simply
+// overwrites what an earlier attempt wrote.
+func (s *ImageService) ProcessResizeJob(ctx context.Context, job ResizeJob) error {
+	img := job.Image
+	if err := img.Validate(); err != nil {
+		return err
+	}
+
+	// 1. Load the original once; it is bounded by MaxBytes
+	rc, _, err := s.blobs.Get(ctx, img.Key(domain.OriginalVariant))
+	if err != nil {
+		return fmt.Errorf("failed to open original %s: %w", img.ID, err)
+	}
+	original, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		return fmt.Errorf("failed to read original %s: %w", img.ID, err)
+	}
+
+	// 2. Resize and store each variant, smallest first
+	names := make([]string, 0, len(img.Variants()))
+	for name := range img.Variants() {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return img.Variants()[names[i]] < img.Variants()[names[j]] })
+	for _, name := range names {
+		var out bytes.Buffer
+		if err := s.resizer.Resize(ctx, bytes.NewReader(original), img.ContentType(), img.Variants()[name], &out); err != nil {
+			return fmt.Errorf("failed to resize %s to %s: %w", img.ID, name, err)
+		}
+		if err := s.blobs.Put(ctx, img.Key(name), &out, int64(out.Len()), img.ContentType()); err != nil {
+			return fmt.Errorf("failed to store %s of %s: %w", name, img.ID, err)
+		}
+	}
+	return nil
+}
+
+// URL returns a signed link to variant of img and when it expires. Links
+// are not checked against storage: one for a variant still being made
+// answers 404 until its job has run.
+func (s *ImageService) URL(ctx context.Context, img domain.Image, variant string) (string, time.Time, error) {
+	if err := img.Validate(); err != nil {
+		return "", time.Time{}, err
+	}
+	if !img.HasVariant(variant) {
+		return "", time.Time{}, domain.ErrImageNotFound
+	}
+	expires := s.Clock.Now().Add(s.URLExpiry)
+	url, err := s.blobs.SignedURL(ctx, img.Key(variant), s.URLExpiry)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to sign url: %w", err)
+	}
+	return url, expires, nil
+}
+
+// cappedReader passes r through until more than left bytes have been
+// read, then fails with ErrImageTooLarge.
+type cappedReader struct {
+	r        io.Reader
+	left     int64
+	exceeded bool
+}
+
+func (c *cappedReader) Read(p []byte) (int, error) {
+	if int64(len(p)) > c.left+1 {
+		p = p[:c.left+1]
+	}
+	n, err := c.r.Read(p)
+	c.left -= int64(n)
+	if c.left < 0 {
+		c.exceeded = true
+		return n, domain.ErrImageTooLarge
+	}
+	return n, err
+}
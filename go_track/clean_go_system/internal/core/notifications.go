@@ -0,0 +1,104 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"clean_go_system/internal/domain"
+	"github.com/google/uuid"
+)
+
+// Notifications tells users about what happened to their account. Each
+// event type in Routes is rendered from Templates, in the locale the event
+// carries, and sent on the channels listed for it.
+type Notifications struct {
+	Templates domain.MessageTemplates
+	Notifiers map[domain.Channel]domain.Notifier
+	// Routes lists the channels each event type goes out on, e.g.
+	// "user.registered": {ChannelEmail}. Unlisted events send nothing.
+	Routes map[string][]domain.Channel
+	// Transactional lists the event types whose email Compose renders in
+	// the transaction that raises them; the email channel leaves them
+	// alone.
+	Transactional []string
+}
+
+// Channel returns the event handler for one channel. Channels are
+// subscribed apart, so a retry caused by a webhook that is down does not
+// send the email a second time.
+func (n *Notifications) Channel(ch domain.Channel) func(ctx context.Context, e domain.DomainEvent) error {
+	notifier := n.Notifiers[ch]
+	return func(ctx context.Context, e domain.DomainEvent) error {
+		if notifier == nil || !slices.Contains(n.Routes[e.EventName()], ch) {
+			return nil
+		}
+		if ch == domain.ChannelEmail && slices.Contains(n.Transactional, e.EventName()) {
+			return nil
+		}
+		to, userID, ok := recipient(e, ch)
+		if !ok {
+			return nil
+		}
+		msg, err := n.Templates.Render(e.EventName(), eventLocale(e), e)
+		if err != nil {
+			return fmt.Errorf("render %s: %w", e.EventName(), err)
+		}
+		return notifier.Notify(ctx, domain.Notification{Channel: ch, To: to, UserID: userID, Event: e.EventName(), Locale: eventLocale(e), Message: msg})
+	}
+}
+
+// Compose renders the email of a Transactional event routed to email. It
+// is an EmailComposer.
+func (n *Notifications) Compose(ctx context.Context, e domain.DomainEvent) (domain.EmailRequested, bool, error) {
+	if !slices.Contains(n.Transactional, e.EventName()) || !slices.Contains(n.Routes[e.EventName()], domain.ChannelEmail) {
+		return domain.EmailRequested{}, false, nil
+	}
+	to, userID, ok := recipient(e, domain.ChannelEmail)
+	if !ok {
+		return domain.EmailRequested{}, false, nil
+	}
+	msg, err := n.Templates.Render(e.EventName(), eventLocale(e), e)
+	if err != nil {
+		return domain.EmailRequested{}, false, fmt.Errorf("render %s: %w", e.EventName(), err)
+	}
+	return domain.EmailRequested{UserID: userID, Template: e.EventName(), Email: to, Subject: msg.Subject, Body: msg.Body, HTML: msg.HTML, At: e.OccurredAt()}, true, nil
+}
+
+// recipient is where e is sent on ch, and the user it goes to. Users have
+// no phone number yet, so nothing goes out by SMS; webhooks carry their
+// own URL.
+func recipient(e domain.DomainEvent, ch domain.Channel) (string, uuid.UUID, bool) {
+	switch ch {
+	case domain.ChannelWebhook:
+		return "", uuid.Nil, true
+	case domain.ChannelEmail:
+		switch e := e.(type) {
+		case domain.UserRegistered:
+			return e.Email, e.UserID, true
+		case domain.UserEmailChanged:
+			return e.NewEmail, e.UserID, true
+		case domain.UserDeactivated:
+			return e.Email, e.UserID, true
+		case domain.UserDeleted:
+			return e.Email, e.UserID, true
+		}
+	}
+	return "", uuid.Nil, false
+}
+
+// eventLocale is the language of the user e is about, or "" for the
+// default.
+func eventLocale(e domain.DomainEvent) string {
+	switch e := e.(type) {
+	case domain.UserRegistered:
+		return e.Locale
+	case domain.UserEmailChanged:
+		return e.Locale
+	case domain.UserDeactivated:
+		return e.Locale
+	case domain.UserDeleted:
+		return e.Locale
+	}
+	return ""
+}
@@ -0,0 +1,234 @@
+package core
+
+import (
+	"cmp"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"clean_go_system/internal/domain"
+	"clean_go_system/pkg/clock"
+	"clean_go_system/pkg/idgen"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// PasswordService lets users set a new password through a token mailed to
+// them. The password is stored by the auth service, where users sign in
+// with it.
+type PasswordService struct {
+	// Clock decides expiry; it defaults to the wall clock.
+	Clock domain.Clock
+	// IDs mints reset IDs; it defaults to UUIDv7.
+	IDs domain.IDGenerator
+	// TTL is how long a reset token stays valid.
+	TTL time.Duration
+	// QueueWait bounds how long a request waits for room in the email
+	// queue before failing with ErrQueueFull.
+	QueueWait time.Duration
+	// Sessions, if set, are all ended by a reset, so a stolen one does
+	// not survive it.
+	Sessions *SessionService
+	// Logger reports reset requests that failed; it defaults to
+	// discarding.
+	Logger *log.Logger
+
+	pending sync.WaitGroup
+
+	users       domain.UserRepository
+	credentials domain.CredentialRepository
+	resets      domain.PasswordResetRepository
+	tx          domain.Transactor
+	templates   domain.MessageTemplates
+	queue       EmailQueue
+}
+
+// PasswordResetEmail is what the "password.reset" template is rendered
+// with.
+type PasswordResetEmail struct {
+	Username     string
+	Token        string
+	ValidMinutes int
+}
+
+func NewPasswordService(users domain.UserRepository, credentials domain.CredentialRepository, resets domain.PasswordResetRepository, tx domain.Transactor, templates domain.MessageTemplates, queue EmailQueue) *PasswordService {
+	return &PasswordService{
+		Clock:       clock.System,
+		IDs:         idgen.UUIDv7{},
+		TTL:         30 * time.Minute,
+		QueueWait:   time.Second,
+		Logger:      log.New(io.Discard, "", 0),
+		users:       users,
+		credentials: credentials,
+		resets:      resets,
+		tx:          tx,
+		templates:   templates,
+		queue:       queue,
+	}
+}
+
+// RequestPasswordReset mails a reset token to email in the background and
+// returns at once. It answers the same for every address, in about the
+// same time, so it does not reveal who has an account; failures are only
+// logged. ctx keeps its values but not its cancellation.
+func (s *PasswordService) RequestPasswordReset(ctx context.Context, email string) error {
+	ctx = context.WithoutCancel(ctx)
+	s.pending.Add(1)
+	go func() {
+		defer s.pending.Done()
+		if err := s.mailReset(ctx, email); err != nil {
+			s.Logger.Printf("password reset: %v", err)
+		}
+	}()
+	return nil
+}
+
+// Stop waits until the resets requested so far are mailed or given up, or
+// ctx ends. Call it before the email queue stops taking jobs.
+func (s *PasswordService) Stop(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.pending.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("password resets not mailed: %w", ctx.Err())
+	}
+}
+
+// mailReset mails a reset token to email. Unknown and inactive accounts
+// get nothing.
+func (s *PasswordService) mailReset(ctx context.Context, email string) error {
+	// 1. Find the account
+	user, err := s.users.GetByEmail(ctx, email)
+	if errors.Is(err, domain.ErrUserNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load user: %w", err)
+	}
+	if !user.Active {
+		return nil
+	}
+
+	// 2. Store the hash of a fresh secret
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return fmt.Errorf("failed to generate token: %w", err)
+	}
+	reset := domain.PasswordReset{
+		ID:        s.IDs.NewID(),
+		UserID:    user.ID,
+		Hash:      hashResetSecret(secret),
+		ExpiresAt: s.Clock.Now().Add(s.TTL),
+	}
+	if err := s.resets.Save(ctx, reset); err != nil {
+		return fmt.Errorf("failed to save reset: %w", err)
+	}
+
+	// 3. Mail the token; only the user ever sees the secret
+	token := reset.ID.String() + "." + base64.RawURLEncoding.EncodeToString(secret)
+	// In the user's language, or the one they asked in if they never chose
+	msg, err := s.templates.Render("password.reset", cmp.Or(user.Locale, domain.LocaleOf(ctx)), PasswordResetEmail{
+		Username:     user.Username,
+		Token:        token,
+		ValidMinutes: int(s.TTL / time.Minute),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to render reset email: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, s.QueueWait)
+	defer cancel()
+	return s.queue.Enqueue(ctx, EmailJob{UserID: user.ID, Template: "password.reset", Email: user.Email, Subject: msg.Subject, Body: msg.Body, HTML: msg.HTML})
+}
+
+// ResetPassword sets a new password if token is a live reset token, uses
+// the token up and ends the user's sessions. Every bad token fails with
+// ErrInvalidResetToken.
+func (s *PasswordService) ResetPassword(ctx context.Context, token, password string) error {
+	// 1. Validate input
+	var invalid domain.ValidationError
+	invalid.Check("password", domain.ValidatePassword(password))
+	if err := invalid.Err(); err != nil {
+		return err
+	}
+	id, secret, err := parseResetToken(token)
+	if err != nil {
+		return err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	// 2. Redeem the token, sign the user out and store the password
+	// together: if the auth service fails, the token stays usable.
+	return s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		reset, err := s.resets.Get(ctx, id)
+		if errors.Is(err, domain.ErrInvalidResetToken) {
+			return err
+		}
+		if err != nil {
+			return fmt.Errorf("failed to load reset: %w", err)
+		}
+		// Constant time, so response timing does not leak how much of a
+		// guessed secret was right.
+		if subtle.ConstantTimeCompare(hashResetSecret(secret), reset.Hash) != 1 {
+			return domain.ErrInvalidResetToken
+		}
+		now := s.Clock.Now()
+		if !reset.Usable(now) {
+			return domain.ErrInvalidResetToken
+		}
+		if err := s.resets.MarkUsed(ctx, id, now); err != nil {
+			return err
+		}
+		user, err := s.users.GetByID(ctx, reset.UserID)
+		if err != nil {
+			return fmt.Errorf("failed to load user: %w", err)
+		}
+		if s.Sessions != nil {
+			if _, err := s.Sessions.RevokeAll(ctx, user.ID); err != nil {
+				return err
+			}
+		}
+		if err := s.credentials.SetPassword(ctx, *user, hash); err != nil {
+			return fmt.Errorf("failed to save password: %w", err)
+		}
+		return nil
+	})
+}
+
+// parseResetToken splits "<reset id>.<secret>".
+func parseResetToken(token string) (uuid.UUID, []byte, error) {
+	rawID, rawSecret, ok := strings.Cut(token, ".")
+	if !ok {
+		return uuid.Nil, nil, domain.ErrInvalidResetToken
+	}
+	id, err := uuid.Parse(rawID)
+	if err != nil {
+		return uuid.Nil, nil, domain.ErrInvalidResetToken
+	}
+	secret, err := base64.RawURLEncoding.DecodeString(rawSecret)
+	if err != nil {
+		return uuid.Nil, nil, domain.ErrInvalidResetToken
+	}
+	return id, secret, nil
+}
+
+func hashResetSecret(secret []byte) []byte {
+	sum := sha256.Sum256(secret)
+	return sum[:]
+}
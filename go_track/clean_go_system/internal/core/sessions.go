@@ -0,0 +1,161 @@
+package core
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"clean_go_system/internal/domain"
+	"clean_go_system/pkg/clock"
+	"clean_go_system/pkg/idgen"
+	"github.com/google/uuid"
+)
+
+// SessionTokenPrefix marks opaque session tokens, telling them apart from
+// JWTs in the same Authorization header.
+const SessionTokenPrefix = "sess_"
+
+// Device describes where a session was started.
+type Device struct {
+	UserAgent string
+	IP        string
+}
+
+// SessionService runs server-side sessions: unlike a JWT, a session can be
+// listed and revoked, and revocation takes effect on the next request.
+type SessionService struct {
+	// Clock stamps and expires sessions; it defaults to the wall clock.
+	Clock domain.Clock
+	// IDs mints session IDs; it defaults to UUIDv7.
+	IDs domain.IDGenerator
+	// IdleTTL is how long a session lives without use; every use extends
+	// it by that much again.
+	IdleTTL time.Duration
+	// MaxTTL caps a session's lifetime however often it is used.
+	MaxTTL time.Duration
+	// TouchEvery bounds how often use is written back, so a busy client
+	// does not cost a write per request.
+	TouchEvery time.Duration
+
+	repo domain.SessionRepository
+}
+
+func NewSessionService(repo domain.SessionRepository) *SessionService {
+	return &SessionService{
+		Clock:      clock.System,
+		IDs:        idgen.UUIDv7{},
+		IdleTTL:    7 * 24 * time.Hour,
+		MaxTTL:     30 * 24 * time.Hour,
+		TouchEvery: time.Minute,
+		repo:       repo,
+	}
+}
+
+// Create starts a session for userID and returns its token, which is not
+// stored and cannot be recovered later.
+func (s *SessionService) Create(ctx context.Context, userID uuid.UUID, device Device) (string, *domain.Session, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", nil, fmt.Errorf("failed to generate session token: %w", err)
+	}
+	token := SessionTokenPrefix + base64.RawURLEncoding.EncodeToString(raw)
+
+	now := s.Clock.Now()
+	session := domain.Session{
+		ID:         s.IDs.NewID(),
+		UserID:     userID,
+		Hash:       hashSessionToken(token),
+		UserAgent:  device.UserAgent,
+		IP:         device.IP,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  s.expiry(now, now),
+	}
+	if err := s.repo.Save(ctx, session); err != nil {
+		return "", nil, fmt.Errorf("failed to save session: %w", err)
+	}
+	return token, &session, nil
+}
+
+// Authenticate returns the live session for token and slides its expiry,
+// or ErrInvalidSession.
+func (s *SessionService) Authenticate(ctx context.Context, token string) (*domain.Session, error) {
+	if !strings.HasPrefix(token, SessionTokenPrefix) {
+		return nil, domain.ErrInvalidSession
+	}
+	session, err := s.repo.GetByHash(ctx, hashSessionToken(token))
+	if errors.Is(err, domain.ErrSessionNotFound) {
+		return nil, domain.ErrInvalidSession
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load session: %w", err)
+	}
+	now := s.Clock.Now()
+	if !session.Active(now) {
+		return nil, domain.ErrInvalidSession
+	}
+
+	if now.Sub(session.LastSeenAt) >= s.TouchEvery {
+		session.LastSeenAt, session.ExpiresAt = now, s.expiry(session.CreatedAt, now)
+		if err := s.repo.Touch(ctx, session.ID, session.LastSeenAt, session.ExpiresAt); err != nil {
+			return nil, fmt.Errorf("failed to touch session: %w", err)
+		}
+	}
+	return session, nil
+}
+
+// List returns userID's live sessions.
+func (s *SessionService) List(ctx context.Context, userID uuid.UUID) ([]domain.Session, error) {
+	return s.repo.ListByUser(ctx, userID, s.Clock.Now())
+}
+
+// Revoke ends one of userID's sessions. Other users' sessions are
+// reported as ErrSessionNotFound, so IDs cannot be probed.
+func (s *SessionService) Revoke(ctx context.Context, userID, sessionID uuid.UUID) error {
+	sessions, err := s.List(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to list sessions: %w", err)
+	}
+	for _, session := range sessions {
+		if session.ID == sessionID {
+			return s.repo.Revoke(ctx, sessionID, s.Clock.Now())
+		}
+	}
+	return domain.ErrSessionNotFound
+}
+
+// RevokeAll ends every live session of userID, e.g. when the user is
+// suspended, and returns how many it ended.
+func (s *SessionService) RevokeAll(ctx context.Context, userID uuid.UUID) (int, error) {
+	sessions, err := s.List(ctx, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	now := s.Clock.Now()
+	for i, session := range sessions {
+		if err := s.repo.Revoke(ctx, session.ID, now); err != nil {
+			return i, fmt.Errorf("failed to revoke session: %w", err)
+		}
+	}
+	return len(sessions), nil
+}
+
+// expiry is IdleTTL after the last use, but no later than MaxTTL after
+// creation.
+func (s *SessionService) expiry(created, lastSeen time.Time) time.Time {
+	idle, limit := lastSeen.Add(s.IdleTTL), created.Add(s.MaxTTL)
+	if idle.After(limit) {
+		return limit
+	}
+	return idle
+}
+
+func hashSessionToken(token string) []byte {
+	sum := sha256.Sum256([]byte(token))
+	return sum[:]
+}
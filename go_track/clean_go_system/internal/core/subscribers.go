@@ -0,0 +1,17 @@
+package core
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"clean_go_system/internal/domain"
+)
+
+// AuditLog writes one line per domain event to the audit logger.
+func AuditLog(logger *log.Logger) func(ctx context.Context, e domain.DomainEvent) error {
+	return func(ctx context.Context, e domain.DomainEvent) error {
+		logger.Printf("audit: %s at %s: %+v", e.EventName(), e.OccurredAt().Format(time.RFC3339), e)
+		return nil
+	}
+}
@@ -0,0 +1,121 @@
+package core
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"clean_go_system/internal/domain"
+	"clean_go_system/pkg/clock"
+	"github.com/google/uuid"
+)
+
+// UserLoader coalesces lookups by ID, dataloader style: the Loads that
+// arrive within Window of each other share one GetByIDs, so resolving a
+// list of N users costs one query instead of N.
+//
+// Only Loads with the same tenant and WithDeleted setting share a batch,
+// and the batch runs on its own, without the caller's cancellation, so it
+// must not be used inside a transaction.
+type UserLoader struct {
+	// Window is how long a batch waits for more IDs after its first.
+	Window time.Duration
+	// MaxBatch sends a batch early once it holds that many IDs.
+	MaxBatch int
+	// Clock times the window; it defaults to the wall clock.
+	Clock domain.Clock
+
+	reader  domain.UserBatchReader
+	mu      sync.Mutex
+	pending map[batchKey]*userBatch
+}
+
+// batchKey is what Loads must agree on to share a query.
+type batchKey struct {
+	tenant  domain.TenantID
+	deleted bool
+}
+
+type userBatch struct {
+	ctx   context.Context
+	ids   []uuid.UUID
+	done  chan struct{}
+	users map[uuid.UUID]domain.User
+	err   error
+}
+
+func NewUserLoader(reader domain.UserBatchReader) *UserLoader {
+	return &UserLoader{
+		Window:   2 * time.Millisecond,
+		MaxBatch: 100,
+		Clock:    clock.System,
+		reader:   reader,
+		pending:  make(map[batchKey]*userBatch),
+	}
+}
+
+// Load returns the user with id once its batch is back, or
+// domain.ErrUserNotFound when the batch did not find it.
+func (l *UserLoader) Load(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	b := l.join(ctx, id)
+	select {
+	case <-b.done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if b.err != nil {
+		return nil, b.err
+	}
+	u, ok := b.users[id]
+	if !ok {
+		return nil, domain.ErrUserNotFound
+	}
+	return &u, nil
+}
+
+// join adds id to the pending batch of ctx's kind, starting one if there
+// is none.
+func (l *UserLoader) join(ctx context.Context, id uuid.UUID) *userBatch {
+	key := batchKey{tenant: domain.TenantOf(ctx), deleted: domain.IncludesDeleted(ctx)}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b, ok := l.pending[key]
+	if !ok {
+		b = &userBatch{ctx: context.WithoutCancel(ctx), done: make(chan struct{})}
+		l.pending[key] = b
+		go func() {
+			<-l.Clock.After(l.Window)
+			l.mu.Lock()
+			due := l.pending[key] == b
+			if due {
+				delete(l.pending, key)
+			}
+			l.mu.Unlock()
+			if due {
+				l.run(b)
+			}
+		}()
+	}
+	if !slices.Contains(b.ids, id) {
+		b.ids = append(b.ids, id)
+	}
+	if l.MaxBatch > 0 && len(b.ids) >= l.MaxBatch {
+		delete(l.pending, key)
+		go l.run(b)
+	}
+	return b
+}
+
+func (l *UserLoader) run(b *userBatch) {
+	defer close(b.done)
+	users, err := l.reader.GetByIDs(b.ctx, b.ids)
+	if err != nil {
+		b.err = err
+		return
+	}
+	b.users = make(map[uuid.UUID]domain.User, len(users))
+	for _, u := range users {
+		b.users[u.ID] = u
+	}
+}
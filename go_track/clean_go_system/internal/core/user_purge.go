@@ -0,0 +1,46 @@
+package core
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"clean_go_system/internal/domain"
+	"clean_go_system/pkg/clock"
+)
+
+// UserPurge hard-deletes users once they have been soft-deleted for
+// longer than the retention period. It runs as a scheduled job (see
+// pkg/scheduler), which also keeps it to one instance at a time.
+type UserPurge struct {
+	// Clock dates the cutoff; it defaults to the wall clock.
+	Clock domain.Clock
+
+	purger    domain.UserPurger
+	retention time.Duration
+	logger    *log.Logger
+}
+
+func NewUserPurge(purger domain.UserPurger, retention time.Duration, logger *log.Logger) *UserPurge {
+	return &UserPurge{
+		Clock:     clock.System,
+		purger:    purger,
+		retention: retention,
+		logger:    logger,
+	}
+}
+
+// Run is the scheduled job: one purge, logged when it removed anyone.
+func (p *UserPurge) Run(ctx context.Context) error {
+	n, err := p.PurgeOnce(ctx)
+	if n > 0 {
+		p.logger.Printf("user purge: removed %d deleted users", n)
+	}
+	return err
+}
+
+// PurgeOnce removes the users deleted more than the retention period ago
+// and returns how many went.
+func (p *UserPurge) PurgeOnce(ctx context.Context) (int, error) {
+	return p.purger.PurgeDeleted(ctx, p.Clock.Now().Add(-p.retention))
+}
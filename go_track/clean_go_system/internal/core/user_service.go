@@ -2,46 +2,342 @@ package core
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
 	"time"
 
+	"clean-code-cookbook/go/pkg/page"
 	"clean_go_system/internal/domain"
+	"clean_go_system/pkg/clock"
+	"clean_go_system/pkg/idgen"
 	"github.com/google/uuid"
 )
 
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// ErrEmptyUserQuery is returned by Search when the query names no user.
+var ErrEmptyUserQuery = errors.New("user query needs an id or an email")
+
+// UserQuery finds users by exact ID or email; with both, a user must
+// match both. There is no partial match: emails may be stored encrypted,
+// behind a blind index that only answers equality.
+type UserQuery struct {
+	ID    uuid.UUID
+	Email string
+}
+
 // UserService contains the business logic
 type UserService struct {
-	repo domain.UserRepository
+	repo   domain.UserRepository
+	events domain.EventPublisher
+	tx     domain.Transactor
+	clock  domain.Clock
+	ids    domain.IDGenerator
+	authz  domain.Authorizer
+	lister domain.UserLister
+	stream domain.UserStreamer
+	loader *UserLoader
+	emails []EmailComposer
+}
+
+// Option overrides one of UserService's defaults.
+type Option func(*UserService)
+
+// WithClock replaces the wall clock, e.g. with a clock.Fake in tests.
+func WithClock(c domain.Clock) Option {
+	return func(s *UserService) { s.clock = c }
+}
+
+// WithIDGenerator replaces the UUIDv7 generator, e.g. with an
+// idgen.Sequential in tests.
+func WithIDGenerator(g domain.IDGenerator) Option {
+	return func(s *UserService) { s.ids = g }
+}
+
+// WithAuthorizer checks reads against authz, on behalf of the actor in
+// the context (see WithActor). Without it every read is allowed.
+func WithAuthorizer(authz domain.Authorizer) Option {
+	return func(s *UserService) { s.authz = authz }
+}
+
+// WithLister enables List. Listing is a store capability the caching
+// decorators do not pass through, so it is given separately.
+func WithLister(l domain.UserLister) Option {
+	return func(s *UserService) { s.lister = l }
+}
+
+// WithStreamer enables Export. Like listing, streaming is a store
+// capability.
+func WithStreamer(st domain.UserStreamer) Option {
+	return func(s *UserService) { s.stream = st }
+}
+
+// WithLoader has Load coalesce concurrent lookups through l.
+func WithLoader(l *UserLoader) Option {
+	return func(s *UserService) { s.loader = l }
+}
+
+// EmailComposer renders the email an event owes its user, such as the
+// verification email of UserRegistered; ok is false when it owes none.
+type EmailComposer func(ctx context.Context, e domain.DomainEvent) (email domain.EmailRequested, ok bool, err error)
+
+// WithRegistrationEmails has Register publish the emails of composers as
+// EmailRequested events in the transaction that stores the user. With
+// the outbox they commit with the user row, so a crash after the commit
+// delays them instead of losing them.
+func WithRegistrationEmails(composers ...EmailComposer) Option {
+	return func(s *UserService) { s.emails = append(s.emails, composers...) }
 }
 
 // NewUserService is a constructor (Factory)
-func NewUserService(repo domain.UserRepository) *UserService {
-	return &UserService{repo: repo}
+func NewUserService(repo domain.UserRepository, events domain.EventPublisher, tx domain.Transactor, opts ...Option) *UserService {
+	s := &UserService{repo: repo, events: events, tx: tx, clock: clock.System, ids: idgen.UUIDv7{}}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
-// Register handles the user creation flow
+// Register handles the user creation flow. The user joins the tenant ctx
+// is scoped to.
 func (s *UserService) Register(ctx context.Context, email, username string) (*domain.User, error) {
-	// 1. Check existence
-	existing, err := s.repo.GetByEmail(ctx, email)
-	if err != nil && err != domain.ErrUserNotFound {
+	// 1. Create the aggregate; it validates its own input
+	user, err := domain.RegisterUser(domain.TenantOf(ctx), s.ids.NewID(), email, username, domain.LocaleOf(ctx), s.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. Check existence; a deleted user holds its address until purged
+	existing, err := s.repo.GetByEmail(domain.WithDeleted(ctx), email)
+	if err != nil && !errors.Is(err, domain.ErrUserNotFound) {
 		return nil, fmt.Errorf("failed to check user: %w", err)
 	}
 	if existing != nil {
-		return nil, fmt.Errorf("user already exists")
+		return nil, domain.ErrUserExists
+	}
+
+	// 3. Persist and announce atomically: the welcome and verification
+	// emails and other side effects commit with the user row.
+	events := user.PullEvents()
+	emails, err := s.compose(ctx, events)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.store(ctx, user, s.repo.Save, append(events, emails...)); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// compose renders the registration emails events owe.
+func (s *UserService) compose(ctx context.Context, events []domain.DomainEvent) ([]domain.DomainEvent, error) {
+	var emails []domain.DomainEvent
+	for _, e := range events {
+		for _, compose := range s.emails {
+			email, ok, err := compose(ctx, e)
+			if err != nil {
+				return nil, fmt.Errorf("failed to compose %s email: %w", e.EventName(), err)
+			}
+			if ok {
+				emails = append(emails, email)
+			}
+		}
+	}
+	return emails, nil
+}
+
+// Get returns the user with id, if the actor may read it.
+func (s *UserService) Get(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	if err := authorize(ctx, s.authz, ActionReadUser, domain.Resource{Type: "user", ID: id.String()}); err != nil {
+		return nil, err
 	}
+	return s.repo.GetByID(ctx, id)
+}
 
-	// 2. Create Entity
-	newUser := domain.User{
-		ID:        uuid.New(),
-		Email:     email,
-		Username:  username,
-		CreatedAt: time.Now(),
+// Load is Get for resolvers and aggregations that look up many users at
+// once: with a loader, the Loads made together share one query. It reads
+// the store directly, past the caches, so single lookups should use Get.
+func (s *UserService) Load(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	if s.loader == nil {
+		return s.Get(ctx, id)
 	}
+	if err := authorize(ctx, s.authz, ActionReadUser, domain.Resource{Type: "user", ID: id.String()}); err != nil {
+		return nil, err
+	}
+	return s.loader.Load(ctx, id)
+}
 
-	// 3. Persist
-	if err := s.repo.Save(ctx, newUser); err != nil {
-		return nil, fmt.Errorf("failed to save user: %w", err)
+// List returns one page of the users of ctx's tenant, if the actor may
+// list them. Pages hold 20 users unless req asks for up to 100.
+func (s *UserService) List(ctx context.Context, req page.Request) (page.Page[domain.User], error) {
+	if err := authorize(ctx, s.authz, ActionListUsers, domain.Resource{Type: "user"}); err != nil {
+		return page.Page[domain.User]{}, err
 	}
+	if s.lister == nil {
+		return page.Page[domain.User]{}, fmt.Errorf("listing users: %w", errors.ErrUnsupported)
+	}
+	if f := req.Sort.Field; f != "" && !slices.Contains(domain.UserSortFields, f) {
+		return page.Page[domain.User]{}, fmt.Errorf("%w %q", page.ErrInvalidSort, f)
+	}
+	return s.lister.ListUsers(ctx, req.Clamp(defaultPageSize, maxPageSize))
+}
 
-	return &newUser, nil
-}
\ No newline at end of file
+// Export calls fn for every user of ctx's tenant, soft-deleted ones
+// included, oldest first, if the actor may export them. Users are read a
+// batch at a time, so memory stays flat however many there are.
+func (s *UserService) Export(ctx context.Context, fn func(domain.User) error) error {
+	if err := authorize(ctx, s.authz, ActionExportUsers, domain.Resource{Type: "user"}); err != nil {
+		return err
+	}
+	if s.stream == nil {
+		return fmt.Errorf("exporting users: %w", errors.ErrUnsupported)
+	}
+	return s.stream.StreamUsers(domain.WithDeleted(ctx), fn)
+}
+
+// ChangeEmail moves the user with id to email, which no other user may
+// hold.
+func (s *UserService) ChangeEmail(ctx context.Context, id uuid.UUID, email string) (*domain.User, error) {
+	user, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load user: %w", err)
+	}
+	if err := user.ChangeEmail(email, s.clock.Now()); err != nil {
+		return nil, err
+	}
+	if owner, err := s.repo.GetByEmail(ctx, email); err == nil && owner.ID != user.ID {
+		return nil, domain.ErrUserExists
+	} else if err != nil && !errors.Is(err, domain.ErrUserNotFound) {
+		return nil, fmt.Errorf("failed to check user: %w", err)
+	}
+	if err := s.commit(ctx, user, s.repo.Update); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// ChangeLocale sets the language the user with id reads their emails in;
+// an empty locale goes back to the default.
+func (s *UserService) ChangeLocale(ctx context.Context, id uuid.UUID, locale string) (*domain.User, error) {
+	user, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load user: %w", err)
+	}
+	if err := user.ChangeLocale(locale, s.clock.Now()); err != nil {
+		return nil, err
+	}
+	if err := s.commit(ctx, user, s.repo.Update); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// Deactivate revokes a user's access. Deactivating an inactive user is a no-op.
+func (s *UserService) Deactivate(ctx context.Context, email string) error {
+	user, err := s.repo.GetByEmail(ctx, email)
+	if err != nil {
+		return fmt.Errorf("failed to load user: %w", err)
+	}
+	user.Deactivate(s.clock.Now())
+	return s.commit(ctx, user, s.repo.Update)
+}
+
+// Delete soft-deletes the user with id, if the actor may. The user
+// disappears from lookups at once and is removed for good by the purge
+// job once the retention period has passed.
+func (s *UserService) Delete(ctx context.Context, id uuid.UUID) error {
+	if err := authorize(ctx, s.authz, ActionDeleteUser, domain.Resource{Type: "user", ID: id.String()}); err != nil {
+		return err
+	}
+	user, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to load user: %w", err)
+	}
+	user.Delete(s.clock.Now())
+	return s.commit(ctx, user, s.repo.Update)
+}
+
+// Search returns the users of ctx's tenant matching q, soft-deleted ones
+// included, if the actor may search them. It finds one user at most.
+func (s *UserService) Search(ctx context.Context, q UserQuery) ([]domain.User, error) {
+	if err := authorize(ctx, s.authz, ActionSearchUsers, domain.Resource{Type: "user"}); err != nil {
+		return nil, err
+	}
+	ctx = domain.WithDeleted(ctx)
+	var (
+		user *domain.User
+		err  error
+	)
+	switch {
+	case q.ID != uuid.Nil:
+		user, err = s.repo.GetByID(ctx, q.ID)
+	case q.Email != "":
+		user, err = s.repo.GetByEmail(ctx, q.Email)
+	default:
+		return nil, ErrEmptyUserQuery
+	}
+	if errors.Is(err, domain.ErrUserNotFound) || (err == nil && q.Email != "" && user.Email != q.Email) {
+		return []domain.User{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to search users: %w", err)
+	}
+	return []domain.User{*user}, nil
+}
+
+// Suspend deactivates the user with id, if the actor may. Unlike
+// Deactivate, it is meant for operators and finds the user by ID.
+func (s *UserService) Suspend(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	return s.update(ctx, ActionSuspendUser, id, (*domain.User).Deactivate)
+}
+
+// Verify marks the email of the user with id as verified, if the actor
+// may, e.g. when the user cannot receive the verification mail.
+func (s *UserService) Verify(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	return s.update(ctx, ActionVerifyUser, id, (*domain.User).Verify)
+}
+
+// update authorizes action on the user with id, applies change and
+// commits it.
+func (s *UserService) update(ctx context.Context, action string, id uuid.UUID, change func(*domain.User, time.Time)) (*domain.User, error) {
+	if err := authorize(ctx, s.authz, action, domain.Resource{Type: "user", ID: id.String()}); err != nil {
+		return nil, err
+	}
+	user, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load user: %w", err)
+	}
+	change(user, s.clock.Now())
+	if err := s.commit(ctx, user, s.repo.Update); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// commit stores user with persist and publishes what it recorded, in one
+// transaction. A user that recorded nothing has not changed and is left
+// alone.
+func (s *UserService) commit(ctx context.Context, user *domain.User, persist func(context.Context, domain.User) error) error {
+	return s.store(ctx, user, persist, user.PullEvents())
+}
+
+// store persists user and publishes events in one transaction, unless
+// there are no events.
+func (s *UserService) store(ctx context.Context, user *domain.User, persist func(context.Context, domain.User) error, events []domain.DomainEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	return s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := persist(ctx, *user); err != nil {
+			return fmt.Errorf("failed to save user: %w", err)
+		}
+		if err := s.events.Publish(ctx, events...); err != nil {
+			return fmt.Errorf("failed to publish events: %w", err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,316 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrQueueFull is returned when a job cannot be queued: the buffer stayed
+// full for as long as the caller was willing to wait, or the pool is
+// draining.
+var ErrQueueFull = errors.New("job queue full")
+
+// PanicError is a job failure caused by a panic in its handler.
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string { return fmt.Sprintf("job panicked: %v", e.Value) }
+
+// RunJob calls process for job, turning a panic into a *PanicError so one
+// bad job fails instead of taking the process down.
+func RunJob[J any](ctx context.Context, job J, process func(ctx context.Context, job J) error) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = &PanicError{Value: v, Stack: debug.Stack()}
+		}
+	}()
+	return process(ctx, job)
+}
+
+// PoolStatus is a snapshot of a worker pool for operators.
+type PoolStatus struct {
+	Name    string
+	Workers int
+	// Queued jobs wait in the buffer or, while paused, in a worker.
+	Queued   int
+	Busy     int
+	Paused   bool
+	Draining bool
+	// DeadLetters counts the failed jobs parked for a retry.
+	DeadLetters int
+}
+
+// ManagedPool is a worker pool operators can control at run time; Pool
+// implements it.
+type ManagedPool interface {
+	Status() PoolStatus
+	// Pause stops workers from starting jobs; the queue keeps filling.
+	Pause()
+	// Resume undoes Pause and Drain.
+	Resume()
+	// Drain turns new jobs away with ErrQueueFull and waits until the
+	// queued ones are done or ctx ends. It reports whether the pool is
+	// empty; it keeps draining either way until Resume.
+	Drain(ctx context.Context) bool
+	// RetryDeadLetters queues the parked failures again and returns how
+	// many were queued.
+	RetryDeadLetters(ctx context.Context) (int, error)
+}
+
+// DeadLetter is a job that failed, kept for a retry.
+type DeadLetter[J any] struct {
+	Job J
+	Err string
+	At  time.Time
+}
+
+// DeadLetters parks the last Max failed jobs of a pool, dropping the
+// oldest beyond that. It lives in memory, so parked jobs do not survive a
+// restart.
+type DeadLetters[J any] struct {
+	Max int
+
+	mu   sync.Mutex
+	jobs []DeadLetter[J]
+}
+
+func NewDeadLetters[J any](max int) *DeadLetters[J] {
+	return &DeadLetters[J]{Max: max}
+}
+
+// Add parks job, which failed with err.
+func (d *DeadLetters[J]) Add(job J, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.jobs = append(d.jobs, DeadLetter[J]{Job: job, Err: err.Error(), At: time.Now()})
+	if over := len(d.jobs) - d.Max; over > 0 {
+		d.jobs = d.jobs[over:]
+	}
+}
+
+// Len returns how many jobs are parked.
+func (d *DeadLetters[J]) Len() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.jobs)
+}
+
+// Take removes and returns every parked job, oldest first.
+func (d *DeadLetters[J]) Take() []DeadLetter[J] {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	jobs := d.jobs
+	d.jobs = nil
+	return jobs
+}
+
+// Pool runs jobs of type J on a fixed number of goroutines fed by a
+// buffered channel. WorkerPool is the email one.
+type Pool[J any] struct {
+	JobQueue chan J
+	Workers  int
+	// Name labels the pool's log lines.
+	Name string
+	// Process does each job.
+	Process func(ctx context.Context, job J) error
+	// OnFailure receives every job that failed or panicked, e.g. to park
+	// it in a dead-letter store. Failures are logged either way.
+	OnFailure func(job J, err error)
+	// DeadLetters, if set, parks every failed job for RetryDeadLetters.
+	DeadLetters *DeadLetters[J]
+	wg          sync.WaitGroup
+
+	// pending counts jobs from Enqueue until they are done; busy those
+	// running.
+	pending  atomic.Int64
+	busy     atomic.Int64
+	draining atomic.Bool
+	mu       sync.Mutex
+	resumed  chan struct{} // open while paused, closed by Resume
+}
+
+func NewPool[J any](name string, workers, bufferSize int, process func(ctx context.Context, job J) error) *Pool[J] {
+	return &Pool[J]{
+		JobQueue: make(chan J, bufferSize), // Buffered Channel
+		Workers:  workers,
+		Name:     name,
+		Process:  process,
+	}
+}
+
+// Enqueue hands job to the pool, waiting for room in the buffer until ctx
+// ends; then it fails with ErrQueueFull (wrapping ctx's error).
+func (wp *Pool[J]) Enqueue(ctx context.Context, job J) error {
+	if wp.draining.Load() {
+		return fmt.Errorf("%w: %s pool is draining", ErrQueueFull, wp.Name)
+	}
+	wp.pending.Add(1)
+	select {
+	case wp.JobQueue <- job:
+		return nil
+	case <-ctx.Done():
+		wp.pending.Add(-1)
+		return fmt.Errorf("%w: %w", ErrQueueFull, ctx.Err())
+	}
+}
+
+// TryEnqueue hands job to the pool without blocking; it fails with
+// ErrQueueFull when the buffer is full.
+func (wp *Pool[J]) TryEnqueue(job J) error {
+	if wp.draining.Load() {
+		return fmt.Errorf("%w: %s pool is draining", ErrQueueFull, wp.Name)
+	}
+	wp.pending.Add(1)
+	select {
+	case wp.JobQueue <- job:
+		return nil
+	default:
+		wp.pending.Add(-1)
+		return ErrQueueFull
+	}
+}
+
+func (wp *Pool[J]) Start() {
+	for i := 0; i < wp.Workers; i++ {
+		wp.wg.Add(1)
+		go func(workerID int) {
+			defer wp.wg.Done()
+			fmt.Printf("%s worker %d started\n", wp.Name, workerID)
+
+			// Range over channel: This loop blocks until a job comes in
+			// It exits when the channel is closed.
+			for job := range wp.JobQueue {
+				<-wp.gate()
+				wp.busy.Add(1)
+				if err := RunJob(context.Background(), job, wp.Process); err != nil {
+					wp.fail(workerID, job, err)
+				}
+				wp.busy.Add(-1)
+				wp.pending.Add(-1)
+			}
+			fmt.Printf("%s worker %d stopped\n", wp.Name, workerID)
+		}(i)
+	}
+}
+
+func (wp *Pool[J]) fail(workerID int, job J, err error) {
+	var panicked *PanicError
+	if errors.As(err, &panicked) {
+		fmt.Printf("%s worker %d: job panicked: %v\n%s", wp.Name, workerID, panicked.Value, panicked.Stack)
+	} else {
+		fmt.Printf("%s worker %d: job failed: %v\n", wp.Name, workerID, err)
+	}
+	if wp.DeadLetters != nil {
+		wp.DeadLetters.Add(job, err)
+	}
+	if wp.OnFailure != nil {
+		wp.OnFailure(job, err)
+	}
+}
+
+// Stop resumes a paused pool, finishes the queued jobs and stops the
+// workers.
+func (wp *Pool[J]) Stop() {
+	wp.Resume()
+	close(wp.JobQueue) // This signals all workers to finish current loop and exit
+	wp.wg.Wait()       // Wait for all goroutines to finish
+}
+
+// closedGate lets workers through while the pool runs.
+var closedGate = func() chan struct{} {
+	c := make(chan struct{})
+	close(c)
+	return c
+}()
+
+// gate is what a worker waits on before starting a job.
+func (wp *Pool[J]) gate() <-chan struct{} {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+	if wp.resumed == nil {
+		return closedGate
+	}
+	return wp.resumed
+}
+
+func (wp *Pool[J]) Pause() {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+	if wp.resumed == nil {
+		wp.resumed = make(chan struct{})
+	}
+}
+
+func (wp *Pool[J]) Resume() {
+	wp.draining.Store(false)
+	wp.unpause()
+}
+
+func (wp *Pool[J]) unpause() {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+	if wp.resumed != nil {
+		close(wp.resumed)
+		wp.resumed = nil
+	}
+}
+
+// Drain lifts a pause, since the queue only empties while jobs run.
+func (wp *Pool[J]) Drain(ctx context.Context) bool {
+	wp.draining.Store(true)
+	wp.unpause()
+	tick := time.NewTicker(10 * time.Millisecond)
+	defer tick.Stop()
+	for wp.pending.Load() > 0 {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-tick.C:
+		}
+	}
+	return true
+}
+
+// RetryDeadLetters puts back the jobs it could not queue before ctx
+// ended, and fails with ErrQueueFull.
+func (wp *Pool[J]) RetryDeadLetters(ctx context.Context) (int, error) {
+	if wp.DeadLetters == nil {
+		return 0, nil
+	}
+	letters := wp.DeadLetters.Take()
+	for i, letter := range letters {
+		if err := wp.Enqueue(ctx, letter.Job); err != nil {
+			for _, rest := range letters[i:] {
+				wp.DeadLetters.Add(rest.Job, errors.New(rest.Err))
+			}
+			return i, err
+		}
+	}
+	return len(letters), nil
+}
+
+func (wp *Pool[J]) Status() PoolStatus {
+	wp.mu.Lock()
+	paused := wp.resumed != nil
+	wp.mu.Unlock()
+	busy := wp.busy.Load()
+	s := PoolStatus{
+		Name:     wp.Name,
+		Workers:  wp.Workers,
+		Queued:   int(max(wp.pending.Load()-busy, 0)),
+		Busy:     int(busy),
+		Paused:   paused,
+		Draining: wp.draining.Load(),
+	}
+	if wp.DeadLetters != nil {
+		s.DeadLetters = wp.DeadLetters.Len()
+	}
+	return s
+}
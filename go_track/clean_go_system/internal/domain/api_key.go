@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Scopes an APIKey can be granted.
+const (
+	ScopeUsersWrite = "users:write"
+	ScopeKeysAdmin  = "keys:admin"
+	// ScopeAdmin opens the /admin operations on users, worker pools and
+	// caches; the authorizer still decides which ones.
+	ScopeAdmin = "admin"
+)
+
+// APIKey lets another service call the API without a user login. Only a
+// SHA-256 hash of the secret is stored; the secret itself is shown once,
+// when the key is created.
+type APIKey struct {
+	ID uuid.UUID
+	// TenantID is the customer the key calls on behalf of; the repository
+	// fills it in from the context, like User.TenantID.
+	TenantID TenantID
+	Name     string
+	Hash     []byte
+	Scopes   []string
+	// RateLimit is the requests per second the key may make; 0 is
+	// unlimited.
+	RateLimit float64
+	CreatedAt time.Time
+	RevokedAt *time.Time
+}
+
+// Revoked reports whether the key was revoked.
+func (k APIKey) Revoked() bool {
+	return k.RevokedAt != nil
+}
+
+// Allows reports whether the key was granted scope.
+func (k APIKey) Allows(scope string) bool {
+	return slices.Contains(k.Scopes, scope)
+}
+
+// APIKeyRepository stores API keys by the hash of their secret. Like
+// UserRepository, every call is scoped to TenantOf(ctx), so a key only
+// authenticates against its own tenant.
+type APIKeyRepository interface {
+	Save(ctx context.Context, k APIKey) error
+	GetByHash(ctx context.Context, hash []byte) (*APIKey, error)
+	// Revoke stamps the key as revoked at the given time. Revoking a
+	// revoked key keeps the first timestamp.
+	Revoke(ctx context.Context, id uuid.UUID, at time.Time) error
+}
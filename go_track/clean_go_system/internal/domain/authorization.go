@@ -0,0 +1,30 @@
+package domain
+
+import "context"
+
+// Actor kinds.
+const (
+	ActorUser   = "user"
+	ActorAPIKey = "api_key"
+)
+
+// Actor is who a use case runs on behalf of. Attrs carries whatever else
+// policies may test, e.g. an API key's "scopes" as a comma list.
+type Actor struct {
+	Kind  string
+	ID    string
+	Attrs map[string]string
+}
+
+// Resource is what an action targets.
+type Resource struct {
+	Type  string
+	ID    string
+	Attrs map[string]string
+}
+
+// Authorizer decides whether actor may perform action on resource and
+// returns ErrForbidden if not.
+type Authorizer interface {
+	Authorize(ctx context.Context, actor Actor, action string, resource Resource) error
+}
@@ -0,0 +1,29 @@
+package domain
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// BlobInfo describes a stored object.
+type BlobInfo struct {
+	Size        int64
+	ContentType string
+	ModTime     time.Time
+}
+
+// BlobStore keeps binary objects (avatars, product images) outside the
+// database. Bodies are streamed in both directions, so implementations must
+// not buffer whole objects in memory.
+type BlobStore interface {
+	// Put stores r under key, replacing any existing object. size may be -1
+	// when unknown.
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get opens the object; the caller must close the reader.
+	Get(ctx context.Context, key string) (io.ReadCloser, BlobInfo, error)
+	// Delete removes the object; deleting a missing key is not an error.
+	Delete(ctx context.Context, key string) error
+	// SignedURL returns a URL that grants read access to key until expiry.
+	SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error)
+}
@@ -0,0 +1,11 @@
+package domain
+
+import "time"
+
+// Clock is the source of time for services and adapters. Production uses
+// the wall clock; tests inject a fake they can advance.
+type Clock interface {
+	Now() time.Time
+	// After behaves like time.After on this clock.
+	After(d time.Duration) <-chan time.Time
+}
@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DeliveryStatus is how far an email got.
+type DeliveryStatus string
+
+const (
+	// DeliveryQueued emails wait in the email queue.
+	DeliveryQueued DeliveryStatus = "queued"
+	// DeliverySent emails were accepted by the provider.
+	DeliverySent DeliveryStatus = "sent"
+	// DeliveryFailed emails could not be handed to the provider this time;
+	// the queue may still retry them.
+	DeliveryFailed DeliveryStatus = "failed"
+	// DeliveryBounced emails were rejected, by the provider or later by
+	// the recipient's server.
+	DeliveryBounced DeliveryStatus = "bounced"
+	// DeliveryComplained emails were marked as spam by the recipient.
+	DeliveryComplained DeliveryStatus = "complained"
+	// DeliverySuppressed emails were not sent: their address is on the
+	// suppression list.
+	DeliverySuppressed DeliveryStatus = "suppressed"
+)
+
+// Delivery is the record of one email sent to a user, so support can tell
+// whether it went out. The address is not kept: it is the user's, and
+// stays encrypted with the user.
+type Delivery struct {
+	ID       uuid.UUID
+	UserID   uuid.UUID
+	Template string
+	Subject  string
+	Status   DeliveryStatus
+	// Attempts counts sends to the provider, retries included.
+	Attempts int
+	// ProviderID is the ID the provider gave the message, which its
+	// reports quote.
+	ProviderID string
+	// Error is the last failure, or why the email bounced or was
+	// suppressed.
+	Error     string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// DeliveryRepository stores email deliveries.
+type DeliveryRepository interface {
+	// Record stores d or, when a delivery with d.ID exists, replaces its
+	// status, provider ID, error and update time, adds d.Attempts to its
+	// attempts and keeps the rest.
+	Record(ctx context.Context, d Delivery) error
+	// GetByProviderID returns the delivery the provider knows as
+	// providerID, or ErrDeliveryNotFound.
+	GetByProviderID(ctx context.Context, providerID string) (*Delivery, error)
+	// ListByUser returns up to limit of the user's deliveries, newest
+	// first.
+	ListByUser(ctx context.Context, userID uuid.UUID, limit int) ([]Delivery, error)
+}
@@ -0,0 +1,70 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DigestFrequency is how often a user gets the low-priority emails held
+// for their digest.
+type DigestFrequency string
+
+const (
+	// DigestImmediate sends every email as its event happens; it is what
+	// users get until they choose otherwise.
+	DigestImmediate DigestFrequency = "immediate"
+	DigestDaily     DigestFrequency = "daily"
+	DigestWeekly    DigestFrequency = "weekly"
+)
+
+// Valid reports whether f is one of the frequencies above.
+func (f DigestFrequency) Valid() bool {
+	switch f {
+	case DigestImmediate, DigestDaily, DigestWeekly:
+		return true
+	}
+	return false
+}
+
+// NotificationPreferences is what a user chose about the notifications
+// they get.
+type NotificationPreferences struct {
+	UserID    uuid.UUID
+	Digest    DigestFrequency
+	UpdatedAt time.Time
+}
+
+// NotificationPreferencesRepository stores preferences by user.
+type NotificationPreferencesRepository interface {
+	Save(ctx context.Context, p NotificationPreferences) error
+	// Get returns nil when the user never chose anything.
+	Get(ctx context.Context, userID uuid.UUID) (*NotificationPreferences, error)
+}
+
+// DigestItem is a rendered email notification held back for the next
+// digest of its user, sent to Email at that frequency. Locale is the
+// language it was rendered in, which the digest is written in too.
+type DigestItem struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Email     string
+	Frequency DigestFrequency
+	Event     string
+	Locale    string
+	Subject   string
+	Body      string
+	CreatedAt time.Time
+}
+
+// DigestRepository holds digest items until they are sent. Items are
+// deleted only after their digest went out, so a failed flush sends them
+// with the next one.
+type DigestRepository interface {
+	Add(ctx context.Context, item DigestItem) error
+	// Pending returns up to limit items of frequency created before
+	// before, oldest first.
+	Pending(ctx context.Context, frequency DigestFrequency, before time.Time, limit int) ([]DigestItem, error)
+	Delete(ctx context.Context, ids []uuid.UUID) error
+}
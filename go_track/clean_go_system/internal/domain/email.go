@@ -0,0 +1,28 @@
+package domain
+
+import "context"
+
+// Email is one message on its way to a single recipient. From may be left
+// empty for the sender's configured address. HTML, if set, is the same
+// message as an HTML document, sent alongside Text for clients that show
+// it.
+type Email struct {
+	From    string
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// EmailSender hands emails to a mail provider. A nil error means the
+// provider accepted the message, not that it reached the inbox; id is the
+// provider's ID for it, which later reports (bounces) refer to, or empty
+// when the provider has none.
+//
+// Send wraps the provider's error in ErrEmailThrottled when the provider
+// asks to slow down, so the send is worth retrying later, and in
+// ErrEmailRejected when the message can never go out as it is (a refused
+// recipient, a malformed message), so it is not.
+type EmailSender interface {
+	Send(ctx context.Context, msg Email) (id string, err error)
+}
@@ -0,0 +1,23 @@
+package domain
+
+import "context"
+
+// DataKey is one version of the key that encrypts personal data at rest.
+// Ciphertexts record the version they were written with, so old versions
+// keep decrypting after the current one rotates.
+type DataKey struct {
+	Version uint32
+	Secret  []byte // 32 bytes, for AES-256
+}
+
+// KeyProvider supplies data keys, from configuration or a KMS.
+type KeyProvider interface {
+	// Current returns the key new data is encrypted with.
+	Current(ctx context.Context) (DataKey, error)
+	// Version returns the key data written under version v needs, or
+	// ErrUnknownKeyVersion.
+	Version(ctx context.Context, v uint32) (DataKey, error)
+	// IndexKey keys the blind indexes that make encrypted values
+	// searchable. Changing it means recomputing every index.
+	IndexKey(ctx context.Context) ([]byte, error)
+}
@@ -3,7 +3,38 @@ package domain
 import "errors"
 
 var (
-	ErrUserNotFound = errors.New("user not found")
-	ErrInvalidEmail = errors.New("invalid email format")
-	ErrUserExists   = errors.New("user already exists")
+	ErrUserNotFound             = errors.New("user not found")
+	ErrInvalidEmail             = errors.New("invalid email format")
+	ErrInvalidUsername          = errors.New("invalid username")
+	ErrUserExists               = errors.New("user already exists")
+	ErrUserInactive             = errors.New("user is deactivated")
+	ErrBlobNotFound             = errors.New("blob not found")
+	ErrInvalidKey               = errors.New("invalid blob key")
+	ErrLockHeld                 = errors.New("lock held by another owner")
+	ErrAPIKeyNotFound           = errors.New("api key not found")
+	ErrInvalidAPIKey            = errors.New("invalid or revoked api key")
+	ErrInvalidKeyName           = errors.New("invalid api key name")
+	ErrIdentityExists           = errors.New("identity already linked")
+	ErrIdentityUnknown          = errors.New("identity not linked to a user")
+	ErrWeakPassword             = errors.New("password must be 8-72 bytes")
+	ErrInvalidResetToken        = errors.New("invalid or expired reset token")
+	ErrForbidden                = errors.New("forbidden")
+	ErrSessionNotFound          = errors.New("session not found")
+	ErrInvalidSession           = errors.New("invalid, expired or revoked session")
+	ErrUnknownKeyVersion        = errors.New("unknown encryption key version")
+	ErrInvalidTenant            = errors.New("invalid tenant id")
+	ErrUnknownTenant            = errors.New("unknown tenant")
+	ErrNoTemplate               = errors.New("no message template")
+	ErrUnsupportedImage         = errors.New("image must be a JPEG or PNG")
+	ErrImageTooLarge            = errors.New("image too large")
+	ErrImageNotFound            = errors.New("image not found")
+	ErrEmailThrottled           = errors.New("email provider is throttling sends")
+	ErrEmailRejected            = errors.New("email rejected for good")
+	ErrInvalidVerificationToken = errors.New("invalid or expired verification token")
+	ErrDeliveryNotFound         = errors.New("email delivery not found")
+	ErrInvalidDigestFrequency   = errors.New("digest frequency must be immediate, daily or weekly")
+	ErrInvalidLocale            = errors.New("invalid locale")
+	ErrProductNotFound          = errors.New("product not found")
+	ErrInvalidProductSearch     = errors.New("invalid product search")
+	ErrCatalogUnavailable       = errors.New("catalog unavailable")
 )
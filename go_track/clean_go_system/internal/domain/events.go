@@ -0,0 +1,145 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DomainEvent is a fact about something that already happened in the domain.
+// Events are value types: EventName must not depend on the receiver's fields.
+type DomainEvent interface {
+	EventName() string
+	OccurredAt() time.Time
+	// AggregateID identifies the entity the event belongs to; brokers use
+	// it as the partition key so an entity's events stay ordered.
+	AggregateID() string
+}
+
+// EventPublisher hands events to whoever reacts to them (emails, audit, ...).
+// Services depend on this port, never on a concrete bus or broker.
+type EventPublisher interface {
+	Publish(ctx context.Context, events ...DomainEvent) error
+}
+
+// UserRegistered is emitted after a new user has been persisted. Locale
+// is the language the user signed up in, if the client said.
+type UserRegistered struct {
+	UserID   uuid.UUID
+	Email    string
+	Username string
+	Locale   string `json:",omitempty"`
+	At       time.Time
+}
+
+func (UserRegistered) EventName() string       { return "user.registered" }
+func (e UserRegistered) OccurredAt() time.Time { return e.At }
+func (e UserRegistered) AggregateID() string   { return e.UserID.String() }
+
+// UserEmailChanged is emitted when a user moves to a new address.
+// Locale, here and in the events below, is the language the user reads,
+// so notifications about the event need not look the user up.
+type UserEmailChanged struct {
+	UserID   uuid.UUID
+	OldEmail string
+	NewEmail string
+	Locale   string `json:",omitempty"`
+	At       time.Time
+}
+
+func (UserEmailChanged) EventName() string       { return "user.email_changed" }
+func (e UserEmailChanged) OccurredAt() time.Time { return e.At }
+func (e UserEmailChanged) AggregateID() string   { return e.UserID.String() }
+
+// UserDeactivated is emitted when a user loses access to the system.
+type UserDeactivated struct {
+	UserID uuid.UUID
+	Email  string
+	Locale string `json:",omitempty"`
+	At     time.Time
+}
+
+func (UserDeactivated) EventName() string       { return "user.deactivated" }
+func (e UserDeactivated) OccurredAt() time.Time { return e.At }
+func (e UserDeactivated) AggregateID() string   { return e.UserID.String() }
+
+// UserVerified is emitted when a user's email is verified.
+type UserVerified struct {
+	UserID uuid.UUID
+	Email  string
+	At     time.Time
+}
+
+func (UserVerified) EventName() string       { return "user.verified" }
+func (e UserVerified) OccurredAt() time.Time { return e.At }
+func (e UserVerified) AggregateID() string   { return e.UserID.String() }
+
+// UserDeleted is emitted when a user is soft-deleted. The row is kept,
+// hidden from lookups, until the purge job removes it for good.
+type UserDeleted struct {
+	UserID uuid.UUID
+	Email  string
+	Locale string `json:",omitempty"`
+	At     time.Time
+}
+
+func (UserDeleted) EventName() string       { return "user.deleted" }
+func (e UserDeleted) OccurredAt() time.Time { return e.At }
+func (e UserDeleted) AggregateID() string   { return e.UserID.String() }
+
+// UserLocaleChanged is emitted when a user picks the language they read;
+// an empty Locale means the default.
+type UserLocaleChanged struct {
+	UserID uuid.UUID
+	Locale string
+	At     time.Time
+}
+
+func (UserLocaleChanged) EventName() string       { return "user.locale_changed" }
+func (e UserLocaleChanged) OccurredAt() time.Time { return e.At }
+func (e UserLocaleChanged) AggregateID() string   { return e.UserID.String() }
+
+// UserUpdated is emitted after every change to a registered user, next
+// to the event saying what changed, and carries the user as it now is.
+// Consumers that keep a copy of users, such as other services' caches,
+// replace theirs without knowing every kind of change. Deletion is
+// UserDeleted alone.
+type UserUpdated struct {
+	UserID   uuid.UUID
+	Email    string
+	Username string
+	Locale   string `json:",omitempty"`
+	Active   bool
+	Verified bool
+	At       time.Time
+}
+
+func (UserUpdated) EventName() string       { return "user.updated" }
+func (e UserUpdated) OccurredAt() time.Time { return e.At }
+func (e UserUpdated) AggregateID() string   { return e.UserID.String() }
+
+// EmailRequested is an email composed for a user in the transaction of
+// the change it is about, such as the verification email of a new user,
+// so it is sent if and only if that change commits. Template names it.
+// The content may carry secrets such as tokens: the event is meant for
+// the email workers, not for brokers or webhooks, and String leaves the
+// content out.
+type EmailRequested struct {
+	UserID   uuid.UUID
+	Template string
+	Email    string
+	Subject  string
+	Body     string
+	HTML     string `json:",omitempty"`
+	At       time.Time
+}
+
+func (EmailRequested) EventName() string       { return "email.requested" }
+func (e EmailRequested) OccurredAt() time.Time { return e.At }
+func (e EmailRequested) AggregateID() string   { return e.UserID.String() }
+
+func (e EmailRequested) String() string {
+	return fmt.Sprintf("{UserID:%s Template:%s At:%s}", e.UserID, e.Template, e.At.Format(time.RFC3339))
+}
@@ -0,0 +1,9 @@
+package domain
+
+import "github.com/google/uuid"
+
+// IDGenerator mints identifiers for new aggregates, so tests can make them
+// predictable.
+type IDGenerator interface {
+	NewID() uuid.UUID
+}
@@ -0,0 +1,26 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FederatedIdentity links a user to an account at an external identity
+// provider. Issuer and Subject identify that account for good; the email
+// the provider reports may change.
+type FederatedIdentity struct {
+	Issuer   string
+	Subject  string
+	UserID   uuid.UUID
+	LinkedAt time.Time
+}
+
+// IdentityRepository stores the links between users and provider accounts.
+type IdentityRepository interface {
+	// Link records id; linking an account that is already linked fails
+	// with ErrIdentityExists.
+	Link(ctx context.Context, id FederatedIdentity) error
+	Find(ctx context.Context, issuer, subject string) (*FederatedIdentity, error)
+}
@@ -0,0 +1,126 @@
+package domain
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// ImageKind is what an image belongs to: a user's avatar or a product
+// (by SKU). It decides where the image is stored and which sizes are made.
+type ImageKind string
+
+const (
+	ImageAvatar  ImageKind = "avatar"
+	ImageProduct ImageKind = "product"
+)
+
+// OriginalVariant names the image as uploaded.
+const OriginalVariant = "original"
+
+// imageTypes are the formats accepted, by sniffed content type.
+var imageTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+}
+
+// imageSignatures identify the accepted formats by their leading bytes;
+// the client's declared type is not trusted.
+var imageSignatures = []struct {
+	magic       []byte
+	contentType string
+}{
+	{[]byte("\xff\xd8\xff"), "image/jpeg"},
+	{[]byte("\x89PNG\r\n\x1a\n"), "image/png"},
+}
+
+// SniffImageType returns the content type of the image starting with
+// head, or "" when it is not one of the accepted formats.
+func SniffImageType(head []byte) string {
+	for _, sig := range imageSignatures {
+		if bytes.HasPrefix(head, sig.magic) {
+			return sig.contentType
+		}
+	}
+	return ""
+}
+
+// variants are the resized copies made of each kind, by name, as the
+// longest side in pixels.
+var variants = map[ImageKind]map[string]int{
+	ImageAvatar:  {"small": 64, "medium": 256},
+	ImageProduct: {"thumb": 256, "large": 1024},
+}
+
+var (
+	imageIDPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\.(jpg|png)$`)
+	ownerPattern   = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+)
+
+// Image is one uploaded picture. ID carries the file extension, so the
+// stored keys, and with them the served content type, follow from it.
+type Image struct {
+	Kind  ImageKind
+	Owner string
+	ID    string
+}
+
+// NewImage names a new image of contentType for owner. It returns
+// ErrUnsupportedImage for formats other than JPEG and PNG.
+func NewImage(kind ImageKind, owner, contentType string) (Image, error) {
+	ext, ok := imageTypes[contentType]
+	if !ok {
+		return Image{}, ErrUnsupportedImage
+	}
+	img := Image{Kind: kind, Owner: owner, ID: uuid.NewString() + ext}
+	return img, img.Validate()
+}
+
+// Validate checks that img names an image that could exist, so a request
+// for anything else is answered with ErrImageNotFound without a lookup.
+func (img Image) Validate() error {
+	if _, ok := variants[img.Kind]; !ok || !ownerPattern.MatchString(img.Owner) || !imageIDPattern.MatchString(img.ID) {
+		return ErrImageNotFound
+	}
+	return nil
+}
+
+// Variants returns the resized sizes of img's kind, by name.
+func (img Image) Variants() map[string]int {
+	return variants[img.Kind]
+}
+
+// HasVariant reports whether name is OriginalVariant or one of Variants.
+func (img Image) HasVariant(name string) bool {
+	_, ok := img.Variants()[name]
+	return ok || name == OriginalVariant
+}
+
+// Key is where variant of img is stored, e.g.
+// "avatars/<user id>/<uuid>/medium.jpg".
+func (img Image) Key(variant string) string {
+	base, ext, _ := strings.Cut(img.ID, ".")
+	return fmt.Sprintf("%ss/%s/%s/%s.%s", img.Kind, img.Owner, base, variant, ext)
+}
+
+// ContentType is the media type of every variant of img.
+func (img Image) ContentType() string {
+	for ct, ext := range imageTypes {
+		if strings.HasSuffix(img.ID, ext) {
+			return ct
+		}
+	}
+	return "application/octet-stream"
+}
+
+// ImageResizer scales images down.
+type ImageResizer interface {
+	// Resize decodes src, scales it so its longest side is at most
+	// maxSide pixels (never up) and encodes it to dst in contentType.
+	Resize(ctx context.Context, src io.Reader, contentType string, maxSide int, dst io.Writer) error
+}
@@ -0,0 +1,21 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Locker hands out named, mutually exclusive leases shared by every
+// instance of the service, so singleton work (relaying the outbox, a
+// nightly job) runs in one place at a time.
+type Locker interface {
+	// Acquire takes the lock without waiting and returns ErrLockHeld if
+	// another owner has it. The lease lapses after ttl unless released
+	// first, so a crashed owner cannot hold it forever.
+	Acquire(ctx context.Context, key string, ttl time.Duration) (Lock, error)
+}
+
+// Lock is a held lease.
+type Lock interface {
+	Release(ctx context.Context) error
+}
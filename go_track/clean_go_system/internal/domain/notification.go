@@ -0,0 +1,130 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Channel is the way a notification reaches its recipient.
+type Channel string
+
+const (
+	ChannelEmail   Channel = "email"
+	ChannelSMS     Channel = "sms"
+	ChannelWebhook Channel = "webhook"
+)
+
+// Message is the text of a notification, rendered from a template. HTML
+// is only set by templates that have an HTML version; Body is always the
+// plain text.
+type Message struct {
+	Subject string
+	Body    string
+	HTML    string
+}
+
+// Notification is a rendered message on its way to one recipient. To is
+// an address on Channel: an email address, a phone number, or empty for a
+// webhook, which knows its own URL. UserID is the user a message goes
+// to, and zero for webhooks; Locale is the language it was rendered in.
+type Notification struct {
+	Channel Channel
+	To      string
+	UserID  uuid.UUID
+	Event   string
+	Locale  string
+	Message
+}
+
+// Notifier delivers notifications on one channel.
+type Notifier interface {
+	Notify(ctx context.Context, n Notification) error
+}
+
+// MessageTemplates renders the message for an event type in a locale. An
+// implementation falls back from "pt-BR" to "pt" to its default locale,
+// through any LocaleFallbacks it was given, and returns ErrNoTemplate
+// when none of those has a template.
+type MessageTemplates interface {
+	Render(event, locale string, data any) (Message, error)
+}
+
+var localePattern = regexp.MustCompile(`^[a-z]{2,3}(-[a-z0-9]{2,8})*$`)
+
+// NormalizeLocale lowercases a BCP 47 tag such as "pt-BR", and returns ""
+// for anything that is not one.
+func NormalizeLocale(tag string) string {
+	tag = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(tag), "_", "-"))
+	if !localePattern.MatchString(tag) {
+		return ""
+	}
+	return tag
+}
+
+type localeKey struct{}
+
+// WithLocale records the language the caller of ctx reads.
+func WithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, localeKey{}, NormalizeLocale(locale))
+}
+
+// LocaleOf is the locale ctx carries, or "" if none.
+func LocaleOf(ctx context.Context) string {
+	locale, _ := ctx.Value(localeKey{}).(string)
+	return locale
+}
+
+// LocaleFallbacks maps a locale or a language to those tried after it,
+// in order, when it has no template: {"pt-br": {"pt", "es"}, "ca": {"es"}}.
+type LocaleFallbacks map[string][]string
+
+// ParseLocaleFallbacks reads "pt-br:pt,es;ca:es", the form
+// NOTIFY_LOCALE_FALLBACKS takes.
+func ParseLocaleFallbacks(s string) (LocaleFallbacks, error) {
+	out := make(LocaleFallbacks)
+	for _, entry := range strings.Split(s, ";") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		from, to, ok := strings.Cut(entry, ":")
+		locale := NormalizeLocale(from)
+		if !ok || locale == "" {
+			return nil, fmt.Errorf("locale fallback %q: want locale:fallback,fallback", entry)
+		}
+		for _, tag := range strings.Split(to, ",") {
+			fallback := NormalizeLocale(tag)
+			if fallback == "" {
+				return nil, fmt.Errorf("locale fallback %q: %q is not a locale", entry, tag)
+			}
+			out[locale] = append(out[locale], fallback)
+		}
+	}
+	return out, nil
+}
+
+// Chain is the locales to try for locale, in order: locale and its
+// fallbacks, its language ("pt" for "pt-br") and the language's
+// fallbacks, then defaultLocale. Each appears once and "" never does.
+func (f LocaleFallbacks) Chain(locale, defaultLocale string) []string {
+	locale = NormalizeLocale(locale)
+	language, _, _ := strings.Cut(locale, "-")
+	var chain []string
+	add := func(locales ...string) {
+		for _, l := range locales {
+			if l != "" && !slices.Contains(chain, l) {
+				chain = append(chain, l)
+			}
+		}
+	}
+	add(locale)
+	add(f[locale]...)
+	add(language)
+	add(f[language]...)
+	add(defaultLocale)
+	return chain
+}
@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	minPasswordLength = 8
+	maxPasswordLength = 72 // bcrypt ignores anything longer
+)
+
+// ValidatePassword accepts 8–72 bytes.
+func ValidatePassword(password string) error {
+	if len(password) < minPasswordLength || len(password) > maxPasswordLength {
+		return &FieldError{Rule: "length", Message: fmt.Sprintf("must be %d-%d bytes", minPasswordLength, maxPasswordLength), Err: ErrWeakPassword}
+	}
+	return nil
+}
+
+// PasswordReset is a single-use, expiring permission to set a user's
+// password. Only a hash of its secret is stored.
+type PasswordReset struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Hash      []byte
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+}
+
+// Usable reports whether the reset can still be redeemed at now.
+func (r PasswordReset) Usable(now time.Time) bool {
+	return r.UsedAt == nil && now.Before(r.ExpiresAt)
+}
+
+// CredentialRepository stores password hashes, apart from the user record.
+// The auth service keeps them; this service only ever sets one.
+type CredentialRepository interface {
+	SetPassword(ctx context.Context, user User, hash []byte) error
+}
+
+// PasswordResetRepository stores reset requests.
+type PasswordResetRepository interface {
+	Save(ctx context.Context, r PasswordReset) error
+	Get(ctx context.Context, id uuid.UUID) (*PasswordReset, error)
+	// MarkUsed redeems the reset; it fails with ErrInvalidResetToken if it
+	// was redeemed already, so two concurrent redemptions cannot both win.
+	MarkUsed(ctx context.Context, id uuid.UUID, at time.Time) error
+}
@@ -0,0 +1,36 @@
+package domain
+
+import (
+	"context"
+
+	"clean-code-cookbook/go/pkg/page"
+)
+
+// ProductSortFields are what products can be searched by, as the catalog
+// service sorts them; the zero page.Sort is its relevance order.
+var ProductSortFields = []string{"name", "price"}
+
+// Product is a catalog entry as the catalog service (go/services/catalog)
+// serves it. This service does not own products; it only reads them.
+type Product struct {
+	ID    string
+	Name  string
+	Price float64
+}
+
+// ProductSearch asks for one page of the products whose name matches
+// Query and whose price lies within [MinPrice, MaxPrice]; zero bounds are
+// open.
+type ProductSearch struct {
+	Query    string
+	MinPrice float64
+	MaxPrice float64
+	Page     page.Request
+}
+
+// ProductCatalog reads products from the catalog service.
+type ProductCatalog interface {
+	// GetProduct returns ErrProductNotFound when there is no such product.
+	GetProduct(ctx context.Context, id string) (*Product, error)
+	SearchProducts(ctx context.Context, search ProductSearch) (page.Page[Product], error)
+}
@@ -0,0 +1,32 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// RateLimit allows Count actions per Period, of which up to Burst may
+// come back to back.
+type RateLimit struct {
+	Count  int
+	Period time.Duration
+	Burst  int
+}
+
+// Interval is the steady-state spacing between two allowed actions.
+func (l RateLimit) Interval() time.Duration {
+	return l.Period / time.Duration(l.Count)
+}
+
+// Tolerance is how far ahead of the steady state a caller may get.
+func (l RateLimit) Tolerance() time.Duration {
+	return l.Interval() * time.Duration(max(l.Burst, 1)-1)
+}
+
+// RateLimiter meters actions per key across every instance of the
+// service, so a limit holds however many replicas serve the caller.
+type RateLimiter interface {
+	// Allow spends one action of key's budget under limit. When none is
+	// left it returns false and how long until the next one frees up.
+	Allow(ctx context.Context, key string, limit RateLimit) (ok bool, retryAfter time.Duration, err error)
+}
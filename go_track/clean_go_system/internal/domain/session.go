@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Session is a server-side login: the client holds an opaque token, and
+// the session behind it can be listed and revoked at any time. Only a
+// SHA-256 hash of the token is stored.
+type Session struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+	Hash   []byte
+	// UserAgent and IP describe the device, so users can tell their
+	// sessions apart when revoking one.
+	UserAgent  string
+	IP         string
+	CreatedAt  time.Time
+	LastSeenAt time.Time
+	// ExpiresAt slides forward with use, up to the service's maximum
+	// session lifetime.
+	ExpiresAt time.Time
+	RevokedAt *time.Time
+}
+
+// Active reports whether the session still authenticates at now.
+func (s Session) Active(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
+
+// SessionRepository stores sessions by ID and by token hash.
+type SessionRepository interface {
+	Save(ctx context.Context, s Session) error
+	GetByHash(ctx context.Context, hash []byte) (*Session, error)
+	// Touch records use of the session and its new expiry.
+	Touch(ctx context.Context, id uuid.UUID, lastSeen, expires time.Time) error
+	// ListByUser returns the user's sessions that are neither revoked nor
+	// expired at now, most recently used first.
+	ListByUser(ctx context.Context, userID uuid.UUID, now time.Time) ([]Session, error)
+	// Revoke stamps the session as revoked. Revoking a revoked session
+	// keeps the first timestamp.
+	Revoke(ctx context.Context, id uuid.UUID, at time.Time) error
+}
@@ -0,0 +1,63 @@
+package domain
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// BounceKind is what a provider reported about an email.
+type BounceKind string
+
+const (
+	// BounceHard means the address does not take mail at all.
+	BounceHard BounceKind = "bounce"
+	// BounceSoft means the mailbox is full, the server down or the like;
+	// a later email may get through.
+	BounceSoft BounceKind = "soft_bounce"
+	// BounceComplaint means the recipient marked the email as spam.
+	BounceComplaint BounceKind = "complaint"
+)
+
+// BounceReport is one bounce or complaint a provider reported, for the
+// message with ProviderID that went to Email.
+type BounceReport struct {
+	Kind       BounceKind
+	Email      string
+	ProviderID string
+	Detail     string
+	At         time.Time
+}
+
+// Undeliverable reports whether no more email should go to the address:
+// it bounced for good, or its owner complained.
+func (r BounceReport) Undeliverable() bool {
+	return r.Kind == BounceHard || r.Kind == BounceComplaint
+}
+
+// Suppression marks an address as undeliverable. Sends to it are skipped
+// until it is lifted.
+type Suppression struct {
+	Email  string
+	Reason BounceKind
+	Detail string
+	At     time.Time
+}
+
+// SuppressionList holds the addresses email is no longer sent to. Both
+// methods compare addresses after NormalizeEmail.
+type SuppressionList interface {
+	// Suppress adds s, or replaces the suppression of the same address.
+	Suppress(ctx context.Context, s Suppression) error
+	// Suppressed returns the suppression of email, or nil if it has none.
+	Suppressed(ctx context.Context, email string) (*Suppression, error)
+	// Lift removes the suppression of email, if any.
+	Lift(ctx context.Context, email string) error
+}
+
+// NormalizeEmail is the form addresses are compared in: trimmed and
+// lowercased. Providers report the address they were given, but may
+// change its case.
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
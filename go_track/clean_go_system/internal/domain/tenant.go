@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"context"
+	"regexp"
+)
+
+// TenantID names one isolated customer of the system. IDs double as DNS
+// labels, so a tenant can be addressed by subdomain.
+type TenantID string
+
+// DefaultTenant owns everything done without a tenant in the context:
+// single-tenant deployments, CLI commands and background jobs.
+const DefaultTenant TenantID = "default"
+
+var tenantIDPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// ValidateTenantID accepts lowercase DNS labels.
+func ValidateTenantID(id TenantID) error {
+	if !tenantIDPattern.MatchString(string(id)) {
+		return ErrInvalidTenant
+	}
+	return nil
+}
+
+type tenantKey struct{}
+
+// WithTenant scopes everything done with ctx to tenant: repositories only
+// read and write that tenant's rows.
+func WithTenant(ctx context.Context, tenant TenantID) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantOf is the tenant ctx is scoped to, DefaultTenant if none.
+func TenantOf(ctx context.Context) TenantID {
+	if tenant, ok := ctx.Value(tenantKey{}).(TenantID); ok && tenant != "" {
+		return tenant
+	}
+	return DefaultTenant
+}
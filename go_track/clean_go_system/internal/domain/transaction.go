@@ -0,0 +1,10 @@
+package domain
+
+import "context"
+
+// Transactor runs fn as one atomic unit of work. Repositories and
+// publishers called with the ctx handed to fn take part in the same
+// transaction, so "save user" and "record event" commit or fail together.
+type Transactor interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
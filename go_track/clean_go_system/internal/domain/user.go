@@ -2,29 +2,214 @@ package domain
 
 import (
 	"context"
-	"errors"
 	"time"
 
 	"github.com/google/uuid"
 )
 
-// Common Domain Errors
-var (
-	ErrUserNotFound = errors.New("user not found")
-	ErrInvalidEmail = errors.New("invalid email format")
-)
-
-// User is our clean entity
+// User is the aggregate root for an account. Its fields are read freely,
+// but changes go through its methods, which enforce the invariants and
+// record what happened; the service persists the user and then publishes
+// PullEvents.
 type User struct {
-	ID        uuid.UUID
+	ID uuid.UUID
+	// TenantID is the customer the user belongs to. Repositories fill it
+	// in from the context they were called with.
+	TenantID  TenantID
 	Email     string
 	Username  string
+	Active    bool
 	CreatedAt time.Time
+	// UpdatedAt is when the user last changed; it starts at CreatedAt.
+	UpdatedAt time.Time
+	// VerifiedAt is set once the user's email is known to reach them.
+	VerifiedAt *time.Time
+	// DeletedAt is set once the user is soft-deleted. Repositories hide
+	// such users unless the context asks for them (see WithDeleted).
+	DeletedAt *time.Time
+	// Locale is the language the user reads their emails in, a
+	// normalized tag such as "pt-br"; empty means the default.
+	Locale string
+	// Stale marks a last-known-good copy served while storage was failing;
+	// it is never persisted.
+	Stale bool
+
+	events []DomainEvent
+}
+
+// RegisterUser creates an active user of tenant reading locale, which
+// may be empty, and records UserRegistered. Bad input fails with a
+// ValidationError naming every broken field; a locale that is not a
+// language tag is dropped instead, as clients send all sorts.
+func RegisterUser(tenant TenantID, id uuid.UUID, email, username, locale string, at time.Time) (*User, error) {
+	var invalid ValidationError
+	invalid.Check("email", ValidateEmail(email))
+	invalid.Check("username", ValidateUsername(username))
+	if err := invalid.Err(); err != nil {
+		return nil, err
+	}
+	locale = NormalizeLocale(locale)
+	u := &User{ID: id, TenantID: tenant, Email: email, Username: username, Locale: locale, Active: true, CreatedAt: at, UpdatedAt: at}
+	u.record(UserRegistered{UserID: id, Email: email, Username: username, Locale: locale, At: at})
+	return u, nil
+}
+
+// ChangeEmail moves the user to a new address and records
+// UserEmailChanged and UserUpdated. The new address is unverified. Changing to the
+// current address is a no-op; a deactivated user cannot change it.
+func (u *User) ChangeEmail(email string, at time.Time) error {
+	var invalid ValidationError
+	invalid.Check("email", ValidateEmail(email))
+	if err := invalid.Err(); err != nil {
+		return err
+	}
+	if !u.Active {
+		return ErrUserInactive
+	}
+	if email == u.Email {
+		return nil
+	}
+	old := u.Email
+	u.Email = email
+	u.VerifiedAt = nil
+	u.changed(UserEmailChanged{UserID: u.ID, OldEmail: old, NewEmail: email, Locale: u.Locale, At: at}, at)
+	return nil
+}
+
+// ChangeLocale sets the language the user reads and records
+// UserLocaleChanged and UserUpdated; an empty locale goes back to the default. Setting
+// the current locale is a no-op.
+func (u *User) ChangeLocale(locale string, at time.Time) error {
+	normalized := NormalizeLocale(locale)
+	if normalized == "" && locale != "" {
+		return ErrInvalidLocale
+	}
+	if normalized == u.Locale {
+		return nil
+	}
+	u.Locale = normalized
+	u.changed(UserLocaleChanged{UserID: u.ID, Locale: normalized, At: at}, at)
+	return nil
+}
+
+// Deactivate revokes the user's access and records UserDeactivated and
+// UserUpdated. Deactivating an inactive user is a no-op.
+func (u *User) Deactivate(at time.Time) {
+	if !u.Active {
+		return
+	}
+	u.Active = false
+	u.changed(UserDeactivated{UserID: u.ID, Email: u.Email, Locale: u.Locale, At: at}, at)
+}
+
+// Verify marks the user's email as verified and records UserVerified and
+// UserUpdated. Verifying a verified user is a no-op.
+func (u *User) Verify(at time.Time) {
+	if u.Verified() {
+		return
+	}
+	u.VerifiedAt = &at
+	u.changed(UserVerified{UserID: u.ID, Email: u.Email, At: at}, at)
+}
+
+// Verified reports whether the user's email has been verified.
+func (u *User) Verified() bool {
+	return u.VerifiedAt != nil
+}
+
+// Delete soft-deletes the user and records UserDeleted. A deleted user is
+// also inactive, so nothing it held keeps working; its row stays, hidden,
+// until the purge job removes it. Deleting twice is a no-op.
+func (u *User) Delete(at time.Time) {
+	if u.Deleted() {
+		return
+	}
+	u.Active = false
+	u.UpdatedAt = at
+	u.DeletedAt = &at
+	u.record(UserDeleted{UserID: u.ID, Email: u.Email, Locale: u.Locale, At: at})
+}
+
+// Deleted reports whether the user has been soft-deleted.
+func (u *User) Deleted() bool {
+	return u.DeletedAt != nil
+}
+
+// PullEvents returns the events recorded since the last call and forgets
+// them, so each is published once.
+func (u *User) PullEvents() []DomainEvent {
+	events := u.events
+	u.events = nil
+	return events
+}
+
+// changed stamps the user as changed at and records e, then UserUpdated
+// with the user as it now is.
+func (u *User) changed(e DomainEvent, at time.Time) {
+	u.UpdatedAt = at
+	u.record(e)
+	u.record(UserUpdated{UserID: u.ID, Email: u.Email, Username: u.Username, Locale: u.Locale, Active: u.Active, Verified: u.Verified(), At: at})
+}
+
+func (u *User) record(e DomainEvent) {
+	u.events = append(u.events, e)
 }
 
 // UserRepository defines the contract for storage.
 // Note: It uses context.Context for timeout/cancellation propagation.
+//
+// Lookups return ErrUserNotFound for soft-deleted users unless ctx comes
+// from WithDeleted. A deleted user's email stays taken until it is purged.
+//
+// Every call is scoped to TenantOf(ctx): Save stores the user under that
+// tenant, and lookups and updates never reach another tenant's users.
+// Emails are unique per tenant.
 type UserRepository interface {
 	Save(ctx context.Context, u User) error
+	Update(ctx context.Context, u User) error
 	GetByEmail(ctx context.Context, email string) (*User, error)
-}
\ No newline at end of file
+	GetByID(ctx context.Context, id uuid.UUID) (*User, error)
+}
+
+// UserCache is a best-effort read-through cache in front of UserRepository,
+// scoped to TenantOf(ctx) like the repository. It never fails a lookup on
+// its own: when the cache is unavailable, lookups fall through to load.
+type UserCache interface {
+	// GetByEmail and GetByID return the cached user, or call load and cache
+	// the user it returns under both lookups. Errors from load are returned
+	// as is and never cached.
+	GetByEmail(ctx context.Context, email string, load func(context.Context) (*User, error)) (*User, error)
+	GetByID(ctx context.Context, id uuid.UUID, load func(context.Context) (*User, error)) (*User, error)
+	// Invalidate drops u so the next lookup reloads it, along with the entry
+	// under the email it was cached with if that has changed since.
+	Invalidate(ctx context.Context, u User)
+}
+
+// UserBatchReader fetches many users of ctx's tenant in one round trip,
+// leaving out unknown and, unless ctx comes from WithDeleted, soft-deleted
+// ones; order is not kept. Like UserPurger, only the stores implement it.
+type UserBatchReader interface {
+	GetByIDs(ctx context.Context, ids []uuid.UUID) ([]User, error)
+}
+
+// UserPurger removes soft-deleted users for good, of every tenant. Only
+// the stores implement it; decorators have nothing to add to a bulk delete.
+type UserPurger interface {
+	// PurgeDeleted deletes users soft-deleted before cutoff and returns
+	// how many went.
+	PurgeDeleted(ctx context.Context, cutoff time.Time) (int, error)
+}
+
+type includeDeletedKey struct{}
+
+// WithDeleted makes repository lookups with ctx return soft-deleted users
+// too, e.g. for audits or to restore an account.
+func WithDeleted(ctx context.Context) context.Context {
+	return context.WithValue(ctx, includeDeletedKey{}, true)
+}
+
+// IncludesDeleted reports whether ctx came from WithDeleted.
+func IncludesDeleted(ctx context.Context) bool {
+	include, _ := ctx.Value(includeDeletedKey{}).(bool)
+	return include
+}
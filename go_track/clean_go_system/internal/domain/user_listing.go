@@ -0,0 +1,94 @@
+package domain
+
+import (
+	"cmp"
+	"context"
+	"time"
+
+	"clean-code-cookbook/go/pkg/page"
+	"github.com/google/uuid"
+)
+
+// UserSortFields are what users can be listed by; the zero page.Sort means
+// created_at, oldest first. Personal fields are left out: with PII
+// encryption on, the store cannot order by them.
+var UserSortFields = []string{"created_at", "updated_at"}
+
+// UserLister pages through the users of ctx's tenant, hiding soft-deleted
+// ones unless ctx comes from WithDeleted. Like UserPurger, only the stores
+// implement it.
+type UserLister interface {
+	ListUsers(ctx context.Context, req page.Request) (page.Page[User], error)
+}
+
+// UserStreamer reads every user of ctx's tenant, oldest first, hiding
+// soft-deleted ones unless ctx comes from WithDeleted. It holds one batch
+// of rows at a time, however many users there are, and stops at the first
+// error of fn, which it returns. Like UserLister, only the stores
+// implement it.
+type UserStreamer interface {
+	StreamUsers(ctx context.Context, fn func(User) error) error
+}
+
+// UserCursor is where a user listing resumes: after the user whose sort
+// field is At, ties broken by ID. Cursors remember their sort, so one
+// cannot be replayed under another.
+type UserCursor struct {
+	Sort page.Sort
+	At   time.Time
+	ID   uuid.UUID
+}
+
+// CursorAfter is the cursor resuming after u in a listing sorted by s.
+func CursorAfter(u User, s page.Sort) page.Cursor {
+	return page.NewCursor(s.String(), UserSortValue(u, s).UTC().Format(time.RFC3339Nano), u.ID.String())
+}
+
+// ParseUserCursor unpacks a cursor issued under sort s; ok is false for
+// the empty cursor.
+func ParseUserCursor(cursor page.Cursor, s page.Sort) (c UserCursor, ok bool, err error) {
+	if cursor == "" {
+		return UserCursor{}, false, nil
+	}
+	keys, err := cursor.Keys(3)
+	if err != nil {
+		return UserCursor{}, false, err
+	}
+	if keys[0] != s.String() {
+		return UserCursor{}, false, page.ErrInvalidCursor
+	}
+	c.Sort = s
+	if c.At, err = time.Parse(time.RFC3339Nano, keys[1]); err != nil {
+		return UserCursor{}, false, page.ErrInvalidCursor
+	}
+	if c.ID, err = uuid.Parse(keys[2]); err != nil {
+		return UserCursor{}, false, page.ErrInvalidCursor
+	}
+	return c, true, nil
+}
+
+// UserSortValue is the value of u that a listing sorted by s orders on.
+func UserSortValue(u User, s page.Sort) time.Time {
+	if s.Field == "updated_at" {
+		return u.UpdatedAt
+	}
+	return u.CreatedAt
+}
+
+// CompareUsers orders a and b the way a listing sorted by s does: by the
+// sort field, then by ID, both reversed when s is descending.
+func CompareUsers(a, b User, s page.Sort) int {
+	c := UserSortValue(a, s).Compare(UserSortValue(b, s))
+	if c == 0 {
+		c = cmp.Compare(a.ID.String(), b.ID.String())
+	}
+	if s.Desc {
+		return -c
+	}
+	return c
+}
+
+// After reports whether u comes strictly after c in its listing.
+func (c UserCursor) After(u User) bool {
+	return CompareUsers(u, User{ID: c.ID, CreatedAt: c.At, UpdatedAt: c.At}, c.Sort) > 0
+}
@@ -0,0 +1,116 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	maxEmailLength    = 254 // RFC 5321 path limit
+	minUsernameLength = 3
+	maxUsernameLength = 32
+)
+
+// FieldError is one rule an input field broke. Rule is a stable name
+// clients can switch on ("format", "length", ...); Message is for people.
+// It unwraps to the sentinel for the kind of value, e.g. ErrInvalidEmail.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+	Err     error  `json:"-"`
+}
+
+func (e *FieldError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("%v: %s", e.Err, e.Message)
+	}
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+func (e *FieldError) Unwrap() error { return e.Err }
+
+// ValidationError collects every rule an input broke, so a client can fix
+// them all at once. errors.Is sees through it to each field's sentinel.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+// Check records err, if any, against field. A FieldError from a validator
+// is placed under field (nested paths are joined with dots); any other
+// error becomes an "invalid" rule.
+func (e *ValidationError) Check(field string, err error) {
+	if err == nil {
+		return
+	}
+	var fe *FieldError
+	if !errors.As(err, &fe) {
+		e.Fields = append(e.Fields, FieldError{Field: field, Rule: "invalid", Message: err.Error(), Err: err})
+		return
+	}
+	v := *fe
+	if v.Field != "" {
+		field += "." + v.Field
+	}
+	v.Field = field
+	e.Fields = append(e.Fields, v)
+}
+
+// Err returns e if anything was recorded and nil otherwise.
+func (e *ValidationError) Err() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Fields))
+	for i := range e.Fields {
+		msgs[i] = e.Fields[i].Error()
+	}
+	return "validation failed: " + strings.Join(msgs, "; ")
+}
+
+func (e *ValidationError) Unwrap() []error {
+	errs := make([]error, len(e.Fields))
+	for i := range e.Fields {
+		errs[i] = &e.Fields[i]
+	}
+	return errs
+}
+
+// ValidateEmail accepts a bare address ("alice@example.com"); display names,
+// angle brackets and comments are rejected so the stored value is exactly
+// what the user typed.
+func ValidateEmail(email string) error {
+	if email == "" {
+		return &FieldError{Rule: "required", Message: "must not be empty", Err: ErrInvalidEmail}
+	}
+	if len(email) > maxEmailLength {
+		return &FieldError{Rule: "length", Message: fmt.Sprintf("must be at most %d bytes", maxEmailLength), Err: ErrInvalidEmail}
+	}
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email || addr.Name != "" {
+		return &FieldError{Rule: "format", Message: "must be a bare address such as alice@example.com", Err: ErrInvalidEmail}
+	}
+	return nil
+}
+
+// ValidateUsername accepts 3–32 ASCII letters, digits, '.', '_' and '-'.
+func ValidateUsername(username string) error {
+	if n := utf8.RuneCountInString(username); n < minUsernameLength || n > maxUsernameLength {
+		return &FieldError{Rule: "length", Message: fmt.Sprintf("must be %d-%d characters", minUsernameLength, maxUsernameLength), Err: ErrInvalidUsername}
+	}
+	for _, r := range username {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '_', r == '-':
+		default:
+			return &FieldError{Rule: "charset", Message: fmt.Sprintf("unexpected character %q", r), Err: ErrInvalidUsername}
+		}
+	}
+	return nil
+}
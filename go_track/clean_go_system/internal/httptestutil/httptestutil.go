@@ -0,0 +1,101 @@
+// Package httptestutil builds requests for the HTTP adapter's tests and
+// asserts on what the handlers wrote.
+package httptestutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// NewRequest builds a request. A string or []byte body is sent verbatim, so
+// tests can send malformed JSON; any other non-nil body is encoded as JSON
+// and labelled application/json.
+func NewRequest(t *testing.T, method, target string, body any) *http.Request {
+	t.Helper()
+	var reader io.Reader
+	switch b := body.(type) {
+	case nil:
+	case string:
+		reader = bytes.NewBufferString(b)
+	case []byte:
+		reader = bytes.NewBuffer(b)
+	default:
+		raw, err := json.Marshal(b)
+		if err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+		reader = bytes.NewBuffer(raw)
+	}
+	r := httptest.NewRequest(method, target, reader)
+	if body != nil {
+		r.Header.Set("Content-Type", "application/json")
+	}
+	return r
+}
+
+// Authenticated sets a bearer token on r and returns it.
+func Authenticated(r *http.Request, token string) *http.Request {
+	r.Header.Set("Authorization", "Bearer "+token)
+	return r
+}
+
+// Serve runs h on r and returns what it wrote.
+func Serve(h http.Handler, r *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+	return rec
+}
+
+// AssertStatus fails the test unless the response has status want.
+func AssertStatus(t *testing.T, rec *httptest.ResponseRecorder, want int) {
+	t.Helper()
+	if rec.Code != want {
+		t.Fatalf("Expected status %d, but got %d: %s", want, rec.Code, rec.Body.String())
+	}
+}
+
+// AssertHeader fails the test unless header key has value want.
+func AssertHeader(t *testing.T, rec *httptest.ResponseRecorder, key, want string) {
+	t.Helper()
+	if got := rec.Header().Get(key); got != want {
+		t.Errorf("Expected header %s '%s', but got '%s'", key, want, got)
+	}
+}
+
+// DecodeJSON decodes the response body into a T, failing the test if the
+// body is not JSON.
+func DecodeJSON[T any](t *testing.T, rec *httptest.ResponseRecorder) T {
+	t.Helper()
+	var v T
+	if err := json.Unmarshal(rec.Body.Bytes(), &v); err != nil {
+		t.Fatalf("Expected a JSON body, but got %q: %v", rec.Body.String(), err)
+	}
+	return v
+}
+
+// AssertJSON fails the test unless the body is JSON equal to want, ignoring
+// formatting and key order. want may be a struct, a map or a JSON string.
+func AssertJSON(t *testing.T, rec *httptest.ResponseRecorder, want any) {
+	t.Helper()
+	raw, ok := want.(string)
+	if !ok {
+		encoded, err := json.Marshal(want)
+		if err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+		raw = string(encoded)
+	}
+	var wantValue any
+	if err := json.Unmarshal([]byte(raw), &wantValue); err != nil {
+		t.Fatalf("Expected valid JSON in the assertion, but got %q: %v", raw, err)
+	}
+	got := DecodeJSON[any](t, rec)
+	if !reflect.DeepEqual(got, wantValue) {
+		t.Errorf("Expected JSON %s, but got %s", raw, rec.Body.String())
+	}
+}
@@ -0,0 +1,454 @@
+// Package repotest is a contract suite for domain.UserRepository. Every
+// adapter runs the same cases, so not-found, duplicate, cancellation and
+// tenant-scoping behaviour cannot drift between Postgres, Mongo, SQLite and memory.
+package repotest
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"clean-code-cookbook/go/pkg/page"
+	"clean_go_system/internal/domain"
+	"github.com/google/uuid"
+)
+
+// Factory returns an empty repository for one subtest. It should register
+// any teardown with t.Cleanup.
+type Factory func(t *testing.T) domain.UserRepository
+
+// RunUserRepositoryTests runs the contract against repositories from newRepo.
+func RunUserRepositoryTests(t *testing.T, newRepo Factory) {
+	cases := []struct {
+		name string
+		run  func(t *testing.T, repo domain.UserRepository)
+	}{
+		{"SaveThenGet", saveThenGet},
+		{"GetUnknownIsNotFound", getUnknownIsNotFound},
+		{"UpdateUnknownIsNotFound", updateUnknownIsNotFound},
+		{"UpdateChangesEmail", updateChangesEmail},
+		{"UpdateKeepsVerification", updateKeepsVerification},
+		{"SaveDuplicateEmailIsExists", saveDuplicateEmailIsExists},
+		{"UpdateToTakenEmailIsExists", updateToTakenEmailIsExists},
+		{"CancelledContext", cancelledContext},
+		{"SoftDeletedIsHidden", softDeletedIsHidden},
+		{"PurgeDeleted", purgeDeleted},
+		{"ListUsersPagesInOrder", listUsersPagesInOrder},
+		{"GetByIDsSkipsWhatIsHidden", getByIDsSkipsWhatIsHidden},
+		{"StreamUsersInCreationOrder", streamUsersInCreationOrder},
+		{"TenantsAreIsolated", tenantsAreIsolated},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			c.run(t, newRepo(t))
+		})
+	}
+}
+
+// newUser truncates its timestamps to milliseconds, the coarsest
+// precision any adapter stores (BSON dates).
+func newUser(email string) domain.User {
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	return domain.User{
+		ID:        uuid.New(),
+		Email:     email,
+		Username:  "alice",
+		Active:    true,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+func mustSave(t *testing.T, repo domain.UserRepository, u domain.User) {
+	t.Helper()
+	if err := repo.Save(context.Background(), u); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+}
+
+func assertSame(t *testing.T, want domain.User, got *domain.User) {
+	t.Helper()
+	if got.ID != want.ID || got.Email != want.Email || got.Username != want.Username || got.Locale != want.Locale ||
+		got.Active != want.Active || !got.CreatedAt.Equal(want.CreatedAt) || !got.UpdatedAt.Equal(want.UpdatedAt) ||
+		(got.VerifiedAt == nil) != (want.VerifiedAt == nil) || got.VerifiedAt != nil && !got.VerifiedAt.Equal(*want.VerifiedAt) ||
+		(got.DeletedAt == nil) != (want.DeletedAt == nil) || got.DeletedAt != nil && !got.DeletedAt.Equal(*want.DeletedAt) {
+		t.Errorf("Expected %+v, but got %+v", want, *got)
+	}
+}
+
+func saveThenGet(t *testing.T, repo domain.UserRepository) {
+	// Arrange
+	alice := newUser("alice@example.com")
+	alice.Locale = "pt-br"
+	mustSave(t, repo, alice)
+
+	// Act
+	byEmail, emailErr := repo.GetByEmail(context.Background(), alice.Email)
+	byID, idErr := repo.GetByID(context.Background(), alice.ID)
+
+	// Assert
+	if emailErr != nil || idErr != nil {
+		t.Fatalf("Expected no errors, but got %v and %v", emailErr, idErr)
+	}
+	assertSame(t, alice, byEmail)
+	assertSame(t, alice, byID)
+}
+
+func getUnknownIsNotFound(t *testing.T, repo domain.UserRepository) {
+	// Act
+	_, emailErr := repo.GetByEmail(context.Background(), "nobody@example.com")
+	_, idErr := repo.GetByID(context.Background(), uuid.New())
+
+	// Assert
+	if !errors.Is(emailErr, domain.ErrUserNotFound) || !errors.Is(idErr, domain.ErrUserNotFound) {
+		t.Errorf("Expected error '%v' twice, but got '%v' and '%v'", domain.ErrUserNotFound, emailErr, idErr)
+	}
+}
+
+func updateUnknownIsNotFound(t *testing.T, repo domain.UserRepository) {
+	// Act
+	err := repo.Update(context.Background(), newUser("alice@example.com"))
+
+	// Assert
+	if !errors.Is(err, domain.ErrUserNotFound) {
+		t.Errorf("Expected error '%v', but got '%v'", domain.ErrUserNotFound, err)
+	}
+}
+
+func updateChangesEmail(t *testing.T, repo domain.UserRepository) {
+	// Arrange
+	alice := newUser("alice@example.com")
+	mustSave(t, repo, alice)
+	alice.Email = "alice@example.org"
+	alice.Active = false
+	alice.Locale = "de"
+
+	// Act
+	err := repo.Update(context.Background(), alice)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	got, err := repo.GetByEmail(context.Background(), alice.Email)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	assertSame(t, alice, got)
+	if _, err := repo.GetByEmail(context.Background(), "alice@example.com"); !errors.Is(err, domain.ErrUserNotFound) {
+		t.Errorf("Expected the old email to be gone, but got: %v", err)
+	}
+}
+
+func updateKeepsVerification(t *testing.T, repo domain.UserRepository) {
+	// Arrange
+	alice := newUser("alice@example.com")
+	mustSave(t, repo, alice)
+	at := alice.CreatedAt.Add(time.Minute)
+	alice.VerifiedAt, alice.UpdatedAt = &at, at
+
+	// Act
+	err := repo.Update(context.Background(), alice)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	got, err := repo.GetByID(context.Background(), alice.ID)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	assertSame(t, alice, got)
+}
+
+func saveDuplicateEmailIsExists(t *testing.T, repo domain.UserRepository) {
+	// Arrange
+	mustSave(t, repo, newUser("alice@example.com"))
+
+	// Act
+	err := repo.Save(context.Background(), newUser("alice@example.com"))
+
+	// Assert
+	if !errors.Is(err, domain.ErrUserExists) {
+		t.Errorf("Expected error '%v', but got '%v'", domain.ErrUserExists, err)
+	}
+}
+
+func updateToTakenEmailIsExists(t *testing.T, repo domain.UserRepository) {
+	// Arrange
+	alice, bob := newUser("alice@example.com"), newUser("bob@example.com")
+	mustSave(t, repo, alice)
+	mustSave(t, repo, bob)
+
+	// Act
+	bob.Email = alice.Email
+	err := repo.Update(context.Background(), bob)
+
+	// Assert
+	if !errors.Is(err, domain.ErrUserExists) {
+		t.Errorf("Expected error '%v', but got '%v'", domain.ErrUserExists, err)
+	}
+}
+
+func cancelledContext(t *testing.T, repo domain.UserRepository) {
+	// Arrange
+	alice := newUser("alice@example.com")
+	mustSave(t, repo, alice)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// Act
+	saveErr := repo.Save(ctx, newUser("bob@example.com"))
+	_, getErr := repo.GetByEmail(ctx, alice.Email)
+
+	// Assert
+	if !errors.Is(saveErr, context.Canceled) || !errors.Is(getErr, context.Canceled) {
+		t.Errorf("Expected '%v' twice, but got '%v' and '%v'", context.Canceled, saveErr, getErr)
+	}
+	if _, err := repo.GetByEmail(context.Background(), "bob@example.com"); !errors.Is(err, domain.ErrUserNotFound) {
+		t.Errorf("Expected the cancelled save to write nothing, but got: %v", err)
+	}
+}
+
+// deleteUser soft-deletes u in repo as of at.
+func deleteUser(t *testing.T, repo domain.UserRepository, u *domain.User, at time.Time) {
+	t.Helper()
+	at = at.UTC().Truncate(time.Millisecond)
+	u.Active, u.UpdatedAt, u.DeletedAt = false, at, &at
+	if err := repo.Update(context.Background(), *u); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+}
+
+func softDeletedIsHidden(t *testing.T, repo domain.UserRepository) {
+	// Arrange
+	alice := newUser("alice@example.com")
+	mustSave(t, repo, alice)
+	deleteUser(t, repo, &alice, time.Now())
+
+	// Act
+	_, emailErr := repo.GetByEmail(context.Background(), alice.Email)
+	_, idErr := repo.GetByID(context.Background(), alice.ID)
+	got, err := repo.GetByID(domain.WithDeleted(context.Background()), alice.ID)
+
+	// Assert
+	if !errors.Is(emailErr, domain.ErrUserNotFound) || !errors.Is(idErr, domain.ErrUserNotFound) {
+		t.Errorf("Expected error '%v' twice, but got '%v' and '%v'", domain.ErrUserNotFound, emailErr, idErr)
+	}
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	assertSame(t, alice, got)
+}
+
+// listUsersPagesInOrder runs only for stores; decorators do not list. Two
+// users share a creation time, so the cursor has to break the tie by ID.
+func listUsersPagesInOrder(t *testing.T, repo domain.UserRepository) {
+	lister, ok := repo.(domain.UserLister)
+	if !ok {
+		t.Skip("repository does not implement domain.UserLister")
+	}
+	// Arrange
+	first, deleted, second, twin := newUser("first@example.com"), newUser("deleted@example.com"), newUser("second@example.com"), newUser("twin@example.com")
+	deleted.CreatedAt = first.CreatedAt.Add(time.Second)
+	second.CreatedAt = first.CreatedAt.Add(2 * time.Second)
+	twin.CreatedAt = second.CreatedAt
+	for _, u := range []domain.User{first, deleted, second, twin} {
+		mustSave(t, repo, u)
+	}
+	deleteUser(t, repo, &deleted, time.Now())
+	if err := repo.Save(domain.WithTenant(context.Background(), "acme"), newUser("other@example.com")); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	newest := []domain.User{second, twin}
+	if second.ID.String() < twin.ID.String() {
+		newest = []domain.User{twin, second}
+	}
+	want := append(newest, first)
+
+	// Act
+	var got []domain.User
+	var pages int
+	req := page.Request{Size: 1, Sort: page.Sort{Field: "created_at", Desc: true}}
+	for {
+		p, err := lister.ListUsers(context.Background(), req)
+		if err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+		got, pages = append(got, p.Items...), pages+1
+		if p.Next == "" || pages > len(want) {
+			break
+		}
+		req.After = p.Next
+	}
+	req.Sort.Desc = false
+	_, replayErr := lister.ListUsers(context.Background(), req)
+
+	// Assert
+	if len(got) != len(want) {
+		t.Fatalf("Expected %d users, but got %d: %+v", len(want), len(got), got)
+	}
+	for i := range want {
+		if got[i].ID != want[i].ID {
+			t.Errorf("Expected %s at %d, but got %s", want[i].Email, i, got[i].Email)
+		}
+	}
+	if !errors.Is(replayErr, page.ErrInvalidCursor) {
+		t.Errorf("Expected error '%v' for a cursor from another sort, but got '%v'", page.ErrInvalidCursor, replayErr)
+	}
+}
+
+// getByIDsSkipsWhatIsHidden runs only for stores; decorators do not
+// batch. Unknown, soft-deleted and other tenants' users are left out.
+func getByIDsSkipsWhatIsHidden(t *testing.T, repo domain.UserRepository) {
+	batch, ok := repo.(domain.UserBatchReader)
+	if !ok {
+		t.Skip("repository does not implement domain.UserBatchReader")
+	}
+	// Arrange
+	alice, bob, deleted, other := newUser("alice@example.com"), newUser("bob@example.com"), newUser("deleted@example.com"), newUser("other@example.com")
+	for _, u := range []domain.User{alice, bob, deleted} {
+		mustSave(t, repo, u)
+	}
+	deleteUser(t, repo, &deleted, time.Now())
+	if err := repo.Save(domain.WithTenant(context.Background(), "acme"), other); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+
+	// Act
+	got, err := batch.GetByIDs(context.Background(), []uuid.UUID{bob.ID, uuid.New(), deleted.ID, other.ID, alice.ID})
+	none, noneErr := batch.GetByIDs(context.Background(), nil)
+
+	// Assert
+	if err != nil || noneErr != nil {
+		t.Fatalf("Expected no errors, but got: %v, %v", err, noneErr)
+	}
+	if len(got) != 2 || len(none) != 0 {
+		t.Fatalf("Expected alice and bob and nothing for no IDs, but got %+v and %+v", got, none)
+	}
+	byID := map[uuid.UUID]domain.User{got[0].ID: got[0], got[1].ID: got[1]}
+	for _, want := range []domain.User{alice, bob} {
+		u, ok := byID[want.ID]
+		if !ok {
+			t.Fatalf("Expected %s, but got %+v", want.Email, got)
+		}
+		assertSame(t, want, &u)
+	}
+}
+
+// streamUsersInCreationOrder runs only for stores; decorators do not
+// stream. An error of the callback ends the stream.
+func streamUsersInCreationOrder(t *testing.T, repo domain.UserRepository) {
+	streamer, ok := repo.(domain.UserStreamer)
+	if !ok {
+		t.Skip("repository does not implement domain.UserStreamer")
+	}
+	// Arrange
+	first, deleted, second := newUser("first@example.com"), newUser("deleted@example.com"), newUser("second@example.com")
+	deleted.CreatedAt = first.CreatedAt.Add(time.Second)
+	second.CreatedAt = first.CreatedAt.Add(2 * time.Second)
+	for _, u := range []domain.User{second, deleted, first} {
+		mustSave(t, repo, u)
+	}
+	deleteUser(t, repo, &deleted, time.Now())
+	if err := repo.Save(domain.WithTenant(context.Background(), "acme"), newUser("other@example.com")); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	stop := errors.New("stop")
+
+	// Act
+	var visible, all []string
+	err := streamer.StreamUsers(context.Background(), func(u domain.User) error {
+		visible = append(visible, u.Email)
+		return nil
+	})
+	withDeletedErr := streamer.StreamUsers(domain.WithDeleted(context.Background()), func(u domain.User) error {
+		all = append(all, u.Email)
+		return nil
+	})
+	calls := 0
+	stopErr := streamer.StreamUsers(context.Background(), func(domain.User) error {
+		calls++
+		return stop
+	})
+
+	// Assert
+	if err != nil || withDeletedErr != nil {
+		t.Fatalf("Expected no errors, but got: %v, %v", err, withDeletedErr)
+	}
+	if want := []string{first.Email, second.Email}; !slices.Equal(visible, want) {
+		t.Errorf("Expected %v, but got %v", want, visible)
+	}
+	if want := []string{first.Email, deleted.Email, second.Email}; !slices.Equal(all, want) {
+		t.Errorf("Expected %v with the deleted user, but got %v", want, all)
+	}
+	if !errors.Is(stopErr, stop) || calls != 1 {
+		t.Errorf("Expected the stream to end at the first error, but got '%v' after %d calls", stopErr, calls)
+	}
+}
+
+// purgeDeleted runs only for stores; decorators do not purge.
+func purgeDeleted(t *testing.T, repo domain.UserRepository) {
+	purger, ok := repo.(domain.UserPurger)
+	if !ok {
+		t.Skip("repository does not implement domain.UserPurger")
+	}
+	// Arrange
+	now := time.Now()
+	old, recent, live := newUser("old@example.com"), newUser("recent@example.com"), newUser("live@example.com")
+	for _, u := range []domain.User{old, recent, live} {
+		mustSave(t, repo, u)
+	}
+	deleteUser(t, repo, &old, now.Add(-48*time.Hour))
+	deleteUser(t, repo, &recent, now)
+
+	// Act
+	n, err := purger.PurgeDeleted(context.Background(), now.Add(-24*time.Hour))
+
+	// Assert
+	if err != nil || n != 1 {
+		t.Fatalf("Expected 1 user purged, but got %d, %v", n, err)
+	}
+	ctx := domain.WithDeleted(context.Background())
+	if _, err := repo.GetByID(ctx, old.ID); !errors.Is(err, domain.ErrUserNotFound) {
+		t.Errorf("Expected error '%v', but got '%v'", domain.ErrUserNotFound, err)
+	}
+	for _, u := range []domain.User{recent, live} {
+		if _, err := repo.GetByID(ctx, u.ID); err != nil {
+			t.Errorf("Expected %s to be kept, but got: %v", u.Email, err)
+		}
+	}
+}
+
+func tenantsAreIsolated(t *testing.T, repo domain.UserRepository) {
+	// Arrange
+	acme := domain.WithTenant(context.Background(), "acme")
+	globex := domain.WithTenant(context.Background(), "globex")
+	alice, twin := newUser("alice@example.com"), newUser("alice@example.com")
+	if err := repo.Save(acme, alice); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+
+	// Act
+	saveErr := repo.Save(globex, twin)
+	got, getErr := repo.GetByEmail(globex, alice.Email)
+	_, crossErr := repo.GetByID(globex, alice.ID)
+	alice.Username = "mallory"
+	updateErr := repo.Update(globex, alice)
+
+	// Assert
+	if saveErr != nil || getErr != nil {
+		t.Fatalf("Expected the email to be free in another tenant, but got '%v' and '%v'", saveErr, getErr)
+	}
+	if got.ID != twin.ID || got.TenantID != "globex" {
+		t.Errorf("Expected globex's own user, but got %s of %q", got.ID, got.TenantID)
+	}
+	if !errors.Is(crossErr, domain.ErrUserNotFound) || !errors.Is(updateErr, domain.ErrUserNotFound) {
+		t.Errorf("Expected error '%v' twice, but got '%v' and '%v'", domain.ErrUserNotFound, crossErr, updateErr)
+	}
+	if own, err := repo.GetByID(acme, alice.ID); err != nil || own.Username != "alice" || own.TenantID != "acme" {
+		t.Errorf("Expected acme's alice untouched, but got %+v, %v", own, err)
+	}
+}
@@ -0,0 +1,220 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"clean_go_system/internal/adapter/authz"
+	"clean_go_system/internal/adapter/cache"
+	httpadapter "clean_go_system/internal/adapter/http"
+	"clean_go_system/internal/adapter/memory"
+	"clean_go_system/internal/core"
+	"clean_go_system/internal/domain"
+	"clean_go_system/internal/httptestutil"
+	"github.com/google/uuid"
+)
+
+// asAPIKey acts as an API key holding scopes.
+func asAPIKey(scopes string) context.Context {
+	return core.WithActor(context.Background(), domain.Actor{Kind: domain.ActorAPIKey, ID: uuid.NewString(), Attrs: map[string]string{"scopes": scopes}})
+}
+
+// newAdmin returns an Admin under the default policies over a memory
+// store holding alice, plus that store.
+func newAdmin(t *testing.T) (*core.Admin, *memory.UserRepository, domain.User) {
+	t.Helper()
+	repo := memory.NewUserRepository()
+	alice := domain.User{ID: uuid.New(), Email: "alice@example.com", Username: "alice", Active: true, CreatedAt: time.Now()}
+	if err := repo.Save(context.Background(), alice); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	authorizer, err := authz.NewPolicyAuthorizer(authz.DefaultPolicies()...)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	users := core.NewUserService(repo, &recordingPublisher{}, memory.NewTransactor(), core.WithAuthorizer(authorizer), core.WithStreamer(repo))
+	return core.NewAdmin(users, authorizer), repo, alice
+}
+
+func TestAdmin_SuspendUserRevokesSessions(t *testing.T) {
+	// Arrange
+	admin, repo, alice := newAdmin(t)
+	admin.Sessions = core.NewSessionService(memory.NewSessionRepository())
+	for range 2 {
+		if _, _, err := admin.Sessions.Create(context.Background(), alice.ID, core.Device{}); err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+	}
+
+	// Act
+	user, err := admin.SuspendUser(asAPIKey(domain.ScopeAdmin), alice.ID)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	stored, _ := repo.GetByID(context.Background(), alice.ID)
+	if user.Active || stored.Active {
+		t.Error("Expected the user to be deactivated")
+	}
+	if live, _ := admin.Sessions.List(context.Background(), alice.ID); len(live) != 0 {
+		t.Errorf("Expected every session revoked, but %d are live", len(live))
+	}
+}
+
+func TestAdmin_VerifyAndSearchUsers(t *testing.T) {
+	// Arrange
+	admin, _, alice := newAdmin(t)
+	ctx := asAPIKey(domain.ScopeAdmin)
+
+	// Act
+	verified, err := admin.VerifyUser(ctx, alice.ID)
+	byEmail, _ := admin.SearchUsers(ctx, core.UserQuery{Email: alice.Email})
+	mismatch, _ := admin.SearchUsers(ctx, core.UserQuery{ID: alice.ID, Email: "bob@example.com"})
+	_, empty := admin.SearchUsers(ctx, core.UserQuery{})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if !verified.Verified() {
+		t.Error("Expected the user to be verified")
+	}
+	if len(byEmail) != 1 || !byEmail[0].Verified() {
+		t.Errorf("Expected to find the verified user by email, but got %+v", byEmail)
+	}
+	if len(mismatch) != 0 {
+		t.Errorf("Expected no user matching both, but got %+v", mismatch)
+	}
+	if !errors.Is(empty, core.ErrEmptyUserQuery) {
+		t.Errorf("Expected error '%v', but got '%v'", core.ErrEmptyUserQuery, empty)
+	}
+}
+
+func TestAdmin_RequiresAdminScope(t *testing.T) {
+	admin, _, alice := newAdmin(t)
+	admin.AddPool("email", core.NewWorkerPool(1, 1))
+	actors := map[string]context.Context{
+		"key without admin scope": asAPIKey(domain.ScopeUsersWrite),
+		"the user themselves":     asUser(alice.ID.String()),
+		"no actor":                context.Background(),
+	}
+	for name, ctx := range actors {
+		t.Run(name, func(t *testing.T) {
+			// Act
+			_, suspendErr := admin.SuspendUser(ctx, alice.ID)
+			_, pauseErr := admin.PausePool(ctx, "email")
+			_, flushErr := admin.FlushCache(ctx, "users")
+
+			// Assert
+			for _, err := range []error{suspendErr, pauseErr, flushErr} {
+				if !errors.Is(err, domain.ErrForbidden) {
+					t.Errorf("Expected error '%v', but got '%v'", domain.ErrForbidden, err)
+				}
+			}
+		})
+	}
+}
+
+func TestAdmin_FlushCache(t *testing.T) {
+	// Arrange
+	admin, repo, alice := newAdmin(t)
+	counting := &countingRepo{UserRepository: repo}
+	users := cache.NewUserCache(memory.NewStore[domain.User](time.Minute), quietLogger())
+	cached := cache.NewRepository(counting, users)
+	admin.AddCache("users", users)
+	ctx := asAPIKey(domain.ScopeAdmin)
+	_, _ = cached.GetByEmail(context.Background(), alice.Email)
+
+	// Act
+	flushed, err := admin.FlushCache(ctx, "users")
+	_, _ = cached.GetByEmail(context.Background(), alice.Email)
+	_, unknown := admin.FlushCache(ctx, "sessions")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if flushed != 2 {
+		t.Errorf("Expected the email and ID keys flushed, but got %d", flushed)
+	}
+	if counting.reads != 2 {
+		t.Errorf("Expected the lookup after the flush to miss, but got %d reads", counting.reads)
+	}
+	if !errors.Is(unknown, core.ErrUnknownCache) {
+		t.Errorf("Expected error '%v', but got '%v'", core.ErrUnknownCache, unknown)
+	}
+}
+
+func TestAdminHandler_Workers(t *testing.T) {
+	// Arrange
+	admin, _, _ := newAdmin(t)
+	pool := core.NewWorkerPool(1, 4)
+	pool.Process = func(context.Context, core.EmailJob) error { return nil }
+	pool.Start()
+	defer pool.Stop()
+	admin.AddPool("email", pool)
+	h := httpadapter.NewAdminHandler(admin, quietLogger())
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /admin/workers", h.Pools)
+	mux.HandleFunc("POST /admin/workers/{name}/pause", h.PausePool)
+	mux.HandleFunc("POST /admin/workers/{name}/drain", h.DrainPool)
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		req := httptestutil.NewRequest(t, method, path, nil)
+		return httptestutil.Serve(mux, req.WithContext(asAPIKey(domain.ScopeAdmin)))
+	}
+
+	// Act
+	paused := serve(http.MethodPost, "/admin/workers/email/pause")
+	_ = pool.TryEnqueue(core.EmailJob{Email: "alice@example.com"})
+	listed := serve(http.MethodGet, "/admin/workers")
+	drained := serve(http.MethodPost, "/admin/workers/email/drain")
+	unknown := serve(http.MethodPost, "/admin/workers/sms/pause")
+
+	// Assert
+	httptestutil.AssertStatus(t, paused, http.StatusOK)
+	httptestutil.AssertJSON(t, paused, map[string]any{
+		"name": "email", "workers": 1.0, "queued": 0.0, "busy": 0.0, "paused": true, "draining": false, "dead_letters": 0.0,
+	})
+	body := httptestutil.DecodeJSON[map[string][]map[string]any](t, listed)
+	if items := body["items"]; len(items) != 1 || items[0]["queued"] != 1.0 {
+		t.Errorf("Expected the paused pool with 1 queued job, but got %v", items)
+	}
+	httptestutil.AssertStatus(t, drained, http.StatusOK)
+	httptestutil.AssertStatus(t, unknown, http.StatusNotFound)
+}
+
+func TestAdminHandler_ExportUsers(t *testing.T) {
+	// Arrange
+	admin, repo, alice := newAdmin(t)
+	mallory := domain.User{ID: uuid.New(), Email: "mallory@example.com", Username: "=HYPERLINK(\"x\")", CreatedAt: alice.CreatedAt.Add(time.Second)}
+	if err := repo.Save(context.Background(), mallory); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	h := http.HandlerFunc(httpadapter.NewAdminHandler(admin, quietLogger()).ExportUsers)
+	req := httptestutil.NewRequest(t, http.MethodGet, "/admin/users/export", nil)
+
+	// Act
+	rec := httptestutil.Serve(h, req.WithContext(asAPIKey(domain.ScopeAdmin)))
+	forbidden := httptestutil.Serve(h, req.WithContext(asAPIKey(domain.ScopeUsersWrite)))
+
+	// Assert
+	httptestutil.AssertStatus(t, rec, http.StatusOK)
+	httptestutil.AssertHeader(t, rec, "Content-Type", "text/csv; charset=utf-8")
+	lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+	if len(lines) != 3 || lines[0] != "id,email,username,locale,active,verified_at,deleted_at,created_at" {
+		t.Fatalf("Expected a header and two users, but got:\n%s", rec.Body.String())
+	}
+	if !strings.HasPrefix(lines[1], alice.ID.String()+",alice@example.com,alice,") {
+		t.Errorf("Expected alice first, but got %q", lines[1])
+	}
+	if !strings.Contains(lines[2], `,"'=HYPERLINK(""x"")",`) {
+		t.Errorf("Expected the formula defused, but got %q", lines[2])
+	}
+	httptestutil.AssertStatus(t, forbidden, http.StatusForbidden)
+}
@@ -0,0 +1,79 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"clean_go_system/internal/adapter/eventbus"
+	"clean_go_system/internal/adapter/memory"
+	"clean_go_system/internal/domain"
+)
+
+// retryingTransactor runs fn twice when its first run fails, as Mongo does
+// on transient transaction errors.
+type retryingTransactor struct{}
+
+func (retryingTransactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if err := fn(ctx); err == nil {
+		return nil
+	}
+	return fn(ctx)
+}
+
+func TestAfterCommit_PublishesOnlyCommittedEvents(t *testing.T) {
+	// Arrange
+	published := &recordingPublisher{}
+	deferred := eventbus.NewAfterCommit(memory.NewTransactor(), published)
+	boom := errors.New("boom")
+
+	// Act
+	var seenInside int
+	committed := deferred.WithinTransaction(context.Background(), func(ctx context.Context) error {
+		err := deferred.Publish(ctx, domain.UserRegistered{Email: "alice@example.com"})
+		seenInside = len(published.events)
+		return err
+	})
+	rolledBack := deferred.WithinTransaction(context.Background(), func(ctx context.Context) error {
+		_ = deferred.Publish(ctx, domain.UserRegistered{Email: "bob@example.com"})
+		return boom
+	})
+
+	// Assert
+	if committed != nil || !errors.Is(rolledBack, boom) {
+		t.Fatalf("Expected nil and %v, but got %v and %v", boom, committed, rolledBack)
+	}
+	if seenInside != 0 {
+		t.Errorf("Expected nothing published before commit, but got %d events", seenInside)
+	}
+	if len(published.events) != 1 || published.events[0].(domain.UserRegistered).Email != "alice@example.com" {
+		t.Errorf("Expected only alice's registration, but got %+v", published.events)
+	}
+}
+
+func TestAfterCommit_PublishesARetriedTransactionOnce(t *testing.T) {
+	// Arrange
+	published := &recordingPublisher{}
+	deferred := eventbus.NewAfterCommit(retryingTransactor{}, published)
+	runs := 0
+
+	// Act
+	err := deferred.WithinTransaction(context.Background(), func(ctx context.Context) error {
+		runs++
+		if err := deferred.Publish(ctx, domain.UserRegistered{Email: "alice@example.com"}); err != nil {
+			return err
+		}
+		if runs == 1 {
+			return errors.New("transient")
+		}
+		return nil
+	})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if runs != 2 || len(published.events) != 1 {
+		t.Errorf("Expected 2 runs and 1 event, but got %d and %d", runs, len(published.events))
+	}
+}
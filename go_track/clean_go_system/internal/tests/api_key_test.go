@@ -0,0 +1,182 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	httpadapter "clean_go_system/internal/adapter/http"
+	"clean_go_system/internal/adapter/memory"
+	"clean_go_system/internal/core"
+	"clean_go_system/internal/domain"
+	"clean_go_system/internal/httptestutil"
+	"github.com/google/uuid"
+)
+
+func newAPIKeyService() (*core.APIKeyService, *memory.APIKeyRepository) {
+	repo := memory.NewAPIKeyRepository()
+	return core.NewAPIKeyService(repo), repo
+}
+
+func createKey(t *testing.T, svc *core.APIKeyService, rate float64, scopes ...string) (string, *domain.APIKey) {
+	t.Helper()
+	secret, key, err := svc.Create(context.Background(), "billing", scopes, rate)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	return secret, key
+}
+
+func TestAPIKeyService_StoresOnlyTheHash(t *testing.T) {
+	// Arrange
+	svc, repo := newAPIKeyService()
+
+	// Act
+	secret, key := createKey(t, svc, 0, domain.ScopeUsersWrite)
+
+	// Assert
+	if string(key.Hash) == secret || len(key.Hash) != 32 {
+		t.Errorf("Expected a SHA-256 hash, but got %x", key.Hash)
+	}
+	got, err := svc.Authenticate(context.Background(), secret)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if got.ID != key.ID || !got.Allows(domain.ScopeUsersWrite) {
+		t.Errorf("Expected key %v with users:write, but got %+v", key.ID, got)
+	}
+	if _, err := repo.GetByHash(context.Background(), []byte(secret)); !errors.Is(err, domain.ErrAPIKeyNotFound) {
+		t.Errorf("Expected error '%v', but got '%v'", domain.ErrAPIKeyNotFound, err)
+	}
+}
+
+func TestAPIKeyService_RejectsUnknownAndRevokedKeys(t *testing.T) {
+	// Arrange
+	svc, _ := newAPIKeyService()
+	secret, key := createKey(t, svc, 0, domain.ScopeUsersWrite)
+	if err := svc.Revoke(context.Background(), key.ID); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+
+	for name, candidate := range map[string]string{"revoked": secret, "unknown": "cgs_nope", "foreign": "sk_live_123"} {
+		t.Run(name, func(t *testing.T) {
+			// Act
+			_, err := svc.Authenticate(context.Background(), candidate)
+
+			// Assert
+			if !errors.Is(err, domain.ErrInvalidAPIKey) {
+				t.Errorf("Expected error '%v', but got '%v'", domain.ErrInvalidAPIKey, err)
+			}
+		})
+	}
+}
+
+func TestAPIKeyService_CreateRequiresName(t *testing.T) {
+	// Arrange
+	svc, _ := newAPIKeyService()
+
+	// Act
+	_, _, err := svc.Create(context.Background(), "  ", nil, 0)
+
+	// Assert
+	if !errors.Is(err, domain.ErrInvalidKeyName) {
+		t.Errorf("Expected error '%v', but got '%v'", domain.ErrInvalidKeyName, err)
+	}
+}
+
+func TestAPIKeyAuth_Require(t *testing.T) {
+	svc, _ := newAPIKeyService()
+	writer, _ := createKey(t, svc, 0, domain.ScopeUsersWrite)
+	admin, _ := createKey(t, svc, 0, domain.ScopeKeysAdmin)
+	auth := httpadapter.NewAPIKeyAuth(svc, quietLogger())
+	handler := auth.Require(domain.ScopeUsersWrite, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := httpadapter.APIKeyFrom(r.Context()); !ok {
+			t.Error("Expected the key in the request context")
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	cases := map[string]struct {
+		key  string
+		want int
+	}{
+		"granted":       {writer, http.StatusNoContent},
+		"missing":       {"", http.StatusUnauthorized},
+		"invalid":       {"cgs_forged", http.StatusUnauthorized},
+		"missing scope": {admin, http.StatusForbidden},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			// Arrange
+			req := httptestutil.NewRequest(t, http.MethodPost, "/register", nil)
+			if tc.key != "" {
+				req.Header.Set(httpadapter.APIKeyHeader, tc.key)
+			}
+
+			// Act
+			rec := httptestutil.Serve(handler, req)
+
+			// Assert
+			httptestutil.AssertStatus(t, rec, tc.want)
+		})
+	}
+}
+
+func TestAPIKeyAuth_RateLimitsPerKey(t *testing.T) {
+	// Arrange
+	svc, _ := newAPIKeyService()
+	limited, _ := createKey(t, svc, 2, domain.ScopeUsersWrite)
+	other, _ := createKey(t, svc, 2, domain.ScopeUsersWrite)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	auth := httpadapter.NewAPIKeyAuth(svc, quietLogger())
+	auth.Now = func() time.Time { return now }
+	handler := auth.Require(domain.ScopeUsersWrite, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	call := func(key string) int {
+		req := httptestutil.NewRequest(t, http.MethodPost, "/register", nil)
+		req.Header.Set(httpadapter.APIKeyHeader, key)
+		return httptestutil.Serve(handler, req).Code
+	}
+
+	// Act
+	burst := []int{call(limited), call(limited), call(limited)}
+	otherCode := call(other)
+	now = now.Add(500 * time.Millisecond)
+	refilled := call(limited)
+
+	// Assert
+	if burst[0] != http.StatusOK || burst[1] != http.StatusOK || burst[2] != http.StatusTooManyRequests {
+		t.Errorf("Expected 200, 200, 429, but got %v", burst)
+	}
+	if otherCode != http.StatusOK {
+		t.Errorf("Expected another key to keep its own budget, but got %d", otherCode)
+	}
+	if refilled != http.StatusOK {
+		t.Errorf("Expected a token after half a second at 2/s, but got %d", refilled)
+	}
+}
+
+func TestAPIKeyHandler_CreateAndRevoke(t *testing.T) {
+	// Arrange
+	svc, _ := newAPIKeyService()
+	admin := httpadapter.NewAPIKeyHandler(svc, quietLogger())
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /admin/keys", admin.Create)
+	mux.HandleFunc("DELETE /admin/keys/{id}", admin.Revoke)
+
+	// Act
+	created := httptestutil.Serve(mux, httptestutil.NewRequest(t, http.MethodPost, "/admin/keys",
+		map[string]any{"name": "billing", "scopes": []string{domain.ScopeUsersWrite}, "rate_limit": 5}))
+	body := httptestutil.DecodeJSON[map[string]any](t, created)
+	revoked := httptestutil.Serve(mux, httptestutil.NewRequest(t, http.MethodDelete, "/admin/keys/"+body["id"].(string), nil))
+	missing := httptestutil.Serve(mux, httptestutil.NewRequest(t, http.MethodDelete, "/admin/keys/"+uuid.NewString(), nil))
+
+	// Assert
+	httptestutil.AssertStatus(t, created, http.StatusCreated)
+	httptestutil.AssertStatus(t, revoked, http.StatusNoContent)
+	httptestutil.AssertStatus(t, missing, http.StatusNotFound)
+	if _, err := svc.Authenticate(context.Background(), body["key"].(string)); !errors.Is(err, domain.ErrInvalidAPIKey) {
+		t.Errorf("Expected error '%v', but got '%v'", domain.ErrInvalidAPIKey, err)
+	}
+}
@@ -0,0 +1,286 @@
+package tests
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"clean-code-cookbook/go/pkg/jwt"
+	httpadapter "clean_go_system/internal/adapter/http"
+	"clean_go_system/internal/adapter/memory"
+	"clean_go_system/internal/adapter/oidc"
+	"clean_go_system/internal/core"
+	"clean_go_system/internal/domain"
+	"clean_go_system/internal/httptestutil"
+	"github.com/google/uuid"
+)
+
+// fakeIdP is an OpenID Connect provider on httptest: discovery, a JWKS
+// with one RSA key, and a token endpoint answering with idToken.
+type fakeIdP struct {
+	*httptest.Server
+	key     *rsa.PrivateKey
+	idToken string
+}
+
+func newFakeIdP(t *testing.T) *fakeIdP {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	idp := &fakeIdP{key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 idp.URL,
+			"authorization_endpoint": idp.URL + "/authorize",
+			"token_endpoint":         idp.URL + "/token",
+			"jwks_uri":               idp.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kid": "k1",
+			"kty": "RSA",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("code") != "the-code" || r.FormValue("code_verifier") == "" {
+			http.Error(w, "bad grant", http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"id_token": idp.idToken})
+	})
+	idp.Server = httptest.NewServer(mux)
+	t.Cleanup(idp.Close)
+	return idp
+}
+
+// sign returns an ID token for alice with the given nonce, signed by kid.
+func (idp *fakeIdP) sign(t *testing.T, kid, nonce string) string {
+	t.Helper()
+	token, err := jwt.SignRS256(jwt.Claims{
+		Issuer:            idp.URL,
+		Subject:           "idp-alice",
+		Audience:          jwt.Audience{"client-1"},
+		ExpiresAt:         time.Now().Add(time.Hour).Unix(),
+		Nonce:             nonce,
+		Email:             "alice@example.com",
+		EmailVerified:     true,
+		PreferredUsername: "alice",
+	}, idp.key, kid)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	return token
+}
+
+func (idp *fakeIdP) provider() *oidc.Provider {
+	return oidc.NewProvider(oidc.Config{Issuer: idp.URL, ClientID: "client-1", RedirectURL: "http://app/callback"})
+}
+
+func newFederatedLogin() (*core.FederatedLogin, *memory.UserRepository) {
+	repo := memory.NewUserRepository()
+	tx := memory.NewTransactor()
+	users := core.NewUserService(repo, &recordingPublisher{}, tx)
+	return core.NewFederatedLogin(users, repo, memory.NewIdentityRepository(), tx), repo
+}
+
+func TestJWT_HS256(t *testing.T) {
+	// Arrange
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	raw, err := jwt.SignHS256(jwt.Claims{Issuer: "me", Subject: "u", ExpiresAt: time.Now().Add(time.Minute).Unix()}, secret)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+
+	// Act
+	token, err := jwt.Parse(raw)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+
+	// Assert
+	if err := token.VerifyHS256(secret); err != nil {
+		t.Errorf("Expected a valid signature, but got: %v", err)
+	}
+	if err := token.VerifyHS256([]byte("another secret")); !errors.Is(err, jwt.ErrSignature) {
+		t.Errorf("Expected error '%v', but got '%v'", jwt.ErrSignature, err)
+	}
+	if err := token.Claims.Validate(jwt.Expect{Issuer: "me", Now: time.Now().Add(time.Hour)}); !errors.Is(err, jwt.ErrExpired) {
+		t.Errorf("Expected error '%v', but got '%v'", jwt.ErrExpired, err)
+	}
+}
+
+func TestOIDCProvider_Verify(t *testing.T) {
+	idp := newFakeIdP(t)
+
+	t.Run("valid", func(t *testing.T) {
+		// Act
+		claims, err := idp.provider().Verify(context.Background(), idp.sign(t, "k1", "n-1"), "n-1")
+
+		// Assert
+		if err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+		if claims.Subject != "idp-alice" || claims.Email != "alice@example.com" {
+			t.Errorf("Expected alice's claims, but got %+v", claims)
+		}
+	})
+
+	t.Run("nonce mismatch", func(t *testing.T) {
+		// Act
+		_, err := idp.provider().Verify(context.Background(), idp.sign(t, "k1", "n-1"), "n-2")
+
+		// Assert
+		if !errors.Is(err, jwt.ErrClaims) {
+			t.Errorf("Expected error '%v', but got '%v'", jwt.ErrClaims, err)
+		}
+	})
+
+	t.Run("unknown key", func(t *testing.T) {
+		// Act
+		_, err := idp.provider().Verify(context.Background(), idp.sign(t, "rotated-away", ""), "")
+
+		// Assert
+		if !errors.Is(err, oidc.ErrUnknownKey) {
+			t.Errorf("Expected error '%v', but got '%v'", oidc.ErrUnknownKey, err)
+		}
+	})
+}
+
+func TestFederatedLogin_SignIn(t *testing.T) {
+	profile := core.ExternalProfile{Issuer: "https://idp", Subject: "s-1", Email: "alice@example.com", EmailVerified: true, Username: "alice"}
+
+	t.Run("registers and links a new user", func(t *testing.T) {
+		// Arrange
+		logins, _ := newFederatedLogin()
+
+		// Act
+		first, err := logins.SignIn(context.Background(), profile)
+		if err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+		again, err := logins.SignIn(context.Background(), profile)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+		if first.ID != again.ID || first.Username != "alice" {
+			t.Errorf("Expected the same user alice twice, but got %+v and %+v", first, again)
+		}
+	})
+
+	t.Run("links an existing user by verified email", func(t *testing.T) {
+		// Arrange
+		logins, repo := newFederatedLogin()
+		existing := domain.User{ID: uuid.New(), Email: profile.Email, Username: "alice-local", Active: true}
+		if err := repo.Save(context.Background(), existing); err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+
+		// Act
+		user, err := logins.SignIn(context.Background(), profile)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+		if user.ID != existing.ID {
+			t.Errorf("Expected user %v, but got %v", existing.ID, user.ID)
+		}
+	})
+
+	t.Run("refuses an unverified email", func(t *testing.T) {
+		// Arrange
+		logins, _ := newFederatedLogin()
+		unverified := profile
+		unverified.EmailVerified = false
+
+		// Act
+		_, err := logins.SignIn(context.Background(), unverified)
+
+		// Assert
+		if !errors.Is(err, domain.ErrInvalidEmail) {
+			t.Errorf("Expected error '%v', but got '%v'", domain.ErrInvalidEmail, err)
+		}
+	})
+}
+
+func TestOIDCLogin_EndToEnd(t *testing.T) {
+	// Arrange
+	idp := newFakeIdP(t)
+	provider := idp.provider()
+	logins, repo := newFederatedLogin()
+	sessions := httpadapter.NewSessionTokens("clean_go_system", []byte("0123456789abcdef0123456789abcdef"), time.Hour)
+	users := core.NewUserService(repo, &recordingPublisher{}, memory.NewTransactor())
+	oidcHandler := httpadapter.NewOIDCHandler(provider, logins, sessions, quietLogger())
+	bearer := httpadapter.NewBearerAuth(sessions, provider, logins, quietLogger())
+	me := bearer.Middleware(http.HandlerFunc(httpadapter.NewHandler(users, quietLogger()).Me))
+
+	// Act
+	// 1. Login redirects to the IdP and remembers the attempt in a cookie
+	login := httptestutil.Serve(http.HandlerFunc(oidcHandler.Login), httptestutil.NewRequest(t, http.MethodGet, "/auth/oidc/login", nil))
+	location, err := url.Parse(login.Header().Get("Location"))
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	query := location.Query()
+	idp.idToken = idp.sign(t, "k1", query.Get("nonce"))
+
+	// 2. The IdP sends the browser back with a code
+	callbackReq := httptestutil.NewRequest(t, http.MethodGet, "/auth/oidc/callback?code=the-code&state="+url.QueryEscape(query.Get("state")), nil)
+	for _, c := range login.Result().Cookies() {
+		callbackReq.AddCookie(c)
+	}
+	callback := httptestutil.Serve(http.HandlerFunc(oidcHandler.Callback), callbackReq)
+	session := httptestutil.DecodeJSON[map[string]any](t, callback)
+
+	// 3. Both our session token and the IdP's token open /me
+	bySession := httptestutil.Serve(me, httptestutil.Authenticated(httptestutil.NewRequest(t, http.MethodGet, "/me", nil), session["token"].(string)))
+	byIDToken := httptestutil.Serve(me, httptestutil.Authenticated(httptestutil.NewRequest(t, http.MethodGet, "/me", nil), idp.idToken))
+	forged := httptestutil.Serve(me, httptestutil.Authenticated(httptestutil.NewRequest(t, http.MethodGet, "/me", nil), session["token"].(string)+"x"))
+
+	// Assert
+	httptestutil.AssertStatus(t, login, http.StatusFound)
+	if query.Get("code_challenge_method") != "S256" || query.Get("client_id") != "client-1" {
+		t.Errorf("Expected a PKCE auth request for client-1, but got %v", query)
+	}
+	httptestutil.AssertStatus(t, callback, http.StatusOK)
+	httptestutil.AssertStatus(t, bySession, http.StatusOK)
+	httptestutil.AssertStatus(t, byIDToken, http.StatusOK)
+	httptestutil.AssertStatus(t, forged, http.StatusUnauthorized)
+	if body := httptestutil.DecodeJSON[map[string]string](t, bySession); body["email"] != "alice@example.com" {
+		t.Errorf("Expected alice, but got %v", body)
+	}
+}
+
+func TestOIDCCallback_RejectsStateMismatch(t *testing.T) {
+	// Arrange
+	idp := newFakeIdP(t)
+	logins, _ := newFederatedLogin()
+	sessions := httpadapter.NewSessionTokens("clean_go_system", []byte("0123456789abcdef0123456789abcdef"), time.Hour)
+	handler := httpadapter.NewOIDCHandler(idp.provider(), logins, sessions, quietLogger())
+	req := httptestutil.NewRequest(t, http.MethodGet, "/auth/oidc/callback?code=the-code&state=attacker", nil)
+	req.AddCookie(&http.Cookie{Name: "oidc_login", Value: "mine.nonce.verifier"})
+
+	// Act
+	rec := httptestutil.Serve(http.HandlerFunc(handler.Callback), req)
+
+	// Assert
+	httptestutil.AssertStatus(t, rec, http.StatusBadRequest)
+}
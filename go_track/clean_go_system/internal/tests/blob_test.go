@@ -0,0 +1,108 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"clean_go_system/internal/adapter/filesystem"
+	"clean_go_system/internal/domain"
+	"clean_go_system/pkg/clock"
+)
+
+func TestFilesystemBlobStore_PutGetDelete(t *testing.T) {
+	// Arrange
+	store, err := filesystem.NewBlobStore(t.TempDir(), "http://localhost/blobs", []byte("secret"))
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	ctx := context.Background()
+
+	// Act
+	err = store.Put(ctx, "avatars/alice.png", strings.NewReader("png bytes"), -1, "image/png")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	r, info, err := store.Get(ctx, "avatars/alice.png")
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	body, _ := io.ReadAll(r)
+	r.Close()
+	if string(body) != "png bytes" || info.ContentType != "image/png" {
+		t.Errorf("Expected the stored png, but got %q (%s)", body, info.ContentType)
+	}
+	if err := store.Delete(ctx, "avatars/alice.png"); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if _, _, err := store.Get(ctx, "avatars/alice.png"); !errors.Is(err, domain.ErrBlobNotFound) {
+		t.Errorf("Expected error '%v', but got '%v'", domain.ErrBlobNotFound, err)
+	}
+}
+
+func TestFilesystemBlobStore_RejectsKeysOutsideRoot(t *testing.T) {
+	// Arrange
+	store, _ := filesystem.NewBlobStore(t.TempDir(), "http://localhost/blobs", []byte("secret"))
+
+	// Act
+	err := store.Put(context.Background(), "../escape.txt", strings.NewReader("x"), 1, "text/plain")
+
+	// Assert
+	if !errors.Is(err, domain.ErrInvalidKey) {
+		t.Fatalf("Expected error '%v', but got '%v'", domain.ErrInvalidKey, err)
+	}
+}
+
+func TestFilesystemBlobStore_SignedURL(t *testing.T) {
+	// Arrange
+	store, _ := filesystem.NewBlobStore(t.TempDir(), "http://localhost/blobs", []byte("secret"))
+	ctx := context.Background()
+	_ = store.Put(ctx, "products/sku-1.jpg", strings.NewReader("jpeg bytes"), -1, "image/jpeg")
+	handler := http.StripPrefix("/blobs", store.Handler())
+
+	// Act
+	signed, err := store.SignedURL(ctx, "products/sku-1.jpg", time.Minute)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	ok := httptest.NewRecorder()
+	handler.ServeHTTP(ok, httptest.NewRequest(http.MethodGet, signed, nil))
+	tampered := httptest.NewRecorder()
+	handler.ServeHTTP(tampered, httptest.NewRequest(http.MethodGet, strings.Replace(signed, "sku-1", "sku-2", 1), nil))
+
+	// Assert
+	if ok.Code != http.StatusOK || ok.Body.String() != "jpeg bytes" {
+		t.Errorf("Expected 200 with the image, but got %d %q", ok.Code, ok.Body.String())
+	}
+	if tampered.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for a tampered URL, but got %d", tampered.Code)
+	}
+}
+
+func TestFilesystemBlobStore_SignedURLExpires(t *testing.T) {
+	// Arrange
+	store, _ := filesystem.NewBlobStore(t.TempDir(), "http://localhost/blobs", []byte("secret"))
+	clk := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	store.Clock = clk
+	ctx := context.Background()
+	_ = store.Put(ctx, "products/sku-1.jpg", strings.NewReader("jpeg bytes"), -1, "image/jpeg")
+	handler := http.StripPrefix("/blobs", store.Handler())
+	signed, _ := store.SignedURL(ctx, "products/sku-1.jpg", time.Minute)
+
+	// Act
+	clk.Advance(2 * time.Minute)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, signed, nil))
+
+	// Assert
+	if rec.Code != http.StatusForbidden || !strings.Contains(rec.Body.String(), "expired") {
+		t.Errorf("Expected 403 for an expired link, but got %d %q", rec.Code, rec.Body.String())
+	}
+}
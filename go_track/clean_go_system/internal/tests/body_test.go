@@ -0,0 +1,81 @@
+package tests
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	httpadapter "clean_go_system/internal/adapter/http"
+	"clean_go_system/internal/httptestutil"
+)
+
+func TestRegisterHandler_RejectsHostilePayloads(t *testing.T) {
+	cases := []struct {
+		name        string
+		body        string
+		contentType string
+		want        int
+	}{
+		{"valid", `{"email":"alice@example.com","username":"alice"}`, "application/json", http.StatusCreated},
+		{"charset parameter", `{"email":"alice@example.com","username":"alice"}`, "application/json; charset=utf-8", http.StatusCreated},
+		{"unknown field", `{"email":"alice@example.com","username":"alice","admin":true}`, "application/json", http.StatusBadRequest},
+		{"trailing data", `{"email":"alice@example.com","username":"alice"} {}`, "application/json", http.StatusBadRequest},
+		{"too deep", `{"email":` + strings.Repeat("[", 100) + strings.Repeat("]", 100) + `}`, "application/json", http.StatusBadRequest},
+		{"form", "email=alice@example.com", "application/x-www-form-urlencoded", http.StatusUnsupportedMediaType},
+		{"no content type", `{"email":"alice@example.com","username":"alice"}`, "", http.StatusUnsupportedMediaType},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			handler, _ := newRegisterHandler(&recordingPublisher{})
+			req := httptestutil.NewRequest(t, http.MethodPost, "/register", tc.body)
+			req.Header.Set("Content-Type", tc.contentType)
+
+			// Act
+			rec := httptestutil.Serve(handler, req)
+
+			// Assert
+			httptestutil.AssertStatus(t, rec, tc.want)
+		})
+	}
+}
+
+func TestLimitBody(t *testing.T) {
+	handler, _ := newRegisterHandler(&recordingPublisher{})
+	limited := httpadapter.LimitBody(64, handler)
+	oversized := `{"email":"alice@example.com","username":"` + strings.Repeat("a", 100) + `"}`
+
+	t.Run("declared length over the limit", func(t *testing.T) {
+		// Arrange
+		req := httptestutil.NewRequest(t, http.MethodPost, "/register", oversized)
+
+		// Act
+		rec := httptestutil.Serve(limited, req)
+
+		// Assert
+		httptestutil.AssertStatus(t, rec, http.StatusRequestEntityTooLarge)
+	})
+
+	t.Run("chunked body over the limit", func(t *testing.T) {
+		// Arrange
+		req := httptestutil.NewRequest(t, http.MethodPost, "/register", oversized)
+		req.ContentLength = -1 // unknown, as with Transfer-Encoding: chunked
+
+		// Act
+		rec := httptestutil.Serve(limited, req)
+
+		// Assert
+		httptestutil.AssertStatus(t, rec, http.StatusRequestEntityTooLarge)
+	})
+
+	t.Run("within the limit", func(t *testing.T) {
+		// Arrange
+		req := httptestutil.NewRequest(t, http.MethodPost, "/register", map[string]string{"email": "bob@example.com", "username": "bob"})
+
+		// Act
+		rec := httptestutil.Serve(limited, req)
+
+		// Assert
+		httptestutil.AssertStatus(t, rec, http.StatusCreated)
+	})
+}
@@ -0,0 +1,304 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"clean_go_system/internal/adapter/email"
+	"clean_go_system/internal/adapter/memory"
+	"clean_go_system/internal/core"
+	"clean_go_system/internal/domain"
+	"clean_go_system/internal/httptestutil"
+	"clean_go_system/pkg/clock"
+	"github.com/google/uuid"
+)
+
+// bounceFixture is a Bounces over memory stores holding one delivery
+// that the provider knows as "msg-1".
+type bounceFixture struct {
+	bounces      *core.Bounces
+	suppressions *memory.SuppressionList
+	deliveries   *memory.DeliveryRepository
+	delivery     domain.Delivery
+}
+
+func newBounceFixture(t *testing.T) *bounceFixture {
+	t.Helper()
+	f := &bounceFixture{suppressions: memory.NewSuppressionList(), deliveries: memory.NewDeliveryRepository()}
+	f.delivery = domain.Delivery{ID: uuid.New(), UserID: uuid.New(), Template: "user.registered", Status: domain.DeliverySent, ProviderID: "msg-1"}
+	if err := f.deliveries.Record(context.Background(), f.delivery); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	f.bounces = core.NewBounces(f.suppressions, f.deliveries)
+	return f
+}
+
+func (f *bounceFixture) suppressed(t *testing.T, address string) bool {
+	t.Helper()
+	s, err := f.suppressions.Suppressed(context.Background(), address)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	return s != nil
+}
+
+func (f *bounceFixture) status(t *testing.T) domain.DeliveryStatus {
+	t.Helper()
+	d, err := f.deliveries.GetByProviderID(context.Background(), f.delivery.ProviderID)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	return d.Status
+}
+
+func TestBounces_SuppressHardBouncesAndComplaintsOnly(t *testing.T) {
+	// Arrange
+	f := newBounceFixture(t)
+	reports := []domain.BounceReport{
+		{Kind: domain.BounceSoft, Email: "full@example.com", Detail: "mailbox full"},
+		{Kind: domain.BounceHard, Email: "Gone@Example.com", ProviderID: "msg-1", Detail: "550 no such user"},
+		{Kind: domain.BounceComplaint, Email: "annoyed@example.com", ProviderID: "unknown"},
+	}
+
+	// Act
+	err := f.bounces.Handle(context.Background(), reports)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if f.suppressed(t, "full@example.com") {
+		t.Error("Expected a soft bounce not to suppress the address")
+	}
+	if !f.suppressed(t, "gone@example.com") || !f.suppressed(t, "annoyed@example.com") {
+		t.Error("Expected the bounced and the complaining address to be suppressed")
+	}
+	if status := f.status(t); status != domain.DeliveryBounced {
+		t.Errorf("Expected the delivery to be marked %s, but got %s", domain.DeliveryBounced, status)
+	}
+}
+
+func TestEmailDelivery_SkipsSuppressedAddresses(t *testing.T) {
+	// Arrange
+	f := newBounceFixture(t)
+	_ = f.suppressions.Suppress(context.Background(), domain.Suppression{Email: "gone@example.com", Reason: domain.BounceHard})
+	sender := &recordingSender{}
+	delivery := core.NewEmailDelivery(sender)
+	delivery.Deliveries, delivery.Suppressions = f.deliveries, f.suppressions
+	job := core.EmailJob{ID: uuid.New(), UserID: uuid.New(), Email: "GONE@example.com"}
+
+	// Act
+	err := delivery.Process(context.Background(), job)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if len(sender.sent) != 0 {
+		t.Errorf("Expected no send, but got %+v", sender.sent)
+	}
+	list, _ := f.deliveries.ListByUser(context.Background(), job.UserID, 1)
+	if len(list) != 1 || list[0].Status != domain.DeliverySuppressed {
+		t.Errorf("Expected a suppressed delivery, but got %+v", list)
+	}
+}
+
+// signSendGrid signs body the way SendGrid's event webhook does, at ts.
+func signSendGrid(t *testing.T, key *ecdsa.PrivateKey, ts int64, body []byte) http.Header {
+	t.Helper()
+	stamp := strconv.FormatInt(ts, 10)
+	digest := sha256.Sum256(append([]byte(stamp), body...))
+	sig, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	return http.Header{
+		"X-Twilio-Email-Event-Webhook-Signature": {base64.StdEncoding.EncodeToString(sig)},
+		"X-Twilio-Email-Event-Webhook-Timestamp": {stamp},
+	}
+}
+
+func TestSendGridEvents(t *testing.T) {
+	// Arrange
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	der, _ := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	body := []byte(`[
+		{"email":"gone@example.com","timestamp":1714564800,"event":"bounce","type":"bounce","reason":"550 5.1.1 unknown user","sg_message_id":"msg-1.filterdrecv-1"},
+		{"email":"busy@example.com","timestamp":1714564800,"event":"bounce","type":"blocked","reason":"421 try later"},
+		{"email":"alice@example.com","timestamp":1714564800,"event":"delivered"}
+	]`)
+	post := func(header http.Header, body []byte) (*httptest.ResponseRecorder, *bounceFixture) {
+		f := newBounceFixture(t)
+		events, err := email.NewSendGridEvents(base64.StdEncoding.EncodeToString(der), f.bounces.Handle, quietLogger())
+		if err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+		events.Clock = clock.NewFake(now)
+		req := httptest.NewRequest(http.MethodPost, "/email/events", bytes.NewReader(body))
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		return httptestutil.Serve(events.Handler(), req), f
+	}
+
+	// Act
+	accepted, f := post(signSendGrid(t, key, now.Unix(), body), body)
+	tampered, _ := post(signSendGrid(t, key, now.Unix(), body), append(body, ' '))
+	stale, _ := post(signSendGrid(t, key, now.Add(-time.Hour).Unix(), body), body)
+	unsigned, _ := post(nil, body)
+
+	// Assert
+	httptestutil.AssertStatus(t, accepted, http.StatusNoContent)
+	if !f.suppressed(t, "gone@example.com") || f.suppressed(t, "busy@example.com") {
+		t.Error("Expected only the hard bounce to be suppressed")
+	}
+	if status := f.status(t); status != domain.DeliveryBounced {
+		t.Errorf("Expected the delivery to be marked %s, but got %s", domain.DeliveryBounced, status)
+	}
+	for name, rec := range map[string]*httptest.ResponseRecorder{"tampered": tampered, "stale": stale, "unsigned": unsigned} {
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("Expected a %s request to get %d, but got %d", name, http.StatusUnauthorized, rec.Code)
+		}
+	}
+}
+
+// roundTripFunc serves a client's requests without a network.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+// snsSigner signs SNS messages with a self-signed certificate served at
+// certURL, and records the other URLs fetched.
+type snsSigner struct {
+	key     *rsa.PrivateKey
+	certPEM []byte
+	fetched []string
+}
+
+const (
+	snsTopic   = "arn:aws:sns:eu-west-1:123456789012:ses-bounces"
+	snsCertURL = "https://sns.eu-west-1.amazonaws.com/SimpleNotificationService-1234.pem"
+)
+
+func newSNSSigner(t *testing.T) *snsSigner {
+	t.Helper()
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	template := &x509.Certificate{SerialNumber: big.NewInt(1), Subject: pkix.Name{CommonName: "sns.amazonaws.com"}, NotAfter: time.Now().Add(time.Hour)}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	return &snsSigner{key: key, certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+func (s *snsSigner) client() *http.Client {
+	return &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		body := "ok"
+		if r.URL.String() == snsCertURL {
+			body = string(s.certPEM)
+		} else {
+			s.fetched = append(s.fetched, r.URL.String())
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body)), Request: r}, nil
+	})}
+}
+
+// sign fills in msg's signature fields, signing the fields SNS signs.
+func (s *snsSigner) sign(t *testing.T, msg map[string]string) []byte {
+	t.Helper()
+	names := []string{"Message", "MessageId", "SubscribeURL", "Timestamp", "Token", "TopicArn", "Type"}
+	if msg["Type"] == "Notification" {
+		names = []string{"Message", "MessageId", "Subject", "Timestamp", "TopicArn", "Type"}
+	}
+	var signed strings.Builder
+	for _, name := range names {
+		if value, ok := msg[name]; ok {
+			signed.WriteString(name + "\n" + value + "\n")
+		}
+	}
+	digest := sha256.Sum256([]byte(signed.String()))
+	sig, _ := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, digest[:])
+	msg["SignatureVersion"] = "2"
+	msg["Signature"] = base64.StdEncoding.EncodeToString(sig)
+	if _, ok := msg["SigningCertURL"]; !ok {
+		msg["SigningCertURL"] = snsCertURL
+	}
+	body, _ := json.Marshal(msg)
+	return body
+}
+
+func TestSESEvents(t *testing.T) {
+	// Arrange
+	signer := newSNSSigner(t)
+	bounce := `{"notificationType":"Bounce","mail":{"messageId":"msg-1"},"bounce":{"bounceType":"Permanent","bounceSubType":"General",` +
+		`"bouncedRecipients":[{"emailAddress":"gone@example.com","diagnosticCode":"smtp; 550 5.1.1 user unknown"}],"timestamp":"2024-05-01T12:00:00Z"}}`
+	notification := func() map[string]string {
+		return map[string]string{"Type": "Notification", "MessageId": "1", "TopicArn": snsTopic, "Message": bounce, "Timestamp": "2024-05-01T12:00:01Z"}
+	}
+	post := func(body []byte) (*httptest.ResponseRecorder, *bounceFixture) {
+		f := newBounceFixture(t)
+		events, err := email.NewSESEvents(snsTopic, f.bounces.Handle, quietLogger())
+		if err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+		events.Client = signer.client()
+		req := httptest.NewRequest(http.MethodPost, "/email/events", bytes.NewReader(body))
+		return httptestutil.Serve(events.Handler(), req), f
+	}
+	otherTopic := notification()
+	otherTopic["TopicArn"] = "arn:aws:sns:eu-west-1:999999999999:elsewhere"
+	foreignCert := notification()
+	foreignCert["SigningCertURL"] = "https://attacker.example.com/cert.pem"
+	tampered := signer.sign(t, notification())
+	tampered = bytes.Replace(tampered, []byte("gone@example.com"), []byte("alice@example.com"), 1)
+	subscribeURL := "https://sns.eu-west-1.amazonaws.com/?Action=ConfirmSubscription&Token=abc"
+
+	// Act
+	accepted, f := post(signer.sign(t, notification()))
+	confirmed, _ := post(signer.sign(t, map[string]string{
+		"Type": "SubscriptionConfirmation", "MessageId": "2", "Token": "abc", "TopicArn": snsTopic,
+		"Message": "You have chosen to subscribe", "SubscribeURL": subscribeURL, "Timestamp": "2024-05-01T12:00:00Z",
+	}))
+	rejected := map[string]*httptest.ResponseRecorder{}
+	rejected["other topic"], _ = post(signer.sign(t, otherTopic))
+	rejected["foreign certificate"], _ = post(signer.sign(t, foreignCert))
+	rejected["tampered"], _ = post(tampered)
+
+	// Assert
+	httptestutil.AssertStatus(t, accepted, http.StatusNoContent)
+	if !f.suppressed(t, "gone@example.com") {
+		t.Error("Expected the permanently bounced address to be suppressed")
+	}
+	if status := f.status(t); status != domain.DeliveryBounced {
+		t.Errorf("Expected the delivery to be marked %s, but got %s", domain.DeliveryBounced, status)
+	}
+	httptestutil.AssertStatus(t, confirmed, http.StatusNoContent)
+	if len(signer.fetched) != 1 || signer.fetched[0] != subscribeURL {
+		t.Errorf("Expected the subscription to be confirmed, but fetched %v", signer.fetched)
+	}
+	for name, rec := range rejected {
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("Expected a message with a %s to get %d, but got %d", name, http.StatusUnauthorized, rec.Code)
+		}
+	}
+}
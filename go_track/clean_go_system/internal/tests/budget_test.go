@@ -0,0 +1,82 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"clean-code-cookbook/go/pkg/budget"
+	"clean_go_system/internal/httptestutil"
+)
+
+func TestBudget_TakesShareOfRemainingTime(t *testing.T) {
+	// Arrange
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	b := budget.Budget{Share: 0.6}
+
+	// Act
+	d, ok := b.Timeout(ctx)
+
+	// Assert
+	if !ok || d > 600*time.Millisecond || d < 500*time.Millisecond {
+		t.Errorf("Expected about 600ms, but got %v (bounded %v)", d, ok)
+	}
+}
+
+func TestBudget_FloorAndCeiling(t *testing.T) {
+	// Arrange
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	tiny := budget.Budget{Share: 0.01, Floor: 50 * time.Millisecond}
+	capped := budget.Budget{Share: 1, Ceiling: 100 * time.Millisecond}
+
+	// Act
+	floored, _ := tiny.Timeout(ctx)
+	ceiled, _ := capped.Timeout(ctx)
+
+	// Assert
+	if floored != 50*time.Millisecond {
+		t.Errorf("Expected the 50ms floor, but got %v", floored)
+	}
+	if ceiled != 100*time.Millisecond {
+		t.Errorf("Expected the 100ms ceiling, but got %v", ceiled)
+	}
+}
+
+func TestBudget_WithoutDeadlineUsesCeiling(t *testing.T) {
+	// Arrange
+	bounded := budget.Budget{Share: 0.5, Ceiling: time.Second}
+	unbounded := budget.Budget{Share: 0.5}
+
+	// Act
+	d, ok := bounded.Timeout(context.Background())
+	_, unboundedOK := unbounded.Timeout(context.Background())
+
+	// Assert
+	if !ok || d != time.Second {
+		t.Errorf("Expected the 1s ceiling, but got %v (bounded %v)", d, ok)
+	}
+	if unboundedOK {
+		t.Error("Expected no timeout without a deadline or ceiling")
+	}
+}
+
+func TestBudgetMiddleware_SetsRequestDeadline(t *testing.T) {
+	// Arrange
+	var deadline time.Time
+	var ok bool
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		deadline, ok = r.Context().Deadline()
+	})
+	req := httptestutil.NewRequest(t, http.MethodGet, "/", nil)
+
+	// Act
+	httptestutil.Serve(budget.Middleware(time.Second, next), req)
+
+	// Assert
+	if !ok || time.Until(deadline) > time.Second {
+		t.Errorf("Expected a deadline within 1s, but got %v (set %v)", deadline, ok)
+	}
+}
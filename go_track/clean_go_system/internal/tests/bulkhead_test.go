@@ -0,0 +1,87 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"clean-code-cookbook/go/pkg/bulkhead"
+	httpadapter "clean_go_system/internal/adapter/http"
+	"clean_go_system/internal/adapter/limited"
+	"clean_go_system/internal/adapter/memory"
+	"clean_go_system/internal/core"
+	"clean_go_system/internal/httptestutil"
+)
+
+func TestBulkhead_RejectsAfterQueueTimeout(t *testing.T) {
+	// Arrange
+	pool := bulkhead.New("db", 1, 10*time.Millisecond)
+	release, err := pool.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	defer release()
+
+	// Act
+	start := time.Now()
+	err = pool.Do(context.Background(), func(context.Context) error { return nil })
+
+	// Assert
+	if !errors.Is(err, bulkhead.ErrFull) {
+		t.Fatalf("Expected error '%v', but got '%v'", bulkhead.ErrFull, err)
+	}
+	if elapsed := time.Since(start); elapsed < 10*time.Millisecond {
+		t.Errorf("Expected the call to queue for the timeout, but it failed after %s", elapsed)
+	}
+}
+
+func TestBulkhead_QueuedCallGetsFreedSlot(t *testing.T) {
+	// Arrange
+	pool := bulkhead.New("db", 1, time.Second)
+	release, _ := pool.Acquire(context.Background())
+	time.AfterFunc(10*time.Millisecond, release)
+
+	// Act
+	err := pool.Do(context.Background(), func(context.Context) error { return nil })
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if pool.InUse() != 0 {
+		t.Errorf("Expected every slot released, but %d are in use", pool.InUse())
+	}
+}
+
+func TestBulkhead_ZeroLimitIsUnlimited(t *testing.T) {
+	// Arrange
+	pool := bulkhead.New("db", 0, 0)
+
+	// Act
+	err := pool.Do(context.Background(), func(context.Context) error { return nil })
+
+	// Assert
+	if err != nil || pool.Limit() != 0 {
+		t.Errorf("Expected a pass-through, but got limit %d and error %v", pool.Limit(), err)
+	}
+}
+
+func TestRegisterHandler_SaturatedDatabaseIs503(t *testing.T) {
+	// Arrange
+	pool := bulkhead.New("database", 1, 0)
+	release, _ := pool.Acquire(context.Background())
+	defer release()
+	repo := limited.NewUserRepository(memory.NewUserRepository(), pool)
+	svc := core.NewUserService(repo, &recordingPublisher{}, memory.NewTransactor())
+	handler := http.HandlerFunc(httpadapter.NewHandler(svc, quietLogger()).Register)
+	req := httptestutil.NewRequest(t, http.MethodPost, "/register", map[string]string{"email": "alice@example.com", "username": "alice"})
+
+	// Act
+	rec := httptestutil.Serve(handler, req)
+
+	// Assert
+	httptestutil.AssertStatus(t, rec, http.StatusServiceUnavailable)
+	httptestutil.AssertHeader(t, rec, "Retry-After", "1")
+}
@@ -0,0 +1,111 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"clean_go_system/internal/adapter/cache"
+	"clean_go_system/internal/adapter/memory"
+	"clean_go_system/internal/domain"
+	"github.com/google/uuid"
+)
+
+// downStore is a cacheaside.Store whose backend is unreachable.
+type downStore struct{}
+
+func (downStore) Get(ctx context.Context, key string) (domain.User, error) {
+	return domain.User{}, errors.New("connection refused")
+}
+
+func (downStore) Set(ctx context.Context, key string, u domain.User) error {
+	return errors.New("connection refused")
+}
+
+func (downStore) Delete(ctx context.Context, keys ...string) error {
+	return errors.New("connection refused")
+}
+
+// countingRepo counts the lookups that reach the wrapped repository.
+type countingRepo struct {
+	domain.UserRepository
+	reads int
+}
+
+func (r *countingRepo) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
+	r.reads++
+	return r.UserRepository.GetByEmail(ctx, email)
+}
+
+func (r *countingRepo) GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	r.reads++
+	return r.UserRepository.GetByID(ctx, id)
+}
+
+func TestCachedRepository_ServesRepeatReadsFromCache(t *testing.T) {
+	// Arrange
+	repo := &countingRepo{UserRepository: memory.NewUserRepository()}
+	alice := domain.User{ID: uuid.New(), Email: "alice@example.com", Username: "alice"}
+	_ = repo.Save(context.Background(), alice)
+	cached := cache.NewRepository(repo, cache.NewUserCache(memory.NewStore[domain.User](time.Minute), quietLogger()))
+
+	// Act
+	_, _ = cached.GetByEmail(context.Background(), alice.Email)
+	got, err := cached.GetByID(context.Background(), alice.ID)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if got.Email != alice.Email {
+		t.Errorf("Expected %s, but got %s", alice.Email, got.Email)
+	}
+	if repo.reads != 1 {
+		t.Errorf("Expected 1 repository read, but got %d", repo.reads)
+	}
+}
+
+func TestCachedRepository_UpdateInvalidatesOldEmail(t *testing.T) {
+	// Arrange
+	store := memory.NewStore[domain.User](time.Minute)
+	alice := domain.User{ID: uuid.New(), Email: "alice@example.com", Username: "alice"}
+	cached := cache.NewRepository(memory.NewUserRepository(), cache.NewUserCache(store, quietLogger()))
+	_ = cached.Save(context.Background(), alice)
+	_, _ = cached.GetByEmail(context.Background(), alice.Email)
+
+	// Act
+	renamed := alice
+	renamed.Email = "alice@new.example.com"
+	err := cached.Update(context.Background(), renamed)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if _, err := cached.GetByEmail(context.Background(), alice.Email); !errors.Is(err, domain.ErrUserNotFound) {
+		t.Errorf("Expected error '%v' for the old email, but got '%v'", domain.ErrUserNotFound, err)
+	}
+}
+
+func TestCachedRepository_DegradesWhenCacheIsDown(t *testing.T) {
+	// Arrange
+	repo := memory.NewUserRepository()
+	alice := domain.User{ID: uuid.New(), Email: "alice@example.com", Username: "alice"}
+	_ = repo.Save(context.Background(), alice)
+	cached := cache.NewRepository(repo, cache.NewUserCache(downStore{}, quietLogger()))
+
+	// Act
+	got, err := cached.GetByID(context.Background(), alice.ID)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if got.Email != alice.Email {
+		t.Errorf("Expected %s, but got %s", alice.Email, got.Email)
+	}
+	if err := cached.Update(context.Background(), alice); err != nil {
+		t.Errorf("Expected writes to succeed without the cache, but got: %v", err)
+	}
+}
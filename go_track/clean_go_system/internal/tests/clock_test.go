@@ -0,0 +1,90 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"clean-code-cookbook/go/pkg/cacheaside"
+	"clean_go_system/internal/adapter/memory"
+	"clean_go_system/internal/core"
+	"clean_go_system/internal/domain"
+	"clean_go_system/pkg/clock"
+)
+
+var epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestFakeClock_AfterFiresOnlyWhenDue(t *testing.T) {
+	// Arrange
+	clk := clock.NewFake(epoch)
+	early, late := clk.After(time.Second), clk.After(time.Minute)
+
+	// Act
+	clk.Advance(30 * time.Second)
+
+	// Assert
+	select {
+	case at := <-early:
+		if !at.Equal(epoch.Add(30 * time.Second)) {
+			t.Errorf("Expected to fire at the advanced time, but got %s", at)
+		}
+	default:
+		t.Error("Expected the 1s timer to fire")
+	}
+	select {
+	case <-late:
+		t.Error("Expected the 1m timer to still be pending")
+	default:
+	}
+	if clk.Waiters() != 1 {
+		t.Errorf("Expected 1 pending waiter, but got %d", clk.Waiters())
+	}
+}
+
+func TestUserService_UsesInjectedClock(t *testing.T) {
+	// Arrange
+	clk := clock.NewFake(epoch)
+	publisher := &recordingPublisher{}
+	svc := core.NewUserService(memory.NewUserRepository(), publisher, memory.NewTransactor(), core.WithClock(clk))
+	ctx := context.Background()
+
+	// Act
+	user, err := svc.Register(ctx, "alice@example.com", "alice")
+	clk.Advance(time.Hour)
+	_ = svc.Deactivate(ctx, "alice@example.com")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if !user.CreatedAt.Equal(epoch) {
+		t.Errorf("Expected CreatedAt %s, but got %s", epoch, user.CreatedAt)
+	}
+	if got := publisher.events[1].(domain.UserDeactivated).At; !got.Equal(epoch.Add(time.Hour)) {
+		t.Errorf("Expected deactivation at %s, but got %s", epoch.Add(time.Hour), got)
+	}
+}
+
+func TestMemoryStore_ExpiresOnTheInjectedClock(t *testing.T) {
+	// Arrange
+	clk := clock.NewFake(epoch)
+	store := memory.NewStore[string](time.Minute)
+	store.Clock = clk
+	ctx := context.Background()
+	_ = store.Set(ctx, "k", "v")
+
+	// Act
+	clk.Advance(59 * time.Second)
+	_, fresh := store.Get(ctx, "k")
+	clk.Advance(2 * time.Second)
+	_, stale := store.Get(ctx, "k")
+
+	// Assert
+	if fresh != nil {
+		t.Errorf("Expected a hit before the TTL, but got: %v", fresh)
+	}
+	if !errors.Is(stale, cacheaside.ErrMiss) {
+		t.Errorf("Expected a miss after the TTL, but got: %v", stale)
+	}
+}
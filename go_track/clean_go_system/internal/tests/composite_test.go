@@ -0,0 +1,114 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"clean_go_system/internal/adapter/composite"
+	"clean_go_system/internal/adapter/memory"
+	"clean_go_system/internal/domain"
+	"github.com/google/uuid"
+)
+
+// downRepo is a UserRepository whose backend is unreachable.
+type downRepo struct{ domain.UserRepository }
+
+func (downRepo) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
+	return nil, errors.New("connection refused")
+}
+
+func (downRepo) GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	return nil, errors.New("connection refused")
+}
+
+func TestCompositeRepository_FallsBackOnRead(t *testing.T) {
+	// Arrange
+	replica := memory.NewUserRepository()
+	alice := domain.User{ID: uuid.New(), Email: "alice@example.com", Username: "alice"}
+	_ = replica.Save(context.Background(), alice)
+	repo := composite.NewUserRepository(
+		composite.Source{Name: "primary", Repo: downRepo{}},
+		composite.Source{Name: "replica", Repo: replica},
+	)
+
+	// Act
+	got, err := repo.GetByID(context.Background(), alice.ID)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if got.Email != alice.Email {
+		t.Errorf("Expected %s, but got %s", alice.Email, got.Email)
+	}
+}
+
+func TestCompositeRepository_WritesOnlyToPrimary(t *testing.T) {
+	// Arrange
+	primary, replica := memory.NewUserRepository(), memory.NewUserRepository()
+	repo := composite.NewUserRepository(
+		composite.Source{Name: "primary", Repo: primary},
+		composite.Source{Name: "replica", Repo: replica},
+	)
+	alice := domain.User{ID: uuid.New(), Email: "alice@example.com", Username: "alice"}
+
+	// Act
+	err := repo.Save(context.Background(), alice)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if _, err := replica.GetByID(context.Background(), alice.ID); !errors.Is(err, domain.ErrUserNotFound) {
+		t.Errorf("Expected the replica to be untouched, but got: %v", err)
+	}
+}
+
+func TestCompositeRepository_NotFoundEndsTheChain(t *testing.T) {
+	// Arrange
+	replica := &countingRepo{UserRepository: memory.NewUserRepository()}
+	repo := composite.NewUserRepository(
+		composite.Source{Name: "primary", Repo: memory.NewUserRepository()},
+		composite.Source{Name: "replica", Repo: replica},
+	)
+
+	// Act
+	_, err := repo.GetByEmail(context.Background(), "nobody@example.com")
+
+	// Assert
+	if !errors.Is(err, domain.ErrUserNotFound) {
+		t.Fatalf("Expected error '%v', but got '%v'", domain.ErrUserNotFound, err)
+	}
+	if replica.reads != 0 {
+		t.Errorf("Expected the replica to be skipped, but it served %d reads", replica.reads)
+	}
+}
+
+func TestCompositeRepository_SkipsUnhealthySourceDuringCooldown(t *testing.T) {
+	// Arrange
+	primary := &countingRepo{UserRepository: downRepo{}}
+	replica := memory.NewUserRepository()
+	alice := domain.User{ID: uuid.New(), Email: "alice@example.com", Username: "alice"}
+	_ = replica.Save(context.Background(), alice)
+	repo := composite.NewUserRepository(
+		composite.Source{Name: "primary", Repo: primary},
+		composite.Source{Name: "replica", Repo: replica},
+	)
+	repo.FailureThreshold = 2
+
+	// Act
+	for i := 0; i < 5; i++ {
+		if _, err := repo.GetByEmail(context.Background(), alice.Email); err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+	}
+
+	// Assert
+	if primary.reads != 2 {
+		t.Errorf("Expected 2 primary reads before the cooldown, but got %d", primary.reads)
+	}
+	if health := repo.Health(); health[0].Healthy || !health[1].Healthy {
+		t.Errorf("Expected only the primary to be unhealthy, but got %+v", health)
+	}
+}
@@ -0,0 +1,149 @@
+package tests
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	httpadapter "clean_go_system/internal/adapter/http"
+	"clean_go_system/pkg/compression"
+	"github.com/klauspost/compress/zstd"
+)
+
+var compressJSON = compression.Config{MinBytes: 64, Types: []string{"application/json"}}
+
+func serveCompressed(h http.HandlerFunc, acceptEncoding string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, "/users", nil)
+	r.Header.Set("Accept-Encoding", acceptEncoding)
+	rec := httptest.NewRecorder()
+	compression.Middleware(compressJSON, h).ServeHTTP(rec, r)
+	return rec
+}
+
+func decompress(t *testing.T, encoding string, body []byte) string {
+	t.Helper()
+	var r io.Reader
+	switch encoding {
+	case compression.Gzip:
+		gz, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+		r = gz
+	case compression.Zstd:
+		z, err := zstd.NewReader(bytes.NewReader(body))
+		if err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+		defer z.Close()
+		r = z
+	}
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	return string(out)
+}
+
+func TestNegotiate_PrefersZstdAndHonoursQValues(t *testing.T) {
+	cases := map[string]string{
+		"":                       "",
+		"identity":               "",
+		"gzip, deflate, br":      compression.Gzip,
+		"gzip, zstd":             compression.Zstd,
+		"zstd;q=0.5, gzip":       compression.Gzip,
+		"zstd;q=0, gzip;q=0":     "",
+		"*":                      compression.Zstd,
+		"*;q=0.1, gzip;q=0.2":    compression.Gzip,
+		"GZIP;q=1.0, zstd;q=0.9": compression.Gzip,
+	}
+	for header, want := range cases {
+		if got := compression.Negotiate(header); got != want {
+			t.Errorf("Expected %q for %q, but got %q", want, header, got)
+		}
+	}
+}
+
+func TestCompressionMiddleware_CompressesLargeJSON(t *testing.T) {
+	for _, encoding := range []string{compression.Zstd, compression.Gzip} {
+		t.Run(encoding, func(t *testing.T) {
+			// Arrange
+			items := strings.Repeat("alice@example.com ", 20)
+			payload := map[string]string{"items": items}
+
+			// Act
+			rec := serveCompressed(func(w http.ResponseWriter, r *http.Request) {
+				httpadapter.WriteJSON(w, http.StatusOK, payload)
+			}, encoding)
+
+			// Assert
+			if got := rec.Header().Get("Content-Encoding"); got != encoding {
+				t.Fatalf("Expected Content-Encoding %q, but got %q", encoding, got)
+			}
+			if rec.Header().Get("Content-Length") != "" || rec.Header().Get("Vary") != "Accept-Encoding" {
+				t.Errorf("Expected no Content-Length and Vary: Accept-Encoding, but got %v", rec.Header())
+			}
+			if got := decompress(t, encoding, rec.Body.Bytes()); !strings.Contains(got, items) {
+				t.Errorf("Expected the JSON back, but got %q", got)
+			}
+		})
+	}
+}
+
+func TestCompressionMiddleware_LeavesSmallAndOtherResponsesAlone(t *testing.T) {
+	cases := map[string]http.HandlerFunc{
+		"small": func(w http.ResponseWriter, r *http.Request) {
+			httpadapter.WriteJSON(w, http.StatusOK, map[string]int{"n": 1})
+		},
+		"event stream": func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = io.WriteString(w, "data: "+strings.Repeat("x", 100)+"\n\n")
+			http.NewResponseController(w).Flush()
+		},
+		"no content": func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		},
+	}
+	for name, handler := range cases {
+		t.Run(name, func(t *testing.T) {
+			// Act
+			rec := serveCompressed(handler, "zstd, gzip")
+
+			// Assert
+			if got := rec.Header().Get("Content-Encoding"); got != "" {
+				t.Errorf("Expected no Content-Encoding, but got %q", got)
+			}
+		})
+	}
+}
+
+func TestCompressionMiddleware_AbortedResponseIsNotEnded(t *testing.T) {
+	// Arrange
+	h := compression.Middleware(compressJSON, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, "["+strings.Repeat(`"row",`, 50))
+		panic(http.ErrAbortHandler)
+	}))
+	r := httptest.NewRequest(http.MethodGet, "/admin/users/export", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+
+	// Act
+	func() {
+		defer func() { _ = recover() }()
+		h.ServeHTTP(rec, r)
+	}()
+
+	// Assert
+	gz, err := gzip.NewReader(bytes.NewReader(rec.Body.Bytes()))
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if _, err := io.ReadAll(gz); err == nil {
+		t.Error("Expected the truncated stream to fail to decompress, but it read cleanly")
+	}
+}
@@ -0,0 +1,106 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"clean-code-cookbook/go/pkg/concurrent"
+)
+
+func TestMap_ReturnsResultsInOrder(t *testing.T) {
+	// Arrange
+	items := []int{5, 1, 4, 2, 3}
+
+	// Act
+	got, err := concurrent.Map(context.Background(), concurrent.Options{}, items, func(_ context.Context, n int) (int, error) {
+		time.Sleep(time.Duration(n) * time.Millisecond)
+		return n * 10, nil
+	})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	for i, n := range items {
+		if got[i] != n*10 {
+			t.Fatalf("Expected %v, but got %v", []int{50, 10, 40, 20, 30}, got)
+		}
+	}
+}
+
+func TestMap_RunsNoMoreThanTheLimit(t *testing.T) {
+	// Arrange
+	var running, peak atomic.Int32
+	items := make([]int, 20)
+
+	// Act
+	_, err := concurrent.Map(context.Background(), concurrent.Options{Limit: 3}, items, func(context.Context, int) (struct{}, error) {
+		n := running.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(2 * time.Millisecond)
+		running.Add(-1)
+		return struct{}{}, nil
+	})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if p := peak.Load(); p > 3 {
+		t.Errorf("Expected at most 3 tasks at once, but saw %d", p)
+	}
+}
+
+func TestMap_FirstErrorCancelsTheRest(t *testing.T) {
+	// Arrange
+	boom := errors.New("boom")
+	var started atomic.Int32
+	items := []int{0, 1, 2, 3, 4, 5, 6, 7}
+
+	// Act
+	_, err := concurrent.Map(context.Background(), concurrent.Options{Limit: 2}, items, func(ctx context.Context, n int) (int, error) {
+		started.Add(1)
+		if n == 0 {
+			return 0, boom
+		}
+		<-ctx.Done()
+		return 0, ctx.Err()
+	})
+
+	// Assert
+	if !errors.Is(err, boom) {
+		t.Fatalf("Expected %v, but got: %v", boom, err)
+	}
+	if n := started.Load(); n > 2 {
+		t.Errorf("Expected no task to start after the failure, but %d ran", n)
+	}
+}
+
+func TestGather_TimeoutBoundsEachTask(t *testing.T) {
+	// Arrange
+	opts := concurrent.Options{Timeout: 20 * time.Millisecond}
+	stuck := func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	// Act
+	start := time.Now()
+	err := concurrent.Gather(context.Background(), opts, stuck, func(context.Context) error { return nil })
+
+	// Assert
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected %v, but got: %v", context.DeadlineExceeded, err)
+	}
+	if took := time.Since(start); took > time.Second {
+		t.Errorf("Expected the stuck task to be cut off, but Gather took %v", took)
+	}
+}
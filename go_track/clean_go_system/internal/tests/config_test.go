@@ -0,0 +1,557 @@
+package tests
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"clean_go_system/internal/config"
+	"clean_go_system/pkg/logger"
+)
+
+func writeConfigFile(t *testing.T, path, body string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+}
+
+// setMutualTLS configures the mutual TLS the prod profile insists on.
+func setMutualTLS(t *testing.T) {
+	t.Helper()
+	t.Setenv("TLS_CERT_FILE", "/etc/tls/server.pem")
+	t.Setenv("TLS_KEY_FILE", "/etc/tls/server-key.pem")
+	t.Setenv("TLS_CLIENT_CA_FILE", "/etc/tls/ca.pem")
+}
+
+func TestReloader_AppliesDynamicSettingsAndNotifies(t *testing.T) {
+	// Arrange
+	path := filepath.Join(t.TempDir(), "config.json")
+	writeConfigFile(t, path, `{"dynamic": {"log_level": "info"}}`)
+	t.Setenv("APP_ENV", "test")
+	t.Setenv("CONFIG_FILE", path)
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	reloader := config.NewReloader(cfg, time.Hour, quietLogger())
+
+	var notified config.Dynamic
+	reloader.Subscribe(func(d config.Dynamic) { notified = d })
+
+	// Act
+	writeConfigFile(t, path, `{"http_addr": ":9999", "dynamic": {"log_level": "debug", "chaos": {"http": {"latency_ms": 5}}}}`)
+	err = reloader.Reload()
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if got := reloader.Current(); got.LogLevel != "debug" || got.Chaos["http"].LatencyMS != 5 {
+		t.Errorf("Expected debug level with http latency, but got %+v", got)
+	}
+	if notified.LogLevel != "debug" {
+		t.Errorf("Expected subscriber to see debug, but got '%s'", notified.LogLevel)
+	}
+}
+
+func TestReloader_RejectsInvalidSettings(t *testing.T) {
+	// Arrange
+	path := filepath.Join(t.TempDir(), "config.json")
+	writeConfigFile(t, path, `{"dynamic": {"log_level": "warn"}}`)
+	t.Setenv("APP_ENV", "test")
+	t.Setenv("CONFIG_FILE", path)
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	reloader := config.NewReloader(cfg, time.Hour, quietLogger())
+
+	// Act
+	writeConfigFile(t, path, `{"dynamic": {"log_level": "loud"}}`)
+	err = reloader.Reload()
+
+	// Assert
+	if err == nil {
+		t.Fatal("Expected an error, but got nil")
+	}
+	if got := reloader.Current().LogLevel; got != "warn" {
+		t.Errorf("Expected previous level 'warn' to stay, but got '%s'", got)
+	}
+}
+
+func TestReloader_LayersEnvOverTheFileLikeLoad(t *testing.T) {
+	// Arrange
+	path := filepath.Join(t.TempDir(), "config.json")
+	writeConfigFile(t, path, `{"dynamic": {"log_level": "info", "chaos": {"*": {"error_rate": 0.5}}}}`)
+	t.Setenv("APP_ENV", "dev")
+	t.Setenv("CONFIG_FILE", path)
+	t.Setenv("LOG_LEVEL", "error")
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	reloader := config.NewReloader(cfg, time.Hour, quietLogger())
+
+	// Act
+	writeConfigFile(t, path, `{"dynamic": {"log_level": "debug", "chaos": {"http": {"latency_ms": 5}}}}`)
+	err = reloader.Reload()
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	got := reloader.Current()
+	if got.LogLevel != "error" {
+		t.Errorf("Expected LOG_LEVEL to keep precedence over the file, but got '%s'", got.LogLevel)
+	}
+	if _, ok := got.Chaos["*"]; ok || got.Chaos["http"].LatencyMS != 5 {
+		t.Errorf("Expected the dropped fault gone and http latency on, but got %+v", got)
+	}
+}
+
+func TestReloader_FieldsTheFileLeavesOutFallBackToTheProfile(t *testing.T) {
+	// Arrange
+	path := filepath.Join(t.TempDir(), "config.json")
+	writeConfigFile(t, path, `{"dynamic": {"log_level": "error"}}`)
+	t.Setenv("APP_ENV", "dev")
+	t.Setenv("CONFIG_FILE", path)
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	reloader := config.NewReloader(cfg, time.Hour, quietLogger())
+
+	// Act
+	writeConfigFile(t, path, `{"dynamic": {"chaos": {"http": {"latency_ms": 5}}}}`)
+	err = reloader.Reload()
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if got := reloader.Current().LogLevel; got != "debug" {
+		t.Errorf("Expected the dev profile's 'debug', but got '%s'", got)
+	}
+}
+
+func TestReloader_LogLevelQuietensAndSpeaksUpLive(t *testing.T) {
+	// Arrange
+	path := filepath.Join(t.TempDir(), "config.json")
+	writeConfigFile(t, path, `{"dynamic": {"log_level": "warn"}}`)
+	t.Setenv("APP_ENV", "test")
+	t.Setenv("CONFIG_FILE", path)
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	reloader := config.NewReloader(cfg, time.Hour, quietLogger())
+	var out bytes.Buffer
+	log := logger.NewStructured(&out, reloader.LogLevel())
+
+	// Act
+	log.Info("before")
+	writeConfigFile(t, path, `{"dynamic": {"log_level": "info"}}`)
+	err = reloader.Reload()
+	log.Info("after")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if strings.Contains(out.String(), `"before"`) {
+		t.Errorf("Expected info to be dropped at warn, but got: %s", out.String())
+	}
+	if !strings.Contains(out.String(), `"after"`) {
+		t.Errorf("Expected info to be logged after the reload, but got: %s", out.String())
+	}
+}
+
+func TestLoad_ProfileDefaultsAreOverridablePerKey(t *testing.T) {
+	// Arrange
+	t.Setenv("APP_ENV", "prod")
+	t.Setenv("DATABASE_URL", "postgres://db/users")
+	t.Setenv("LOG_LEVEL", "warn")
+	setMutualTLS(t)
+
+	// Act
+	cfg, err := config.Load()
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if cfg.DatabaseDriver != "postgres" || !cfg.TLS.MutualTLS() {
+		t.Errorf("Expected postgres with mutual TLS, but got %+v", cfg)
+	}
+	if cfg.Dynamic.LogLevel != "warn" {
+		t.Errorf("Expected overridden level 'warn', but got '%s'", cfg.Dynamic.LogLevel)
+	}
+}
+
+func TestLoad_AdminListenerIsLoopbackUnlessSet(t *testing.T) {
+	// Arrange
+	t.Setenv("APP_ENV", "test")
+
+	// Act
+	defaults, err := config.Load()
+	t.Setenv("ADMIN_ADDR", ":9090")
+	overridden, overrideErr := config.Load()
+
+	// Assert
+	if err != nil || overrideErr != nil {
+		t.Fatalf("Expected no errors, but got: %v, %v", err, overrideErr)
+	}
+	if defaults.AdminAddr != "127.0.0.1:6060" || defaults.AdminAddr == defaults.HTTPAddr {
+		t.Errorf("Expected the admin listener on loopback, apart from %s, but got %q", defaults.HTTPAddr, defaults.AdminAddr)
+	}
+	if overridden.AdminAddr != ":9090" {
+		t.Errorf("Expected ADMIN_ADDR to move it to :9090, but got %q", overridden.AdminAddr)
+	}
+}
+
+func TestLoad_ProdRequiresDatabaseURL(t *testing.T) {
+	// Arrange
+	t.Setenv("APP_ENV", "prod")
+	setMutualTLS(t)
+
+	// Act
+	_, err := config.Load()
+
+	// Assert
+	if err == nil {
+		t.Fatal("Expected an error, but got nil")
+	}
+}
+
+func TestLoad_RequiresAppEnv(t *testing.T) {
+	// Arrange
+	t.Setenv("APP_ENV", "")
+
+	// Act
+	_, err := config.Load()
+
+	// Assert
+	if err == nil || !strings.Contains(err.Error(), "APP_ENV") {
+		t.Fatalf("Expected an error naming APP_ENV, but got: %v", err)
+	}
+}
+
+func TestLoad_ProdRequiresMutualTLS(t *testing.T) {
+	// Arrange
+	t.Setenv("APP_ENV", "prod")
+	t.Setenv("DATABASE_URL", "postgres://db/users")
+	t.Setenv("TLS_CERT_FILE", "/etc/tls/server.pem")
+	t.Setenv("TLS_KEY_FILE", "/etc/tls/server-key.pem")
+
+	// Act
+	_, err := config.Load()
+
+	// Assert
+	if err == nil {
+		t.Fatal("Expected an error, but got nil")
+	}
+}
+
+func TestLoad_DevProfileIsVerbose(t *testing.T) {
+	// Arrange
+	t.Setenv("APP_ENV", "dev")
+
+	// Act
+	cfg, err := config.Load()
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if cfg.Dynamic.LogLevel != "debug" || cfg.DatabaseDriver != "sqlite" || cfg.TLS.Enabled() {
+		t.Errorf("Expected dev defaults, but got %+v", cfg)
+	}
+}
+
+func TestLoad_EveryProfileDefaultsToAServedDriver(t *testing.T) {
+	for _, profile := range []string{"dev", "test", "staging", "prod"} {
+		t.Run(profile, func(t *testing.T) {
+			// Arrange
+			t.Setenv("APP_ENV", profile)
+			t.Setenv("DATABASE_URL", "postgres://db.internal/users")
+			setMutualTLS(t)
+
+			// Act
+			cfg, err := config.Load()
+
+			// Assert
+			if err != nil {
+				t.Fatalf("Expected no error, but got: %v", err)
+			}
+			if !slices.Contains(config.DatabaseDrivers, cfg.DatabaseDriver) {
+				t.Errorf("Expected one of %v, but got %q", config.DatabaseDrivers, cfg.DatabaseDriver)
+			}
+		})
+	}
+}
+
+func TestLoad_OpenAPIValidationIsOptIn(t *testing.T) {
+	for _, profile := range []string{"dev", "test", "staging", "prod"} {
+		t.Run(profile, func(t *testing.T) {
+			// Arrange
+			t.Setenv("APP_ENV", profile)
+			t.Setenv("DATABASE_URL", "postgres://db.internal/users")
+			setMutualTLS(t)
+
+			// Act
+			cfg, err := config.Load()
+
+			// Assert
+			if err != nil {
+				t.Fatalf("Expected no error, but got: %v", err)
+			}
+			if cfg.OpenAPIValidate {
+				t.Error("Expected request validation off by default, but it was on")
+			}
+		})
+	}
+}
+
+func TestLoad_RejectsUnknownDatabaseDriver(t *testing.T) {
+	// Arrange
+	t.Setenv("APP_ENV", "test")
+	t.Setenv("DATABASE_DRIVER", "mysql")
+
+	// Act
+	_, err := config.Load()
+
+	// Assert
+	if err == nil {
+		t.Fatal("Expected an error, but got nil")
+	}
+}
+
+func TestLoad_TestProfileNeedsNoDatabase(t *testing.T) {
+	// Arrange
+	t.Setenv("APP_ENV", "test")
+
+	// Act
+	cfg, err := config.Load()
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if cfg.DatabaseDriver != "memory" {
+		t.Errorf("Expected the memory driver, but got %q", cfg.DatabaseDriver)
+	}
+}
+
+func TestLoad_ProdRefusesChaos(t *testing.T) {
+	// Arrange
+	t.Setenv("APP_ENV", "prod")
+	t.Setenv("DATABASE_URL", "postgres://db/users")
+	t.Setenv("CHAOS_ENABLED", "true")
+	setMutualTLS(t)
+
+	// Act
+	_, err := config.Load()
+
+	// Assert
+	if err == nil {
+		t.Fatal("Expected an error, but got nil")
+	}
+}
+
+func TestLoad_ProdRefusesMailbox(t *testing.T) {
+	// Arrange
+	t.Setenv("APP_ENV", "prod")
+	t.Setenv("DATABASE_URL", "postgres://db/users")
+	t.Setenv("EMAIL_PROVIDER", "mailbox")
+	setMutualTLS(t)
+
+	// Act
+	_, err := config.Load()
+
+	// Assert
+	if err == nil {
+		t.Fatal("Expected an error, but got nil")
+	}
+}
+
+func TestLoad_ProdOIDCRequiresTokenSecret(t *testing.T) {
+	// Arrange
+	t.Setenv("APP_ENV", "prod")
+	t.Setenv("DATABASE_URL", "postgres://db/users")
+	t.Setenv("OIDC_ISSUER", "https://idp.example.com")
+	t.Setenv("OIDC_CLIENT_ID", "clean-go")
+	t.Setenv("OIDC_REDIRECT_URL", "https://api.example.com/auth/oidc/callback")
+	setMutualTLS(t)
+
+	// Act
+	_, err := config.Load()
+
+	// Assert
+	if err == nil {
+		t.Fatal("Expected an error, but got nil")
+	}
+}
+
+func TestLoad_WebhooksRequireSigningKeys(t *testing.T) {
+	// Arrange
+	t.Setenv("APP_ENV", "test")
+	t.Setenv("WEBHOOK_URLS", "https://hooks.example.com/users")
+
+	// Act
+	_, err := config.Load()
+
+	// Assert
+	if err == nil {
+		t.Fatal("Expected an error, but got nil")
+	}
+}
+
+func TestLoad_RejectsUnknownSessionMode(t *testing.T) {
+	// Arrange
+	t.Setenv("APP_ENV", "test")
+	t.Setenv("AUTH_SESSION_MODE", "cookie")
+
+	// Act
+	_, err := config.Load()
+
+	// Assert
+	if err == nil {
+		t.Fatal("Expected an error, but got nil")
+	}
+}
+
+func TestLoad_TenantsFromFileAndEnv(t *testing.T) {
+	// Arrange
+	path := filepath.Join(t.TempDir(), "config.json")
+	writeConfigFile(t, path, `{"tenancy":{"base_domain":"example.com","tenants":{"acme":{"rate_limits":{"register_per_minute":100,"burst":10}}}}}`)
+	t.Setenv("APP_ENV", "test")
+	t.Setenv("CONFIG_FILE", path)
+	t.Setenv("TENANTS", "acme,globex")
+
+	// Act
+	cfg, err := config.Load()
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if ids := cfg.Tenancy.IDs(); len(ids) != 2 || ids[0] != "acme" || ids[1] != "globex" {
+		t.Fatalf("Expected tenants acme and globex, but got %v", ids)
+	}
+	if limits := cfg.Tenancy.Tenants["acme"].RateLimits; limits == nil || limits.RegisterPerMinute != 100 {
+		t.Errorf("Expected acme to keep its own rate limits, but got %+v", limits)
+	}
+}
+
+func TestLoad_RejectsInvalidTenantID(t *testing.T) {
+	// Arrange
+	t.Setenv("APP_ENV", "test")
+	t.Setenv("TENANTS", "Acme Corp")
+
+	// Act
+	_, err := config.Load()
+
+	// Assert
+	if err == nil {
+		t.Fatal("Expected an error, but got nil")
+	}
+}
+
+func TestLoad_UploadsToDiskRequireSigningSecret(t *testing.T) {
+	// Arrange
+	t.Setenv("APP_ENV", "test")
+	t.Setenv("UPLOAD_BLOB_DIR", t.TempDir())
+	t.Setenv("UPLOAD_BLOB_SECRET", "short")
+
+	// Act
+	_, err := config.Load()
+
+	// Assert
+	if err == nil {
+		t.Fatal("Expected an error, but got nil")
+	}
+}
+
+func TestLoad_SMTPRequiresHost(t *testing.T) {
+	// Arrange
+	t.Setenv("APP_ENV", "test")
+	t.Setenv("EMAIL_PROVIDER", "smtp")
+	t.Setenv("EMAIL_FROM", "Shop <shop@example.com>")
+
+	// Act
+	_, err := config.Load()
+
+	// Assert
+	if err == nil || !strings.Contains(err.Error(), "EMAIL_SMTP_HOST") {
+		t.Errorf("Expected an EMAIL_SMTP_HOST error, but got '%v'", err)
+	}
+}
+
+func TestLoad_SESRequiresCredentials(t *testing.T) {
+	// Arrange
+	t.Setenv("APP_ENV", "test")
+	t.Setenv("EMAIL_PROVIDER", "ses")
+	t.Setenv("EMAIL_SES_REGION", "eu-west-1")
+
+	// Act
+	_, err := config.Load()
+
+	// Assert
+	if err == nil || !strings.Contains(err.Error(), "EMAIL_SES_ACCESS_KEY") {
+		t.Errorf("Expected an EMAIL_SES_ACCESS_KEY error, but got '%v'", err)
+	}
+}
+
+func TestLoad_EmailDomainRates(t *testing.T) {
+	// Arrange
+	t.Setenv("APP_ENV", "test")
+	t.Setenv("EMAIL_DOMAIN_RATES", "Gmail.com:600, yahoo.com:300")
+
+	// Act
+	cfg, err := config.Load()
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	limits, err := cfg.Email.DomainRateLimits()
+	if err != nil || limits["gmail.com"] != 600 || limits["yahoo.com"] != 300 {
+		t.Errorf("Expected per-minute limits for both domains, but got %v, %v", limits, err)
+	}
+}
+
+func TestLoad_RejectsBadEmailDomainRates(t *testing.T) {
+	// Arrange
+	t.Setenv("APP_ENV", "test")
+	t.Setenv("EMAIL_DOMAIN_RATES", "gmail.com:fast")
+
+	// Act
+	_, err := config.Load()
+
+	// Assert
+	if err == nil || !strings.Contains(err.Error(), "EMAIL_DOMAIN_RATES") {
+		t.Errorf("Expected an EMAIL_DOMAIN_RATES error, but got '%v'", err)
+	}
+}
+
+func TestLoad_CompressionTypes(t *testing.T) {
+	// Arrange
+	t.Setenv("APP_ENV", "test")
+	t.Setenv("COMPRESSION_TYPES", "application/json, application/x-ndjson")
+
+	// Act
+	cfg, err := config.Load()
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if got := cfg.Compression; got.MinBytes != 1024 || len(got.Types) != 2 || got.Types[1] != "application/x-ndjson" {
+		t.Errorf("Expected both types over the default 1024 bytes, but got %+v", got)
+	}
+}
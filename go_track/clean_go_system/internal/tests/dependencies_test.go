@@ -0,0 +1,81 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"clean_go_system/pkg/lifecycle"
+)
+
+var fastPolicy = lifecycle.RetryPolicy{
+	Attempts:       3,
+	InitialBackoff: time.Millisecond,
+	MaxBackoff:     2 * time.Millisecond,
+	AttemptTimeout: 50 * time.Millisecond,
+}
+
+func TestVerifier_RetriesUntilDependencyIsUp(t *testing.T) {
+	// Arrange
+	calls := 0
+	flaky := lifecycle.Dependency{Name: "db", Check: func(context.Context) error {
+		calls++
+		if calls < 3 {
+			return errors.New("connection refused")
+		}
+		return nil
+	}}
+	verifier := lifecycle.NewVerifier(fastPolicy, quietLogger(), flaky)
+
+	// Act
+	err := verifier.Start(context.Background())
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("Expected 3 attempts, but got %d", calls)
+	}
+}
+
+func TestVerifier_FailsFastOnRequiredDependency(t *testing.T) {
+	// Arrange
+	down := errors.New("connection refused")
+	verifier := lifecycle.NewVerifier(fastPolicy, quietLogger(),
+		lifecycle.Dependency{Name: "db", Check: func(context.Context) error { return down }},
+	)
+
+	// Act
+	err := verifier.Start(context.Background())
+
+	// Assert
+	if !errors.Is(err, down) {
+		t.Fatalf("Expected error '%v', but got '%v'", down, err)
+	}
+	if !strings.Contains(err.Error(), "dependency db") {
+		t.Errorf("Expected the dependency name in '%v'", err)
+	}
+}
+
+func TestVerifier_DegradesOnOptionalDependency(t *testing.T) {
+	// Arrange
+	verifier := lifecycle.NewVerifier(fastPolicy, quietLogger(),
+		lifecycle.Dependency{Name: "db", Check: func(context.Context) error { return nil }},
+		lifecycle.Dependency{
+			Name:     "catalog",
+			Check:    func(context.Context) error { return errors.New("no route") },
+			Optional: true,
+		},
+	)
+
+	// Act
+	err := verifier.Start(context.Background())
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected degraded start without error, but got: %v", err)
+	}
+}
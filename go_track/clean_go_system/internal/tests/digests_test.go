@@ -0,0 +1,177 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	httpadapter "clean_go_system/internal/adapter/http"
+	"clean_go_system/internal/adapter/memory"
+	"clean_go_system/internal/adapter/notify"
+	"clean_go_system/internal/core"
+	"clean_go_system/internal/domain"
+	"clean_go_system/internal/httptestutil"
+	"clean_go_system/pkg/clock"
+	"github.com/google/uuid"
+)
+
+type digestFixture struct {
+	digests *core.Digests
+	prefs   *core.NotificationPreferences
+	queue   *recordingQueue
+	clock   *clock.Fake
+}
+
+// newDigestFixture holds user.deactivated for digests and sends emails to
+// a recording queue.
+func newDigestFixture(t *testing.T) *digestFixture {
+	t.Helper()
+	templates, err := notify.BuiltinTemplates("en")
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	f := &digestFixture{queue: &recordingQueue{}, clock: clock.NewFake(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC))}
+	f.prefs = core.NewNotificationPreferences(memory.NewNotificationPreferencesRepository())
+	f.prefs.Clock = f.clock
+	f.digests = core.NewDigests(notify.NewEmail(f.queue, 10*time.Millisecond), f.prefs, memory.NewDigestRepository(), notify.Chain{emailTemplates(t), templates}, []string{"user.deactivated"})
+	f.digests.Clock = f.clock
+	return f
+}
+
+func deactivatedNotice(userID uuid.UUID, subject string) domain.Notification {
+	return domain.Notification{
+		Channel: domain.ChannelEmail,
+		To:      "alice@example.com",
+		UserID:  userID,
+		Event:   "user.deactivated",
+		Message: domain.Message{Subject: subject, Body: "Your account was deactivated."},
+	}
+}
+
+func TestDigests_HoldLowPriorityEmailsUntilFlushed(t *testing.T) {
+	// Arrange
+	f := newDigestFixture(t)
+	ctx := context.Background()
+	alice, bob := uuid.New(), uuid.New()
+	if _, err := f.prefs.SetDigest(ctx, alice, domain.DigestDaily); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	for _, n := range []domain.Notification{
+		deactivatedNotice(alice, "First"),
+		deactivatedNotice(alice, "Second"),
+		deactivatedNotice(bob, "Bob's"),
+		{Channel: domain.ChannelEmail, To: "alice@example.com", UserID: alice, Event: "user.registered", Message: domain.Message{Subject: "Welcome"}},
+	} {
+		if err := f.digests.Notify(ctx, n); err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+	}
+	held := len(f.queue.jobs)
+
+	// Act
+	f.clock.Advance(time.Minute)
+	weekly, weeklyErr := f.digests.Flush(ctx, domain.DigestWeekly)
+	daily, dailyErr := f.digests.Flush(ctx, domain.DigestDaily)
+	again, againErr := f.digests.Flush(ctx, domain.DigestDaily)
+
+	// Assert
+	if weeklyErr != nil || dailyErr != nil || againErr != nil {
+		t.Fatalf("Expected no errors, but got: %v, %v, %v", weeklyErr, dailyErr, againErr)
+	}
+	if held != 2 {
+		t.Errorf("Expected only bob's notice and the welcome to go out at once, but %d did", held)
+	}
+	if weekly != 0 || daily != 1 || again != 0 {
+		t.Errorf("Expected one daily digest, once, but got %d weekly, %d daily, %d again", weekly, daily, again)
+	}
+	if len(f.queue.jobs) != 3 {
+		t.Fatalf("Expected 3 emails, but got %d", len(f.queue.jobs))
+	}
+	digest := f.queue.jobs[2]
+	if digest.UserID != alice || digest.Template != "notification.digest" || digest.Subject != "Your daily account summary" {
+		t.Errorf("Expected alice's daily digest, but got %+v", digest)
+	}
+	if !strings.Contains(digest.HTML, "First") || !strings.Contains(digest.HTML, "Second") || strings.Index(digest.HTML, "First") > strings.Index(digest.HTML, "Second") {
+		t.Errorf("Expected both notices, oldest first, but got %s", digest.HTML)
+	}
+}
+
+func TestDigests_FlushLeavesItemsHeldWhileItRuns(t *testing.T) {
+	// Arrange
+	f := newDigestFixture(t)
+	ctx := context.Background()
+	alice := uuid.New()
+	if _, err := f.prefs.SetDigest(ctx, alice, domain.DigestWeekly); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if err := f.digests.Notify(ctx, deactivatedNotice(alice, "Later")); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+
+	// Act: the clock has not moved since the item was held.
+	sent, err := f.digests.Flush(ctx, domain.DigestWeekly)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if sent != 0 || len(f.queue.jobs) != 0 {
+		t.Errorf("Expected the item to wait for the next flush, but %d digests went out", sent)
+	}
+}
+
+func TestNotificationPreferences_RejectUnknownFrequency(t *testing.T) {
+	// Arrange
+	f := newDigestFixture(t)
+	userID := uuid.New()
+
+	// Act
+	defaults, getErr := f.prefs.Get(context.Background(), userID)
+	_, setErr := f.prefs.SetDigest(context.Background(), userID, "hourly")
+
+	// Assert
+	if getErr != nil {
+		t.Fatalf("Expected no error, but got: %v", getErr)
+	}
+	if defaults.Digest != domain.DigestImmediate {
+		t.Errorf("Expected %q by default, but got %q", domain.DigestImmediate, defaults.Digest)
+	}
+	if !errors.Is(setErr, domain.ErrInvalidDigestFrequency) {
+		t.Errorf("Expected error '%v', but got '%v'", domain.ErrInvalidDigestFrequency, setErr)
+	}
+}
+
+func TestNotificationPreferencesHandler(t *testing.T) {
+	// Arrange
+	f := newDigestFixture(t)
+	sessions := httpadapter.NewSessionTokens("clean_go_system", []byte("0123456789abcdef0123456789abcdef"), time.Hour)
+	bearer := httpadapter.NewBearerAuth(sessions, nil, nil, quietLogger())
+	handler := httpadapter.NewNotificationPreferencesHandler(f.prefs, quietLogger())
+	mux := http.NewServeMux()
+	mux.Handle("GET /me/notifications", bearer.Middleware(http.HandlerFunc(handler.Get)))
+	mux.Handle("PUT /me/notifications", bearer.Middleware(http.HandlerFunc(handler.Put)))
+	token, err := sessions.Issue(&domain.User{ID: uuid.New(), Email: "alice@example.com", Username: "alice"})
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	request := func(method string, body any) *http.Request {
+		return httptestutil.Authenticated(httptestutil.NewRequest(t, method, "/me/notifications", body), token)
+	}
+
+	// Act
+	before := httptestutil.Serve(mux, request(http.MethodGet, nil))
+	set := httptestutil.Serve(mux, request(http.MethodPut, map[string]string{"digest": "weekly"}))
+	after := httptestutil.Serve(mux, request(http.MethodGet, nil))
+	invalid := httptestutil.Serve(mux, request(http.MethodPut, map[string]string{"digest": "hourly"}))
+	anonymous := httptestutil.Serve(mux, httptestutil.NewRequest(t, http.MethodGet, "/me/notifications", nil))
+
+	// Assert
+	httptestutil.AssertJSON(t, before, map[string]any{"digest": "immediate"})
+	httptestutil.AssertStatus(t, set, http.StatusOK)
+	httptestutil.AssertJSON(t, after, map[string]any{"digest": "weekly", "updated_at": "2024-01-01T09:00:00Z"})
+	httptestutil.AssertStatus(t, invalid, http.StatusBadRequest)
+	httptestutil.AssertStatus(t, anonymous, http.StatusUnauthorized)
+}
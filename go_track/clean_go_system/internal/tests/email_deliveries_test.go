@@ -0,0 +1,141 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"clean-code-cookbook/go/pkg/retry"
+	httpadapter "clean_go_system/internal/adapter/http"
+	"clean_go_system/internal/adapter/memory"
+	"clean_go_system/internal/core"
+	"clean_go_system/internal/domain"
+	"clean_go_system/internal/httptestutil"
+	"github.com/google/uuid"
+)
+
+func TestTrackedQueue_RecordsQueuedAndTurnedAwayJobs(t *testing.T) {
+	// Arrange
+	deliveries := memory.NewDeliveryRepository()
+	pool := core.NewWorkerPool(1, 1)
+	queue := core.NewTrackedQueue(pool, deliveries)
+	userID := uuid.New()
+	job := core.EmailJob{UserID: userID, Template: "password.reset", Email: "alice@example.com", Subject: "Reset your password"}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	// Act
+	queued := queue.Enqueue(context.Background(), job)
+	full := queue.Enqueue(ctx, job)
+	untracked := core.NewTrackedQueue(&recordingQueue{}, deliveries).Enqueue(context.Background(), core.EmailJob{Email: "ops@example.com"})
+
+	// Assert
+	if queued != nil || untracked != nil {
+		t.Fatalf("Expected no error, but got: %v, %v", queued, untracked)
+	}
+	if !errors.Is(full, core.ErrQueueFull) {
+		t.Errorf("Expected error '%v', but got '%v'", core.ErrQueueFull, full)
+	}
+	if sent := <-pool.JobQueue; sent.ID == uuid.Nil {
+		t.Error("Expected the queued job to carry its delivery ID")
+	}
+	list, _ := deliveries.ListByUser(context.Background(), userID, 10)
+	if len(list) != 2 {
+		t.Fatalf("Expected 2 deliveries, but got %+v", list)
+	}
+	statuses := map[domain.DeliveryStatus]int{}
+	for _, d := range list {
+		statuses[d.Status]++
+		if d.Template != "password.reset" || d.Subject != "Reset your password" {
+			t.Errorf("Expected the job's template and subject, but got %+v", d)
+		}
+	}
+	if statuses[domain.DeliveryQueued] != 1 || statuses[domain.DeliveryFailed] != 1 {
+		t.Errorf("Expected one queued and one failed delivery, but got %v", statuses)
+	}
+}
+
+func TestEmailDelivery_RecordsOutcomes(t *testing.T) {
+	// Arrange
+	deliveries := memory.NewDeliveryRepository()
+	sender := &recordingSender{errs: []error{fmt.Errorf("%w: 429", domain.ErrEmailThrottled)}}
+	delivery := core.NewEmailDelivery(sender)
+	delivery.Retry = retry.Policy{Attempts: 3}
+	delivery.Deliveries = deliveries
+	queued := &recordingQueue{}
+	queue := core.NewTrackedQueue(queued, deliveries)
+	userID := uuid.New()
+	for range 2 {
+		if err := queue.Enqueue(context.Background(), core.EmailJob{UserID: userID, Template: "email.verification", Email: "alice@example.com"}); err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+	}
+	sent, bounced := queued.jobs[0], queued.jobs[1]
+
+	// Act
+	sentErr := delivery.Process(context.Background(), sent)
+	sender.errs = []error{fmt.Errorf("%w: 550 no such user", domain.ErrEmailRejected)}
+	bouncedErr := delivery.Process(context.Background(), bounced)
+
+	// Assert
+	if sentErr != nil {
+		t.Fatalf("Expected no error, but got: %v", sentErr)
+	}
+	if !errors.Is(bouncedErr, domain.ErrEmailRejected) {
+		t.Errorf("Expected error '%v', but got '%v'", domain.ErrEmailRejected, bouncedErr)
+	}
+	list, _ := deliveries.ListByUser(context.Background(), userID, 10)
+	byID := map[uuid.UUID]domain.Delivery{}
+	for _, d := range list {
+		byID[d.ID] = d
+	}
+	if d := byID[sent.ID]; d.Status != domain.DeliverySent || d.Attempts != 2 || d.ProviderID != "msg-2" {
+		t.Errorf("Expected a sent delivery after 2 attempts with the provider's ID, but got %+v", d)
+	}
+	if d := byID[bounced.ID]; d.Status != domain.DeliveryBounced || d.Attempts != 1 || d.Error == "" {
+		t.Errorf("Expected a bounced delivery with its error, but got %+v", d)
+	}
+}
+
+func TestAdminHandler_EmailDeliveries(t *testing.T) {
+	// Arrange
+	admin, _, alice := newAdmin(t)
+	deliveries := memory.NewDeliveryRepository()
+	admin.Deliveries = deliveries
+	created := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i, template := range []string{"user.registered", "email.verification"} {
+		err := deliveries.Record(context.Background(), domain.Delivery{
+			ID: uuid.New(), UserID: alice.ID, Template: template, Status: domain.DeliverySent, Attempts: 1,
+			CreatedAt: created.Add(time.Duration(i) * time.Minute),
+		})
+		if err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+	}
+	h := httpadapter.NewAdminHandler(admin, quietLogger())
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /admin/emails", h.EmailDeliveries)
+	serve := func(ctx context.Context, path string) *httptest.ResponseRecorder {
+		return httptestutil.Serve(mux, httptestutil.NewRequest(t, http.MethodGet, path, nil).WithContext(ctx))
+	}
+
+	// Act
+	byEmail := serve(asAPIKey(domain.ScopeAdmin), "/admin/emails?user="+alice.Email)
+	byID := serve(asAPIKey(domain.ScopeAdmin), "/admin/emails?user="+alice.ID.String())
+	unknown := serve(asAPIKey(domain.ScopeAdmin), "/admin/emails?user=bob@example.com")
+	forbidden := serve(asAPIKey(domain.ScopeUsersWrite), "/admin/emails?user="+alice.Email)
+
+	// Assert
+	httptestutil.AssertStatus(t, byEmail, http.StatusOK)
+	body := httptestutil.DecodeJSON[map[string][]map[string]any](t, byEmail)
+	if items := body["items"]; len(items) != 2 || items[0]["template"] != "email.verification" || items[0]["status"] != "sent" {
+		t.Errorf("Expected alice's 2 emails, newest first, but got %v", items)
+	}
+	httptestutil.AssertStatus(t, byID, http.StatusOK)
+	httptestutil.AssertStatus(t, unknown, http.StatusNotFound)
+	httptestutil.AssertStatus(t, forbidden, http.StatusForbidden)
+}
@@ -0,0 +1,140 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"clean_go_system/internal/adapter/email"
+	"clean_go_system/internal/adapter/notify"
+	"clean_go_system/internal/domain"
+	"clean_go_system/internal/httptestutil"
+	"clean_go_system/pkg/clock"
+)
+
+func newPreview(t *testing.T) http.Handler {
+	t.Helper()
+	templates, err := notify.BuiltinTemplates("en")
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	preview := notify.NewPreview(notify.Chain{emailTemplates(t), templates})
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /dev/emails", preview.Index)
+	mux.HandleFunc("GET /dev/emails/{template}", preview.Show)
+	return mux
+}
+
+func TestPreview_RendersEveryBuiltinTemplate(t *testing.T) {
+	// Arrange
+	preview := newPreview(t)
+
+	for name := range notify.Samples() {
+		t.Run(name, func(t *testing.T) {
+			// Act
+			page := httptestutil.Serve(preview, httptestutil.NewRequest(t, http.MethodGet, "/dev/emails/"+name, nil))
+			text := httptestutil.Serve(preview, httptestutil.NewRequest(t, http.MethodGet, "/dev/emails/"+name+"?format=text&locale=de", nil))
+
+			// Assert: user.deleted has no template yet.
+			if name == "user.deleted" {
+				httptestutil.AssertStatus(t, page, http.StatusNotFound)
+				return
+			}
+			httptestutil.AssertStatus(t, page, http.StatusOK)
+			httptestutil.AssertStatus(t, text, http.StatusOK)
+			httptestutil.AssertHeader(t, text, "Content-Type", "text/plain; charset=utf-8")
+			if !strings.HasPrefix(text.Body.String(), "Subject: ") {
+				t.Errorf("Expected the text variant to start with the subject, but got %q", text.Body.String())
+			}
+		})
+	}
+}
+
+func TestPreview_IndexAndUnknownTemplate(t *testing.T) {
+	// Arrange
+	preview := newPreview(t)
+
+	// Act
+	index := httptestutil.Serve(preview, httptestutil.NewRequest(t, http.MethodGet, "/dev/emails", nil))
+	unknown := httptestutil.Serve(preview, httptestutil.NewRequest(t, http.MethodGet, "/dev/emails/user.unknown", nil))
+
+	// Assert
+	httptestutil.AssertStatus(t, index, http.StatusOK)
+	if !strings.Contains(index.Body.String(), `href="emails/password.reset?format=text"`) {
+		t.Errorf("Expected the index to link the previews, but got %s", index.Body.String())
+	}
+	httptestutil.AssertStatus(t, unknown, http.StatusNotFound)
+}
+
+func TestMailbox_KeepsLatestMessages(t *testing.T) {
+	// Arrange
+	mailbox, err := email.NewMailbox("Clean Go <no-reply@example.com>", 2)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	mailbox.Clock = clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	ctx := context.Background()
+	var ids []string
+	for _, to := range []string{"alice@example.com", "bob@example.com", "alice@example.com"} {
+		id, err := mailbox.Send(ctx, domain.Email{To: to, Subject: "Hi " + to, Text: "Hello", HTML: "<p>Hello</p>"})
+		if err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+		ids = append(ids, id)
+	}
+	_, badErr := mailbox.Send(ctx, domain.Email{To: "not an address"})
+
+	// Act
+	messages := mailbox.Messages()
+
+	// Assert
+	if !errors.Is(badErr, domain.ErrEmailRejected) {
+		t.Errorf("Expected error '%v', but got '%v'", domain.ErrEmailRejected, badErr)
+	}
+	if len(messages) != 2 || messages[0].ID != ids[2] || messages[1].ID != ids[1] {
+		t.Fatalf("Expected the last two messages, newest first, but got %+v", messages)
+	}
+	if messages[0].From != `"Clean Go" <no-reply@example.com>` || messages[0].To != "<alice@example.com>" {
+		t.Errorf("Expected the parsed addresses, but got %q and %q", messages[0].From, messages[0].To)
+	}
+}
+
+func TestMailbox_Handlers(t *testing.T) {
+	// Arrange
+	mailbox, err := email.NewMailbox("no-reply@example.com", 10)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /dev/mailbox", mailbox.List)
+	mux.HandleFunc("GET /dev/mailbox/{id}", mailbox.Show)
+	mux.HandleFunc("DELETE /dev/mailbox", mailbox.Clear)
+	ctx := context.Background()
+	aliceID, _ := mailbox.Send(ctx, domain.Email{To: "alice@example.com", Subject: "Welcome", Text: "Hello alice", HTML: "<p>Hello alice</p>"})
+	_, _ = mailbox.Send(ctx, domain.Email{To: "bob@example.com", Subject: "Welcome", Text: "Hello bob"})
+
+	// Act
+	forAlice := httptestutil.Serve(mux, httptestutil.NewRequest(t, http.MethodGet, "/dev/mailbox?to=Alice@Example.com", nil))
+	page := httptestutil.Serve(mux, httptestutil.NewRequest(t, http.MethodGet, "/dev/mailbox/"+aliceID, nil))
+	text := httptestutil.Serve(mux, httptestutil.NewRequest(t, http.MethodGet, "/dev/mailbox/"+aliceID+"?format=text", nil))
+	cleared := httptestutil.Serve(mux, httptestutil.NewRequest(t, http.MethodDelete, "/dev/mailbox", nil))
+	gone := httptestutil.Serve(mux, httptestutil.NewRequest(t, http.MethodGet, "/dev/mailbox/"+aliceID, nil))
+
+	// Assert
+	httptestutil.AssertStatus(t, forAlice, http.StatusOK)
+	items := httptestutil.DecodeJSON[map[string][]email.MailboxMessage](t, forAlice)["items"]
+	if len(items) != 1 || items[0].ID != aliceID {
+		t.Errorf("Expected alice's message only, but got %+v", items)
+	}
+	if page.Body.String() != "<p>Hello alice</p>" {
+		t.Errorf("Expected the HTML, but got %q", page.Body.String())
+	}
+	if !strings.HasSuffix(text.Body.String(), "\n\nHello alice") {
+		t.Errorf("Expected the text, but got %q", text.Body.String())
+	}
+	httptestutil.AssertStatus(t, cleared, http.StatusNoContent)
+	httptestutil.AssertStatus(t, gone, http.StatusNotFound)
+}
@@ -0,0 +1,96 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"clean_go_system/internal/adapter/notify"
+	"clean_go_system/internal/core"
+	"clean_go_system/internal/domain"
+)
+
+// emailTemplates returns the built-in HTML emails, in English by default.
+func emailTemplates(t *testing.T) *notify.EmailTemplates {
+	t.Helper()
+	templates, err := notify.BuiltinEmailTemplates("en")
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	return templates
+}
+
+func TestEmailTemplates_RenderInlinedHTMLAndPlainText(t *testing.T) {
+	// Arrange
+	templates := emailTemplates(t)
+
+	// Act
+	msg, err := templates.Render("password.reset", "en", core.PasswordResetEmail{Username: "O'Brien <b>", Token: "abc.def", ValidMinutes: 30})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if msg.Subject != "Reset your password" {
+		t.Errorf("Expected the subject, but got %q", msg.Subject)
+	}
+	for _, want := range []string{`<body style="margin: 0; padding: 0;`, `<p class="code" style="margin: 0 0 16px; padding: 12px 16px;`, "Hi O&#39;Brien &lt;b&gt;,"} {
+		if !strings.Contains(msg.HTML, want) {
+			t.Errorf("Expected the HTML to contain %q, but got:\n%s", want, msg.HTML)
+		}
+	}
+	if strings.Contains(msg.HTML, "<style") {
+		t.Error("Expected no <style> element, the CSS is inlined")
+	}
+	want := "Clean Go\n\nHi O'Brien <b>,\n\nUse this token to reset your password within 30 minutes:\n\nabc.def\n\n" +
+		"If you did not ask for a new password, you can ignore this email.\n\nYou are receiving this email because of your Clean Go account."
+	if msg.Body != want {
+		t.Errorf("Expected the plain text\n%s\nbut got\n%s", want, msg.Body)
+	}
+}
+
+func TestEmailTemplates_LocalesOverrideLayoutBlocks(t *testing.T) {
+	// Arrange
+	templates := emailTemplates(t)
+	event := domain.UserRegistered{Email: "alice@example.com", Username: "alice"}
+
+	// Act
+	german, deErr := templates.Render("user.registered", "de", event)
+	english, enErr := templates.Render("user.registered", "en", event)
+
+	// Assert
+	if deErr != nil || enErr != nil {
+		t.Fatalf("Expected no errors, but got: %v, %v", deErr, enErr)
+	}
+	if !strings.HasSuffix(german.Body, "Du erhältst diese E-Mail wegen deines Clean-Go-Kontos.") {
+		t.Errorf("Expected the German footer, but got:\n%s", german.Body)
+	}
+	if !strings.HasSuffix(english.Body, "You are receiving this email because of your Clean Go account.") {
+		t.Errorf("Expected the layout's footer, but got:\n%s", english.Body)
+	}
+}
+
+func TestLoadEmailTemplates_RejectsBrokenTemplates(t *testing.T) {
+	layout := &fstest.MapFile{Data: []byte(`{{define "layout"}}<body>{{template "content" .}}</body>{{end}}`)}
+	email := &fstest.MapFile{Data: []byte(`{{define "subject"}}Hi{{end}}{{define "content"}}<p>Hi</p>{{end}}`)}
+	css := &fstest.MapFile{Data: []byte(`p { margin: 0 }`)}
+	cases := map[string]fstest.MapFS{
+		"descendant selector": {"layout.html": layout, "style.css": {Data: []byte(`td p { margin: 0 }`)}, "user.registered/en.html": email},
+		"media query":         {"layout.html": layout, "style.css": {Data: []byte(`@media (max-width: 600px) { p { margin: 0 } }`)}, "user.registered/en.html": email},
+		"no layout":           {"layout.html": {Data: []byte(`<body></body>`)}, "style.css": css, "user.registered/en.html": email},
+		"no content":          {"layout.html": layout, "style.css": css, "user.registered/en.html": {Data: []byte(`{{define "subject"}}Hi{{end}}`)}},
+		"not a locale":        {"layout.html": layout, "style.css": css, "user.registered/English.html": email},
+		"no style sheet":      {"layout.html": layout, "user.registered/en.html": email},
+	}
+	for name, fsys := range cases {
+		t.Run(name, func(t *testing.T) {
+			// Act
+			_, err := notify.LoadEmailTemplates(fsys, "en")
+
+			// Assert
+			if err == nil {
+				t.Error("Expected an error, but got none")
+			}
+		})
+	}
+}
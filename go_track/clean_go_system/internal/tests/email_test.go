@@ -0,0 +1,480 @@
+package tests
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"clean-code-cookbook/go/pkg/retry"
+	"clean_go_system/internal/adapter/email"
+	"clean_go_system/internal/core"
+	"clean_go_system/internal/domain"
+	"clean_go_system/pkg/clock"
+)
+
+// fakeSMTP is a plaintext SMTP server on localhost that accepts every
+// message and remembers what it was told.
+type fakeSMTP struct {
+	ln net.Listener
+
+	mu       sync.Mutex
+	conns    int
+	auths    []string
+	messages []string
+}
+
+func newFakeSMTP(t *testing.T) *fakeSMTP {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	s := &fakeSMTP{ln: ln}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.conns++
+			s.mu.Unlock()
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *fakeSMTP) port() int { return s.ln.Addr().(*net.TCPAddr).Port }
+
+func (s *fakeSMTP) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(lines ...string) { conn.Write([]byte(strings.Join(lines, "\r\n") + "\r\n")) }
+	reply("220 fake ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(strings.Fields(line + " x")[0])
+		switch cmd {
+		case "EHLO":
+			reply("250-fake", "250 AUTH PLAIN")
+		case "AUTH":
+			s.mu.Lock()
+			s.auths = append(s.auths, strings.TrimSpace(line))
+			s.mu.Unlock()
+			reply("235 ok")
+		case "DATA":
+			reply("354 go ahead")
+			var msg strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				msg.WriteString(l)
+			}
+			s.mu.Lock()
+			s.messages = append(s.messages, msg.String())
+			s.mu.Unlock()
+			reply("250 queued")
+		case "RCPT":
+			if strings.Contains(line, "nobody@") {
+				reply("550 5.1.1 no such user")
+				continue
+			}
+			reply("250 ok")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
+
+func TestSMTPSender_SendsAndReusesConnection(t *testing.T) {
+	// Arrange
+	server := newFakeSMTP(t)
+	sender, err := email.NewSMTPSender(email.SMTPConfig{
+		Host: "127.0.0.1", Port: server.port(), Username: "app", Password: "secret", TLS: email.TLSNone, MaxIdle: 1,
+	}, "Clean Go <no-reply@example.com>")
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	defer sender.Close()
+
+	// Act
+	id, first := sender.Send(context.Background(), domain.Email{To: "alice@example.com", Subject: "Grüße", Text: "Hello\nAlice"})
+	_, second := sender.Send(context.Background(), domain.Email{To: "bob@example.com", Subject: "Hi", Text: "Hello Bob"})
+
+	// Assert
+	if first != nil || second != nil {
+		t.Fatalf("Expected no error, but got: %v, %v", first, second)
+	}
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if server.conns != 1 || len(server.auths) != 1 {
+		t.Errorf("Expected both messages over one authenticated connection, but got %d connections, %d logins", server.conns, len(server.auths))
+	}
+	if len(server.messages) != 2 {
+		t.Fatalf("Expected 2 messages, but got %d", len(server.messages))
+	}
+	msg := server.messages[0]
+	for _, want := range []string{"Message-ID: <" + id + ">\r\n", "To: <alice@example.com>\r\n", "Subject: =?utf-8?q?Gr=C3=BC=C3=9Fe?=\r\n", "Hello\r\nAlice"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("Expected the message to contain %q, but got:\n%s", want, msg)
+		}
+	}
+}
+
+func TestSMTPSender_SendsHTMLAsAlternative(t *testing.T) {
+	// Arrange
+	server := newFakeSMTP(t)
+	sender, _ := email.NewSMTPSender(email.SMTPConfig{Host: "127.0.0.1", Port: server.port(), TLS: email.TLSNone}, "no-reply@example.com")
+
+	// Act
+	_, err := sender.Send(context.Background(), domain.Email{To: "alice@example.com", Subject: "Hi", Text: "Hello Alice", HTML: "<p>Hello Alice</p>"})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	msg := server.messages[0]
+	text, html := strings.Index(msg, "Content-Type: text/plain"), strings.Index(msg, "Content-Type: text/html")
+	if !strings.Contains(msg, "Content-Type: multipart/alternative; boundary=") || text < 0 || html < text {
+		t.Errorf("Expected a multipart/alternative message with the text first, but got:\n%s", msg)
+	}
+	if !strings.Contains(msg, "<p>Hello Alice</p>") {
+		t.Errorf("Expected the HTML part, but got:\n%s", msg)
+	}
+}
+
+func TestSMTPSender_RejectsHeaderInjection(t *testing.T) {
+	// Arrange
+	server := newFakeSMTP(t)
+	sender, _ := email.NewSMTPSender(email.SMTPConfig{Host: "127.0.0.1", Port: server.port(), TLS: email.TLSNone}, "no-reply@example.com")
+
+	// Act
+	_, err := sender.Send(context.Background(), domain.Email{To: "alice@example.com\r\nBcc: eve@example.com", Text: "hi"})
+
+	// Assert
+	if err == nil {
+		t.Fatal("Expected an invalid address error")
+	}
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if server.conns != 0 {
+		t.Errorf("Expected nothing sent, but the server saw %d connections", server.conns)
+	}
+}
+
+func TestSMTPSender_RefusesServerWithoutStartTLS(t *testing.T) {
+	// Arrange
+	server := newFakeSMTP(t)
+	sender, _ := email.NewSMTPSender(email.SMTPConfig{Host: "127.0.0.1", Port: server.port()}, "no-reply@example.com")
+
+	// Act
+	_, err := sender.Send(context.Background(), domain.Email{To: "alice@example.com", Text: "hi"})
+
+	// Assert
+	if err == nil || !strings.Contains(err.Error(), "STARTTLS") {
+		t.Errorf("Expected a STARTTLS error, but got '%v'", err)
+	}
+}
+
+func TestSMTPSender_TimesOutOnSilentServer(t *testing.T) {
+	// Arrange
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	defer ln.Close()
+	go func() {
+		// Accept and never greet.
+		conn, err := ln.Accept()
+		if err == nil {
+			defer conn.Close()
+			time.Sleep(time.Second)
+		}
+	}()
+	sender, _ := email.NewSMTPSender(email.SMTPConfig{
+		Host: "127.0.0.1", Port: ln.Addr().(*net.TCPAddr).Port, TLS: email.TLSNone, Timeout: 50 * time.Millisecond,
+	}, "no-reply@example.com")
+
+	// Act
+	start := time.Now()
+	_, err = sender.Send(context.Background(), domain.Email{To: "alice@example.com", Text: "hi"})
+
+	// Assert
+	if err == nil {
+		t.Fatal("Expected a timeout")
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Expected the send to give up after its timeout, but it took %v", elapsed)
+	}
+}
+
+func TestSMTPSender_ClassifiesPermanentFailure(t *testing.T) {
+	// Arrange
+	server := newFakeSMTP(t)
+	sender, _ := email.NewSMTPSender(email.SMTPConfig{Host: "127.0.0.1", Port: server.port(), TLS: email.TLSNone}, "no-reply@example.com")
+
+	// Act
+	_, err := sender.Send(context.Background(), domain.Email{To: "nobody@example.com", Text: "hi"})
+
+	// Assert
+	if !errors.Is(err, domain.ErrEmailRejected) {
+		t.Errorf("Expected error '%v', but got '%v'", domain.ErrEmailRejected, err)
+	}
+}
+
+// apiServer answers every request with status and body, and keeps the
+// last request and its body.
+func apiServer(t *testing.T, status int, header http.Header, body string) (*httptest.Server, *http.Request, *[]byte) {
+	t.Helper()
+	var (
+		last     http.Request
+		received []byte
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		last = *r
+		received, _ = io.ReadAll(r.Body)
+		for k, v := range header {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(status)
+		io.WriteString(w, body)
+	}))
+	t.Cleanup(server.Close)
+	return server, &last, &received
+}
+
+func newSESSender(t *testing.T, endpoint string) *email.SESSender {
+	t.Helper()
+	sender, err := email.NewSESSender(email.SESConfig{
+		Region: "eu-west-1", AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "secret", Endpoint: endpoint,
+	}, "no-reply@example.com")
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	sender.Clock = clock.NewFake(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	return sender
+}
+
+func TestSESSender_SignsAndSends(t *testing.T) {
+	// Arrange
+	server, req, body := apiServer(t, http.StatusOK, nil, `{"MessageId":"0102018f-ses"}`)
+	sender := newSESSender(t, server.URL)
+
+	// Act
+	id, err := sender.Send(context.Background(), domain.Email{To: "alice@example.com", Subject: "Welcome", Text: "Hello"})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if id != "0102018f-ses" {
+		t.Errorf("Expected SES's message ID, but got %q", id)
+	}
+	if req.URL.Path != "/v2/email/outbound-emails" {
+		t.Errorf("Expected the SendEmail path, but got %s", req.URL.Path)
+	}
+	auth := req.Header.Get("Authorization")
+	if want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20240501/eu-west-1/ses/aws4_request, SignedHeaders=content-type;host;x-amz-content-sha256;x-amz-date, Signature="; !strings.HasPrefix(auth, want) {
+		t.Errorf("Expected Authorization to start with %q, but got %q", want, auth)
+	}
+	var sent struct {
+		FromEmailAddress string
+		Destination      struct{ ToAddresses []string }
+		Content          struct {
+			Simple struct{ Subject struct{ Data string } }
+		}
+	}
+	if err := json.Unmarshal(*body, &sent); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if sent.FromEmailAddress != "<no-reply@example.com>" || len(sent.Destination.ToAddresses) != 1 || sent.Content.Simple.Subject.Data != "Welcome" {
+		t.Errorf("Expected the message in the request, but got %+v", sent)
+	}
+}
+
+func TestSESSender_ClassifiesErrors(t *testing.T) {
+	cases := map[string]struct {
+		status int
+		code   string
+		want   error
+	}{
+		"throttled":      {http.StatusTooManyRequests, "TooManyRequestsException", domain.ErrEmailThrottled},
+		"quota":          {http.StatusBadRequest, "LimitExceededException", domain.ErrEmailThrottled},
+		"rejected":       {http.StatusBadRequest, "MessageRejected", domain.ErrEmailRejected},
+		"unverified":     {http.StatusBadRequest, "MailFromDomainNotVerifiedException", domain.ErrEmailRejected},
+		"internal error": {http.StatusInternalServerError, "InternalFailure", nil},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			// Arrange
+			header := http.Header{"X-Amzn-Errortype": {tc.code + ":http://internal.amazon.com/"}}
+			server, _, _ := apiServer(t, tc.status, header, `{"message":"nope"}`)
+			sender := newSESSender(t, server.URL)
+
+			// Act
+			_, err := sender.Send(context.Background(), domain.Email{To: "alice@example.com", Text: "hi"})
+
+			// Assert
+			var apiErr *email.APIError
+			if !errors.As(err, &apiErr) || apiErr.Code != tc.code {
+				t.Fatalf("Expected an APIError with code %s, but got '%v'", tc.code, err)
+			}
+			for _, class := range []error{domain.ErrEmailThrottled, domain.ErrEmailRejected} {
+				if errors.Is(err, class) != (class == tc.want) {
+					t.Errorf("Expected error class '%v', but got '%v'", tc.want, err)
+				}
+			}
+		})
+	}
+}
+
+func TestSendGridSender_Send(t *testing.T) {
+	// Arrange
+	server, req, body := apiServer(t, http.StatusAccepted, http.Header{"X-Message-Id": {"sg-4bE3"}}, "")
+	sender, err := email.NewSendGridSender("SG.key", server.URL, "Clean Go <no-reply@example.com>")
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+
+	// Act
+	id, err := sender.Send(context.Background(), domain.Email{To: "alice@example.com", Subject: "Welcome", Text: "Hello"})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if id != "sg-4bE3" {
+		t.Errorf("Expected SendGrid's message ID, but got %q", id)
+	}
+	if req.URL.Path != "/v3/mail/send" || req.Header.Get("Authorization") != "Bearer SG.key" {
+		t.Errorf("Expected an authorized Mail Send request, but got %s %q", req.URL.Path, req.Header.Get("Authorization"))
+	}
+	for _, want := range []string{`"to":[{"email":"alice@example.com"}]`, `"from":{"email":"no-reply@example.com","name":"Clean Go"}`, `"value":"Hello"`} {
+		if !strings.Contains(string(*body), want) {
+			t.Errorf("Expected the request to contain %s, but got %s", want, *body)
+		}
+	}
+}
+
+func TestSendGridSender_ClassifiesErrors(t *testing.T) {
+	cases := map[string]struct {
+		status int
+		want   error
+	}{
+		"rate limited": {http.StatusTooManyRequests, domain.ErrEmailThrottled},
+		"bad message":  {http.StatusBadRequest, domain.ErrEmailRejected},
+		"bad key":      {http.StatusUnauthorized, nil},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			// Arrange
+			server, _, _ := apiServer(t, tc.status, nil, `{"errors":[{"message":"nope"}]}`)
+			sender, _ := email.NewSendGridSender("SG.key", server.URL, "no-reply@example.com")
+
+			// Act
+			_, err := sender.Send(context.Background(), domain.Email{To: "alice@example.com", Text: "hi"})
+
+			// Assert
+			if err == nil || !strings.Contains(err.Error(), "nope") {
+				t.Fatalf("Expected the provider's message in the error, but got '%v'", err)
+			}
+			for _, class := range []error{domain.ErrEmailThrottled, domain.ErrEmailRejected} {
+				if errors.Is(err, class) != (class == tc.want) {
+					t.Errorf("Expected error class '%v', but got '%v'", tc.want, err)
+				}
+			}
+		})
+	}
+}
+
+// recordingSender keeps the emails it was asked to send, failing each
+// with the next of errs while any are left. Sent emails get the IDs
+// "msg-1", "msg-2" and so on, counting every attempt.
+type recordingSender struct {
+	sent []domain.Email
+	errs []error
+}
+
+func (s *recordingSender) Send(ctx context.Context, msg domain.Email) (string, error) {
+	s.sent = append(s.sent, msg)
+	if len(s.errs) == 0 {
+		return fmt.Sprintf("msg-%d", len(s.sent)), nil
+	}
+	err := s.errs[0]
+	s.errs = s.errs[1:]
+	return "", err
+}
+
+func TestEmailDelivery_Process(t *testing.T) {
+	// Arrange
+	sender := &recordingSender{}
+	delivery := core.NewEmailDelivery(sender)
+	delivery.Retry = retry.Policy{}
+	refused := errors.New("550 mailbox unavailable")
+
+	// Act
+	err := delivery.Process(context.Background(), core.EmailJob{Email: "alice@example.com", Subject: "Welcome", Body: "Hello"})
+	sender.errs = []error{refused}
+	failed := delivery.Process(context.Background(), core.EmailJob{Email: "bob@example.com", Body: "Hello"})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if want := (domain.Email{To: "alice@example.com", Subject: "Welcome", Text: "Hello"}); sender.sent[0] != want {
+		t.Errorf("Expected %+v, but got %+v", want, sender.sent[0])
+	}
+	if !errors.Is(failed, refused) {
+		t.Errorf("Expected error '%v', but got '%v'", refused, failed)
+	}
+}
+
+func TestEmailDelivery_RetriesThrottledButNotRejected(t *testing.T) {
+	// Arrange
+	throttled := fmt.Errorf("%w: 429", domain.ErrEmailThrottled)
+	rejected := fmt.Errorf("%w: 550", domain.ErrEmailRejected)
+	sender := &recordingSender{errs: []error{throttled, throttled}}
+	delivery := core.NewEmailDelivery(sender)
+	delivery.Retry = retry.Policy{Attempts: 5}
+
+	// Act
+	first := delivery.Process(context.Background(), core.EmailJob{Email: "alice@example.com"})
+	sender.errs = []error{rejected, rejected}
+	second := delivery.Process(context.Background(), core.EmailJob{Email: "bob@example.com"})
+
+	// Assert
+	if first != nil {
+		t.Fatalf("Expected the throttled send to succeed on its third attempt, but got: %v", first)
+	}
+	if !errors.Is(second, domain.ErrEmailRejected) {
+		t.Errorf("Expected error '%v', but got '%v'", domain.ErrEmailRejected, second)
+	}
+	if len(sender.sent) != 4 {
+		t.Errorf("Expected 3 attempts for alice and 1 for bob, but got %d sends", len(sender.sent))
+	}
+}
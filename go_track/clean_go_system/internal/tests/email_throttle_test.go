@@ -0,0 +1,157 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"clean_go_system/internal/adapter/memory"
+	"clean_go_system/internal/core"
+	"clean_go_system/internal/domain"
+	"clean_go_system/pkg/clock"
+)
+
+// sentJobs records the jobs that made it past a throttle.
+type sentJobs struct {
+	mu   sync.Mutex
+	jobs []core.EmailJob
+}
+
+func (s *sentJobs) process(ctx context.Context, job core.EmailJob) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs = append(s.jobs, job)
+	return nil
+}
+
+func (s *sentJobs) len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.jobs)
+}
+
+func (q *recordingQueue) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.jobs)
+}
+
+func newEmailThrottle(clk *clock.Fake, queue core.EmailQueue, sent *sentJobs) *core.EmailThrottle {
+	limiter := memory.NewRateLimiter()
+	limiter.Clock = clk
+	throttle := core.NewEmailThrottle(limiter, queue, sent.process)
+	throttle.Clock = clk
+	throttle.Domains = map[string]domain.RateLimit{"gmail.com": {Count: 2, Period: time.Minute, Burst: 2}}
+	return throttle
+}
+
+func TestEmailThrottle_DefersJobsOverTheDomainLimit(t *testing.T) {
+	// Arrange
+	clk := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	queue := &recordingQueue{}
+	sent := &sentJobs{}
+	throttle := newEmailThrottle(clk, queue, sent)
+	ctx := context.Background()
+
+	// Act
+	var errs []error
+	for _, to := range []string{"a@gmail.com", "b@Gmail.com", "c@gmail.com", "d@example.com"} {
+		errs = append(errs, throttle.Process(ctx, core.EmailJob{Email: to}))
+	}
+
+	// Assert
+	if err := errors.Join(errs...); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if sent.len() != 3 || queue.len() != 0 {
+		t.Fatalf("Expected two gmail.com jobs and the other domain's sent and one held back, but got %d sent, %d queued", sent.len(), queue.len())
+	}
+	waitUntil(t, func() bool { return clk.Waiters() == 1 })
+	clk.Advance(30 * time.Second)
+	waitUntil(t, func() bool { return queue.len() == 1 })
+	if queue.jobs[0].Email != "c@gmail.com" {
+		t.Errorf("Expected the deferred job queued again, but got %+v", queue.jobs)
+	}
+}
+
+func TestEmailThrottle_GlobalLimitCoversEveryDomain(t *testing.T) {
+	// Arrange
+	clk := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	queue := &recordingQueue{}
+	sent := &sentJobs{}
+	throttle := newEmailThrottle(clk, queue, sent)
+	throttle.Global = domain.RateLimit{Count: 1, Period: time.Second, Burst: 1}
+	ctx := context.Background()
+
+	// Act
+	first := throttle.Process(ctx, core.EmailJob{Email: "a@example.com"})
+	second := throttle.Process(ctx, core.EmailJob{Email: "b@example.org"})
+
+	// Assert
+	if first != nil || second != nil {
+		t.Fatalf("Expected no errors, but got: %v, %v", first, second)
+	}
+	if sent.len() != 1 {
+		t.Errorf("Expected one send through the provider, but got %d", sent.len())
+	}
+	waitUntil(t, func() bool { return clk.Waiters() == 1 })
+}
+
+func TestEmailThrottle_StopQueuesDeferredJobsNow(t *testing.T) {
+	// Arrange
+	clk := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	queue := &recordingQueue{}
+	sent := &sentJobs{}
+	throttle := newEmailThrottle(clk, queue, sent)
+	ctx := context.Background()
+	for _, to := range []string{"a@gmail.com", "b@gmail.com", "c@gmail.com"} {
+		if err := throttle.Process(ctx, core.EmailJob{Email: to}); err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+	}
+
+	// Act
+	stopErr := throttle.Stop(ctx)
+	afterStop := throttle.Process(ctx, core.EmailJob{Email: "d@gmail.com"})
+
+	// Assert
+	if stopErr != nil {
+		t.Fatalf("Expected no error, but got: %v", stopErr)
+	}
+	if queue.len() != 1 || queue.jobs[0].Email != "c@gmail.com" {
+		t.Errorf("Expected the deferred job queued without waiting, but got %+v", queue.jobs)
+	}
+	if !errors.Is(afterStop, core.ErrEmailThrottled) {
+		t.Errorf("Expected error '%v', but got '%v'", core.ErrEmailThrottled, afterStop)
+	}
+}
+
+func TestEmailThrottle_JobsTheQueueTurnsAwayFail(t *testing.T) {
+	// Arrange
+	clk := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	pool := core.NewWorkerPool(1, 1)
+	pool.Drain(context.Background())
+	sent := &sentJobs{}
+	throttle := newEmailThrottle(clk, pool, sent)
+	var failed []core.EmailJob
+	throttle.OnFailure = func(job core.EmailJob, err error) { failed = append(failed, job) }
+	ctx := context.Background()
+	for _, to := range []string{"a@gmail.com", "b@gmail.com", "c@gmail.com"} {
+		if err := throttle.Process(ctx, core.EmailJob{Email: to}); err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+	}
+
+	// Act
+	err := throttle.Stop(ctx)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if len(failed) != 1 || failed[0].Email != "c@gmail.com" {
+		t.Errorf("Expected the deferred job failed, but got %+v", failed)
+	}
+}
@@ -0,0 +1,69 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"clean_go_system/internal/adapter/eventbus"
+	"clean_go_system/internal/domain"
+)
+
+func TestBus_SyncHandlerErrorsReachPublisher(t *testing.T) {
+	// Arrange
+	bus := eventbus.New(quietLogger(), 1, 1)
+	handlerErr := errors.New("audit store down")
+	eventbus.Subscribe(bus, eventbus.Sync, func(ctx context.Context, e domain.UserRegistered) error {
+		return handlerErr
+	})
+
+	// Act
+	err := bus.Publish(context.Background(), domain.UserRegistered{Email: "a@example.com"})
+
+	// Assert
+	if !errors.Is(err, handlerErr) {
+		t.Fatalf("Expected error '%v', but got '%v'", handlerErr, err)
+	}
+}
+
+func TestBus_AsyncHandlersRunOnWorkersAndOnlyForTheirType(t *testing.T) {
+	// Arrange
+	bus := eventbus.New(quietLogger(), 2, 8)
+	if err := bus.Start(context.Background()); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	registered := make(chan string, 1)
+	eventbus.Subscribe(bus, eventbus.Async, func(ctx context.Context, e domain.UserRegistered) error {
+		registered <- e.Email
+		return nil
+	})
+	all := make(chan string, 2)
+	eventbus.SubscribeAll(bus, eventbus.Async, func(ctx context.Context, e domain.DomainEvent) error {
+		all <- e.EventName()
+		return nil
+	})
+
+	// Act
+	err := bus.Publish(context.Background(),
+		domain.UserRegistered{Email: "a@example.com"},
+		domain.UserDeactivated{Email: "b@example.com"},
+	)
+	stopCtx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	stopErr := bus.Stop(stopCtx)
+
+	// Assert
+	if err != nil || stopErr != nil {
+		t.Fatalf("Expected no errors, but got %v and %v", err, stopErr)
+	}
+	if got := <-registered; got != "a@example.com" {
+		t.Errorf("Expected a@example.com, but got '%s'", got)
+	}
+	if len(all) != 2 {
+		t.Errorf("Expected the wildcard handler to see 2 events, but got %d", len(all))
+	}
+	if err := bus.Publish(context.Background(), domain.UserRegistered{}); !errors.Is(err, eventbus.ErrClosed) {
+		t.Errorf("Expected ErrClosed after Stop, but got '%v'", err)
+	}
+}
@@ -0,0 +1,154 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"clean_go_system/internal/adapter/eventcodec"
+	"clean_go_system/internal/domain"
+	"github.com/google/uuid"
+)
+
+func TestEventCodec_RoundTrip(t *testing.T) {
+	// Arrange
+	original := domain.UserRegistered{
+		UserID:   uuid.New(),
+		Email:    "alice@example.com",
+		Username: "alice",
+		At:       time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+
+	// Act
+	payload, err := eventcodec.Encode(context.Background(), original)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	decoded, id, err := eventcodec.Decode(context.Background(), original.EventName(), payload)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if decoded != original {
+		t.Errorf("Expected %+v, but got %+v", original, decoded)
+	}
+	if id == "" {
+		t.Error("Expected the envelope to carry an event ID")
+	}
+}
+
+func TestEventCodec_UnknownType(t *testing.T) {
+	if _, _, err := eventcodec.Decode(context.Background(), "order.placed", []byte(`{}`)); err == nil {
+		t.Fatal("Expected an error, but got nil")
+	}
+}
+
+// profileUpdated is a test event whose schema went through two versions:
+// v1 carried "Name", v2 split it into "First" and "Last".
+type profileUpdated struct {
+	First string
+	Last  string
+	At    time.Time
+}
+
+func (profileUpdated) EventName() string       { return "profile.updated" }
+func (e profileUpdated) OccurredAt() time.Time { return e.At }
+func (e profileUpdated) AggregateID() string   { return e.First }
+
+func splitName(payload json.RawMessage) (json.RawMessage, error) {
+	var v1 struct {
+		Name string
+		At   time.Time
+	}
+	if err := json.Unmarshal(payload, &v1); err != nil {
+		return nil, err
+	}
+	first, last, _ := strings.Cut(v1.Name, " ")
+	return json.Marshal(profileUpdated{First: first, Last: last, At: v1.At})
+}
+
+func TestRegistry_UpcastsOlderVersions(t *testing.T) {
+	// Arrange
+	registry := eventcodec.NewRegistry()
+	eventcodec.Register[profileUpdated](registry, 2, map[int]eventcodec.Upcaster{1: splitName})
+	v1 := []byte(`{"type":"profile.updated","version":1,"occurred_at":"2024-01-02T03:04:05Z","payload":{"Name":"Ada Lovelace"}}`)
+
+	// Act
+	event, _, err := registry.Unmarshal(context.Background(), v1)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	got, ok := event.(profileUpdated)
+	if !ok || got.First != "Ada" || got.Last != "Lovelace" {
+		t.Errorf("Expected Ada Lovelace split into two fields, but got %+v", event)
+	}
+}
+
+func TestRegistry_RejectsNewerVersions(t *testing.T) {
+	registry := eventcodec.NewRegistry()
+	eventcodec.Register[profileUpdated](registry, 1, nil)
+	v2 := []byte(`{"type":"profile.updated","version":2,"occurred_at":"2024-01-02T03:04:05Z","payload":{}}`)
+
+	if _, _, err := registry.Unmarshal(context.Background(), v2); err == nil {
+		t.Fatal("Expected an error, but got nil")
+	}
+}
+
+func TestEventCodec_DecodesLegacyBarePayload(t *testing.T) {
+	// Arrange: payloads written to the outbox before envelopes existed.
+	legacy := []byte(`{"UserID":"6f1c3a52-4c1e-4d4e-9a55-0c3f2c1d9b8a","Email":"a@example.com","Username":"a","At":"2024-01-02T03:04:05Z"}`)
+
+	// Act
+	event, _, err := eventcodec.Decode(context.Background(), "user.registered", legacy)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if got := event.(domain.UserRegistered).Email; got != "a@example.com" {
+		t.Errorf("Expected a@example.com, but got '%s'", got)
+	}
+}
+
+func TestRegistry_SealsPersonalFields(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	registry := eventcodec.NewRegistry()
+	eventcodec.Register[domain.UserRegistered](registry, 1, nil)
+	eventcodec.Personal[domain.UserRegistered](registry, "Email")
+	registry.PII = newFieldCodec(t, domain.DataKey{Version: 1, Secret: bytes.Repeat([]byte("a"), 32)})
+	original := domain.UserRegistered{
+		UserID:   uuid.New(),
+		Email:    "alice@example.com",
+		Username: "alice",
+		At:       time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+
+	// Act
+	payload, err := registry.Marshal(ctx, original)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	decoded, _, err := registry.Unmarshal(ctx, payload)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if bytes.Contains(payload, []byte("alice@example.com")) {
+		t.Errorf("Expected the email sealed, but got %s", payload)
+	}
+	if decoded != original {
+		t.Errorf("Expected %+v, but got %+v", original, decoded)
+	}
+	registry.PII = nil
+	if _, _, err := registry.Unmarshal(ctx, payload); err == nil {
+		t.Error("Expected an error decoding a sealed event without keys, but got nil")
+	}
+}
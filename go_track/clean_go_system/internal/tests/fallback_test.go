@@ -0,0 +1,115 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"clean_go_system/internal/adapter/fallback"
+	"clean_go_system/internal/adapter/memory"
+	"clean_go_system/internal/domain"
+	"github.com/google/uuid"
+)
+
+// flakyRepo delegates to UserRepository until down is set.
+type flakyRepo struct {
+	domain.UserRepository
+	down bool
+}
+
+func (r *flakyRepo) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
+	if r.down {
+		return nil, errors.New("connection refused")
+	}
+	return r.UserRepository.GetByEmail(ctx, email)
+}
+
+func (r *flakyRepo) GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	if r.down {
+		return nil, errors.New("connection refused")
+	}
+	return r.UserRepository.GetByID(ctx, id)
+}
+
+func newFallbackRepo() (*fallback.UserRepository, *flakyRepo) {
+	source := &flakyRepo{UserRepository: memory.NewUserRepository()}
+	return fallback.NewUserRepository(source, memory.NewStore[domain.User](time.Minute)), source
+}
+
+func TestFallbackRepository_ServesStaleCopyWhenDown(t *testing.T) {
+	// Arrange
+	repo, source := newFallbackRepo()
+	ctx := context.Background()
+	alice := domain.User{ID: uuid.New(), Email: "alice@example.com", Username: "alice"}
+	if err := repo.Save(ctx, alice); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	source.down = true
+
+	// Act
+	byEmail, emailErr := repo.GetByEmail(ctx, alice.Email)
+	byID, idErr := repo.GetByID(ctx, alice.ID)
+
+	// Assert
+	if emailErr != nil || idErr != nil {
+		t.Fatalf("Expected stale copies, but got errors %v and %v", emailErr, idErr)
+	}
+	if !byEmail.Stale || !byID.Stale || byID.Username != "alice" {
+		t.Errorf("Expected stale alice twice, but got %+v and %+v", byEmail, byID)
+	}
+}
+
+func TestFallbackRepository_FreshReadsAreNotStale(t *testing.T) {
+	// Arrange
+	repo, _ := newFallbackRepo()
+	ctx := context.Background()
+	alice := domain.User{ID: uuid.New(), Email: "alice@example.com", Username: "alice"}
+	_ = repo.Save(ctx, alice)
+
+	// Act
+	got, err := repo.GetByID(ctx, alice.ID)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if got.Stale {
+		t.Error("Expected a fresh copy, but it was flagged stale")
+	}
+}
+
+func TestFallbackRepository_UnknownUserStillFails(t *testing.T) {
+	// Arrange
+	repo, source := newFallbackRepo()
+	source.down = true
+
+	// Act
+	_, err := repo.GetByEmail(context.Background(), "nobody@example.com")
+
+	// Assert
+	if err == nil {
+		t.Fatal("Expected an error, but got nil")
+	}
+}
+
+func TestFallbackRepository_ForgetsOldEmailAfterUpdate(t *testing.T) {
+	// Arrange
+	repo, source := newFallbackRepo()
+	ctx := context.Background()
+	alice := domain.User{ID: uuid.New(), Email: "alice@example.com", Username: "alice"}
+	_ = repo.Save(ctx, alice)
+	alice.Email = "alice@example.org"
+	if err := repo.Update(ctx, alice); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	source.down = true
+
+	// Act
+	_, err := repo.GetByEmail(ctx, "alice@example.com")
+
+	// Assert
+	if err == nil {
+		t.Error("Expected no stale copy under the old email, but got one")
+	}
+}
@@ -0,0 +1,134 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"clean_go_system/internal/adapter/chaos"
+	httpadapter "clean_go_system/internal/adapter/http"
+	"clean_go_system/internal/adapter/memory"
+	"clean_go_system/internal/core"
+	"clean_go_system/internal/domain"
+	"clean_go_system/internal/httptestutil"
+	"clean_go_system/pkg/faults"
+	"github.com/google/uuid"
+)
+
+// fixedRand makes every random draw return v.
+func fixedRand(v float64) func() float64 { return func() float64 { return v } }
+
+func TestInjector_ErrorRateFailsBeforeTheCall(t *testing.T) {
+	// Arrange
+	injector := faults.NewInjector(map[string]faults.Fault{"users.repo": {ErrorRate: 0.5}})
+	injector.Rand = fixedRand(0.1)
+	var called bool
+
+	// Act
+	err := injector.Do(context.Background(), "users.repo", func(context.Context) error {
+		called = true
+		return nil
+	})
+
+	// Assert
+	if !errors.Is(err, faults.ErrInjected) {
+		t.Fatalf("Expected error '%v', but got '%v'", faults.ErrInjected, err)
+	}
+	if called {
+		t.Error("Expected the call to be skipped, but it ran")
+	}
+}
+
+func TestInjector_PartialFailureRunsTheCall(t *testing.T) {
+	// Arrange
+	repo := memory.NewUserRepository()
+	injector := faults.NewInjector(map[string]faults.Fault{faults.Wildcard: {PartialRate: 1}})
+	decorated := chaos.NewUserRepository(repo, injector)
+	user := domain.User{ID: uuid.New(), Email: "alice@example.com", Username: "alice", Active: true}
+
+	// Act
+	err := decorated.Save(context.Background(), user)
+
+	// Assert
+	if !errors.Is(err, faults.ErrInjected) {
+		t.Fatalf("Expected error '%v', but got '%v'", faults.ErrInjected, err)
+	}
+	if _, err := repo.GetByID(context.Background(), user.ID); err != nil {
+		t.Errorf("Expected the user to be saved anyway, but got: %v", err)
+	}
+}
+
+func TestInjector_UnconfiguredTargetPassesThrough(t *testing.T) {
+	// Arrange
+	injector := faults.NewInjector(map[string]faults.Fault{"http": {ErrorRate: 1}})
+
+	// Act
+	err := injector.Do(context.Background(), chaos.UserRepoTarget, func(context.Context) error { return nil })
+
+	// Assert
+	if err != nil {
+		t.Errorf("Expected no error, but got: %v", err)
+	}
+}
+
+func TestInjector_LatencyRespectsContext(t *testing.T) {
+	// Arrange
+	injector := faults.NewInjector(map[string]faults.Fault{faults.Wildcard: {LatencyMS: 10_000}})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	// Act
+	err := injector.Do(ctx, "users.repo", func(context.Context) error { return nil })
+
+	// Assert
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected error '%v', but got '%v'", context.DeadlineExceeded, err)
+	}
+}
+
+func TestInjector_SetReplacesFaults(t *testing.T) {
+	// Arrange
+	injector := faults.NewInjector(map[string]faults.Fault{faults.Wildcard: {ErrorRate: 1}})
+
+	// Act
+	injector.Set(nil)
+	err := injector.Do(context.Background(), "users.repo", func(context.Context) error { return nil })
+
+	// Assert
+	if err != nil {
+		t.Errorf("Expected no error after clearing faults, but got: %v", err)
+	}
+}
+
+func TestMiddleware_HeadersInjectIntoTheRepository(t *testing.T) {
+	// Arrange
+	injector := faults.NewInjector(nil)
+	svc := core.NewUserService(chaos.NewUserRepository(memory.NewUserRepository(), injector), &recordingPublisher{}, memory.NewTransactor())
+	handler := http.HandlerFunc(httpadapter.NewHandler(svc, quietLogger()).Register)
+	req := httptestutil.NewRequest(t, http.MethodPost, "/register", map[string]string{"email": "alice@example.com", "username": "alice"})
+	req.Header.Set(faults.HeaderTarget, chaos.UserRepoTarget)
+	req.Header.Set(faults.HeaderErrorRate, "1")
+
+	// Act
+	rec := httptestutil.Serve(faults.Middleware(injector, handler), req)
+
+	// Assert
+	httptestutil.AssertStatus(t, rec, http.StatusInternalServerError)
+}
+
+func TestMiddleware_HTTPFaultReturns503(t *testing.T) {
+	// Arrange
+	injector := faults.NewInjector(map[string]faults.Fault{faults.HTTPTarget: {ErrorRate: 1}})
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Expected the handler not to run")
+	})
+	req := httptestutil.NewRequest(t, http.MethodGet, "/", nil)
+
+	// Act
+	rec := httptestutil.Serve(faults.Middleware(injector, next), req)
+
+	// Assert
+	httptestutil.AssertStatus(t, rec, http.StatusServiceUnavailable)
+}
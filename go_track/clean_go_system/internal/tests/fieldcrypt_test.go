@@ -0,0 +1,104 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"clean_go_system/internal/adapter/fieldcrypt"
+	"clean_go_system/internal/domain"
+)
+
+func newFieldCodec(t *testing.T, keys ...domain.DataKey) *fieldcrypt.Codec {
+	t.Helper()
+	provider, err := fieldcrypt.NewStaticKeys(bytes.Repeat([]byte("i"), 32), keys...)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	return fieldcrypt.NewCodec(provider)
+}
+
+func TestFieldCodec_EncryptDecrypt(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	v1 := domain.DataKey{Version: 1, Secret: bytes.Repeat([]byte("a"), 32)}
+	v2 := domain.DataKey{Version: 2, Secret: bytes.Repeat([]byte("b"), 32)}
+	before := newFieldCodec(t, v1)
+	after := newFieldCodec(t, v1, v2) // rotated: v2 encrypts, v1 still decrypts
+	aad := []byte("users.email:1")
+	sealed, err := before.Encrypt(ctx, "alice@example.com", aad)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+
+	t.Run("old version after rotation", func(t *testing.T) {
+		// Act
+		got, err := after.Decrypt(ctx, sealed, aad)
+
+		// Assert
+		if err != nil || got != "alice@example.com" {
+			t.Errorf("Expected alice@example.com, but got %q (%v)", got, err)
+		}
+	})
+
+	t.Run("not plaintext", func(t *testing.T) {
+		// Assert
+		if bytes.Contains(sealed, []byte("alice")) {
+			t.Errorf("Expected ciphertext, but got %q", sealed)
+		}
+	})
+
+	t.Run("other row", func(t *testing.T) {
+		// Act
+		_, err := after.Decrypt(ctx, sealed, []byte("users.email:2"))
+
+		// Assert
+		if !errors.Is(err, fieldcrypt.ErrCiphertext) {
+			t.Errorf("Expected error '%v', but got '%v'", fieldcrypt.ErrCiphertext, err)
+		}
+	})
+
+	t.Run("retired version", func(t *testing.T) {
+		// Act
+		_, err := newFieldCodec(t, v2).Decrypt(ctx, sealed, aad)
+
+		// Assert
+		if !errors.Is(err, domain.ErrUnknownKeyVersion) {
+			t.Errorf("Expected error '%v', but got '%v'", domain.ErrUnknownKeyVersion, err)
+		}
+	})
+}
+
+func TestFieldCodec_BlindIndex(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	codec := newFieldCodec(t, domain.DataKey{Version: 1, Secret: bytes.Repeat([]byte("a"), 32)})
+
+	// Act
+	first, _ := codec.BlindIndex(ctx, "users.email", "alice@example.com")
+	second, _ := codec.BlindIndex(ctx, "users.email", "alice@example.com")
+	otherField, _ := codec.BlindIndex(ctx, "users.username", "alice@example.com")
+
+	// Assert
+	if !bytes.Equal(first, second) {
+		t.Error("Expected the same value to index the same")
+	}
+	if bytes.Equal(first, otherField) {
+		t.Error("Expected indexes of different fields to differ")
+	}
+}
+
+func TestFieldCrypt_ParseKeys(t *testing.T) {
+	// Act
+	keys, err := fieldcrypt.ParseKeys("1:YWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWE=, 2:YmJi")
+	_, shortErr := fieldcrypt.NewStaticKeys(bytes.Repeat([]byte("i"), 32), keys...)
+
+	// Assert
+	if err != nil || len(keys) != 2 || keys[1].Version != 2 {
+		t.Fatalf("Expected two keys, but got %+v (%v)", keys, err)
+	}
+	if shortErr == nil {
+		t.Error("Expected a 3-byte key to be refused, but got nil")
+	}
+}
@@ -0,0 +1,95 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"clean_go_system/internal/domain"
+	"clean_go_system/internal/httptestutil"
+	"github.com/google/uuid"
+)
+
+func FuzzValidateEmail(f *testing.F) {
+	for _, seed := range []string{"alice@example.com", "", "@", "a@b", "Alice <alice@example.com>", `"quoted"@example.com`, "alice@[127.0.0.1]", strings.Repeat("a", 300) + "@example.com"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, email string) {
+		err := domain.ValidateEmail(email)
+		if err != nil {
+			if !errors.Is(err, domain.ErrInvalidEmail) {
+				t.Fatalf("Expected ErrInvalidEmail for %q, but got: %v", email, err)
+			}
+			return
+		}
+		if len(email) > 254 || !strings.Contains(email, "@") {
+			t.Errorf("Expected %q to be rejected, but it was accepted", email)
+		}
+	})
+}
+
+func FuzzValidateUsername(f *testing.F) {
+	for _, seed := range []string{"alice", "al", "alice smith", "älice", "a.b_c-d", strings.Repeat("x", 33)} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, username string) {
+		err := domain.ValidateUsername(username)
+		if err != nil {
+			if !errors.Is(err, domain.ErrInvalidUsername) {
+				t.Fatalf("Expected ErrInvalidUsername for %q, but got: %v", username, err)
+			}
+			return
+		}
+		if len(username) < 3 || len(username) > 32 {
+			t.Errorf("Expected %q to be rejected by length, but it was accepted", username)
+		}
+		for _, r := range username {
+			if r > 0x7f || r == ' ' {
+				t.Errorf("Expected %q to be rejected for %q, but it was accepted", username, r)
+			}
+		}
+	})
+}
+
+// FuzzRegisterHandler feeds arbitrary bodies to the JSON decoding path.
+// Whatever arrives, the handler must answer with a client error or create a
+// user that passes validation and matches the response.
+func FuzzRegisterHandler(f *testing.F) {
+	for _, seed := range []string{
+		`{"email":"alice@example.com","username":"alice"}`,
+		`{"email":"alice@example.com","username":"alice","extra":true}`,
+		`{"email":`, `[]`, `null`, `""`, `{"email":1}`, "{\"email\":\"a\\u0000@example.com\",\"username\":\"alice\"}",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, body string) {
+		handler, repo := newRegisterHandler(&recordingPublisher{})
+
+		rec := httptestutil.Serve(handler, httptestutil.NewRequest(t, http.MethodPost, "/register", body))
+
+		switch rec.Code {
+		case http.StatusBadRequest:
+			return
+		case http.StatusCreated:
+		default:
+			t.Fatalf("Expected 201 or 400 for %q, but got %d: %s", body, rec.Code, rec.Body.String())
+		}
+		resp := httptestutil.DecodeJSON[map[string]string](t, rec)
+		id, err := uuid.Parse(resp["id"])
+		if err != nil {
+			t.Fatalf("Expected a UUID id, but got %q", resp["id"])
+		}
+		stored, err := repo.GetByID(context.Background(), id)
+		if err != nil {
+			t.Fatalf("Expected the user to be stored, but got: %v", err)
+		}
+		if stored.Email != resp["email"] || stored.Username != resp["username"] {
+			t.Errorf("Expected the response to match the stored user, but got %+v vs %v", stored, resp)
+		}
+		if domain.ValidateEmail(stored.Email) != nil || domain.ValidateUsername(stored.Username) != nil {
+			t.Errorf("Expected only valid users to be stored, but got %+v", stored)
+		}
+	})
+}
@@ -0,0 +1,127 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"clean_go_system/internal/adapter/eventcodec"
+	"clean_go_system/internal/core"
+	"clean_go_system/internal/domain"
+	"clean_go_system/internal/httptestutil"
+	"github.com/google/uuid"
+)
+
+// update rewrites the golden files instead of comparing against them:
+//
+//	go test ./internal/tests -run Golden -update
+var update = flag.Bool("update", false, "rewrite golden files in testdata/golden")
+
+var uuidPattern = regexp.MustCompile(`[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}`)
+
+// assertGolden compares got with testdata/golden/<name>.golden. Random IDs
+// are scrubbed first so only the wire format is compared.
+func assertGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	got = uuidPattern.ReplaceAll(got, []byte("<uuid>"))
+	path := filepath.Join("testdata", "golden", name+".golden")
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Expected golden file %s (run with -update to create it), but got: %v", path, err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("Wire format of %s changed; run with -update if intended.\n--- want\n%s\n--- got\n%s", name, want, got)
+	}
+}
+
+// dumpResponse renders the parts of a response clients depend on.
+func dumpResponse(rec *httptest.ResponseRecorder) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "%d %s\n", rec.Code, http.StatusText(rec.Code))
+	for _, key := range []string{"Allow", "Content-Type", "Retry-After"} {
+		if v := rec.Header().Get(key); v != "" {
+			fmt.Fprintf(&b, "%s: %s\n", key, v)
+		}
+	}
+	fmt.Fprintf(&b, "\n%s", rec.Body.String())
+	return b.Bytes()
+}
+
+func TestGolden_RegisterResponses(t *testing.T) {
+	alice := map[string]string{"email": "alice@example.com", "username": "alice"}
+	cases := []struct {
+		name      string
+		method    string
+		body      any
+		publisher domain.EventPublisher
+		seed      bool
+	}{
+		{"register_created", http.MethodPost, alice, &recordingPublisher{}, false},
+		{"register_invalid_payload", http.MethodPost, `{"email":`, &recordingPublisher{}, false},
+		{"register_invalid_email", http.MethodPost, map[string]string{"email": "nope", "username": "alice"}, &recordingPublisher{}, false},
+		{"register_invalid_username", http.MethodPost, map[string]string{"email": "alice@example.com", "username": "a b"}, &recordingPublisher{}, false},
+		{"register_conflict", http.MethodPost, alice, &recordingPublisher{}, true},
+		{"register_queue_full", http.MethodPost, alice, &recordingPublisher{err: core.ErrQueueFull}, false},
+		{"register_internal_error", http.MethodPost, alice, &recordingPublisher{err: errors.New("broker down")}, false},
+		{"register_method_not_allowed", http.MethodGet, nil, &recordingPublisher{}, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Arrange
+			handler, _ := newRegisterHandler(c.publisher)
+			if c.seed {
+				httptestutil.Serve(handler, httptestutil.NewRequest(t, http.MethodPost, "/register", c.body))
+			}
+
+			// Act
+			rec := httptestutil.Serve(handler, httptestutil.NewRequest(t, c.method, "/register", c.body))
+
+			// Assert
+			assertGolden(t, c.name, dumpResponse(rec))
+		})
+	}
+}
+
+func TestGolden_EventEnvelopes(t *testing.T) {
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	userID := uuid.MustParse("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	cases := map[string]domain.DomainEvent{
+		"event_user_registered":     domain.UserRegistered{UserID: userID, Email: "alice@example.com", Username: "alice", At: at},
+		"event_user_email_changed":  domain.UserEmailChanged{UserID: userID, OldEmail: "alice@example.com", NewEmail: "alice@example.org", At: at},
+		"event_user_deactivated":    domain.UserDeactivated{UserID: userID, Email: "alice@example.com", At: at},
+		"event_user_deleted":        domain.UserDeleted{UserID: userID, Email: "alice@example.com", At: at},
+		"event_user_locale_changed": domain.UserLocaleChanged{UserID: userID, Locale: "pt-br", At: at},
+		"event_user_updated":        domain.UserUpdated{UserID: userID, Email: "alice@example.org", Username: "alice", Active: true, At: at},
+		"event_email_requested":     domain.EmailRequested{UserID: userID, Template: "email.verification", Email: "alice@example.com", Subject: "Confirm your email address", Body: "Use this token: abc.def", At: at},
+	}
+	for name, event := range cases {
+		t.Run(name, func(t *testing.T) {
+			// Act
+			payload, err := eventcodec.EncodeWithID(context.Background(), event, "evt-1")
+
+			// Assert
+			if err != nil {
+				t.Fatalf("Expected no error, but got: %v", err)
+			}
+			assertGolden(t, name, append(payload, '\n'))
+		})
+	}
+}
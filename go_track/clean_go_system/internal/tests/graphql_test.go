@@ -0,0 +1,244 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"clean-code-cookbook/go/pkg/edgetoken"
+	"clean_go_system/internal/adapter/catalogsvc"
+	graphqladapter "clean_go_system/internal/adapter/graphql"
+	httpadapter "clean_go_system/internal/adapter/http"
+	"clean_go_system/internal/adapter/memory"
+	"clean_go_system/internal/core"
+	"clean_go_system/internal/httptestutil"
+	"github.com/google/uuid"
+)
+
+type graphQLResponse struct {
+	Data   map[string]any `json:"data"`
+	Errors []struct {
+		Message    string         `json:"message"`
+		Extensions map[string]any `json:"extensions"`
+	} `json:"errors"`
+}
+
+var graphQLLimits = graphqladapter.Limits{MaxDepth: 8, MaxComplexity: 1000}
+
+func postGraphQL(t *testing.T, h http.Handler, query string) graphQLResponse {
+	t.Helper()
+	rec := httptestutil.Serve(h, httptestutil.NewRequest(t, http.MethodPost, "/graphql", map[string]string{"query": query}))
+	return httptestutil.DecodeJSON[graphQLResponse](t, rec)
+}
+
+// errorCode is the code of the response's only error, or "" when it has
+// none or several.
+func (r graphQLResponse) errorCode() string {
+	if len(r.Errors) != 1 {
+		return ""
+	}
+	code, _ := r.Errors[0].Extensions["code"].(string)
+	return code
+}
+
+func TestGraphQL_UsersByIdsShareOneBatch(t *testing.T) {
+	// Arrange
+	batches := &countingBatches{UserRepository: memory.NewUserRepository()}
+	ids := saveUsers(t, context.Background(), batches, 3)
+	loader := core.NewUserLoader(batches)
+	loader.Window = 50 * time.Millisecond
+	svc := core.NewUserService(batches, &recordingPublisher{}, memory.NewTransactor(), core.WithLoader(loader))
+	h := graphqladapter.NewHandler(svc, nil, graphQLLimits, quietLogger())
+	query := `{ usersByIds(ids: ["` + ids[0].String() + `", "` + uuid.NewString() + `", "` + ids[2].String() + `"]) { email } }`
+
+	// Act
+	resp := postGraphQL(t, h, query)
+
+	// Assert
+	if len(resp.Errors) != 0 {
+		t.Fatalf("Expected no errors, but got %+v", resp.Errors)
+	}
+	users, _ := resp.Data["usersByIds"].([]any)
+	if len(users) != 3 || users[1] != nil || users[2].(map[string]any)["email"] != "user2@example.com" {
+		t.Errorf("Expected user0, null and user2, but got %v", users)
+	}
+	if len(batches.batches) != 1 {
+		t.Errorf("Expected one batch, but got %v", batches.batches)
+	}
+}
+
+func TestGraphQL_RejectsDeepAndCostlyOperations(t *testing.T) {
+	// Arrange
+	repo := memory.NewUserRepository()
+	svc := core.NewUserService(repo, &recordingPublisher{}, memory.NewTransactor(), core.WithLister(repo))
+	shallow := graphqladapter.NewHandler(svc, nil, graphqladapter.Limits{MaxDepth: 2, MaxComplexity: 1000}, quietLogger())
+	cheap := graphqladapter.NewHandler(svc, nil, graphqladapter.Limits{MaxDepth: 8, MaxComplexity: 50}, quietLogger())
+
+	// Act
+	deep := postGraphQL(t, shallow, `{ users { items { email } } }`)
+	throughFragment := postGraphQL(t, shallow, `{ users { ...page } } fragment page on UserPage { items { email } }`)
+	costly := postGraphQL(t, cheap, `{ users(size: 100) { items { email } } }`)
+	affordable := postGraphQL(t, cheap, `{ users(size: 10) { items { email } } }`)
+
+	// Assert
+	if code := deep.errorCode(); code != "DEPTH_LIMIT_EXCEEDED" {
+		t.Errorf("Expected DEPTH_LIMIT_EXCEEDED, but got %q: %+v", code, deep.Errors)
+	}
+	if code := throughFragment.errorCode(); code != "DEPTH_LIMIT_EXCEEDED" {
+		t.Errorf("Expected DEPTH_LIMIT_EXCEEDED through the fragment, but got %q: %+v", code, throughFragment.Errors)
+	}
+	if code := costly.errorCode(); code != "COMPLEXITY_LIMIT_EXCEEDED" {
+		t.Errorf("Expected COMPLEXITY_LIMIT_EXCEEDED, but got %q: %+v", code, costly.Errors)
+	}
+	if len(affordable.Errors) != 0 {
+		t.Errorf("Expected no errors within the limit, but got %+v", affordable.Errors)
+	}
+}
+
+func TestGraphQL_MapsDomainErrorsToCodes(t *testing.T) {
+	// Arrange
+	repo := memory.NewUserRepository()
+	listing := graphqladapter.NewHandler(core.NewUserService(repo, &recordingPublisher{}, memory.NewTransactor(), core.WithLister(repo)), nil, graphQLLimits, quietLogger())
+	unlisted := graphqladapter.NewHandler(core.NewUserService(repo, &recordingPublisher{}, memory.NewTransactor()), nil, graphQLLimits, quietLogger())
+
+	// Act
+	anonymous := postGraphQL(t, listing, `{ me { email } }`)
+	badSort := postGraphQL(t, listing, `{ users(sort: "email") { next } }`)
+	unsupported := postGraphQL(t, unlisted, `{ users { next } }`)
+	missing := postGraphQL(t, listing, `{ user(id: "`+uuid.NewString()+`") { email } }`)
+
+	// Assert
+	for name, tc := range map[string]struct {
+		resp graphQLResponse
+		want string
+	}{
+		"anonymous":   {anonymous, "UNAUTHENTICATED"},
+		"badSort":     {badSort, "BAD_USER_INPUT"},
+		"unsupported": {unsupported, "NOT_IMPLEMENTED"},
+	} {
+		if code := tc.resp.errorCode(); code != tc.want {
+			t.Errorf("%s: expected %s, but got %q: %+v", name, tc.want, code, tc.resp.Errors)
+		}
+	}
+	if len(missing.Errors) != 0 || missing.Data["user"] != nil {
+		t.Errorf("Expected a null user and no error, but got %+v", missing)
+	}
+}
+
+func TestGraphQL_SetLocaleActsAsTheCaller(t *testing.T) {
+	// Arrange
+	repo := memory.NewUserRepository()
+	svc := core.NewUserService(repo, &recordingPublisher{}, memory.NewTransactor())
+	user, err := svc.Register(context.Background(), "alice@example.com", "alice")
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	sessions := httpadapter.NewSessionTokens("clean_go_system", []byte("0123456789abcdef0123456789abcdef"), time.Hour)
+	token, err := sessions.Issue(user)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	h := httpadapter.NewBearerAuth(sessions, nil, nil, quietLogger()).Middleware(graphqladapter.NewHandler(svc, nil, graphQLLimits, quietLogger()))
+	req := httptestutil.NewRequest(t, http.MethodPost, "/graphql", map[string]string{"query": `mutation { setLocale(locale: "pt-BR") { email locale } }`})
+
+	// Act
+	rec := httptestutil.Serve(h, httptestutil.Authenticated(req, token))
+
+	// Assert
+	httptestutil.AssertStatus(t, rec, http.StatusOK)
+	resp := httptestutil.DecodeJSON[graphQLResponse](t, rec)
+	got, _ := resp.Data["setLocale"].(map[string]any)
+	if len(resp.Errors) != 0 || got["email"] != "alice@example.com" || got["locale"] != "pt-br" {
+		t.Errorf("Expected alice reading pt-br, but got %+v", resp)
+	}
+}
+
+func TestGraphQL_ProductsAreReadFromTheCatalogAsTheCaller(t *testing.T) {
+	// Arrange
+	repo := memory.NewUserRepository()
+	svc := core.NewUserService(repo, &recordingPublisher{}, memory.NewTransactor())
+	user, err := svc.Register(context.Background(), "alice@example.com", "alice")
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	sessions := httpadapter.NewSessionTokens("clean_go_system", []byte("0123456789abcdef0123456789abcdef"), time.Hour)
+	token, err := sessions.Issue(user)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+
+	secret := []byte("catalog-identity-secret")
+	var mu sync.Mutex
+	var subjects, searches []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/products/", func(w http.ResponseWriter, r *http.Request) {
+		caller, _ := edgetoken.FromContext(r.Context())
+		mu.Lock()
+		subjects = append(subjects, caller.Subject)
+		mu.Unlock()
+		if r.URL.Path != "/products/sku-1" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`{"id": "sku-1", "name": "Clean Code", "price": 39.99}`))
+	})
+	mux.HandleFunc("/products", func(w http.ResponseWriter, r *http.Request) {
+		caller, _ := edgetoken.FromContext(r.Context())
+		mu.Lock()
+		subjects = append(subjects, caller.Subject)
+		searches = append(searches, r.URL.RawQuery)
+		mu.Unlock()
+		_, _ = w.Write([]byte(`{"items": [{"id": "sku-1", "name": "Clean Code", "price": 39.99}], "next": "c2"}`))
+	})
+	upstream := httptest.NewServer(edgetoken.NewSigner(secret).Middleware("catalog", mux))
+	defer upstream.Close()
+	catalog := catalogsvc.NewCatalog(upstream.URL)
+	catalog.Identity = edgetoken.NewSigner(secret)
+
+	h := httpadapter.NewBearerAuth(sessions, nil, nil, quietLogger()).Middleware(graphqladapter.NewHandler(svc, catalog, graphQLLimits, quietLogger()))
+	query := `{ product(id: "sku-1") { name price } missing: product(id: "sku-9") { name } products(q: "clean", size: 5, sort: "-price") { items { id } next } }`
+	req := httptestutil.NewRequest(t, http.MethodPost, "/graphql", map[string]string{"query": query})
+
+	// Act
+	rec := httptestutil.Serve(h, httptestutil.Authenticated(req, token))
+
+	// Assert
+	httptestutil.AssertStatus(t, rec, http.StatusOK)
+	resp := httptestutil.DecodeJSON[graphQLResponse](t, rec)
+	if len(resp.Errors) != 0 {
+		t.Fatalf("Expected no errors, but got %+v", resp.Errors)
+	}
+	product, _ := resp.Data["product"].(map[string]any)
+	if product["name"] != "Clean Code" || product["price"] != 39.99 || resp.Data["missing"] != nil {
+		t.Errorf("Expected Clean Code and a null for sku-9, but got %v", resp.Data)
+	}
+	products, _ := resp.Data["products"].(map[string]any)
+	items, _ := products["items"].([]any)
+	if len(items) != 1 || items[0].(map[string]any)["id"] != "sku-1" || products["next"] != "c2" {
+		t.Errorf("Expected sku-1 and the next cursor, but got %v", products)
+	}
+	if len(searches) != 1 || searches[0] != "q=clean&size=5&sort=-price" {
+		t.Errorf("Expected the search passed on to the catalog, but got %v", searches)
+	}
+	if len(subjects) != 3 || slices.ContainsFunc(subjects, func(s string) bool { return s != user.ID.String() }) {
+		t.Errorf("Expected every call vouched for as %s, but got %v", user.ID, subjects)
+	}
+}
+
+func TestGraphQL_ProductsWithoutACatalogAreNotImplemented(t *testing.T) {
+	// Arrange
+	svc := core.NewUserService(memory.NewUserRepository(), &recordingPublisher{}, memory.NewTransactor())
+	h := graphqladapter.NewHandler(svc, nil, graphQLLimits, quietLogger())
+
+	// Act
+	resp := postGraphQL(t, h, `{ product(id: "sku-1") { name } }`)
+
+	// Assert
+	if got := resp.errorCode(); got != "NOT_IMPLEMENTED" {
+		t.Errorf("Expected NOT_IMPLEMENTED, but got %+v", resp)
+	}
+}
@@ -0,0 +1,178 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	httpadapter "clean_go_system/internal/adapter/http"
+	"clean_go_system/internal/adapter/memory"
+	"clean_go_system/internal/core"
+	"clean_go_system/internal/domain"
+	"clean_go_system/internal/httptestutil"
+	"github.com/google/uuid"
+)
+
+// publisherFunc adapts a function to domain.EventPublisher.
+type publisherFunc func(ctx context.Context, events ...domain.DomainEvent) error
+
+func (f publisherFunc) Publish(ctx context.Context, events ...domain.DomainEvent) error {
+	return f(ctx, events...)
+}
+
+func newRegisterHandler(publisher domain.EventPublisher) (http.Handler, *memory.UserRepository) {
+	repo := memory.NewUserRepository()
+	svc := core.NewUserService(repo, publisher, memory.NewTransactor())
+	return http.HandlerFunc(httpadapter.NewHandler(svc, quietLogger()).Register), repo
+}
+
+func TestRegisterHandler_Created(t *testing.T) {
+	// Arrange
+	handler, repo := newRegisterHandler(&recordingPublisher{})
+	req := httptestutil.NewRequest(t, http.MethodPost, "/register", map[string]string{"email": "alice@example.com", "username": "alice"})
+
+	// Act
+	rec := httptestutil.Serve(handler, req)
+
+	// Assert
+	httptestutil.AssertStatus(t, rec, http.StatusCreated)
+	httptestutil.AssertHeader(t, rec, "Content-Type", "application/json")
+	body := httptestutil.DecodeJSON[map[string]string](t, rec)
+	if body["email"] != "alice@example.com" || body["username"] != "alice" {
+		t.Errorf("Expected alice's details, but got %v", body)
+	}
+	id, err := uuid.Parse(body["id"])
+	if err != nil {
+		t.Fatalf("Expected a UUID id, but got %q", body["id"])
+	}
+	if _, err := repo.GetByID(context.Background(), id); err != nil {
+		t.Errorf("Expected the user to be stored, but got: %v", err)
+	}
+}
+
+func TestRegisterHandler_ValidationFailures(t *testing.T) {
+	cases := map[string]any{
+		"malformed JSON":   `{"email":`,
+		"missing email":    map[string]string{"username": "alice"},
+		"invalid email":    map[string]string{"email": "not-an-email", "username": "alice"},
+		"display name":     map[string]string{"email": "Alice <alice@example.com>", "username": "alice"},
+		"short username":   map[string]string{"email": "alice@example.com", "username": "al"},
+		"bad username":     map[string]string{"email": "alice@example.com", "username": "alice smith"},
+		"wrong JSON shape": `["alice@example.com"]`,
+	}
+	for name, body := range cases {
+		t.Run(name, func(t *testing.T) {
+			// Arrange
+			handler, _ := newRegisterHandler(&recordingPublisher{})
+			req := httptestutil.NewRequest(t, http.MethodPost, "/register", body)
+
+			// Act
+			rec := httptestutil.Serve(handler, req)
+
+			// Assert
+			httptestutil.AssertStatus(t, rec, http.StatusBadRequest)
+		})
+	}
+}
+
+func TestRegisterHandler_ListsInvalidFields(t *testing.T) {
+	// Arrange
+	handler, _ := newRegisterHandler(&recordingPublisher{})
+	req := httptestutil.NewRequest(t, http.MethodPost, "/register", map[string]string{"email": "not-an-email", "username": "al"})
+
+	// Act
+	rec := httptestutil.Serve(handler, req)
+
+	// Assert
+	httptestutil.AssertStatus(t, rec, http.StatusBadRequest)
+	httptestutil.AssertHeader(t, rec, "Content-Type", "application/json")
+	body := httptestutil.DecodeJSON[struct {
+		Fields []domain.FieldError `json:"fields"`
+	}](t, rec)
+	if len(body.Fields) != 2 || body.Fields[0].Field != "email" || body.Fields[1].Field != "username" {
+		t.Errorf("Expected email and username to be listed, but got %+v", body.Fields)
+	}
+}
+
+func TestRegisterHandler_DuplicateIsConflict(t *testing.T) {
+	// Arrange
+	handler, _ := newRegisterHandler(&recordingPublisher{})
+	body := map[string]string{"email": "alice@example.com", "username": "alice"}
+	httptestutil.AssertStatus(t, httptestutil.Serve(handler, httptestutil.NewRequest(t, http.MethodPost, "/register", body)), http.StatusCreated)
+
+	// Act
+	rec := httptestutil.Serve(handler, httptestutil.NewRequest(t, http.MethodPost, "/register", body))
+
+	// Assert
+	httptestutil.AssertStatus(t, rec, http.StatusConflict)
+}
+
+func TestRegisterHandler_QueueFullIsRetryable(t *testing.T) {
+	// Arrange: the welcome email is queued synchronously on a full pool
+	welcome := newNotifications(t, core.NewWorkerPool(1, 0)).Channel(domain.ChannelEmail)
+	handler, _ := newRegisterHandler(publisherFunc(func(ctx context.Context, events ...domain.DomainEvent) error {
+		return welcome(ctx, events[0])
+	}))
+	req := httptestutil.NewRequest(t, http.MethodPost, "/register", map[string]string{"email": "alice@example.com", "username": "alice"})
+
+	// Act
+	rec := httptestutil.Serve(handler, req)
+
+	// Assert
+	httptestutil.AssertStatus(t, rec, http.StatusServiceUnavailable)
+	httptestutil.AssertHeader(t, rec, "Retry-After", "1")
+}
+
+func TestRegisterHandler_UnexpectedErrorDoesNotLeak(t *testing.T) {
+	// Arrange
+	handler, _ := newRegisterHandler(&recordingPublisher{err: errors.New("broker down")})
+	req := httptestutil.NewRequest(t, http.MethodPost, "/register", map[string]string{"email": "alice@example.com", "username": "alice"})
+
+	// Act
+	rec := httptestutil.Serve(handler, req)
+
+	// Assert
+	httptestutil.AssertStatus(t, rec, http.StatusInternalServerError)
+	if body := rec.Body.String(); body != "internal server error\n" {
+		t.Errorf("Expected a generic body, but got %q", body)
+	}
+}
+
+func TestRegisterHandler_MethodNotAllowed(t *testing.T) {
+	for _, method := range []string{http.MethodGet, http.MethodPut, http.MethodDelete} {
+		t.Run(method, func(t *testing.T) {
+			// Arrange
+			handler, _ := newRegisterHandler(&recordingPublisher{})
+			req := httptestutil.Authenticated(httptestutil.NewRequest(t, method, "/register", nil), "token")
+
+			// Act
+			rec := httptestutil.Serve(handler, req)
+
+			// Assert
+			httptestutil.AssertStatus(t, rec, http.StatusMethodNotAllowed)
+			httptestutil.AssertHeader(t, rec, "Allow", http.MethodPost)
+		})
+	}
+}
+
+// BenchmarkRegisterHandler is a registration end to end: decoding the
+// body, the user service over the in-memory adapters, and the response.
+func BenchmarkRegisterHandler(b *testing.B) {
+	handler, _ := newRegisterHandler(publisherFunc(func(context.Context, ...domain.DomainEvent) error { return nil }))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := range b.N {
+		body := `{"email":"user` + strconv.Itoa(i) + `@example.com","username":"user"}`
+		r := httptest.NewRequest(http.MethodPost, "/register", strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, r)
+		if rec.Code != http.StatusCreated {
+			b.Fatalf("Expected status %d, but got %d: %s", http.StatusCreated, rec.Code, rec.Body.String())
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"clean_go_system/internal/adapter/eventbus"
+	"clean_go_system/internal/adapter/eventcodec"
+	"clean_go_system/internal/adapter/memory"
+	"clean_go_system/internal/domain"
+)
+
+func TestIdempotent_SkipsRedeliveries(t *testing.T) {
+	// Arrange
+	store := memory.NewDedupStore()
+	calls := 0
+	handler := eventbus.Idempotent(store, memory.NewTransactor(), "welcome-email", func(ctx context.Context, e domain.UserRegistered) error {
+		calls++
+		return nil
+	})
+	ctx := eventcodec.WithEventID(context.Background(), "evt-1")
+
+	// Act
+	_ = handler(ctx, domain.UserRegistered{})
+	_ = handler(ctx, domain.UserRegistered{})
+
+	// Assert
+	if calls != 1 {
+		t.Errorf("Expected 1 call, but got %d", calls)
+	}
+}
+
+func TestIdempotent_FailedAttemptCanBeRetried(t *testing.T) {
+	// Arrange
+	store := memory.NewDedupStore()
+	calls := 0
+	handler := eventbus.Idempotent(store, memory.NewTransactor(), "welcome-email", func(ctx context.Context, e domain.UserRegistered) error {
+		calls++
+		if calls == 1 {
+			return errors.New("queue full")
+		}
+		return nil
+	})
+	ctx := eventcodec.WithEventID(context.Background(), "evt-1")
+
+	// Act
+	first := handler(ctx, domain.UserRegistered{})
+	second := handler(ctx, domain.UserRegistered{})
+
+	// Assert
+	if first == nil || second != nil {
+		t.Fatalf("Expected failure then success, but got %v and %v", first, second)
+	}
+	if calls != 2 {
+		t.Errorf("Expected 2 calls, but got %d", calls)
+	}
+}
+
+func TestIdempotent_HandlesEventsPublishedWhileHandlingAnother(t *testing.T) {
+	// Arrange: a registration is relayed, and its handler verifies the user
+	bus := eventbus.New(quietLogger(), 1, 1)
+	var audited []string
+	eventbus.SubscribeAll(bus, eventbus.Sync, eventbus.Idempotent(memory.NewDedupStore(), memory.NewTransactor(), "audit", func(ctx context.Context, e domain.DomainEvent) error {
+		audited = append(audited, e.EventName())
+		return nil
+	}))
+	eventbus.Subscribe(bus, eventbus.Sync, func(ctx context.Context, e domain.UserRegistered) error {
+		return bus.Publish(ctx, domain.UserVerified{UserID: e.UserID})
+	})
+
+	// Act
+	err := bus.Forward(context.Background(), "evt-1", domain.UserRegistered{})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if len(audited) != 2 {
+		t.Errorf("Expected both events audited, but got %v", audited)
+	}
+}
@@ -0,0 +1,46 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"clean_go_system/internal/adapter/memory"
+	"clean_go_system/internal/core"
+	"clean_go_system/pkg/idgen"
+	"github.com/google/uuid"
+)
+
+func TestUUIDv7_IsVersion7AndTimeOrdered(t *testing.T) {
+	// Arrange
+	gen := idgen.UUIDv7{}
+
+	// Act
+	first, second := gen.NewID(), gen.NewID()
+
+	// Assert
+	if first.Version() != 7 {
+		t.Errorf("Expected version 7, but got %d", first.Version())
+	}
+	if bytes.Compare(first[:], second[:]) >= 0 {
+		t.Errorf("Expected %s to sort before %s", first, second)
+	}
+}
+
+func TestUserService_UsesInjectedIDGenerator(t *testing.T) {
+	// Arrange
+	svc := core.NewUserService(memory.NewUserRepository(), &recordingPublisher{}, memory.NewTransactor(), core.WithIDGenerator(idgen.NewSequential()))
+	ctx := context.Background()
+
+	// Act
+	alice, _ := svc.Register(ctx, "alice@example.com", "alice")
+	bob, _ := svc.Register(ctx, "bob@example.com", "bob")
+
+	// Assert
+	if want := uuid.MustParse("00000000-0000-0000-0000-000000000001"); alice.ID != want {
+		t.Errorf("Expected %s, but got %s", want, alice.ID)
+	}
+	if want := uuid.MustParse("00000000-0000-0000-0000-000000000002"); bob.ID != want {
+		t.Errorf("Expected %s, but got %s", want, bob.ID)
+	}
+}
@@ -0,0 +1,330 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"clean_go_system/internal/adapter/authz"
+	"clean_go_system/internal/adapter/filesystem"
+	httpadapter "clean_go_system/internal/adapter/http"
+	"clean_go_system/internal/adapter/imaging"
+	"clean_go_system/internal/core"
+	"clean_go_system/internal/domain"
+	"clean_go_system/internal/httptestutil"
+	"github.com/google/uuid"
+)
+
+// recordingImageQueue keeps resize jobs for the test to run.
+type recordingImageQueue struct {
+	jobs []core.ResizeJob
+	err  error
+}
+
+func (q *recordingImageQueue) Enqueue(_ context.Context, job core.ResizeJob) error {
+	if q.err != nil {
+		return q.err
+	}
+	q.jobs = append(q.jobs, job)
+	return nil
+}
+
+func newImageService(t *testing.T) (*core.ImageService, *filesystem.BlobStore, *recordingImageQueue) {
+	t.Helper()
+	blobs, err := filesystem.NewBlobStore(t.TempDir(), "http://localhost/blobs", []byte("0123456789abcdef0123456789abcdef"))
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	authorizer, err := authz.NewPolicyAuthorizer(authz.DefaultPolicies()...)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	queue := &recordingImageQueue{}
+	return core.NewImageService(blobs, imaging.NewResizer(), queue, authorizer), blobs, queue
+}
+
+func encodeTestImage(t *testing.T, format string, w, h int) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	var err error
+	if format == "png" {
+		err = png.Encode(&buf, img)
+	} else {
+		err = jpeg.Encode(&buf, img, nil)
+	}
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func asUser(id string) context.Context {
+	return core.WithActor(context.Background(), domain.Actor{Kind: domain.ActorUser, ID: id})
+}
+
+func TestImageService_UploadStoresOriginalAndQueuesResize(t *testing.T) {
+	// Arrange
+	images, blobs, queue := newImageService(t)
+	owner := uuid.NewString()
+
+	// Act
+	img, err := images.Upload(asUser(owner), domain.ImageAvatar, owner, bytes.NewReader(encodeTestImage(t, "png", 300, 200)))
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if !strings.HasSuffix(img.ID, ".png") || img.Owner != owner {
+		t.Errorf("Expected a png avatar of %s, but got %+v", owner, img)
+	}
+	if len(queue.jobs) != 1 || queue.jobs[0].Image != img {
+		t.Errorf("Expected one resize job for %+v, but got %+v", img, queue.jobs)
+	}
+	if _, info, err := blobs.Get(context.Background(), img.Key(domain.OriginalVariant)); err != nil || info.ContentType != "image/png" {
+		t.Errorf("Expected the original stored as image/png, but got %+v, %v", info, err)
+	}
+}
+
+func TestImageService_ProcessResizeJobWritesVariants(t *testing.T) {
+	// Arrange
+	images, blobs, queue := newImageService(t)
+	owner := uuid.NewString()
+	img, err := images.Upload(asUser(owner), domain.ImageAvatar, owner, bytes.NewReader(encodeTestImage(t, "jpeg", 600, 300)))
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+
+	// Act
+	err = images.ProcessResizeJob(context.Background(), queue.jobs[0])
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	for name, side := range img.Variants() {
+		r, _, err := blobs.Get(context.Background(), img.Key(name))
+		if err != nil {
+			t.Fatalf("Expected variant %s, but got: %v", name, err)
+		}
+		cfg, format, err := image.DecodeConfig(r)
+		r.Close()
+		if err != nil || format != "jpeg" || cfg.Width != side || cfg.Height != side/2 {
+			t.Errorf("Expected %s to be a %dx%d jpeg, but got %dx%d %s (%v)", name, side, side/2, cfg.Width, cfg.Height, format, err)
+		}
+	}
+}
+
+func TestImageService_UploadRejectsNonImages(t *testing.T) {
+	// Arrange
+	images, _, queue := newImageService(t)
+	owner := uuid.NewString()
+
+	// Act
+	_, err := images.Upload(asUser(owner), domain.ImageAvatar, owner, strings.NewReader("GIF89a not accepted"))
+
+	// Assert
+	if !errors.Is(err, domain.ErrUnsupportedImage) {
+		t.Errorf("Expected error '%v', but got '%v'", domain.ErrUnsupportedImage, err)
+	}
+	if len(queue.jobs) != 0 {
+		t.Errorf("Expected no resize job, but got %d", len(queue.jobs))
+	}
+}
+
+func TestImageService_UploadRejectsOversizedImages(t *testing.T) {
+	// Arrange
+	_, blobs, queue := newImageService(t)
+	var stored []string
+	images := core.NewImageService(&listingBlobStore{BlobStore: blobs, keys: &stored}, imaging.NewResizer(), queue, nil)
+	images.MaxBytes = 1024
+	owner := uuid.NewString()
+	body := encodeTestImage(t, "png", 256, 256)
+
+	// Act
+	_, err := images.Upload(asUser(owner), domain.ImageAvatar, owner, bytes.NewReader(body))
+
+	// Assert
+	if !errors.Is(err, domain.ErrImageTooLarge) {
+		t.Errorf("Expected error '%v', but got '%v'", domain.ErrImageTooLarge, err)
+	}
+	if len(stored) != 1 {
+		t.Fatalf("Expected one attempted original, but got %v", stored)
+	}
+	if _, _, err := blobs.Get(context.Background(), stored[0]); !errors.Is(err, domain.ErrBlobNotFound) {
+		t.Errorf("Expected error '%v', but got '%v'", domain.ErrBlobNotFound, err)
+	}
+}
+
+func TestImageService_UploadOthersAvatarIsForbidden(t *testing.T) {
+	// Arrange
+	images, _, _ := newImageService(t)
+
+	// Act
+	_, avatarErr := images.Upload(asUser(uuid.NewString()), domain.ImageAvatar, uuid.NewString(), bytes.NewReader(encodeTestImage(t, "png", 8, 8)))
+	_, productErr := images.Upload(asUser(uuid.NewString()), domain.ImageProduct, "sku-1", bytes.NewReader(encodeTestImage(t, "png", 8, 8)))
+
+	// Assert
+	if !errors.Is(avatarErr, domain.ErrForbidden) {
+		t.Errorf("Expected error '%v', but got '%v'", domain.ErrForbidden, avatarErr)
+	}
+	if !errors.Is(productErr, domain.ErrForbidden) {
+		t.Errorf("Expected error '%v', but got '%v'", domain.ErrForbidden, productErr)
+	}
+}
+
+func TestImageService_UploadDropsOriginalWhenQueueIsFull(t *testing.T) {
+	// Arrange
+	_, blobs, queue := newImageService(t)
+	queue.err = core.ErrQueueFull
+	owner := uuid.NewString()
+	var stored []string
+	images := core.NewImageService(&listingBlobStore{BlobStore: blobs, keys: &stored}, imaging.NewResizer(), queue, nil)
+
+	// Act
+	_, err := images.Upload(asUser(owner), domain.ImageAvatar, owner, bytes.NewReader(encodeTestImage(t, "png", 8, 8)))
+
+	// Assert
+	if !errors.Is(err, core.ErrQueueFull) {
+		t.Fatalf("Expected error '%v', but got '%v'", core.ErrQueueFull, err)
+	}
+	if len(stored) != 1 {
+		t.Fatalf("Expected one stored original, but got %v", stored)
+	}
+	if _, _, err := blobs.Get(context.Background(), stored[0]); !errors.Is(err, domain.ErrBlobNotFound) {
+		t.Errorf("Expected error '%v', but got '%v'", domain.ErrBlobNotFound, err)
+	}
+}
+
+// listingBlobStore records the keys written through it.
+type listingBlobStore struct {
+	domain.BlobStore
+	keys *[]string
+}
+
+func (s *listingBlobStore) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	*s.keys = append(*s.keys, key)
+	return s.BlobStore.Put(ctx, key, r, size, contentType)
+}
+
+func TestImageService_URL(t *testing.T) {
+	// Arrange
+	images, blobs, _ := newImageService(t)
+	owner := uuid.NewString()
+	img, err := images.Upload(asUser(owner), domain.ImageAvatar, owner, bytes.NewReader(encodeTestImage(t, "png", 8, 8)))
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+
+	// Act
+	signed, expires, err := images.URL(context.Background(), img, domain.OriginalVariant)
+	_, _, unknownVariant := images.URL(context.Background(), img, "huge")
+	_, _, traversal := images.URL(context.Background(), domain.Image{Kind: domain.ImageAvatar, Owner: "..", ID: img.ID}, "small")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if time.Until(expires) <= 0 || time.Until(expires) > images.URLExpiry {
+		t.Errorf("Expected expiry within %s, but got %s", images.URLExpiry, expires)
+	}
+	rec := httptestutil.Serve(http.StripPrefix("/blobs", blobs.Handler()), httptest.NewRequest(http.MethodGet, signed, nil))
+	httptestutil.AssertStatus(t, rec, http.StatusOK)
+	httptestutil.AssertHeader(t, rec, "Content-Type", "image/png")
+	if !errors.Is(unknownVariant, domain.ErrImageNotFound) || !errors.Is(traversal, domain.ErrImageNotFound) {
+		t.Errorf("Expected error '%v', but got '%v' and '%v'", domain.ErrImageNotFound, unknownVariant, traversal)
+	}
+}
+
+func multipartImage(t *testing.T, field string, body []byte) (*bytes.Buffer, string) {
+	t.Helper()
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	_ = mw.WriteField("caption", "ignored")
+	fw, err := mw.CreateFormFile(field, "upload.bin")
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	_, _ = fw.Write(body)
+	_ = mw.Close()
+	return &buf, mw.FormDataContentType()
+}
+
+func TestImageHandler_UploadAvatar(t *testing.T) {
+	// Arrange
+	images, _, queue := newImageService(t)
+	sessions := httpadapter.NewSessionTokens("clean_go_system", []byte("0123456789abcdef0123456789abcdef"), time.Hour)
+	bearer := httpadapter.NewBearerAuth(sessions, nil, nil, quietLogger())
+	handler := httpadapter.NewImageHandler(images, quietLogger())
+	upload := bearer.Middleware(http.HandlerFunc(handler.UploadAvatar))
+	user := &domain.User{ID: uuid.New(), Email: "alice@example.com", Username: "alice"}
+	token, err := sessions.Issue(user)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	request := func(field string, body []byte, contentType string) *http.Request {
+		var r *http.Request
+		if contentType == "" {
+			buf, ct := multipartImage(t, field, body)
+			r = httptest.NewRequest(http.MethodPost, "/me/avatar", buf)
+			r.Header.Set("Content-Type", ct)
+		} else {
+			r = httptest.NewRequest(http.MethodPost, "/me/avatar", bytes.NewReader(body))
+			r.Header.Set("Content-Type", contentType)
+		}
+		return httptestutil.Authenticated(r, token)
+	}
+	png := encodeTestImage(t, "png", 16, 16)
+
+	// Act
+	accepted := httptestutil.Serve(upload, request("image", png, ""))
+	wrongField := httptestutil.Serve(upload, request("file", png, ""))
+	notMultipart := httptestutil.Serve(upload, request("", png, "image/png"))
+	notImage := httptestutil.Serve(upload, request("image", []byte("<svg/>"), ""))
+
+	// Assert
+	httptestutil.AssertStatus(t, accepted, http.StatusAccepted)
+	got := httptestutil.DecodeJSON[map[string]string](t, accepted)
+	if got["owner"] != user.ID.String() || got["kind"] != "avatar" || len(queue.jobs) != 1 {
+		t.Errorf("Expected one queued avatar of %s, but got %v (%d jobs)", user.ID, got, len(queue.jobs))
+	}
+	httptestutil.AssertHeader(t, accepted, "Location", "/images/avatar/"+user.ID.String()+"/"+got["id"])
+	httptestutil.AssertStatus(t, wrongField, http.StatusBadRequest)
+	httptestutil.AssertStatus(t, notMultipart, http.StatusUnsupportedMediaType)
+	httptestutil.AssertStatus(t, notImage, http.StatusUnsupportedMediaType)
+}
+
+func TestImageHandler_UploadOverBodyLimit(t *testing.T) {
+	// Arrange
+	_, blobs, queue := newImageService(t)
+	images := core.NewImageService(blobs, imaging.NewResizer(), queue, nil)
+	handler := httpadapter.NewImageHandler(images, quietLogger())
+	upload := httpadapter.LimitBody(1024, http.HandlerFunc(handler.UploadProductImage))
+	buf, ct := multipartImage(t, "image", encodeTestImage(t, "jpeg", 256, 256))
+	r := httptest.NewRequest(http.MethodPost, "/products/sku-1/images", io.NopCloser(buf))
+	r.ContentLength = -1
+	r.Header.Set("Content-Type", ct)
+	r.SetPathValue("sku", "sku-1")
+
+	// Act
+	rec := httptestutil.Serve(upload, r)
+
+	// Assert
+	httptestutil.AssertStatus(t, rec, http.StatusRequestEntityTooLarge)
+}
@@ -0,0 +1,441 @@
+//go:build integration
+
+// Package integration runs the Postgres adapters against a disposable
+// database started with testcontainers. It needs a Docker daemon:
+//
+//	go test -tags integration ./internal/tests/integration/...
+package integration
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	"clean_go_system/internal/adapter/fieldcrypt"
+	"clean_go_system/internal/adapter/postgres"
+	"clean_go_system/internal/core"
+	"clean_go_system/internal/domain"
+	"clean_go_system/internal/repotest"
+	"github.com/google/uuid"
+	_ "github.com/lib/pq"
+	"github.com/testcontainers/testcontainers-go"
+	tcpostgres "github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+// db is shared by every test; reset empties it between tests.
+var db *sql.DB
+
+func TestMain(m *testing.M) {
+	code, err := run(m)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	os.Exit(code)
+}
+
+func run(m *testing.M) (int, error) {
+	ctx := context.Background()
+
+	// 1. Start Postgres; it logs "ready" once for initdb and once for real
+	container, err := tcpostgres.Run(ctx, "postgres:16-alpine",
+		tcpostgres.WithDatabase("clean_go_system"),
+		tcpostgres.WithUsername("test"),
+		tcpostgres.WithPassword("test"),
+		testcontainers.WithWaitStrategy(wait.ForLog("database system is ready to accept connections").
+			WithOccurrence(2).WithStartupTimeout(time.Minute)),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("start postgres: %w", err)
+	}
+	defer container.Terminate(ctx) //nolint:errcheck // best effort
+
+	// 2. Connect and migrate
+	dsn, err := container.ConnectionString(ctx, "sslmode=disable")
+	if err != nil {
+		return 0, err
+	}
+	if db, err = sql.Open("postgres", dsn); err != nil {
+		return 0, err
+	}
+	defer db.Close()
+	if err := postgres.MigrateUp(ctx, db); err != nil {
+		return 0, fmt.Errorf("migrate: %w", err)
+	}
+
+	return m.Run(), nil
+}
+
+func reset(t *testing.T) {
+	t.Helper()
+	if _, err := db.Exec(`TRUNCATE users, outbox, outbox_dead_letters, processed_events`); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+}
+
+func newUser(email string) domain.User {
+	return domain.User{
+		ID:        uuid.New(),
+		Email:     email,
+		Username:  "alice",
+		Active:    true,
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond), // Postgres precision
+	}
+}
+
+func TestUserRepositoryContract_Postgres(t *testing.T) {
+	repotest.RunUserRepositoryTests(t, func(t *testing.T) domain.UserRepository {
+		reset(t)
+		return postgres.NewPostgresRepository(db)
+	})
+}
+
+func TestUserRepositoryContract_PostgresUnprepared(t *testing.T) {
+	repotest.RunUserRepositoryTests(t, func(t *testing.T) domain.UserRepository {
+		reset(t)
+		repo := postgres.NewPostgresRepository(db)
+		repo.Unprepared = true
+		return repo
+	})
+}
+
+// BenchmarkPostgresRepository compares the hot queries as prepared
+// statements against sending the query text with every call.
+func BenchmarkPostgresRepository(b *testing.B) {
+	ctx := context.Background()
+	for _, unprepared := range []bool{false, true} {
+		name := "prepared"
+		if unprepared {
+			name = "unprepared"
+		}
+		repo := postgres.NewPostgresRepository(db)
+		repo.Unprepared = unprepared
+		alice := newUser(name + "@example.com")
+		if err := repo.Save(ctx, alice); err != nil {
+			b.Fatalf("Expected no error, but got: %v", err)
+		}
+		b.Run(name+"/GetByEmail", func(b *testing.B) {
+			b.ReportAllocs()
+			for range b.N {
+				if _, err := repo.GetByEmail(ctx, alice.Email); err != nil {
+					b.Fatalf("Expected no error, but got: %v", err)
+				}
+			}
+		})
+		b.Run(name+"/Save", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := range b.N {
+				if err := repo.Save(ctx, newUser(fmt.Sprintf("%s-%d-%s@example.com", name, i, uuid.NewString()))); err != nil {
+					b.Fatalf("Expected no error, but got: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestPostgresRepository_SaveThenGetByEmail(t *testing.T) {
+	// Arrange
+	reset(t)
+	repo := postgres.NewPostgresRepository(db)
+	alice := newUser("alice@example.com")
+
+	// Act
+	err := repo.Save(context.Background(), alice)
+	got, getErr := repo.GetByEmail(context.Background(), alice.Email)
+
+	// Assert
+	if err != nil || getErr != nil {
+		t.Fatalf("Expected no errors, but got %v and %v", err, getErr)
+	}
+	if got.ID != alice.ID || got.Username != alice.Username || !got.Active || !got.CreatedAt.Equal(alice.CreatedAt) {
+		t.Errorf("Expected %+v, but got %+v", alice, *got)
+	}
+}
+
+func TestPostgresRepository_GetByEmail_NotFound(t *testing.T) {
+	// Arrange
+	reset(t)
+	repo := postgres.NewPostgresRepository(db)
+
+	// Act
+	_, err := repo.GetByEmail(context.Background(), "nobody@example.com")
+
+	// Assert
+	if !errors.Is(err, domain.ErrUserNotFound) {
+		t.Errorf("Expected error '%v', but got '%v'", domain.ErrUserNotFound, err)
+	}
+}
+
+func TestQueryLatencies_NamedAfterTheRepositoryMethod(t *testing.T) {
+	// Arrange
+	reset(t)
+	repo := postgres.NewPostgresRepository(db)
+	before := postgres.QueryLatencies()["PostgresRepository.GetByEmail"]
+
+	// Act
+	_, err := repo.GetByEmail(context.Background(), "nobody@example.com")
+
+	// Assert
+	if !errors.Is(err, domain.ErrUserNotFound) {
+		t.Fatalf("Expected error '%v', but got '%v'", domain.ErrUserNotFound, err)
+	}
+	after := postgres.QueryLatencies()["PostgresRepository.GetByEmail"]
+	if after.Count != before.Count+1 || after.Errors != before.Errors {
+		t.Errorf("Expected one more query and no error counted, but got %+v after %+v", after, before)
+	}
+	if last := after.Buckets[len(after.Buckets)-1]; last.Count > after.Count {
+		t.Errorf("Expected cumulative buckets up to %d, but got %+v", after.Count, after.Buckets)
+	}
+	if pool := postgres.Pool(db); pool.Open == 0 {
+		t.Errorf("Expected an open connection, but got %+v", pool)
+	}
+}
+
+func TestPostgresRepository_Save_RejectsDuplicateEmail(t *testing.T) {
+	// Arrange
+	reset(t)
+	repo := postgres.NewPostgresRepository(db)
+	ctx := context.Background()
+	if err := repo.Save(ctx, newUser("alice@example.com")); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+
+	// Act
+	err := repo.Save(ctx, newUser("alice@example.com"))
+
+	// Assert
+	if !errors.Is(err, domain.ErrUserExists) {
+		t.Errorf("Expected error '%v', but got '%v'", domain.ErrUserExists, err)
+	}
+}
+
+func TestPostgresRepository_Update_UnknownUser(t *testing.T) {
+	// Arrange
+	reset(t)
+	repo := postgres.NewPostgresRepository(db)
+
+	// Act
+	err := repo.Update(context.Background(), newUser("alice@example.com"))
+
+	// Assert
+	if !errors.Is(err, domain.ErrUserNotFound) {
+		t.Errorf("Expected error '%v', but got '%v'", domain.ErrUserNotFound, err)
+	}
+}
+
+func newEncryptedRepository(t *testing.T) *postgres.PostgresRepository {
+	t.Helper()
+	keys, err := fieldcrypt.NewStaticKeys(bytes.Repeat([]byte("i"), 32), domain.DataKey{Version: 1, Secret: bytes.Repeat([]byte("k"), 32)})
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	repo := postgres.NewPostgresRepository(db)
+	repo.PII = fieldcrypt.NewCodec(keys)
+	return repo
+}
+
+func TestPostgresRepository_EncryptsPII(t *testing.T) {
+	// Arrange: a row from before encryption was turned on, and a new one.
+	reset(t)
+	t.Cleanup(func() { reset(t) }) // encrypted rows block migrating down
+	ctx := context.Background()
+	legacy := newUser("bob@example.com")
+	if err := postgres.NewPostgresRepository(db).Save(ctx, legacy); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	repo := newEncryptedRepository(t)
+	alice := newUser("alice@example.com")
+
+	// Act
+	err := repo.Save(ctx, alice)
+	got, getErr := repo.GetByEmail(ctx, alice.Email)
+	old, oldErr := repo.GetByEmail(ctx, legacy.Email)
+	dupErr := repo.Save(ctx, newUser("alice@example.com"))
+
+	// Assert
+	if err != nil || getErr != nil || oldErr != nil {
+		t.Fatalf("Expected no errors, but got %v, %v and %v", err, getErr, oldErr)
+	}
+	if got.ID != alice.ID || got.Email != alice.Email || got.Username != alice.Username {
+		t.Errorf("Expected %+v, but got %+v", alice, *got)
+	}
+	if old.ID != legacy.ID {
+		t.Errorf("Expected the plaintext row %v, but got %v", legacy.ID, old.ID)
+	}
+	if !errors.Is(dupErr, domain.ErrUserExists) {
+		t.Errorf("Expected error '%v', but got '%v'", domain.ErrUserExists, dupErr)
+	}
+	var email sql.NullString
+	var ciphertext []byte
+	if err := db.QueryRow(`SELECT email, email_ciphertext FROM users WHERE id = $1`, alice.ID).Scan(&email, &ciphertext); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if email.Valid || bytes.Contains(ciphertext, []byte("alice")) {
+		t.Errorf("Expected the email encrypted at rest, but got %q / %q", email.String, ciphertext)
+	}
+}
+
+func TestTransactor_RollsBackOnError(t *testing.T) {
+	// Arrange
+	reset(t)
+	repo := postgres.NewPostgresRepository(db)
+	tx := postgres.NewTransactor(db)
+	ctx := context.Background()
+	alice := newUser("alice@example.com")
+	boom := errors.New("boom")
+
+	// Act
+	err := tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := repo.Save(ctx, alice); err != nil {
+			return err
+		}
+		return boom
+	})
+
+	// Assert
+	if !errors.Is(err, boom) {
+		t.Fatalf("Expected error '%v', but got '%v'", boom, err)
+	}
+	if _, err := repo.GetByID(ctx, alice.ID); !errors.Is(err, domain.ErrUserNotFound) {
+		t.Errorf("Expected the insert to be rolled back, but got: %v", err)
+	}
+}
+
+func TestTransactor_CommitsAndOutboxJoins(t *testing.T) {
+	// Arrange
+	reset(t)
+	repo := postgres.NewPostgresRepository(db)
+	outbox := postgres.NewOutbox(db)
+	tx := postgres.NewTransactor(db)
+	ctx := context.Background()
+	alice := newUser("alice@example.com")
+
+	// Act
+	err := tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := repo.Save(ctx, alice); err != nil {
+			return err
+		}
+		return outbox.Publish(ctx, domain.UserRegistered{UserID: alice.ID, Email: alice.Email, Username: alice.Username, At: alice.CreatedAt})
+	})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	var pending int
+	if err := db.QueryRow(`SELECT count(*) FROM outbox`).Scan(&pending); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if pending != 1 {
+		t.Errorf("Expected 1 outbox row, but got %d", pending)
+	}
+}
+
+func TestUserService_RegistrationEmailsCommitWithTheUser(t *testing.T) {
+	// Arrange
+	reset(t)
+	repo := postgres.NewPostgresRepository(db)
+	welcome := func(ctx context.Context, e domain.DomainEvent) (domain.EmailRequested, bool, error) {
+		r := e.(domain.UserRegistered)
+		return domain.EmailRequested{UserID: r.UserID, Template: "user.registered", Email: r.Email, Subject: "Welcome", Body: "Hi", At: r.At}, true, nil
+	}
+	users := core.NewUserService(repo, postgres.NewOutbox(db), postgres.NewTransactor(db), core.WithRegistrationEmails(welcome))
+	var relayed []domain.DomainEvent
+	relay := postgres.NewOutboxRelay(db, publisherFunc(func(ctx context.Context, events ...domain.DomainEvent) error {
+		relayed = append(relayed, events...)
+		return nil
+	}), time.Second, 10, log.New(io.Discard, "", 0))
+	ctx := context.Background()
+
+	// Act
+	alice, err := users.Register(ctx, "alice@example.com", "alice")
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	n, err := relay.RelayBatch(ctx)
+
+	// Assert
+	if err != nil || n != 2 {
+		t.Fatalf("Expected 2 relayed events, but got %d: %v", n, err)
+	}
+	if email, ok := relayed[1].(domain.EmailRequested); !ok || email.UserID != alice.ID || email.Subject != "Welcome" {
+		t.Errorf("Expected the welcome email after the registration, but got %+v", relayed)
+	}
+}
+
+func TestOutboxRelay_DeadLettersAPoisonEvent(t *testing.T) {
+	// Arrange
+	reset(t)
+	outbox := postgres.NewOutbox(db)
+	ctx := context.Background()
+	poison := domain.UserRegistered{UserID: uuid.New(), Email: "poison@example.com", At: time.Now()}
+	fine := domain.UserRegistered{UserID: uuid.New(), Email: "fine@example.com", At: time.Now()}
+	if err := outbox.Publish(ctx, poison, fine); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	var relayed []domain.DomainEvent
+	relay := postgres.NewOutboxRelay(db, publisherFunc(func(ctx context.Context, events ...domain.DomainEvent) error {
+		if events[0].(domain.UserRegistered).UserID == poison.UserID {
+			return errors.New("rejected")
+		}
+		relayed = append(relayed, events...)
+		return nil
+	}), time.Second, 10, log.New(io.Discard, "", 0))
+	relay.Retry.Attempts = 1
+	relay.Lease = 0
+	relay.MaxAttempts = 2
+
+	// Act
+	first, err := relay.RelayBatch(ctx)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	second, err := relay.RelayBatch(ctx)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+
+	// Assert
+	if first != 1 || second != 0 || len(relayed) != 1 {
+		t.Errorf("Expected the fine event relayed once behind the poison one, but got %d, %d and %v", first, second, relayed)
+	}
+	var pending, dead, attempts int
+	if err := db.QueryRow(`SELECT count(*) FROM outbox WHERE published_at IS NULL`).Scan(&pending); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if err := db.QueryRow(`SELECT count(*), COALESCE(max(attempts), 0) FROM outbox_dead_letters`).Scan(&dead, &attempts); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if pending != 0 || dead != 1 || attempts != 2 {
+		t.Errorf("Expected the poison event dead-lettered after 2 attempts, but got %d pending and %d dead after %d", pending, dead, attempts)
+	}
+}
+
+type publisherFunc func(ctx context.Context, events ...domain.DomainEvent) error
+
+func (f publisherFunc) Publish(ctx context.Context, events ...domain.DomainEvent) error {
+	return f(ctx, events...)
+}
+
+func TestMigrations_DownThenUpAgain(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+
+	// Act
+	down := postgres.MigrateDown(ctx, db, 1000)
+	up := postgres.MigrateUp(ctx, db)
+
+	// Assert
+	if down != nil || up != nil {
+		t.Fatalf("Expected no errors, but got %v and %v", down, up)
+	}
+	reset(t)
+}
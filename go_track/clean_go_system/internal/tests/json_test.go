@@ -0,0 +1,118 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	httpadapter "clean_go_system/internal/adapter/http"
+	"clean_go_system/internal/core"
+	"github.com/google/uuid"
+)
+
+// discardWriter is a ResponseWriter that throws the body away and keeps
+// its headers across requests, so benchmarks measure only the encoding.
+type discardWriter struct {
+	header http.Header
+}
+
+func (w *discardWriter) Header() http.Header         { return w.header }
+func (w *discardWriter) Write(p []byte) (int, error) { return len(p), nil }
+func (w *discardWriter) WriteHeader(int)             {}
+
+type benchUser struct {
+	ID        string    `json:"id"`
+	Email     string    `json:"email"`
+	Username  string    `json:"username"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func benchUsers(n int) []benchUser {
+	users := make([]benchUser, n)
+	for i := range users {
+		users[i] = benchUser{ID: strconv.Itoa(i), Email: "user" + strconv.Itoa(i) + "@example.com", Username: "user", CreatedAt: time.Unix(int64(i), 0)}
+	}
+	return users
+}
+
+func TestWriteJSON_SetsLengthAndKeepsCallerHeaders(t *testing.T) {
+	// Arrange
+	rec := httptest.NewRecorder()
+	rec.Header().Set("Cache-Control", "no-store")
+
+	// Act
+	httpadapter.WriteJSON(rec, http.StatusCreated, map[string]int{"n": 1})
+
+	// Assert
+	if rec.Code != http.StatusCreated || rec.Body.String() != "{\"n\":1}\n" {
+		t.Fatalf("Expected 201 with the body, but got %d: %q", rec.Code, rec.Body.String())
+	}
+	for name, want := range map[string]string{"Content-Type": "application/json", "Content-Length": "8", "Cache-Control": "no-store"} {
+		if got := rec.Header().Get(name); got != want {
+			t.Errorf("Expected %s %q, but got %q", name, want, got)
+		}
+	}
+}
+
+func TestWriteJSON_UnencodableValueAnswers500(t *testing.T) {
+	// Arrange
+	rec := httptest.NewRecorder()
+
+	// Act
+	httpadapter.WriteJSON(rec, http.StatusOK, map[string]any{"ch": make(chan int)})
+
+	// Assert
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status %d, but got %d: %s", http.StatusInternalServerError, rec.Code, rec.Body.String())
+	}
+}
+
+func BenchmarkWriteJSON(b *testing.B) {
+	for _, n := range []int{1, 50} {
+		users := benchUsers(n)
+		b.Run("encoder/"+strconv.Itoa(n), func(b *testing.B) {
+			w := &discardWriter{header: http.Header{}}
+			b.ReportAllocs()
+			for range b.N {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusOK)
+				_ = json.NewEncoder(w).Encode(users)
+			}
+		})
+		b.Run("pooled/"+strconv.Itoa(n), func(b *testing.B) {
+			w := &discardWriter{header: http.Header{}}
+			b.ReportAllocs()
+			for range b.N {
+				httpadapter.WriteJSON(w, http.StatusOK, users)
+			}
+		})
+	}
+}
+
+// BenchmarkEmailJobJSON round-trips the job DTO that crosses the broker.
+func BenchmarkEmailJobJSON(b *testing.B) {
+	job := core.EmailJob{ID: uuid.New(), UserID: uuid.New(), Template: "welcome", Email: "alice@example.com", Subject: "Welcome", Body: strings.Repeat("Hello Alice. ", 40)}
+	raw, err := json.Marshal(job)
+	if err != nil {
+		b.Fatalf("Expected no error, but got: %v", err)
+	}
+	b.Run("encode", func(b *testing.B) {
+		b.ReportAllocs()
+		for range b.N {
+			_, _ = json.Marshal(job)
+		}
+	})
+	b.Run("decode", func(b *testing.B) {
+		b.ReportAllocs()
+		for range b.N {
+			var out core.EmailJob
+			if err := json.Unmarshal(raw, &out); err != nil {
+				b.Fatalf("Expected no error, but got: %v", err)
+			}
+		}
+	})
+}
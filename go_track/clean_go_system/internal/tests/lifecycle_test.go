@@ -0,0 +1,111 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"clean_go_system/pkg/lifecycle"
+)
+
+// recorder collects start/stop calls across components in order.
+type recorder struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (r *recorder) component(name string, startErr error) lifecycle.Func {
+	return lifecycle.Func{
+		OnStart: func(context.Context) error {
+			r.record("start " + name)
+			return startErr
+		},
+		OnStop: func(context.Context) error {
+			r.record("stop " + name)
+			return nil
+		},
+	}
+}
+
+func (r *recorder) record(call string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, call)
+}
+
+func quietLogger() *log.Logger {
+	return log.New(io.Discard, "", 0)
+}
+
+func TestRunner_StopsInReverseOrder(t *testing.T) {
+	// Arrange
+	rec := &recorder{}
+	runner := lifecycle.NewRunner(quietLogger())
+	runner.Add("db", rec.component("db", nil), 0)
+	runner.Add("workers", rec.component("workers", nil), 0)
+	runner.Add("http", rec.component("http", nil), 0)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// Act
+	done := make(chan error, 1)
+	go func() { done <- runner.Run(ctx) }()
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	err := <-done
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	want := []string{"start db", "start workers", "start http", "stop http", "stop workers", "stop db"}
+	if !reflect.DeepEqual(rec.calls, want) {
+		t.Errorf("Expected calls %v, but got %v", want, rec.calls)
+	}
+}
+
+func TestRunner_StartFailureRollsBackStartedComponents(t *testing.T) {
+	// Arrange
+	rec := &recorder{}
+	bootErr := errors.New("boom")
+	runner := lifecycle.NewRunner(quietLogger())
+	runner.Add("db", rec.component("db", nil), 0)
+	runner.Add("http", rec.component("http", bootErr), 0)
+	runner.Add("never", rec.component("never", nil), 0)
+
+	// Act
+	err := runner.Run(context.Background())
+
+	// Assert
+	if !errors.Is(err, bootErr) {
+		t.Fatalf("Expected error '%v', but got '%v'", bootErr, err)
+	}
+	want := []string{"start db", "start http", "stop db"}
+	if !reflect.DeepEqual(rec.calls, want) {
+		t.Errorf("Expected calls %v, but got %v", want, rec.calls)
+	}
+}
+
+func TestRunner_StopTimeoutIsReported(t *testing.T) {
+	// Arrange
+	runner := lifecycle.NewRunner(quietLogger())
+	runner.Add("stuck", lifecycle.Func{
+		OnStop: func(context.Context) error {
+			select {} // ignores its context entirely
+		},
+	}, 20*time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// Act
+	err := runner.Run(ctx)
+
+	// Assert
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected deadline exceeded, but got '%v'", err)
+	}
+}
@@ -0,0 +1,141 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"clean-code-cookbook/go/pkg/page"
+	"clean_go_system/internal/adapter/authz"
+	httpadapter "clean_go_system/internal/adapter/http"
+	"clean_go_system/internal/adapter/memory"
+	"clean_go_system/internal/core"
+	"clean_go_system/internal/domain"
+	"clean_go_system/internal/httptestutil"
+	"clean_go_system/pkg/policy"
+	"github.com/google/uuid"
+)
+
+type listedUsers struct {
+	Items []struct {
+		Email string `json:"email"`
+	} `json:"items"`
+	Next string `json:"next"`
+}
+
+func TestUserService_List_NeedsAPolicy(t *testing.T) {
+	// Arrange
+	repo := memory.NewUserRepository()
+	defaults, err := authz.NewPolicyAuthorizer(authz.DefaultPolicies()...)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	admins, err := authz.NewPolicyAuthorizer(policy.Policy{ID: "admins-list", Effect: policy.Allow, Actions: []string{core.ActionListUsers}, Resources: []string{"user"},
+		When: []policy.Condition{{Attr: "subject.role", Op: "eq", Value: "admin"}}})
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	admin := core.WithActor(context.Background(), domain.Actor{Kind: domain.ActorUser, ID: uuid.NewString(), Attrs: map[string]string{"role": "admin"}})
+
+	// Act
+	_, deniedErr := core.NewUserService(repo, &recordingPublisher{}, memory.NewTransactor(), core.WithAuthorizer(defaults), core.WithLister(repo)).
+		List(admin, page.Request{})
+	_, allowedErr := core.NewUserService(repo, &recordingPublisher{}, memory.NewTransactor(), core.WithAuthorizer(admins), core.WithLister(repo)).
+		List(admin, page.Request{})
+	_, unsupportedErr := core.NewUserService(repo, &recordingPublisher{}, memory.NewTransactor()).List(admin, page.Request{})
+
+	// Assert
+	if !errors.Is(deniedErr, domain.ErrForbidden) {
+		t.Errorf("Expected error '%v' without a listing policy, but got '%v'", domain.ErrForbidden, deniedErr)
+	}
+	if allowedErr != nil {
+		t.Errorf("Expected no error, but got: %v", allowedErr)
+	}
+	if !errors.Is(unsupportedErr, errors.ErrUnsupported) {
+		t.Errorf("Expected error '%v' without a lister, but got '%v'", errors.ErrUnsupported, unsupportedErr)
+	}
+}
+
+func TestListUsersHandler_PagesThroughUsers(t *testing.T) {
+	// Arrange
+	repo := memory.NewUserRepository()
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, email := range []string{"ann@example.com", "ben@example.com", "cat@example.com"} {
+		at := start.Add(time.Duration(i) * time.Minute)
+		u := domain.User{ID: uuid.New(), Email: email, Username: "user", Active: true, CreatedAt: at, UpdatedAt: at}
+		if err := repo.Save(context.Background(), u); err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+	}
+	svc := core.NewUserService(repo, &recordingPublisher{}, memory.NewTransactor(), core.WithLister(repo))
+	handler := http.HandlerFunc(httpadapter.NewHandler(svc, quietLogger()).List)
+
+	// Act
+	first := httptestutil.Serve(handler, httptestutil.NewRequest(t, http.MethodGet, "/users?size=2&sort=-created_at", nil))
+	firstPage := httptestutil.DecodeJSON[listedUsers](t, first)
+	second := httptestutil.Serve(handler, httptestutil.NewRequest(t, http.MethodGet, "/users?size=2&sort=-created_at&after="+firstPage.Next, nil))
+	secondPage := httptestutil.DecodeJSON[listedUsers](t, second)
+	badSort := httptestutil.Serve(handler, httptestutil.NewRequest(t, http.MethodGet, "/users?sort=email", nil))
+	badCursor := httptestutil.Serve(handler, httptestutil.NewRequest(t, http.MethodGet, "/users?after=nonsense", nil))
+
+	// Assert
+	httptestutil.AssertStatus(t, first, http.StatusOK)
+	httptestutil.AssertStatus(t, second, http.StatusOK)
+	if len(firstPage.Items) != 2 || firstPage.Items[0].Email != "cat@example.com" || firstPage.Next == "" {
+		t.Errorf("Expected cat and ben then a cursor, but got %+v", firstPage)
+	}
+	if len(secondPage.Items) != 1 || secondPage.Items[0].Email != "ann@example.com" || secondPage.Next != "" {
+		t.Errorf("Expected ann alone on the last page, but got %+v", secondPage)
+	}
+	httptestutil.AssertStatus(t, badSort, http.StatusBadRequest)
+	httptestutil.AssertStatus(t, badCursor, http.StatusBadRequest)
+}
+
+func TestPageEach_FollowsCursorsPageByPage(t *testing.T) {
+	// Arrange
+	repo := memory.NewUserRepository()
+	ids := saveUsers(t, context.Background(), repo, 5)
+	var sizes []int
+	fetch := func(ctx context.Context, req page.Request) (page.Page[domain.User], error) {
+		p, err := repo.ListUsers(ctx, req)
+		sizes = append(sizes, len(p.Items))
+		return p, err
+	}
+
+	// Act
+	var seen []uuid.UUID
+	err := page.Each(context.Background(), page.Request{Size: 2}, fetch, func(u domain.User) error {
+		seen = append(seen, u.ID)
+		return nil
+	})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if len(seen) != len(ids) || !slices.Equal(sizes, []int{2, 2, 1}) {
+		t.Errorf("Expected 5 users in pages of 2, 2 and 1, but got %d in %v", len(seen), sizes)
+	}
+}
+
+func BenchmarkCursor(b *testing.B) {
+	at, id := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).Format(time.RFC3339Nano), uuid.NewString()
+	cursor := page.NewCursor(at, id)
+	b.Run("encode", func(b *testing.B) {
+		b.ReportAllocs()
+		for range b.N {
+			_ = page.NewCursor(at, id)
+		}
+	})
+	b.Run("decode", func(b *testing.B) {
+		b.ReportAllocs()
+		for range b.N {
+			if _, err := cursor.Keys(2); err != nil {
+				b.Fatalf("Expected no error, but got: %v", err)
+			}
+		}
+	})
+}
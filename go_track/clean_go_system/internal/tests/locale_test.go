@@ -0,0 +1,204 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	httpadapter "clean_go_system/internal/adapter/http"
+	"clean_go_system/internal/adapter/memory"
+	"clean_go_system/internal/adapter/notify"
+	"clean_go_system/internal/core"
+	"clean_go_system/internal/domain"
+	"clean_go_system/internal/httptestutil"
+)
+
+func TestLocaleFallbacks_Chain(t *testing.T) {
+	// Arrange
+	fallbacks, err := domain.ParseLocaleFallbacks("pt-BR:pt-pt,es; ca:es")
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	cases := map[string][]string{
+		"pt-BR": {"pt-br", "pt-pt", "es", "pt", "en"},
+		"ca-ES": {"ca-es", "ca", "es", "en"},
+		"en-GB": {"en-gb", "en"},
+		"":      {"en"},
+	}
+	for locale, want := range cases {
+		t.Run(locale, func(t *testing.T) {
+			// Act
+			got := fallbacks.Chain(locale, "en")
+
+			// Assert
+			if !slices.Equal(got, want) {
+				t.Errorf("Expected %v, but got %v", want, got)
+			}
+		})
+	}
+}
+
+func TestParseLocaleFallbacks_RejectsBadEntries(t *testing.T) {
+	for _, s := range []string{"pt-br", "pt-br:", "English:en", "pt-br:pt,Português"} {
+		t.Run(s, func(t *testing.T) {
+			// Act
+			_, err := domain.ParseLocaleFallbacks(s)
+
+			// Assert
+			if err == nil {
+				t.Error("Expected an error, but got none")
+			}
+		})
+	}
+}
+
+func TestEmailTemplates_FollowLocaleFallbacks(t *testing.T) {
+	// Arrange: there is no Catalan welcome email, but a Spanish one
+	templates := emailTemplates(t)
+	event := domain.UserRegistered{Email: "alice@example.com", Username: "alice"}
+	before, _ := templates.Render("user.registered", "ca", event)
+	templates.Fallbacks = domain.LocaleFallbacks{"ca": {"es"}}
+
+	// Act
+	after, err := templates.Render("user.registered", "ca", event)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if before.Subject != "Welcome aboard, alice" || after.Subject != "Te damos la bienvenida, alice" {
+		t.Errorf("Expected English without the fallback and Spanish with it, but got %q and %q", before.Subject, after.Subject)
+	}
+}
+
+func TestTemplates_PluralFollowsTheLanguage(t *testing.T) {
+	// Arrange
+	file := func(forms string) *fstest.MapFile {
+		return &fstest.MapFile{Data: []byte(`{{define "subject"}}{{plural . ` + forms + `}}{{end}}{{define "body"}}-{{end}}`)}
+	}
+	templates, err := notify.LoadTemplates(fstest.MapFS{
+		"files/en.tmpl": file(`"# file" "# files"`),
+		"files/fr.tmpl": file(`"# fichier" "# fichiers"`),
+		"files/ru.tmpl": file(`"# файл" "# файла" "# файлов"`),
+		"files/ja.tmpl": file(`"#件"`),
+	}, "en")
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	cases := []struct {
+		locale string
+		n      int
+		want   string
+	}{
+		{"en", 1, "1 file"}, {"en", 0, "0 files"}, {"en", 2, "2 files"},
+		{"fr", 0, "0 fichier"}, {"fr", 1, "1 fichier"}, {"fr", 2, "2 fichiers"},
+		{"ru", 1, "1 файл"}, {"ru", 3, "3 файла"}, {"ru", 5, "5 файлов"}, {"ru", 11, "11 файлов"}, {"ru", 21, "21 файл"}, {"ru", 22, "22 файла"},
+		{"ja", 1, "1件"}, {"ja", 7, "7件"},
+	}
+	for _, c := range cases {
+		// Act
+		msg, err := templates.Render("files", c.locale, c.n)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+		if msg.Subject != c.want {
+			t.Errorf("Expected %s %d to read %q, but got %q", c.locale, c.n, c.want, msg.Subject)
+		}
+	}
+}
+
+func TestEmailTemplates_VerificationInTheUsersLanguage(t *testing.T) {
+	// Arrange
+	templates := emailTemplates(t)
+	email := func(hours int) core.VerificationEmail {
+		return core.VerificationEmail{Username: "alice", Email: "alice@example.com", Token: "abc.def", ValidHours: hours}
+	}
+
+	// Act
+	german, deErr := templates.Render("email.verification", "de-AT", email(48))
+	english, enErr := templates.Render("email.verification", "en", email(1))
+
+	// Assert
+	if deErr != nil || enErr != nil {
+		t.Fatalf("Expected no errors, but got: %v, %v", deErr, enErr)
+	}
+	if german.Subject != "Bestätige deine E-Mail-Adresse" || !strings.Contains(german.Body, "innerhalb von 48 Stunden") {
+		t.Errorf("Expected the German email, but got %q:\n%s", german.Subject, german.Body)
+	}
+	if !strings.Contains(english.Body, "within an hour") {
+		t.Errorf("Expected the singular, but got:\n%s", english.Body)
+	}
+}
+
+func TestUser_ChangeLocale(t *testing.T) {
+	// Arrange
+	u := registeredUser(t)
+
+	// Act
+	changeErr := u.ChangeLocale("pt_BR", time.Now())
+	sameErr := u.ChangeLocale("pt-br", time.Now())
+	badErr := u.ChangeLocale("Portuguese!", time.Now())
+
+	// Assert
+	if changeErr != nil || sameErr != nil {
+		t.Fatalf("Expected no errors, but got: %v, %v", changeErr, sameErr)
+	}
+	if !errors.Is(badErr, domain.ErrInvalidLocale) {
+		t.Errorf("Expected error '%v', but got '%v'", domain.ErrInvalidLocale, badErr)
+	}
+	events := u.PullEvents()
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events, but got %d", len(events))
+	}
+	if e, ok := events[0].(domain.UserLocaleChanged); !ok || e.Locale != "pt-br" || u.Locale != "pt-br" {
+		t.Errorf("Expected the change to pt-br to be recorded, but got %+v", events[0])
+	}
+	if e, ok := events[1].(domain.UserUpdated); !ok || e.Locale != "pt-br" {
+		t.Errorf("Expected UserUpdated reading pt-br, but got %+v", events[1])
+	}
+}
+
+func TestSetLocaleHandler(t *testing.T) {
+	// Arrange
+	repo := memory.NewUserRepository()
+	publisher := &recordingPublisher{}
+	users := core.NewUserService(repo, publisher, memory.NewTransactor())
+	user, err := users.Register(context.Background(), "alice@example.com", "alice")
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	sessions := httpadapter.NewSessionTokens("clean_go_system", []byte("0123456789abcdef0123456789abcdef"), time.Hour)
+	token, err := sessions.Issue(user)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	bearer := httpadapter.NewBearerAuth(sessions, nil, nil, quietLogger())
+	setLocale := bearer.Middleware(http.HandlerFunc(httpadapter.NewHandler(users, quietLogger()).SetLocale))
+	put := func(body string) *http.Request {
+		return httptestutil.Authenticated(httptestutil.NewRequest(t, http.MethodPut, "/me/locale", body), token)
+	}
+
+	// Act
+	ok := httptestutil.Serve(setLocale, put(`{"locale": "de-AT"}`))
+	bad := httptestutil.Serve(setLocale, put(`{"locale": "Deutsch!"}`))
+	anonymous := httptestutil.Serve(setLocale, httptestutil.NewRequest(t, http.MethodPut, "/me/locale", `{"locale": "de"}`))
+
+	// Assert
+	httptestutil.AssertStatus(t, ok, http.StatusOK)
+	if got := httptestutil.DecodeJSON[map[string]string](t, ok)["locale"]; got != "de-at" {
+		t.Errorf("Expected the profile in de-at, but got %q", got)
+	}
+	httptestutil.AssertStatus(t, bad, http.StatusBadRequest)
+	httptestutil.AssertStatus(t, anonymous, http.StatusUnauthorized)
+	stored, err := repo.GetByID(context.Background(), user.ID)
+	if err != nil || stored.Locale != "de-at" {
+		t.Errorf("Expected the locale stored, but got %+v, %v", stored, err)
+	}
+}
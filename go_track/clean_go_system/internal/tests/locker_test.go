@@ -0,0 +1,58 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"clean_go_system/internal/adapter/memory"
+	"clean_go_system/internal/domain"
+	"clean_go_system/pkg/clock"
+)
+
+func TestMemoryLocker_IsExclusiveUntilReleased(t *testing.T) {
+	// Arrange
+	locker := memory.NewLocker()
+	ctx := context.Background()
+	lock, err := locker.Acquire(ctx, "outbox-relay", time.Minute)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+
+	// Act
+	_, contended := locker.Acquire(ctx, "outbox-relay", time.Minute)
+	_ = lock.Release(ctx)
+	_, afterRelease := locker.Acquire(ctx, "outbox-relay", time.Minute)
+
+	// Assert
+	if !errors.Is(contended, domain.ErrLockHeld) {
+		t.Errorf("Expected error '%v', but got '%v'", domain.ErrLockHeld, contended)
+	}
+	if afterRelease != nil {
+		t.Errorf("Expected the lock to be free after release, but got: %v", afterRelease)
+	}
+}
+
+func TestMemoryLocker_LeaseExpires(t *testing.T) {
+	// Arrange
+	locker := memory.NewLocker()
+	clk := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	locker.Clock = clk
+	ctx := context.Background()
+	stale, _ := locker.Acquire(ctx, "nightly", time.Minute)
+	clk.Advance(time.Minute + time.Second)
+
+	// Act
+	fresh, err := locker.Acquire(ctx, "nightly", time.Minute)
+	_ = stale.Release(ctx)
+	_, contended := locker.Acquire(ctx, "nightly", time.Minute)
+
+	// Assert
+	if err != nil || fresh == nil {
+		t.Fatalf("Expected to take over an expired lease, but got: %v", err)
+	}
+	if !errors.Is(contended, domain.ErrLockHeld) {
+		t.Errorf("Expected the stale owner's release to leave the new lease, but got '%v'", contended)
+	}
+}
@@ -0,0 +1,129 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"clean_go_system/pkg/logger"
+)
+
+func TestLoggerMiddleware_RecordsStatusAndSize(t *testing.T) {
+	// Arrange
+	var out bytes.Buffer
+	level := new(slog.LevelVar)
+	handler := logger.Middleware(logger.NewStructured(&out, level), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+		_, _ = w.Write([]byte("short and stout"))
+	}))
+
+	// Act
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/pot", nil))
+
+	// Assert
+	var record struct {
+		Msg    string `json:"msg"`
+		Path   string `json:"path"`
+		Status int    `json:"status"`
+		Bytes  int    `json:"bytes"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &record); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if record.Msg != "request" || record.Path != "/pot" || record.Status != http.StatusTeapot || record.Bytes != 15 {
+		t.Errorf("Expected a request record for /pot, 418, 15 bytes, but got %+v", record)
+	}
+}
+
+func TestLoggerMiddleware_DisabledLevelLeavesTheWriterAlone(t *testing.T) {
+	// Arrange
+	var out bytes.Buffer
+	level := new(slog.LevelVar)
+	level.Set(slog.LevelWarn)
+	rec := httptest.NewRecorder()
+	var got http.ResponseWriter
+	handler := logger.Middleware(logger.NewStructured(&out, level), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = w
+	}))
+
+	// Act
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	// Assert
+	if got != http.ResponseWriter(rec) {
+		t.Errorf("Expected the writer passed through unwrapped, but got %T", got)
+	}
+	if out.Len() != 0 {
+		t.Errorf("Expected nothing logged, but got %s", out.String())
+	}
+}
+
+func TestParseLevel_AcceptsTheConfiguredNames(t *testing.T) {
+	for name, want := range map[string]slog.Level{"debug": slog.LevelDebug, "": slog.LevelInfo, "WARN": slog.LevelWarn, "error": slog.LevelError} {
+		got, err := logger.ParseLevel(name)
+		if err != nil || got != want {
+			t.Errorf("Expected %q to be %v, but got %v, %v", name, want, got, err)
+		}
+	}
+	if _, err := logger.ParseLevel("loud"); err == nil {
+		t.Error("Expected an error for an unknown level, but got none")
+	}
+}
+
+// BenchmarkLogger_Disabled compares the ways of writing a record below
+// the level: only LogAttrs with a pre-bound logger costs nothing.
+func BenchmarkLogger_Disabled(b *testing.B) {
+	level := new(slog.LevelVar)
+	level.Set(slog.LevelWarn)
+	l := logger.NewStructured(io.Discard, level).With(slog.String("component", "http"))
+	ctx := context.Background()
+	b.Run("printf", func(b *testing.B) {
+		std := log.New(io.Discard, "", 0)
+		b.ReportAllocs()
+		for i := range b.N {
+			std.Printf("request %s %s %d", http.MethodGet, "/users", i)
+		}
+	})
+	b.Run("sprintf", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := range b.N {
+			l.Info(fmt.Sprintf("request %s %s %d", http.MethodGet, "/users", i))
+		}
+	})
+	b.Run("any", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := range b.N {
+			l.Info("request", "method", http.MethodGet, "path", "/users", "status", i)
+		}
+	})
+	b.Run("attrs", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := range b.N {
+			l.LogAttrs(ctx, slog.LevelInfo, "request", slog.String("method", http.MethodGet), slog.String("path", "/users"), slog.Int("status", i))
+		}
+	})
+}
+
+func BenchmarkLoggerMiddleware(b *testing.B) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	r := httptest.NewRequest(http.MethodGet, "/users", nil)
+	w := &discardWriter{header: http.Header{}}
+	for _, lvl := range []slog.Level{slog.LevelWarn, slog.LevelInfo} {
+		b.Run(lvl.String(), func(b *testing.B) {
+			level := new(slog.LevelVar)
+			level.Set(lvl)
+			handler := logger.Middleware(logger.NewStructured(io.Discard, level), ok)
+			b.ReportAllocs()
+			for range b.N {
+				handler.ServeHTTP(w, r)
+			}
+		})
+	}
+}
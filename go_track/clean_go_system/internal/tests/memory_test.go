@@ -0,0 +1,33 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"clean_go_system/internal/adapter/memory"
+	"clean_go_system/internal/domain"
+	"github.com/google/uuid"
+)
+
+func TestMemoryUserRepository_EmailsStayUnique(t *testing.T) {
+	// Arrange
+	repo := memory.NewUserRepository()
+	ctx := context.Background()
+	alice := domain.User{ID: uuid.New(), Email: "alice@example.com"}
+	bob := domain.User{ID: uuid.New(), Email: "bob@example.com"}
+	_ = repo.Save(ctx, alice)
+	_ = repo.Save(ctx, bob)
+
+	// Act
+	bob.Email = alice.Email
+	err := repo.Update(ctx, bob)
+
+	// Assert
+	if !errors.Is(err, domain.ErrUserExists) {
+		t.Fatalf("Expected error '%v', but got '%v'", domain.ErrUserExists, err)
+	}
+	if got, _ := repo.GetByEmail(ctx, "bob@example.com"); got == nil || got.ID != bob.ID {
+		t.Errorf("Expected bob's row to be unchanged, but got %+v", got)
+	}
+}
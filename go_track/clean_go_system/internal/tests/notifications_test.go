@@ -0,0 +1,164 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	httpadapter "clean_go_system/internal/adapter/http"
+	"clean_go_system/internal/adapter/notify"
+	"clean_go_system/internal/core"
+	"clean_go_system/internal/domain"
+	"clean_go_system/internal/httptestutil"
+)
+
+// newNotifications sends the welcome email, from the built-in templates,
+// to queue.
+func newNotifications(t *testing.T, queue core.EmailQueue) *core.Notifications {
+	t.Helper()
+	templates, err := notify.BuiltinTemplates("en")
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	return &core.Notifications{
+		Templates: notify.Chain{emailTemplates(t), templates},
+		Notifiers: map[domain.Channel]domain.Notifier{domain.ChannelEmail: notify.NewEmail(queue, 10*time.Millisecond)},
+		Routes:    map[string][]domain.Channel{"user.registered": {domain.ChannelEmail}},
+	}
+}
+
+func TestEmailTemplates_FallBackToLanguageThenDefault(t *testing.T) {
+	// Arrange
+	templates := emailTemplates(t)
+	event := domain.UserRegistered{Email: "alice@example.com", Username: "alice"}
+
+	// Act
+	spanish, esErr := templates.Render("user.registered", "es-MX", event)
+	english, frErr := templates.Render("user.registered", "fr", event)
+	_, missingErr := templates.Render("user.unknown", "en", event)
+
+	// Assert
+	if esErr != nil || frErr != nil {
+		t.Fatalf("Expected no errors, but got: %v, %v", esErr, frErr)
+	}
+	if spanish.Subject != "Te damos la bienvenida, alice" {
+		t.Errorf("Expected the Spanish subject, but got %q", spanish.Subject)
+	}
+	if english.Subject != "Welcome aboard, alice" {
+		t.Errorf("Expected the English subject, but got %q", english.Subject)
+	}
+	if !errors.Is(missingErr, domain.ErrNoTemplate) {
+		t.Errorf("Expected error '%v', but got '%v'", domain.ErrNoTemplate, missingErr)
+	}
+}
+
+func TestLoadTemplates_RejectsBrokenTemplates(t *testing.T) {
+	cases := map[string]fstest.MapFS{
+		"no body":      {"user.registered/en.tmpl": {Data: []byte(`{{define "subject"}}Hi{{end}}`)}},
+		"bad syntax":   {"user.registered/en.tmpl": {Data: []byte(`{{define "subject"}}{{.Username{{end}}`)}},
+		"not a locale": {"user.registered/English.tmpl": {Data: []byte(`{{define "subject"}}Hi{{end}}{{define "body"}}Hi{{end}}`)}},
+	}
+	for name, fsys := range cases {
+		t.Run(name, func(t *testing.T) {
+			// Act
+			_, err := notify.LoadTemplates(fsys, "en")
+
+			// Assert
+			if err == nil {
+				t.Error("Expected an error, but got none")
+			}
+		})
+	}
+}
+
+func TestNotifications_SendRoutedEventsInTheirLocale(t *testing.T) {
+	// Arrange
+	queue := &recordingQueue{}
+	email := newNotifications(t, queue).Channel(domain.ChannelEmail)
+
+	// Act
+	err := email(context.Background(), domain.UserRegistered{Email: "alice@example.com", Username: "alice", Locale: "de-AT"})
+	unroutedErr := email(context.Background(), domain.UserDeactivated{Email: "alice@example.com"})
+
+	// Assert
+	if err != nil || unroutedErr != nil {
+		t.Fatalf("Expected no errors, but got: %v, %v", err, unroutedErr)
+	}
+	if len(queue.jobs) != 1 {
+		t.Fatalf("Expected one email, but got %d", len(queue.jobs))
+	}
+	if job := queue.jobs[0]; job.Email != "alice@example.com" || job.Subject != "Willkommen an Bord, alice" || job.HTML == "" {
+		t.Errorf("Expected the German welcome email to alice, but got %+v", job)
+	}
+}
+
+func TestWebhookNotifier_PostsTheMessage(t *testing.T) {
+	// Arrange
+	var got map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+	hook := notify.NewWebhook(server.URL)
+
+	// Act
+	err := hook.Notify(context.Background(), domain.Notification{
+		Channel: domain.ChannelWebhook,
+		Event:   "user.registered",
+		Message: domain.Message{Subject: "Welcome aboard, alice", Body: "Hi alice"},
+	})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if got["event"] != "user.registered" || got["subject"] != "Welcome aboard, alice" || got["body"] != "Hi alice" {
+		t.Errorf("Unexpected delivery %v", got)
+	}
+}
+
+func TestPreferredLocale(t *testing.T) {
+	cases := map[string]string{
+		"":                       "",
+		"*":                      "",
+		"de":                     "de",
+		"pt-BR, en;q=0.8":        "pt-br",
+		"en;q=0.5, es-MX;q=0.9":  "es-mx",
+		"fr;q=oops, it;q=0.1":    "it",
+		"not a tag, nl-BE;q=0.3": "nl-be",
+	}
+	for header, want := range cases {
+		t.Run(header, func(t *testing.T) {
+			// Act
+			got := httpadapter.PreferredLocale(header)
+
+			// Assert
+			if got != want {
+				t.Errorf("Expected %q, but got %q", want, got)
+			}
+		})
+	}
+}
+
+func TestRegisterHandler_RecordsTheLocale(t *testing.T) {
+	// Arrange
+	publisher := &recordingPublisher{}
+	handler, _ := newRegisterHandler(publisher)
+	req := httptestutil.NewRequest(t, http.MethodPost, "/register", map[string]string{"email": "alice@example.com", "username": "alice"})
+	req.Header.Set("Accept-Language", "es-MX, en;q=0.5")
+
+	// Act
+	rec := httptestutil.Serve(handler, req)
+
+	// Assert
+	httptestutil.AssertStatus(t, rec, http.StatusCreated)
+	if e, ok := publisher.events[0].(domain.UserRegistered); !ok || e.Locale != "es-mx" {
+		t.Errorf("Expected UserRegistered in es-mx, but got %+v", publisher.events[0])
+	}
+}
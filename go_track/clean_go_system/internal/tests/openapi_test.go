@@ -0,0 +1,115 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	httpadapter "clean_go_system/internal/adapter/http"
+	"clean_go_system/internal/httptestutil"
+)
+
+type validationFailure struct {
+	Error  string `json:"error"`
+	Fields []struct {
+		Field string `json:"field"`
+		Rule  string `json:"rule"`
+	} `json:"fields"`
+}
+
+// reached answers 204 and records that a request got past the validator.
+type reached struct{ count int }
+
+func (h *reached) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	h.count++
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func newOpenAPI(t *testing.T) *httpadapter.OpenAPI {
+	t.Helper()
+	spec, err := httpadapter.NewOpenAPI()
+	if err != nil {
+		t.Fatalf("Expected the embedded document to load, but got: %v", err)
+	}
+	return spec
+}
+
+func TestOpenAPI_ServesTheDocumentAndDocs(t *testing.T) {
+	// Arrange
+	spec := newOpenAPI(t)
+
+	// Act
+	doc := httptestutil.Serve(http.HandlerFunc(spec.ServeSpec), httptestutil.NewRequest(t, http.MethodGet, "/openapi.json", nil))
+	docs := httptestutil.Serve(http.HandlerFunc(spec.ServeDocs), httptestutil.NewRequest(t, http.MethodGet, "/docs", nil))
+
+	// Assert
+	httptestutil.AssertStatus(t, doc, http.StatusOK)
+	var parsed struct {
+		OpenAPI string                    `json:"openapi"`
+		Paths   map[string]map[string]any `json:"paths"`
+	}
+	if err := json.Unmarshal(doc.Body.Bytes(), &parsed); err != nil {
+		t.Fatalf("Expected JSON, but got: %v", err)
+	}
+	if !strings.HasPrefix(parsed.OpenAPI, "3.") || parsed.Paths["/register"]["post"] == nil || parsed.Paths["/users/{id}"]["get"] == nil {
+		t.Errorf("Expected an OpenAPI 3 document with register and users/{id}, but got version %q and %d paths", parsed.OpenAPI, len(parsed.Paths))
+	}
+	httptestutil.AssertStatus(t, docs, http.StatusOK)
+	if !strings.Contains(docs.Body.String(), `"/openapi.json"`) {
+		t.Errorf("Expected the docs page to load /openapi.json, but got %s", docs.Body.String())
+	}
+}
+
+func TestOpenAPI_RejectsRequestsThatBreakTheDocument(t *testing.T) {
+	// Arrange
+	next := &reached{}
+	h := newOpenAPI(t).Validate(next)
+
+	// Act
+	body := httptestutil.Serve(h, httptestutil.NewRequest(t, http.MethodPost, "/register", map[string]any{"email": 42, "admin": true}))
+	query := httptestutil.Serve(h, httptestutil.NewRequest(t, http.MethodGet, "/users?size=0&sort=email", nil))
+
+	// Assert
+	httptestutil.AssertStatus(t, body, http.StatusBadRequest)
+	got := map[string]bool{}
+	for _, f := range httptestutil.DecodeJSON[validationFailure](t, body).Fields {
+		got[f.Field] = true
+	}
+	// An unknown property is reported against the object that holds it.
+	if !got["email"] || !got["username"] || !got["body"] {
+		t.Errorf("Expected email, username and the body to be reported, but got %v", got)
+	}
+	httptestutil.AssertStatus(t, query, http.StatusBadRequest)
+	got = map[string]bool{}
+	for _, f := range httptestutil.DecodeJSON[validationFailure](t, query).Fields {
+		got[f.Field] = true
+	}
+	if !got["size"] || !got["sort"] {
+		t.Errorf("Expected size and sort to be reported, but got %v", got)
+	}
+	if next.count != 0 {
+		t.Errorf("Expected no request to reach the handler, but %d did", next.count)
+	}
+}
+
+func TestOpenAPI_PassesValidAndUndocumentedRequests(t *testing.T) {
+	// Arrange
+	next := &reached{}
+	h := newOpenAPI(t).Validate(next)
+
+	// Act
+	for _, req := range []*http.Request{
+		httptestutil.NewRequest(t, http.MethodPost, "/register", map[string]string{"email": "alice@example.com", "username": "alice"}),
+		httptestutil.NewRequest(t, http.MethodGet, "/users?size=10&sort=-created_at", nil),
+		httptestutil.NewRequest(t, http.MethodGet, "/debug/vars", nil),
+		httptestutil.NewRequest(t, http.MethodPatch, "/register", nil),
+	} {
+		httptestutil.AssertStatus(t, httptestutil.Serve(h, req), http.StatusNoContent)
+	}
+
+	// Assert
+	if next.count != 4 {
+		t.Errorf("Expected all 4 requests to reach the handler, but %d did", next.count)
+	}
+}
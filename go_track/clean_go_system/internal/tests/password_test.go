@@ -0,0 +1,278 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"clean-code-cookbook/go/pkg/signing"
+	"clean_go_system/internal/adapter/authsvc"
+	httpadapter "clean_go_system/internal/adapter/http"
+	"clean_go_system/internal/adapter/memory"
+	"clean_go_system/internal/core"
+	"clean_go_system/internal/domain"
+	"clean_go_system/internal/httptestutil"
+	"clean_go_system/pkg/clock"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// recordingQueue is a core.EmailQueue that keeps every job, or fails
+// them all with err.
+type recordingQueue struct {
+	mu   sync.Mutex
+	jobs []core.EmailJob
+	err  error
+}
+
+func (q *recordingQueue) Enqueue(ctx context.Context, job core.EmailJob) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.err != nil {
+		return q.err
+	}
+	q.jobs = append(q.jobs, job)
+	return nil
+}
+
+// recordingCredentials stands in for the auth service.
+type recordingCredentials struct {
+	hashes map[uuid.UUID][]byte
+}
+
+func (c *recordingCredentials) SetPassword(ctx context.Context, user domain.User, hash []byte) error {
+	c.hashes[user.ID] = hash
+	return nil
+}
+
+type passwordFixture struct {
+	svc         *core.PasswordService
+	credentials *recordingCredentials
+	sessions    *core.SessionService
+	queue       *recordingQueue
+	clock       *clock.Fake
+	alice       domain.User
+}
+
+func newPasswordFixture(t *testing.T) *passwordFixture {
+	t.Helper()
+	users := memory.NewUserRepository()
+	alice := domain.User{ID: uuid.New(), Email: "alice@example.com", Username: "alice", Active: true}
+	if err := users.Save(context.Background(), alice); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	f := &passwordFixture{
+		credentials: &recordingCredentials{hashes: make(map[uuid.UUID][]byte)},
+		sessions:    core.NewSessionService(memory.NewSessionRepository()),
+		queue:       &recordingQueue{},
+		clock:       clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)),
+		alice:       alice,
+	}
+	f.svc = core.NewPasswordService(users, f.credentials, memory.NewPasswordResetRepository(), memory.NewTransactor(), emailTemplates(t), f.queue)
+	f.svc.Clock, f.svc.Sessions = f.clock, f.sessions
+	return f
+}
+
+// request asks for a reset for email and waits until it is handled.
+func (f *passwordFixture) request(t *testing.T, email string) error {
+	t.Helper()
+	err := f.svc.RequestPasswordReset(context.Background(), email)
+	if stopErr := f.svc.Stop(context.Background()); stopErr != nil {
+		t.Fatalf("Expected no error, but got: %v", stopErr)
+	}
+	return err
+}
+
+// requestToken asks for a reset for alice and returns the mailed token.
+func (f *passwordFixture) requestToken(t *testing.T) string {
+	t.Helper()
+	if err := f.request(t, f.alice.Email); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if len(f.queue.jobs) == 0 {
+		t.Fatal("Expected a reset email, but none was queued")
+	}
+	return resetToken.FindString(f.queue.jobs[len(f.queue.jobs)-1].Body)
+}
+
+var resetToken = regexp.MustCompile(`[0-9a-f-]{36}\.[A-Za-z0-9_-]+`)
+
+func TestPasswordService_ResetWithMailedToken(t *testing.T) {
+	// Arrange
+	f := newPasswordFixture(t)
+	token := f.requestToken(t)
+
+	// Act
+	err := f.svc.ResetPassword(context.Background(), token, "correct horse")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if f.queue.jobs[0].Email != f.alice.Email {
+		t.Errorf("Expected the email to go to alice, but got %q", f.queue.jobs[0].Email)
+	}
+	hash, ok := f.credentials.hashes[f.alice.ID]
+	if !ok || bcrypt.CompareHashAndPassword(hash, []byte("correct horse")) != nil {
+		t.Errorf("Expected a bcrypt hash of the new password, but got %q", hash)
+	}
+}
+
+func TestPasswordService_RejectsBadTokens(t *testing.T) {
+	cases := map[string]func(t *testing.T, f *passwordFixture, token string) string{
+		"malformed": func(*testing.T, *passwordFixture, string) string { return "not-a-token" },
+		"unknown":   func(*testing.T, *passwordFixture, string) string { return uuid.NewString() + ".c2VjcmV0" },
+		"wrong secret": func(_ *testing.T, _ *passwordFixture, token string) string {
+			id, _, _ := strings.Cut(token, ".")
+			return id + ".c2VjcmV0"
+		},
+		"expired": func(_ *testing.T, f *passwordFixture, token string) string {
+			f.clock.Advance(31 * time.Minute)
+			return token
+		},
+		"already used": func(t *testing.T, f *passwordFixture, token string) string {
+			if err := f.svc.ResetPassword(context.Background(), token, "first password"); err != nil {
+				t.Fatalf("Expected no error, but got: %v", err)
+			}
+			return token
+		},
+	}
+	for name, tamper := range cases {
+		t.Run(name, func(t *testing.T) {
+			// Arrange
+			f := newPasswordFixture(t)
+			token := tamper(t, f, f.requestToken(t))
+
+			// Act
+			err := f.svc.ResetPassword(context.Background(), token, "correct horse")
+
+			// Assert
+			if !errors.Is(err, domain.ErrInvalidResetToken) {
+				t.Errorf("Expected error '%v', but got '%v'", domain.ErrInvalidResetToken, err)
+			}
+		})
+	}
+}
+
+func TestPasswordService_ResetEndsEverySession(t *testing.T) {
+	// Arrange
+	f := newPasswordFixture(t)
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if _, _, err := f.sessions.Create(ctx, f.alice.ID, core.Device{}); err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+	}
+	token := f.requestToken(t)
+
+	// Act
+	err := f.svc.ResetPassword(ctx, token, "correct horse")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	live, err := f.sessions.List(ctx, f.alice.ID)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if len(live) != 0 {
+		t.Errorf("Expected no live sessions, but got %d", len(live))
+	}
+}
+
+func TestPasswordService_UnknownEmailSendsNothing(t *testing.T) {
+	// Arrange
+	f := newPasswordFixture(t)
+
+	// Act
+	err := f.request(t, "mallory@example.com")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if len(f.queue.jobs) != 0 {
+		t.Errorf("Expected no email, but got %v", f.queue.jobs)
+	}
+}
+
+func TestPasswordService_FullQueueLooksLikeUnknownEmail(t *testing.T) {
+	// Arrange
+	f := newPasswordFixture(t)
+	f.queue.err = core.ErrQueueFull
+
+	// Act
+	err := f.request(t, f.alice.Email)
+
+	// Assert
+	if err != nil {
+		t.Errorf("Expected no error, so the answer does not tell who has an account, but got: %v", err)
+	}
+}
+
+func TestPasswordHandler(t *testing.T) {
+	// Arrange
+	f := newPasswordFixture(t)
+	handler := httpadapter.NewPasswordHandler(f.svc, quietLogger())
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /password/reset-request", handler.RequestReset)
+	mux.HandleFunc("POST /password/reset", handler.Reset)
+
+	// Act
+	requested := httptestutil.Serve(mux, httptestutil.NewRequest(t, http.MethodPost, "/password/reset-request", map[string]string{"email": f.alice.Email}))
+	if err := f.svc.Stop(context.Background()); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	token := resetToken.FindString(f.queue.jobs[0].Body)
+	weak := httptestutil.Serve(mux, httptestutil.NewRequest(t, http.MethodPost, "/password/reset", map[string]string{"token": token, "password": "short"}))
+	reset := httptestutil.Serve(mux, httptestutil.NewRequest(t, http.MethodPost, "/password/reset", map[string]string{"token": token, "password": "correct horse"}))
+	replayed := httptestutil.Serve(mux, httptestutil.NewRequest(t, http.MethodPost, "/password/reset", map[string]string{"token": token, "password": "correct horse"}))
+
+	// Assert
+	httptestutil.AssertStatus(t, requested, http.StatusAccepted)
+	httptestutil.AssertStatus(t, weak, http.StatusBadRequest)
+	httptestutil.AssertStatus(t, reset, http.StatusNoContent)
+	httptestutil.AssertStatus(t, replayed, http.StatusBadRequest)
+}
+
+func TestCredentialStore_PutsASignedHashToTheAuthService(t *testing.T) {
+	// Arrange
+	keys := signing.NewKeyring(signing.Key{ID: "users", Secret: []byte("shared")})
+	var got struct {
+		UserID       string
+		Email        string `json:"email"`
+		PasswordHash []byte `json:"password_hash"`
+	}
+	mux := http.NewServeMux()
+	mux.Handle("PUT /credentials/{user_id}", keys.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got.UserID = r.PathValue("user_id")
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(http.StatusNoContent)
+	})))
+	auth := httptest.NewServer(mux)
+	defer auth.Close()
+	alice := domain.User{ID: uuid.New(), Email: "alice@example.com"}
+
+	// Act
+	err := authsvc.NewCredentialStore(auth.URL+"/", keys).SetPassword(context.Background(), alice, []byte("$2a$10$hash"))
+	forged := authsvc.NewCredentialStore(auth.URL, signing.NewKeyring(signing.Key{ID: "users", Secret: []byte("guess")})).SetPassword(context.Background(), alice, []byte("$2a$10$hash"))
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if got.UserID != alice.ID.String() || got.Email != alice.Email || string(got.PasswordHash) != "$2a$10$hash" {
+		t.Errorf("Unexpected request %+v", got)
+	}
+	if forged == nil {
+		t.Error("Expected a wrongly signed request to fail, but it succeeded")
+	}
+}
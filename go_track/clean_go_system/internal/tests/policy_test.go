@@ -0,0 +1,157 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"clean_go_system/internal/adapter/authz"
+	httpadapter "clean_go_system/internal/adapter/http"
+	"clean_go_system/internal/adapter/memory"
+	"clean_go_system/internal/core"
+	"clean_go_system/internal/domain"
+	"clean_go_system/internal/httptestutil"
+	"clean_go_system/pkg/policy"
+	"github.com/google/uuid"
+)
+
+func TestPolicyEngine_Evaluate(t *testing.T) {
+	engine, err := policy.New(
+		policy.Policy{ID: "support-reads", Effect: policy.Allow, Actions: []string{"users:*"}, Resources: []string{"user"},
+			When: []policy.Condition{{Attr: "subject.team", Op: "in", Value: "support,security"}}},
+		policy.Policy{ID: "no-admin-reads", Effect: policy.Deny, Actions: []string{"users:read"}, Resources: []string{"*"},
+			When: []policy.Condition{{Attr: "resource.admin", Op: "eq", Value: "true"}}},
+		policy.Policy{ID: "scoped-keys", Effect: policy.Allow, Actions: []string{"users:read"}, Resources: []string{"user"},
+			When: []policy.Condition{{Attr: "subject.scopes", Op: "contains", Value: "users:read"}}},
+	)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+
+	cases := []struct {
+		name     string
+		subject  policy.Attributes
+		action   string
+		resource policy.Attributes
+		want     policy.Decision
+	}{
+		{"allowed by attribute", policy.Attributes{"team": "support"}, "users:read", policy.Attributes{"type": "user"}, policy.Decision{Allowed: true, Policy: "support-reads"}},
+		{"action wildcard", policy.Attributes{"team": "security"}, "users:deactivate", policy.Attributes{"type": "user"}, policy.Decision{Allowed: true, Policy: "support-reads"}},
+		{"deny wins", policy.Attributes{"team": "support"}, "users:read", policy.Attributes{"type": "user", "admin": "true"}, policy.Decision{Allowed: false, Policy: "no-admin-reads"}},
+		{"list attribute", policy.Attributes{"scopes": "keys:admin,users:read"}, "users:read", policy.Attributes{"type": "user"}, policy.Decision{Allowed: true, Policy: "scoped-keys"}},
+		{"default deny", policy.Attributes{"team": "sales"}, "users:read", policy.Attributes{"type": "user"}, policy.Decision{}},
+		{"other resource type", policy.Attributes{"team": "support"}, "users:read", policy.Attributes{"type": "api_key"}, policy.Decision{}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Act
+			got := engine.Evaluate(policy.Request{Subject: tc.subject, Action: tc.action, Resource: tc.resource})
+
+			// Assert
+			if got != tc.want {
+				t.Errorf("Expected %+v, but got %+v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestPolicyLoad_RejectsInvalidPolicies(t *testing.T) {
+	cases := map[string]string{
+		"unknown op":     `[{"id":"p","effect":"allow","actions":["a"],"resources":["r"],"when":[{"attr":"subject.x","op":"like","value":"y"}]}]`,
+		"unknown effect": `[{"id":"p","effect":"maybe","actions":["a"],"resources":["r"]}]`,
+		"bare attribute": `[{"id":"p","effect":"allow","actions":["a"],"resources":["r"],"when":[{"attr":"x","op":"eq","value":"y"}]}]`,
+	}
+	for name, raw := range cases {
+		t.Run(name, func(t *testing.T) {
+			// Act
+			policies, err := policy.Load(strings.NewReader(raw))
+			if err == nil {
+				_, err = policy.New(policies...)
+			}
+
+			// Assert
+			if err == nil {
+				t.Error("Expected an error, but got nil")
+			}
+		})
+	}
+}
+
+func newAuthorizedUserService(t *testing.T) (*core.UserService, domain.User, domain.User) {
+	t.Helper()
+	repo := memory.NewUserRepository()
+	alice := domain.User{ID: uuid.New(), Email: "alice@example.com", Username: "alice", Active: true, CreatedAt: time.Now()}
+	bob := domain.User{ID: uuid.New(), Email: "bob@example.com", Username: "bob", Active: true, CreatedAt: time.Now()}
+	for _, u := range []domain.User{alice, bob} {
+		if err := repo.Save(context.Background(), u); err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+	}
+	authorizer, err := authz.NewPolicyAuthorizer(authz.DefaultPolicies()...)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	return core.NewUserService(repo, &recordingPublisher{}, memory.NewTransactor(), core.WithAuthorizer(authorizer)), alice, bob
+}
+
+func TestUserService_Get_Authorizes(t *testing.T) {
+	svc, alice, bob := newAuthorizedUserService(t)
+	asAlice := core.WithActor(context.Background(), domain.Actor{Kind: domain.ActorUser, ID: alice.ID.String()})
+
+	t.Run("own profile", func(t *testing.T) {
+		// Act
+		user, err := svc.Get(asAlice, alice.ID)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+		if user.ID != alice.ID {
+			t.Errorf("Expected alice, but got %v", user.ID)
+		}
+	})
+
+	t.Run("someone else's profile", func(t *testing.T) {
+		// Act
+		_, err := svc.Get(asAlice, bob.ID)
+
+		// Assert
+		if !errors.Is(err, domain.ErrForbidden) {
+			t.Errorf("Expected error '%v', but got '%v'", domain.ErrForbidden, err)
+		}
+	})
+
+	t.Run("no actor", func(t *testing.T) {
+		// Act
+		_, err := svc.Get(context.Background(), alice.ID)
+
+		// Assert
+		if !errors.Is(err, domain.ErrForbidden) {
+			t.Errorf("Expected error '%v', but got '%v'", domain.ErrForbidden, err)
+		}
+	})
+}
+
+func TestGetUserHandler_Forbidden(t *testing.T) {
+	// Arrange
+	svc, alice, bob := newAuthorizedUserService(t)
+	sessions := httpadapter.NewSessionTokens("clean_go_system", []byte("0123456789abcdef0123456789abcdef"), time.Hour)
+	token, err := sessions.Issue(&alice)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle("GET /users/{id}", httpadapter.NewBearerAuth(sessions, nil, nil, quietLogger()).
+		Middleware(http.HandlerFunc(httpadapter.NewHandler(svc, quietLogger()).Get)))
+
+	// Act
+	own := httptestutil.Serve(mux, httptestutil.Authenticated(httptestutil.NewRequest(t, http.MethodGet, "/users/"+alice.ID.String(), nil), token))
+	other := httptestutil.Serve(mux, httptestutil.Authenticated(httptestutil.NewRequest(t, http.MethodGet, "/users/"+bob.ID.String(), nil), token))
+
+	// Assert
+	httptestutil.AssertStatus(t, own, http.StatusOK)
+	httptestutil.AssertStatus(t, other, http.StatusForbidden)
+}
@@ -0,0 +1,143 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	httpadapter "clean_go_system/internal/adapter/http"
+	"clean_go_system/internal/adapter/memory"
+	"clean_go_system/internal/domain"
+	"clean_go_system/internal/httptestutil"
+	"clean_go_system/pkg/clock"
+)
+
+// rateLimiterFunc adapts a function to domain.RateLimiter.
+type rateLimiterFunc func(ctx context.Context, key string, limit domain.RateLimit) (bool, time.Duration, error)
+
+func (f rateLimiterFunc) Allow(ctx context.Context, key string, limit domain.RateLimit) (bool, time.Duration, error) {
+	return f(ctx, key, limit)
+}
+
+func TestMemoryRateLimiter_BurstThenSteadyRate(t *testing.T) {
+	// Arrange
+	limiter := memory.NewRateLimiter()
+	clk := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	limiter.Clock = clk
+	limit := domain.RateLimit{Count: 6, Period: time.Minute, Burst: 3} // one per 10s
+	ctx := context.Background()
+
+	// Act
+	var burst int
+	for i := 0; i < 5; i++ {
+		if ok, _, _ := limiter.Allow(ctx, "alice", limit); ok {
+			burst++
+		}
+	}
+	_, retryAfter, _ := limiter.Allow(ctx, "alice", limit)
+	other, _, _ := limiter.Allow(ctx, "bob", limit)
+	clk.Advance(10 * time.Second)
+	refilled, _, _ := limiter.Allow(ctx, "alice", limit)
+	again, _, _ := limiter.Allow(ctx, "alice", limit)
+
+	// Assert
+	if burst != 3 {
+		t.Errorf("Expected a burst of 3, but got %d", burst)
+	}
+	if retryAfter != 10*time.Second {
+		t.Errorf("Expected to wait 10s, but got %v", retryAfter)
+	}
+	if !other {
+		t.Error("Expected bob's budget to be separate from alice's")
+	}
+	if !refilled || again {
+		t.Errorf("Expected exactly one request after 10s, but got %v then %v", refilled, again)
+	}
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	// Arrange
+	limit := domain.RateLimit{Count: 1, Period: time.Minute, Burst: 1}
+	handler := httpadapter.NewRateLimit(memory.NewRateLimiter(), "register", limit, quietLogger()).
+		Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	fromIP := func(ip string) *http.Request {
+		req := httptestutil.NewRequest(t, http.MethodPost, "/register", nil)
+		req.RemoteAddr = ip + ":41000"
+		return req
+	}
+
+	// Act
+	first := httptestutil.Serve(handler, fromIP("10.0.0.1"))
+	second := httptestutil.Serve(handler, fromIP("10.0.0.1"))
+	otherCaller := httptestutil.Serve(handler, fromIP("10.0.0.2"))
+
+	// Assert
+	httptestutil.AssertStatus(t, first, http.StatusOK)
+	httptestutil.AssertStatus(t, second, http.StatusTooManyRequests)
+	httptestutil.AssertHeader(t, second, "Retry-After", "60")
+	httptestutil.AssertStatus(t, otherCaller, http.StatusOK)
+}
+
+func TestRateLimitMiddleware_FailsOpen(t *testing.T) {
+	// Arrange
+	broken := rateLimiterFunc(func(context.Context, string, domain.RateLimit) (bool, time.Duration, error) {
+		return false, 0, errors.New("redis down")
+	})
+	handler := httpadapter.NewRateLimit(broken, "register", domain.RateLimit{Count: 1, Period: time.Minute}, quietLogger()).
+		Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	// Act
+	rec := httptestutil.Serve(handler, httptestutil.NewRequest(t, http.MethodPost, "/register", nil))
+
+	// Assert
+	httptestutil.AssertStatus(t, rec, http.StatusOK)
+}
+
+func TestRateLimitMiddleware_PerTenant(t *testing.T) {
+	// Arrange
+	limit := domain.RateLimit{Count: 1, Period: time.Minute, Burst: 1}
+	rl := httpadapter.NewRateLimit(memory.NewRateLimiter(), "register", limit, quietLogger())
+	rl.PerTenant = map[domain.TenantID]domain.RateLimit{"globex": {}}
+	handler := rl.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	in := func(tenant domain.TenantID) *http.Request {
+		req := httptestutil.NewRequest(t, http.MethodPost, "/register", nil)
+		req.RemoteAddr = "10.0.0.1:41000"
+		return req.WithContext(domain.WithTenant(req.Context(), tenant))
+	}
+
+	// Act
+	acme := httptestutil.Serve(handler, in("acme"))
+	initech := httptestutil.Serve(handler, in("initech"))
+	httptestutil.Serve(handler, in("globex"))
+	globex := httptestutil.Serve(handler, in("globex"))
+
+	// Assert
+	httptestutil.AssertStatus(t, acme, http.StatusOK)
+	httptestutil.AssertStatus(t, initech, http.StatusOK) // same IP, another tenant's budget
+	httptestutil.AssertStatus(t, globex, http.StatusOK)  // limit lifted for globex
+}
+
+func TestRateLimitMiddleware_SeparateBucketsPerEndpoint(t *testing.T) {
+	// Arrange
+	limiter := memory.NewRateLimiter()
+	limit := domain.RateLimit{Count: 1, Period: time.Minute, Burst: 1}
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	list := httpadapter.NewRateLimit(limiter, "users.list", limit, quietLogger()).Middleware(ok)
+	graphql := httpadapter.NewRateLimit(limiter, "graphql", limit, quietLogger()).Middleware(ok)
+	as := func(method, path string) *http.Request {
+		req := httptestutil.NewRequest(t, method, path, nil)
+		req.RemoteAddr = "10.0.0.1:41000"
+		return req
+	}
+
+	// Act
+	httptestutil.Serve(list, as(http.MethodGet, "/users"))
+	listed := httptestutil.Serve(list, as(http.MethodGet, "/users"))
+	queried := httptestutil.Serve(graphql, as(http.MethodPost, "/graphql"))
+
+	// Assert
+	httptestutil.AssertStatus(t, listed, http.StatusTooManyRequests)
+	httptestutil.AssertStatus(t, queried, http.StatusOK) // same caller, the graphql budget
+}
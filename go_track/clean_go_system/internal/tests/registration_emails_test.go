@@ -0,0 +1,150 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"clean_go_system/internal/adapter/eventbus"
+	"clean_go_system/internal/adapter/memory"
+	"clean_go_system/internal/core"
+	"clean_go_system/internal/domain"
+)
+
+// newRegistration registers users whose welcome and verification emails
+// are published, with the user, to publisher.
+func newRegistration(t *testing.T, publisher domain.EventPublisher) *core.UserService {
+	t.Helper()
+	notifications := newNotifications(t, &recordingQueue{})
+	notifications.Transactional = []string{"user.registered"}
+	var verification *core.EmailVerification
+	verify := func(ctx context.Context, e domain.DomainEvent) (domain.EmailRequested, bool, error) {
+		return verification.Compose(ctx, e)
+	}
+	users := core.NewUserService(memory.NewUserRepository(), publisher, memory.NewTransactor(), core.WithRegistrationEmails(notifications.Compose, verify))
+	verification = core.NewEmailVerification(users, emailTemplates(t), []byte("0123456789abcdef0123456789abcdef"))
+	return users
+}
+
+func TestUserService_Register_PublishesEmailsWithTheUser(t *testing.T) {
+	// Arrange
+	publisher := &recordingPublisher{}
+	users := newRegistration(t, publisher)
+
+	// Act
+	ctx := domain.WithLocale(context.Background(), "de")
+	alice, err := users.Register(ctx, "alice@example.com", "alice")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if len(publisher.events) != 3 {
+		t.Fatalf("Expected the registration and two emails, but got %+v", publisher.events)
+	}
+	var subjects []string
+	for _, e := range publisher.events[1:] {
+		email, ok := e.(domain.EmailRequested)
+		if !ok || email.UserID != alice.ID || email.Email != "alice@example.com" || email.HTML == "" {
+			t.Fatalf("Expected an email to alice, but got %+v", e)
+		}
+		subjects = append(subjects, email.Subject)
+	}
+	if want := []string{"Willkommen an Bord, alice", "Bestätige deine E-Mail-Adresse"}; fmt.Sprint(subjects) != fmt.Sprint(want) {
+		t.Errorf("Expected the German welcome and verification emails %v, but got %v", want, subjects)
+	}
+}
+
+func TestUserService_Register_UnrenderableEmailKeepsTheUserOut(t *testing.T) {
+	// Arrange
+	boom := errors.New("template broken")
+	repo := memory.NewUserRepository()
+	publisher := &recordingPublisher{}
+	broken := func(context.Context, domain.DomainEvent) (domain.EmailRequested, bool, error) {
+		return domain.EmailRequested{}, false, boom
+	}
+	users := core.NewUserService(repo, publisher, memory.NewTransactor(), core.WithRegistrationEmails(broken))
+
+	// Act
+	_, err := users.Register(context.Background(), "alice@example.com", "alice")
+
+	// Assert
+	if !errors.Is(err, boom) {
+		t.Fatalf("Expected error '%v', but got '%v'", boom, err)
+	}
+	if _, err := repo.GetByEmail(context.Background(), "alice@example.com"); !errors.Is(err, domain.ErrUserNotFound) || len(publisher.events) != 0 {
+		t.Errorf("Expected nothing stored or published, but got %v and %+v", err, publisher.events)
+	}
+}
+
+func TestNotifications_EmailChannelSkipsTransactionalEvents(t *testing.T) {
+	// Arrange
+	queue := &recordingQueue{}
+	notifications := newNotifications(t, queue)
+	notifications.Transactional = []string{"user.registered"}
+
+	// Act
+	err := notifications.Channel(domain.ChannelEmail)(context.Background(), domain.UserRegistered{Email: "alice@example.com", Username: "alice"})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if len(queue.jobs) != 0 {
+		t.Errorf("Expected the welcome email left to registration, but got %+v", queue.jobs)
+	}
+}
+
+func TestEnqueueRequested_RelayedEmailsReachTheWorkers(t *testing.T) {
+	// Arrange: the relay delivers the email twice after a crash
+	queue := &recordingQueue{}
+	bus := eventbus.New(quietLogger(), 1, 1)
+	eventbus.Subscribe(bus, eventbus.Sync, eventbus.Idempotent(memory.NewDedupStore(), memory.NewTransactor(), "send-email", core.EnqueueRequested(queue, time.Second)))
+	email := domain.EmailRequested{Template: "email.verification", Email: "alice@example.com", Subject: "Confirm your email address", Body: "token", At: time.Now()}
+	ctx := context.Background()
+
+	// Act
+	first := bus.Forward(ctx, "evt-1", email)
+	again := bus.Forward(ctx, "evt-1", email)
+
+	// Assert
+	if first != nil || again != nil {
+		t.Fatalf("Expected no errors, but got: %v, %v", first, again)
+	}
+	if len(queue.jobs) != 1 || queue.jobs[0].Template != "email.verification" || queue.jobs[0].Body != "token" {
+		t.Errorf("Expected one verification job, but got %+v", queue.jobs)
+	}
+}
+
+func TestEmailRequested_KeepsItsContentOutOfLogs(t *testing.T) {
+	// Arrange
+	email := domain.EmailRequested{Template: "email.verification", Email: "alice@example.com", Body: "secret-token", At: time.Now()}
+
+	// Act
+	line := fmt.Sprintf("%+v", email)
+
+	// Assert
+	if strings.Contains(line, "secret-token") || strings.Contains(line, "alice@example.com") {
+		t.Errorf("Expected no content in %q", line)
+	}
+}
+
+func TestExcept_LeavesNamedEventsOut(t *testing.T) {
+	// Arrange
+	broker := &recordingPublisher{}
+	publisher := eventbus.Except(broker, "email.requested")
+
+	// Act
+	err := publisher.Publish(context.Background(), domain.UserRegistered{Email: "alice@example.com"}, domain.EmailRequested{Email: "alice@example.com"})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if len(broker.events) != 1 || broker.events[0].EventName() != "user.registered" {
+		t.Errorf("Expected only the registration, but got %+v", broker.events)
+	}
+}
@@ -0,0 +1,30 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"clean_go_system/internal/adapter/cache"
+	"clean_go_system/internal/adapter/memory"
+	"clean_go_system/internal/domain"
+	"clean_go_system/internal/repotest"
+)
+
+func TestUserRepositoryContract_Memory(t *testing.T) {
+	repotest.RunUserRepositoryTests(t, func(t *testing.T) domain.UserRepository {
+		return memory.NewUserRepository()
+	})
+}
+
+func TestUserRepositoryContract_SQLite(t *testing.T) {
+	repotest.RunUserRepositoryTests(t, func(t *testing.T) domain.UserRepository {
+		_, repo := newSQLiteUserService(t)
+		return repo
+	})
+}
+
+func TestUserRepositoryContract_Cached(t *testing.T) {
+	repotest.RunUserRepositoryTests(t, func(t *testing.T) domain.UserRepository {
+		return cache.NewRepository(memory.NewUserRepository(), cache.NewUserCache(memory.NewStore[domain.User](time.Minute), quietLogger()))
+	})
+}
@@ -0,0 +1,122 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"clean-code-cookbook/go/pkg/retry"
+)
+
+func TestRetry_StopsOnSuccess(t *testing.T) {
+	// Arrange
+	calls := 0
+	var hooks []int
+	policy := retry.Policy{
+		Attempts: 5,
+		Backoff:  retry.Constant(time.Millisecond),
+		OnRetry:  func(attempt int, err error, wait time.Duration) { hooks = append(hooks, attempt) },
+	}
+
+	// Act
+	err := retry.Do(context.Background(), policy, func(context.Context) error {
+		calls++
+		if calls < 3 {
+			return errors.New("flaky")
+		}
+		return nil
+	})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if calls != 3 || len(hooks) != 2 || hooks[1] != 2 {
+		t.Errorf("Expected 3 calls and hooks for attempts 1 and 2, but got %d calls and %v", calls, hooks)
+	}
+}
+
+func TestRetry_ReturnsLastErrorWhenExhausted(t *testing.T) {
+	// Arrange
+	down := errors.New("down")
+	calls := 0
+
+	// Act
+	err := retry.Do(context.Background(), retry.Policy{Attempts: 3}, func(context.Context) error {
+		calls++
+		return down
+	})
+
+	// Assert
+	if !errors.Is(err, down) {
+		t.Fatalf("Expected error '%v', but got '%v'", down, err)
+	}
+	if calls != 3 {
+		t.Errorf("Expected 3 calls, but got %d", calls)
+	}
+}
+
+func TestRetry_RetryIfStopsOnPermanentErrors(t *testing.T) {
+	// Arrange
+	permanent := errors.New("not found")
+	calls := 0
+	policy := retry.Policy{Attempts: 5, RetryIf: func(err error) bool { return !errors.Is(err, permanent) }}
+
+	// Act
+	err := retry.Do(context.Background(), policy, func(context.Context) error {
+		calls++
+		return permanent
+	})
+
+	// Assert
+	if !errors.Is(err, permanent) || calls != 1 {
+		t.Errorf("Expected one call failing with '%v', but got %d calls and '%v'", permanent, calls, err)
+	}
+}
+
+func TestRetry_ContextEndsTheWait(t *testing.T) {
+	// Arrange
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	policy := retry.Policy{Attempts: 3, Backoff: retry.Constant(time.Hour)}
+
+	// Act
+	err := retry.Do(ctx, policy, func(context.Context) error { return errors.New("down") })
+
+	// Assert
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected error '%v', but got '%v'", context.DeadlineExceeded, err)
+	}
+}
+
+func TestBackoff_ExponentialIsCapped(t *testing.T) {
+	// Arrange
+	backoff := retry.Exponential(100*time.Millisecond, time.Second)
+
+	// Act
+	waits := []time.Duration{backoff(1), backoff(2), backoff(4), backoff(10)}
+
+	// Assert
+	want := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 800 * time.Millisecond, time.Second}
+	for i := range want {
+		if waits[i] != want[i] {
+			t.Errorf("Expected wait %d to be %v, but got %v", i, want[i], waits[i])
+		}
+	}
+}
+
+func TestBackoff_JitterStaysWithinHalfAndFull(t *testing.T) {
+	// Arrange
+	backoff := retry.Jitter(retry.Constant(100 * time.Millisecond))
+
+	for i := 0; i < 100; i++ {
+		// Act
+		wait := backoff(1)
+
+		// Assert
+		if wait < 50*time.Millisecond || wait >= 100*time.Millisecond {
+			t.Fatalf("Expected a wait in [50ms, 100ms), but got %v", wait)
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"clean_go_system/pkg/clock"
+	"clean_go_system/pkg/scheduler"
+)
+
+func TestCron_NextMatchesSpec(t *testing.T) {
+	// epoch is Monday 2024-01-01 00:00 UTC
+	cases := []struct {
+		spec  string
+		after time.Time
+		want  time.Time
+	}{
+		{"*/15 * * * *", epoch.Add(7 * time.Minute), epoch.Add(15 * time.Minute)},
+		{"0 9 * * mon-fri", time.Date(2024, 1, 5, 10, 0, 0, 0, time.UTC), time.Date(2024, 1, 8, 9, 0, 0, 0, time.UTC)},
+		{"30 2 1,15 * *", epoch, time.Date(2024, 1, 1, 2, 30, 0, 0, time.UTC)},
+		{"0 0 13 * fri", epoch, time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 feb *", epoch, time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", epoch, time.Date(2024, 1, 7, 0, 0, 0, 0, time.UTC)},
+		{"@daily", epoch, epoch.AddDate(0, 0, 1)},
+		{"@hourly", epoch.Add(90 * time.Minute), epoch.Add(2 * time.Hour)},
+		{"@every 10m", epoch.Add(3 * time.Minute), epoch.Add(10 * time.Minute)},
+	}
+	for _, c := range cases {
+		t.Run(c.spec, func(t *testing.T) {
+			// Arrange
+			schedule, err := scheduler.Parse(c.spec)
+			if err != nil {
+				t.Fatalf("Expected no error, but got: %v", err)
+			}
+
+			// Act
+			got := schedule.Next(c.after)
+
+			// Assert
+			if !got.Equal(c.want) {
+				t.Errorf("Expected next run at %s, but got %s", c.want, got)
+			}
+		})
+	}
+}
+
+func TestCron_ParseRejectsInvalidSpecs(t *testing.T) {
+	for _, spec := range []string{"* * * *", "61 * * * *", "* * * * 8", "5-1 * * * *", "*/0 * * * *", "@every 0s", "@fortnightly"} {
+		if _, err := scheduler.Parse(spec); err == nil {
+			t.Errorf("Expected %q to be rejected", spec)
+		}
+	}
+}
+
+// startScheduler runs s on a fake clock until the test ends and returns
+// the clock, once the loop is waiting on it.
+func startScheduler(t *testing.T, s *scheduler.Scheduler) *clock.Fake {
+	t.Helper()
+	clk := clock.NewFake(epoch)
+	s.Clock = clk
+	if err := s.Start(context.Background()); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Stop(context.Background()) })
+	waitUntil(t, func() bool { return clk.Waiters() == 1 })
+	return clk
+}
+
+// tick moves clk to the next minute and waits for the loop to sleep again.
+func tick(t *testing.T, clk *clock.Fake) {
+	t.Helper()
+	clk.Advance(time.Minute)
+	waitUntil(t, func() bool { return clk.Waiters() == 1 })
+}
+
+func waitUntil(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func everyMinute(t *testing.T) scheduler.Schedule {
+	t.Helper()
+	s, err := scheduler.Parse("* * * * *")
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	return s
+}
+
+func TestScheduler_RunsJobWhenDue(t *testing.T) {
+	// Arrange
+	s := scheduler.New(1, quietLogger())
+	var runs atomic.Int32
+	_ = s.Add(scheduler.Job{Name: "count", Schedule: everyMinute(t), Run: func(context.Context) error {
+		runs.Add(1)
+		return nil
+	}})
+	clk := startScheduler(t, s)
+
+	// Act
+	tick(t, clk)
+	tick(t, clk)
+
+	// Assert
+	waitUntil(t, func() bool { return runs.Load() == 2 })
+}
+
+func TestScheduler_SkipsRunWhileThePreviousIsGoing(t *testing.T) {
+	// Arrange
+	s := scheduler.New(2, quietLogger())
+	release := make(chan struct{})
+	var runs atomic.Int32
+	_ = s.Add(scheduler.Job{Name: "slow", Schedule: everyMinute(t), Run: func(context.Context) error {
+		runs.Add(1)
+		<-release
+		return nil
+	}})
+	clk := startScheduler(t, s)
+
+	// Act
+	tick(t, clk)
+	waitUntil(t, func() bool { return runs.Load() == 1 })
+	tick(t, clk)
+	tick(t, clk)
+	close(release)
+
+	// Assert
+	if got := runs.Load(); got != 1 {
+		t.Errorf("Expected overlapping turns to be skipped, but the job ran %d times", got)
+	}
+}
+
+func TestScheduler_RunsOnlyWhenElected(t *testing.T) {
+	// Arrange
+	s := scheduler.New(1, quietLogger())
+	var leader atomic.Bool
+	var runs, releases atomic.Int32
+	s.Elector = scheduler.ElectorFunc(func(ctx context.Context, job string, lease time.Duration) (func(), error) {
+		if !leader.Load() {
+			return nil, scheduler.ErrNotLeader
+		}
+		return func() { releases.Add(1) }, nil
+	})
+	s.OnError = func(job string, err error) { t.Errorf("Expected no error, but got: %v", err) }
+	_ = s.Add(scheduler.Job{Name: "elected", Schedule: everyMinute(t), Run: func(context.Context) error {
+		runs.Add(1)
+		return nil
+	}})
+	clk := startScheduler(t, s)
+
+	// Act
+	tick(t, clk)
+	leader.Store(true)
+	tick(t, clk)
+
+	// Assert
+	waitUntil(t, func() bool { return releases.Load() == 1 })
+	if got := runs.Load(); got != 1 {
+		t.Errorf("Expected 1 run as leader, but got %d", got)
+	}
+}
+
+func TestScheduler_TimeoutCancelsRun(t *testing.T) {
+	// Arrange
+	s := scheduler.New(1, quietLogger())
+	failed := make(chan error, 1)
+	s.OnError = func(job string, err error) { failed <- err }
+	_ = s.Add(scheduler.Job{Name: "stuck", Schedule: everyMinute(t), Timeout: 10 * time.Millisecond, Run: func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}})
+	clk := startScheduler(t, s)
+
+	// Act
+	tick(t, clk)
+
+	// Assert
+	select {
+	case err := <-failed:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected error '%v', but got '%v'", context.DeadlineExceeded, err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected the run to time out")
+	}
+}
+
+func TestScheduler_RecoversPanickingRun(t *testing.T) {
+	// Arrange
+	s := scheduler.New(1, quietLogger())
+	failed := make(chan error, 1)
+	s.OnError = func(job string, err error) { failed <- err }
+	_ = s.Add(scheduler.Job{Name: "boom", Schedule: everyMinute(t), Run: func(context.Context) error {
+		panic("boom")
+	}})
+	clk := startScheduler(t, s)
+
+	// Act
+	tick(t, clk)
+
+	// Assert
+	select {
+	case err := <-failed:
+		if err == nil {
+			t.Error("Expected the panic to surface as an error")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected the panic to be reported")
+	}
+}
+
+func TestScheduler_RejectsDuplicateJob(t *testing.T) {
+	// Arrange
+	s := scheduler.New(1, quietLogger())
+	job := scheduler.Job{Name: "twice", Schedule: everyMinute(t), Run: func(context.Context) error { return nil }}
+	_ = s.Add(job)
+
+	// Act
+	err := s.Add(job)
+
+	// Assert
+	if !errors.Is(err, scheduler.ErrDuplicateJob) {
+		t.Errorf("Expected error '%v', but got '%v'", scheduler.ErrDuplicateJob, err)
+	}
+}
@@ -0,0 +1,173 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	httpadapter "clean_go_system/internal/adapter/http"
+	"clean_go_system/internal/adapter/memory"
+	"clean_go_system/internal/core"
+	"clean_go_system/internal/domain"
+	"clean_go_system/internal/httptestutil"
+	"clean_go_system/pkg/clock"
+	"github.com/google/uuid"
+)
+
+func newSessionService() (*core.SessionService, *clock.Fake) {
+	clk := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	svc := core.NewSessionService(memory.NewSessionRepository())
+	svc.Clock = clk
+	svc.IdleTTL, svc.MaxTTL = time.Hour, 3*time.Hour
+	return svc, clk
+}
+
+func TestSessionService_SlidingExpiry(t *testing.T) {
+	// Arrange
+	svc, clk := newSessionService()
+	ctx := context.Background()
+	token, _, err := svc.Create(ctx, uuid.New(), core.Device{UserAgent: "curl/8.0", IP: "10.0.0.1"})
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+
+	// Act: used every 50 minutes, the session outlives its idle TTL...
+	var used int
+	for i := 0; i < 3; i++ {
+		clk.Advance(50 * time.Minute)
+		if _, err := svc.Authenticate(ctx, token); err == nil {
+			used++
+		}
+	}
+	// ...but not its maximum lifetime.
+	clk.Advance(50 * time.Minute)
+	_, err = svc.Authenticate(ctx, token)
+
+	// Assert
+	if used != 3 {
+		t.Errorf("Expected the session to slide 3 times, but it held for %d", used)
+	}
+	if !errors.Is(err, domain.ErrInvalidSession) {
+		t.Errorf("Expected error '%v', but got '%v'", domain.ErrInvalidSession, err)
+	}
+}
+
+func TestSessionService_IdleSessionExpires(t *testing.T) {
+	// Arrange
+	svc, clk := newSessionService()
+	token, _, err := svc.Create(context.Background(), uuid.New(), core.Device{})
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	clk.Advance(time.Hour)
+
+	// Act
+	_, err = svc.Authenticate(context.Background(), token)
+
+	// Assert
+	if !errors.Is(err, domain.ErrInvalidSession) {
+		t.Errorf("Expected error '%v', but got '%v'", domain.ErrInvalidSession, err)
+	}
+}
+
+func TestSessionService_Revoke(t *testing.T) {
+	// Arrange
+	svc, _ := newSessionService()
+	ctx := context.Background()
+	alice, bob := uuid.New(), uuid.New()
+	token, session, err := svc.Create(ctx, alice, core.Device{UserAgent: "laptop"})
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if _, _, err := svc.Create(ctx, alice, core.Device{UserAgent: "phone"}); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+
+	// Act
+	byOther := svc.Revoke(ctx, bob, session.ID)
+	byOwner := svc.Revoke(ctx, alice, session.ID)
+	_, authErr := svc.Authenticate(ctx, token)
+	remaining, listErr := svc.List(ctx, alice)
+
+	// Assert
+	if !errors.Is(byOther, domain.ErrSessionNotFound) {
+		t.Errorf("Expected error '%v', but got '%v'", domain.ErrSessionNotFound, byOther)
+	}
+	if byOwner != nil {
+		t.Fatalf("Expected no error, but got: %v", byOwner)
+	}
+	if !errors.Is(authErr, domain.ErrInvalidSession) {
+		t.Errorf("Expected error '%v', but got '%v'", domain.ErrInvalidSession, authErr)
+	}
+	if listErr != nil || len(remaining) != 1 || remaining[0].UserAgent != "phone" {
+		t.Errorf("Expected only the phone session to remain, but got %+v (%v)", remaining, listErr)
+	}
+}
+
+func TestSessionEndpoints(t *testing.T) {
+	// Arrange
+	svc, _ := newSessionService()
+	alice := uuid.New()
+	token, current, err := svc.Create(context.Background(), alice, core.Device{UserAgent: "laptop", IP: "10.0.0.1"})
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	_, other, err := svc.Create(context.Background(), alice, core.Device{UserAgent: "phone", IP: "10.0.0.2"})
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	bearer := httpadapter.NewBearerAuth(httpadapter.NewSessionTokens("clean_go_system", []byte("0123456789abcdef0123456789abcdef"), time.Hour), nil, nil, quietLogger())
+	bearer.Sessions = svc
+	handler := httpadapter.NewSessionHandler(svc, quietLogger())
+	mux := http.NewServeMux()
+	mux.Handle("GET /sessions", bearer.Middleware(http.HandlerFunc(handler.List)))
+	mux.Handle("DELETE /sessions/{id}", bearer.Middleware(http.HandlerFunc(handler.Revoke)))
+	request := func(method, path string) *http.Request {
+		return httptestutil.Authenticated(httptestutil.NewRequest(t, method, path, nil), token)
+	}
+
+	// Act
+	listed := httptestutil.Serve(mux, request(http.MethodGet, "/sessions"))
+	revokeOther := httptestutil.Serve(mux, request(http.MethodDelete, "/sessions/"+other.ID.String()))
+	revokeUnknown := httptestutil.Serve(mux, request(http.MethodDelete, "/sessions/"+uuid.NewString()))
+	signOut := httptestutil.Serve(mux, request(http.MethodDelete, "/sessions/"+current.ID.String()))
+	afterSignOut := httptestutil.Serve(mux, request(http.MethodGet, "/sessions"))
+
+	// Assert
+	httptestutil.AssertStatus(t, listed, http.StatusOK)
+	sessions := httptestutil.DecodeJSON[[]struct {
+		ID      string `json:"id"`
+		Current bool   `json:"current"`
+	}](t, listed)
+	if len(sessions) != 2 {
+		t.Fatalf("Expected 2 sessions, but got %d", len(sessions))
+	}
+	for _, s := range sessions {
+		if s.Current != (s.ID == current.ID.String()) {
+			t.Errorf("Expected only session %s to be current, but got %+v", current.ID, s)
+		}
+	}
+	httptestutil.AssertStatus(t, revokeOther, http.StatusNoContent)
+	httptestutil.AssertStatus(t, revokeUnknown, http.StatusNotFound)
+	httptestutil.AssertStatus(t, signOut, http.StatusNoContent)
+	httptestutil.AssertStatus(t, afterSignOut, http.StatusUnauthorized)
+}
+
+func TestBearerAuth_RejectsSessionTokensWithoutSessions(t *testing.T) {
+	// Arrange
+	svc, _ := newSessionService()
+	token, _, err := svc.Create(context.Background(), uuid.New(), core.Device{})
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	bearer := httpadapter.NewBearerAuth(httpadapter.NewSessionTokens("clean_go_system", []byte("0123456789abcdef0123456789abcdef"), time.Hour), nil, nil, quietLogger())
+	handler := bearer.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	// Act
+	rec := httptestutil.Serve(handler, httptestutil.Authenticated(httptestutil.NewRequest(t, http.MethodGet, "/me", nil), token))
+
+	// Assert
+	httptestutil.AssertStatus(t, rec, http.StatusUnauthorized)
+}
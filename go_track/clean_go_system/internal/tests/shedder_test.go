@@ -0,0 +1,71 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	httpadapter "clean_go_system/internal/adapter/http"
+	"clean_go_system/internal/httptestutil"
+)
+
+func TestLoadShedder_RejectsBeyondMaxInFlight(t *testing.T) {
+	// Arrange
+	shedder := httpadapter.NewLoadShedder(1, 0)
+	entered, release := make(chan struct{}), make(chan struct{})
+	slow := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(entered)
+		<-release
+	})
+	handler := shedder.Middleware(slow)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		handler.ServeHTTP(httptest.NewRecorder(), httptestutil.NewRequest(t, http.MethodGet, "/", nil))
+	}()
+	<-entered
+
+	// Act
+	rec := httptestutil.Serve(handler, httptestutil.NewRequest(t, http.MethodGet, "/", nil))
+	close(release)
+	wg.Wait()
+
+	// Assert
+	httptestutil.AssertStatus(t, rec, http.StatusServiceUnavailable)
+	httptestutil.AssertHeader(t, rec, "Retry-After", "1")
+	if stats := shedder.Stats(); stats.Shed != 1 || stats.InFlight != 0 {
+		t.Errorf("Expected 1 shed and nothing in flight, but got %+v", stats)
+	}
+}
+
+func TestLoadShedder_HalvesLimitWhenP99IsHigh(t *testing.T) {
+	// Arrange
+	shedder := httpadapter.NewLoadShedder(4, time.Millisecond)
+	slow := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { time.Sleep(2 * time.Millisecond) })
+	handler := shedder.Middleware(slow)
+
+	// Act
+	for i := 0; i < 32; i++ {
+		httptestutil.Serve(handler, httptestutil.NewRequest(t, http.MethodGet, "/", nil))
+	}
+
+	// Assert
+	if stats := shedder.Stats(); stats.Limit != 2 || stats.P99MS < 2 {
+		t.Errorf("Expected the limit halved to 2 with p99 >= 2ms, but got %+v", stats)
+	}
+}
+
+func TestLoadShedder_DisabledIsPassThrough(t *testing.T) {
+	// Arrange
+	shedder := httpadapter.NewLoadShedder(0, 0)
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) })
+
+	// Act
+	rec := httptestutil.Serve(shedder.Middleware(ok), httptestutil.NewRequest(t, http.MethodGet, "/", nil))
+
+	// Assert
+	httptestutil.AssertStatus(t, rec, http.StatusNoContent)
+}
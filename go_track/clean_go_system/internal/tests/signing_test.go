@@ -0,0 +1,123 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"clean-code-cookbook/go/pkg/signing"
+	"clean_go_system/internal/adapter/webhook"
+	"clean_go_system/internal/domain"
+	"github.com/google/uuid"
+)
+
+func TestKeyring_Verify(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	old := signing.Key{ID: "2023", Secret: []byte("old secret")}
+	current := signing.Key{ID: "2024", Secret: []byte("new secret")}
+	body := []byte(`{"hello":"world"}`)
+
+	cases := []struct {
+		name    string
+		signer  []signing.Key
+		verify  []signing.Key
+		elapsed time.Duration
+		path    string
+		want    error
+	}{
+		{"valid", []signing.Key{current}, []signing.Key{current}, 0, "/hooks", nil},
+		{"rotated: old sender, new receiver", []signing.Key{old}, []signing.Key{current, old}, 0, "/hooks", nil},
+		{"retired key", []signing.Key{old}, []signing.Key{current}, 0, "/hooks", signing.ErrUnknownKey},
+		{"wrong secret", []signing.Key{{ID: "2024", Secret: []byte("guess")}}, []signing.Key{current}, 0, "/hooks", signing.ErrSignature},
+		{"other path", []signing.Key{current}, []signing.Key{current}, 0, "/admin", signing.ErrSignature},
+		{"replayed too late", []signing.Key{current}, []signing.Key{current}, 6 * time.Minute, "/hooks", signing.ErrStale},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			signer := signing.NewKeyring(tc.signer...)
+			signer.Now = func() time.Time { return now }
+			verifier := signing.NewKeyring(tc.verify...)
+			verifier.Now = func() time.Time { return now.Add(tc.elapsed) }
+			header := signer.Sign(http.MethodPost, "/hooks", body)
+
+			// Act
+			err := verifier.Verify(header, http.MethodPost, tc.path, body)
+
+			// Assert
+			if !errors.Is(err, tc.want) {
+				t.Errorf("Expected error '%v', but got '%v'", tc.want, err)
+			}
+		})
+	}
+}
+
+func TestKeyring_RejectsMissingAndMalformed(t *testing.T) {
+	keys := signing.NewKeyring(signing.Key{ID: "k", Secret: []byte("s")})
+
+	if err := keys.Verify("", http.MethodPost, "/", nil); !errors.Is(err, signing.ErrMissing) {
+		t.Errorf("Expected error '%v', but got '%v'", signing.ErrMissing, err)
+	}
+	if err := keys.Verify("t=abc,kid=k,v1=zz", http.MethodPost, "/", nil); !errors.Is(err, signing.ErrMalformed) {
+		t.Errorf("Expected error '%v', but got '%v'", signing.ErrMalformed, err)
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	keys, err := signing.ParseKeys("new:s3cret, old:0ld")
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if len(keys) != 2 || keys[0].ID != "new" || string(keys[1].Secret) != "0ld" {
+		t.Errorf("Expected keys new and old, but got %+v", keys)
+	}
+	if _, err := signing.ParseKeys("no-secret"); err == nil {
+		t.Error("Expected an error for a key without a secret, but got none")
+	}
+}
+
+func TestWebhook_DeliversSignedEvents(t *testing.T) {
+	// Arrange
+	keys := []signing.Key{{ID: "k1", Secret: []byte("shared")}}
+	sink := &recordingPublisher{}
+	receiver := httptest.NewServer(webhook.NewReceiver(signing.NewKeyring(keys...), sink, quietLogger()).Handler())
+	defer receiver.Close()
+	publisher := webhook.NewPublisher([]string{receiver.URL + "/webhooks/events"}, signing.NewKeyring(keys...))
+	event := domain.UserRegistered{UserID: uuid.New(), Email: "alice@example.com", Username: "alice", At: time.Now().UTC()}
+
+	// Act
+	err := publisher.Publish(context.Background(), event)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if len(sink.events) != 1 {
+		t.Fatalf("Expected 1 event, but got %d", len(sink.events))
+	}
+	if got, ok := sink.events[0].(domain.UserRegistered); !ok || got.UserID != event.UserID {
+		t.Errorf("Expected %+v, but got %+v", event, sink.events[0])
+	}
+}
+
+func TestWebhook_ReceiverRejectsUnsigned(t *testing.T) {
+	// Arrange
+	sink := &recordingPublisher{}
+	handler := webhook.NewReceiver(signing.NewKeyring(signing.Key{ID: "k1", Secret: []byte("shared")}), sink, quietLogger()).Handler()
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/events", nil)
+	rec := httptest.NewRecorder()
+
+	// Act
+	handler.ServeHTTP(rec, req)
+
+	// Assert
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401, but got %d", rec.Code)
+	}
+	if len(sink.events) != 0 {
+		t.Errorf("Expected no events, but got %v", sink.events)
+	}
+}
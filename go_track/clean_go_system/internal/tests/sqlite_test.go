@@ -0,0 +1,65 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"clean_go_system/internal/adapter/sqlite"
+	"clean_go_system/internal/core"
+	"clean_go_system/internal/domain"
+	"github.com/google/uuid"
+)
+
+func newSQLiteUserService(t *testing.T) (*core.UserService, *sqlite.UserRepository) {
+	t.Helper()
+	db, err := sqlite.Open("file::memory:")
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := sqlite.Migrate(context.Background(), db); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	repo := sqlite.NewUserRepository(db)
+	return core.NewUserService(repo, &recordingPublisher{}, sqlite.NewTransactor(db)), repo
+}
+
+func TestSQLite_RegisterRoundTrips(t *testing.T) {
+	// Arrange
+	svc, repo := newSQLiteUserService(t)
+	ctx := context.Background()
+
+	// Act
+	user, err := svc.Register(ctx, "alice@example.com", "alice")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	got, err := repo.GetByID(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if got.Email != user.Email || !got.Active || !got.CreatedAt.Equal(user.CreatedAt) {
+		t.Errorf("Expected %+v, but got %+v", user, got)
+	}
+}
+
+func TestSQLite_SaveRejectsDuplicateEmail(t *testing.T) {
+	// Arrange
+	_, repo := newSQLiteUserService(t)
+	ctx := context.Background()
+	alice := domain.User{ID: uuid.New(), Email: "alice@example.com", Username: "alice", CreatedAt: time.Now()}
+	_ = repo.Save(ctx, alice)
+
+	// Act
+	alice.ID = uuid.New()
+	err := repo.Save(ctx, alice)
+
+	// Assert
+	if !errors.Is(err, domain.ErrUserExists) {
+		t.Fatalf("Expected error '%v', but got '%v'", domain.ErrUserExists, err)
+	}
+}
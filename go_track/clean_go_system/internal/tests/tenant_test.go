@@ -0,0 +1,104 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	httpadapter "clean_go_system/internal/adapter/http"
+	"clean_go_system/internal/adapter/memory"
+	"clean_go_system/internal/core"
+	"clean_go_system/internal/domain"
+	"clean_go_system/internal/httptestutil"
+	"github.com/google/uuid"
+)
+
+func TestUserService_Register_IsPerTenant(t *testing.T) {
+	// Arrange
+	svc := core.NewUserService(memory.NewUserRepository(), &recordingPublisher{}, memory.NewTransactor())
+	acme := domain.WithTenant(context.Background(), "acme")
+	globex := domain.WithTenant(context.Background(), "globex")
+	if _, err := svc.Register(acme, "alice@example.com", "alice"); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+
+	// Act
+	user, err := svc.Register(globex, "alice@example.com", "alice")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if user.TenantID != "globex" {
+		t.Errorf("Expected the user to join globex, but got %q", user.TenantID)
+	}
+	if _, err := svc.Register(globex, "alice@example.com", "alice2"); !errors.Is(err, domain.ErrUserExists) {
+		t.Errorf("Expected error '%v', but got '%v'", domain.ErrUserExists, err)
+	}
+}
+
+func TestTenantResolver(t *testing.T) {
+	var seen domain.TenantID
+	handler := httpadapter.NewTenantResolver("example.com", "acme", "globex").
+		Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			seen = domain.TenantOf(r.Context())
+		}))
+	cases := []struct {
+		name, host, header string
+		wantStatus         int
+		wantTenant         domain.TenantID
+	}{
+		{"header", "api.internal", "acme", http.StatusOK, "acme"},
+		{"header wins over subdomain", "acme.example.com", "globex", http.StatusOK, "globex"},
+		{"subdomain with port", "globex.example.com:8443", "", http.StatusOK, "globex"},
+		{"unknown tenant", "initech.example.com", "", http.StatusNotFound, ""},
+		{"nested subdomain", "a.acme.example.com", "", http.StatusBadRequest, ""},
+		{"no tenant", "example.com", "", http.StatusBadRequest, ""},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Arrange
+			seen = ""
+			req := httptestutil.NewRequest(t, http.MethodGet, "/me", nil)
+			req.Host = c.host
+			if c.header != "" {
+				req.Header.Set(httpadapter.TenantHeader, c.header)
+			}
+
+			// Act
+			rec := httptestutil.Serve(handler, req)
+
+			// Assert
+			httptestutil.AssertStatus(t, rec, c.wantStatus)
+			if seen != c.wantTenant {
+				t.Errorf("Expected tenant %q, but got %q", c.wantTenant, seen)
+			}
+		})
+	}
+}
+
+func TestSessionTokens_AreBoundToTheirTenant(t *testing.T) {
+	// Arrange
+	alice := &domain.User{ID: uuid.New(), TenantID: "acme"}
+	tokens := httpadapter.NewSessionTokens("clean_go_system", []byte("0123456789abcdef0123456789abcdef"), time.Hour)
+	token, err := tokens.Issue(alice)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	handler := httpadapter.NewBearerAuth(tokens, nil, nil, quietLogger()).
+		Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	in := func(tenant domain.TenantID) *http.Request {
+		req := httptestutil.Authenticated(httptestutil.NewRequest(t, http.MethodGet, "/me", nil), token)
+		return req.WithContext(domain.WithTenant(req.Context(), tenant))
+	}
+
+	// Act
+	own := httptestutil.Serve(handler, in("acme"))
+	other := httptestutil.Serve(handler, in("globex"))
+
+	// Assert
+	httptestutil.AssertStatus(t, own, http.StatusOK)
+	httptestutil.AssertStatus(t, other, http.StatusUnauthorized)
+}
@@ -0,0 +1,47 @@
+package tests
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	httpadapter "clean_go_system/internal/adapter/http"
+)
+
+func TestRedirectToHTTPS_KeepsHostAndPath(t *testing.T) {
+	cases := []struct {
+		name      string
+		httpsPort string
+		target    string
+		want      string
+	}{
+		{"default port", "443", "http://example.com:80/register?x=1", "https://example.com/register?x=1"},
+		{"custom port", "8443", "http://example.com/register", "https://example.com:8443/register"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, tc.target, nil)
+
+			// Act
+			httpadapter.RedirectToHTTPS(tc.httpsPort).ServeHTTP(rec, req)
+
+			// Assert
+			if rec.Code != http.StatusMovedPermanently {
+				t.Fatalf("Expected 301, but got %d", rec.Code)
+			}
+			if got := rec.Header().Get("Location"); got != tc.want {
+				t.Errorf("Expected Location '%s', but got '%s'", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestNewTLSConfig_RejectsLegacyProtocols(t *testing.T) {
+	cfg := httpadapter.NewTLSConfig()
+	if cfg.MinVersion < tls.VersionTLS12 {
+		t.Errorf("Expected TLS 1.2 minimum, but got %x", cfg.MinVersion)
+	}
+}
@@ -0,0 +1,188 @@
+package tests
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"clean-code-cookbook/go/pkg/tlsconfig"
+)
+
+// testCA issues certificates for the mTLS tests and writes them to disk,
+// since tlsconfig loads PEM files.
+type testCA struct {
+	dir  string
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "internal CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	ca := &testCA{dir: t.TempDir(), cert: cert, key: key}
+	ca.write(t, "ca.pem", "CERTIFICATE", der)
+	return ca
+}
+
+// issue writes a leaf for the DNS SAN name and returns its Files, trusting
+// the CA.
+func (ca *testCA) issue(t *testing.T, name string) tlsconfig.Files {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	return tlsconfig.Files{
+		CertFile: ca.write(t, name+".pem", "CERTIFICATE", der),
+		KeyFile:  ca.write(t, name+"-key.pem", "EC PRIVATE KEY", keyDER),
+		CAFile:   filepath.Join(ca.dir, "ca.pem"),
+	}
+}
+
+func (ca *testCA) write(t *testing.T, name, blockType string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(ca.dir, name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	return path
+}
+
+// startMTLS serves 200 OK over mutual TLS with the server's Files.
+func startMTLS(t *testing.T, files tlsconfig.Files) *httptest.Server {
+	t.Helper()
+	cfg, err := tlsconfig.Server(files)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.TLS = cfg
+	server.StartTLS()
+	t.Cleanup(server.Close)
+	return server
+}
+
+func mtlsClient(t *testing.T, files tlsconfig.Files) *http.Client {
+	t.Helper()
+	cfg, err := tlsconfig.Client(files, "users")
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	return &http.Client{Transport: &http.Transport{TLSClientConfig: cfg}}
+}
+
+func TestTLSConfig_MutualTLS(t *testing.T) {
+	// Arrange
+	ca := newTestCA(t)
+	serverFiles := ca.issue(t, "users")
+	serverFiles.PeerNames = []string{"edge"}
+	server := startMTLS(t, serverFiles)
+
+	t.Run("allowed peer", func(t *testing.T) {
+		// Act
+		resp, err := mtlsClient(t, ca.issue(t, "edge")).Get(server.URL)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+		resp.Body.Close()
+	})
+
+	t.Run("peer not in the allow list", func(t *testing.T) {
+		// Act
+		_, err := mtlsClient(t, ca.issue(t, "catalog")).Get(server.URL)
+
+		// Assert
+		if err == nil {
+			t.Error("Expected the handshake to fail, but it succeeded")
+		}
+	})
+
+	t.Run("no client certificate", func(t *testing.T) {
+		// Arrange: trusts the server but presents nothing
+		roots := x509.NewCertPool()
+		roots.AddCert(ca.cert)
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots, ServerName: "users"}}}
+
+		// Act
+		_, err := client.Get(server.URL)
+
+		// Assert
+		if err == nil {
+			t.Error("Expected the handshake to fail, but it succeeded")
+		}
+	})
+}
+
+func TestTLSConfig_ClientChecksServerSANs(t *testing.T) {
+	// Arrange
+	ca := newTestCA(t)
+	server := startMTLS(t, ca.issue(t, "users"))
+	clientFiles := ca.issue(t, "edge")
+	clientFiles.PeerNames = []string{"billing"}
+
+	// Act
+	_, err := mtlsClient(t, clientFiles).Get(server.URL)
+
+	// Assert
+	if !errors.Is(err, tlsconfig.ErrPeerNotAllowed) {
+		t.Errorf("Expected error '%v', but got '%v'", tlsconfig.ErrPeerNotAllowed, err)
+	}
+}
+
+func TestTLSConfig_RequiresAllFiles(t *testing.T) {
+	// Act
+	_, err := tlsconfig.Server(tlsconfig.Files{CertFile: "cert.pem"})
+
+	// Assert
+	if err == nil {
+		t.Error("Expected an error for missing key and CA, but got none")
+	}
+}
@@ -0,0 +1,46 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"clean_go_system/pkg/tracing"
+)
+
+func TestTracingMiddleware(t *testing.T) {
+	const incoming = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	cases := []struct {
+		name   string
+		header string
+		keep   bool
+	}{
+		{"propagates valid header", incoming, true},
+		{"replaces malformed header", "garbage", false},
+		{"starts a trace when absent", "", false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			var seen string
+			h := tracing.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				seen = tracing.TraceParent(r.Context())
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.header != "" {
+				req.Header.Set(tracing.Header, tc.header)
+			}
+
+			// Act
+			h.ServeHTTP(httptest.NewRecorder(), req)
+
+			// Assert
+			if tc.keep && seen != tc.header {
+				t.Errorf("Expected '%s', but got '%s'", tc.header, seen)
+			}
+			if !tc.keep && (seen == "" || seen == tc.header) {
+				t.Errorf("Expected a fresh traceparent, but got '%s'", seen)
+			}
+		})
+	}
+}
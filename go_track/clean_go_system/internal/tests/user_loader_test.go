@@ -0,0 +1,165 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"clean_go_system/internal/adapter/memory"
+	"clean_go_system/internal/core"
+	"clean_go_system/internal/domain"
+	"github.com/google/uuid"
+)
+
+// countingBatches records every batch a loader sends to the store.
+type countingBatches struct {
+	*memory.UserRepository
+	mu      sync.Mutex
+	batches [][]uuid.UUID
+	err     error
+}
+
+func (c *countingBatches) GetByIDs(ctx context.Context, ids []uuid.UUID) ([]domain.User, error) {
+	c.mu.Lock()
+	c.batches = append(c.batches, ids)
+	c.mu.Unlock()
+	if c.err != nil {
+		return nil, c.err
+	}
+	return c.UserRepository.GetByIDs(ctx, ids)
+}
+
+// loadAll loads ids concurrently and returns what each Load got, in order.
+func loadAll(ctx context.Context, loader *core.UserLoader, ids []uuid.UUID) ([]*domain.User, []error) {
+	users, errs := make([]*domain.User, len(ids)), make([]error, len(ids))
+	var wg sync.WaitGroup
+	for i, id := range ids {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			users[i], errs[i] = loader.Load(ctx, id)
+		}()
+	}
+	wg.Wait()
+	return users, errs
+}
+
+func saveUsers(t *testing.T, ctx context.Context, repo domain.UserRepository, n int) []uuid.UUID {
+	t.Helper()
+	var ids []uuid.UUID
+	for i := range n {
+		u, err := domain.RegisterUser(domain.TenantOf(ctx), uuid.New(), fmt.Sprintf("user%d@example.com", i), "user", "", time.Now())
+		if err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+		if err := repo.Save(ctx, *u); err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+		ids = append(ids, u.ID)
+	}
+	return ids
+}
+
+func TestUserLoader_CoalescesConcurrentLoads(t *testing.T) {
+	// Arrange
+	store := &countingBatches{UserRepository: memory.NewUserRepository()}
+	ctx := context.Background()
+	ids := saveUsers(t, ctx, store, 5)
+	loader := core.NewUserLoader(store)
+	loader.Window = 50 * time.Millisecond
+	unknown := uuid.New()
+
+	// Act
+	users, errs := loadAll(ctx, loader, append(ids, ids[0], unknown))
+
+	// Assert
+	if len(store.batches) != 1 || len(store.batches[0]) != 6 {
+		t.Fatalf("Expected one query for 6 distinct IDs, but got %v", store.batches)
+	}
+	for i, id := range append(ids, ids[0]) {
+		if errs[i] != nil || users[i].ID != id {
+			t.Errorf("Expected user %s, but got %+v, %v", id, users[i], errs[i])
+		}
+	}
+	if last := len(errs) - 1; !errors.Is(errs[last], domain.ErrUserNotFound) {
+		t.Errorf("Expected error '%v', but got '%v'", domain.ErrUserNotFound, errs[last])
+	}
+}
+
+func TestUserLoader_SplitsBatchesByTenantAndSize(t *testing.T) {
+	// Arrange
+	store := &countingBatches{UserRepository: memory.NewUserRepository()}
+	acme := domain.WithTenant(context.Background(), "acme")
+	ours := saveUsers(t, context.Background(), store, 3)
+	theirs := saveUsers(t, acme, store, 1)
+	loader := core.NewUserLoader(store)
+	loader.Window, loader.MaxBatch = 50*time.Millisecond, 2
+
+	// Act
+	var wg sync.WaitGroup
+	var acmeErr error
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_, acmeErr = loader.Load(acme, theirs[0])
+	}()
+	_, errs := loadAll(context.Background(), loader, ours)
+	wg.Wait()
+
+	// Assert
+	if err := errors.Join(append(errs, acmeErr)...); err != nil {
+		t.Fatalf("Expected no errors, but got: %v", err)
+	}
+	if len(store.batches) != 3 {
+		t.Errorf("Expected two batches for 3 users at 2 a batch and one for acme, but got %v", store.batches)
+	}
+}
+
+func TestUserLoader_BatchErrorFailsEveryLoad(t *testing.T) {
+	// Arrange
+	boom := errors.New("database down")
+	store := &countingBatches{UserRepository: memory.NewUserRepository(), err: boom}
+	loader := core.NewUserLoader(store)
+
+	// Act
+	_, errs := loadAll(context.Background(), loader, []uuid.UUID{uuid.New(), uuid.New()})
+
+	// Assert
+	for _, err := range errs {
+		if !errors.Is(err, boom) {
+			t.Errorf("Expected error '%v', but got '%v'", boom, err)
+		}
+	}
+}
+
+func TestUserService_Load_GoesThroughTheLoader(t *testing.T) {
+	// Arrange
+	store := &countingBatches{UserRepository: memory.NewUserRepository()}
+	ids := saveUsers(t, context.Background(), store, 2)
+	loader := core.NewUserLoader(store)
+	loader.Window = 50 * time.Millisecond
+	users := core.NewUserService(store, &recordingPublisher{}, memory.NewTransactor(), core.WithLoader(loader))
+
+	// Act
+	var wg sync.WaitGroup
+	errs := make([]error, len(ids))
+	for i, id := range ids {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = users.Load(context.Background(), id)
+		}()
+	}
+	wg.Wait()
+
+	// Assert
+	if err := errors.Join(errs...); err != nil {
+		t.Fatalf("Expected no errors, but got: %v", err)
+	}
+	if len(store.batches) != 1 {
+		t.Errorf("Expected one query, but got %v", store.batches)
+	}
+}
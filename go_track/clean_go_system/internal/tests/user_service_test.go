@@ -0,0 +1,201 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"clean_go_system/internal/adapter/memory"
+	"clean_go_system/internal/core"
+	"clean_go_system/internal/domain"
+	"clean_go_system/pkg/clock"
+)
+
+// recordingPublisher captures published events.
+type recordingPublisher struct {
+	events []domain.DomainEvent
+	err    error
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, events ...domain.DomainEvent) error {
+	p.events = append(p.events, events...)
+	return p.err
+}
+
+func TestUserService_Register_PublishesUserRegistered(t *testing.T) {
+	// Arrange
+	publisher := &recordingPublisher{}
+	svc := core.NewUserService(memory.NewUserRepository(), publisher, memory.NewTransactor())
+
+	// Act
+	user, err := svc.Register(context.Background(), "alice@example.com", "alice")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if len(publisher.events) != 1 {
+		t.Fatalf("Expected 1 event, but got %d", len(publisher.events))
+	}
+	event, ok := publisher.events[0].(domain.UserRegistered)
+	if !ok || event.UserID != user.ID {
+		t.Errorf("Expected UserRegistered for %s, but got %+v", user.ID, publisher.events[0])
+	}
+}
+
+func TestUserService_Register_RejectsDuplicates(t *testing.T) {
+	// Arrange
+	svc := core.NewUserService(memory.NewUserRepository(), &recordingPublisher{}, memory.NewTransactor())
+	ctx := context.Background()
+	if _, err := svc.Register(ctx, "alice@example.com", "alice"); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+
+	// Act
+	_, err := svc.Register(ctx, "alice@example.com", "alice2")
+
+	// Assert
+	if !errors.Is(err, domain.ErrUserExists) {
+		t.Fatalf("Expected error '%v', but got '%v'", domain.ErrUserExists, err)
+	}
+}
+
+func TestUserService_Deactivate_IsIdempotent(t *testing.T) {
+	// Arrange
+	publisher := &recordingPublisher{}
+	svc := core.NewUserService(memory.NewUserRepository(), publisher, memory.NewTransactor())
+	ctx := context.Background()
+	if _, err := svc.Register(ctx, "alice@example.com", "alice"); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+
+	// Act
+	first := svc.Deactivate(ctx, "alice@example.com")
+	second := svc.Deactivate(ctx, "alice@example.com")
+
+	// Assert
+	if first != nil || second != nil {
+		t.Fatalf("Expected no errors, but got %v and %v", first, second)
+	}
+	if len(publisher.events) != 3 {
+		t.Fatalf("Expected registered + one deactivation and its update, but got %d", len(publisher.events))
+	}
+	if _, ok := publisher.events[1].(domain.UserDeactivated); !ok {
+		t.Errorf("Expected UserDeactivated, but got %T", publisher.events[1])
+	}
+}
+
+func TestUserService_ChangeEmail(t *testing.T) {
+	// Arrange
+	publisher := &recordingPublisher{}
+	repo := memory.NewUserRepository()
+	svc := core.NewUserService(repo, publisher, memory.NewTransactor())
+	ctx := context.Background()
+	alice, err := svc.Register(ctx, "alice@example.com", "alice")
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if _, err := svc.Register(ctx, "bob@example.com", "bob"); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+
+	// Act
+	_, taken := svc.ChangeEmail(ctx, alice.ID, "bob@example.com")
+	changed, err := svc.ChangeEmail(ctx, alice.ID, "alice@example.org")
+
+	// Assert
+	if !errors.Is(taken, domain.ErrUserExists) {
+		t.Errorf("Expected error '%v', but got '%v'", domain.ErrUserExists, taken)
+	}
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if stored, _ := repo.GetByEmail(ctx, "alice@example.org"); stored == nil || stored.ID != alice.ID || changed.Email != "alice@example.org" {
+		t.Errorf("Expected alice stored under the new address, but got %+v", stored)
+	}
+	if len(publisher.events) != 4 {
+		t.Fatalf("Expected two registrations and one change with its update, but got %d events", len(publisher.events))
+	}
+	if event, ok := publisher.events[2].(domain.UserEmailChanged); !ok || event.OldEmail != "alice@example.com" {
+		t.Errorf("Expected UserEmailChanged from alice@example.com, but got %+v", publisher.events[2])
+	}
+	if event, ok := publisher.events[3].(domain.UserUpdated); !ok || event.Email != "alice@example.org" || event.Verified {
+		t.Errorf("Expected UserUpdated with the new, unverified address, but got %+v", publisher.events[3])
+	}
+}
+
+func TestUserService_Delete_IsSoft(t *testing.T) {
+	// Arrange
+	repo := memory.NewUserRepository()
+	svc := core.NewUserService(repo, &recordingPublisher{}, memory.NewTransactor())
+	ctx := context.Background()
+	alice, err := svc.Register(ctx, "alice@example.com", "alice")
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+
+	// Act
+	err = svc.Delete(ctx, alice.ID)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if _, err := svc.Get(ctx, alice.ID); !errors.Is(err, domain.ErrUserNotFound) {
+		t.Errorf("Expected error '%v', but got '%v'", domain.ErrUserNotFound, err)
+	}
+	if kept, err := repo.GetByID(domain.WithDeleted(ctx), alice.ID); err != nil || !kept.Deleted() {
+		t.Errorf("Expected the deleted row to be kept, but got %+v, %v", kept, err)
+	}
+	if _, err := svc.Register(ctx, "alice@example.com", "alice2"); !errors.Is(err, domain.ErrUserExists) {
+		t.Errorf("Expected the address to stay taken until purged, but got '%v'", err)
+	}
+}
+
+func TestUserPurge_RemovesUsersPastRetention(t *testing.T) {
+	// Arrange
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	repo := memory.NewUserRepository()
+	svc := core.NewUserService(repo, &recordingPublisher{}, memory.NewTransactor(), core.WithClock(fake))
+	ctx := context.Background()
+	old, _ := svc.Register(ctx, "old@example.com", "old")
+	recent, _ := svc.Register(ctx, "recent@example.com", "recent")
+	if err := svc.Delete(ctx, old.ID); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	fake.Advance(48 * time.Hour)
+	if err := svc.Delete(ctx, recent.ID); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	purge := core.NewUserPurge(repo, 24*time.Hour, quietLogger())
+	purge.Clock = fake
+
+	// Act
+	n, err := purge.PurgeOnce(ctx)
+
+	// Assert
+	if err != nil || n != 1 {
+		t.Fatalf("Expected 1 user purged, but got %d, %v", n, err)
+	}
+	if _, err := repo.GetByID(domain.WithDeleted(ctx), old.ID); !errors.Is(err, domain.ErrUserNotFound) {
+		t.Errorf("Expected the old deletion to be purged, but got '%v'", err)
+	}
+	if _, err := repo.GetByID(domain.WithDeleted(ctx), recent.ID); err != nil {
+		t.Errorf("Expected the recent deletion to be kept, but got '%v'", err)
+	}
+}
+
+func BenchmarkUserService_Register(b *testing.B) {
+	nop := publisherFunc(func(context.Context, ...domain.DomainEvent) error { return nil })
+	svc := core.NewUserService(memory.NewUserRepository(), nop, memory.NewTransactor())
+	ctx := context.Background()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := range b.N {
+		if _, err := svc.Register(ctx, "user"+strconv.Itoa(i)+"@example.com", "user"); err != nil {
+			b.Fatalf("Expected no error, but got: %v", err)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"clean_go_system/internal/domain"
+	"github.com/google/uuid"
+)
+
+func registeredUser(t *testing.T) *domain.User {
+	t.Helper()
+	u, err := domain.RegisterUser(domain.DefaultTenant, uuid.New(), "alice@example.com", "alice", "", time.Now())
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	u.PullEvents()
+	return u
+}
+
+func TestRegisterUser(t *testing.T) {
+	t.Run("records UserRegistered", func(t *testing.T) {
+		// Act
+		u, err := domain.RegisterUser(domain.DefaultTenant, uuid.New(), "alice@example.com", "alice", "", time.Now())
+
+		// Assert
+		if err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+		events := u.PullEvents()
+		if len(events) != 1 || !u.Active {
+			t.Fatalf("Expected an active user with 1 event, but got %+v and %d events", u, len(events))
+		}
+		if _, ok := events[0].(domain.UserRegistered); !ok {
+			t.Errorf("Expected UserRegistered, but got %T", events[0])
+		}
+		if again := u.PullEvents(); len(again) != 0 {
+			t.Errorf("Expected events to be pulled once, but got %d again", len(again))
+		}
+	})
+
+	t.Run("validates", func(t *testing.T) {
+		// Act
+		_, err := domain.RegisterUser(domain.DefaultTenant, uuid.New(), "not-an-email", "alice", "", time.Now())
+
+		// Assert
+		if !errors.Is(err, domain.ErrInvalidEmail) {
+			t.Errorf("Expected error '%v', but got '%v'", domain.ErrInvalidEmail, err)
+		}
+	})
+
+	t.Run("reports every invalid field", func(t *testing.T) {
+		// Act
+		_, err := domain.RegisterUser(domain.DefaultTenant, uuid.New(), "not-an-email", "a", "", time.Now())
+
+		// Assert
+		var invalid *domain.ValidationError
+		if !errors.As(err, &invalid) {
+			t.Fatalf("Expected a ValidationError, but got '%v'", err)
+		}
+		if len(invalid.Fields) != 2 || invalid.Fields[0].Field != "email" || invalid.Fields[1].Field != "username" || invalid.Fields[1].Rule != "length" {
+			t.Errorf("Expected email and username length violations, but got %+v", invalid.Fields)
+		}
+		if !errors.Is(err, domain.ErrInvalidUsername) {
+			t.Errorf("Expected error '%v', but got '%v'", domain.ErrInvalidUsername, err)
+		}
+	})
+}
+
+func TestUser_ChangeEmail(t *testing.T) {
+	t.Run("records the old and new address", func(t *testing.T) {
+		// Arrange
+		u := registeredUser(t)
+
+		// Act
+		err := u.ChangeEmail("alice@example.org", time.Now())
+
+		// Assert
+		if err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+		events := u.PullEvents()
+		if len(events) != 2 {
+			t.Fatalf("Expected 2 events, but got %d", len(events))
+		}
+		if e, ok := events[0].(domain.UserEmailChanged); !ok || e.OldEmail != "alice@example.com" || e.NewEmail != "alice@example.org" {
+			t.Errorf("Expected the change to be recorded, but got %+v", events[0])
+		}
+		if e, ok := events[1].(domain.UserUpdated); !ok || e.Email != "alice@example.org" || e.Username != "alice" || !e.Active {
+			t.Errorf("Expected UserUpdated with the new address, but got %+v", events[1])
+		}
+	})
+
+	t.Run("same address is a no-op", func(t *testing.T) {
+		// Arrange
+		u := registeredUser(t)
+
+		// Act
+		err := u.ChangeEmail("alice@example.com", time.Now())
+
+		// Assert
+		if err != nil || len(u.PullEvents()) != 0 {
+			t.Errorf("Expected nothing recorded, but got error %v", err)
+		}
+	})
+
+	t.Run("deactivated user", func(t *testing.T) {
+		// Arrange
+		u := registeredUser(t)
+		u.Deactivate(time.Now())
+
+		// Act
+		err := u.ChangeEmail("alice@example.org", time.Now())
+
+		// Assert
+		if !errors.Is(err, domain.ErrUserInactive) || u.Email != "alice@example.com" {
+			t.Errorf("Expected error '%v' and the address kept, but got '%v' and %s", domain.ErrUserInactive, err, u.Email)
+		}
+	})
+}
+
+func TestUser_Deactivate_RecordsOnce(t *testing.T) {
+	// Arrange
+	u := registeredUser(t)
+
+	// Act
+	u.Deactivate(time.Now())
+	u.Deactivate(time.Now())
+
+	// Assert
+	events := u.PullEvents()
+	if u.Active || len(events) != 2 {
+		t.Fatalf("Expected an inactive user with 2 events, but got %d", len(events))
+	}
+	if _, ok := events[0].(domain.UserDeactivated); !ok {
+		t.Errorf("Expected UserDeactivated, but got %T", events[0])
+	}
+	if e, ok := events[1].(domain.UserUpdated); !ok || e.Active {
+		t.Errorf("Expected UserUpdated for an inactive user, but got %+v", events[1])
+	}
+}
+
+func TestUser_Delete(t *testing.T) {
+	// Arrange
+	u := registeredUser(t)
+	at := time.Now()
+
+	// Act
+	u.Delete(at)
+	u.Delete(at.Add(time.Hour))
+
+	// Assert
+	events := u.PullEvents()
+	if !u.Deleted() || u.Active || len(events) != 1 {
+		t.Fatalf("Expected a deleted, inactive user with 1 event, but got %+v and %d events", u, len(events))
+	}
+	if !u.DeletedAt.Equal(at) || !u.UpdatedAt.Equal(at) {
+		t.Errorf("Expected DeletedAt and UpdatedAt %v, but got %v and %v", at, u.DeletedAt, u.UpdatedAt)
+	}
+	if _, ok := events[0].(domain.UserDeleted); !ok {
+		t.Errorf("Expected UserDeleted, but got %T", events[0])
+	}
+}
@@ -0,0 +1,141 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"regexp"
+	"testing"
+	"time"
+
+	httpadapter "clean_go_system/internal/adapter/http"
+	"clean_go_system/internal/adapter/memory"
+	"clean_go_system/internal/core"
+	"clean_go_system/internal/domain"
+	"clean_go_system/internal/httptestutil"
+	"clean_go_system/pkg/clock"
+	"github.com/google/uuid"
+)
+
+type verificationFixture struct {
+	svc   *core.EmailVerification
+	users *core.UserService
+	repo  *memory.UserRepository
+	queue *recordingQueue
+	clock *clock.Fake
+	alice domain.User
+}
+
+func newVerificationFixture(t *testing.T) *verificationFixture {
+	t.Helper()
+	repo := memory.NewUserRepository()
+	alice := domain.User{ID: uuid.New(), Email: "alice@example.com", Username: "alice", Active: true}
+	if err := repo.Save(context.Background(), alice); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	f := &verificationFixture{
+		users: core.NewUserService(repo, &recordingPublisher{}, memory.NewTransactor()),
+		repo:  repo,
+		queue: &recordingQueue{},
+		clock: clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)),
+		alice: alice,
+	}
+	f.svc = core.NewEmailVerification(f.users, emailTemplates(t), []byte("0123456789abcdef0123456789abcdef"))
+	f.svc.Clock = f.clock
+	return f
+}
+
+var verificationToken = regexp.MustCompile(`[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`)
+
+// mailToken registers alice and returns the token mailed to her.
+func (f *verificationFixture) mailToken(t *testing.T) string {
+	t.Helper()
+	email, ok, err := f.svc.Compose(context.Background(), domain.UserRegistered{UserID: f.alice.ID, Email: f.alice.Email, Username: f.alice.Username})
+	if err != nil || !ok {
+		t.Fatalf("Expected the verification email, but got: %v", err)
+	}
+	if err := core.EnqueueRequested(f.queue, time.Second)(context.Background(), email); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if len(f.queue.jobs) == 0 {
+		t.Fatal("Expected a verification email, but none was queued")
+	}
+	return verificationToken.FindString(f.queue.jobs[len(f.queue.jobs)-1].Body)
+}
+
+func TestEmailVerification_ConfirmWithMailedToken(t *testing.T) {
+	// Arrange
+	f := newVerificationFixture(t)
+	token := f.mailToken(t)
+
+	// Act
+	user, err := f.svc.Confirm(context.Background(), token)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if job := f.queue.jobs[0]; job.Email != f.alice.Email || job.Subject != "Confirm your email address" || job.HTML == "" {
+		t.Errorf("Expected the verification email to alice, but got %+v", job)
+	}
+	stored, _ := f.repo.GetByID(context.Background(), f.alice.ID)
+	if !user.Verified() || !stored.Verified() {
+		t.Error("Expected alice to be verified")
+	}
+}
+
+func TestEmailVerification_RejectsBadTokens(t *testing.T) {
+	cases := map[string]func(t *testing.T, f *verificationFixture, token string) string{
+		"malformed": func(*testing.T, *verificationFixture, string) string { return "not-a-token" },
+		"tampered":  func(_ *testing.T, _ *verificationFixture, token string) string { return token + "x" },
+		"expired": func(_ *testing.T, f *verificationFixture, token string) string {
+			f.clock.Advance(49 * time.Hour)
+			return token
+		},
+		"address changed": func(t *testing.T, f *verificationFixture, token string) string {
+			user, _ := f.repo.GetByID(context.Background(), f.alice.ID)
+			user.Email = "alice@example.org"
+			if err := f.repo.Update(context.Background(), *user); err != nil {
+				t.Fatalf("Expected no error, but got: %v", err)
+			}
+			return token
+		},
+		"session token": func(*testing.T, *verificationFixture, string) string {
+			sessions := httpadapter.NewSessionTokens("clean_go_system", []byte("0123456789abcdef0123456789abcdef"), time.Hour)
+			token, _ := sessions.Issue(&domain.User{ID: uuid.New()})
+			return token
+		},
+	}
+	for name, tamper := range cases {
+		t.Run(name, func(t *testing.T) {
+			// Arrange
+			f := newVerificationFixture(t)
+			token := tamper(t, f, f.mailToken(t))
+
+			// Act
+			_, err := f.svc.Confirm(context.Background(), token)
+
+			// Assert
+			if !errors.Is(err, domain.ErrInvalidVerificationToken) {
+				t.Errorf("Expected error '%v', but got '%v'", domain.ErrInvalidVerificationToken, err)
+			}
+		})
+	}
+}
+
+func TestVerificationHandler(t *testing.T) {
+	// Arrange
+	f := newVerificationFixture(t)
+	token := f.mailToken(t)
+	handler := httpadapter.NewVerificationHandler(f.svc, quietLogger())
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /email/verify", handler.Verify)
+
+	// Act
+	verified := httptestutil.Serve(mux, httptestutil.NewRequest(t, http.MethodPost, "/email/verify", map[string]string{"token": token}))
+	bad := httptestutil.Serve(mux, httptestutil.NewRequest(t, http.MethodPost, "/email/verify", map[string]string{"token": "nope"}))
+
+	// Assert
+	httptestutil.AssertStatus(t, verified, http.StatusNoContent)
+	httptestutil.AssertStatus(t, bad, http.StatusBadRequest)
+}
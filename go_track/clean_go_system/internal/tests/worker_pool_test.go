@@ -0,0 +1,269 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"clean_go_system/internal/core"
+)
+
+func TestWorkerPool_PanicBecomesJobFailure(t *testing.T) {
+	// Arrange
+	pool := core.NewWorkerPool(1, 2)
+	pool.Process = func(ctx context.Context, job core.EmailJob) error {
+		if job.Email == "boom@example.com" {
+			panic("template missing")
+		}
+		return nil
+	}
+	var mu sync.Mutex
+	var failed []error
+	pool.OnFailure = func(job core.EmailJob, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		failed = append(failed, err)
+	}
+	pool.Start()
+
+	// Act
+	_ = pool.Enqueue(context.Background(), core.EmailJob{Email: "boom@example.com"})
+	_ = pool.Enqueue(context.Background(), core.EmailJob{Email: "alice@example.com"})
+	pool.Stop()
+
+	// Assert
+	if len(failed) != 1 {
+		t.Fatalf("Expected 1 failed job, but got %d", len(failed))
+	}
+	var panicked *core.PanicError
+	if !errors.As(failed[0], &panicked) || panicked.Value != "template missing" || len(panicked.Stack) == 0 {
+		t.Errorf("Expected a PanicError with its stack, but got %v", failed[0])
+	}
+}
+
+func TestRunJob_ReturnsErrorsUnchanged(t *testing.T) {
+	// Arrange
+	bounce := errors.New("mailbox full")
+
+	// Act
+	err := core.RunJob(context.Background(), core.EmailJob{}, func(context.Context, core.EmailJob) error { return bounce })
+
+	// Assert
+	if !errors.Is(err, bounce) {
+		t.Errorf("Expected error '%v', but got '%v'", bounce, err)
+	}
+}
+
+func TestWorkerPool_EnqueueWaitsForRoom(t *testing.T) {
+	// Arrange
+	pool := core.NewWorkerPool(1, 1)
+	_ = pool.TryEnqueue(core.EmailJob{Email: "first@example.com"})
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		<-pool.JobQueue
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// Act
+	err := pool.Enqueue(ctx, core.EmailJob{Email: "second@example.com"})
+
+	// Assert
+	if err != nil {
+		t.Errorf("Expected the job to be queued once room freed up, but got: %v", err)
+	}
+}
+
+func TestWorkerPool_EnqueueGivesUpAtDeadline(t *testing.T) {
+	// Arrange
+	pool := core.NewWorkerPool(1, 0)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	// Act
+	err := pool.Enqueue(ctx, core.EmailJob{Email: "alice@example.com"})
+
+	// Assert
+	if !errors.Is(err, core.ErrQueueFull) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected ErrQueueFull wrapping the deadline, but got '%v'", err)
+	}
+}
+
+func TestWorkerPool_TryEnqueueDoesNotBlock(t *testing.T) {
+	// Arrange
+	pool := core.NewWorkerPool(1, 0)
+
+	// Act
+	err := pool.TryEnqueue(core.EmailJob{Email: "alice@example.com"})
+
+	// Assert
+	if !errors.Is(err, core.ErrQueueFull) {
+		t.Errorf("Expected error '%v', but got '%v'", core.ErrQueueFull, err)
+	}
+}
+
+func TestWorkerPool_PauseHoldsJobsUntilResume(t *testing.T) {
+	// Arrange
+	pool := core.NewWorkerPool(1, 4)
+	done := make(chan string, 4)
+	pool.Process = func(ctx context.Context, job core.EmailJob) error {
+		done <- job.Email
+		return nil
+	}
+	pool.Start()
+	defer pool.Stop()
+	pool.Pause()
+
+	// Act
+	_ = pool.Enqueue(context.Background(), core.EmailJob{Email: "alice@example.com"})
+	_ = pool.Enqueue(context.Background(), core.EmailJob{Email: "bob@example.com"})
+	time.Sleep(20 * time.Millisecond)
+	paused := pool.Status()
+	pool.Resume()
+
+	// Assert
+	if len(done) != 0 {
+		t.Errorf("Expected no job to run while paused, but %d did", len(done))
+	}
+	if !paused.Paused || paused.Queued != 2 || paused.Busy != 0 {
+		t.Errorf("Expected 2 queued jobs in a paused pool, but got %+v", paused)
+	}
+	for range 2 {
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("Expected the held jobs to run after Resume")
+		}
+	}
+}
+
+func TestWorkerPool_DrainFinishesQueuedJobsAndRefusesNewOnes(t *testing.T) {
+	// Arrange
+	pool := core.NewWorkerPool(2, 8)
+	var mu sync.Mutex
+	var sent []string
+	pool.Process = func(ctx context.Context, job core.EmailJob) error {
+		time.Sleep(5 * time.Millisecond)
+		mu.Lock()
+		defer mu.Unlock()
+		sent = append(sent, job.Email)
+		return nil
+	}
+	pool.Pause()
+	for _, email := range []string{"a@example.com", "b@example.com", "c@example.com"} {
+		_ = pool.Enqueue(context.Background(), core.EmailJob{Email: email})
+	}
+	pool.Start()
+	defer pool.Stop()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// Act
+	drained := pool.Drain(ctx)
+	refused := pool.TryEnqueue(core.EmailJob{Email: "late@example.com"})
+	pool.Resume()
+	accepted := pool.TryEnqueue(core.EmailJob{Email: "later@example.com"})
+
+	// Assert
+	if !drained {
+		t.Fatal("Expected the pool to drain")
+	}
+	mu.Lock()
+	if len(sent) != 3 {
+		t.Errorf("Expected the 3 queued jobs to run, but got %v", sent)
+	}
+	mu.Unlock()
+	if !errors.Is(refused, core.ErrQueueFull) {
+		t.Errorf("Expected error '%v', but got '%v'", core.ErrQueueFull, refused)
+	}
+	if accepted != nil {
+		t.Errorf("Expected Resume to accept jobs again, but got: %v", accepted)
+	}
+}
+
+func TestWorkerPool_RetryDeadLetters(t *testing.T) {
+	// Arrange
+	pool := core.NewWorkerPool(1, 4)
+	pool.DeadLetters = core.NewDeadLetters[core.EmailJob](10)
+	var mu sync.Mutex
+	attempts := map[string]int{}
+	pool.Process = func(ctx context.Context, job core.EmailJob) error {
+		mu.Lock()
+		defer mu.Unlock()
+		attempts[job.Email]++
+		if attempts[job.Email] == 1 {
+			return errors.New("smtp unavailable")
+		}
+		return nil
+	}
+	pool.Start()
+	_ = pool.Enqueue(context.Background(), core.EmailJob{Email: "alice@example.com"})
+	pool.Drain(context.Background())
+	parked := pool.Status().DeadLetters
+	pool.Resume()
+
+	// Act
+	n, err := pool.RetryDeadLetters(context.Background())
+	pool.Stop()
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if parked != 1 || n != 1 {
+		t.Errorf("Expected 1 job parked and retried, but got %d parked, %d retried", parked, n)
+	}
+	if attempts["alice@example.com"] != 2 || pool.DeadLetters.Len() != 0 {
+		t.Errorf("Expected the retry to succeed, but got %d attempts, %d parked", attempts["alice@example.com"], pool.DeadLetters.Len())
+	}
+}
+
+func TestDeadLetters_DropsOldestBeyondMax(t *testing.T) {
+	// Arrange
+	letters := core.NewDeadLetters[int](2)
+
+	// Act
+	for i := range 3 {
+		letters.Add(i, errors.New("failed"))
+	}
+	taken := letters.Take()
+
+	// Assert
+	if len(taken) != 2 || taken[0].Job != 1 || taken[1].Job != 2 {
+		t.Errorf("Expected jobs 1 and 2, but got %+v", taken)
+	}
+	if letters.Len() != 0 {
+		t.Errorf("Expected Take to empty the store, but %d remain", letters.Len())
+	}
+}
+
+// BenchmarkPool_Throughput measures the pool's own overhead: jobs that do
+// nothing, through pools of 1 to 16 workers.
+func BenchmarkPool_Throughput(b *testing.B) {
+	for _, workers := range []int{1, 4, 16} {
+		b.Run(strconv.Itoa(workers), func(b *testing.B) {
+			var done atomic.Int64
+			pool := core.NewPool("bench", workers, 256, func(ctx context.Context, job int) error {
+				done.Add(1)
+				return nil
+			})
+			pool.Start()
+			ctx := context.Background()
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := range b.N {
+				if err := pool.Enqueue(ctx, i); err != nil {
+					b.Fatalf("Expected no error, but got: %v", err)
+				}
+			}
+			pool.Stop()
+			if done.Load() != int64(b.N) {
+				b.Fatalf("Expected %d jobs done, but got %d", b.N, done.Load())
+			}
+		})
+	}
+}
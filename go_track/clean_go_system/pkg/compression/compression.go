@@ -0,0 +1,256 @@
+// Package compression compresses HTTP responses with zstd or gzip, as the
+// client's Accept-Encoding allows. Only responses of the allowed content
+// types and at least a minimum size are compressed: below a kilobyte or so
+// the framing costs more than it saves, and event streams must reach the
+// client as they are written, not when a compressor block fills.
+package compression
+
+import (
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Encodings this package writes, in order of preference.
+const (
+	Zstd = "zstd"
+	Gzip = "gzip"
+)
+
+// Config is what gets compressed.
+type Config struct {
+	// MinBytes is the smallest body compressed; smaller ones go as they
+	// are. A response with a Content-Length is judged by it, any other by
+	// how much the handler writes before returning or flushing.
+	MinBytes int
+	// Types are the media types compressed, such as "application/json";
+	// parameters like charset are ignored when matching.
+	Types []string
+}
+
+var (
+	gzipWriters = sync.Pool{New: func() any {
+		w, _ := gzip.NewWriterLevel(io.Discard, gzip.DefaultCompression)
+		return w
+	}}
+	zstdWriters = sync.Pool{New: func() any {
+		w, _ := zstd.NewWriter(io.Discard, zstd.WithEncoderLevel(zstd.SpeedFastest), zstd.WithEncoderConcurrency(1))
+		return w
+	}}
+)
+
+// Middleware compresses the responses of next that c allows, with the
+// encoding the request prefers. Every response it looks at says Vary:
+// Accept-Encoding, compressed or not, so caches keep the two apart.
+func Middleware(c Config, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		encoding := Negotiate(r.Header.Get("Accept-Encoding"))
+		if encoding == "" || r.Method == http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+		cw := &writer{ResponseWriter: w, config: c, encoding: encoding}
+		next.ServeHTTP(cw, r)
+		// Not deferred: a handler that panics to abort its response must
+		// not have the stream ended cleanly after it, which would pass a
+		// truncated body off as whole.
+		cw.close()
+	})
+}
+
+// Negotiate picks the encoding to answer an Accept-Encoding header with:
+// zstd, gzip, or "" for none. Of the two, the one with the higher q value
+// wins, zstd on a tie; "*" stands for both and q=0 rules one out.
+func Negotiate(acceptEncoding string) string {
+	q := map[string]float64{}
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		weight := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			weight = f
+		}
+		q[name] = weight
+	}
+	best, bestQ := "", 0.0
+	for _, encoding := range []string{Zstd, Gzip} {
+		weight, ok := q[encoding]
+		if !ok {
+			weight, ok = q["*"]
+		}
+		if ok && weight > bestQ {
+			best, bestQ = encoding, weight
+		}
+	}
+	return best
+}
+
+// writer holds the start of a body back until it knows whether to
+// compress it: once MinBytes are written or the handler returns or
+// flushes, whichever comes first.
+type writer struct {
+	http.ResponseWriter
+	config   Config
+	encoding string
+
+	status  int
+	buf     []byte
+	decided bool
+	enc     io.WriteCloser // nil when the body goes as it is
+}
+
+func (w *writer) WriteHeader(status int) {
+	if w.decided || w.status != 0 {
+		return
+	}
+	// Informational answers go straight out; the real one is still to come.
+	if status >= 100 && status < 200 {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	w.status = status
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if w.decided {
+		if w.enc != nil {
+			return w.enc.Write(p)
+		}
+		return w.ResponseWriter.Write(p)
+	}
+	w.buf = append(w.buf, p...)
+	// A response that is never compressed, like an event stream, is not
+	// held back either.
+	if big := len(w.buf) >= w.config.MinBytes; big || !w.compressible() {
+		if err := w.decide(big); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Flush sends what is held back, compressed or not, and flushes the
+// compressor, so a streaming handler's output reaches the client.
+func (w *writer) Flush() {
+	if !w.decided {
+		_ = w.decide(len(w.buf) >= w.config.MinBytes)
+	}
+	if f, ok := w.enc.(interface{ Flush() error }); ok {
+		_ = f.Flush()
+	}
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap lets http.ResponseController reach the writer's deadlines.
+func (w *writer) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// decide writes the header, compressed when big says the body is large
+// enough and the response qualifies, and then whatever was held back.
+func (w *writer) decide(big bool) error {
+	w.decided = true
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	h := w.Header()
+	if big && w.compressible() {
+		h.Del("Content-Length")
+		h.Set("Content-Encoding", w.encoding)
+		w.enc = w.encoder()
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	if len(w.buf) == 0 {
+		return nil
+	}
+	var err error
+	if w.enc != nil {
+		_, err = w.enc.Write(w.buf)
+	} else {
+		_, err = w.ResponseWriter.Write(w.buf)
+	}
+	w.buf = nil
+	return err
+}
+
+// compressible reports whether the response may be compressed at all,
+// whatever its size.
+func (w *writer) compressible() bool {
+	h := w.Header()
+	if h.Get("Content-Encoding") != "" || w.status == http.StatusNoContent || w.status == http.StatusNotModified || w.status < 200 {
+		return false
+	}
+	if n, err := strconv.Atoi(h.Get("Content-Length")); err == nil && n < w.config.MinBytes {
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(h.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+	for _, t := range w.config.Types {
+		if strings.EqualFold(mediaType, t) {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *writer) encoder() io.WriteCloser {
+	switch w.encoding {
+	case Zstd:
+		z := zstdWriters.Get().(*zstd.Encoder)
+		z.Reset(w.ResponseWriter)
+		return pooled{z, func() { z.Reset(io.Discard); zstdWriters.Put(z) }}
+	default:
+		g := gzipWriters.Get().(*gzip.Writer)
+		g.Reset(w.ResponseWriter)
+		return pooled{g, func() { g.Reset(io.Discard); gzipWriters.Put(g) }}
+	}
+}
+
+// close ends the body: it sends a small one as it is, or finishes the
+// compressed stream.
+func (w *writer) close() {
+	if !w.decided {
+		if w.status == 0 && len(w.buf) == 0 {
+			// The handler wrote nothing; net/http answers 200 for it.
+			return
+		}
+		_ = w.decide(len(w.buf) >= w.config.MinBytes)
+	}
+	if w.enc != nil {
+		_ = w.enc.Close()
+	}
+}
+
+// pooled is a compressor that goes back to its pool once closed.
+type pooled struct {
+	compressor interface {
+		io.WriteCloser
+		Flush() error
+	}
+	release func()
+}
+
+func (p pooled) Write(b []byte) (int, error) { return p.compressor.Write(b) }
+func (p pooled) Flush() error                { return p.compressor.Flush() }
+
+func (p pooled) Close() error {
+	err := p.compressor.Close()
+	p.release()
+	return err
+}
@@ -0,0 +1,134 @@
+// Package faults injects latency and failures into calls on purpose, so
+// retries, breakers and timeouts can be exercised outside of unit tests.
+// Nothing is injected unless a Fault is configured for the call's target.
+package faults
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// ErrInjected marks a failure that was injected rather than real.
+var ErrInjected = errors.New("injected fault")
+
+// Fault describes what to do to the calls of one target.
+type Fault struct {
+	// LatencyMS is added to every call, plus up to JitterMS at random.
+	LatencyMS int `json:"latency_ms"`
+	JitterMS  int `json:"jitter_ms"`
+	// ErrorRate is the share of calls (0..1) failed before they run.
+	ErrorRate float64 `json:"error_rate"`
+	// PartialRate is the share of calls that run and succeed but still
+	// report failure, the way a timeout after a commit does.
+	PartialRate float64 `json:"partial_rate"`
+}
+
+func (f Fault) delay(rnd func() float64) time.Duration {
+	d := time.Duration(f.LatencyMS) * time.Millisecond
+	if f.JitterMS > 0 {
+		d += time.Duration(rnd() * float64(time.Duration(f.JitterMS)*time.Millisecond))
+	}
+	return d
+}
+
+// Wildcard is the target that applies to every call without a more
+// specific fault.
+const Wildcard = "*"
+
+// Injector holds the configured faults per target ("users.repo", "http",
+// …). It is safe for concurrent use; Set may be called while calls run.
+type Injector struct {
+	// Rand returns a number in [0, 1); tests replace it to make outcomes
+	// deterministic.
+	Rand func() float64
+
+	mu     sync.RWMutex
+	faults map[string]Fault
+}
+
+func NewInjector(faults map[string]Fault) *Injector {
+	i := &Injector{Rand: rand.Float64}
+	i.Set(faults)
+	return i
+}
+
+// Set replaces every configured fault, e.g. after a config reload.
+func (i *Injector) Set(faults map[string]Fault) {
+	copied := make(map[string]Fault, len(faults))
+	for k, v := range faults {
+		copied[k] = v
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.faults = copied
+}
+
+// lookup prefers a per-request fault from ctx over the configured ones,
+// and an exact target over the wildcard.
+func (i *Injector) lookup(ctx context.Context, target string) (Fault, bool) {
+	if o, ok := ctx.Value(overrideKey{}).(override); ok && (o.target == target || o.target == Wildcard) {
+		return o.fault, true
+	}
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	if f, ok := i.faults[target]; ok {
+		return f, true
+	}
+	f, ok := i.faults[Wildcard]
+	return f, ok
+}
+
+// Do runs fn under the fault configured for target: it waits out the
+// latency (returning early if ctx ends), may fail before calling fn, and
+// may report failure after fn succeeded.
+func (i *Injector) Do(ctx context.Context, target string, fn func(ctx context.Context) error) error {
+	if i == nil {
+		return fn(ctx)
+	}
+	f, ok := i.lookup(ctx, target)
+	if !ok {
+		return fn(ctx)
+	}
+
+	// 1. Latency
+	if d := f.delay(i.Rand); d > 0 {
+		timer := time.NewTimer(d)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	// 2. Failure before the call
+	if i.Rand() < f.ErrorRate {
+		return fmt.Errorf("%s: %w", target, ErrInjected)
+	}
+
+	// 3. The call, then possibly a lost acknowledgement
+	if err := fn(ctx); err != nil {
+		return err
+	}
+	if i.Rand() < f.PartialRate {
+		return fmt.Errorf("%s: %w after the call completed", target, ErrInjected)
+	}
+	return nil
+}
+
+type overrideKey struct{}
+
+type override struct {
+	target string
+	fault  Fault
+}
+
+// WithFault makes every Do for target (or Wildcard) in ctx use fault,
+// regardless of configuration.
+func WithFault(ctx context.Context, target string, fault Fault) context.Context {
+	return context.WithValue(ctx, overrideKey{}, override{target: target, fault: fault})
+}
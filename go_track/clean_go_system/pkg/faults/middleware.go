@@ -0,0 +1,70 @@
+package faults
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+)
+
+// Request headers that set a fault for one request. They are only read by
+// Middleware, which must never be mounted in production.
+const (
+	HeaderTarget      = "X-Chaos-Target" // defaults to Wildcard
+	HeaderLatency     = "X-Chaos-Latency-Ms"
+	HeaderJitter      = "X-Chaos-Jitter-Ms"
+	HeaderErrorRate   = "X-Chaos-Error-Rate"
+	HeaderPartialRate = "X-Chaos-Partial-Rate"
+)
+
+// HTTPTarget is the target Middleware injects into before the handler runs.
+const HTTPTarget = "http"
+
+// Middleware applies the "http" fault to every request, failing injected
+// errors with 503, and passes any X-Chaos-* fault down in the request
+// context so decorated repositories and clients pick it up. A nil injector
+// makes it a pass-through.
+func Middleware(i *Injector, next http.Handler) http.Handler {
+	if i == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if f, ok := fromHeaders(r.Header); ok {
+			target := r.Header.Get(HeaderTarget)
+			if target == "" {
+				target = Wildcard
+			}
+			ctx = WithFault(ctx, target, f)
+		}
+
+		var served bool
+		err := i.Do(ctx, HTTPTarget, func(ctx context.Context) error {
+			served = true
+			next.ServeHTTP(w, r.WithContext(ctx))
+			return nil
+		})
+		if err != nil && !served {
+			http.Error(w, "injected fault", http.StatusServiceUnavailable)
+		}
+	})
+}
+
+// fromHeaders reads a Fault from X-Chaos-* headers; malformed values are
+// ignored.
+func fromHeaders(h http.Header) (Fault, bool) {
+	var f Fault
+	var found bool
+	if v, err := strconv.Atoi(h.Get(HeaderLatency)); err == nil {
+		f.LatencyMS, found = v, true
+	}
+	if v, err := strconv.Atoi(h.Get(HeaderJitter)); err == nil {
+		f.JitterMS, found = v, true
+	}
+	if v, err := strconv.ParseFloat(h.Get(HeaderErrorRate), 64); err == nil {
+		f.ErrorRate, found = v, true
+	}
+	if v, err := strconv.ParseFloat(h.Get(HeaderPartialRate), 64); err == nil {
+		f.PartialRate, found = v, true
+	}
+	return f, found
+}
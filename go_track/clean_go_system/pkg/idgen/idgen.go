@@ -0,0 +1,38 @@
+// Package idgen provides the ID generators that satisfy domain.IDGenerator:
+// time-ordered UUIDv7s for production and a predictable sequence for tests.
+package idgen
+
+import (
+	"encoding/binary"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// UUIDv7 generates RFC 9562 version 7 UUIDs. They sort by creation time,
+// which keeps B-tree inserts on the primary key append-only.
+type UUIDv7 struct{}
+
+func (UUIDv7) NewID() uuid.UUID {
+	return uuid.Must(uuid.NewV7())
+}
+
+// Sequential returns 00000000-0000-0000-0000-000000000001, then …0002 and
+// so on, so tests can assert on IDs. It is safe for concurrent use.
+type Sequential struct {
+	mu   sync.Mutex
+	next uint64
+}
+
+func NewSequential() *Sequential {
+	return &Sequential{next: 1}
+}
+
+func (s *Sequential) NewID() uuid.UUID {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var id uuid.UUID
+	binary.BigEndian.PutUint64(id[8:], s.next)
+	s.next++
+	return id
+}
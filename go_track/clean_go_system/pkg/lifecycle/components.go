@@ -0,0 +1,71 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+)
+
+// Func adapts plain start/stop functions (e.g., WorkerPool.Start/Stop) to a Component.
+type Func struct {
+	OnStart func(ctx context.Context) error
+	OnStop  func(ctx context.Context) error
+}
+
+func (f Func) Start(ctx context.Context) error {
+	if f.OnStart == nil {
+		return nil
+	}
+	return f.OnStart(ctx)
+}
+
+func (f Func) Stop(ctx context.Context) error {
+	if f.OnStop == nil {
+		return nil
+	}
+	return f.OnStop(ctx)
+}
+
+// HTTPServer runs an *http.Server as a Component. Binding happens in Start,
+// so "address already in use" fails startup instead of surfacing later.
+// The server speaks TLS when CertFile/KeyFile or Server.TLSConfig is set.
+type HTTPServer struct {
+	Server   *http.Server
+	CertFile string
+	KeyFile  string
+	errs     chan error
+}
+
+// NewHTTPServer wraps srv.
+func NewHTTPServer(srv *http.Server) *HTTPServer {
+	return &HTTPServer{Server: srv, errs: make(chan error, 1)}
+}
+
+func (s *HTTPServer) Start(ctx context.Context) error {
+	ln, err := net.Listen("tcp", s.Server.Addr)
+	if err != nil {
+		return err
+	}
+	go func() {
+		if err := s.serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.errs <- err
+		}
+	}()
+	return nil
+}
+
+func (s *HTTPServer) serve(ln net.Listener) error {
+	if s.CertFile != "" || s.Server.TLSConfig != nil {
+		return s.Server.ServeTLS(ln, s.CertFile, s.KeyFile)
+	}
+	return s.Server.Serve(ln)
+}
+
+func (s *HTTPServer) Stop(ctx context.Context) error {
+	return s.Server.Shutdown(ctx)
+}
+
+func (s *HTTPServer) Err() <-chan error {
+	return s.errs
+}
@@ -0,0 +1,123 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"time"
+
+	"clean-code-cookbook/go/pkg/concurrent"
+	"clean-code-cookbook/go/pkg/retry"
+)
+
+// Dependency is something the process needs before it accepts traffic.
+type Dependency struct {
+	Name  string
+	Check func(ctx context.Context) error
+	// Optional dependencies are logged when unreachable and startup
+	// continues in degraded mode instead of failing.
+	Optional bool
+}
+
+// RetryPolicy caps how long startup waits for a dependency.
+type RetryPolicy struct {
+	Attempts       int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	AttemptTimeout time.Duration
+}
+
+// DefaultRetryPolicy gives a dependency roughly 10 seconds to come up.
+var DefaultRetryPolicy = RetryPolicy{
+	Attempts:       5,
+	InitialBackoff: 200 * time.Millisecond,
+	MaxBackoff:     3 * time.Second,
+	AttemptTimeout: 2 * time.Second,
+}
+
+// Verifier is a Component that checks all dependencies on Start. Register
+// it before the servers so a missing database fails fast instead of
+// turning every request into a 500.
+type Verifier struct {
+	deps   []Dependency
+	policy RetryPolicy
+	logger *log.Logger
+}
+
+// NewVerifier creates a Verifier for deps.
+func NewVerifier(policy RetryPolicy, logger *log.Logger, deps ...Dependency) *Verifier {
+	if policy.Attempts < 1 {
+		policy.Attempts = 1
+	}
+	return &Verifier{deps: deps, policy: policy, logger: logger}
+}
+
+// Start checks the dependencies concurrently and returns an error naming
+// every required dependency that stayed unreachable.
+func (v *Verifier) Start(ctx context.Context) error {
+	// Each failure is a result, not an error, so one dependency down does
+	// not cut the others' checks short.
+	errs, _ := concurrent.Map(ctx, concurrent.Options{}, v.deps, func(ctx context.Context, dep Dependency) (error, error) {
+		return v.verify(ctx, dep), nil
+	})
+
+	var required []error
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+		if v.deps[i].Optional {
+			v.logger.Printf("dependency %s unavailable, continuing degraded: %v", v.deps[i].Name, err)
+			continue
+		}
+		required = append(required, err)
+	}
+	return errors.Join(required...)
+}
+
+// Stop is a no-op; the Verifier holds no resources.
+func (v *Verifier) Stop(context.Context) error { return nil }
+
+func (v *Verifier) verify(ctx context.Context, dep Dependency) error {
+	policy := retry.Policy{
+		Attempts: v.policy.Attempts,
+		Backoff:  retry.Exponential(v.policy.InitialBackoff, v.policy.MaxBackoff),
+		OnRetry: func(attempt int, err error, _ time.Duration) {
+			v.logger.Printf("dependency %s: attempt %d/%d failed: %v", dep.Name, attempt, v.policy.Attempts, err)
+		},
+	}
+	err := retry.Do(ctx, policy, func(ctx context.Context) error { return v.attempt(ctx, dep) })
+	switch {
+	case err == nil:
+		v.logger.Printf("dependency %s ok", dep.Name)
+		return nil
+	case ctx.Err() != nil:
+		return fmt.Errorf("dependency %s: %w", dep.Name, ctx.Err())
+	default:
+		return fmt.Errorf("dependency %s unreachable after %d attempts: %w", dep.Name, v.policy.Attempts, err)
+	}
+}
+
+func (v *Verifier) attempt(ctx context.Context, dep Dependency) error {
+	if v.policy.AttemptTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, v.policy.AttemptTimeout)
+		defer cancel()
+	}
+	return dep.Check(ctx)
+}
+
+// TCPCheck reports whether addr accepts TCP connections. It is enough to
+// tell "gRPC upstream not running" apart from "upstream misbehaving".
+func TCPCheck(addr string) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", addr)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+}
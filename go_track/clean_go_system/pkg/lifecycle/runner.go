@@ -0,0 +1,135 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// DefaultStopTimeout bounds a component's Stop when no explicit timeout is given.
+const DefaultStopTimeout = 10 * time.Second
+
+// Component is a unit of the application with a managed lifetime.
+type Component interface {
+	// Start launches the component. It must return once the component is
+	// ready; long-running work belongs in a goroutine that watches ctx.
+	Start(ctx context.Context) error
+	// Stop releases the component's resources before ctx expires.
+	Stop(ctx context.Context) error
+}
+
+// Failer is implemented by components that can fail after Start returns
+// (e.g., a server whose listener dies). A value on Err triggers shutdown.
+type Failer interface {
+	Err() <-chan error
+}
+
+type managed struct {
+	name        string
+	component   Component
+	stopTimeout time.Duration
+}
+
+// Runner starts components in registration order, waits for a signal, a
+// cancelled root context or a component failure, then stops them in reverse.
+type Runner struct {
+	components []managed
+	signals    []os.Signal
+	logger     *log.Logger
+}
+
+// NewRunner creates a Runner that reacts to SIGINT and SIGTERM.
+func NewRunner(logger *log.Logger) *Runner {
+	return &Runner{
+		signals: []os.Signal{os.Interrupt, syscall.SIGTERM},
+		logger:  logger,
+	}
+}
+
+// Add registers a component. A zero stopTimeout falls back to DefaultStopTimeout.
+func (r *Runner) Add(name string, c Component, stopTimeout time.Duration) {
+	if stopTimeout <= 0 {
+		stopTimeout = DefaultStopTimeout
+	}
+	r.components = append(r.components, managed{name: name, component: c, stopTimeout: stopTimeout})
+}
+
+// Run blocks until the application should exit and returns the start error
+// or the aggregated errors of failed and stopped components.
+func (r *Runner) Run(ctx context.Context) error {
+	// 1. Root context: cancelled by the caller or an OS signal.
+	ctx, stopSignals := signal.NotifyContext(ctx, r.signals...)
+	defer stopSignals()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// 2. Start in order; on failure roll back whatever already started.
+	failures := make(chan error, len(r.components))
+	for i, m := range r.components {
+		r.logger.Printf("starting %s", m.name)
+		if err := m.component.Start(ctx); err != nil {
+			cancel()
+			startErr := fmt.Errorf("start %s: %w", m.name, err)
+			return errors.Join(startErr, r.stop(r.components[:i]))
+		}
+		if f, ok := m.component.(Failer); ok {
+			go forward(ctx, m.name, f, failures)
+		}
+	}
+
+	// 3. Wait for the reason to exit.
+	var runErr error
+	select {
+	case <-ctx.Done():
+		r.logger.Println("shutdown requested")
+	case runErr = <-failures:
+		r.logger.Printf("shutting down: %v", runErr)
+	}
+	cancel()
+
+	// 4. Stop everything in reverse order.
+	return errors.Join(runErr, r.stop(r.components))
+}
+
+func (r *Runner) stop(components []managed) error {
+	var errs []error
+	for i := len(components) - 1; i >= 0; i-- {
+		m := components[i]
+		r.logger.Printf("stopping %s", m.name)
+		if err := stopWithTimeout(m); err != nil {
+			errs = append(errs, fmt.Errorf("stop %s: %w", m.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// stopWithTimeout enforces the deadline even if the component ignores ctx.
+func stopWithTimeout(m managed) error {
+	ctx, cancel := context.WithTimeout(context.Background(), m.stopTimeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() { done <- m.component.Stop(ctx) }()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func forward(ctx context.Context, name string, f Failer, failures chan<- error) {
+	select {
+	case err, ok := <-f.Err():
+		if ok && err != nil {
+			failures <- fmt.Errorf("%s failed: %w", name, err)
+		}
+	case <-ctx.Done():
+	}
+}
@@ -0,0 +1,223 @@
+// Package policy is a small attribute-based authorization engine. A
+// request names a subject, an action and a resource, each subject and
+// resource carrying string attributes; policies allow or deny actions on
+// resource types when all of their conditions hold. Deny wins over allow,
+// and nothing is allowed unless a policy says so.
+//
+// Policies are plain values, so they can be written in code or loaded
+// from JSON:
+//
+//	[{"id": "read-self", "effect": "allow", "actions": ["users:read"],
+//	  "resources": ["user"],
+//	  "when": [{"attr": "resource.id", "op": "eq", "ref": "subject.id"}]}]
+package policy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+)
+
+// ErrDenied is returned by Engine.Check when a request is not allowed.
+var ErrDenied = errors.New("policy: denied")
+
+// Effect is what a matching policy decides.
+type Effect string
+
+const (
+	Allow Effect = "allow"
+	Deny  Effect = "deny"
+)
+
+// Attributes describe a subject or resource. Lists are comma-separated
+// and tested with the "contains" operator.
+type Attributes map[string]string
+
+// Request asks whether Subject may perform Action on Resource. The
+// resource's type is its "type" attribute.
+type Request struct {
+	Subject  Attributes
+	Action   string
+	Resource Attributes
+}
+
+// Condition compares an attribute ("subject.x" or "resource.x") with
+// either a literal Value or another attribute named by Ref.
+type Condition struct {
+	Attr  string `json:"attr"`
+	Op    string `json:"op"` // eq, ne, in (Value is a comma list), contains
+	Value string `json:"value,omitempty"`
+	Ref   string `json:"ref,omitempty"`
+}
+
+// Policy applies to requests for one of Actions on one of Resources
+// ("*" matches any; "users:*" any action with that prefix).
+type Policy struct {
+	ID        string      `json:"id"`
+	Effect    Effect      `json:"effect"`
+	Actions   []string    `json:"actions"`
+	Resources []string    `json:"resources"`
+	When      []Condition `json:"when,omitempty"`
+}
+
+// Decision is the outcome of Evaluate and the policy that decided it, if
+// any.
+type Decision struct {
+	Allowed bool
+	Policy  string
+}
+
+// Engine evaluates a fixed set of policies; it is safe for concurrent use.
+type Engine struct {
+	policies []Policy
+}
+
+// New validates policies and returns an engine for them.
+func New(policies ...Policy) (*Engine, error) {
+	for _, p := range policies {
+		if err := p.validate(); err != nil {
+			return nil, err
+		}
+	}
+	return &Engine{policies: slices.Clone(policies)}, nil
+}
+
+// Load reads a JSON array of policies.
+func Load(r io.Reader) ([]Policy, error) {
+	var policies []Policy
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&policies); err != nil {
+		return nil, fmt.Errorf("policy: decode: %w", err)
+	}
+	return policies, nil
+}
+
+// LoadFile reads a JSON array of policies from path.
+func LoadFile(path string) ([]Policy, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("policy: %w", err)
+	}
+	defer f.Close()
+	return Load(f)
+}
+
+// Evaluate decides req: the first matching deny, else the first matching
+// allow, else a default deny.
+func (e *Engine) Evaluate(req Request) Decision {
+	allowedBy := ""
+	for _, p := range e.policies {
+		if !p.matches(req) {
+			continue
+		}
+		if p.Effect == Deny {
+			return Decision{Allowed: false, Policy: p.ID}
+		}
+		if allowedBy == "" {
+			allowedBy = p.ID
+		}
+	}
+	return Decision{Allowed: allowedBy != "", Policy: allowedBy}
+}
+
+// Check is Evaluate as an error: nil or ErrDenied.
+func (e *Engine) Check(req Request) error {
+	if d := e.Evaluate(req); !d.Allowed {
+		if d.Policy != "" {
+			return fmt.Errorf("%w by %s: %s on %s", ErrDenied, d.Policy, req.Action, req.Resource["type"])
+		}
+		return fmt.Errorf("%w: %s on %s", ErrDenied, req.Action, req.Resource["type"])
+	}
+	return nil
+}
+
+func (p Policy) validate() error {
+	if p.Effect != Allow && p.Effect != Deny {
+		return fmt.Errorf("policy %q: effect must be allow or deny, got %q", p.ID, p.Effect)
+	}
+	if len(p.Actions) == 0 || len(p.Resources) == 0 {
+		return fmt.Errorf("policy %q: needs actions and resources", p.ID)
+	}
+	for _, c := range p.When {
+		if !validAttr(c.Attr) {
+			return fmt.Errorf("policy %q: attr %q must start with subject. or resource.", p.ID, c.Attr)
+		}
+		if c.Ref != "" && !validAttr(c.Ref) {
+			return fmt.Errorf("policy %q: ref %q must start with subject. or resource.", p.ID, c.Ref)
+		}
+		switch c.Op {
+		case "eq", "ne", "in", "contains":
+		default:
+			return fmt.Errorf("policy %q: unknown op %q", p.ID, c.Op)
+		}
+	}
+	return nil
+}
+
+func (p Policy) matches(req Request) bool {
+	if !slices.ContainsFunc(p.Actions, func(pattern string) bool { return matchAction(pattern, req.Action) }) {
+		return false
+	}
+	if !slices.Contains(p.Resources, "*") && !slices.Contains(p.Resources, req.Resource["type"]) {
+		return false
+	}
+	for _, c := range p.When {
+		if !c.holds(req) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c Condition) holds(req Request) bool {
+	left, ok := lookup(req, c.Attr)
+	if !ok {
+		return false // a missing attribute never satisfies a condition
+	}
+	right := c.Value
+	if c.Ref != "" {
+		if right, ok = lookup(req, c.Ref); !ok {
+			return false
+		}
+	}
+	switch c.Op {
+	case "eq":
+		return left == right
+	case "ne":
+		return left != right
+	case "in":
+		return slices.Contains(strings.Split(right, ","), left)
+	case "contains":
+		return slices.Contains(strings.Split(left, ","), right)
+	}
+	return false
+}
+
+func matchAction(pattern, action string) bool {
+	if pattern == "*" || pattern == action {
+		return true
+	}
+	prefix, ok := strings.CutSuffix(pattern, "*")
+	return ok && strings.HasPrefix(action, prefix)
+}
+
+func lookup(req Request, attr string) (string, bool) {
+	if name, ok := strings.CutPrefix(attr, "subject."); ok {
+		v, ok := req.Subject[name]
+		return v, ok
+	}
+	if name, ok := strings.CutPrefix(attr, "resource."); ok {
+		v, ok := req.Resource[name]
+		return v, ok
+	}
+	return "", false
+}
+
+func validAttr(attr string) bool {
+	return strings.HasPrefix(attr, "subject.") || strings.HasPrefix(attr, "resource.")
+}
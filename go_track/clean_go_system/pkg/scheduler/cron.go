@@ -0,0 +1,199 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule tells when a job is next due.
+type Schedule interface {
+	// Next returns the first time strictly after t the job is due.
+	Next(t time.Time) time.Time
+}
+
+// Every is a fixed-interval schedule, aligned to the interval, like
+// "@every 15m" due at :00, :15, :30 and :45. It must be positive.
+type Every time.Duration
+
+func (e Every) Next(t time.Time) time.Time {
+	return t.Truncate(time.Duration(e)).Add(time.Duration(e))
+}
+
+// descriptors are the named schedules Parse accepts besides @every.
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// field is one position of a cron expression: its range and the names it
+// accepts for values.
+type field struct {
+	name     string
+	min, max int
+	names    []string // names[i] stands for min+i
+}
+
+var (
+	minutes  = field{name: "minute", min: 0, max: 59}
+	hours    = field{name: "hour", min: 0, max: 23}
+	days     = field{name: "day of month", min: 1, max: 31}
+	months   = field{name: "month", min: 1, max: 12, names: []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}}
+	weekdays = field{name: "day of week", min: 0, max: 7, names: []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}}
+)
+
+// cron is a parsed five-field expression; each field is a bit set of the
+// values it matches.
+type cron struct {
+	minute, hour, dom, month, dow uint64
+	// domStar and dowStar record an unrestricted day field: cron runs on
+	// days matching either day field, unless one of them is "*".
+	domStar, dowStar bool
+}
+
+// Parse reads a standard five-field cron expression (minute, hour, day
+// of month, month, day of week), with *, ranges, steps, lists and
+// three-letter month and day names, or one of @hourly, @daily, @weekly,
+// @monthly, @yearly and "@every <duration>". Times are matched in the
+// location of the time passed to Next.
+func Parse(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if rest, ok := strings.CutPrefix(spec, "@every "); ok {
+		d, err := time.ParseDuration(strings.TrimSpace(rest))
+		if err != nil || d < time.Second {
+			return nil, fmt.Errorf("scheduler: %q: @every needs a duration of at least 1s", spec)
+		}
+		return Every(d), nil
+	}
+	if expr, ok := descriptors[spec]; ok {
+		spec = expr
+	}
+
+	parts := strings.Fields(spec)
+	if len(parts) != 5 {
+		return nil, fmt.Errorf("scheduler: %q: want 5 fields, got %d", spec, len(parts))
+	}
+	var c cron
+	var err error
+	for i, f := range []struct {
+		field field
+		into  *uint64
+	}{
+		{minutes, &c.minute}, {hours, &c.hour}, {days, &c.dom}, {months, &c.month}, {weekdays, &c.dow},
+	} {
+		if *f.into, err = f.field.parse(parts[i]); err != nil {
+			return nil, fmt.Errorf("scheduler: %q: %w", spec, err)
+		}
+	}
+	// Sunday is both 0 and 7
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1
+	}
+	c.domStar, c.dowStar = parts[2] == "*", parts[4] == "*"
+	return c, nil
+}
+
+// parse turns a comma-separated list of values, ranges and steps into a
+// bit set.
+func (f field) parse(expr string) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(expr, ",") {
+		rng, stepStr, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepStr); err != nil || step < 1 {
+				return 0, fmt.Errorf("%s: bad step %q", f.name, stepStr)
+			}
+		}
+		lo, hi := f.min, f.max
+		switch {
+		case rng == "*":
+		case strings.Contains(rng, "-"):
+			loStr, hiStr, _ := strings.Cut(rng, "-")
+			var err error
+			if lo, err = f.value(loStr); err != nil {
+				return 0, err
+			}
+			if hi, err = f.value(hiStr); err != nil {
+				return 0, err
+			}
+			if hi < lo {
+				return 0, fmt.Errorf("%s: range %q runs backwards", f.name, rng)
+			}
+		default:
+			v, err := f.value(rng)
+			if err != nil {
+				return 0, err
+			}
+			lo, hi = v, v
+			if hasStep {
+				hi = f.max // "5/15" means from 5 on, every 15
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			set |= 1 << v
+		}
+	}
+	return set, nil
+}
+
+func (f field) value(s string) (int, error) {
+	for i, name := range f.names {
+		if strings.EqualFold(s, name) {
+			return f.min + i, nil
+		}
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("%s: %q is not in %d-%d", f.name, s, f.min, f.max)
+	}
+	return v, nil
+}
+
+// maxSearch bounds Next for expressions that never match, like Feb 30.
+const maxSearch = 5 * 366 * 24 * time.Hour
+
+// Next walks forward a field at a time, from the month down, resetting
+// the smaller fields whenever a larger one moves.
+func (c cron) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(maxSearch)
+
+	for t.Before(limit) {
+		if c.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !c.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if c.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			continue
+		}
+		if c.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Truncate(time.Minute).Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func (c cron) dayMatches(t time.Time) bool {
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.domStar || c.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
@@ -0,0 +1,256 @@
+// Package scheduler runs named jobs on cron schedules. Due runs go to a
+// small pool of workers; a run still going when the job comes due again
+// makes the scheduler skip that turn instead of stacking runs, and an
+// Elector lets one instance of a multi-instance deployment run each job.
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"clean_go_system/pkg/clock"
+)
+
+var (
+	// ErrNotLeader is returned by an Elector when another instance runs
+	// the job this time.
+	ErrNotLeader = errors.New("scheduler: not the leader for this job")
+	// ErrDuplicateJob means a job with the same name was already added.
+	ErrDuplicateJob = errors.New("scheduler: duplicate job")
+)
+
+// defaultLease is how long an elected run holds its lease when the job
+// has no Timeout.
+const defaultLease = time.Hour
+
+// Clock is the time source; pkg/clock's System and Fake satisfy it.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// Elector decides which instance runs a due job. Elect returns a release
+// func when this instance should run it, and ErrNotLeader when another
+// one does. The lease must outlast the run.
+type Elector interface {
+	Elect(ctx context.Context, job string, lease time.Duration) (release func(), err error)
+}
+
+// ElectorFunc adapts a function to an Elector.
+type ElectorFunc func(ctx context.Context, job string, lease time.Duration) (func(), error)
+
+func (f ElectorFunc) Elect(ctx context.Context, job string, lease time.Duration) (func(), error) {
+	return f(ctx, job, lease)
+}
+
+// Job is a named unit of recurring work.
+type Job struct {
+	Name     string
+	Schedule Schedule
+	// Timeout bounds one run; zero lets it run until Stop.
+	Timeout time.Duration
+	Run     func(ctx context.Context) error
+}
+
+// entry is a job with its run state, guarded by Scheduler.mu.
+type entry struct {
+	job     Job
+	next    time.Time
+	running bool
+}
+
+// Scheduler runs jobs when they fall due, in the order they were added
+// when several are due at once. It satisfies
+// lifecycle.Component.
+type Scheduler struct {
+	// Elector, when set, is asked before every run.
+	Elector Elector
+	// Clock drives the schedule; it defaults to the wall clock.
+	Clock Clock
+	// Location is the time zone cron fields are read in; it defaults to
+	// UTC.
+	Location *time.Location
+	// OnError receives every failed or panicked run, e.g. for a metric.
+	// Failures are logged either way.
+	OnError func(job string, err error)
+
+	workers int
+	logger  *log.Logger
+
+	mu      sync.Mutex
+	entries []*entry
+	tasks   chan *entry
+	cancel  context.CancelFunc // ends the loop
+	abort   context.CancelFunc // ends in-flight runs
+	loop    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// New returns a scheduler running at most workers jobs at a time.
+func New(workers int, logger *log.Logger) *Scheduler {
+	if workers < 1 {
+		workers = 1
+	}
+	return &Scheduler{Clock: clock.System, Location: time.UTC, workers: workers, logger: logger}
+}
+
+// Add registers job. Jobs are added before Start.
+func (s *Scheduler) Add(job Job) error {
+	if job.Name == "" || job.Schedule == nil || job.Run == nil {
+		return fmt.Errorf("scheduler: job %q needs a name, a schedule and a run func", job.Name)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, e := range s.entries {
+		if e.job.Name == job.Name {
+			return fmt.Errorf("%w: %s", ErrDuplicateJob, job.Name)
+		}
+	}
+	s.entries = append(s.entries, &entry{job: job})
+	return nil
+}
+
+// Start launches the workers and the loop. Jobs first run at their next
+// due time, not right away.
+func (s *Scheduler) Start(ctx context.Context) error {
+	runCtx, abort := context.WithCancel(context.WithoutCancel(ctx))
+	loopCtx, cancel := context.WithCancel(ctx)
+	s.abort, s.cancel = abort, cancel
+	s.tasks = make(chan *entry, len(s.entries))
+	s.loop = make(chan struct{})
+
+	now := s.now()
+	for _, e := range s.entries {
+		e.next = e.job.Schedule.Next(now)
+	}
+	for i := 0; i < s.workers; i++ {
+		s.wg.Add(1)
+		go s.work(runCtx)
+	}
+	go s.run(loopCtx)
+	return nil
+}
+
+// Stop ends the loop and waits for in-flight runs. When ctx ends first,
+// the runs are cancelled and Stop returns ctx's error.
+func (s *Scheduler) Stop(ctx context.Context) error {
+	if s.cancel == nil {
+		return nil
+	}
+	s.cancel()
+	<-s.loop
+	close(s.tasks)
+
+	done := make(chan struct{})
+	go func() { s.wg.Wait(); close(done) }()
+	select {
+	case <-done:
+		s.abort()
+		return nil
+	case <-ctx.Done():
+		s.abort()
+		return ctx.Err()
+	}
+}
+
+// run sleeps until the earliest job is due, then hands every due job to
+// the workers.
+func (s *Scheduler) run(ctx context.Context) {
+	defer close(s.loop)
+	for {
+		wait := s.dispatch()
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.Clock.After(wait):
+		}
+	}
+}
+
+// dispatch queues the due jobs and returns how long until the next one.
+func (s *Scheduler) dispatch() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	var earliest time.Time
+	for _, e := range s.entries {
+		if !e.next.After(now) {
+			s.queue(e)
+			e.next = e.job.Schedule.Next(now)
+		}
+		if earliest.IsZero() || (!e.next.IsZero() && e.next.Before(earliest)) {
+			earliest = e.next
+		}
+	}
+	if earliest.IsZero() {
+		return time.Hour // nothing will ever be due; idle
+	}
+	return earliest.Sub(now)
+}
+
+// queue hands e to the workers unless its last run is still queued or
+// going; that turn is skipped, not made up later. The task buffer has a
+// slot per job, so the send never blocks.
+func (s *Scheduler) queue(e *entry) {
+	if e.running {
+		s.logger.Printf("scheduler: %s is still running; skipping this run", e.job.Name)
+		return
+	}
+	e.running = true
+	s.tasks <- e
+}
+
+func (s *Scheduler) work(ctx context.Context) {
+	defer s.wg.Done()
+	for e := range s.tasks {
+		if err := s.execute(ctx, e.job); err != nil {
+			s.logger.Printf("scheduler: %s: %v", e.job.Name, err)
+			if s.OnError != nil {
+				s.OnError(e.job.Name, err)
+			}
+		}
+		s.mu.Lock()
+		e.running = false
+		s.mu.Unlock()
+	}
+}
+
+// execute runs job once, under its timeout and, with an Elector, its
+// lease. Losing the election is not an error.
+func (s *Scheduler) execute(ctx context.Context, job Job) (err error) {
+	if job.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, job.Timeout)
+		defer cancel()
+	}
+	if s.Elector != nil {
+		lease := job.Timeout
+		if lease <= 0 {
+			lease = defaultLease
+		}
+		release, err := s.Elector.Elect(ctx, job.Name, lease)
+		if errors.Is(err, ErrNotLeader) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("elect: %w", err)
+		}
+		defer release()
+	}
+	defer func() {
+		if v := recover(); v != nil {
+			err = fmt.Errorf("panicked: %v\n%s", v, debug.Stack())
+		}
+	}()
+	return job.Run(ctx)
+}
+
+func (s *Scheduler) now() time.Time {
+	return s.Clock.Now().In(s.Location)
+}
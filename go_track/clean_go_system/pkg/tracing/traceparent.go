@@ -0,0 +1,48 @@
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"regexp"
+)
+
+// Header is the W3C Trace Context header name.
+const Header = "traceparent"
+
+var traceParentPattern = regexp.MustCompile(`^00-[0-9a-f]{32}-[0-9a-f]{16}-[0-9a-f]{2}$`)
+
+type ctxKey struct{}
+
+// WithTraceParent returns a copy of ctx carrying a W3C traceparent value.
+func WithTraceParent(ctx context.Context, traceParent string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, traceParent)
+}
+
+// TraceParent returns the traceparent carried by ctx, or "".
+func TraceParent(ctx context.Context) string {
+	v, _ := ctx.Value(ctxKey{}).(string)
+	return v
+}
+
+// NewTraceParent starts a new sampled trace.
+func NewTraceParent() string {
+	var traceID [16]byte
+	var spanID [8]byte
+	_, _ = rand.Read(traceID[:])
+	_, _ = rand.Read(spanID[:])
+	return "00-" + hex.EncodeToString(traceID[:]) + "-" + hex.EncodeToString(spanID[:]) + "-01"
+}
+
+// Middleware puts the caller's traceparent (or a fresh one) into the
+// request context so it can follow the request into events and brokers.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tp := r.Header.Get(Header)
+		if !traceParentPattern.MatchString(tp) {
+			tp = NewTraceParent()
+		}
+		next.ServeHTTP(w, r.WithContext(WithTraceParent(r.Context(), tp)))
+	})
+}